@@ -1,9 +1,11 @@
 package copper
 
 import (
+	"flag"
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
 	"github.com/gocopper/copper/cconfig"
@@ -28,12 +30,27 @@ func New() *App {
 }
 
 // NewApp creates a new Copper app and returns it along with the app's lifecycle manager,
-// config, and the logger.
-func NewApp(lifecycle *clifecycle.Lifecycle, config cconfig.Loader, logger clogger.Logger) *App {
+// config, the logger, and the logger's levels.
+func NewApp(
+	lifecycle *clifecycle.Lifecycle,
+	config cconfig.Loader,
+	copperConfig Config,
+	logger clogger.Logger,
+	logLevels *clogger.Levels,
+	flags *Flags,
+) *App {
+	// Keep the log levels in sync with the config when it is reloaded (see App.Start)
+	_ = config.Watch("clogger", func(c clogger.Config) error {
+		return logLevels.Load(c)
+	})
+
 	return &App{
-		Lifecycle: lifecycle,
-		Config:    config,
-		Logger:    logger,
+		Lifecycle:     lifecycle,
+		Config:        config,
+		Logger:        logger,
+		LogLevels:     logLevels,
+		StartupConfig: copperConfig.Startup,
+		DebugWiring:   flags.DebugWiring,
 	}
 }
 
@@ -44,45 +61,91 @@ type App struct {
 	Lifecycle *clifecycle.Lifecycle
 	Config    cconfig.Loader
 	Logger    clogger.Logger
+	LogLevels *clogger.Levels
+
+	// StartupConfig configures the timeouts and retries of the runners started by StartRunners
+	StartupConfig ConfigStartup
+
+	// DebugWiring prints the app's diagnostics to stderr once it has started (see the -debug-wiring flag)
+	DebugWiring bool
+
+	startupMu sync.Mutex
+	startup   []StartupStep
 }
 
-// Run runs the provided funcs. Once all of the functions complete their run,
-// the  lifecycle's stop funcs are also called. If any of the fns return an error,
+// Run runs the provided funcs in the order of their startup stages (see StartRunners). Once all of the functions
+// complete their run, the lifecycle's stop funcs are also called. If any of the fns return an error,
 // the app exits with an exit code 1.
 // Run should be used when none of the fn are long-running. For long-running funcs like
 // an HTTP server, use Start.
 func (a *App) Run(fns ...Runner) {
-	for i := range fns {
-		err := fns[i].Run()
-		if err != nil {
-			a.Logger.Error("Failed to run", err)
-			a.Lifecycle.Stop(a.Logger)
-			os.Exit(1)
-		}
+	a.runConfigCommand()
+
+	err := a.StartRunners(fns...)
+	if err != nil {
+		a.Logger.Error("Failed to run", err)
+		a.Lifecycle.Stop(a.Logger)
+		os.Exit(1)
 	}
 
+	a.PrintDiagnostics()
+
 	a.Lifecycle.Stop(a.Logger)
 }
 
-// Start runs the provided fns and then waits on the OS's INT and TERM signals from the
-// user to exit. Once the signal is received, the lifecycle's stop funcs are
+// Start runs the provided fns in the order of their startup stages (see StartRunners) and then waits on the OS's
+// INT and TERM signals from the user to exit. Once the signal is received, the lifecycle's stop funcs are
 // called.
-// If any of the fns fail to run and returns an error, the app exits with exit code
-// 1.
+// While the app is running, the config is reloaded on SIGHUP. See cconfig.Loader's Watch.
+// If any of the fns fail to run and returns an error, the lifecycle's stop funcs are called so that the fns that
+// already started are stopped, and the app exits with exit code 1.
 func (a *App) Start(fns ...Runner) {
-	for i := range fns {
-		err := fns[i].Run()
-		if err != nil {
-			a.Logger.Error("Failed to run", err)
-			os.Exit(1)
-		}
+	a.runConfigCommand()
+
+	err := a.StartRunners(fns...)
+	if err != nil {
+		a.Logger.Error("Failed to run", err)
+		a.Lifecycle.Stop(a.Logger)
+		os.Exit(1)
 	}
 
+	a.PrintDiagnostics()
+
+	a.Wait()
+}
+
+// Wait waits on the OS's INT and TERM signals and then calls the lifecycle's stop funcs. While waiting, the config
+// is reloaded on SIGHUP. Wait is used by Start and can be used by apps that start their long-running funcs
+// themselves (ex. see ccli).
+func (a *App) Wait() {
+	stopReload := cconfig.ReloadOnSIGHUP(a.Config, func(err error) {
+		a.Logger.Error("Failed to reload config", err)
+	})
+
 	osInt := make(chan os.Signal, 1)
 
 	signal.Notify(osInt, syscall.SIGINT, syscall.SIGTERM)
 
 	<-osInt
 
+	stopReload()
+
 	a.Lifecycle.Stop(a.Logger)
 }
+
+// runConfigCommand runs the config command instead of the app if the app is started with one. For example,
+// `./app config dump --redacted` prints the effective config and `./app config sample` prints a sample config file.
+// See cconfig.RunCommand.
+func (a *App) runConfigCommand() {
+	if flag.NArg() == 0 || flag.Arg(0) != "config" {
+		return
+	}
+
+	err := cconfig.RunCommand(a.Config, flag.Args()[1:], os.Stdout)
+	if err != nil {
+		a.Logger.Error("Failed to run config command", err)
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
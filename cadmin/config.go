@@ -0,0 +1,51 @@
+package cadmin
+
+import (
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+const defaultPath = "/admin"
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "cadmin",
+		Description: "cadmin configures the admin dashboard",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("cadmin", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load cadmin config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Path:  defaultPath,
+		Roles: []string{"admin"},
+	}
+}
+
+// Config configures the admin dashboard. For example:
+//
+//	[cadmin]
+//	enabled = true
+//	path = "/admin"
+//	roles = ["admin", "support"]
+type Config struct {
+	Enabled bool     `toml:"enabled" doc:"Serve the admin dashboard"`
+	Path    string   `toml:"path" doc:"Path of the admin dashboard"`
+	Roles   []string `toml:"roles" doc:"Roles that can access the admin dashboard"`
+
+	// LoginPath is where unauthenticated users are redirected to. The dashboard's URL is passed in the next query
+	// param. If it is empty, unauthenticated users get a 401.
+	LoginPath string `toml:"login_path" doc:"Where unauthenticated users are redirected to"`
+}
@@ -0,0 +1,4 @@
+// Package cadmin serves a server-rendered admin dashboard made of panels. cadmin provides panels for the app's
+// config, health checks, background jobs, users, sessions, and log levels, and other modules or the app can add their own by
+// implementing Panel. Access is restricted to the users with one of the configured roles (see Authorizer).
+package cadmin
@@ -0,0 +1,104 @@
+package cadmin
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/cqueue"
+)
+
+// deadJobsLimit is the max number of dead jobs shown per queue
+const deadJobsLimit = 50
+
+var jobsTemplate = template.Must(template.New("jobs").Parse(jobsHTML)) //nolint:gochecknoglobals
+
+// NewJobsPanelParams holds the params needed for NewJobsPanel
+type NewJobsPanelParams struct {
+	Queue       *cqueue.Queue
+	QueueConfig cqueue.Config
+	Config      Config
+}
+
+// NewJobsPanel creates a new JobsPanel
+func NewJobsPanel(p NewJobsPanelParams) *JobsPanel {
+	names := make([]string, 0, len(p.QueueConfig.Queues))
+	for name := range p.QueueConfig.Queues {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return &JobsPanel{
+		queue:    p.Queue,
+		queues:   names,
+		basePath: p.Config.Path,
+	}
+}
+
+// JobsPanel shows the dead jobs of each cqueue queue and lets them be requeued
+type JobsPanel struct {
+	queue    *cqueue.Queue
+	queues   []string
+	basePath string
+}
+
+type jobsPanelQueue struct {
+	Name string
+	Jobs []cqueue.Job
+}
+
+// ID returns the panel's URL segment
+func (p *JobsPanel) ID() string { return "jobs" }
+
+// Title returns the panel's title
+func (p *JobsPanel) Title() string { return "Background jobs" }
+
+// Render renders the most recent dead jobs of each queue
+func (p *JobsPanel) Render(r *http.Request) (template.HTML, error) {
+	queues := make([]jobsPanelQueue, 0, len(p.queues))
+
+	for _, name := range p.queues {
+		jobs, err := p.queue.DeadJobs(r.Context(), name, deadJobsLimit)
+		if err != nil {
+			return "", cerrors.New(err, "failed to list dead jobs", map[string]interface{}{
+				"queue": name,
+			})
+		}
+
+		queues = append(queues, jobsPanelQueue{Name: name, Jobs: jobs})
+	}
+
+	return renderTemplate(jobsTemplate, map[string]interface{}{
+		"Queues":      queues,
+		"RequeuePath": ActionPath(p.basePath, p, "requeue"),
+	})
+}
+
+// HandleAction runs the requeue action that requeues the dead job with the submitted id
+func (p *JobsPanel) HandleAction(r *http.Request, action string) error {
+	if action != "requeue" {
+		return cerrors.New(nil, "unknown action", map[string]interface{}{
+			"action": action,
+		})
+	}
+
+	return p.queue.Requeue(r.Context(), r.PostFormValue("id"))
+}
+
+const jobsHTML = `
+{{ range .Queues }}<h2>{{ .Name }}</h2>
+{{ if .Jobs }}<table>
+<tr><th>ID</th><th>Type</th><th>Attempts</th><th>Last error</th><th>Failed at</th><th></th></tr>
+{{ range .Jobs }}<tr>
+<td>{{ .ID }}</td>
+<td>{{ .Type }}</td>
+<td>{{ .Attempts }}</td>
+<td>{{ .LastError }}</td>
+<td>{{ .UpdatedAt.Format "2006-01-02 15:04:05" }}</td>
+<td><form class="inline" method="post" action="{{ $.RequeuePath }}">
+<input type="hidden" name="id" value="{{ .ID }}"><button type="submit">Requeue</button></form></td>
+</tr>{{ end }}
+</table>{{ else }}<p class="empty">No dead jobs.</p>{{ end }}
+{{ end }}`
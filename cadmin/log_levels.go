@@ -0,0 +1,92 @@
+package cadmin
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+)
+
+var logLevelsTemplate = template.Must(template.New("logLevels").Parse(logLevelsHTML)) //nolint:gochecknoglobals
+
+// NewLogLevelsPanel creates a new LogLevelsPanel
+func NewLogLevelsPanel(levels *clogger.Levels, config Config) *LogLevelsPanel {
+	return &LogLevelsPanel{
+		levels:   levels,
+		basePath: config.Path,
+	}
+}
+
+// LogLevelsPanel shows the global and per-module log levels (see clogger.Levels) and lets them be changed at runtime.
+// Changes last until the levels are reloaded from the config (ex. on SIGHUP) or the app restarts.
+type LogLevelsPanel struct {
+	levels   *clogger.Levels
+	basePath string
+}
+
+// ID returns the panel's URL segment
+func (p *LogLevelsPanel) ID() string { return "log-levels" }
+
+// Title returns the panel's title
+func (p *LogLevelsPanel) Title() string { return "Log Levels" }
+
+// Render renders the current levels along with a form to change them
+func (p *LogLevelsPanel) Render(r *http.Request) (template.HTML, error) {
+	config := p.levels.Config()
+
+	modules := make([]string, 0, len(config.Modules))
+	for module := range config.Modules {
+		modules = append(modules, module)
+	}
+
+	sort.Strings(modules)
+
+	return renderTemplate(logLevelsTemplate, map[string]interface{}{
+		"Level":   config.Level,
+		"Modules": modules,
+		"Levels":  config.Modules,
+		"SetPath": ActionPath(p.basePath, p, "set"),
+	})
+}
+
+// HandleAction runs the set action that changes the level of the submitted module, or the global level if no module
+// is submitted
+func (p *LogLevelsPanel) HandleAction(r *http.Request, action string) error {
+	if action != "set" {
+		return cerrors.New(nil, "unknown action", map[string]interface{}{
+			"action": action,
+		})
+	}
+
+	level, err := clogger.ParseLevel(r.FormValue("level"))
+	if err != nil {
+		return err
+	}
+
+	module := strings.TrimSpace(r.FormValue("module"))
+	if module == "" {
+		p.levels.SetLevel(level)
+		return nil
+	}
+
+	p.levels.SetModuleLevel(module, level)
+
+	return nil
+}
+
+const logLevelsHTML = `
+<table>
+<tr><th>Module</th><th>Level</th></tr>
+<tr><td>(global)</td><td>{{ .Level }}</td></tr>
+{{ range .Modules }}<tr><td>{{ . }}</td><td>{{ index $.Levels . }}</td></tr>
+{{ end }}</table>
+<form method="post" action="{{ .SetPath }}">
+<input name="module" placeholder="module (ex. csql), empty for global">
+<select name="level">
+<option>debug</option><option>info</option><option>warn</option><option>error</option>
+</select>
+<button type="submit">Set</button>
+</form>`
@@ -0,0 +1,71 @@
+package cadmin
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/chttp"
+)
+
+var maintenanceTemplate = template.Must(template.New("maintenance").Parse(maintenanceHTML)) //nolint:gochecknoglobals
+
+// NewMaintenancePanel creates a new MaintenancePanel
+func NewMaintenancePanel(mw *chttp.MaintenanceMiddleware, config Config) *MaintenancePanel {
+	return &MaintenancePanel{
+		mw:       mw,
+		basePath: config.Path,
+	}
+}
+
+// MaintenancePanel shows whether chttp's maintenance mode is enabled and lets it be toggled at runtime. The dashboard
+// stays reachable during maintenance since its path is exempt by default (see chttp.ConfigMaintenance).
+type MaintenancePanel struct {
+	mw       *chttp.MaintenanceMiddleware
+	basePath string
+}
+
+// ID returns the panel's URL segment
+func (p *MaintenancePanel) ID() string { return "maintenance" }
+
+// Title returns the panel's title
+func (p *MaintenancePanel) Title() string { return "Maintenance" }
+
+// Render renders the maintenance status along with the enable/disable actions
+func (p *MaintenancePanel) Render(r *http.Request) (template.HTML, error) {
+	return renderTemplate(maintenanceTemplate, map[string]interface{}{
+		"Status":      p.mw.Status(),
+		"EnablePath":  ActionPath(p.basePath, p, "enable"),
+		"DisablePath": ActionPath(p.basePath, p, "disable"),
+	})
+}
+
+// HandleAction runs the enable and disable actions that toggle maintenance mode
+func (p *MaintenancePanel) HandleAction(r *http.Request, action string) error {
+	switch action {
+	case "enable":
+		p.mw.Enable()
+	case "disable":
+		p.mw.Disable()
+	default:
+		return cerrors.New(nil, "unknown action", map[string]interface{}{
+			"action": action,
+		})
+	}
+
+	return nil
+}
+
+const maintenanceHTML = `
+{{ if .Status.Enabled }}<p class="error">Maintenance mode is enabled.</p>
+{{ else }}<p class="ok">Maintenance mode is disabled.</p>{{ end }}
+<table>
+<tr><th>Switch</th><th>Status</th></tr>
+<tr><td>Dashboard</td><td>{{ if .Status.Runtime }}on{{ else }}off{{ end }}</td></tr>
+<tr><td>Config (chttp.maintenance.enabled)</td><td>{{ if .Status.Config }}on{{ else }}off{{ end }}</td></tr>
+<tr><td>File (chttp.maintenance.file)</td><td>{{ if .Status.File }}on{{ else }}off{{ end }}</td></tr>
+</table>
+{{ if .Status.Runtime }}<form method="post" action="{{ .DisablePath }}"><button type="submit">Disable</button></form>
+{{ else }}<form method="post" action="{{ .EnablePath }}"><button type="submit">Enable</button></form>{{ end }}
+{{ if and (not .Status.Runtime) .Status.Enabled }}
+<p class="empty">Maintenance mode was enabled by the config or the file, so it cannot be disabled here.</p>{{ end }}`
@@ -0,0 +1,64 @@
+package cadmin
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Panel is a page of the admin dashboard. Panels are passed to NewRouter in the order they are shown in the
+// dashboard's navigation.
+type Panel interface {
+	// ID is the panel's URL segment (ex. jobs for /admin/jobs)
+	ID() string
+
+	// Title is shown in the navigation and as the panel's heading
+	Title() string
+
+	// Render returns the panel's HTML content
+	Render(r *http.Request) (template.HTML, error)
+}
+
+// ActionPanel is a Panel with actions that change data (ex. requeueing a job). Actions are submitted by forms in the
+// panel's content that POST to ActionPath, after which the user is redirected back to the panel.
+type ActionPanel interface {
+	Panel
+
+	// HandleAction runs the named action using the submitted form values
+	HandleAction(r *http.Request, action string) error
+}
+
+// ActionPath returns the path that the panel's forms POST to in order to run an action. basePath is Config.Path.
+func ActionPath(basePath string, p Panel, action string) string {
+	return basePath + "/" + p.ID() + "/actions/" + action
+}
+
+// Authorizer identifies the user of admin requests. The app implements it using its auth module.
+type Authorizer interface {
+	// Roles returns the roles of the request's authenticated user, or false if the request is not authenticated
+	Roles(r *http.Request) ([]string, bool)
+}
+
+// AuthorizerFunc is a func that implements the Authorizer interface
+type AuthorizerFunc func(r *http.Request) ([]string, bool)
+
+// Roles calls f
+func (f AuthorizerFunc) Roles(r *http.Request) ([]string, bool) {
+	return f(r)
+}
+
+// renderTemplate executes tmpl into HTML that can be returned by Panel.Render
+func renderTemplate(tmpl *template.Template, data interface{}) (template.HTML, error) {
+	var buf strings.Builder
+
+	err := tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", cerrors.New(err, "failed to render panel", map[string]interface{}{
+			"template": tmpl.Name(),
+		})
+	}
+
+	return template.HTML(buf.String()), nil //nolint:gosec
+}
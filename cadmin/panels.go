@@ -0,0 +1,83 @@
+package cadmin
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/chealth"
+)
+
+var (
+	configTemplate = template.Must(template.New("config").Parse(configHTML)) //nolint:gochecknoglobals
+	healthTemplate = template.Must(template.New("health").Parse(healthHTML)) //nolint:gochecknoglobals
+)
+
+// NewConfigPanel creates a new ConfigPanel
+func NewConfigPanel(loader cconfig.Loader) *ConfigPanel {
+	return &ConfigPanel{loader: loader}
+}
+
+// ConfigPanel shows the app's effective config with secrets redacted (see cconfig.Dump). Keys that fail to load (ex.
+// because they are invalid) are shown with their error.
+type ConfigPanel struct {
+	loader cconfig.Loader
+}
+
+// ID returns the panel's URL segment
+func (p *ConfigPanel) ID() string { return "config" }
+
+// Title returns the panel's title
+func (p *ConfigPanel) Title() string { return "Config" }
+
+// Render renders the redacted config
+func (p *ConfigPanel) Render(r *http.Request) (template.HTML, error) {
+	var buf strings.Builder
+
+	for i, doc := range cconfig.DeclaredKeys() {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+
+		err := cconfig.Dump(p.loader, &buf, []cconfig.KeyDoc{doc}, true)
+		if err != nil {
+			buf.WriteString("# [" + doc.Key + "] failed to load: " + strings.ReplaceAll(err.Error(), "\n", "\n# ") + "\n")
+		}
+	}
+
+	return renderTemplate(configTemplate, buf.String())
+}
+
+// NewHealthPanel creates a new HealthPanel
+func NewHealthPanel() *HealthPanel {
+	return &HealthPanel{}
+}
+
+// HealthPanel runs the checkers registered with chealth and shows their results
+type HealthPanel struct{}
+
+// ID returns the panel's URL segment
+func (p *HealthPanel) ID() string { return "health" }
+
+// Title returns the panel's title
+func (p *HealthPanel) Title() string { return "Health" }
+
+// Render runs the health checks and renders their results
+func (p *HealthPanel) Render(r *http.Request) (template.HTML, error) {
+	return renderTemplate(healthTemplate, chealth.Check(r.Context(), 0))
+}
+
+const configHTML = `<pre>{{ . }}</pre>`
+
+const healthHTML = `
+{{ if .Ready }}<p class="ok">The app is ready.</p>{{ else }}<p class="error">The app is not ready.</p>{{ end }}
+{{ if .Checks }}<table>
+<tr><th>Check</th><th>Status</th><th>Duration</th><th>Error</th></tr>
+{{ range .Checks }}<tr>
+<td>{{ .Name }}</td>
+<td class="{{ if eq .Status "ok" }}ok{{ else }}error{{ end }}">{{ .Status }}</td>
+<td>{{ .DurationMs }}ms</td>
+<td>{{ .Error }}</td>
+</tr>{{ end }}
+</table>{{ else }}<p class="empty">No health checks are registered.</p>{{ end }}`
@@ -0,0 +1,242 @@
+package cadmin
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+)
+
+var layoutTemplate = template.Must(template.New("layout").Parse(layoutHTML)) //nolint:gochecknoglobals
+
+const layoutHTML = `<!doctype html>
+<html>
+<head><meta charset="utf-8"><title>{{ if .Panel }}{{ .Panel.Title }} - {{ end }}Admin</title><style>` + uiCSS + `</style></head>
+<body>
+<nav><a class="brand" href="{{ .BasePath }}">Admin</a>
+{{ range .Panels }}<a href="{{ $.BasePath }}/{{ .ID }}"{{ if and $.Panel (eq .ID $.Panel.ID) }} class="active"{{ end }}>{{ .Title }}</a>
+{{ end }}</nav>
+<main>
+{{ if .Panel }}<h1>{{ .Panel.Title }}</h1>{{ end }}
+{{ if .Error }}<p class="error">{{ .Error }}</p>{{ end }}
+{{ .Content }}
+</main>
+</body>
+</html>`
+
+const uiCSS = `body{font-family:system-ui,sans-serif;margin:0;display:flex;min-height:100vh;color:#222}
+nav{width:12rem;background:#1f2933;padding:1rem 0}nav a{display:block;color:#cbd2d9;padding:.5rem 1rem;
+text-decoration:none}nav a.active,nav a:hover{background:#323f4b;color:#fff}nav .brand{font-weight:bold;color:#fff}
+main{flex:1;padding:1.5rem 2rem;overflow:auto}table{border-collapse:collapse;width:100%}th,td{text-align:left;
+padding:.5rem;border-bottom:1px solid #ddd;vertical-align:top}pre{background:#f6f6f6;padding:1rem;overflow:auto}
+.error{color:#b91c1c}.ok{color:#15803d}.empty{color:#666}form.inline{display:inline}`
+
+// NewRouterParams holds the params needed for NewRouter
+type NewRouterParams struct {
+	Panels     []Panel
+	Authorizer Authorizer
+	Config     Config
+	Logger     clogger.Logger
+}
+
+// NewRouter creates a new Router
+func NewRouter(p NewRouterParams) *Router {
+	return &Router{
+		panels:     p.Panels,
+		authorizer: p.Authorizer,
+		config:     p.Config,
+		logger:     p.Logger.With(clogger.Module("cadmin")),
+	}
+}
+
+// Router is a chttp.Router that serves the admin dashboard at Config.Path if Config.Enabled is set:
+//
+//	GET  /admin                          redirects to the first panel
+//	GET  /admin/{panel}                  renders the panel
+//	POST /admin/{panel}/actions/{action} runs a panel's action (see ActionPanel)
+//
+// Every route requires a user with one of Config.Roles. Actions are rejected if they are submitted from another
+// origin.
+type Router struct {
+	panels     []Panel
+	authorizer Authorizer
+	config     Config
+	logger     clogger.Logger
+}
+
+// Routes returns the admin dashboard routes or no routes if the dashboard is disabled
+func (ro *Router) Routes() []chttp.Route {
+	if !ro.config.Enabled {
+		return nil
+	}
+
+	mw := []chttp.Middleware{chttp.HandleMiddleware(ro.authorize)}
+
+	return []chttp.Route{
+		{
+			Middlewares: mw,
+			Path:        ro.config.Path,
+			Methods:     []string{http.MethodGet},
+			Handler:     ro.HandleIndex,
+		},
+		{
+			Middlewares: mw,
+			Path:        ro.config.Path + "/{panel}",
+			Methods:     []string{http.MethodGet},
+			Handler:     ro.HandlePanel,
+		},
+		{
+			Middlewares: mw,
+			Path:        ro.config.Path + "/{panel}/actions/{action}",
+			Methods:     []string{http.MethodPost},
+			Handler:     ro.HandleAction,
+		},
+	}
+}
+
+// HandleIndex redirects to the first panel
+func (ro *Router) HandleIndex(w http.ResponseWriter, r *http.Request) {
+	if len(ro.panels) == 0 {
+		ro.render(w, http.StatusOK, nil, "", template.HTML(`<p class="empty">No panels are registered.</p>`))
+		return
+	}
+
+	http.Redirect(w, r, ro.config.Path+"/"+ro.panels[0].ID(), http.StatusFound)
+}
+
+// HandlePanel renders a panel
+func (ro *Router) HandlePanel(w http.ResponseWriter, r *http.Request) {
+	panel, ok := ro.panel(chttp.URLParams(r)["panel"])
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	content, err := panel.Render(r)
+	if err != nil {
+		ro.logger.WithTags(map[string]interface{}{
+			"panel": panel.ID(),
+		}).Error("Failed to render admin panel", err)
+
+		ro.render(w, http.StatusInternalServerError, panel, "Failed to render the panel. See the logs for details.", "")
+
+		return
+	}
+
+	ro.render(w, http.StatusOK, panel, r.URL.Query().Get("error"), content)
+}
+
+// HandleAction runs a panel's action and redirects back to the panel. If the action fails, its error is shown on the
+// panel.
+func (ro *Router) HandleAction(w http.ResponseWriter, r *http.Request) {
+	var (
+		params    = chttp.URLParams(r)
+		panel, ok = ro.panel(params["panel"])
+	)
+
+	actionPanel, isActionPanel := panel.(ActionPanel)
+	if !ok || !isActionPanel {
+		http.NotFound(w, r)
+		return
+	}
+
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin admin actions are not allowed", http.StatusForbidden)
+		return
+	}
+
+	redirect := ro.config.Path + "/" + panel.ID()
+
+	err := actionPanel.HandleAction(r, params["action"])
+	if err != nil {
+		ro.logger.WithTags(map[string]interface{}{
+			"panel":  panel.ID(),
+			"action": params["action"],
+		}).Warn("Admin action failed", err)
+
+		redirect += "?" + url.Values{"error": {err.Error()}}.Encode()
+	}
+
+	http.Redirect(w, r, redirect, http.StatusSeeOther)
+}
+
+// authorize only lets the users with one of Config.Roles through
+func (ro *Router) authorize(next http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(ro.config.Roles))
+	for _, role := range ro.config.Roles {
+		allowed[role] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ro.authorizer == nil {
+			http.Error(w, "admin dashboard has no authorizer", http.StatusForbidden)
+			return
+		}
+
+		roles, ok := ro.authorizer.Roles(r)
+		if !ok {
+			if ro.config.LoginPath != "" && r.Method == http.MethodGet {
+				http.Redirect(w, r, ro.config.LoginPath+"?"+url.Values{"next": {r.URL.RequestURI()}}.Encode(),
+					http.StatusFound)
+
+				return
+			}
+
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+			return
+		}
+
+		for _, role := range roles {
+			if allowed[role] {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	})
+}
+
+func (ro *Router) panel(id string) (Panel, bool) {
+	for _, p := range ro.panels {
+		if p.ID() == id {
+			return p, true
+		}
+	}
+
+	return nil, false
+}
+
+func (ro *Router) render(w http.ResponseWriter, status int, panel Panel, errMsg string, content template.HTML) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	err := layoutTemplate.Execute(w, map[string]interface{}{
+		"BasePath": ro.config.Path,
+		"Panels":   ro.panels,
+		"Panel":    panel,
+		"Error":    errMsg,
+		"Content":  content,
+	})
+	if err != nil {
+		ro.logger.Error("Failed to render admin dashboard", cerrors.New(err, "failed to execute layout", nil))
+	}
+}
+
+// sameOrigin returns false if the request was sent by a page of another origin. Browsers set the Origin header on
+// form submissions, so actions cannot be triggered by other sites.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || origin == "null" {
+		return origin == ""
+	}
+
+	u, err := url.Parse(origin)
+
+	return err == nil && u.Host == r.Host
+}
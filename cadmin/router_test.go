@@ -0,0 +1,267 @@
+package cadmin_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/cadmin"
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cconfig/cconfigtest"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSessions struct {
+	sessions []cadmin.Session
+}
+
+func (s *fakeSessions) ListSessions(ctx context.Context, limit int) ([]cadmin.Session, error) {
+	return s.sessions, nil
+}
+
+func (s *fakeSessions) RevokeSession(ctx context.Context, id string) error {
+	for i := range s.sessions {
+		if s.sessions[i].ID == id {
+			s.sessions = append(s.sessions[:i], s.sessions[i+1:]...)
+			return nil
+		}
+	}
+
+	return errors.New("session not found")
+}
+
+func newTestServer(t *testing.T, config cadmin.Config, panels ...cadmin.Panel) *httptest.Server {
+	t.Helper()
+
+	router := cadmin.NewRouter(cadmin.NewRouterParams{
+		Panels: panels,
+		Authorizer: cadmin.AuthorizerFunc(func(r *http.Request) ([]string, bool) {
+			roles := r.Header.Get("X-Roles")
+			if roles == "" {
+				return nil, false
+			}
+
+			return strings.Split(roles, ","), true
+		}),
+		Config: config,
+		Logger: clogger.NewNoop(),
+	})
+
+	server := httptest.NewServer(chttp.NewHandler(chttp.NewHandlerParams{
+		Routers: []chttp.Router{router},
+		Logger:  clogger.NewNoop(),
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func request(t *testing.T, server *httptest.Server, method, path, roles string, form url.Values) (int, string,
+	http.Header) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), method, server.URL+path,
+		strings.NewReader(form.Encode()))
+	assert.NoError(t, err)
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if roles != "" {
+		req.Header.Set("X-Roles", roles)
+	}
+
+	if form != nil {
+		req.Header.Set("Origin", server.URL)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	return resp.StatusCode, string(body), resp.Header
+}
+
+func TestRouter_Disabled(t *testing.T) {
+	t.Parallel()
+
+	router := cadmin.NewRouter(cadmin.NewRouterParams{
+		Config: cadmin.Config{Path: "/admin"},
+		Logger: clogger.NewNoop(),
+	})
+
+	assert.Empty(t, router.Routes())
+}
+
+func TestRouter_Authorize(t *testing.T) {
+	t.Parallel()
+
+	config := cadmin.Config{Enabled: true, Path: "/admin", Roles: []string{"admin", "support"}}
+	server := newTestServer(t, config, cadmin.NewHealthPanel())
+
+	status, _, _ := request(t, server, http.MethodGet, "/admin/health", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, _, _ = request(t, server, http.MethodGet, "/admin/health", "user", nil)
+	assert.Equal(t, http.StatusForbidden, status)
+
+	status, body, _ := request(t, server, http.MethodGet, "/admin/health", "user,support", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "<h1>Health</h1>")
+
+	config.LoginPath = "/login"
+	server = newTestServer(t, config, cadmin.NewHealthPanel())
+
+	status, _, header := request(t, server, http.MethodGet, "/admin/health", "", nil)
+	assert.Equal(t, http.StatusFound, status)
+	assert.Equal(t, "/login?next=%2Fadmin%2Fhealth", header.Get("Location"))
+}
+
+func TestRouter_Panels(t *testing.T) {
+	t.Parallel()
+
+	dir := cconfigtest.SetupDirWithConfigs(t, map[string]string{"test.toml": "[cadmin]\nenabled = true\n"})
+
+	loader, err := cconfig.New(cconfig.Path(filepath.Join(dir, "test.toml")), "")
+	assert.NoError(t, err)
+
+	var (
+		sessions = &fakeSessions{sessions: []cadmin.Session{
+			{ID: "s1", UserID: "alice", IP: "10.0.0.1", CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)},
+		}}
+		config = cadmin.Config{Enabled: true, Path: "/admin", Roles: []string{"admin"}}
+		server = newTestServer(t, config,
+			cadmin.NewConfigPanel(loader),
+			cadmin.NewSessionsPanel(sessions, config),
+		)
+	)
+
+	status, _, header := request(t, server, http.MethodGet, "/admin", "admin", nil)
+	assert.Equal(t, http.StatusFound, status)
+	assert.Equal(t, "/admin/config", header.Get("Location"))
+
+	status, body, _ := request(t, server, http.MethodGet, "/admin/config", "admin", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `<a href="/admin/sessions">Sessions</a>`)
+	assert.Contains(t, body, "[cadmin]")
+
+	status, body, _ = request(t, server, http.MethodGet, "/admin/sessions", "admin", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "10.0.0.1")
+	assert.Contains(t, body, `action="/admin/sessions/actions/revoke"`)
+
+	status, _, header = request(t, server, http.MethodPost, "/admin/sessions/actions/revoke", "admin",
+		url.Values{"id": {"unknown"}})
+	assert.Equal(t, http.StatusSeeOther, status)
+	assert.Contains(t, header.Get("Location"), "/admin/sessions?error=")
+
+	status, _, header = request(t, server, http.MethodPost, "/admin/sessions/actions/revoke", "admin",
+		url.Values{"id": {"s1"}})
+	assert.Equal(t, http.StatusSeeOther, status)
+	assert.Equal(t, "/admin/sessions", header.Get("Location"))
+	assert.Empty(t, sessions.sessions)
+
+	status, _, _ = request(t, server, http.MethodPost, "/admin/config/actions/reload", "admin", url.Values{})
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _, _ = request(t, server, http.MethodGet, "/admin/unknown", "admin", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestRouter_HandleAction_CrossOrigin(t *testing.T) {
+	t.Parallel()
+
+	var (
+		sessions = &fakeSessions{sessions: []cadmin.Session{{ID: "s1"}}}
+		config   = cadmin.Config{Enabled: true, Path: "/admin", Roles: []string{"admin"}}
+		server   = newTestServer(t, config, cadmin.NewSessionsPanel(sessions, config))
+	)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost,
+		server.URL+"/admin/sessions/actions/revoke", strings.NewReader("id=s1"))
+	assert.NoError(t, err)
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Roles", "admin")
+	req.Header.Set("Origin", "https://evil.example.com")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Len(t, sessions.sessions, 1)
+}
+
+func TestMaintenancePanel(t *testing.T) {
+	t.Parallel()
+
+	mw, err := chttp.NewMaintenanceMiddleware(chttp.NewMaintenanceMiddlewareParams{
+		RW:     chttp.NewReaderWriter(nil, chttp.Config{}, clogger.NewNoop()),
+		Logger: clogger.NewNoop(),
+	})
+	assert.NoError(t, err)
+
+	var (
+		config = cadmin.Config{Enabled: true, Path: "/admin", Roles: []string{"admin"}}
+		server = newTestServer(t, config, cadmin.NewMaintenancePanel(mw, config))
+	)
+
+	status, body, _ := request(t, server, http.MethodGet, "/admin/maintenance", "admin", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "Maintenance mode is disabled.")
+
+	status, _, _ = request(t, server, http.MethodPost, "/admin/maintenance/actions/enable", "admin", url.Values{})
+	assert.Equal(t, http.StatusSeeOther, status)
+	assert.True(t, mw.Status().Runtime)
+
+	status, _, _ = request(t, server, http.MethodPost, "/admin/maintenance/actions/disable", "admin", url.Values{})
+	assert.Equal(t, http.StatusSeeOther, status)
+	assert.False(t, mw.Status().Enabled())
+}
+
+func TestLogLevelsPanel(t *testing.T) {
+	t.Parallel()
+
+	levels, err := clogger.NewLevels(clogger.Config{Level: "info"})
+	assert.NoError(t, err)
+
+	var (
+		config = cadmin.Config{Enabled: true, Path: "/admin", Roles: []string{"admin"}}
+		server = newTestServer(t, config, cadmin.NewLogLevelsPanel(levels, config))
+	)
+
+	status, _, _ := request(t, server, http.MethodPost, "/admin/log-levels/actions/set", "admin", url.Values{
+		"module": []string{"csql"},
+		"level":  []string{"warn"},
+	})
+	assert.Equal(t, http.StatusSeeOther, status)
+	assert.False(t, levels.Enabled("csql", clogger.LevelInfo))
+	assert.True(t, levels.Enabled("chttp", clogger.LevelInfo))
+
+	status, _, _ = request(t, server, http.MethodPost, "/admin/log-levels/actions/set", "admin", url.Values{
+		"level": []string{"debug"},
+	})
+	assert.Equal(t, http.StatusSeeOther, status)
+	assert.True(t, levels.Enabled("chttp", clogger.LevelDebug))
+
+	status, body, _ := request(t, server, http.MethodGet, "/admin/log-levels", "admin", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "<td>csql</td><td>warn</td>")
+}
@@ -0,0 +1,159 @@
+package cadmin
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// listLimit is the max number of users and sessions shown
+const listLimit = 100
+
+var (
+	usersTemplate    = template.Must(template.New("users").Parse(usersHTML))       //nolint:gochecknoglobals
+	sessionsTemplate = template.Must(template.New("sessions").Parse(sessionsHTML)) //nolint:gochecknoglobals
+)
+
+// User is a user shown by UsersPanel
+type User struct {
+	ID        string
+	Email     string
+	Roles     []string
+	CreatedAt time.Time
+}
+
+// Session is a session shown by SessionsPanel
+type Session struct {
+	ID        string
+	UserID    string
+	IP        string
+	UserAgent string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Users lists the app's users. The app implements it using its auth module.
+type Users interface {
+	// ListUsers returns up to limit users that match query (ex. by email), or the most recent users if query is
+	// empty
+	ListUsers(ctx context.Context, query string, limit int) ([]User, error)
+}
+
+// Sessions lists and revokes the app's active sessions. The app implements it using its auth module.
+type Sessions interface {
+	// ListSessions returns up to limit active sessions, most recent first
+	ListSessions(ctx context.Context, limit int) ([]Session, error)
+
+	// RevokeSession signs out the session with the given id
+	RevokeSession(ctx context.Context, id string) error
+}
+
+// NewUsersPanel creates a new UsersPanel
+func NewUsersPanel(users Users) *UsersPanel {
+	return &UsersPanel{users: users}
+}
+
+// UsersPanel lists and searches the app's users
+type UsersPanel struct {
+	users Users
+}
+
+// ID returns the panel's URL segment
+func (p *UsersPanel) ID() string { return "users" }
+
+// Title returns the panel's title
+func (p *UsersPanel) Title() string { return "Users" }
+
+// Render renders the users that match the q query param
+func (p *UsersPanel) Render(r *http.Request) (template.HTML, error) {
+	query := r.URL.Query().Get("q")
+
+	users, err := p.users.ListUsers(r.Context(), query, listLimit)
+	if err != nil {
+		return "", cerrors.New(err, "failed to list users", nil)
+	}
+
+	return renderTemplate(usersTemplate, map[string]interface{}{
+		"Query": query,
+		"Users": users,
+	})
+}
+
+// NewSessionsPanel creates a new SessionsPanel
+func NewSessionsPanel(sessions Sessions, config Config) *SessionsPanel {
+	return &SessionsPanel{
+		sessions: sessions,
+		basePath: config.Path,
+	}
+}
+
+// SessionsPanel lists the app's active sessions and lets them be revoked
+type SessionsPanel struct {
+	sessions Sessions
+	basePath string
+}
+
+// ID returns the panel's URL segment
+func (p *SessionsPanel) ID() string { return "sessions" }
+
+// Title returns the panel's title
+func (p *SessionsPanel) Title() string { return "Sessions" }
+
+// Render renders the most recent active sessions
+func (p *SessionsPanel) Render(r *http.Request) (template.HTML, error) {
+	sessions, err := p.sessions.ListSessions(r.Context(), listLimit)
+	if err != nil {
+		return "", cerrors.New(err, "failed to list sessions", nil)
+	}
+
+	return renderTemplate(sessionsTemplate, map[string]interface{}{
+		"Sessions":   sessions,
+		"RevokePath": ActionPath(p.basePath, p, "revoke"),
+	})
+}
+
+// HandleAction runs the revoke action that revokes the session with the submitted id
+func (p *SessionsPanel) HandleAction(r *http.Request, action string) error {
+	if action != "revoke" {
+		return cerrors.New(nil, "unknown action", map[string]interface{}{
+			"action": action,
+		})
+	}
+
+	err := p.sessions.RevokeSession(r.Context(), r.PostFormValue("id"))
+	if err != nil {
+		return cerrors.New(err, "failed to revoke session", nil)
+	}
+
+	return nil
+}
+
+const usersHTML = `
+<form method="get"><input type="search" name="q" value="{{ .Query }}" placeholder="Search users">
+<button type="submit">Search</button></form>
+{{ if .Users }}<table>
+<tr><th>ID</th><th>Email</th><th>Roles</th><th>Created at</th></tr>
+{{ range .Users }}<tr>
+<td>{{ .ID }}</td>
+<td>{{ .Email }}</td>
+<td>{{ range $i, $r := .Roles }}{{ if $i }}, {{ end }}{{ $r }}{{ end }}</td>
+<td>{{ .CreatedAt.Format "2006-01-02 15:04:05" }}</td>
+</tr>{{ end }}
+</table>{{ else }}<p class="empty">No users found.</p>{{ end }}`
+
+const sessionsHTML = `
+{{ if .Sessions }}<table>
+<tr><th>User</th><th>IP</th><th>User agent</th><th>Created at</th><th>Expires at</th><th></th></tr>
+{{ range .Sessions }}<tr>
+<td>{{ .UserID }}</td>
+<td>{{ .IP }}</td>
+<td>{{ .UserAgent }}</td>
+<td>{{ .CreatedAt.Format "2006-01-02 15:04:05" }}</td>
+<td>{{ .ExpiresAt.Format "2006-01-02 15:04:05" }}</td>
+<td><form class="inline" method="post" action="{{ $.RevokePath }}">
+<input type="hidden" name="id" value="{{ .ID }}"><button type="submit">Revoke</button></form></td>
+</tr>{{ end }}
+</table>{{ else }}<p class="empty">No active sessions.</p>{{ end }}`
@@ -0,0 +1,22 @@
+package cadmin
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup. The app provides the Authorizer and the []Panel shown in the
+// dashboard (ex. using the panels' constructors), along with Users and Sessions if it uses their panels.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+
+	NewRouter,
+	wire.Struct(new(NewRouterParams), "*"),
+
+	NewConfigPanel,
+	NewHealthPanel,
+	NewUsersPanel,
+	NewSessionsPanel,
+	NewMaintenancePanel,
+	NewLogLevelsPanel,
+
+	NewJobsPanel,
+	wire.Struct(new(NewJobsPanelParams), "*"),
+)
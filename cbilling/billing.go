@@ -0,0 +1,396 @@
+package cbilling
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cwebhook"
+)
+
+type ctxKey string
+
+const ctxSubscriptionKey = ctxKey("cbilling/subscription")
+
+// NewBillingParams holds the params needed for NewBilling
+type NewBillingParams struct {
+	Queries *Queries
+	Stripe  *Stripe
+	Users   Users
+	RW      *chttp.ReaderWriter
+	Config  Config
+	Logger  clogger.Logger
+}
+
+// NewBilling creates a new Billing
+func NewBilling(p NewBillingParams) *Billing {
+	return &Billing{
+		queries: p.Queries,
+		stripe:  p.Stripe,
+		users:   p.Users,
+		rw:      p.RW,
+		config:  p.Config,
+		logger:  p.Logger.With(clogger.Module("cbilling")),
+		now:     time.Now,
+	}
+}
+
+// Billing manages the Stripe customers and subscriptions of the app's users. Subscriptions are created using Stripe
+// Checkout and managed by users in the Stripe customer portal (see Router). The app's copy of each subscription is
+// kept in sync by HandleEvent. All methods must be called with a context that has a database transaction (see
+// csql.CtxWithTx).
+type Billing struct {
+	queries *Queries
+	stripe  *Stripe
+	users   Users
+	rw      *chttp.ReaderWriter
+	config  Config
+	logger  clogger.Logger
+	now     func() time.Time
+}
+
+// Customer returns the Stripe customer of the user. The customer is created in Stripe if the user does not have one.
+func (b *Billing) Customer(ctx context.Context, user *User) (*Customer, error) {
+	customer, err := b.queries.GetCustomer(ctx, user.ID)
+	if err == nil {
+		return customer, nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, cerrors.New(err, "failed to get customer", map[string]interface{}{
+			"userID": user.ID,
+		})
+	}
+
+	stripeID, err := b.stripe.CreateCustomer(ctx, CreateCustomerParams{
+		UserID: user.ID,
+		Email:  user.Email,
+		Name:   user.Name,
+	})
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create stripe customer", map[string]interface{}{
+			"userID": user.ID,
+		})
+	}
+
+	customer = &Customer{
+		UserID:           user.ID,
+		StripeCustomerID: stripeID,
+		CreatedAt:        b.now(),
+	}
+
+	err = b.queries.InsertCustomer(ctx, customer)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to insert customer", map[string]interface{}{
+			"userID": user.ID,
+		})
+	}
+
+	return customer, nil
+}
+
+// Subscription returns the user's current subscription: the most recent one that is entitled to its plan, or the
+// most recent one if none is. It returns ErrNotFound if the user never subscribed.
+func (b *Billing) Subscription(ctx context.Context, userID string) (*Subscription, error) {
+	subs, err := b.queries.ListSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to list subscriptions", map[string]interface{}{
+			"userID": userID,
+		})
+	}
+
+	if len(subs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	for i := range subs {
+		if subs[i].Entitled(b.config.AllowPastDue) {
+			return &subs[i], nil
+		}
+	}
+
+	return &subs[0], nil
+}
+
+// HasPlan returns true if the user has an entitled subscription to one of the plans
+func (b *Billing) HasPlan(ctx context.Context, userID string, plans ...string) (bool, error) {
+	sub, err := b.Subscription(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return sub.Entitled(b.config.AllowPastDue) && hasPlan(sub.Plan, plans), nil
+}
+
+// CheckoutURL returns the URL of a Stripe Checkout page that subscribes the user to the plan
+func (b *Billing) CheckoutURL(ctx context.Context, user *User, plan, successURL, cancelURL string) (string, error) {
+	priceID, ok := b.config.Plans[plan]
+	if !ok {
+		return "", cerrors.New(nil, "plan does not exist in cbilling.plans", map[string]interface{}{
+			"plan": plan,
+		})
+	}
+
+	customer, err := b.Customer(ctx, user)
+	if err != nil {
+		return "", err
+	}
+
+	url, err := b.stripe.CreateCheckoutSession(ctx, CreateCheckoutSessionParams{
+		CustomerID: customer.StripeCustomerID,
+		UserID:     user.ID,
+		PriceID:    priceID,
+		SuccessURL: successURL,
+		CancelURL:  cancelURL,
+	})
+	if err != nil {
+		return "", cerrors.New(err, "failed to create stripe checkout session", map[string]interface{}{
+			"userID": user.ID,
+			"plan":   plan,
+		})
+	}
+
+	return url, nil
+}
+
+// PortalURL returns the URL of the Stripe customer portal where the user can manage their subscription
+func (b *Billing) PortalURL(ctx context.Context, user *User, returnURL string) (string, error) {
+	customer, err := b.Customer(ctx, user)
+	if err != nil {
+		return "", err
+	}
+
+	url, err := b.stripe.CreatePortalSession(ctx, customer.StripeCustomerID, returnURL)
+	if err != nil {
+		return "", cerrors.New(err, "failed to create stripe portal session", map[string]interface{}{
+			"userID": user.ID,
+		})
+	}
+
+	return url, nil
+}
+
+// stripeEvent is the envelope of a Stripe webhook event
+type stripeEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// stripeSubscription holds the fields of a Stripe subscription object that are synced. Newer API versions moved
+// current_period_end to the subscription's items, so it is read from either.
+type stripeSubscription struct {
+	ID                string            `json:"id"`
+	Customer          string            `json:"customer"`
+	Status            string            `json:"status"`
+	CurrentPeriodEnd  int64             `json:"current_period_end"`
+	CancelAtPeriodEnd bool              `json:"cancel_at_period_end"`
+	Metadata          map[string]string `json:"metadata"`
+	Items             struct {
+		Data []struct {
+			CurrentPeriodEnd int64 `json:"current_period_end"`
+			Price            struct {
+				ID string `json:"id"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+// HandleEvent syncs the app's copy of a subscription from a customer.subscription.* webhook event. Other events are
+// ignored. Events that are older than the last one the subscription was synced from are ignored as well.
+func (b *Billing) HandleEvent(ctx context.Context, event cwebhook.Event) error {
+	var e stripeEvent
+
+	err := event.Decode(&e)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(e.Type, "customer.subscription.") {
+		return nil
+	}
+
+	var obj stripeSubscription
+
+	err = json.Unmarshal(e.Data.Object, &obj)
+	if err != nil {
+		return cerrors.New(err, "failed to decode stripe subscription", map[string]interface{}{
+			"eventID": e.ID,
+		})
+	}
+
+	return b.syncSubscription(ctx, obj, time.Unix(e.Created, 0).UTC())
+}
+
+func (b *Billing) syncSubscription(ctx context.Context, obj stripeSubscription, eventAt time.Time) error {
+	log := b.logger.WithTags(map[string]interface{}{
+		"subscriptionID": obj.ID,
+		"customerID":     obj.Customer,
+	})
+
+	sub, err := b.queries.GetSubscription(ctx, obj.ID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return cerrors.New(err, "failed to get subscription", map[string]interface{}{
+			"subscriptionID": obj.ID,
+		})
+	}
+
+	isNew := sub == nil
+
+	if isNew {
+		userID, err := b.subscriptionUserID(ctx, obj)
+		if err != nil {
+			return err
+		}
+
+		if userID == "" {
+			// retrying will not help so the event is acknowledged
+			log.Warn("Ignoring subscription of an unknown customer", nil)
+			return nil
+		}
+
+		sub = &Subscription{
+			ID:               obj.ID,
+			UserID:           userID,
+			StripeCustomerID: obj.Customer,
+			CreatedAt:        b.now(),
+		}
+	} else if eventAt.Before(sub.EventAt) {
+		return nil
+	}
+
+	sub.Status = obj.Status
+	sub.CancelAtPeriodEnd = obj.CancelAtPeriodEnd
+	sub.CurrentPeriodEnd = time.Unix(obj.CurrentPeriodEnd, 0).UTC()
+	sub.EventAt = eventAt
+	sub.UpdatedAt = b.now()
+
+	if len(obj.Items.Data) > 0 {
+		sub.PriceID = obj.Items.Data[0].Price.ID
+
+		if obj.CurrentPeriodEnd == 0 {
+			sub.CurrentPeriodEnd = time.Unix(obj.Items.Data[0].CurrentPeriodEnd, 0).UTC()
+		}
+	}
+
+	sub.Plan = b.config.plan(sub.PriceID)
+
+	if isNew {
+		err = b.queries.InsertSubscription(ctx, sub)
+	} else {
+		err = b.queries.UpdateSubscription(ctx, sub)
+	}
+
+	if err != nil {
+		return cerrors.New(err, "failed to save subscription", map[string]interface{}{
+			"subscriptionID": obj.ID,
+		})
+	}
+
+	log.WithTags(map[string]interface{}{
+		"status": sub.Status,
+		"plan":   sub.Plan,
+	}).Info("Synced subscription")
+
+	return nil
+}
+
+// subscriptionUserID returns the id of the user that owns the Stripe subscription using its customer, or the user id
+// set in its metadata by the checkout session
+func (b *Billing) subscriptionUserID(ctx context.Context, obj stripeSubscription) (string, error) {
+	customer, err := b.queries.GetCustomerByStripeID(ctx, obj.Customer)
+	if err == nil {
+		return customer.UserID, nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return "", cerrors.New(err, "failed to get customer", map[string]interface{}{
+			"customerID": obj.Customer,
+		})
+	}
+
+	return obj.Metadata["user_id"], nil
+}
+
+// RequirePlan returns a middleware that only lets through the requests of users with an entitled subscription to
+// one of the plans. Anonymous requests get a 401 response. Users without the plan are redirected to
+// cbilling.upgrade_path if the request is a page load, or get a 403 response otherwise. The subscription is stored
+// in the request context and can be read using SubscriptionFromCtx. For example:
+//
+//	chttp.Route{
+//		Middlewares: []chttp.Middleware{billing.RequirePlan("pro", "team")},
+//		Path:        "/reports",
+//		Methods:     []string{http.MethodGet},
+//		Handler:     ro.HandleReports,
+//	}
+func (b *Billing) RequirePlan(plans ...string) chttp.Middleware {
+	return chttp.HandleMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, err := b.users.CurrentUser(r)
+			if err != nil {
+				b.logger.Error("Failed to get current user", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if user == nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			sub, err := b.Subscription(r.Context(), user.ID)
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				b.logger.Error("Failed to get subscription", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if sub == nil || !sub.Entitled(b.config.AllowPastDue) || !hasPlan(sub.Plan, plans) {
+				b.denyPlan(w, r, plans)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxSubscriptionKey, sub)))
+		})
+	})
+}
+
+func (b *Billing) denyPlan(w http.ResponseWriter, r *http.Request, plans []string) {
+	isPage := r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html")
+
+	if b.config.UpgradePath != "" && isPage {
+		http.Redirect(w, r, b.config.UpgradePath, http.StatusSeeOther)
+		return
+	}
+
+	b.rw.WriteJSON(w, chttp.WriteJSONParams{
+		StatusCode: http.StatusForbidden,
+		Data: map[string]interface{}{
+			"error": "plan required",
+			"plans": plans,
+		},
+	})
+}
+
+// SubscriptionFromCtx returns the subscription of the request's user set by RequirePlan
+func SubscriptionFromCtx(ctx context.Context) *Subscription {
+	sub, _ := ctx.Value(ctxSubscriptionKey).(*Subscription)
+	return sub
+}
+
+func hasPlan(plan string, plans []string) bool {
+	for _, p := range plans {
+		if p == plan {
+			return true
+		}
+	}
+
+	return false
+}
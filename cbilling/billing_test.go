@@ -0,0 +1,290 @@
+package cbilling_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/cbilling"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/chttp/chttptest"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/csql"
+	"github.com/gocopper/copper/csql/csqltest"
+	"github.com/gocopper/copper/cwebhook"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+const testWebhookSecret = "whsec_test"
+
+type testUsers struct{}
+
+func (testUsers) CurrentUser(r *http.Request) (*cbilling.User, error) {
+	id := r.Header.Get("X-User")
+	if id == "" {
+		return nil, nil
+	}
+
+	return &cbilling.User{ID: id, Email: id + "@example.com"}, nil
+}
+
+type stripeRequest struct {
+	path           string
+	form           url.Values
+	idempotencyKey string
+}
+
+type fakeStripe struct {
+	mu       sync.Mutex
+	requests []stripeRequest
+}
+
+func (s *fakeStripe) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+
+	if user, _, _ := r.BasicAuth(); user != "sk_test" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, stripeRequest{
+		path:           r.URL.Path,
+		form:           r.PostForm,
+		idempotencyKey: r.Header.Get("Idempotency-Key"),
+	})
+	s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/v1/customers":
+		_, _ = w.Write([]byte(`{"id": "cus_1"}`))
+	case "/v1/checkout/sessions":
+		_, _ = w.Write([]byte(`{"url": "https://checkout.stripe.com/c/1"}`))
+	case "/v1/billing_portal/sessions":
+		_, _ = w.Write([]byte(`{"url": "https://billing.stripe.com/p/1"}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error": {"type": "invalid_request_error", "message": "not found"}}`))
+	}
+}
+
+func newTestHandler(t *testing.T, stripeURL string) http.Handler {
+	t.Helper()
+
+	var (
+		testDB = csqltest.NewDB(t, csqltest.Options{Migrations: csql.Migrations(cbilling.Migrations)})
+		db     = testDB.DB
+		rw     = chttptest.NewReaderWriter(t)
+		config = cbilling.Config{
+			SecretKey:     "sk_test",
+			WebhookSecret: testWebhookSecret,
+			Plans:         map[string]string{"pro": "price_pro", "team": "price_team"},
+			BasePath:      "/billing",
+			SuccessPath:   "/welcome",
+			CancelPath:    "/pricing",
+			ReturnPath:    "/settings",
+			UpgradePath:   "/pricing",
+			BaseURL:       stripeURL,
+			Timeout:       time.Second,
+		}
+		billing = cbilling.NewBilling(cbilling.NewBillingParams{
+			Queries: cbilling.NewQueries(testDB.Querier),
+			Stripe:  cbilling.NewStripe(config),
+			Users:   testUsers{},
+			RW:      rw,
+			Config:  config,
+			Logger:  clogger.NewNoop(),
+		})
+		router, routerErr = cbilling.NewRouter(cbilling.NewRouterParams{
+			Billing:       billing,
+			Users:         testUsers{},
+			RW:            rw,
+			DeliveryStore: cwebhook.NewMemoryDeliveryStore(),
+			WebhookConfig: cwebhook.Config{MaxBodyBytes: 1 << 20, Tolerance: time.Minute, DeliveryIDTTL: time.Hour},
+			Config:        config,
+			Logger:        clogger.NewNoop(),
+		})
+		reports = chttp.Route{
+			Middlewares: []chttp.Middleware{billing.RequirePlan("pro", "team")},
+			Path:        "/reports",
+			Methods:     []string{http.MethodGet},
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(cbilling.SubscriptionFromCtx(r.Context()).Plan))
+			},
+		}
+		txMiddleware = chttp.HandleMiddleware(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx, tx, err := csql.CtxWithTx(r.Context(), db, "sqlite3")
+				assert.NoError(t, err)
+
+				next.ServeHTTP(w, r.WithContext(ctx))
+
+				assert.NoError(t, tx.Commit())
+			})
+		})
+	)
+	assert.NoError(t, routerErr)
+
+	return chttp.NewHandler(chttp.NewHandlerParams{
+		Routers:           []chttp.Router{router, chttptest.NewRouter([]chttp.Route{reports})},
+		GlobalMiddlewares: []chttp.Middleware{txMiddleware},
+		Logger:            clogger.NewNoop(),
+	})
+}
+
+func do(handler http.Handler, method, path, user string, header http.Header, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "http://app.test"+path, strings.NewReader(body))
+
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp := httptest.NewRecorder()
+
+	handler.ServeHTTP(resp, req)
+
+	return resp
+}
+
+func subscriptionEvent(id, status, price string, created time.Time) string {
+	return fmt.Sprintf(`{"id": %q, "type": "customer.subscription.updated", "created": %d, "data": {"object": {
+		"id": "sub_1", "customer": "cus_1", "status": %q, "current_period_end": %d, "cancel_at_period_end": false,
+		"items": {"data": [{"price": {"id": %q}}]}}}}`, id, created.Unix(), status, created.Add(720*time.Hour).Unix(),
+		price)
+}
+
+func signed(body string) http.Header {
+	ts := fmt.Sprint(time.Now().Unix())
+
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write([]byte(ts + "." + body))
+
+	return http.Header{"Stripe-Signature": {"t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))}}
+}
+
+func TestRouter_Checkout(t *testing.T) {
+	t.Parallel()
+
+	var (
+		stripe  fakeStripe
+		server  = httptest.NewServer(&stripe)
+		handler = newTestHandler(t, server.URL)
+		form    = http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}
+	)
+
+	defer server.Close()
+
+	resp := do(handler, http.MethodPost, "/billing/checkout", "", form, "plan=pro")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	resp = do(handler, http.MethodPost, "/billing/checkout", "u1", form, "plan=gold")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = do(handler, http.MethodPost, "/billing/checkout", "u1", form, "plan=pro")
+	assert.Equal(t, http.StatusSeeOther, resp.Code)
+	assert.Equal(t, "https://checkout.stripe.com/c/1", resp.Header().Get("Location"))
+
+	// the customer is only created once
+	resp = do(handler, http.MethodPost, "/billing/portal", "u1", http.Header{"Accept": {"application/json"}}, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"url": "https://billing.stripe.com/p/1"}`, resp.Body.String())
+
+	assert.Len(t, stripe.requests, 3)
+
+	assert.Equal(t, "/v1/customers", stripe.requests[0].path)
+	assert.Equal(t, "cbilling-customer-u1", stripe.requests[0].idempotencyKey)
+	assert.Equal(t, "u1@example.com", stripe.requests[0].form.Get("email"))
+	assert.Equal(t, "u1", stripe.requests[0].form.Get("metadata[user_id]"))
+
+	assert.Equal(t, "/v1/checkout/sessions", stripe.requests[1].path)
+	assert.Equal(t, url.Values{
+		"mode":                                 {"subscription"},
+		"customer":                             {"cus_1"},
+		"client_reference_id":                  {"u1"},
+		"line_items[0][price]":                 {"price_pro"},
+		"line_items[0][quantity]":              {"1"},
+		"subscription_data[metadata][user_id]": {"u1"},
+		"success_url":                          {"http://app.test/welcome"},
+		"cancel_url":                           {"http://app.test/pricing"},
+	}, stripe.requests[1].form)
+
+	assert.Equal(t, "/v1/billing_portal/sessions", stripe.requests[2].path)
+	assert.Equal(t, "http://app.test/settings", stripe.requests[2].form.Get("return_url"))
+}
+
+func TestBilling_RequirePlan(t *testing.T) {
+	t.Parallel()
+
+	var (
+		stripe  fakeStripe
+		server  = httptest.NewServer(&stripe)
+		handler = newTestHandler(t, server.URL)
+		html    = http.Header{"Accept": {"text/html"}}
+		now     = time.Now()
+	)
+
+	defer server.Close()
+
+	// the customer is linked to the user by the checkout
+	resp := do(handler, http.MethodPost, "/billing/checkout", "u1",
+		http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}, "plan=pro")
+	assert.Equal(t, http.StatusSeeOther, resp.Code)
+
+	resp = do(handler, http.MethodGet, "/reports", "", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	resp = do(handler, http.MethodGet, "/reports", "u1", nil, "")
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	resp = do(handler, http.MethodGet, "/reports", "u1", html, "")
+	assert.Equal(t, http.StatusSeeOther, resp.Code)
+	assert.Equal(t, "/pricing", resp.Header().Get("Location"))
+
+	event := subscriptionEvent("evt_1", cbilling.StatusActive, "price_pro", now)
+
+	resp = do(handler, http.MethodPost, "/billing/webhook", "", http.Header{"Stripe-Signature": {"t=1,v1=bad"}}, event)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	resp = do(handler, http.MethodPost, "/billing/webhook", "", signed(event), event)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp = do(handler, http.MethodGet, "/reports", "u1", nil, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "pro", resp.Body.String())
+
+	// events delivered out of order are ignored
+	event = subscriptionEvent("evt_0", cbilling.StatusIncomplete, "price_pro", now.Add(-time.Minute))
+
+	resp = do(handler, http.MethodPost, "/billing/webhook", "", signed(event), event)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp = do(handler, http.MethodGet, "/reports", "u1", nil, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	event = subscriptionEvent("evt_2", cbilling.StatusCanceled, "price_pro", now.Add(time.Minute))
+
+	resp = do(handler, http.MethodPost, "/billing/webhook", "", signed(event), event)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp = do(handler, http.MethodGet, "/reports", "u1", nil, "")
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	var body map[string]interface{}
+
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, []interface{}{"pro", "team"}, body["plans"])
+}
@@ -0,0 +1,105 @@
+package cbilling
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	defaultBaseURL  = "https://api.stripe.com"
+	defaultBasePath = "/billing"
+	defaultTimeout  = 30 * time.Second
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "cbilling",
+		Description: "cbilling configures the Stripe billing integration",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("cbilling", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load cbilling config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		BaseURL:     defaultBaseURL,
+		BasePath:    defaultBasePath,
+		SuccessPath: "/",
+		CancelPath:  "/",
+		ReturnPath:  "/",
+		Timeout:     defaultTimeout,
+	}
+}
+
+// Config configures the Stripe integration. For example:
+//
+//	[cbilling]
+//	secret_key = "sk_live_xxx"
+//	webhook_secret = "whsec_xxx"
+//	success_path = "/welcome"
+//	upgrade_path = "/pricing"
+//
+//	[cbilling.plans]
+//	pro = "price_xxx"
+//	team = "price_yyy"
+type Config struct {
+	// SecretKey is the Stripe API key
+	SecretKey string `toml:"secret_key" doc:"Stripe API key"`
+
+	// WebhookSecret is the signing secret of the webhook endpoint at <base_path>/webhook
+	WebhookSecret string `toml:"webhook_secret" doc:"Signing secret of the Stripe webhook endpoint"`
+
+	// Plans maps the app's plan names to Stripe price ids
+	Plans map[string]string `toml:"plans" doc:"Plan names mapped to Stripe price ids"`
+
+	// BasePath is the path prefix of the billing routes
+	BasePath string `toml:"base_path" doc:"Path prefix of the billing routes"`
+
+	// SuccessPath and CancelPath are where users are sent back to after the checkout succeeds or is canceled
+	SuccessPath string `toml:"success_path"`
+	CancelPath  string `toml:"cancel_path"`
+
+	// ReturnPath is where users are sent back to from the customer portal
+	ReturnPath string `toml:"return_path"`
+
+	// UpgradePath is where RequirePlan redirects page requests that need a plan the user does not have. If it is not
+	// set, they get a 403 response.
+	UpgradePath string `toml:"upgrade_path"`
+
+	// AllowPastDue keeps past due subscriptions entitled to their plan while Stripe retries the payment
+	AllowPastDue bool `toml:"allow_past_due"`
+
+	// AppURL is the public URL of the app (ex. https://example.com) that the redirect paths are relative to. If it is
+	// not set, the URL of the request is used.
+	AppURL string `toml:"app_url"`
+
+	// BaseURL overrides the Stripe API URL (ex. for stripe-mock)
+	BaseURL string `toml:"base_url"`
+
+	// Timeout is the max duration of a Stripe API request
+	Timeout time.Duration `toml:"timeout"`
+}
+
+// plan returns the name of the plan of a Stripe price, or an empty string if the price is not in Plans
+func (c Config) plan(priceID string) string {
+	for name, id := range c.Plans {
+		if id == priceID {
+			return name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,3 @@
+// Package cbilling integrates Stripe billing: it links the app's users to Stripe customers, serves the checkout and
+// customer portal routes, keeps subscriptions in sync using Stripe's webhooks, and gates routes by plan.
+package cbilling
@@ -0,0 +1,26 @@
+-- +migrate Up
+create table cbilling_customers (
+    user_id varchar(255) primary key,
+    stripe_customer_id varchar(255) not null unique,
+    created_at datetime(6) not null
+);
+
+create table cbilling_subscriptions (
+    id varchar(255) primary key,
+    user_id varchar(255) not null,
+    stripe_customer_id varchar(255) not null,
+    plan varchar(255) not null,
+    price_id varchar(255) not null,
+    status varchar(32) not null,
+    current_period_end datetime(6) not null,
+    cancel_at_period_end boolean not null,
+    event_at datetime(6) not null,
+    created_at datetime(6) not null,
+    updated_at datetime(6) not null
+);
+
+create index cbilling_subscriptions_user_idx on cbilling_subscriptions (user_id);
+
+-- +migrate Down
+drop table cbilling_subscriptions;
+drop table cbilling_customers;
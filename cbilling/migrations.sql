@@ -0,0 +1,26 @@
+-- +migrate Up
+create table cbilling_customers (
+    user_id text primary key,
+    stripe_customer_id text not null unique,
+    created_at timestamp not null
+);
+
+create table cbilling_subscriptions (
+    id text primary key,
+    user_id text not null,
+    stripe_customer_id text not null,
+    plan text not null,
+    price_id text not null,
+    status text not null,
+    current_period_end timestamp not null,
+    cancel_at_period_end boolean not null,
+    event_at timestamp not null,
+    created_at timestamp not null,
+    updated_at timestamp not null
+);
+
+create index cbilling_subscriptions_user_idx on cbilling_subscriptions (user_id);
+
+-- +migrate Down
+drop table cbilling_subscriptions;
+drop table cbilling_customers;
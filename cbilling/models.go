@@ -0,0 +1,60 @@
+package cbilling
+
+import (
+	"time"
+)
+
+// Subscription statuses as defined by Stripe
+const (
+	StatusIncomplete        = "incomplete"
+	StatusIncompleteExpired = "incomplete_expired"
+	StatusTrialing          = "trialing"
+	StatusActive            = "active"
+	StatusPastDue           = "past_due"
+	StatusCanceled          = "canceled"
+	StatusUnpaid            = "unpaid"
+	StatusPaused            = "paused"
+)
+
+// Customer links a user of the app to a Stripe customer
+type Customer struct {
+	UserID           string    `db:"user_id" json:"user_id"`
+	StripeCustomerID string    `db:"stripe_customer_id" json:"stripe_customer_id"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+}
+
+// Subscription is the app's copy of a Stripe subscription. It is kept in sync using Stripe's webhooks.
+type Subscription struct {
+	// ID is the Stripe subscription id
+	ID               string `db:"id" json:"id"`
+	UserID           string `db:"user_id" json:"user_id"`
+	StripeCustomerID string `db:"stripe_customer_id" json:"stripe_customer_id"`
+
+	// Plan is the name of the subscription's price in cbilling.plans. It is empty if the price is not in the config.
+	Plan    string `db:"plan" json:"plan"`
+	PriceID string `db:"price_id" json:"price_id"`
+
+	Status            string    `db:"status" json:"status"`
+	CurrentPeriodEnd  time.Time `db:"current_period_end" json:"current_period_end"`
+	CancelAtPeriodEnd bool      `db:"cancel_at_period_end" json:"cancel_at_period_end"`
+
+	// EventAt is the time of the Stripe event the subscription was last updated from. Older events are ignored since
+	// Stripe does not guarantee the order of deliveries.
+	EventAt time.Time `db:"event_at" json:"event_at"`
+
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Entitled returns true if the subscription grants access to its plan. Past due subscriptions are only entitled if
+// allowPastDue is true.
+func (s *Subscription) Entitled(allowPastDue bool) bool {
+	switch s.Status {
+	case StatusActive, StatusTrialing:
+		return true
+	case StatusPastDue:
+		return allowPastDue
+	default:
+		return false
+	}
+}
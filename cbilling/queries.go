@@ -0,0 +1,118 @@
+package cbilling
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+
+	"github.com/gocopper/copper/csql"
+)
+
+// Migrations holds the database schema needed by cbilling. Register them using csql.RegisterMigrations so that they
+// are applied by csql.Migrator along with the app's migrations:
+//
+//	csql.RegisterMigrations(csql.ModuleMigrations{Module: "cbilling", FS: cbilling.Migrations})
+//
+// MySQL uses the variant in migrations.mysql.sql (see csql.ModuleMigrations).
+//
+//go:embed migrations.sql migrations.mysql.sql
+var Migrations embed.FS
+
+// ErrNotFound is returned when a customer or a subscription does not exist
+var ErrNotFound = errors.New("not found")
+
+// NewQueries creates a new Queries
+func NewQueries(querier csql.Querier) *Queries {
+	return &Queries{querier: querier}
+}
+
+// Queries holds the database queries for customers and subscriptions
+type Queries struct {
+	querier csql.Querier
+}
+
+// GetCustomer returns the customer of the user with the given id
+func (q *Queries) GetCustomer(ctx context.Context, userID string) (*Customer, error) {
+	var c Customer
+
+	err := q.querier.Get(ctx, &c, `select * from cbilling_customers where user_id = ?`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+
+	return &c, err
+}
+
+// GetCustomerByStripeID returns the customer with the given Stripe customer id
+func (q *Queries) GetCustomerByStripeID(ctx context.Context, stripeCustomerID string) (*Customer, error) {
+	var c Customer
+
+	err := q.querier.Get(ctx, &c, `select * from cbilling_customers where stripe_customer_id = ?`, stripeCustomerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+
+	return &c, err
+}
+
+// InsertCustomer saves a new customer. Inserting a customer for a user that already has one does nothing.
+func (q *Queries) InsertCustomer(ctx context.Context, c *Customer) error {
+	const query = `
+	insert into cbilling_customers (user_id, stripe_customer_id, created_at)
+	select ?, ?, ? where not exists (select 1 from cbilling_customers where user_id = ?)`
+
+	_, err := q.querier.Exec(ctx, query, c.UserID, c.StripeCustomerID, c.CreatedAt, c.UserID)
+
+	return err
+}
+
+// GetSubscription returns the subscription with the given Stripe subscription id
+func (q *Queries) GetSubscription(ctx context.Context, id string) (*Subscription, error) {
+	var s Subscription
+
+	err := q.querier.Get(ctx, &s, `select * from cbilling_subscriptions where id = ?`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+
+	return &s, err
+}
+
+// ListSubscriptions returns the subscriptions of the user with the given id, most recent first
+func (q *Queries) ListSubscriptions(ctx context.Context, userID string) ([]Subscription, error) {
+	const query = `select * from cbilling_subscriptions where user_id = ? order by created_at desc`
+
+	var subs []Subscription
+
+	err := q.querier.Select(ctx, &subs, query, userID)
+
+	return subs, err
+}
+
+// InsertSubscription saves a new subscription
+func (q *Queries) InsertSubscription(ctx context.Context, s *Subscription) error {
+	const query = `
+	insert into cbilling_subscriptions (id, user_id, stripe_customer_id, plan, price_id, status, current_period_end,
+		cancel_at_period_end, event_at, created_at, updated_at)
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := q.querier.Exec(ctx, query, s.ID, s.UserID, s.StripeCustomerID, s.Plan, s.PriceID, s.Status,
+		s.CurrentPeriodEnd, s.CancelAtPeriodEnd, s.EventAt, s.CreatedAt, s.UpdatedAt)
+
+	return err
+}
+
+// UpdateSubscription saves the plan and status of a subscription
+func (q *Queries) UpdateSubscription(ctx context.Context, s *Subscription) error {
+	const query = `
+	update cbilling_subscriptions
+	set plan = ?, price_id = ?, status = ?, current_period_end = ?, cancel_at_period_end = ?, event_at = ?,
+		updated_at = ?
+	where id = ?`
+
+	_, err := q.querier.Exec(ctx, query, s.Plan, s.PriceID, s.Status, s.CurrentPeriodEnd, s.CancelAtPeriodEnd,
+		s.EventAt, s.UpdatedAt, s.ID)
+
+	return err
+}
@@ -0,0 +1,179 @@
+package cbilling
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cwebhook"
+)
+
+// NewRouterParams holds the params needed for NewRouter
+type NewRouterParams struct {
+	Billing       *Billing
+	Users         Users
+	RW            *chttp.ReaderWriter
+	DeliveryStore cwebhook.DeliveryStore
+	WebhookConfig cwebhook.Config
+	Config        Config
+	Logger        clogger.Logger
+}
+
+// NewRouter creates a new Router. It returns an error if cbilling.webhook_secret is not set.
+func NewRouter(p NewRouterParams) (*Router, error) {
+	// the webhook endpoint uses its own receiver so that it is verified using cbilling.webhook_secret
+	receiver := cwebhook.NewReceiver(cwebhook.NewReceiverParams{
+		Store:  p.DeliveryStore,
+		Config: p.WebhookConfig,
+		Logger: p.Logger,
+	})
+
+	verifier, err := cwebhook.NewStripeVerifier(cwebhook.Config{
+		Tolerance: p.WebhookConfig.Tolerance,
+		Stripe:    cwebhook.ConfigSecret{Secret: p.Config.WebhookSecret},
+	})
+	if err != nil {
+		return nil, cerrors.New(err, "cbilling.webhook_secret is not set", nil)
+	}
+
+	receiver.Handle(p.Config.BasePath+"/webhook", verifier, p.Billing.HandleEvent)
+
+	return &Router{
+		billing:  p.Billing,
+		users:    p.Users,
+		rw:       p.RW,
+		receiver: receiver,
+		config:   p.Config,
+		logger:   p.Logger.With(clogger.Module("cbilling")),
+	}, nil
+}
+
+// Router is a chttp.Router that serves the billing routes (paths are relative to cbilling.base_path):
+//
+//	POST /checkout  redirects the user to Stripe Checkout to subscribe to the plan in the plan form value
+//	POST /portal    redirects the user to the Stripe customer portal
+//	POST /webhook   syncs subscriptions from Stripe's webhook events
+//
+// The checkout and portal routes respond with {"url": ".."} instead of a redirect if the request accepts JSON. The
+// webhook endpoint must be registered in the Stripe dashboard with the customer.subscription.* events.
+type Router struct {
+	billing  *Billing
+	users    Users
+	rw       *chttp.ReaderWriter
+	receiver *cwebhook.Receiver
+	config   Config
+	logger   clogger.Logger
+}
+
+// Routes implements chttp.Router
+func (ro *Router) Routes() []chttp.Route {
+	return append([]chttp.Route{
+		{
+			Path:    ro.config.BasePath + "/checkout",
+			Methods: []string{http.MethodPost},
+			Handler: ro.HandleCheckout,
+		},
+		{
+			Path:    ro.config.BasePath + "/portal",
+			Methods: []string{http.MethodPost},
+			Handler: ro.HandlePortal,
+		},
+	}, ro.receiver.Routes()...)
+}
+
+// HandleCheckout redirects the user to a Stripe Checkout page for the plan in the plan form value
+func (ro *Router) HandleCheckout(w http.ResponseWriter, r *http.Request) {
+	user, ok := ro.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	plan := r.FormValue("plan")
+	if _, ok := ro.config.Plans[plan]; !ok {
+		ro.rw.WriteJSON(w, chttp.WriteJSONParams{
+			StatusCode: http.StatusBadRequest,
+			Data:       map[string]string{"error": "unknown plan"},
+		})
+
+		return
+	}
+
+	url, err := ro.billing.CheckoutURL(r.Context(), user, plan, ro.appURL(r, ro.config.SuccessPath),
+		ro.appURL(r, ro.config.CancelPath))
+	if err != nil {
+		ro.logger.Error("Failed to create checkout session", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	ro.redirect(w, r, url)
+}
+
+// HandlePortal redirects the user to the Stripe customer portal
+func (ro *Router) HandlePortal(w http.ResponseWriter, r *http.Request) {
+	user, ok := ro.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	url, err := ro.billing.PortalURL(r.Context(), user, ro.appURL(r, ro.config.ReturnPath))
+	if err != nil {
+		ro.logger.Error("Failed to create portal session", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	ro.redirect(w, r, url)
+}
+
+func (ro *Router) currentUser(w http.ResponseWriter, r *http.Request) (*User, bool) {
+	user, err := ro.users.CurrentUser(r)
+	if err != nil {
+		ro.logger.Error("Failed to get current user", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return nil, false
+	}
+
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return user, true
+}
+
+func (ro *Router) redirect(w http.ResponseWriter, r *http.Request, url string) {
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		ro.rw.WriteJSON(w, chttp.WriteJSONParams{
+			StatusCode: http.StatusOK,
+			Data:       map[string]string{"url": url},
+		})
+
+		return
+	}
+
+	http.Redirect(w, r, url, http.StatusSeeOther)
+}
+
+// appURL returns the absolute URL of path, which Stripe requires for its redirects
+func (ro *Router) appURL(r *http.Request, path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+
+	if ro.config.AppURL != "" {
+		return strings.TrimSuffix(ro.config.AppURL, "/") + path
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host + path
+}
@@ -0,0 +1,171 @@
+package cbilling
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+const maxErrorBodyBytes = 4 << 10
+
+// NewStripe creates a new Stripe
+func NewStripe(config Config) *Stripe {
+	return &Stripe{
+		baseURL:   strings.TrimSuffix(config.BaseURL, "/"),
+		secretKey: config.SecretKey,
+		http:      &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// Stripe is a client for the parts of the Stripe API that cbilling uses. Requests are form encoded and authenticated
+// using cbilling.secret_key.
+type Stripe struct {
+	baseURL   string
+	secretKey string
+	http      *http.Client
+}
+
+// CreateCustomerParams holds the params needed for Stripe.CreateCustomer
+type CreateCustomerParams struct {
+	UserID string
+	Email  string
+	Name   string
+}
+
+// CreateCustomer creates a Stripe customer for a user and returns its id. The request uses an idempotency key based
+// on the user id so that concurrent requests do not create duplicate customers.
+func (s *Stripe) CreateCustomer(ctx context.Context, p CreateCustomerParams) (string, error) {
+	form := url.Values{"metadata[user_id]": {p.UserID}}
+
+	if p.Email != "" {
+		form.Set("email", p.Email)
+	}
+
+	if p.Name != "" {
+		form.Set("name", p.Name)
+	}
+
+	var customer struct {
+		ID string `json:"id"`
+	}
+
+	err := s.post(ctx, "/v1/customers", form, "cbilling-customer-"+p.UserID, &customer)
+	if err != nil {
+		return "", err
+	}
+
+	return customer.ID, nil
+}
+
+// CreateCheckoutSessionParams holds the params needed for Stripe.CreateCheckoutSession
+type CreateCheckoutSessionParams struct {
+	CustomerID string
+	UserID     string
+	PriceID    string
+	SuccessURL string
+	CancelURL  string
+}
+
+// CreateCheckoutSession creates a Stripe Checkout session that subscribes the customer to the price and returns its
+// URL
+func (s *Stripe) CreateCheckoutSession(ctx context.Context, p CreateCheckoutSessionParams) (string, error) {
+	form := url.Values{
+		"mode":                                 {"subscription"},
+		"customer":                             {p.CustomerID},
+		"client_reference_id":                  {p.UserID},
+		"line_items[0][price]":                 {p.PriceID},
+		"line_items[0][quantity]":              {"1"},
+		"subscription_data[metadata][user_id]": {p.UserID},
+		"success_url":                          {p.SuccessURL},
+		"cancel_url":                           {p.CancelURL},
+	}
+
+	var session struct {
+		URL string `json:"url"`
+	}
+
+	err := s.post(ctx, "/v1/checkout/sessions", form, "", &session)
+	if err != nil {
+		return "", err
+	}
+
+	return session.URL, nil
+}
+
+// CreatePortalSession creates a Stripe customer portal session and returns its URL
+func (s *Stripe) CreatePortalSession(ctx context.Context, customerID, returnURL string) (string, error) {
+	form := url.Values{
+		"customer":   {customerID},
+		"return_url": {returnURL},
+	}
+
+	var session struct {
+		URL string `json:"url"`
+	}
+
+	err := s.post(ctx, "/v1/billing_portal/sessions", form, "", &session)
+	if err != nil {
+		return "", err
+	}
+
+	return session.URL, nil
+}
+
+func (s *Stripe) post(ctx context.Context, path string, form url.Values, idempotencyKey string,
+	dest interface{}) error {
+	if s.secretKey == "" {
+		return cerrors.New(nil, "cbilling.secret_key is not set", nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return cerrors.New(err, "failed to create stripe request", nil)
+	}
+
+	req.SetBasicAuth(s.secretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return cerrors.New(err, "failed to send stripe request", map[string]interface{}{
+			"path": path,
+		})
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		var body struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+
+		_ = json.NewDecoder(io.LimitReader(resp.Body, maxErrorBodyBytes)).Decode(&body)
+
+		return cerrors.New(nil, "stripe request failed", map[string]interface{}{
+			"path":    path,
+			"status":  resp.StatusCode,
+			"type":    body.Error.Type,
+			"message": body.Error.Message,
+		})
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(dest)
+	if err != nil {
+		return cerrors.New(err, "failed to decode stripe response", map[string]interface{}{
+			"path": path,
+		})
+	}
+
+	return nil
+}
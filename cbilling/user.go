@@ -0,0 +1,19 @@
+package cbilling
+
+import (
+	"net/http"
+)
+
+// User is the user of the app that a Stripe customer is created for
+type User struct {
+	ID    string
+	Email string
+	Name  string
+}
+
+// Users gets the user of a request for the billing routes and RequirePlan. Apps implement it using the session or
+// user set in the request context by their auth middleware.
+type Users interface {
+	// CurrentUser returns the user of the request or nil if the request is anonymous
+	CurrentUser(r *http.Request) (*User, error)
+}
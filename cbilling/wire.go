@@ -0,0 +1,16 @@
+package cbilling
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup. It needs the app's Users and cwebhook.WireModule.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+	NewQueries,
+	NewStripe,
+
+	NewBilling,
+	wire.Struct(new(NewBillingParams), "*"),
+
+	NewRouter,
+	wire.Struct(new(NewRouterParams), "*"),
+)
@@ -0,0 +1,103 @@
+package ccache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/internal/redis"
+)
+
+// Backend stores the encoded values of a Cache
+type Backend interface {
+	// Get returns the value of the key and false if it does not exist or expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores the value of the key until ttl expires
+	Set(ctx context.Context, key string, val []byte, ttl time.Duration) error
+
+	// Delete removes the keys
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// NewBackend creates the Backend configured by Config.Backend
+func NewBackend(config Config) (Backend, error) {
+	switch config.Backend {
+	case BackendMemory:
+		return NewMemoryBackend(config.Memory.MaxEntries), nil
+	case BackendRedis:
+		return NewRedisBackend(config.Redis)
+	default:
+		return nil, cerrors.New(nil, "unknown ccache backend", map[string]interface{}{
+			"backend": config.Backend,
+		})
+	}
+}
+
+// NewRedisBackend creates a new RedisBackend
+func NewRedisBackend(config ConfigRedis) (*RedisBackend, error) {
+	opts, err := redis.ParseURL(config.URL)
+	if err != nil {
+		return nil, cerrors.New(err, "invalid ccache.redis.url", nil)
+	}
+
+	return &RedisBackend{client: redis.New(opts)}, nil
+}
+
+// RedisBackend is a Backend that stores values in Redis
+type RedisBackend struct {
+	client *redis.Client
+}
+
+// Get returns the value of the key and false if it does not exist or expired
+func (b *RedisBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	val, err := redis.Bytes(b.client.Do(ctx, "GET", key))
+	if errors.Is(err, redis.ErrNil) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, cerrors.New(err, "failed to get cached value", map[string]interface{}{
+			"key": key,
+		})
+	}
+
+	return val, true, nil
+}
+
+// Set stores the value of the key until ttl expires
+func (b *RedisBackend) Set(ctx context.Context, key string, val []byte, ttl time.Duration) error {
+	_, err := b.client.Do(ctx, "SET", key, val, "PX", ttl.Milliseconds())
+	if err != nil {
+		return cerrors.New(err, "failed to set cached value", map[string]interface{}{
+			"key": key,
+		})
+	}
+
+	return nil
+}
+
+// Delete removes the keys
+func (b *RedisBackend) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(keys)+1)
+	args = append(args, "DEL")
+
+	for _, key := range keys {
+		args = append(args, key)
+	}
+
+	_, err := b.client.Do(ctx, args...)
+	if err != nil {
+		return cerrors.New(err, "failed to delete cached values", nil)
+	}
+
+	return nil
+}
+
+// Close closes the connections to Redis
+func (b *RedisBackend) Close() error {
+	return b.client.Close()
+}
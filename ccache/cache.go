@@ -0,0 +1,162 @@
+package ccache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+)
+
+// LoadFunc loads the value of a key that is not cached
+type LoadFunc func(ctx context.Context) (interface{}, error)
+
+// NewCacheParams holds the params needed for NewCache
+type NewCacheParams struct {
+	Backend Backend
+	Config  Config
+	Logger  clogger.Logger
+}
+
+// NewCache creates a new Cache
+func NewCache(p NewCacheParams) *Cache {
+	return &Cache{
+		backend:    p.Backend,
+		prefix:     p.Config.Prefix,
+		defaultTTL: p.Config.DefaultTTL,
+		logger:     p.Logger.With(clogger.Module("ccache")),
+	}
+}
+
+// Cache caches JSON encoded values in its Backend. A TTL of 0 uses ccache.default_ttl. See Get and GetOrLoad for
+// typed helpers.
+type Cache struct {
+	backend    Backend
+	prefix     string
+	defaultTTL time.Duration
+	logger     clogger.Logger
+	flight     singleflight
+}
+
+// Get decodes the cached value of the key into dest. It returns false if the key is not cached.
+func (c *Cache) Get(ctx context.Context, key string, dest interface{}) (bool, error) {
+	data, ok, err := c.backend.Get(ctx, c.prefix+key)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	err = json.Unmarshal(data, dest)
+	if err != nil {
+		return false, cerrors.New(err, "failed to decode cached value", map[string]interface{}{
+			"key": key,
+		})
+	}
+
+	return true, nil
+}
+
+// Set caches the value of the key until ttl expires
+func (c *Cache) Set(ctx context.Context, key string, val interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return cerrors.New(err, "failed to encode cached value", map[string]interface{}{
+			"key": key,
+		})
+	}
+
+	return c.backend.Set(ctx, c.prefix+key, data, c.ttl(ttl))
+}
+
+// Delete removes the keys from the cache
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	prefixed := make([]string, len(keys))
+	for i := range keys {
+		prefixed[i] = c.prefix + keys[i]
+	}
+
+	return c.backend.Delete(ctx, prefixed...)
+}
+
+// GetOrLoad decodes the cached value of the key into dest. If the key is not cached, it is loaded using load and
+// cached until ttl expires. Concurrent calls for the same key share a single load. If the backend fails, the error
+// is logged and the value is loaded without being cached so that the cache is never a single point of failure.
+func (c *Cache) GetOrLoad(ctx context.Context, key string, dest interface{}, ttl time.Duration, load LoadFunc) error {
+	ok, err := c.Get(ctx, key, dest)
+	if err != nil {
+		c.logger.WithTags(map[string]interface{}{
+			"key": key,
+		}).Warn("Failed to get cached value; loading it instead", err)
+	} else if ok {
+		return nil
+	}
+
+	data, err := c.flight.do(c.prefix+key, func() ([]byte, error) {
+		val, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(val)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to encode cached value", map[string]interface{}{
+				"key": key,
+			})
+		}
+
+		err = c.backend.Set(ctx, c.prefix+key, data, c.ttl(ttl))
+		if err != nil {
+			c.logger.WithTags(map[string]interface{}{
+				"key": key,
+			}).Warn("Failed to cache loaded value", err)
+		}
+
+		return data, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(data, dest)
+	if err != nil {
+		return cerrors.New(err, "failed to decode loaded value", map[string]interface{}{
+			"key": key,
+		})
+	}
+
+	return nil
+}
+
+func (c *Cache) ttl(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return c.defaultTTL
+	}
+
+	return ttl
+}
+
+// Get returns the cached value of the key and false if it is not cached
+func Get[T any](ctx context.Context, c *Cache, key string) (T, bool, error) {
+	var val T
+
+	ok, err := c.Get(ctx, key, &val)
+
+	return val, ok, err
+}
+
+// GetOrLoad returns the cached value of the key, or loads and caches it if it is not cached. See Cache.GetOrLoad.
+// For example:
+//
+//	user, err := ccache.GetOrLoad(ctx, cache, "users:"+id, time.Minute, func(ctx context.Context) (*User, error) {
+//		return queries.GetUser(ctx, id)
+//	})
+func GetOrLoad[T any](ctx context.Context, c *Cache, key string, ttl time.Duration,
+	load func(ctx context.Context) (T, error)) (T, error) {
+	var val T
+
+	err := c.GetOrLoad(ctx, key, &val, ttl, func(ctx context.Context) (interface{}, error) {
+		return load(ctx)
+	})
+
+	return val, err
+}
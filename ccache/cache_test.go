@@ -0,0 +1,177 @@
+package ccache_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/ccache"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+// envVarRedisURL is the Redis server used to test RedisBackend. The tests are skipped if it is not set.
+const envVarRedisURL = "CCACHE_TEST_REDIS_URL"
+
+type user struct {
+	ID   string
+	Name string
+}
+
+func TestCache_MemoryBackend(t *testing.T) {
+	t.Parallel()
+
+	testCache(t, ccache.NewMemoryBackend(100))
+}
+
+func TestCache_RedisBackend(t *testing.T) {
+	t.Parallel()
+
+	url := os.Getenv(envVarRedisURL)
+	if url == "" {
+		t.Skipf("ccache: set %s to run tests against redis", envVarRedisURL)
+	}
+
+	backend, err := ccache.NewRedisBackend(ccache.ConfigRedis{URL: url})
+	assert.NoError(t, err)
+
+	defer func() { _ = backend.Close() }()
+
+	testCache(t, backend)
+}
+
+func TestGetOrLoad_Stampede(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx   = context.Background()
+		cache = newCache(ccache.NewMemoryBackend(100))
+		loads int32
+		wg    sync.WaitGroup
+	)
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			u, err := ccache.GetOrLoad(ctx, cache, "users:1", time.Minute, func(ctx context.Context) (user, error) {
+				atomic.AddInt32(&loads, 1)
+				time.Sleep(50 * time.Millisecond)
+
+				return user{ID: "1", Name: "Ada"}, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "Ada", u.Name)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+}
+
+func TestGetOrLoad_BackendErr(t *testing.T) {
+	t.Parallel()
+
+	cache := newCache(failingBackend{})
+
+	u, err := ccache.GetOrLoad(context.Background(), cache, "users:1", time.Minute,
+		func(ctx context.Context) (user, error) {
+			return user{ID: "1"}, nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", u.ID)
+
+	_, err = ccache.GetOrLoad(context.Background(), cache, "users:2", time.Minute,
+		func(ctx context.Context) (user, error) {
+			return user{}, errors.New("user not found")
+		})
+	assert.EqualError(t, err, "user not found")
+}
+
+func TestMemoryBackend_Evict(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		backend = ccache.NewMemoryBackend(2)
+	)
+
+	assert.NoError(t, backend.Set(ctx, "a", []byte("1"), time.Minute))
+	assert.NoError(t, backend.Set(ctx, "b", []byte("2"), time.Minute))
+
+	_, ok, _ := backend.Get(ctx, "a")
+	assert.True(t, ok)
+
+	// b is the least recently used value
+	assert.NoError(t, backend.Set(ctx, "c", []byte("3"), time.Minute))
+	assert.Equal(t, 2, backend.Len())
+
+	_, ok, _ = backend.Get(ctx, "b")
+	assert.False(t, ok)
+
+	_, ok, _ = backend.Get(ctx, "a")
+	assert.True(t, ok)
+}
+
+func testCache(t *testing.T, backend ccache.Backend) {
+	t.Helper()
+
+	var (
+		ctx   = context.Background()
+		cache = newCache(backend)
+		key   = "users:" + time.Now().Format(time.RFC3339Nano)
+	)
+
+	_, ok, err := ccache.Get[user](ctx, cache, key)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, cache.Set(ctx, key, user{ID: "1", Name: "Ada"}, 0))
+
+	u, ok, err := ccache.Get[user](ctx, cache, key)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, user{ID: "1", Name: "Ada"}, u)
+
+	assert.NoError(t, cache.Delete(ctx, key))
+
+	_, ok, err = ccache.Get[user](ctx, cache, key)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, cache.Set(ctx, key, user{ID: "1"}, 20*time.Millisecond))
+	time.Sleep(50 * time.Millisecond)
+
+	_, ok, err = ccache.Get[user](ctx, cache, key)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func newCache(backend ccache.Backend) *ccache.Cache {
+	return ccache.NewCache(ccache.NewCacheParams{
+		Backend: backend,
+		Config:  ccache.Config{Prefix: "test:", DefaultTTL: time.Minute},
+		Logger:  clogger.NewNoop(),
+	})
+}
+
+type failingBackend struct{}
+
+func (failingBackend) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("backend is down")
+}
+
+func (failingBackend) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("backend is down")
+}
+
+func (failingBackend) Delete(context.Context, ...string) error {
+	return errors.New("backend is down")
+}
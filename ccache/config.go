@@ -0,0 +1,83 @@
+package ccache
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Backends supported by NewBackend
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+const (
+	defaultTTL              = 5 * time.Minute
+	defaultMemoryMaxEntries = 10000
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "ccache",
+		Description: "ccache configures the cache backend",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("ccache", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load ccache config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Backend:    BackendMemory,
+		DefaultTTL: defaultTTL,
+		Memory: ConfigMemory{
+			MaxEntries: defaultMemoryMaxEntries,
+		},
+	}
+}
+
+// Config configures the cache. For example:
+//
+//	[ccache]
+//	backend = "redis"
+//	prefix = "myapp:"
+//
+//	[ccache.redis]
+//	url = "redis://localhost:6379/0"
+type Config struct {
+	// Backend is where values are stored: memory (per instance) or redis (shared by the app's instances)
+	Backend string `toml:"backend" valid:"in(memory|redis)" doc:"memory or redis"`
+
+	// Prefix is prepended to every key
+	Prefix string `toml:"prefix" doc:"Prepended to every key"`
+
+	// DefaultTTL is how long values are cached when they are set with a TTL of 0
+	DefaultTTL time.Duration `toml:"default_ttl" doc:"How long values are cached when no TTL is given"`
+
+	Memory ConfigMemory `toml:"memory"`
+	Redis  ConfigRedis  `toml:"redis"`
+}
+
+// ConfigMemory configures the memory backend
+type ConfigMemory struct {
+	// MaxEntries is the max number of cached values. The least recently used value is evicted when it is reached.
+	MaxEntries int `toml:"max_entries"`
+}
+
+// ConfigRedis configures the Redis backend
+type ConfigRedis struct {
+	// URL of the Redis server (ex. redis://:password@localhost:6379/0, or rediss:// for TLS)
+	URL string `toml:"url"`
+}
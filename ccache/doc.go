@@ -0,0 +1,3 @@
+// Package ccache caches values in memory or in Redis. Values are encoded as JSON and expire after a TTL. Loads of
+// the same missing key are deduplicated so that an expired key does not cause a stampede on the underlying data.
+package ccache
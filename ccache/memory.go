@@ -0,0 +1,107 @@
+package ccache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// NewMemoryBackend creates a MemoryBackend that holds up to maxEntries values (unlimited if 0)
+func NewMemoryBackend(maxEntries int) *MemoryBackend {
+	return &MemoryBackend{
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// MemoryBackend is a Backend that stores values in the app's memory. When it is full, the least recently used value
+// is evicted.
+type MemoryBackend struct {
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key       string
+	val       []byte
+	expiresAt time.Time
+}
+
+// Get returns the value of the key and false if it does not exist or expired
+func (b *MemoryBackend) Get(_ context.Context, key string) ([]byte, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	el, ok := b.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := el.Value.(*memoryEntry) //nolint:forcetypeassert
+	if !b.now().Before(entry.expiresAt) {
+		b.remove(el)
+		return nil, false, nil
+	}
+
+	b.lru.MoveToFront(el)
+
+	return entry.val, true, nil
+}
+
+// Set stores the value of the key until ttl expires
+func (b *MemoryBackend) Set(_ context.Context, key string, val []byte, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry := &memoryEntry{key: key, val: val, expiresAt: b.now().Add(ttl)}
+
+	if el, ok := b.entries[key]; ok {
+		el.Value = entry
+		b.lru.MoveToFront(el)
+
+		return nil
+	}
+
+	b.entries[key] = b.lru.PushFront(entry)
+
+	for b.maxEntries > 0 && b.lru.Len() > b.maxEntries {
+		b.remove(b.lru.Back())
+	}
+
+	return nil
+}
+
+// Delete removes the keys
+func (b *MemoryBackend) Delete(_ context.Context, keys ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := b.entries[key]; ok {
+			b.remove(el)
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of values held, including the expired ones that were not evicted yet
+func (b *MemoryBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.lru.Len()
+}
+
+// remove must be called with the lock held
+func (b *MemoryBackend) remove(el *list.Element) {
+	delete(b.entries, el.Value.(*memoryEntry).key) //nolint:forcetypeassert
+	b.lru.Remove(el)
+}
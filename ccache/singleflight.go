@@ -0,0 +1,57 @@
+package ccache
+
+import (
+	"sync"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// singleflight deduplicates concurrent calls with the same key so that only the first one runs and the others wait
+// for its result
+type singleflight struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	wg  sync.WaitGroup
+	val []byte
+	err error
+}
+
+func (g *singleflight) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+
+		return c.val, c.err
+	}
+
+	c := new(flightCall)
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+
+		c.wg.Done()
+	}()
+
+	// waiters get an error if fn panics
+	c.err = cerrors.New(nil, "cache load panicked", map[string]interface{}{
+		"key": key,
+	})
+
+	c.val, c.err = fn()
+
+	return c.val, c.err
+}
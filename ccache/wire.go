@@ -0,0 +1,12 @@
+package ccache
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+	NewBackend,
+
+	NewCache,
+	wire.Struct(new(NewCacheParams), "*"),
+)
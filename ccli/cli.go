@@ -0,0 +1,179 @@
+package ccli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/gocopper/copper"
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+// DefaultCommand is the command that runs when the app is started without one so that `./app` keeps serving the app
+const DefaultCommand = "serve"
+
+// Command is a subcommand of the app (ex. `./app migrate`)
+type Command struct {
+	// Name is used to select the command on the command line
+	Name string
+
+	// Description is shown in the app's help
+	Description string
+
+	// Run runs the command with the args that follow its name and writes its output to w. It has the same signature
+	// as the RunCommand methods of other packages (ex. csql.Migrator), so they can be used as is.
+	Run func(ctx context.Context, args []string, w io.Writer) error
+
+	// Runners are started using copper.App.StartRunners, in the order of their startup stages, before Run is called.
+	// Run can be nil if the command only starts runners.
+	Runners []copper.Runner
+
+	// LongRunning commands (ex. serve and worker) start their work in Run and keep the app running until it receives
+	// SIGINT or SIGTERM. Other commands stop the app once Run returns.
+	LongRunning bool
+}
+
+// NewCLIParams holds the params needed for NewCLI
+type NewCLIParams struct {
+	App      *copper.App
+	Commands []Command
+}
+
+// NewCLI creates a CLI that runs one of the commands. The config and help commands are always available. For example,
+// an app can provide its commands using wire:
+//
+//	func NewCommands(server *chttp.Server, worker *cqueue.Worker, migrator *csql.Migrator) []ccli.Command {
+//		return []ccli.Command{
+//			ccli.ServeCommand(server),
+//			ccli.WorkerCommand(worker),
+//			ccli.MigrateCommand(migrator),
+//		}
+//	}
+//
+// and run the selected one in main:
+//
+//	cli, err := app.InitCLI(copper.New())
+//	...
+//	cli.Run()
+func NewCLI(p NewCLIParams) *CLI {
+	commands := make(map[string]Command, len(p.Commands)+2) //nolint:gomnd
+
+	for _, cmd := range p.Commands {
+		commands[cmd.Name] = cmd
+	}
+
+	cli := &CLI{
+		app:      p.App,
+		commands: commands,
+	}
+
+	commands["config"] = Command{
+		Name:        "config",
+		Description: "Prints the effective config (dump [-redacted]) or a sample config file (sample)",
+		Run: func(ctx context.Context, args []string, w io.Writer) error {
+			return cconfig.RunCommand(p.App.Config, args, w)
+		},
+	}
+
+	commands["help"] = Command{
+		Name:        "help",
+		Description: "Lists the app's commands",
+		Run: func(ctx context.Context, args []string, w io.Writer) error {
+			return cli.PrintHelp(w)
+		},
+	}
+
+	return cli
+}
+
+// CLI runs the app's commands
+type CLI struct {
+	app      *copper.App
+	commands map[string]Command
+}
+
+// Run runs the command selected by the command line's args (see flag.Args) and writes its output to stdout. If
+// the command is not long running, the app's lifecycle is stopped once it completes. If the command fails, the app
+// exits with exit code 1.
+func (c *CLI) Run() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
+	cmd, err := c.Execute(ctx, flag.Args(), os.Stdout)
+
+	stop()
+
+	if err != nil {
+		c.app.Logger.Error("Failed to run command", err)
+		c.app.Lifecycle.Stop(c.app.Logger)
+		os.Exit(1)
+	}
+
+	c.app.PrintDiagnostics()
+
+	if cmd.LongRunning {
+		c.app.Wait()
+		return
+	}
+
+	c.app.Lifecycle.Stop(c.app.Logger)
+}
+
+// Execute runs the command selected by args and returns it. It does not wait for long-running commands or stop the
+// app's lifecycle.
+func (c *CLI) Execute(ctx context.Context, args []string, w io.Writer) (Command, error) {
+	name := DefaultCommand
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	cmd, ok := c.commands[name]
+	if !ok {
+		_ = c.PrintHelp(w)
+
+		return Command{}, cerrors.New(nil, "unknown command", map[string]interface{}{
+			"command": name,
+		})
+	}
+
+	err := c.app.RunStep("command "+name, func() error {
+		err := c.app.StartRunners(cmd.Runners...)
+		if err != nil || cmd.Run == nil {
+			return err
+		}
+
+		return cmd.Run(ctx, args, w)
+	})
+	if err != nil {
+		return cmd, cerrors.New(err, "failed to run command", map[string]interface{}{
+			"command": name,
+		})
+	}
+
+	return cmd, nil
+}
+
+// PrintHelp writes the list of the app's commands to w
+func (c *CLI) PrintHelp(w io.Writer) error {
+	names := make([]string, 0, len(c.commands))
+	for name := range c.commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:gomnd
+	_, _ = fmt.Fprintf(tw, "Usage: %s [flags] <command> [args]\n\nCommands:\n", os.Args[0])
+
+	for _, name := range names {
+		_, _ = fmt.Fprintf(tw, "  %s\t%s\n", name, c.commands[name].Description)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,121 @@
+package ccli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/gocopper/copper"
+	"github.com/gocopper/copper/ccli"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/chttp/chttptest"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+type runnerFunc func() error
+
+func (fn runnerFunc) Run() error { return fn() }
+
+type commandRunner struct {
+	args []string
+}
+
+func (r *commandRunner) RunCommand(_ context.Context, args []string, _ io.Writer) error {
+	r.args = args
+	return nil
+}
+
+func newCLI(commands ...ccli.Command) *ccli.CLI {
+	return ccli.NewCLI(ccli.NewCLIParams{
+		App: &copper.App{
+			Lifecycle: clifecycle.New(),
+			Logger:    clogger.NewNoop(),
+		},
+		Commands: commands,
+	})
+}
+
+func TestCLI_Execute_DefaultCommand(t *testing.T) {
+	t.Parallel()
+
+	var served bool
+
+	cli := newCLI(ccli.ServeCommand(runnerFunc(func() error {
+		served = true
+		return nil
+	})))
+
+	cmd, err := cli.Execute(context.Background(), nil, io.Discard)
+	assert.NoError(t, err)
+	assert.True(t, served)
+	assert.Equal(t, "serve", cmd.Name)
+	assert.True(t, cmd.LongRunning)
+}
+
+func TestCLI_Execute_UnknownCommand(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+
+	cli := newCLI(ccli.WorkerCommand())
+
+	_, err := cli.Execute(context.Background(), []string{"foo"}, &out)
+	assert.Error(t, err)
+	assert.Contains(t, out.String(), "worker")
+	assert.Contains(t, out.String(), "help")
+}
+
+func TestCLI_Execute_Failure(t *testing.T) {
+	t.Parallel()
+
+	cli := newCLI(ccli.WorkerCommand(runnerFunc(func() error {
+		return errors.New("test-err")
+	})))
+
+	_, err := cli.Execute(context.Background(), []string{"worker"}, io.Discard)
+	assert.Error(t, err)
+}
+
+func TestMigrateCommand(t *testing.T) {
+	t.Parallel()
+
+	var (
+		migrator commandRunner
+		cli      = newCLI(ccli.MigrateCommand(&migrator))
+	)
+
+	cmd, err := cli.Execute(context.Background(), []string{"migrate"}, io.Discard)
+	assert.NoError(t, err)
+	assert.False(t, cmd.LongRunning)
+	assert.Equal(t, []string{"migrate"}, migrator.args)
+
+	_, err = cli.Execute(context.Background(), []string{"migrate", "rollback", "-steps", "2"}, io.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rollback", "-steps", "2"}, migrator.args)
+}
+
+func TestRoutesCommand(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+
+	cli := newCLI(ccli.RoutesCommand([]chttp.Router{
+		chttptest.NewRouter([]chttp.Route{
+			{Path: "/users", Methods: []string{http.MethodGet, http.MethodPost}},
+			{Path: "/", Methods: []string{http.MethodGet}},
+			{Path: "/static/{path:.*}"},
+		}),
+	}))
+
+	_, err := cli.Execute(context.Background(), []string{"routes"}, &out)
+	assert.NoError(t, err)
+	assert.Equal(t, "METHODS   PATH\n"+
+		"GET       /\n"+
+		"*         /static/{path:.*}\n"+
+		"GET,POST  /users\n", out.String())
+}
@@ -0,0 +1,97 @@
+package ccli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gocopper/copper"
+	"github.com/gocopper/copper/chttp"
+)
+
+// CommandRunner runs a command with args and writes its output to w. It is implemented by csql.Migrator and
+// csql.Seeder.
+type CommandRunner interface {
+	RunCommand(ctx context.Context, args []string, w io.Writer) error
+}
+
+// ServeCommand creates the serve command that starts the runners (ex. chttp.Server) and keeps the app running
+func ServeCommand(runners ...copper.Runner) Command {
+	return Command{
+		Name:        "serve",
+		Description: "Starts the app's servers",
+		Runners:     runners,
+		LongRunning: true,
+	}
+}
+
+// WorkerCommand creates the worker command that starts the runners (ex. cqueue.Worker) and keeps the app running.
+// It lets the app's background workers run in their own processes.
+func WorkerCommand(runners ...copper.Runner) Command {
+	return Command{
+		Name:        "worker",
+		Description: "Starts the app's background workers",
+		Runners:     runners,
+		LongRunning: true,
+	}
+}
+
+// MigrateCommand creates the migrate command that runs csql.Migrator's commands. Without args, it applies all
+// pending migrations (ex. `./app migrate`, `./app migrate rollback -steps 2`, `./app migrate status`).
+func MigrateCommand(migrator CommandRunner) Command {
+	return Command{
+		Name:        "migrate",
+		Description: "Runs the database migrations (migrate, rollback [-steps N], or status)",
+		Run: func(ctx context.Context, args []string, w io.Writer) error {
+			if len(args) == 0 {
+				args = []string{"migrate"}
+			}
+
+			return migrator.RunCommand(ctx, args, w)
+		},
+	}
+}
+
+// SeedCommand creates the seed command that runs csql.Seeder's seeds (ex. `./app seed -module cauth`)
+func SeedCommand(seeder CommandRunner) Command {
+	return Command{
+		Name:        "seed",
+		Description: "Seeds the database ([-module name])",
+		Run:         seeder.RunCommand,
+	}
+}
+
+// RoutesCommand creates the routes command that lists the HTTP routes of the routers
+func RoutesCommand(routers []chttp.Router) Command {
+	return Command{
+		Name:        "routes",
+		Description: "Lists the app's HTTP routes",
+		Run: func(ctx context.Context, args []string, w io.Writer) error {
+			var routes []chttp.Route
+			for _, router := range routers {
+				routes = append(routes, router.Routes()...)
+			}
+
+			sort.SliceStable(routes, func(i, j int) bool {
+				return routes[i].Path < routes[j].Path
+			})
+
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:gomnd
+			_, _ = fmt.Fprintln(tw, "METHODS\tPATH")
+
+			for _, route := range routes {
+				methods := "*"
+				if len(route.Methods) > 0 {
+					methods = strings.Join(route.Methods, ",")
+				}
+
+				_, _ = fmt.Fprintf(tw, "%s\t%s\n", methods, route.Path)
+			}
+
+			return tw.Flush()
+		},
+	}
+}
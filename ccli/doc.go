@@ -0,0 +1,4 @@
+// Package ccli lets an app binary expose subcommands (ex. `./app serve`, `./app migrate status`, `./app seed`) that
+// share the app's DI container and config instead of only booting the HTTP server. The app builds its commands using
+// google/wire and runs the one selected by the command line using CLI.
+package ccli
@@ -0,0 +1,9 @@
+package ccli
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	NewCLI,
+	wire.Struct(new(NewCLIParams), "*"),
+)
@@ -0,0 +1,293 @@
+package cconfig
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/internal/awssig"
+)
+
+// Environment variables used by NewEncryptedValueResolverFromEnv
+const (
+	EnvVarConfigKey          = "CONFIG_ENCRYPTION_KEY"
+	EnvVarConfigEncryptedKey = "CONFIG_ENCRYPTION_KEY_KMS"
+)
+
+const (
+	// SecretSchemeEnc is the scheme of encrypted config values. See EncryptedValueResolver.
+	SecretSchemeEnc = "enc"
+
+	encryptedValuePrefix  = SecretSchemeEnc + ":"
+	encryptedValueVersion = "v1:"
+	encryptionKeySize     = 32
+)
+
+// KeyProvider provides the key used to encrypt and decrypt config values
+type KeyProvider interface {
+	Key(ctx context.Context) ([]byte, error)
+}
+
+// GenerateEncryptionKey generates a random key that can be used with EncryptValue and set in CONFIG_ENCRYPTION_KEY
+func GenerateEncryptionKey() (string, error) {
+	key := make([]byte, encryptionKeySize)
+
+	_, err := io.ReadFull(rand.Reader, key)
+	if err != nil {
+		return "", cerrors.New(err, "failed to generate key", nil)
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// EncryptValue encrypts plaintext with the given 32-byte key using AES-256-GCM. The returned value has the form
+// enc:v1:<base64> and can be committed in config files.
+func EncryptValue(key []byte, plaintext string) (string, error) {
+	aead, err := newConfigAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return "", cerrors.New(err, "failed to generate nonce", nil)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return encryptedValuePrefix + encryptedValueVersion + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptValue decrypts a value created by EncryptValue. The enc: prefix is optional.
+func DecryptValue(key []byte, value string) (string, error) {
+	value = strings.TrimPrefix(value, encryptedValuePrefix)
+
+	if !strings.HasPrefix(value, encryptedValueVersion) {
+		return "", cerrors.New(nil, "unsupported encrypted value version", nil)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedValueVersion))
+	if err != nil {
+		return "", cerrors.New(err, "encrypted value is not valid base64", nil)
+	}
+
+	aead, err := newConfigAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return "", cerrors.New(nil, "encrypted value is too short", nil)
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", cerrors.New(err, "failed to decrypt value; is the key correct?", nil)
+	}
+
+	return string(plaintext), nil
+}
+
+func newConfigAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != encryptionKeySize {
+		return nil, cerrors.New(nil, "encryption key must be 32 bytes", map[string]interface{}{
+			"size": len(key),
+		})
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create cipher", nil)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create gcm cipher", nil)
+	}
+
+	return aead, nil
+}
+
+// NewEncryptedValueResolverFromEnv creates an EncryptedValueResolver that reads a base64 encoded key from
+// CONFIG_ENCRYPTION_KEY. If CONFIG_ENCRYPTION_KEY_KMS is set instead, it is treated as a data key encrypted with AWS KMS
+// and is decrypted using the standard AWS environment variables (see AWSKMSKeyProvider).
+func NewEncryptedValueResolverFromEnv() *EncryptedValueResolver {
+	var provider KeyProvider = &StaticKeyProvider{Base64Key: os.Getenv(EnvVarConfigKey)}
+
+	if encryptedKey := os.Getenv(EnvVarConfigEncryptedKey); encryptedKey != "" && os.Getenv(EnvVarConfigKey) == "" {
+		provider = &AWSKMSKeyProvider{
+			EncryptedKey:    encryptedKey,
+			Region:          awssig.RegionFromEnv(),
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+			Client:          &http.Client{Timeout: secretFetchTimeout},
+		}
+	}
+
+	return NewEncryptedValueResolver(provider)
+}
+
+// NewEncryptedValueResolver creates an EncryptedValueResolver that decrypts values using the key from provider. The
+// key is fetched the first time an encrypted value is resolved.
+func NewEncryptedValueResolver(provider KeyProvider) *EncryptedValueResolver {
+	return &EncryptedValueResolver{provider: provider}
+}
+
+// EncryptedValueResolver decrypts config values created by EncryptValue. An encrypted value can be used as an entire
+// config value or as a reference inside a string:
+//
+//	password = "enc:v1:3q2+7w..."
+//	dsn = "postgres://app:${enc:v1:3q2+7w...}@localhost/app"
+type EncryptedValueResolver struct {
+	provider KeyProvider
+
+	mu  sync.Mutex
+	key []byte
+}
+
+// Scheme returns "enc"
+func (r *EncryptedValueResolver) Scheme() string {
+	return SecretSchemeEnc
+}
+
+// Resolve decrypts the encrypted value
+func (r *EncryptedValueResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	key, err := r.getKey(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return DecryptValue(key, ref)
+}
+
+func (r *EncryptedValueResolver) getKey(ctx context.Context) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.key != nil {
+		return r.key, nil
+	}
+
+	key, err := r.provider.Key(ctx)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to get config encryption key", nil)
+	}
+
+	r.key = key
+
+	return key, nil
+}
+
+// StaticKeyProvider provides a base64 encoded key (ex. from an env var)
+type StaticKeyProvider struct {
+	Base64Key string
+}
+
+// Key decodes and returns the key
+func (p *StaticKeyProvider) Key(_ context.Context) ([]byte, error) {
+	if p.Base64Key == "" {
+		return nil, cerrors.New(nil, "CONFIG_ENCRYPTION_KEY or CONFIG_ENCRYPTION_KEY_KMS must be set to decrypt "+
+			"encrypted config values", nil)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(p.Base64Key)
+	if err != nil {
+		return nil, cerrors.New(err, "encryption key is not valid base64", nil)
+	}
+
+	return key, nil
+}
+
+// AWSKMSKeyProvider provides a data key that is stored encrypted with an AWS KMS key (envelope encryption). The data
+// key can be created using `aws kms generate-data-key --key-spec AES_256`; its CiphertextBlob is set as EncryptedKey
+// and its Plaintext is used with EncryptValue.
+type AWSKMSKeyProvider struct {
+	// EncryptedKey is the base64 encoded ciphertext blob of the data key
+	EncryptedKey string
+
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Endpoint overrides the regional endpoint (ex. for localstack)
+	Endpoint string
+
+	Client *http.Client
+}
+
+// Key decrypts the data key using KMS
+func (p *AWSKMSKeyProvider) Key(ctx context.Context) ([]byte, error) {
+	if p.Region == "" || p.AccessKeyID == "" || p.SecretAccessKey == "" {
+		return nil, cerrors.New(nil, "AWS_REGION, AWS_ACCESS_KEY_ID, and AWS_SECRET_ACCESS_KEY must be set to "+
+			"decrypt the config encryption key", nil)
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(p.EncryptedKey)
+	if err != nil {
+		return nil, cerrors.New(err, "encrypted key is not valid base64", nil)
+	}
+
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://kms.%s.amazonaws.com/", p.Region)
+	}
+
+	reqBody, err := json.Marshal(map[string][]byte{"CiphertextBlob": ciphertext})
+	if err != nil {
+		return nil, cerrors.New(err, "failed to encode request", nil)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, secretFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create kms request", nil)
+	}
+
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService.Decrypt")
+
+	awssig.Sign(req, reqBody, awssig.Credentials{
+		AccessKeyID:     p.AccessKeyID,
+		SecretAccessKey: p.SecretAccessKey,
+		SessionToken:    p.SessionToken,
+	}, p.Region, "kms", time.Now())
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	body, _, err := doSecretRequest(client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Plaintext []byte `json:"Plaintext"`
+	}
+
+	err = json.Unmarshal(body, &resp)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to decode kms response", nil)
+	}
+
+	return resp.Plaintext, nil
+}
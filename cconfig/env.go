@@ -0,0 +1,116 @@
+package cconfig
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/pelletier/go-toml"
+)
+
+// EnvName returns the name of the environment variable that overrides the config value at the given path. For
+// example, the env var for cauth.verification_expiry is CAUTH_VERIFICATION_EXPIRY.
+func EnvName(path ...string) string {
+	return strings.NewReplacer(".", "_", "-", "_").Replace(strings.ToUpper(strings.Join(path, "_")))
+}
+
+// applyEnvOverrides sets the values from environment variables for each field in dest on the given tree. The env var
+// names are derived from key and the fields' toml tags (see EnvName). Nested structs are supported.
+func applyEnvOverrides(tree *toml.Tree, key string, dest interface{}) error {
+	t := reflect.TypeOf(dest)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return applyEnvOverridesForStruct(tree, []string{key}, nil, t)
+}
+
+func applyEnvOverridesForStruct(tree *toml.Tree, envPath, treePath []string, t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("toml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		var (
+			fieldEnvPath  = append(append([]string{}, envPath...), name)
+			fieldTreePath = append(append([]string{}, treePath...), name)
+			fieldType     = field.Type
+		)
+
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() == reflect.Struct && fieldType != reflect.TypeOf(time.Time{}) {
+			err := applyEnvOverridesForStruct(tree, fieldEnvPath, fieldTreePath, fieldType)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		envName := EnvName(fieldEnvPath...)
+
+		raw, ok := os.LookupEnv(envName)
+		if !ok {
+			continue
+		}
+
+		val, err := parseEnvValue(raw, fieldType)
+		if err != nil {
+			return cerrors.New(err, "invalid value in env var", map[string]interface{}{
+				"env": envName,
+			})
+		}
+
+		tree.SetPath(fieldTreePath, val)
+	}
+
+	return nil
+}
+
+// parseEnvValue converts the env var value into a TOML value that can be unmarshalled into the given type. Strings
+// and durations are used as-is. Other values are parsed as TOML (ex. 10, true, ["a", "b"]). A []string may also be
+// set as a comma-separated list.
+func parseEnvValue(raw string, t reflect.Type) (interface{}, error) {
+	if t.Kind() == reflect.String || t == reflect.TypeOf(time.Duration(0)) {
+		return raw, nil
+	}
+
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(raw), "[") {
+		parts := strings.Split(raw, ",")
+		vals := make([]interface{}, 0, len(parts))
+
+		for _, p := range parts {
+			if p = strings.TrimSpace(p); p != "" {
+				vals = append(vals, p)
+			}
+		}
+
+		return vals, nil
+	}
+
+	valTree, err := toml.Load("v = " + raw)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to parse value as toml", nil)
+	}
+
+	return valTree.Get("v"), nil
+}
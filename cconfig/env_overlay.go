@@ -0,0 +1,106 @@
+package cconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/pelletier/go-toml"
+)
+
+// EnvVarAppEnv is the environment variable that selects the environment overlay (ex. production) when the config
+// path is a directory
+const EnvVarAppEnv = "APP_ENV"
+
+// Config layers loaded from a config directory. See loadLayeredTree.
+const (
+	baseConfigLayer  = "base"
+	localConfigLayer = "local"
+)
+
+// AppEnv returns the environment set in APP_ENV
+func AppEnv() string {
+	return os.Getenv(EnvVarAppEnv)
+}
+
+// loadLayeredTree loads the config files in dir and merges them in the following order (later files override
+// earlier ones):
+//  1. base.toml
+//  2. <APP_ENV>.toml (ex. production.toml)
+//  3. local.toml (developer-specific settings that should not be committed)
+//
+// Each file is optional but at least one of them must exist. Each file may also use the extends key. Each layer may
+// be written in YAML or JSON instead (ex. base.yaml), in which case the first existing file in the order .toml,
+// .yaml, .yml, .json is used.
+func loadLayeredTree(dir, env string, disableKeyOverrides bool) (*toml.Tree, error) {
+	if strings.ContainsAny(env, `/\`) || strings.HasPrefix(env, ".") {
+		return nil, cerrors.New(nil, "invalid app env", map[string]interface{}{
+			"env": env,
+		})
+	}
+
+	layers := []string{baseConfigLayer}
+	if env != "" {
+		layers = append(layers, env)
+	}
+
+	layers = append(layers, localConfigLayer)
+
+	var tree *toml.Tree
+
+	for _, layer := range layers {
+		fp, ok := findConfigLayerFile(dir, layer)
+		if !ok {
+			continue
+		}
+
+		layerTree, err := loadTree(fp, "", disableKeyOverrides)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to load config layer", map[string]interface{}{
+				"path": fp,
+			})
+		}
+
+		if tree == nil {
+			tree = layerTree
+			continue
+		}
+
+		// Overlays are meant to override values in the layers below them
+		tree, err = mergeTrees(tree, layerTree, false)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to merge config layer", map[string]interface{}{
+				"path": fp,
+			})
+		}
+	}
+
+	if tree == nil {
+		return nil, cerrors.New(nil, "no config files found in config dir", map[string]interface{}{
+			"dir":    dir,
+			"layers": layers,
+		})
+	}
+
+	return tree, nil
+}
+
+// findConfigLayerFile returns the path to the config file for the given layer name in dir
+func findConfigLayerFile(dir, layer string) (string, bool) {
+	for _, ext := range configFileExts {
+		fp := filepath.Join(dir, layer+ext)
+
+		if _, err := os.Stat(fp); err == nil {
+			return fp, true
+		}
+	}
+
+	return "", false
+}
+
+func isDir(fp string) bool {
+	info, err := os.Stat(fp)
+
+	return err == nil && info.IsDir()
+}
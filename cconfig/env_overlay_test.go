@@ -0,0 +1,87 @@
+package cconfig_test
+
+import (
+	"testing"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cconfig/cconfigtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew_ConfigDir(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir = cconfigtest.SetupDirWithConfigs(t, map[string]string{
+			"base.toml": `
+				[server]
+				host = "localhost"
+				port = 7501
+				debug = true
+			`,
+			"production.toml": `
+				extends = "secrets.toml"
+
+				[server]
+				host = "0.0.0.0"
+				debug = false
+			`,
+			"secrets.toml": `
+				[db]
+				password = "prod-password"
+			`,
+			"local.toml": `
+				[server]
+				port = 9000
+			`,
+		})
+
+		config struct {
+			Host  string `toml:"host"`
+			Port  int    `toml:"port"`
+			Debug bool   `toml:"debug"`
+		}
+	)
+
+	// APP_ENV is shared by the whole process so the environments are tested sequentially
+	setenv(t, cconfig.EnvVarAppEnv, "production")
+
+	loader, err := cconfig.NewWithKeyOverrides(cconfig.Path(dir), "server.port=8080")
+	assert.NoError(t, err)
+
+	assert.NoError(t, loader.Load("server", &config))
+	assert.Equal(t, "0.0.0.0", config.Host)
+	assert.Equal(t, 8080, config.Port)
+	assert.False(t, config.Debug)
+
+	var db struct {
+		Password string `toml:"password"`
+	}
+
+	assert.NoError(t, loader.Load("db", &db))
+	assert.Equal(t, "prod-password", db.Password)
+
+	setenv(t, cconfig.EnvVarAppEnv, "")
+
+	loader, err = cconfig.New(cconfig.Path(dir), "")
+	assert.NoError(t, err)
+
+	assert.NoError(t, loader.Load("server", &config))
+	assert.Equal(t, "localhost", config.Host)
+	assert.Equal(t, 9000, config.Port)
+	assert.True(t, config.Debug)
+
+	setenv(t, cconfig.EnvVarAppEnv, "../etc")
+
+	_, err = cconfig.New(cconfig.Path(dir), "")
+	assert.Error(t, err)
+}
+
+func TestNew_ConfigDir_Empty(t *testing.T) {
+	t.Parallel()
+
+	dir := cconfigtest.SetupDirWithConfigs(t, map[string]string{})
+
+	_, err := cconfig.New(cconfig.Path(dir), "")
+	assert.Error(t, err)
+}
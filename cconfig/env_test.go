@@ -0,0 +1,120 @@
+package cconfig_test
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cconfig/cconfigtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func setenv(t *testing.T, key, val string) {
+	t.Helper()
+
+	assert.NoError(t, os.Setenv(key, val))
+
+	t.Cleanup(func() {
+		assert.NoError(t, os.Unsetenv(key))
+	})
+}
+
+func TestEnvName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "CAUTH_VERIFICATION_EXPIRY", cconfig.EnvName("cauth", "verification_expiry"))
+	assert.Equal(t, "CLOGGER_SAMPLING_INTERVAL", cconfig.EnvName("clogger", "sampling", "interval"))
+}
+
+func TestLoader_Load_EnvOverrides(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir = cconfigtest.SetupDirWithConfigs(t, map[string]string{
+			"test.toml": `
+				[envtest]
+				name = "file"
+				port = 7501
+				timeout = "1s"
+				enabled = false
+
+				[envtest.nested]
+				key = "file"
+			`,
+		})
+		fp = cconfig.Path(path.Join(dir, "test.toml"))
+
+		config struct {
+			Name    string        `toml:"name"`
+			Port    int           `toml:"port"`
+			Timeout time.Duration `toml:"timeout"`
+			Enabled bool          `toml:"enabled"`
+			Hosts   []string      `toml:"hosts"`
+			Nested  struct {
+				Key string `toml:"key"`
+			} `toml:"nested"`
+		}
+	)
+
+	setenv(t, "ENVTEST_PORT", "8080")
+	setenv(t, "ENVTEST_TIMEOUT", "5m")
+	setenv(t, "ENVTEST_ENABLED", "true")
+	setenv(t, "ENVTEST_HOSTS", "a.com, b.com")
+	setenv(t, "ENVTEST_NESTED_KEY", "env")
+	setenv(t, "ENVTEST_NAME", "env")
+
+	loader, err := cconfig.NewWithKeyOverrides(fp, `envtest.name="override"`)
+	assert.NoError(t, err)
+
+	assert.NoError(t, loader.Load("envtest", &config))
+
+	assert.Equal(t, "override", config.Name)
+	assert.Equal(t, 8080, config.Port)
+	assert.Equal(t, 5*time.Minute, config.Timeout)
+	assert.True(t, config.Enabled)
+	assert.Equal(t, []string{"a.com", "b.com"}, config.Hosts)
+	assert.Equal(t, "env", config.Nested.Key)
+}
+
+func TestLoader_Load_EnvOverrides_MissingKey(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir = cconfigtest.SetupDirWithConfigs(t, map[string]string{"test.toml": ""})
+		fp  = cconfig.Path(path.Join(dir, "test.toml"))
+
+		config struct {
+			Port int `toml:"port"`
+		}
+	)
+
+	setenv(t, "ENVTESTMISSING_PORT", "9000")
+
+	loader, err := cconfig.New(fp, "")
+	assert.NoError(t, err)
+
+	assert.NoError(t, loader.Load("envtestmissing", &config))
+	assert.Equal(t, 9000, config.Port)
+}
+
+func TestLoader_Load_EnvOverrides_InvalidValue(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir = cconfigtest.SetupDirWithConfigs(t, map[string]string{"test.toml": ""})
+		fp  = cconfig.Path(path.Join(dir, "test.toml"))
+
+		config struct {
+			Port int `toml:"port"`
+		}
+	)
+
+	setenv(t, "ENVTESTINVALID_PORT", "not a number")
+
+	loader, err := cconfig.New(fp, "")
+	assert.NoError(t, err)
+
+	assert.Error(t, loader.Load("envtestinvalid", &config))
+}
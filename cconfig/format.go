@@ -0,0 +1,128 @@
+package cconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileExts are the supported config file extensions in the order they are looked up for a config layer
+var configFileExts = []string{".toml", ".yaml", ".yml", ".json"} //nolint:gochecknoglobals
+
+// Config formats supported by parseConfig
+const (
+	FormatTOML = "toml"
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// loadFile loads a TOML, YAML, or JSON config file into a tree. The format is detected using the file extension.
+// Files with an unknown extension are parsed as TOML.
+func loadFile(fp string) (*toml.Tree, error) {
+	ext := strings.ToLower(filepath.Ext(fp))
+	if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+		return toml.LoadFile(fp)
+	}
+
+	data, err := ioutil.ReadFile(fp)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to read config file", nil)
+	}
+
+	format := FormatYAML
+	if ext == ".json" {
+		format = FormatJSON
+	}
+
+	return parseConfig(data, format)
+}
+
+// parseConfig parses data in the given format (toml, yaml, or json) into a tree
+func parseConfig(data []byte, format string) (*toml.Tree, error) {
+	var (
+		m   map[string]interface{}
+		err error
+	)
+
+	switch format {
+	case FormatTOML:
+		return toml.LoadBytes(data)
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+
+		err = dec.Decode(&m)
+	case FormatYAML:
+		err = yaml.Unmarshal(data, &m)
+	default:
+		return nil, cerrors.New(nil, "unknown config format", map[string]interface{}{
+			"format": format,
+		})
+	}
+
+	if err != nil {
+		return nil, cerrors.New(err, "failed to parse config", map[string]interface{}{
+			"format": format,
+		})
+	}
+
+	if m == nil {
+		m = make(map[string]interface{})
+	}
+
+	normalized, err := normalizeConfigValue(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return toml.TreeFromMap(normalized.(map[string]interface{}))
+}
+
+// normalizeConfigValue converts decoded YAML and JSON values into values that can be stored in a TOML tree. JSON
+// numbers are converted to int64 or float64, and YAML maps with non-string keys are rejected.
+func normalizeConfigValue(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k := range val {
+			nv, err := normalizeConfigValue(val[k])
+			if err != nil {
+				return nil, cerrors.New(err, "invalid value", map[string]interface{}{
+					"key": k,
+				})
+			}
+
+			val[k] = nv
+		}
+
+		return val, nil
+	case map[interface{}]interface{}:
+		return nil, cerrors.New(nil, "config keys must be strings", nil)
+	case []interface{}:
+		for i := range val {
+			nv, err := normalizeConfigValue(val[i])
+			if err != nil {
+				return nil, err
+			}
+
+			val[i] = nv
+		}
+
+		return val, nil
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i, nil
+		}
+
+		return val.Float64()
+	case int:
+		return int64(val), nil
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,93 @@
+package cconfig_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cconfig/cconfigtest"
+	"github.com/stretchr/testify/assert"
+)
+
+type formatTestConfig struct {
+	Host    string        `toml:"host"`
+	Port    int           `toml:"port"`
+	Ratio   float64       `toml:"ratio"`
+	Debug   bool          `toml:"debug"`
+	Timeout time.Duration `toml:"timeout"`
+	Tags    []string      `toml:"tags"`
+	DB      struct {
+		Name string `toml:"name"`
+	} `toml:"db"`
+}
+
+func TestNew_YAML(t *testing.T) {
+	t.Parallel()
+
+	dir := cconfigtest.SetupDirWithConfigs(t, map[string]string{
+		"base.yml": "server:\n  host: localhost\n  port: 7501\n",
+		"prod.yaml": `extends: base.yml
+server:
+  ratio: 0.5
+  debug: true
+  timeout: 5s
+  tags: [a, b]
+  db:
+    name: app
+`,
+	})
+
+	loader, err := cconfig.New(cconfig.Path(filepath.Join(dir, "prod.yaml")), "")
+	assert.NoError(t, err)
+
+	var config formatTestConfig
+
+	assert.NoError(t, loader.Load("server", &config))
+	assert.Equal(t, "localhost", config.Host)
+	assert.Equal(t, 7501, config.Port)
+	assert.Equal(t, 0.5, config.Ratio)
+	assert.True(t, config.Debug)
+	assert.Equal(t, 5*time.Second, config.Timeout)
+	assert.Equal(t, []string{"a", "b"}, config.Tags)
+	assert.Equal(t, "app", config.DB.Name)
+}
+
+func TestNew_JSON(t *testing.T) {
+	t.Parallel()
+
+	dir := cconfigtest.SetupDirWithConfigs(t, map[string]string{
+		"base.toml": `
+			[server]
+			host = "localhost"
+		`,
+		"prod.json": `{
+			"extends": "base.toml",
+			"server": {"port": 7501, "ratio": 0.5, "tags": ["a", "b"], "db": {"name": "app"}}
+		}`,
+	})
+
+	loader, err := cconfig.New(cconfig.Path(filepath.Join(dir, "prod.json")), "server.debug=true")
+	assert.NoError(t, err)
+
+	var config formatTestConfig
+
+	assert.NoError(t, loader.Load("server", &config))
+	assert.Equal(t, "localhost", config.Host)
+	assert.Equal(t, 7501, config.Port)
+	assert.Equal(t, 0.5, config.Ratio)
+	assert.True(t, config.Debug)
+	assert.Equal(t, []string{"a", "b"}, config.Tags)
+	assert.Equal(t, "app", config.DB.Name)
+}
+
+func TestNew_InvalidYAML(t *testing.T) {
+	t.Parallel()
+
+	dir := cconfigtest.SetupDirWithConfigs(t, map[string]string{
+		"prod.yaml": "server: [unclosed\n",
+	})
+
+	_, err := cconfig.New(cconfig.Path(filepath.Join(dir, "prod.yaml")), "")
+	assert.Error(t, err)
+}
@@ -1,6 +1,9 @@
 package cconfig
 
 import (
+	"context"
+	"sync"
+
 	"github.com/gocopper/copper/cerrors"
 	"github.com/pelletier/go-toml"
 )
@@ -34,7 +37,22 @@ type Loader interface {
 	//
 	//   return config, nil
 	// }
+	//
+	// Each value can be overridden with an environment variable named after the key and the field's toml tag (ex.
+	// MY_CONFIG_KEY1). Environment variables take precedence over config files but not over Overrides.
+	//
+	// If dest has valid tags (see Schema), the loaded values are validated and a *ValidationError listing every
+	// invalid value is returned.
 	Load(key string, dest interface{}) error
+
+	// Watch calls fn each time the values under the given key change after the config is reloaded. fn must be a
+	// func(T) or func(T) error where T is the config struct (ex. func(clogger.Config) error). Errors returned by fn
+	// are returned by Reload.
+	Watch(key string, fn interface{}) error
+
+	// Reload re-reads the config files and notifies the watchers of the keys that changed. If the files are invalid,
+	// the current config is kept. See ReloadOnSIGHUP and ReloadOnFileChange.
+	Reload() error
 }
 
 // New provides an implementation of Loader that reads a config file at the given file path. It supports extending the
@@ -55,6 +73,26 @@ type Loader interface {
 //
 // If a config key is present in multiple files, New returns an error. For example, if prod.toml sets a value for 'key1'
 // that has already been set in base.toml, an error will be returned. To enable key overrides see NewWithKeyOverrides.
+//
+// If fp is a directory, the config is layered per environment. base.toml, <APP_ENV>.toml (ex. production.toml), and
+// local.toml are loaded from the directory in that order, and each file overrides the values of the files before it.
+// For example, with APP_ENV=production:
+//
+// # config/base.toml
+// [chttp]
+// port = 7501
+//
+// # config/production.toml
+// [chttp]
+// port = 80
+//
+// Loading the config dir sets chttp.port to 80. Overrides are applied on top of all the files.
+//
+// Config files may also be written in YAML (.yaml, .yml) or JSON (.json). The format is detected using the file
+// extension, and files in different formats can extend each other.
+//
+// String values may reference secrets (ex. "${vault:secret/db#password}") that are resolved when the config is
+// loaded. See SecretResolver.
 func New(fp Path, ov Overrides) (Loader, error) {
 	return newLoader(string(fp), string(ov), true)
 }
@@ -73,41 +111,170 @@ func NewWithKeyOverrides(fp Path, overrides Overrides) (Loader, error) {
 	return newLoader(string(fp), string(overrides), false)
 }
 
-func newLoader(fp, overrides string, disableKeyOverrides bool) (*loader, error) {
-	tree, err := loadTree(fp, overrides, disableKeyOverrides)
+func newLoader(fp, overrides string, disableKeyOverrides bool, resolvers ...SecretResolver) (*loader, error) {
+	l := &loader{
+		fp:                  fp,
+		overridesStr:        overrides,
+		disableKeyOverrides: disableKeyOverrides,
+		resolvers:           defaultResolversByScheme(resolvers...),
+	}
+
+	err := l.init()
 	if err != nil {
-		return nil, cerrors.New(err, "failed to load config tree", map[string]interface{}{
-			"path": fp,
-		})
+		return nil, err
 	}
 
-	return &loader{
-		tree: tree,
-	}, nil
+	return l, nil
+}
+
+// defaultResolversByScheme returns DefaultSecretResolvers and the given resolvers by their scheme. The given resolvers
+// replace the default resolvers with the same scheme.
+func defaultResolversByScheme(resolvers ...SecretResolver) map[string]SecretResolver {
+	resolversByScheme := make(map[string]SecretResolver)
+	for _, r := range append(DefaultSecretResolvers(), resolvers...) {
+		resolversByScheme[r.Scheme()] = r
+	}
+
+	return resolversByScheme
+}
+
+func (l *loader) init() error {
+	tree, overridesTree, err := l.read()
+	if err != nil {
+		return err
+	}
+
+	l.tree, l.overrides = tree, overridesTree
+
+	return nil
 }
 
 type loader struct {
-	tree *toml.Tree
+	fp                  string
+	overridesStr        string
+	disableKeyOverrides bool
+	resolvers           map[string]SecretResolver
+	remotes             []RemoteSource
+
+	// reloadMu serializes reloads (ex. a SIGHUP and a file change at the same time)
+	reloadMu sync.Mutex
+
+	mu        sync.RWMutex
+	tree      *toml.Tree
+	overrides *toml.Tree
+	watchers  []*watcher
 }
 
+// read loads the config files and overrides and resolves the secrets in them
+func (l *loader) read() (*toml.Tree, *toml.Tree, error) {
+	tree, err := loadTree(l.fp, l.overridesStr, l.disableKeyOverrides)
+	if err != nil {
+		return nil, nil, cerrors.New(err, "failed to load config tree", map[string]interface{}{
+			"path": l.fp,
+		})
+	}
+
+	tree, err = loadRemoteTrees(tree, l.remotes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = resolveSecrets(context.Background(), tree, l.resolvers)
+	if err != nil {
+		return nil, nil, cerrors.New(err, "failed to resolve secrets in config", map[string]interface{}{
+			"path": l.fp,
+		})
+	}
+
+	overridesTree, err := loadOverridesTree(l.overridesStr)
+	if err != nil {
+		return nil, nil, cerrors.New(err, "failed to load config overrides", nil)
+	}
+
+	err = resolveSecrets(context.Background(), overridesTree, l.resolvers)
+	if err != nil {
+		return nil, nil, cerrors.New(err, "failed to resolve secrets in config overrides", nil)
+	}
+
+	return tree, overridesTree, nil
+}
+
+// Load unmarshals the values under key into dest. Values are applied in the following order of precedence (highest
+// first):
+//  1. Overrides (ex. -set "chttp.port=8080")
+//  2. Environment variables named after the key and the dest fields' toml tags (ex. CHTTP_PORT). See EnvName.
+//  3. Remote sources (see NewWithRemoteSources)
+//  4. Config files
 func (l *loader) Load(key string, dest interface{}) error {
-	if !l.tree.Has(key) {
-		return nil
+	l.mu.RLock()
+	tree, overridesTree := l.tree, l.overrides
+	l.mu.RUnlock()
+
+	return l.loadFrom(tree, overridesTree, key, dest)
+}
+
+// loadFrom loads the key from the given config trees into dest
+func (l *loader) loadFrom(tree, overridesTree *toml.Tree, key string, dest interface{}) error {
+	keyTree, err := keyTreeOf(tree, key)
+	if err != nil {
+		return err
 	}
 
-	keyTree, ok := l.tree.Get(key).(*toml.Tree)
-	if !ok {
-		return cerrors.New(nil, "invalid key type", map[string]interface{}{
+	err = applyEnvOverrides(keyTree, key, dest)
+	if err != nil {
+		return cerrors.New(err, "failed to apply env overrides", map[string]interface{}{
+			"key": key,
+		})
+	}
+
+	// Secrets in the config files are resolved when the loader is created. Only references set using env vars are
+	// resolved here.
+	err = resolveSecrets(context.Background(), keyTree, l.resolvers)
+	if err != nil {
+		return cerrors.New(err, "failed to resolve secrets in env overrides", map[string]interface{}{
 			"key": key,
 		})
 	}
 
-	err := keyTree.Unmarshal(dest)
+	if overrides, ok := overridesTree.Get(key).(*toml.Tree); ok {
+		keyTree, err = mergeTrees(keyTree, overrides, false)
+		if err != nil {
+			return cerrors.New(err, "failed to apply overrides", map[string]interface{}{
+				"key": key,
+			})
+		}
+	}
+
+	err = keyTree.Unmarshal(dest)
 	if err != nil {
 		return cerrors.New(err, "failed to unmarshal config into dest", map[string]interface{}{
 			"key": key,
 		})
 	}
 
+	err = validateConfig(key, dest)
+	if err != nil {
+		return cerrors.New(err, "config does not match its schema", map[string]interface{}{
+			"key": key,
+		})
+	}
+
 	return nil
 }
+
+// keyTreeOf returns a copy of the tree at the given key so that env overrides can be applied without modifying the
+// loaded config. If the key does not exist, an empty tree is returned.
+func keyTreeOf(tree *toml.Tree, key string) (*toml.Tree, error) {
+	if !tree.Has(key) {
+		return toml.TreeFromMap(map[string]interface{}{})
+	}
+
+	keyTree, ok := tree.Get(key).(*toml.Tree)
+	if !ok {
+		return nil, cerrors.New(nil, "invalid key type", map[string]interface{}{
+			"key": key,
+		})
+	}
+
+	return toml.TreeFromMap(keyTree.ToMap())
+}
@@ -0,0 +1,554 @@
+package cconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/pelletier/go-toml"
+)
+
+const (
+	remoteFetchTimeout = 10 * time.Second
+	remoteCachePerms   = 0600
+)
+
+// RemoteSource fetches config from a remote store such as Consul KV, etcd, or an HTTP endpoint. See
+// NewWithRemoteSources.
+type RemoteSource interface {
+	// Name is used in error messages (ex. consul)
+	Name() string
+
+	// Fetch returns the current config. The returned tree must not be modified by the source after it is returned.
+	Fetch(ctx context.Context) (*toml.Tree, error)
+}
+
+// NewWithRemoteSources works the same way as NewWithKeyOverrides but also loads config from the given remote sources.
+// The remote config is applied on top of the config files, in order, so a value set in Consul overrides the same
+// value in base.toml. Environment variables and Overrides still take precedence over the remote config.
+//
+// The sources are fetched again on each Reload. Use ReloadEvery to poll them and NewCachedRemoteSource to keep
+// serving the last-known-good config when a source is unavailable.
+func NewWithRemoteSources(fp Path, overrides Overrides, sources []RemoteSource) (Loader, error) {
+	l := &loader{
+		fp:                  string(fp),
+		overridesStr:        string(overrides),
+		disableKeyOverrides: false,
+		resolvers:           defaultResolversByScheme(),
+		remotes:             sources,
+	}
+
+	err := l.init()
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// ReloadEvery reloads the config at the given interval. It is meant to be used with remote sources that do not
+// notify on changes. Reload errors are passed to onError. The returned func stops the polling.
+func ReloadEvery(loader Loader, interval time.Duration, onError func(err error)) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := loader.Reload()
+				if err != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+// loadRemoteTrees fetches the config from each source and merges them on top of tree
+func loadRemoteTrees(tree *toml.Tree, sources []RemoteSource) (*toml.Tree, error) {
+	for _, source := range sources {
+		ctx, cancel := context.WithTimeout(context.Background(), remoteFetchTimeout)
+		remoteTree, err := source.Fetch(ctx)
+		cancel()
+
+		if err != nil {
+			return nil, cerrors.New(err, "failed to fetch remote config", map[string]interface{}{
+				"source": source.Name(),
+			})
+		}
+
+		// The tree is copied since merging and resolving secrets modify it
+		remoteTree, err = toml.TreeFromMap(remoteTree.ToMap())
+		if err != nil {
+			return nil, cerrors.New(err, "failed to copy remote config", map[string]interface{}{
+				"source": source.Name(),
+			})
+		}
+
+		tree, err = mergeTrees(tree, remoteTree, false)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to merge remote config", map[string]interface{}{
+				"source": source.Name(),
+			})
+		}
+	}
+
+	return tree, nil
+}
+
+// NewCachedRemoteSource wraps source so that each successfully fetched config is saved as a snapshot at cachePath.
+// If the source fails, the last-known-good config is returned instead: first from memory and then from the snapshot,
+// which allows the app to start while the remote store is down.
+func NewCachedRemoteSource(source RemoteSource, cachePath string) RemoteSource {
+	return &cachedRemoteSource{
+		source:    source,
+		cachePath: cachePath,
+	}
+}
+
+type cachedRemoteSource struct {
+	source    RemoteSource
+	cachePath string
+
+	mu       sync.Mutex
+	lastGood *toml.Tree
+}
+
+func (s *cachedRemoteSource) Name() string {
+	return s.source.Name()
+}
+
+func (s *cachedRemoteSource) Fetch(ctx context.Context) (*toml.Tree, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tree, fetchErr := s.source.Fetch(ctx)
+	if fetchErr == nil {
+		s.lastGood = tree
+
+		err := s.saveSnapshot(tree)
+		if err != nil {
+			return nil, err
+		}
+
+		return tree, nil
+	}
+
+	if s.lastGood != nil {
+		return s.lastGood, nil
+	}
+
+	tree, err := toml.LoadFile(s.cachePath)
+	if err != nil {
+		return nil, cerrors.New(fetchErr, "failed to fetch remote config and no snapshot is available",
+			map[string]interface{}{
+				"cachePath": s.cachePath,
+			})
+	}
+
+	s.lastGood = tree
+
+	return tree, nil
+}
+
+func (s *cachedRemoteSource) saveSnapshot(tree *toml.Tree) error {
+	data, err := tree.ToTomlString()
+	if err != nil {
+		return cerrors.New(err, "failed to encode remote config snapshot", nil)
+	}
+
+	// The snapshot is written to a temp file and renamed so that a crash never leaves a partial snapshot
+	tmp, err := ioutil.TempFile(filepath.Dir(s.cachePath), filepath.Base(s.cachePath)+".tmp")
+	if err != nil {
+		return cerrors.New(err, "failed to create remote config snapshot", map[string]interface{}{
+			"cachePath": s.cachePath,
+		})
+	}
+
+	_, err = tmp.WriteString(data)
+	if err == nil {
+		err = tmp.Chmod(remoteCachePerms)
+	}
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.cachePath)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return cerrors.New(err, "failed to save remote config snapshot", map[string]interface{}{
+			"cachePath": s.cachePath,
+		})
+	}
+
+	return nil
+}
+
+// NewConsulSourceFromEnv creates a ConsulSource for the given prefix using CONSUL_HTTP_ADDR and CONSUL_HTTP_TOKEN
+func NewConsulSourceFromEnv(prefix string) *ConsulSource {
+	addr := os.Getenv("CONSUL_HTTP_ADDR")
+	if addr != "" && !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+
+	return &ConsulSource{
+		Address: addr,
+		Prefix:  prefix,
+		Token:   os.Getenv("CONSUL_HTTP_TOKEN"),
+		Client:  &http.Client{Timeout: remoteFetchTimeout},
+	}
+}
+
+// ConsulSource loads config from the keys under Prefix in Consul's KV store. Each key is mapped to a config path by
+// its segments. For example, with the prefix "myapp", the key myapp/chttp/port=8080 sets chttp.port to 8080. Values
+// are parsed as TOML values (ex. 8080, true, ["a", "b"]) and are used as strings if they are not valid TOML.
+type ConsulSource struct {
+	// Address is the Consul HTTP API address (ex. http://localhost:8500)
+	Address string
+	Prefix  string
+	Token   string
+
+	Client *http.Client
+}
+
+// Name returns "consul"
+func (s *ConsulSource) Name() string {
+	return "consul"
+}
+
+// Fetch reads all keys under the prefix
+func (s *ConsulSource) Fetch(ctx context.Context) (*toml.Tree, error) {
+	if s.Address == "" {
+		return nil, cerrors.New(nil, "consul address is not set", nil)
+	}
+
+	url := strings.TrimSuffix(s.Address, "/") + "/v1/kv/" + strings.TrimPrefix(s.Prefix, "/") + "?recurse=true"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create consul request", nil)
+	}
+
+	if s.Token != "" {
+		req.Header.Set("X-Consul-Token", s.Token)
+	}
+
+	body, statusCode, err := doRemoteRequest(s.Client, req)
+	if statusCode == http.StatusNotFound {
+		return kvToTree(s.Prefix, nil)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var pairs []struct {
+		Key   string `json:"Key"`
+		Value []byte `json:"Value"`
+	}
+
+	err = json.Unmarshal(body, &pairs)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to decode consul response", nil)
+	}
+
+	kvs := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		kvs[p.Key] = string(p.Value)
+	}
+
+	return kvToTree(s.Prefix, kvs)
+}
+
+// EtcdSource loads config from the keys under Prefix in etcd using the v3 JSON gateway. Keys are mapped to config
+// paths the same way as ConsulSource (ex. /myapp/chttp/port=8080 sets chttp.port with the prefix /myapp).
+type EtcdSource struct {
+	// Endpoint is the etcd client URL (ex. http://localhost:2379)
+	Endpoint string
+	Prefix   string
+
+	// Username and Password are used to authenticate if set
+	Username string
+	Password string
+
+	Client *http.Client
+}
+
+// Name returns "etcd"
+func (s *EtcdSource) Name() string {
+	return "etcd"
+}
+
+// Fetch reads all keys under the prefix
+func (s *EtcdSource) Fetch(ctx context.Context) (*toml.Tree, error) {
+	if s.Endpoint == "" {
+		return nil, cerrors.New(nil, "etcd endpoint is not set", nil)
+	}
+
+	var token string
+
+	if s.Username != "" {
+		var resp struct {
+			Token string `json:"token"`
+		}
+
+		err := s.post(ctx, "/v3/auth/authenticate", "", map[string]string{
+			"name":     s.Username,
+			"password": s.Password,
+		}, &resp)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to authenticate with etcd", nil)
+		}
+
+		token = resp.Token
+	}
+
+	var resp struct {
+		KVs []struct {
+			Key   []byte `json:"key"`
+			Value []byte `json:"value"`
+		} `json:"kvs"`
+	}
+
+	err := s.post(ctx, "/v3/kv/range", token, map[string]string{
+		"key":       base64.StdEncoding.EncodeToString([]byte(s.Prefix)),
+		"range_end": base64.StdEncoding.EncodeToString(prefixRangeEnd([]byte(s.Prefix))),
+	}, &resp)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to read keys from etcd", nil)
+	}
+
+	kvs := make(map[string]string, len(resp.KVs))
+	for _, kv := range resp.KVs {
+		kvs[string(kv.Key)] = string(kv.Value)
+	}
+
+	return kvToTree(s.Prefix, kvs)
+}
+
+func (s *EtcdSource) post(ctx context.Context, path, token string, body, dest interface{}) error {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return cerrors.New(err, "failed to encode request", nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(s.Endpoint, "/")+path,
+		bytes.NewReader(reqBody))
+	if err != nil {
+		return cerrors.New(err, "failed to create etcd request", nil)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	respBody, _, err := doRemoteRequest(s.Client, req)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(respBody, dest)
+	if err != nil {
+		return cerrors.New(err, "failed to decode etcd response", nil)
+	}
+
+	return nil
+}
+
+// prefixRangeEnd returns the end of the etcd key range that contains all keys with the given prefix
+func prefixRangeEnd(prefix []byte) []byte {
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+
+	// The prefix is all 0xff bytes, so the range ends at the last key
+	return []byte{0}
+}
+
+// HTTPSource loads a config document from an HTTP endpoint (ex. a config service or an object in a bucket). The
+// document may be TOML, YAML, or JSON. If Format is not set, it is detected using the response's Content-Type and then
+// the URL's extension, and defaults to TOML.
+type HTTPSource struct {
+	URL     string
+	Headers map[string]string
+	Format  string
+
+	Client *http.Client
+}
+
+// Name returns "http"
+func (s *HTTPSource) Name() string {
+	return "http"
+}
+
+// Fetch downloads and parses the config document
+func (s *HTTPSource) Fetch(ctx context.Context) (*toml.Tree, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create config request", nil)
+	}
+
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: remoteFetchTimeout}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to send request", map[string]interface{}{
+			"url": s.URL,
+		})
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to read response", nil)
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, cerrors.New(nil, "unexpected response status", map[string]interface{}{
+			"url":        s.URL,
+			"statusCode": resp.StatusCode,
+		})
+	}
+
+	return parseConfig(body, s.format(resp.Header.Get("Content-Type")))
+}
+
+func (s *HTTPSource) format(contentType string) string {
+	if s.Format != "" {
+		return s.Format
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case strings.HasSuffix(mediaType, "json"):
+		return FormatJSON
+	case strings.HasSuffix(mediaType, "yaml"):
+		return FormatYAML
+	}
+
+	switch strings.ToLower(filepath.Ext(strings.Split(s.URL, "?")[0])) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	default:
+		return FormatTOML
+	}
+}
+
+func doRemoteRequest(client *http.Client, req *http.Request) ([]byte, int, error) {
+	if client == nil {
+		client = &http.Client{Timeout: remoteFetchTimeout}
+	}
+
+	return doSecretRequest(client, req)
+}
+
+// kvToTree converts flat keys (ex. myapp/chttp/port) under the given prefix into a tree
+func kvToTree(prefix string, kvs map[string]string) (*toml.Tree, error) {
+	tree, err := toml.TreeFromMap(map[string]interface{}{})
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create tree", nil)
+	}
+
+	keys := make([]string, 0, len(kvs))
+	for k := range kvs {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	prefix = strings.Trim(prefix, "/")
+
+	for _, k := range keys {
+		rel := strings.TrimPrefix(k, "/")
+
+		if prefix != "" {
+			if !strings.HasPrefix(rel, prefix+"/") {
+				continue
+			}
+
+			rel = strings.TrimPrefix(rel, prefix+"/")
+		}
+
+		// Keys ending with a / are folders in Consul
+		if rel == "" || strings.HasSuffix(rel, "/") {
+			continue
+		}
+
+		path := strings.Split(rel, "/")
+
+		for i := 1; i < len(path); i++ {
+			if v := tree.GetPath(path[:i]); v != nil {
+				if _, ok := v.(*toml.Tree); !ok {
+					return nil, cerrors.New(nil, "remote key is nested under a value", map[string]interface{}{
+						"key": k,
+					})
+				}
+			}
+		}
+
+		if _, ok := tree.GetPath(path).(*toml.Tree); ok {
+			return nil, cerrors.New(nil, "remote key has both a value and nested keys", map[string]interface{}{
+				"key": k,
+			})
+		}
+
+		tree.SetPath(path, parseRemoteValue(kvs[k]))
+	}
+
+	return tree, nil
+}
+
+// parseRemoteValue parses a TOML value (ex. 8080 or ["a", "b"]). If raw is not a valid TOML value, it is returned as a
+// string.
+func parseRemoteValue(raw string) interface{} {
+	if strings.ContainsAny(raw, "\r\n") {
+		return raw
+	}
+
+	t, err := toml.Load("v = " + raw)
+	if err != nil {
+		return raw
+	}
+
+	return t.Get("v")
+}
@@ -0,0 +1,175 @@
+package cconfig_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cconfig/cconfigtest"
+	"github.com/stretchr/testify/assert"
+)
+
+type remoteTestConfig struct {
+	Host  string   `toml:"host"`
+	Port  int      `toml:"port"`
+	Debug bool     `toml:"debug"`
+	Tags  []string `toml:"tags"`
+}
+
+func TestNewWithRemoteSources_Consul(t *testing.T) {
+	t.Parallel()
+
+	var port atomic.Value
+
+	port.Store("8080")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/kv/myapp", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("recurse"))
+		assert.Equal(t, "token", r.Header.Get("X-Consul-Token"))
+
+		_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"Key": "myapp/", "Value": nil},
+			{"Key": "myapp/server/port", "Value": []byte(port.Load().(string))},
+			{"Key": "myapp/server/tags", "Value": []byte(`["a", "b"]`)},
+			{"Key": "myapp/server/host", "Value": []byte("remote host")},
+			{"Key": "myapp2/server/debug", "Value": []byte("true")},
+		})
+	}))
+	defer server.Close()
+
+	var (
+		dir = cconfigtest.SetupDirWithConfigs(t, map[string]string{
+			"test.toml": `
+				[server]
+				host = "localhost"
+				port = 7501
+			`,
+		})
+		source = &cconfig.ConsulSource{
+			Address: server.URL,
+			Prefix:  "myapp",
+			Token:   "token",
+			Client:  server.Client(),
+		}
+		config remoteTestConfig
+	)
+
+	loader, err := cconfig.NewWithRemoteSources(cconfig.Path(path.Join(dir, "test.toml")), "server.debug=false",
+		[]cconfig.RemoteSource{source})
+	assert.NoError(t, err)
+
+	assert.NoError(t, loader.Load("server", &config))
+	assert.Equal(t, remoteTestConfig{
+		Host: "remote host",
+		Port: 8080,
+		Tags: []string{"a", "b"},
+	}, config)
+
+	port.Store("9090")
+
+	assert.NoError(t, loader.Reload())
+	assert.NoError(t, loader.Load("server", &config))
+	assert.Equal(t, 9090, config.Port)
+}
+
+func TestEtcdSource(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/auth/authenticate":
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": "etcd-token"})
+		case "/v3/kv/range":
+			assert.Equal(t, "etcd-token", r.Header.Get("Authorization"))
+
+			var body struct {
+				Key      []byte `json:"key"`
+				RangeEnd []byte `json:"range_end"`
+			}
+
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "/myapp", string(body.Key))
+			assert.Equal(t, "/myapq", string(body.RangeEnd))
+
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"kvs": []map[string]string{
+					{
+						"key":   base64.StdEncoding.EncodeToString([]byte("/myapp/server/port")),
+						"value": base64.StdEncoding.EncodeToString([]byte("8080")),
+					},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	source := &cconfig.EtcdSource{
+		Endpoint: server.URL,
+		Prefix:   "/myapp",
+		Username: "root",
+		Password: "pass",
+		Client:   server.Client(),
+	}
+
+	tree, err := source.Fetch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8080), tree.GetPath([]string{"server", "port"}))
+}
+
+func TestNewCachedRemoteSource(t *testing.T) {
+	t.Parallel()
+
+	var down int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&down) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write([]byte("server:\n  port: 8080\n"))
+	}))
+	defer server.Close()
+
+	var (
+		dir = cconfigtest.SetupDirWithConfigs(t, map[string]string{
+			"test.toml": "",
+		})
+		fp        = cconfig.Path(path.Join(dir, "test.toml"))
+		cachePath = path.Join(dir, "remote.cache.toml")
+		newSource = func() cconfig.RemoteSource {
+			return cconfig.NewCachedRemoteSource(&cconfig.HTTPSource{URL: server.URL}, cachePath)
+		}
+		config remoteTestConfig
+	)
+
+	loader, err := cconfig.NewWithRemoteSources(fp, "", []cconfig.RemoteSource{newSource()})
+	assert.NoError(t, err)
+
+	atomic.StoreInt32(&down, 1)
+
+	// The last-known-good config is kept in memory
+	assert.NoError(t, loader.Reload())
+	assert.NoError(t, loader.Load("server", &config))
+	assert.Equal(t, 8080, config.Port)
+
+	// A new process falls back to the snapshot
+	loader, err = cconfig.NewWithRemoteSources(fp, "", []cconfig.RemoteSource{newSource()})
+	assert.NoError(t, err)
+	assert.NoError(t, loader.Load("server", &config))
+	assert.Equal(t, 8080, config.Port)
+
+	// Without a snapshot, the error is returned
+	_, err = cconfig.NewWithRemoteSources(fp, "", []cconfig.RemoteSource{&cconfig.HTTPSource{URL: server.URL}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,157 @@
+package cconfig
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// Schema declares the config struct for a key. The struct's valid tags (see github.com/asaskevich/govalidator) define
+// the constraints on each value. For example:
+//
+//	type Config struct {
+//	  DSN     string `toml:"dsn" valid:"required"`
+//	  Port    int    `toml:"port" valid:"range(1|65535)"`
+//	  Dialect string `toml:"dialect" valid:"in(postgres|mysql|sqlite3)"`
+//	}
+//
+//	cconfig.Schema{Key: "my_config", Config: Config{}}
+type Schema struct {
+	Key    string
+	Config interface{}
+}
+
+// ValidationIssue describes a single invalid or missing config value
+type ValidationIssue struct {
+	// Path is the full path to the value (ex. chttp.port)
+	Path    string
+	Message string
+}
+
+// ValidationError is returned when config values do not satisfy their schema. It lists every issue so that all of
+// them can be fixed at once.
+type ValidationError struct {
+	Issues []ValidationIssue
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+
+	b.WriteString("invalid config:")
+
+	for _, issue := range e.Issues {
+		b.WriteString(fmt.Sprintf("\n  - %s: %s", issue.Path, issue.Message))
+	}
+
+	return b.String()
+}
+
+// ValidateSchemas loads each schema's key from the loader and validates it. Unlike Load, which fails on the first
+// invalid key, it returns a single *ValidationError with the issues for all keys. Apps can call it at boot with the
+// schemas of all the modules they use to get a consolidated report of invalid configuration.
+func ValidateSchemas(loader Loader, schemas ...Schema) error {
+	var issues []ValidationIssue
+
+	for _, schema := range schemas {
+		dest := reflect.New(reflect.TypeOf(schema.Config))
+
+		err := loader.Load(schema.Key, dest.Interface())
+		if err == nil {
+			continue
+		}
+
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			issues = append(issues, validationErr.Issues...)
+			continue
+		}
+
+		issues = append(issues, ValidationIssue{Path: schema.Key, Message: err.Error()})
+	}
+
+	if len(issues) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Issues: issues}
+}
+
+// validateConfig validates dest using its valid tags. The returned issues use the toml names of the fields.
+func validateConfig(key string, dest interface{}) error {
+	t := reflect.TypeOf(dest)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	ok, err := govalidator.ValidateStruct(dest)
+	if ok || err == nil {
+		return nil
+	}
+
+	var issues []ValidationIssue
+
+	for _, e := range flattenValidatorErrors(err) {
+		issue := ValidationIssue{Path: key, Message: e.Error()}
+
+		if vErr, ok := e.(govalidator.Error); ok {
+			issue.Path = strings.Join(append([]string{key}, tomlPath(t, append(vErr.Path, vErr.Name))...), ".")
+			issue.Message = vErr.Err.Error()
+		}
+
+		issues = append(issues, issue)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Path < issues[j].Path })
+
+	return &ValidationError{Issues: issues}
+}
+
+func flattenValidatorErrors(err error) []error {
+	errs, ok := err.(govalidator.Errors) //nolint:errorlint
+	if !ok {
+		return []error{err}
+	}
+
+	var flat []error
+
+	for _, e := range errs.Errors() {
+		flat = append(flat, flattenValidatorErrors(e)...)
+	}
+
+	return flat
+}
+
+// tomlPath maps a path of Go field names to the corresponding toml keys
+func tomlPath(t reflect.Type, fieldNames []string) []string {
+	path := make([]string, 0, len(fieldNames))
+
+	for _, name := range fieldNames {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		if t.Kind() != reflect.Struct {
+			path = append(path, name)
+			continue
+		}
+
+		field, ok := t.FieldByName(name)
+		if !ok {
+			path = append(path, name)
+			continue
+		}
+
+		path = append(path, tomlFieldName(field))
+		t = field.Type
+	}
+
+	return path
+}
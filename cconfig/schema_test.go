@@ -0,0 +1,87 @@
+package cconfig_test
+
+import (
+	"errors"
+	"path"
+	"testing"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cconfig/cconfigtest"
+	"github.com/stretchr/testify/assert"
+)
+
+type testServerConfig struct {
+	Port   int    `toml:"port" valid:"range(1|65535)"`
+	Format string `toml:"format" valid:"in(plain|json)"`
+	DB     struct {
+		DSN string `toml:"dsn" valid:"required"`
+	} `toml:"db"`
+}
+
+type testMailerConfig struct {
+	From string `toml:"from" valid:"required,email"`
+}
+
+func TestLoader_Load_Schema(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir = cconfigtest.SetupDirWithConfigs(t, map[string]string{
+			"test.toml": `
+				[server]
+				port = 70000
+				format = "xml"
+			`,
+		})
+		fp     = cconfig.Path(path.Join(dir, "test.toml"))
+		config testServerConfig
+	)
+
+	loader, err := cconfig.New(fp, "")
+	assert.NoError(t, err)
+
+	err = loader.Load("server", &config)
+
+	var validationErr *cconfig.ValidationError
+
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []cconfig.ValidationIssue{
+		{Path: "server.db.dsn", Message: "non zero value required"},
+		{Path: "server.format", Message: "xml does not validate as in(plain|json)"},
+		{Path: "server.port", Message: "70000 does not validate as range(1|65535)"},
+	}, validationErr.Issues)
+}
+
+func TestValidateSchemas(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir = cconfigtest.SetupDirWithConfigs(t, map[string]string{
+			"test.toml": `
+				[server]
+				port = 8080
+				format = "json"
+
+				[mailer]
+				from = "not-an-email"
+			`,
+		})
+		fp = cconfig.Path(path.Join(dir, "test.toml"))
+	)
+
+	loader, err := cconfig.New(fp, "")
+	assert.NoError(t, err)
+
+	err = cconfig.ValidateSchemas(loader,
+		cconfig.Schema{Key: "server", Config: testServerConfig{}},
+		cconfig.Schema{Key: "mailer", Config: testMailerConfig{}},
+	)
+
+	var validationErr *cconfig.ValidationError
+
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Len(t, validationErr.Issues, 2)
+	assert.Equal(t, "server.db.dsn", validationErr.Issues[0].Path)
+	assert.Equal(t, "mailer.from", validationErr.Issues[1].Path)
+	assert.Contains(t, err.Error(), "invalid config:\n  - server.db.dsn: non zero value required")
+}
@@ -0,0 +1,538 @@
+package cconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/internal/awssig"
+	"github.com/pelletier/go-toml"
+)
+
+// Schemes of the secret resolvers provided by cconfig
+const (
+	SecretSchemeEnv    = "env"
+	SecretSchemeVault  = "vault"
+	SecretSchemeAWSSM  = "aws-sm"
+	SecretSchemeGCPSM  = "gcp-sm"
+	secretFetchTimeout = 10 * time.Second
+)
+
+// secretRefRegexp matches secret references of the form ${scheme:ref}
+var secretRefRegexp = regexp.MustCompile(`\$\{([a-z0-9-]+):([^}]+)\}`) //nolint:gochecknoglobals
+
+// SecretResolver resolves references to secrets in config values. A reference has the form ${scheme:ref} where the
+// format of ref depends on the resolver. For example:
+//
+//	password = "${vault:secret/db#password}"
+//	api_key = "${aws-sm:prod/stripe#api_key}"
+//	token = "${gcp-sm:github-token}"
+//	dsn = "postgres://app:${env:DB_PASSWORD}@localhost/app"
+//	smtp_password = "enc:v1:3q2+7w..."
+//
+// References are resolved once when the config is loaded.
+type SecretResolver interface {
+	Scheme() string
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// DefaultSecretResolvers returns the env, enc, vault, aws-sm, and gcp-sm resolvers configured using their standard
+// environment variables (see NewEncryptedValueResolverFromEnv, NewVaultResolverFromEnv,
+// NewAWSSecretsManagerResolverFromEnv, and NewGCPSecretManagerResolverFromEnv).
+func DefaultSecretResolvers() []SecretResolver {
+	return []SecretResolver{
+		&EnvResolver{},
+		NewEncryptedValueResolverFromEnv(),
+		NewVaultResolverFromEnv(),
+		NewAWSSecretsManagerResolverFromEnv(),
+		NewGCPSecretManagerResolverFromEnv(),
+	}
+}
+
+// NewWithSecretResolvers works the same way as NewWithKeyOverrides but resolves secret references using the given
+// resolvers in addition to DefaultSecretResolvers. A resolver replaces the default resolver with the same scheme.
+func NewWithSecretResolvers(fp Path, overrides Overrides, resolvers []SecretResolver) (Loader, error) {
+	return newLoader(string(fp), string(overrides), false, resolvers...)
+}
+
+// resolveSecrets replaces the secret references in all string values in the tree
+func resolveSecrets(ctx context.Context, tree *toml.Tree, resolvers map[string]SecretResolver) error {
+	for _, key := range tree.Keys() {
+		val, err := resolveSecretsInValue(ctx, tree.Get(key), resolvers)
+		if err != nil {
+			return cerrors.New(err, "failed to resolve secret", map[string]interface{}{
+				"key": key,
+			})
+		}
+
+		tree.Set(key, val)
+	}
+
+	return nil
+}
+
+func resolveSecretsInValue(ctx context.Context, val interface{}, resolvers map[string]SecretResolver) (interface{}, error) {
+	switch v := val.(type) {
+	case *toml.Tree:
+		return v, resolveSecrets(ctx, v, resolvers)
+	case []*toml.Tree:
+		for i := range v {
+			err := resolveSecrets(ctx, v[i], resolvers)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return v, nil
+	case []interface{}:
+		for i := range v {
+			resolved, err := resolveSecretsInValue(ctx, v[i], resolvers)
+			if err != nil {
+				return nil, err
+			}
+
+			v[i] = resolved
+		}
+
+		return v, nil
+	case string:
+		return resolveSecretsInString(ctx, v, resolvers)
+	default:
+		return val, nil
+	}
+}
+
+func resolveSecretsInString(ctx context.Context, s string, resolvers map[string]SecretResolver) (string, error) {
+	// Encrypted values may be used as-is without the ${...} wrapper
+	if strings.HasPrefix(s, encryptedValuePrefix) {
+		return resolveSecretsInString(ctx, "${"+s+"}", resolvers)
+	}
+
+	var resolveErr error
+
+	resolved := secretRefRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		if resolveErr != nil {
+			return match
+		}
+
+		var (
+			parts     = secretRefRegexp.FindStringSubmatch(match)
+			scheme    = parts[1]
+			ref       = parts[2]
+			resolver  = resolvers[scheme]
+			secretVal string
+		)
+
+		if resolver == nil {
+			resolveErr = cerrors.New(nil, "unknown secret scheme", map[string]interface{}{
+				"scheme": scheme,
+			})
+
+			return match
+		}
+
+		secretVal, resolveErr = resolver.Resolve(ctx, ref)
+		if resolveErr != nil {
+			resolveErr = cerrors.New(resolveErr, "secret resolver failed", map[string]interface{}{
+				"scheme": scheme,
+				"ref":    ref,
+			})
+		}
+
+		return secretVal
+	})
+
+	return resolved, resolveErr
+}
+
+// splitSecretField splits a reference of the form name#field
+func splitSecretField(ref string) (string, string) {
+	if i := strings.LastIndex(ref, "#"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+
+	return ref, ""
+}
+
+// secretJSONField returns the given field from a JSON object secret. If field is empty, the secret is returned as-is.
+func secretJSONField(secret []byte, field string) (string, error) {
+	if field == "" {
+		return string(secret), nil
+	}
+
+	var fields map[string]interface{}
+
+	err := json.Unmarshal(secret, &fields)
+	if err != nil {
+		return "", cerrors.New(err, "secret is not a json object", nil)
+	}
+
+	val, ok := fields[field]
+	if !ok {
+		return "", cerrors.New(nil, "field not found in secret", map[string]interface{}{
+			"field": field,
+		})
+	}
+
+	if s, ok := val.(string); ok {
+		return s, nil
+	}
+
+	return fmt.Sprintf("%v", val), nil
+}
+
+func doSecretRequest(client *http.Client, req *http.Request) ([]byte, int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, cerrors.New(err, "failed to send request", map[string]interface{}{
+			"url": req.URL.String(),
+		})
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, cerrors.New(err, "failed to read response", nil)
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return body, resp.StatusCode, cerrors.New(nil, "request failed", map[string]interface{}{
+			"url":        req.URL.String(),
+			"statusCode": resp.StatusCode,
+		})
+	}
+
+	return body, resp.StatusCode, nil
+}
+
+// EnvResolver resolves ${env:VAR} to the value of the environment variable. It fails if the variable is not set.
+type EnvResolver struct{}
+
+// Scheme returns "env"
+func (r *EnvResolver) Scheme() string {
+	return SecretSchemeEnv
+}
+
+// Resolve returns the value of the environment variable named ref
+func (r *EnvResolver) Resolve(_ context.Context, ref string) (string, error) {
+	val, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", cerrors.New(nil, "env var is not set", map[string]interface{}{
+			"env": ref,
+		})
+	}
+
+	return val, nil
+}
+
+// NewVaultResolverFromEnv creates a VaultResolver using the VAULT_ADDR, VAULT_TOKEN, and VAULT_NAMESPACE environment
+// variables.
+func NewVaultResolverFromEnv() *VaultResolver {
+	return &VaultResolver{
+		Addr:      os.Getenv("VAULT_ADDR"),
+		Token:     os.Getenv("VAULT_TOKEN"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+		Client:    &http.Client{Timeout: secretFetchTimeout},
+	}
+}
+
+// VaultResolver resolves ${vault:mount/path#field} using HashiCorp Vault's KV secrets engine. Both KV v2 and v1
+// mounts are supported.
+type VaultResolver struct {
+	Addr      string
+	Token     string
+	Namespace string
+	Client    *http.Client
+}
+
+// Scheme returns "vault"
+func (r *VaultResolver) Scheme() string {
+	return SecretSchemeVault
+}
+
+// Resolve reads the secret at the given path and returns the given field
+func (r *VaultResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	if r.Addr == "" || r.Token == "" {
+		return "", cerrors.New(nil, "VAULT_ADDR and VAULT_TOKEN must be set to resolve vault secrets", nil)
+	}
+
+	path, field := splitSecretField(ref)
+	if field == "" {
+		return "", cerrors.New(nil, "vault secret reference must include a field (ex. secret/db#password)", nil)
+	}
+
+	mount, secretPath := path, ""
+	if i := strings.Index(path, "/"); i >= 0 {
+		mount, secretPath = path[:i], path[i+1:]
+	}
+
+	// Try the KV v2 API first and fall back to KV v1
+	data, err := r.read(ctx, fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimSuffix(r.Addr, "/"), mount, secretPath))
+	if err != nil {
+		data, err = r.read(ctx, fmt.Sprintf("%s/v1/%s", strings.TrimSuffix(r.Addr, "/"), path))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return secretJSONField(data, field)
+}
+
+// read returns the secret's key-value pairs as JSON
+func (r *VaultResolver) read(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create vault request", nil)
+	}
+
+	req.Header.Set("X-Vault-Token", r.Token)
+
+	if r.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", r.Namespace)
+	}
+
+	body, _, err := doSecretRequest(r.Client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+
+	err = json.Unmarshal(body, &resp)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to decode vault response", nil)
+	}
+
+	var kv2 struct {
+		Data     json.RawMessage `json:"data"`
+		Metadata json.RawMessage `json:"metadata"`
+	}
+
+	if json.Unmarshal(resp.Data, &kv2) == nil && kv2.Metadata != nil && kv2.Data != nil {
+		return kv2.Data, nil
+	}
+
+	return resp.Data, nil
+}
+
+// NewAWSSecretsManagerResolverFromEnv creates an AWSSecretsManagerResolver using the standard AWS environment
+// variables (AWS_REGION, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN).
+func NewAWSSecretsManagerResolverFromEnv() *AWSSecretsManagerResolver {
+	return &AWSSecretsManagerResolver{
+		Region:          awssig.RegionFromEnv(),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Client:          &http.Client{Timeout: secretFetchTimeout},
+	}
+}
+
+// AWSSecretsManagerResolver resolves ${aws-sm:name} and ${aws-sm:name#field} using AWS Secrets Manager. If a field is
+// given, the secret is parsed as a JSON object and the field's value is returned.
+type AWSSecretsManagerResolver struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Endpoint overrides the regional endpoint (ex. for localstack)
+	Endpoint string
+
+	Client *http.Client
+}
+
+// Scheme returns "aws-sm"
+func (r *AWSSecretsManagerResolver) Scheme() string {
+	return SecretSchemeAWSSM
+}
+
+// Resolve fetches the current version of the secret
+func (r *AWSSecretsManagerResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	if r.Region == "" || r.AccessKeyID == "" || r.SecretAccessKey == "" {
+		return "", cerrors.New(nil, "AWS_REGION, AWS_ACCESS_KEY_ID, and AWS_SECRET_ACCESS_KEY must be set to "+
+			"resolve aws-sm secrets", nil)
+	}
+
+	creds := awssig.Credentials{
+		AccessKeyID:     r.AccessKeyID,
+		SecretAccessKey: r.SecretAccessKey,
+		SessionToken:    r.SessionToken,
+	}
+
+	endpoint := r.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com/", r.Region)
+	}
+
+	name, field := splitSecretField(ref)
+
+	reqBody, err := json.Marshal(map[string]string{"SecretId": name})
+	if err != nil {
+		return "", cerrors.New(err, "failed to encode request", nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return "", cerrors.New(err, "failed to create aws request", nil)
+	}
+
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	awssig.Sign(req, reqBody, creds, r.Region, "secretsmanager", time.Now())
+
+	body, _, err := doSecretRequest(r.Client, req)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		SecretString string `json:"SecretString"`
+		SecretBinary []byte `json:"SecretBinary"`
+	}
+
+	err = json.Unmarshal(body, &resp)
+	if err != nil {
+		return "", cerrors.New(err, "failed to decode aws response", nil)
+	}
+
+	secret := []byte(resp.SecretString)
+	if resp.SecretString == "" {
+		secret = resp.SecretBinary
+	}
+
+	return secretJSONField(secret, field)
+}
+
+// NewGCPSecretManagerResolverFromEnv creates a GCPSecretManagerResolver that uses GOOGLE_CLOUD_PROJECT as the default
+// project and GCP_ACCESS_TOKEN as the OAuth token. If GCP_ACCESS_TOKEN is not set, a token is fetched from the GCE
+// metadata server.
+func NewGCPSecretManagerResolverFromEnv() *GCPSecretManagerResolver {
+	return &GCPSecretManagerResolver{
+		Project:     os.Getenv("GOOGLE_CLOUD_PROJECT"),
+		AccessToken: os.Getenv("GCP_ACCESS_TOKEN"),
+		Client:      &http.Client{Timeout: secretFetchTimeout},
+	}
+}
+
+// GCPSecretManagerResolver resolves ${gcp-sm:name}, ${gcp-sm:name#field}, or a full resource name such as
+// ${gcp-sm:projects/p/secrets/name/versions/3} using Google Cloud Secret Manager. The latest version is used if the
+// version is not specified.
+type GCPSecretManagerResolver struct {
+	Project     string
+	AccessToken string
+
+	// Endpoint and MetadataEndpoint override the Secret Manager API and metadata server URLs
+	Endpoint         string
+	MetadataEndpoint string
+
+	Client *http.Client
+}
+
+// Scheme returns "gcp-sm"
+func (r *GCPSecretManagerResolver) Scheme() string {
+	return SecretSchemeGCPSM
+}
+
+// Resolve accesses the secret version's payload
+func (r *GCPSecretManagerResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	name, field := splitSecretField(ref)
+
+	if !strings.HasPrefix(name, "projects/") {
+		if r.Project == "" {
+			return "", cerrors.New(nil, "GOOGLE_CLOUD_PROJECT must be set to resolve gcp-sm secrets by name", nil)
+		}
+
+		name = fmt.Sprintf("projects/%s/secrets/%s", r.Project, name)
+	}
+
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	token, err := r.token(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := r.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretmanager.googleapis.com"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/%s:access", endpoint, name), nil)
+	if err != nil {
+		return "", cerrors.New(err, "failed to create gcp request", nil)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	body, _, err := doSecretRequest(r.Client, req)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+
+	err = json.Unmarshal(body, &resp)
+	if err != nil {
+		return "", cerrors.New(err, "failed to decode gcp response", nil)
+	}
+
+	secret, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", cerrors.New(err, "failed to decode secret payload", nil)
+	}
+
+	return secretJSONField(secret, field)
+}
+
+func (r *GCPSecretManagerResolver) token(ctx context.Context) (string, error) {
+	if r.AccessToken != "" {
+		return r.AccessToken, nil
+	}
+
+	endpoint := r.MetadataEndpoint
+	if endpoint == "" {
+		endpoint = "http://metadata.google.internal"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		endpoint+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", cerrors.New(err, "failed to create metadata request", nil)
+	}
+
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	body, _, err := doSecretRequest(r.Client, req)
+	if err != nil {
+		return "", cerrors.New(err, "failed to get access token from metadata server", nil)
+	}
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+	}
+
+	err = json.Unmarshal(body, &resp)
+	if err != nil {
+		return "", cerrors.New(err, "failed to decode metadata token response", nil)
+	}
+
+	return resp.AccessToken, nil
+}
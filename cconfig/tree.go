@@ -11,16 +11,26 @@ import (
 
 //nolint:funlen
 func loadTree(fp, overrides string, disableKeyOverrides bool) (*toml.Tree, error) {
-	tree, err := toml.LoadFile(fp)
+	// A config dir is loaded as layers of config files that may each extend other files
+	if isDir(fp) {
+		tree, err := loadLayeredTree(fp, AppEnv(), disableKeyOverrides)
+		if err != nil {
+			return nil, err
+		}
+
+		return applyOverrides(tree, overrides, disableKeyOverrides)
+	}
+
+	tree, err := loadFile(fp)
 	if err != nil {
 		return nil, cerrors.New(err, "failed to load config file", map[string]interface{}{
 			"path": fp,
 		})
 	}
 
-	// If the TOML tree does not have a top-level 'extends' key, we can return the tree as-is
+	// If the TOML tree does not have a top-level 'extends' key, we can return the tree with the overrides applied
 	if !tree.Has("extends") {
-		return tree, nil
+		return applyOverrides(tree, overrides, disableKeyOverrides)
 	}
 
 	parentFilePaths := make([]string, 0)
@@ -72,7 +82,10 @@ func loadTree(fp, overrides string, disableKeyOverrides bool) (*toml.Tree, error
 		}
 	}
 
-	// Apply overrides
+	return applyOverrides(tree, overrides, disableKeyOverrides)
+}
+
+func applyOverrides(tree *toml.Tree, overrides string, disableKeyOverrides bool) (*toml.Tree, error) {
 	for _, ov := range strings.Split(overrides, ";") {
 		t, err := toml.Load(ov)
 		if err != nil {
@@ -88,6 +101,33 @@ func loadTree(fp, overrides string, disableKeyOverrides bool) (*toml.Tree, error
 			})
 		}
 	}
+
+	return tree, nil
+}
+
+// loadOverridesTree parses the ';' separated overrides into a single tree
+func loadOverridesTree(overrides string) (*toml.Tree, error) {
+	tree, err := toml.TreeFromMap(map[string]interface{}{})
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create overrides tree", nil)
+	}
+
+	for _, ov := range strings.Split(overrides, ";") {
+		t, err := toml.Load(ov)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to parse override as TOML", map[string]interface{}{
+				"override": ov,
+			})
+		}
+
+		tree, err = mergeTrees(tree, t, false)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to merge overrides", map[string]interface{}{
+				"override": ov,
+			})
+		}
+	}
+
 	return tree, nil
 }
 
@@ -0,0 +1,218 @@
+package cconfig
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gocopper/copper/cerrors"
+	"github.com/pelletier/go-toml"
+)
+
+const fileChangeDebounce = 100 * time.Millisecond
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem() //nolint:gochecknoglobals
+
+type watcher struct {
+	key     string
+	fn      reflect.Value
+	argType reflect.Type
+	last    interface{}
+}
+
+func (l *loader) Watch(key string, fn interface{}) error {
+	fnVal := reflect.ValueOf(fn)
+	fnType := fnVal.Type()
+
+	if fnType.Kind() != reflect.Func || fnType.NumIn() != 1 || fnType.NumOut() > 1 ||
+		(fnType.NumOut() == 1 && fnType.Out(0) != errorType) {
+		return cerrors.New(nil, "watch fn must be a func(T) or func(T) error", map[string]interface{}{
+			"key":  key,
+			"type": fnType.String(),
+		})
+	}
+
+	w := &watcher{
+		key:     key,
+		fn:      fnVal,
+		argType: fnType.In(0),
+	}
+
+	current, err := l.loadValue(w)
+	if err != nil {
+		return err
+	}
+
+	w.last = current
+
+	l.mu.Lock()
+	l.watchers = append(l.watchers, w)
+	l.mu.Unlock()
+
+	return nil
+}
+
+func (l *loader) Reload() error {
+	l.reloadMu.Lock()
+	defer l.reloadMu.Unlock()
+
+	tree, overridesTree, err := l.read()
+	if err != nil {
+		return cerrors.New(err, "failed to reload config", nil)
+	}
+
+	l.mu.RLock()
+	watchers := append([]*watcher{}, l.watchers...)
+	l.mu.RUnlock()
+
+	// every watched key is validated against the new config before it is applied so that an invalid config does not
+	// replace the current one
+	values := make([]interface{}, len(watchers))
+
+	for i, w := range watchers {
+		values[i], err = l.loadValueFrom(tree, overridesTree, w)
+		if err != nil {
+			return cerrors.New(err, "failed to load reloaded config", map[string]interface{}{
+				"key": w.key,
+			})
+		}
+	}
+
+	l.mu.Lock()
+	l.tree, l.overrides = tree, overridesTree
+	l.mu.Unlock()
+
+	var watchErr error
+
+	for i, w := range watchers {
+		if reflect.DeepEqual(values[i], w.last) {
+			continue
+		}
+
+		w.last = values[i]
+
+		out := w.fn.Call([]reflect.Value{reflect.ValueOf(values[i])})
+		if len(out) == 1 && !out[0].IsNil() && watchErr == nil {
+			watchErr = cerrors.New(out[0].Interface().(error), "config watcher failed", map[string]interface{}{
+				"key": w.key,
+			})
+		}
+	}
+
+	return watchErr
+}
+
+// loadValue loads the watcher's key into a new value of the watcher's config type
+func (l *loader) loadValue(w *watcher) (interface{}, error) {
+	l.mu.RLock()
+	tree, overridesTree := l.tree, l.overrides
+	l.mu.RUnlock()
+
+	return l.loadValueFrom(tree, overridesTree, w)
+}
+
+func (l *loader) loadValueFrom(tree, overridesTree *toml.Tree, w *watcher) (interface{}, error) {
+	dest := reflect.New(w.argType)
+
+	err := l.loadFrom(tree, overridesTree, w.key, dest.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	return dest.Elem().Interface(), nil
+}
+
+// ReloadOnSIGHUP reloads the config each time the process receives a SIGHUP signal. Reload errors are passed to
+// onError. The returned func stops listening for the signal.
+func ReloadOnSIGHUP(loader Loader, onError func(err error)) func() {
+	sig := make(chan os.Signal, 1)
+	done := make(chan struct{})
+
+	signal.Notify(sig, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-sig:
+				err := loader.Reload()
+				if err != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
+
+// ReloadOnFileChange reloads the config each time a file in the config file's directory (or the config directory
+// itself, see New) changes. Changes are debounced so that an editor saving a file triggers a single reload. Reload errors
+// are passed to onError. The returned func stops watching the files.
+func ReloadOnFileChange(loader Loader, fp Path, onError func(err error)) (func(), error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create file watcher", nil)
+	}
+
+	dir := filepath.Dir(string(fp))
+	if isDir(string(fp)) {
+		dir = string(fp)
+	}
+
+	err = fsw.Add(dir)
+	if err != nil {
+		_ = fsw.Close()
+
+		return nil, cerrors.New(err, "failed to watch config dir", map[string]interface{}{
+			"dir": dir,
+		})
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		var debounce <-chan time.Time
+
+		for {
+			select {
+			case <-done:
+				return
+			case event, ok := <-fsw.Events:
+				if !ok {
+					return
+				}
+
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					debounce = time.After(fileChangeDebounce)
+				}
+			case err, ok := <-fsw.Errors:
+				if !ok {
+					return
+				}
+
+				onError(cerrors.New(err, "config file watcher failed", nil))
+			case <-debounce:
+				debounce = nil
+
+				err := loader.Reload()
+				if err != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		_ = fsw.Close()
+	}, nil
+}
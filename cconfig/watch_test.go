@@ -0,0 +1,186 @@
+package cconfig_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cconfig/cconfigtest"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRateLimitConfig struct {
+	RPS int `toml:"rps"`
+}
+
+func TestLoader_Watch(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir = cconfigtest.SetupDirWithConfigs(t, map[string]string{
+			"test.toml": `
+				[ratelimit]
+				rps = 10
+
+				[other]
+				key = "val"
+			`,
+		})
+		fp      = path.Join(dir, "test.toml")
+		changes []testRateLimitConfig
+	)
+
+	loader, err := cconfig.New(cconfig.Path(fp), "")
+	assert.NoError(t, err)
+
+	err = loader.Watch("ratelimit", func(c testRateLimitConfig) {
+		changes = append(changes, c)
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, loader.Reload())
+	assert.Empty(t, changes)
+
+	assert.NoError(t, ioutil.WriteFile(fp, []byte("[ratelimit]\nrps = 20\n"), 0600))
+	assert.NoError(t, loader.Reload())
+	assert.Equal(t, []testRateLimitConfig{{RPS: 20}}, changes)
+
+	var config testRateLimitConfig
+
+	assert.NoError(t, loader.Load("ratelimit", &config))
+	assert.Equal(t, 20, config.RPS)
+
+	// An invalid file is not applied
+	assert.NoError(t, ioutil.WriteFile(fp, []byte("[ratelimit\n"), 0600))
+	assert.Error(t, loader.Reload())
+	assert.NoError(t, loader.Load("ratelimit", &config))
+	assert.Equal(t, 20, config.RPS)
+}
+
+func TestLoader_Reload_InvalidKey(t *testing.T) {
+	t.Parallel()
+
+	type otherConfig struct {
+		Key string `toml:"key"`
+	}
+
+	var (
+		dir = cconfigtest.SetupDirWithConfigs(t, map[string]string{
+			"test.toml": "[other]\nkey = \"a\"\n\n[ratelimit]\nrps = 10\n",
+		})
+		fp      = path.Join(dir, "test.toml")
+		changes []string
+	)
+
+	loader, err := cconfig.New(cconfig.Path(fp), "")
+	assert.NoError(t, err)
+
+	assert.NoError(t, loader.Watch("other", func(c otherConfig) {
+		changes = append(changes, "other="+c.Key)
+	}))
+	assert.NoError(t, loader.Watch("ratelimit", func(c testRateLimitConfig) {
+		changes = append(changes, "ratelimit")
+	}))
+
+	// the file is valid TOML but ratelimit does not decode, so none of the new config is applied
+	assert.NoError(t, ioutil.WriteFile(fp, []byte("[other]\nkey = \"b\"\n\n[ratelimit]\nrps = \"fast\"\n"), 0600))
+	assert.Error(t, loader.Reload())
+	assert.Empty(t, changes)
+
+	var other otherConfig
+
+	assert.NoError(t, loader.Load("other", &other))
+	assert.Equal(t, "a", other.Key)
+
+	assert.NoError(t, ioutil.WriteFile(fp, []byte("[other]\nkey = \"c\"\n\n[ratelimit]\nrps = 20\n"), 0600))
+	assert.NoError(t, loader.Reload())
+	assert.Equal(t, []string{"other=c", "ratelimit"}, changes)
+}
+
+func TestLoader_Reload_WatcherErr(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir = cconfigtest.SetupDirWithConfigs(t, map[string]string{
+			"test.toml": "[ratelimit]\nrps = 10\n",
+		})
+		fp    = path.Join(dir, "test.toml")
+		calls = 0
+	)
+
+	loader, err := cconfig.New(cconfig.Path(fp), "")
+	assert.NoError(t, err)
+
+	assert.NoError(t, loader.Watch("ratelimit", func(c testRateLimitConfig) error {
+		return errors.New("failed to apply")
+	}))
+	assert.NoError(t, loader.Watch("ratelimit", func(c testRateLimitConfig) {
+		calls++
+	}))
+
+	// a failed watcher does not stop the others from being notified
+	assert.NoError(t, ioutil.WriteFile(fp, []byte("[ratelimit]\nrps = 20\n"), 0600))
+	assert.Error(t, loader.Reload())
+	assert.Equal(t, 1, calls)
+}
+
+func TestLoader_Watch_InvalidFn(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir = cconfigtest.SetupDirWithConfigs(t, map[string]string{"test.toml": ""})
+		fp  = cconfig.Path(path.Join(dir, "test.toml"))
+	)
+
+	loader, err := cconfig.New(fp, "")
+	assert.NoError(t, err)
+
+	assert.Error(t, loader.Watch("ratelimit", "not a func"))
+	assert.Error(t, loader.Watch("ratelimit", func(a, b testRateLimitConfig) {}))
+}
+
+func TestReloadOnFileChange(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir = cconfigtest.SetupDirWithConfigs(t, map[string]string{
+			"test.toml": "[ratelimit]\nrps = 10\n",
+		})
+		fp = path.Join(dir, "test.toml")
+
+		mu  sync.Mutex
+		rps int
+	)
+
+	loader, err := cconfig.New(cconfig.Path(fp), "")
+	assert.NoError(t, err)
+
+	assert.NoError(t, loader.Watch("ratelimit", func(c testRateLimitConfig) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		rps = c.RPS
+
+		return nil
+	}))
+
+	stop, err := cconfig.ReloadOnFileChange(loader, cconfig.Path(fp), func(err error) {
+		assert.NoError(t, err)
+	})
+	assert.NoError(t, err)
+
+	defer stop()
+
+	assert.NoError(t, ioutil.WriteFile(fp, []byte("[ratelimit]\nrps = 30\n"), 0600))
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return rps == 30
+	}, 5*time.Second, 10*time.Millisecond)
+}
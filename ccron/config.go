@@ -0,0 +1,88 @@
+package ccron
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Lockers and histories supported by NewLocker and NewHistory
+const (
+	StoreMemory = "memory"
+	StoreSQL    = "sql"
+	StoreRedis  = "redis"
+)
+
+const (
+	defaultTimeout     = time.Hour
+	defaultHistorySize = 100
+	defaultRedisPrefix = "ccron:"
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "ccron",
+		Description: "ccron configures the scheduler of recurring tasks",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("ccron", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load ccron config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Locker:      StoreMemory,
+		History:     StoreMemory,
+		HistorySize: defaultHistorySize,
+		Timeout:     defaultTimeout,
+		Redis: ConfigRedis{
+			Prefix: defaultRedisPrefix,
+		},
+	}
+}
+
+// Config configures the scheduler. For example:
+//
+//	[ccron]
+//	locker = "sql"
+//	history = "sql"
+//	timezone = "America/New_York"
+type Config struct {
+	// Locker makes sure that a scheduled run only runs on one instance of the app: memory (single instance), sql,
+	// or redis
+	Locker string `toml:"locker" valid:"in(memory|sql|redis)" doc:"memory, sql, or redis"`
+
+	// History is where runs are recorded: memory or sql
+	History string `toml:"history" valid:"in(memory|sql)" doc:"memory or sql"`
+
+	// HistorySize is the number of runs kept per task
+	HistorySize int `toml:"history_size" doc:"Number of runs kept per task"`
+
+	// Timeout is the max duration of a run for tasks that do not set their own
+	Timeout time.Duration `toml:"timeout" doc:"Max duration of a run for tasks that do not set their own"`
+
+	// Timezone is the IANA time zone the schedules are evaluated in (default: the local time zone)
+	Timezone string `toml:"timezone" doc:"IANA time zone the schedules are evaluated in"`
+
+	Redis ConfigRedis `toml:"redis"`
+}
+
+// ConfigRedis configures the Redis locker
+type ConfigRedis struct {
+	// URL of the Redis server (ex. redis://:password@localhost:6379/0, or rediss:// for TLS)
+	URL string `toml:"url"`
+
+	// Prefix is prepended to the keys of the locks
+	Prefix string `toml:"prefix"`
+}
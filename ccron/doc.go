@@ -0,0 +1,4 @@
+// Package ccron runs recurring tasks on cron schedules. Tasks do not overlap with their previous run, and a
+// distributed Locker makes sure that only one instance of the app runs each scheduled run. Runs are recorded in a
+// History and exported as Prometheus metrics.
+package ccron
@@ -0,0 +1,119 @@
+package ccron
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/csql"
+)
+
+// Run statuses
+const (
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+	RunStatusTimedOut  = "timed_out"
+)
+
+// Run is a recorded run of a task
+type Run struct {
+	ID          string    `db:"id" json:"id"`
+	Task        string    `db:"task" json:"task"`
+	Instance    string    `db:"instance" json:"instance"`
+	ScheduledAt time.Time `db:"scheduled_at" json:"scheduled_at"`
+	StartedAt   time.Time `db:"started_at" json:"started_at"`
+	FinishedAt  time.Time `db:"finished_at" json:"finished_at"`
+	Status      string    `db:"status" json:"status"`
+	Error       string    `db:"error" json:"error"`
+}
+
+// Duration returns how long the run took
+func (r *Run) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// History records the runs of tasks
+type History interface {
+	// Record saves a finished run
+	Record(ctx context.Context, run *Run) error
+
+	// List returns up to limit runs of the task, most recent first
+	List(ctx context.Context, task string, limit int) ([]Run, error)
+}
+
+// NewHistoryParams holds the params needed for NewHistory
+type NewHistoryParams struct {
+	DB        *sql.DB
+	Querier   csql.Querier
+	SQLConfig csql.Config
+	Config    Config
+}
+
+// NewHistory creates the History configured by Config.History
+func NewHistory(p NewHistoryParams) (History, error) {
+	switch p.Config.History {
+	case StoreMemory:
+		return NewMemoryHistory(p.Config.HistorySize), nil
+	case StoreSQL:
+		return NewSQLStore(NewSQLStoreParams{
+			DB:        p.DB,
+			Querier:   p.Querier,
+			SQLConfig: p.SQLConfig,
+			Config:    p.Config,
+		}), nil
+	default:
+		return nil, cerrors.New(nil, "unknown ccron history", map[string]interface{}{
+			"history": p.Config.History,
+		})
+	}
+}
+
+// NewMemoryHistory creates a MemoryHistory that keeps the last size runs of each task
+func NewMemoryHistory(size int) *MemoryHistory {
+	return &MemoryHistory{
+		size: size,
+		runs: make(map[string][]Run),
+	}
+}
+
+// MemoryHistory is a History that keeps runs in memory
+type MemoryHistory struct {
+	size int
+
+	mu   sync.Mutex
+	runs map[string][]Run
+}
+
+// Record saves a finished run
+func (h *MemoryHistory) Record(_ context.Context, run *Run) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	runs := append(h.runs[run.Task], *run)
+	if len(runs) > h.size {
+		runs = runs[len(runs)-h.size:]
+	}
+
+	h.runs[run.Task] = runs
+
+	return nil
+}
+
+// List returns up to limit runs of the task, most recent first
+func (h *MemoryHistory) List(_ context.Context, task string, limit int) ([]Run, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var (
+		runs   = h.runs[task]
+		recent = make([]Run, 0, limit)
+	)
+
+	for i := len(runs) - 1; i >= 0 && len(recent) < limit; i-- {
+		recent = append(recent, runs[i])
+	}
+
+	return recent, nil
+}
@@ -0,0 +1,102 @@
+package ccron
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/csql"
+)
+
+// Locker coordinates the instances of the app so that each scheduled run of a task only runs once
+type Locker interface {
+	// Acquire locks the task for owner until ttl expires. It returns false if the task is locked by another owner or
+	// if the scheduled run at tick (or a later one) already ran.
+	Acquire(ctx context.Context, task, owner string, tick time.Time, ttl time.Duration) (bool, error)
+
+	// Release unlocks the task if it is locked by owner
+	Release(ctx context.Context, task, owner string) error
+}
+
+// NewLockerParams holds the params needed for NewLocker
+type NewLockerParams struct {
+	DB        *sql.DB
+	Querier   csql.Querier
+	SQLConfig csql.Config
+	Config    Config
+}
+
+// NewLocker creates the Locker configured by Config.Locker
+func NewLocker(p NewLockerParams) (Locker, error) {
+	switch p.Config.Locker {
+	case StoreMemory:
+		return NewMemoryLocker(), nil
+	case StoreSQL:
+		return NewSQLStore(NewSQLStoreParams{
+			DB:        p.DB,
+			Querier:   p.Querier,
+			SQLConfig: p.SQLConfig,
+			Config:    p.Config,
+		}), nil
+	case StoreRedis:
+		return NewRedisLocker(p.Config.Redis)
+	default:
+		return nil, cerrors.New(nil, "unknown ccron locker", map[string]interface{}{
+			"locker": p.Config.Locker,
+		})
+	}
+}
+
+// NewMemoryLocker creates a new MemoryLocker
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{
+		locks: make(map[string]memoryLock),
+		now:   time.Now,
+	}
+}
+
+// MemoryLocker is a Locker for apps that run a single instance
+type MemoryLocker struct {
+	mu    sync.Mutex
+	locks map[string]memoryLock
+	now   func() time.Time
+}
+
+type memoryLock struct {
+	owner       string
+	lockedUntil time.Time
+	lastTick    time.Time
+}
+
+// Acquire locks the task for owner. See Locker.
+func (l *MemoryLocker) Acquire(_ context.Context, task, owner string, tick time.Time, ttl time.Duration) (bool,
+	error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	lock, ok := l.locks[task]
+	if ok && (lock.lockedUntil.After(now) || !lock.lastTick.Before(tick)) {
+		return false, nil
+	}
+
+	l.locks[task] = memoryLock{owner: owner, lockedUntil: now.Add(ttl), lastTick: tick}
+
+	return true, nil
+}
+
+// Release unlocks the task if it is locked by owner
+func (l *MemoryLocker) Release(_ context.Context, task, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if lock, ok := l.locks[task]; ok && lock.owner == owner {
+		lock.lockedUntil = time.Time{}
+		l.locks[task] = lock
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package ccron
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// runStatusSkipped labels the scheduled runs that did not run because the previous run was still running or another
+// instance ran them
+const runStatusSkipped = "skipped"
+
+var (
+	runsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:gochecknoglobals
+		Name: "ccron_runs_total",
+		Help: "Number of scheduled task runs by status",
+	}, []string{"task", "status"})
+
+	runDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{ //nolint:gochecknoglobals
+		Name:    "ccron_run_duration_seconds",
+		Help:    "Duration of scheduled task runs",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 10), //nolint:gomnd
+	}, []string{"task"})
+
+	lastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{ //nolint:gochecknoglobals
+		Name: "ccron_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful run of each task",
+	}, []string{"task"})
+)
+
+func init() { //nolint:gochecknoinits
+	prometheus.MustRegister(runsTotal, runDuration, lastSuccess)
+}
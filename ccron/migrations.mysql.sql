@@ -0,0 +1,24 @@
+-- +migrate Up
+create table ccron_locks (
+    task varchar(255) primary key,
+    owner varchar(255) not null,
+    locked_until datetime(6) not null,
+    last_tick datetime(6) not null
+);
+
+create table ccron_runs (
+    id varchar(64) primary key,
+    task varchar(255) not null,
+    instance varchar(255) not null,
+    scheduled_at datetime(6) not null,
+    started_at datetime(6) not null,
+    finished_at datetime(6) not null,
+    status varchar(32) not null,
+    error text not null
+);
+
+create index ccron_runs_task_idx on ccron_runs (task, started_at);
+
+-- +migrate Down
+drop table ccron_runs;
+drop table ccron_locks;
@@ -0,0 +1,24 @@
+-- +migrate Up
+create table ccron_locks (
+    task text primary key,
+    owner text not null,
+    locked_until timestamp not null,
+    last_tick timestamp not null
+);
+
+create table ccron_runs (
+    id text primary key,
+    task text not null,
+    instance text not null,
+    scheduled_at timestamp not null,
+    started_at timestamp not null,
+    finished_at timestamp not null,
+    status text not null,
+    error text not null default ''
+);
+
+create index ccron_runs_task_idx on ccron_runs (task, started_at);
+
+-- +migrate Down
+drop table ccron_runs;
+drop table ccron_locks;
@@ -0,0 +1,83 @@
+package ccron
+
+import (
+	"context"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/internal/redis"
+)
+
+const (
+	// redisAcquireScript locks the task (KEYS[1]) for the owner (ARGV[1]) until ARGV[3] if it is not locked at ARGV[2]
+	// and its last tick is before ARGV[4]. Times are in unix milliseconds.
+	redisAcquireScript = `
+local lockedUntil = tonumber(redis.call('HGET', KEYS[1], 'locked_until') or '0')
+local lastTick = tonumber(redis.call('HGET', KEYS[1], 'last_tick') or '-1')
+if lockedUntil > tonumber(ARGV[2]) or lastTick >= tonumber(ARGV[4]) then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'owner', ARGV[1], 'locked_until', ARGV[3], 'last_tick', ARGV[4])
+return 1`
+
+	// redisReleaseScript unlocks the task (KEYS[1]) if it is locked by the owner (ARGV[1])
+	redisReleaseScript = `
+if redis.call('HGET', KEYS[1], 'owner') == ARGV[1] then
+	redis.call('HSET', KEYS[1], 'locked_until', '0')
+end
+return 0`
+)
+
+// NewRedisLocker creates a new RedisLocker
+func NewRedisLocker(config ConfigRedis) (*RedisLocker, error) {
+	opts, err := redis.ParseURL(config.URL)
+	if err != nil {
+		return nil, cerrors.New(err, "invalid ccron.redis.url", nil)
+	}
+
+	return &RedisLocker{
+		client: redis.New(opts),
+		prefix: config.Prefix,
+		now:    time.Now,
+	}, nil
+}
+
+// RedisLocker is a Locker that stores the locks in Redis
+type RedisLocker struct {
+	client *redis.Client
+	prefix string
+	now    func() time.Time
+}
+
+// Acquire locks the task for owner. See Locker.
+func (l *RedisLocker) Acquire(ctx context.Context, task, owner string, tick time.Time, ttl time.Duration) (bool,
+	error) {
+	now := l.now()
+
+	n, err := redis.Int64(l.client.Do(ctx, "EVAL", redisAcquireScript, 1, l.prefix+"lock:"+task, owner,
+		now.UnixMilli(), now.Add(ttl).UnixMilli(), tick.UnixMilli()))
+	if err != nil {
+		return false, cerrors.New(err, "failed to acquire task lock", map[string]interface{}{
+			"task": task,
+		})
+	}
+
+	return n == 1, nil
+}
+
+// Release unlocks the task if it is locked by owner
+func (l *RedisLocker) Release(ctx context.Context, task, owner string) error {
+	_, err := l.client.Do(ctx, "EVAL", redisReleaseScript, 1, l.prefix+"lock:"+task, owner)
+	if err != nil {
+		return cerrors.New(err, "failed to release task lock", map[string]interface{}{
+			"task": task,
+		})
+	}
+
+	return nil
+}
+
+// Close closes the connections to Redis
+func (l *RedisLocker) Close() error {
+	return l.client.Close()
+}
@@ -0,0 +1,275 @@
+package ccron
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// maxScheduleSearch is how far in the future Schedule.Next looks for a matching time
+const maxScheduleSearch = 5 * 366 * 24 * time.Hour
+
+// Schedule computes when a task runs
+type Schedule interface {
+	// Next returns the first time after t that the task runs, or the zero time if it never does
+	Next(t time.Time) time.Time
+}
+
+// scheduleDescriptors are the supported shorthands for common expressions
+var scheduleDescriptors = map[string]string{ //nolint:gochecknoglobals
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = map[string]int{ //nolint:gochecknoglobals
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+
+	dayNames = map[string]int{ //nolint:gochecknoglobals
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+)
+
+// ParseSchedule parses a cron expression in the given location. The standard 5 fields are supported (minute, hour,
+// day of month, month, and day of week), with an optional leading seconds field:
+//
+//	*/15 * * * *       every 15 minutes
+//	0 9 * * mon-fri    at 9:00 on weekdays
+//	30 */10 * * * *    every 10 minutes, 30 seconds past the minute
+//
+// Fields can be lists (1,15), ranges (1-5), and steps (*/2 or 10-20/5). Months and days of the week can be written
+// using their first three letters. Like in cron, a day matches if it matches either the day of month or the day of
+// week when both are restricted. The descriptors @yearly, @monthly, @weekly, @daily, @hourly, and @every <duration>
+// (ex. @every 90s) are supported as well.
+func ParseSchedule(expr string, loc *time.Location) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+
+	if strings.HasPrefix(expr, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil || d < time.Second {
+			return nil, cerrors.New(err, "invalid @every duration", map[string]interface{}{
+				"expr": expr,
+			})
+		}
+
+		return everySchedule{interval: d}, nil
+	}
+
+	if descriptor, ok := scheduleDescriptors[strings.ToLower(expr)]; ok {
+		expr = descriptor
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) == 5 { //nolint:gomnd
+		fields = append([]string{"0"}, fields...)
+	}
+
+	if len(fields) != 6 { //nolint:gomnd
+		return nil, cerrors.New(nil, "cron expression must have 5 or 6 fields", map[string]interface{}{
+			"expr": expr,
+		})
+	}
+
+	specs := []struct {
+		min, max int
+		names    map[string]int
+	}{
+		{0, 59, nil},
+		{0, 59, nil},
+		{0, 23, nil},
+		{1, 31, nil},
+		{1, 12, monthNames},
+		{0, 7, dayNames},
+	}
+
+	var bits [6]uint64
+
+	for i, spec := range specs {
+		var err error
+
+		bits[i], err = parseField(fields[i], spec.min, spec.max, spec.names)
+		if err != nil {
+			return nil, cerrors.New(err, "invalid cron expression", map[string]interface{}{
+				"expr":  expr,
+				"field": fields[i],
+			})
+		}
+	}
+
+	// 7 is an alias for sunday
+	if bits[5]&(1<<7) != 0 {
+		bits[5] = bits[5]&^(1<<7) | 1
+	}
+
+	if loc == nil {
+		loc = time.Local
+	}
+
+	return &cronSchedule{
+		second: bits[0],
+		minute: bits[1],
+		hour:   bits[2],
+		dom:    bits[3],
+		month:  bits[4],
+		dow:    bits[5],
+		anyDOM: fields[3] == "*" || fields[3] == "?",
+		anyDOW: fields[5] == "*" || fields[5] == "?",
+		loc:    loc,
+	}, nil
+}
+
+// parseField returns the bitmask of the values matched by a field of a cron expression
+func parseField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		var (
+			rangePart = part
+			step      = 1
+			err       error
+		)
+
+		if i := strings.Index(part, "/"); i != -1 {
+			rangePart = part[:i]
+
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, cerrors.New(err, "invalid step", map[string]interface{}{"part": part})
+			}
+		}
+
+		lo, hi := min, max
+
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2) //nolint:gomnd
+
+			lo, err = parseValue(bounds[0], min, max, names)
+			if err != nil {
+				return 0, err
+			}
+
+			hi, err = parseValue(bounds[1], min, max, names)
+			if err != nil {
+				return 0, err
+			}
+
+			if lo > hi {
+				return 0, cerrors.New(nil, "invalid range", map[string]interface{}{"part": part})
+			}
+		default:
+			lo, err = parseValue(rangePart, min, max, names)
+			if err != nil {
+				return 0, err
+			}
+
+			// a single value with a step (ex. 5/15) starts at the value and goes up to the max
+			hi = lo
+			if step > 1 {
+				hi = max
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func parseValue(s string, min, max int, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < min || v > max {
+		return 0, cerrors.New(err, "value out of range", map[string]interface{}{
+			"value": s,
+			"min":   min,
+			"max":   max,
+		})
+	}
+
+	return v, nil
+}
+
+type cronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	anyDOM, anyDOW                        bool
+	loc                                   *time.Location
+}
+
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	var (
+		origLoc = t.Location()
+		limit   = t.Add(maxScheduleSearch)
+	)
+
+	t = t.In(s.loc).Truncate(time.Second).Add(time.Second)
+
+	for t.Before(limit) {
+		var next time.Time
+
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			next = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.loc)
+		case !s.dayMatches(t):
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			next = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.loc)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			next = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, s.loc)
+		case s.second&(1<<uint(t.Second())) == 0:
+			next = t.Add(time.Second)
+		default:
+			return t.In(origLoc)
+		}
+
+		// wall clock times are ambiguous when clocks are turned back, so make sure that the search moves forward
+		if !next.After(t) {
+			next = t.Add(time.Second)
+		}
+
+		t = next
+	}
+
+	return time.Time{}
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	var (
+		domMatch = s.dom&(1<<uint(t.Day())) != 0
+		dowMatch = s.dow&(1<<uint(t.Weekday())) != 0
+	)
+
+	switch {
+	case s.anyDOM && s.anyDOW:
+		return true
+	case s.anyDOM:
+		return dowMatch
+	case s.anyDOW:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Truncate(time.Second).Add(s.interval)
+}
@@ -0,0 +1,57 @@
+package ccron_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/ccron"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchedule_Next(t *testing.T) {
+	t.Parallel()
+
+	nyc, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		expr string
+		loc  *time.Location
+		from string
+		want string
+	}{
+		{"*/15 * * * *", time.UTC, "2024-03-10T10:07:30Z", "2024-03-10T10:15:00Z"},
+		{"0 9 * * mon-fri", time.UTC, "2024-03-08T09:00:00Z", "2024-03-11T09:00:00Z"},
+		{"30 */10 * * * *", time.UTC, "2024-03-10T10:00:30Z", "2024-03-10T10:10:30Z"},
+		{"0 0 1,15 * *", time.UTC, "2024-02-16T00:00:00Z", "2024-03-01T00:00:00Z"},
+		{"0 12 29 feb *", time.UTC, "2024-03-01T00:00:00Z", "2028-02-29T12:00:00Z"},
+		{"0 0 13 * 5", time.UTC, "2024-09-01T00:00:00Z", "2024-09-06T00:00:00Z"},
+		{"5/20 * * * *", time.UTC, "2024-03-10T10:26:00Z", "2024-03-10T10:45:00Z"},
+		{"0 0 * * 7", time.UTC, "2024-03-11T00:00:00Z", "2024-03-17T00:00:00Z"},
+		{"@daily", time.UTC, "2024-03-10T10:00:00Z", "2024-03-11T00:00:00Z"},
+		{"@hourly", time.UTC, "2024-03-10T10:00:00Z", "2024-03-10T11:00:00Z"},
+		{"@every 90s", time.UTC, "2024-03-10T10:00:00Z", "2024-03-10T10:01:30Z"},
+		{"0 9 * * *", nyc, "2024-03-10T12:00:00Z", "2024-03-10T13:00:00Z"},
+		{"30 2 * * *", nyc, "2024-03-10T05:00:00Z", "2024-03-11T06:30:00Z"},
+	}
+
+	for _, test := range tests {
+		schedule, err := ccron.ParseSchedule(test.expr, test.loc)
+		assert.NoError(t, err, test.expr)
+
+		from, _ := time.Parse(time.RFC3339, test.from)
+		want, _ := time.Parse(time.RFC3339, test.want)
+
+		assert.Equal(t, want.UTC(), schedule.Next(from).UTC(), test.expr)
+	}
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * * * mon-sun-tue", "*/0 * * * *", "5-1 * * * *",
+		"@every 1ms", "@fortnightly"} {
+		_, err := ccron.ParseSchedule(expr, time.UTC)
+		assert.Error(t, err, expr)
+	}
+}
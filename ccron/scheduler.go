@@ -0,0 +1,452 @@
+package ccron
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+)
+
+// Task is a recurring task
+type Task struct {
+	// Name identifies the task in locks, history, logs, and metrics. It must be unique.
+	Name string
+
+	// Schedule is a cron expression (see ParseSchedule)
+	Schedule string
+
+	// Timeout is the max duration of a run (default: ccron.timeout). The run's context is canceled once it expires.
+	Timeout time.Duration
+
+	Run func(ctx context.Context) error
+}
+
+// TaskStatus describes a registered task
+type TaskStatus struct {
+	Name     string
+	Schedule string
+	Next     time.Time
+	Running  bool
+}
+
+// NewSchedulerParams holds the params needed for NewScheduler
+type NewSchedulerParams struct {
+	Locker    Locker
+	History   History
+	Lifecycle *clifecycle.Lifecycle
+	Config    Config
+	Logger    clogger.Logger
+}
+
+// NewScheduler creates a new Scheduler
+func NewScheduler(p NewSchedulerParams) (*Scheduler, error) {
+	loc := time.Local
+
+	if p.Config.Timezone != "" {
+		var err error
+
+		loc, err = time.LoadLocation(p.Config.Timezone)
+		if err != nil {
+			return nil, cerrors.New(err, "invalid ccron.timezone", map[string]interface{}{
+				"timezone": p.Config.Timezone,
+			})
+		}
+	}
+
+	return &Scheduler{
+		locker:   p.Locker,
+		history:  p.History,
+		lc:       p.Lifecycle,
+		config:   p.Config,
+		logger:   p.Logger.With(clogger.Module("ccron")),
+		loc:      loc,
+		instance: newInstanceID(),
+		now:      time.Now,
+		tasks:    make(map[string]*scheduledTask),
+	}, nil
+}
+
+// Scheduler runs the registered tasks on their schedules. A task is skipped if its previous run is still running or
+// if another instance of the app already ran the scheduled run (see Locker).
+type Scheduler struct {
+	locker   Locker
+	history  History
+	lc       *clifecycle.Lifecycle
+	config   Config
+	logger   clogger.Logger
+	loc      *time.Location
+	instance string
+	now      func() time.Time
+
+	mu    sync.Mutex
+	tasks map[string]*scheduledTask
+	wake  chan struct{}
+}
+
+type scheduledTask struct {
+	task     Task
+	schedule Schedule
+	next     time.Time
+	running  bool
+}
+
+// Register adds a task to the scheduler. Tasks can be registered before or after the scheduler starts running.
+func (s *Scheduler) Register(task Task) error {
+	if task.Name == "" || task.Run == nil {
+		return cerrors.New(nil, "task must have a name and a run func", nil)
+	}
+
+	schedule, err := ParseSchedule(task.Schedule, s.loc)
+	if err != nil {
+		return cerrors.New(err, "invalid task schedule", map[string]interface{}{
+			"task": task.Name,
+		})
+	}
+
+	if task.Timeout <= 0 {
+		task.Timeout = s.config.Timeout
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[task.Name]; ok {
+		return cerrors.New(nil, "task is already registered", map[string]interface{}{
+			"task": task.Name,
+		})
+	}
+
+	s.tasks[task.Name] = &scheduledTask{
+		task:     task,
+		schedule: schedule,
+		next:     schedule.Next(s.now()),
+	}
+
+	if s.wake != nil {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Tasks returns the registered tasks sorted by name
+func (s *Scheduler) Tasks() []TaskStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]TaskStatus, 0, len(s.tasks))
+
+	for _, t := range s.tasks {
+		statuses = append(statuses, TaskStatus{
+			Name:     t.task.Name,
+			Schedule: t.task.Schedule,
+			Next:     t.next,
+			Running:  t.running,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses
+}
+
+// History returns up to limit runs of the task, most recent first
+func (s *Scheduler) History(ctx context.Context, task string, limit int) ([]Run, error) {
+	return s.history.List(ctx, task, limit)
+}
+
+// Run starts the scheduler in the background. When the app's lifecycle stops, no new runs are started and the
+// scheduler waits for the running ones to finish. Runs that are still running when the stop deadline expires are
+// canceled.
+func (s *Scheduler) Run() error {
+	var (
+		loopCtx, stopLoop  = context.WithCancel(context.Background())
+		runsCtx, cancelRun = context.WithCancel(context.Background())
+		loopDone           = make(chan struct{})
+		running            sync.WaitGroup
+	)
+
+	s.mu.Lock()
+	s.wake = make(chan struct{}, 1)
+	s.mu.Unlock()
+
+	go func() {
+		defer close(loopDone)
+		s.loop(loopCtx, runsCtx, &running)
+	}()
+
+	s.lc.OnStop(func(stopCtx context.Context) error {
+		s.logger.Info("Stopping scheduler..")
+
+		stopLoop()
+		<-loopDone
+
+		done := make(chan struct{})
+
+		go func() {
+			running.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			cancelRun()
+			return nil
+		case <-stopCtx.Done():
+			cancelRun()
+			return cerrors.New(stopCtx.Err(), "tasks were still running when the scheduler stopped", nil)
+		}
+	})
+
+	return nil
+}
+
+// Stage implements clifecycle.Staged. The scheduler starts once the migrations have run and the servers are listening.
+func (s *Scheduler) Stage() clifecycle.Stage {
+	return clifecycle.Stage{
+		Name:  clifecycle.StageWorkers,
+		After: []string{clifecycle.StageMigrations, clifecycle.StageSeeds, clifecycle.StageHTTP},
+	}
+}
+
+// Trigger runs the task now, regardless of its schedule, and waits for the run to finish. Like scheduled runs, it is
+// skipped if the task is already running.
+func (s *Scheduler) Trigger(ctx context.Context, name string) (*Run, error) {
+	s.mu.Lock()
+	t, ok := s.tasks[name]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, cerrors.New(nil, "task is not registered", map[string]interface{}{
+			"task": name,
+		})
+	}
+
+	return s.execute(ctx, t, s.now())
+}
+
+func (s *Scheduler) loop(ctx, runsCtx context.Context, running *sync.WaitGroup) {
+	for {
+		now := s.now()
+
+		for _, t := range s.dueTasks(now) {
+			running.Add(1)
+
+			go func(t *scheduledTask, tick time.Time) {
+				defer running.Done()
+
+				_, err := s.execute(runsCtx, t, tick)
+				if err != nil {
+					s.logger.WithTags(map[string]interface{}{
+						"task": t.task.Name,
+					}).Error("Failed to run scheduled task", err)
+				}
+			}(t.t, t.tick)
+		}
+
+		timer := time.NewTimer(s.untilNext(now))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+type dueTask struct {
+	t    *scheduledTask
+	tick time.Time
+}
+
+// dueTasks returns the tasks whose next run is due and schedules their following run. Runs that were missed (ex.
+// because the process was suspended) are not caught up.
+func (s *Scheduler) dueTasks(now time.Time) []dueTask {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []dueTask
+
+	for _, t := range s.tasks {
+		if t.next.IsZero() || t.next.After(now) {
+			continue
+		}
+
+		due = append(due, dueTask{t: t, tick: t.next})
+
+		t.next = t.schedule.Next(t.next)
+		if !t.next.After(now) {
+			t.next = t.schedule.Next(now)
+		}
+	}
+
+	return due
+}
+
+// untilNext returns the time until the next task is due
+func (s *Scheduler) untilNext(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	const idle = time.Minute
+
+	wait := idle
+
+	for _, t := range s.tasks {
+		if !t.next.IsZero() && t.next.Sub(now) < wait {
+			wait = t.next.Sub(now)
+		}
+	}
+
+	if wait < 0 {
+		return 0
+	}
+
+	return wait
+}
+
+// execute runs the task for the scheduled run at tick. It returns nil if the run was skipped.
+func (s *Scheduler) execute(ctx context.Context, t *scheduledTask, tick time.Time) (*Run, error) {
+	name := t.task.Name
+	log := s.logger.WithTags(map[string]interface{}{
+		"task": name,
+		"tick": tick,
+	})
+
+	if !s.markRunning(t) {
+		runsTotal.WithLabelValues(name, runStatusSkipped).Inc()
+		log.Warn("Skipped scheduled task because its previous run is still running", nil)
+
+		return nil, nil
+	}
+	defer s.markDone(t)
+
+	acquired, err := s.locker.Acquire(ctx, name, s.instance, tick, t.task.Timeout)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to lock task", nil)
+	}
+
+	if !acquired {
+		runsTotal.WithLabelValues(name, runStatusSkipped).Inc()
+		log.Debug("Skipped scheduled task because it ran on another instance")
+
+		return nil, nil
+	}
+
+	defer func() {
+		err := s.locker.Release(context.Background(), name, s.instance)
+		if err != nil {
+			log.Error("Failed to release task lock", err)
+		}
+	}()
+
+	run := &Run{
+		ID:          newID(),
+		Task:        name,
+		Instance:    s.instance,
+		ScheduledAt: tick,
+		StartedAt:   s.now(),
+	}
+
+	runCtx, cancel := context.WithTimeout(clogger.CtxWithLogger(ctx, log), t.task.Timeout)
+	runErr := runTask(runCtx, t.task)
+	timedOut := errors.Is(runCtx.Err(), context.DeadlineExceeded)
+	cancel()
+
+	run.FinishedAt = s.now()
+
+	switch {
+	case runErr == nil:
+		run.Status = RunStatusSucceeded
+		lastSuccess.WithLabelValues(name).Set(float64(run.FinishedAt.Unix()))
+	case timedOut:
+		run.Status = RunStatusTimedOut
+		run.Error = runErr.Error()
+		log.Error("Scheduled task timed out", runErr)
+	default:
+		run.Status = RunStatusFailed
+		run.Error = runErr.Error()
+		log.Error("Scheduled task failed", runErr)
+	}
+
+	runsTotal.WithLabelValues(name, run.Status).Inc()
+	runDuration.WithLabelValues(name).Observe(run.Duration().Seconds())
+
+	err = s.history.Record(context.Background(), run)
+	if err != nil {
+		return run, cerrors.New(err, "failed to record run", nil)
+	}
+
+	return run, nil
+}
+
+func (s *Scheduler) markRunning(t *scheduledTask) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t.running {
+		return false
+	}
+
+	t.running = true
+
+	return true
+}
+
+func (s *Scheduler) markDone(t *scheduledTask) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t.running = false
+}
+
+func runTask(ctx context.Context, task Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = cerrors.New(nil, fmt.Sprintf("task panicked: %v", r), nil)
+		}
+	}()
+
+	return task.Run(ctx)
+}
+
+func newID() string {
+	const idBytes = 16
+
+	b := make([]byte, idBytes)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// newInstanceID returns a unique id prefixed by the host name so that instances can be identified in the history
+func newInstanceID() string {
+	const idBytes = 6
+
+	b := make([]byte, idBytes)
+	_, _ = rand.Read(b)
+
+	host, _ := os.Hostname()
+	if host == "" {
+		return hex.EncodeToString(b)
+	}
+
+	return host + "-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,170 @@
+package ccron_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/ccron"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler_Trigger(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx       = context.Background()
+		scheduler = newScheduler(t, clifecycle.New(), ccron.NewMemoryLocker())
+	)
+
+	assert.NoError(t, scheduler.Register(ccron.Task{
+		Name:     "cleanup",
+		Schedule: "@daily",
+		Run:      func(ctx context.Context) error { return nil },
+	}))
+
+	assert.NoError(t, scheduler.Register(ccron.Task{
+		Name:     "report",
+		Schedule: "@daily",
+		Run:      func(ctx context.Context) error { return errors.New("report failed") },
+	}))
+
+	assert.NoError(t, scheduler.Register(ccron.Task{
+		Name:     "slow",
+		Schedule: "@daily",
+		Timeout:  10 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}))
+
+	assert.Error(t, scheduler.Register(ccron.Task{
+		Name:     "cleanup",
+		Schedule: "@daily",
+		Run:      func(ctx context.Context) error { return nil },
+	}))
+
+	assert.Error(t, scheduler.Register(ccron.Task{
+		Name:     "invalid",
+		Schedule: "every day",
+		Run:      func(ctx context.Context) error { return nil },
+	}))
+
+	for name, status := range map[string]string{
+		"cleanup": ccron.RunStatusSucceeded,
+		"report":  ccron.RunStatusFailed,
+		"slow":    ccron.RunStatusTimedOut,
+	} {
+		run, err := scheduler.Trigger(ctx, name)
+		assert.NoError(t, err)
+		assert.Equal(t, status, run.Status, name)
+
+		history, err := scheduler.History(ctx, name, 10)
+		assert.NoError(t, err)
+		assert.Len(t, history, 1)
+		assert.Equal(t, run.ID, history[0].ID)
+	}
+
+	_, err := scheduler.Trigger(ctx, "unknown")
+	assert.Error(t, err)
+
+	tasks := scheduler.Tasks()
+	assert.Len(t, tasks, 3)
+	assert.Equal(t, "cleanup", tasks[0].Name)
+	assert.True(t, tasks[0].Next.After(time.Now()))
+}
+
+func TestScheduler_Trigger_Overlap(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx       = context.Background()
+		scheduler = newScheduler(t, clifecycle.New(), ccron.NewMemoryLocker())
+		started   = make(chan struct{})
+		release   = make(chan struct{})
+	)
+
+	assert.NoError(t, scheduler.Register(ccron.Task{
+		Name:     "sync",
+		Schedule: "@hourly",
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-release
+
+			return nil
+		},
+	}))
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		run, err := scheduler.Trigger(ctx, "sync")
+		assert.NoError(t, err)
+		assert.NotNil(t, run)
+	}()
+
+	<-started
+
+	// the task is skipped while its previous run is still running
+	run, err := scheduler.Trigger(ctx, "sync")
+	assert.NoError(t, err)
+	assert.Nil(t, run)
+
+	close(release)
+	<-done
+}
+
+func TestScheduler_Run(t *testing.T) {
+	t.Parallel()
+
+	var (
+		lc     = clifecycle.New()
+		locker = ccron.NewMemoryLocker()
+		runs   int32
+	)
+
+	// both instances share the locker, so each scheduled run only runs once
+	for i := 0; i < 2; i++ {
+		scheduler := newScheduler(t, lc, locker)
+
+		assert.NoError(t, scheduler.Run())
+		assert.NoError(t, scheduler.Register(ccron.Task{
+			Name:     "tick",
+			Schedule: "* * * * * *",
+			Run: func(ctx context.Context) error {
+				atomic.AddInt32(&runs, 1)
+				return nil
+			},
+		}))
+	}
+
+	time.Sleep(2500 * time.Millisecond)
+
+	lc.Stop(clogger.NewNoop())
+
+	n := atomic.LoadInt32(&runs)
+	assert.GreaterOrEqual(t, n, int32(2))
+	assert.LessOrEqual(t, n, int32(3))
+}
+
+func newScheduler(t *testing.T, lc *clifecycle.Lifecycle, locker ccron.Locker) *ccron.Scheduler {
+	t.Helper()
+
+	scheduler, err := ccron.NewScheduler(ccron.NewSchedulerParams{
+		Locker:    locker,
+		History:   ccron.NewMemoryHistory(10),
+		Lifecycle: lc,
+		Config:    ccron.Config{Timeout: time.Minute, Timezone: "UTC"},
+		Logger:    clogger.NewNoop(),
+	})
+	assert.NoError(t, err)
+
+	return scheduler
+}
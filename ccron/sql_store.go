@@ -0,0 +1,205 @@
+package ccron
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/csql"
+)
+
+// Migrations holds the database schema needed by SQLStore. Register them using csql.RegisterMigrations so that they
+// are applied by csql.Migrator along with the app's migrations:
+//
+//	csql.RegisterMigrations(csql.ModuleMigrations{Module: "ccron", FS: ccron.Migrations})
+//
+// MySQL uses the variant in migrations.mysql.sql (see csql.ModuleMigrations).
+//
+//go:embed migrations.sql migrations.mysql.sql
+var Migrations embed.FS
+
+// NewSQLStoreParams holds the params needed for NewSQLStore
+type NewSQLStoreParams struct {
+	DB        *sql.DB
+	Querier   csql.Querier
+	SQLConfig csql.Config
+	Config    Config
+}
+
+// NewSQLStore creates a new SQLStore
+func NewSQLStore(p NewSQLStoreParams) *SQLStore {
+	return &SQLStore{
+		db:          p.DB,
+		querier:     p.Querier,
+		dialect:     p.SQLConfig.Dialect,
+		historySize: p.Config.HistorySize,
+		now:         time.Now,
+	}
+}
+
+// SQLStore is a Locker and a History that uses the ccron_locks and ccron_runs tables of the app's database (see
+// Migrations). Each operation runs in its own transaction.
+type SQLStore struct {
+	db          *sql.DB
+	querier     csql.Querier
+	dialect     string
+	historySize int
+	now         func() time.Time
+}
+
+// Acquire locks the task for owner. See Locker.
+func (s *SQLStore) Acquire(ctx context.Context, task, owner string, tick time.Time, ttl time.Duration) (bool,
+	error) {
+	const (
+		lockQuery = `
+		update ccron_locks
+		set owner = ?, locked_until = ?, last_tick = ?
+		where task = ? and locked_until <= ? and last_tick < ?`
+
+		insertQuery = `insert into ccron_locks (task, owner, locked_until, last_tick) values (?, ?, ?, ?)`
+	)
+
+	var (
+		now      = s.now()
+		acquired bool
+	)
+
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		res, err := s.querier.Exec(ctx, lockQuery, owner, now.Add(ttl), tick, task, now, tick)
+		if err != nil {
+			return err
+		}
+
+		n, _ := res.RowsAffected()
+		acquired = n == 1
+
+		return nil
+	})
+	if err != nil || acquired {
+		return acquired, err
+	}
+
+	exists, err := s.lockExists(ctx, task)
+	if err != nil || exists {
+		return false, err
+	}
+
+	// the task was never locked, so the first instance to insert its lock acquires it
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		_, err := s.querier.Exec(ctx, insertQuery, task, owner, now.Add(ttl), tick)
+
+		return err
+	})
+	if err != nil {
+		if exists, _ := s.lockExists(ctx, task); exists {
+			return false, nil
+		}
+
+		return false, cerrors.New(err, "failed to insert task lock", map[string]interface{}{
+			"task": task,
+		})
+	}
+
+	return true, nil
+}
+
+// Release unlocks the task if it is locked by owner
+func (s *SQLStore) Release(ctx context.Context, task, owner string) error {
+	const query = `update ccron_locks set locked_until = ? where task = ? and owner = ?`
+
+	return s.inTx(ctx, func(ctx context.Context) error {
+		_, err := s.querier.Exec(ctx, query, s.now(), task, owner)
+
+		return err
+	})
+}
+
+// Record saves a finished run and deletes the task's runs beyond the history size
+func (s *SQLStore) Record(ctx context.Context, run *Run) error {
+	const (
+		insertQuery = `
+		insert into ccron_runs (id, task, instance, scheduled_at, started_at, finished_at, status, error)
+		values (?, ?, ?, ?, ?, ?, ?, ?)`
+
+		cutoffQuery = `
+		select started_at from ccron_runs
+		where task = ?
+		order by started_at desc
+		limit 1 offset ?`
+
+		pruneQuery = `delete from ccron_runs where task = ? and started_at < ?`
+	)
+
+	return s.inTx(ctx, func(ctx context.Context) error {
+		_, err := s.querier.Exec(ctx, insertQuery, run.ID, run.Task, run.Instance, run.ScheduledAt, run.StartedAt,
+			run.FinishedAt, run.Status, run.Error)
+		if err != nil {
+			return cerrors.New(err, "failed to insert run", map[string]interface{}{
+				"task": run.Task,
+			})
+		}
+
+		var cutoff time.Time
+
+		err = s.querier.Get(ctx, &cutoff, cutoffQuery, run.Task, s.historySize-1)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		} else if err != nil {
+			return cerrors.New(err, "failed to query history cutoff", nil)
+		}
+
+		_, err = s.querier.Exec(ctx, pruneQuery, run.Task, cutoff)
+
+		return err
+	})
+}
+
+// List returns up to limit runs of the task, most recent first
+func (s *SQLStore) List(ctx context.Context, task string, limit int) ([]Run, error) {
+	const query = `
+	select * from ccron_runs
+	where task = ?
+	order by started_at desc
+	limit ?`
+
+	var runs []Run
+
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		return s.querier.Select(ctx, &runs, query, task, limit)
+	})
+
+	return runs, err
+}
+
+func (s *SQLStore) lockExists(ctx context.Context, task string) (bool, error) {
+	var n int
+
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		return s.querier.Get(ctx, &n, `select count(*) from ccron_locks where task = ?`, task)
+	})
+	if err != nil {
+		return false, cerrors.New(err, "failed to query task lock", map[string]interface{}{
+			"task": task,
+		})
+	}
+
+	return n > 0, nil
+}
+
+func (s *SQLStore) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, tx, err := csql.CtxWithTx(ctx, s.db, s.dialect)
+	if err != nil {
+		return err
+	}
+
+	err = fn(ctx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
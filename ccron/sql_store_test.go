@@ -0,0 +1,93 @@
+package ccron_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/ccron"
+	"github.com/gocopper/copper/csql"
+	"github.com/gocopper/copper/csql/csqltest"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLStore_Acquire(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx   = context.Background()
+		store = newSQLStore(t, 10)
+		tick  = time.Now().Truncate(time.Minute)
+	)
+
+	ok, err := store.Acquire(ctx, "report", "a", tick, time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// locked by another owner
+	ok, err = store.Acquire(ctx, "report", "b", tick.Add(time.Minute), time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, store.Release(ctx, "report", "b"))
+
+	ok, err = store.Acquire(ctx, "report", "b", tick.Add(time.Minute), time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, store.Release(ctx, "report", "a"))
+
+	// the tick already ran
+	ok, err = store.Acquire(ctx, "report", "b", tick, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = store.Acquire(ctx, "report", "b", tick.Add(time.Minute), time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestSQLStore_Record(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx   = context.Background()
+		store = newSQLStore(t, 2)
+		start = time.Now()
+	)
+
+	for i := 0; i < 3; i++ {
+		startedAt := start.Add(time.Duration(i) * time.Minute)
+
+		assert.NoError(t, store.Record(ctx, &ccron.Run{
+			ID:          string(rune('a' + i)),
+			Task:        "report",
+			Instance:    "web-1",
+			ScheduledAt: startedAt,
+			StartedAt:   startedAt,
+			FinishedAt:  startedAt.Add(time.Second),
+			Status:      ccron.RunStatusSucceeded,
+		}))
+	}
+
+	runs, err := store.List(ctx, "report", 10)
+	assert.NoError(t, err)
+	assert.Len(t, runs, 2)
+	assert.Equal(t, "c", runs[0].ID)
+	assert.Equal(t, "b", runs[1].ID)
+	assert.Equal(t, time.Second, runs[0].Duration())
+}
+
+func newSQLStore(t *testing.T, historySize int) *ccron.SQLStore {
+	t.Helper()
+
+	db := csqltest.NewDB(t, csqltest.Options{Migrations: csql.Migrations(ccron.Migrations)})
+
+	return ccron.NewSQLStore(ccron.NewSQLStoreParams{
+		DB:        db.DB,
+		Querier:   db.Querier,
+		SQLConfig: db.Config,
+		Config:    ccron.Config{HistorySize: historySize},
+	})
+}
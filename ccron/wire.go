@@ -0,0 +1,15 @@
+package ccron
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+	NewLocker,
+	wire.Struct(new(NewLockerParams), "*"),
+	NewHistory,
+	wire.Struct(new(NewHistoryParams), "*"),
+
+	NewScheduler,
+	wire.Struct(new(NewSchedulerParams), "*"),
+)
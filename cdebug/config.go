@@ -0,0 +1,63 @@
+package cdebug
+
+import (
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+const defaultPath = "/debug"
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "cdebug",
+		Description: "cdebug configures the pprof and expvar endpoints",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("cdebug", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load cdebug config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Path: defaultPath,
+	}
+}
+
+// Config configures the debug endpoints. For example, in config/prod.toml:
+//
+//	[cdebug]
+//	enabled = true
+//	token = "${env:DEBUG_TOKEN}"
+//
+// and then:
+//
+//	go tool pprof -http=:8080 -H "Authorization: Bearer $DEBUG_TOKEN" https://app.example.com/debug/pprof/heap
+type Config struct {
+	Enabled bool   `toml:"enabled" doc:"Serve the pprof and expvar endpoints"`
+	Path    string `toml:"path" doc:"Path prefix of the debug endpoints"`
+
+	// Token lets requests from any IP access the endpoints if they send it as a bearer token in the Authorization
+	// header. If it is empty, only AllowedIPs can access the endpoints.
+	Token string `toml:"token" secret:"true" doc:"Bearer token that grants access from any IP"`
+
+	// AllowedIPs are the IPs and CIDR ranges that can access the endpoints without a token. It is empty by default
+	// since behind a reverse proxy on the same host every request comes from a loopback IP unless
+	// chttp.ClientIPMiddleware runs and the proxy is one of chttp.trusted_proxies. Only list loopback IPs if the app
+	// is not behind a local proxy.
+	AllowedIPs []string `toml:"allowed_ips" doc:"IPs and CIDR ranges that can access the endpoints without a token"`
+
+	// BlockProfileRate and MutexProfileFraction enable the block and mutex profiles, which are disabled by default
+	// since they add overhead (see runtime.SetBlockProfileRate and runtime.SetMutexProfileFraction)
+	BlockProfileRate     int `toml:"block_profile_rate" doc:"Nanoseconds between block profile samples (disabled if 0)"`
+	MutexProfileFraction int `toml:"mutex_profile_fraction" doc:"1 in N mutex contention events are sampled (disabled if 0)"`
+}
@@ -0,0 +1,6 @@
+// Package cdebug serves Go's runtime debug endpoints (net/http/pprof and expvar) so that the app can be profiled in
+// production without deploying an instrumented build. The endpoints are disabled by default and, once enabled, are
+// only served to requests that present the configured token or come from the configured IPs since they reveal the
+// app's internals and profiling slows the app down. They can be enabled per environment using the environment's
+// config overlay.
+package cdebug
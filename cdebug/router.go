@@ -0,0 +1,217 @@
+package cdebug
+
+import (
+	"crypto/subtle"
+	"expvar"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+)
+
+// NewRouterParams holds the params needed for NewRouter
+type NewRouterParams struct {
+	Config Config
+	Logger clogger.Logger
+}
+
+// NewRouter creates a new Router. It returns an error if one of cdebug.allowed_ips is not an IP or a CIDR range. If
+// the endpoints are enabled, the block and mutex profiles are enabled as configured.
+func NewRouter(p NewRouterParams) (*Router, error) {
+	allowed := make([]*net.IPNet, len(p.Config.AllowedIPs))
+
+	for i, ip := range p.Config.AllowedIPs {
+		ipNet, err := parseIPNet(ip)
+		if err != nil {
+			return nil, cerrors.New(err, "invalid allowed ip", map[string]interface{}{
+				"ip": ip,
+			})
+		}
+
+		allowed[i] = ipNet
+	}
+
+	if p.Config.Enabled {
+		if p.Config.BlockProfileRate > 0 {
+			runtime.SetBlockProfileRate(p.Config.BlockProfileRate)
+		}
+
+		if p.Config.MutexProfileFraction > 0 {
+			runtime.SetMutexProfileFraction(p.Config.MutexProfileFraction)
+		}
+
+		if p.Config.Token == "" && len(p.Config.AllowedIPs) == 0 {
+			p.Logger.Warn("Debug endpoints are enabled but cannot be accessed since cdebug.token and "+
+				"cdebug.allowed_ips are not set", nil)
+		} else if p.Config.Token == "" {
+			p.Logger.WithTags(map[string]interface{}{
+				"allowedIPs": p.Config.AllowedIPs,
+			}).Info("Debug endpoints are enabled for the allowed IPs only since cdebug.token is not set")
+		}
+	}
+
+	return &Router{
+		allowed: allowed,
+		config:  p.Config,
+		logger:  p.Logger.With(clogger.Module("cdebug")),
+	}, nil
+}
+
+// Router is a chttp.Router that serves the debug endpoints if Config.Enabled is set (paths are relative to
+// cdebug.path):
+//
+//	GET /pprof/           index of the profiles
+//	GET /pprof/profile    CPU profile (?seconds=30)
+//	GET /pprof/trace      execution trace (?seconds=5)
+//	GET /pprof/{profile}  heap, allocs, goroutine, block, mutex, and threadcreate profiles
+//	GET /vars             expvar variables as JSON
+//
+// Since the CPU profile and the trace take a while, chttp.write_timeout must be longer than their duration.
+type Router struct {
+	allowed []*net.IPNet
+	config  Config
+	logger  clogger.Logger
+}
+
+// Routes returns the debug routes or no routes if the endpoints are disabled
+func (ro *Router) Routes() []chttp.Route {
+	if !ro.config.Enabled {
+		return nil
+	}
+
+	var (
+		mw     = []chttp.Middleware{chttp.HandleMiddleware(ro.authorize)}
+		prefix = strings.TrimSuffix(ro.config.Path, "/")
+	)
+
+	return []chttp.Route{
+		{
+			Middlewares: mw,
+			Path:        prefix + "/pprof/",
+			Methods:     []string{http.MethodGet},
+			Handler:     pprof.Index,
+		},
+		{
+			Middlewares: mw,
+			Path:        prefix + "/pprof/cmdline",
+			Methods:     []string{http.MethodGet},
+			Handler:     pprof.Cmdline,
+		},
+		{
+			Middlewares: mw,
+			Path:        prefix + "/pprof/profile",
+			Methods:     []string{http.MethodGet},
+			Handler:     pprof.Profile,
+		},
+		{
+			Middlewares: mw,
+			Path:        prefix + "/pprof/symbol",
+			Methods:     []string{http.MethodGet, http.MethodPost},
+			Handler:     pprof.Symbol,
+		},
+		{
+			Middlewares: mw,
+			Path:        prefix + "/pprof/trace",
+			Methods:     []string{http.MethodGet},
+			Handler:     pprof.Trace,
+		},
+		{
+			Middlewares: mw,
+			Path:        prefix + "/pprof/{profile}",
+			Methods:     []string{http.MethodGet},
+			Handler:     ro.HandleProfile,
+		},
+		{
+			Middlewares: mw,
+			Path:        prefix + "/vars",
+			Methods:     []string{http.MethodGet},
+			Handler:     expvar.Handler().ServeHTTP,
+		},
+	}
+}
+
+// HandleProfile serves the runtime profile in the profile URL param (ex. heap)
+func (ro *Router) HandleProfile(w http.ResponseWriter, r *http.Request) {
+	pprof.Handler(chttp.URLParams(r)["profile"]).ServeHTTP(w, r)
+}
+
+// authorize only lets the requests from Config.AllowedIPs or with Config.Token through
+func (ro *Router) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ro.hasToken(r) || ro.isAllowedIP(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ro.logger.WithTags(map[string]interface{}{
+			"path":       r.URL.Path,
+			"remoteAddr": r.RemoteAddr,
+		}).Warn("Rejected unauthorized request to debug endpoint", nil)
+
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	})
+}
+
+func (ro *Router) hasToken(r *http.Request) bool {
+	if ro.config.Token == "" {
+		return false
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(ro.config.Token)) == 1
+}
+
+// isAllowedIP checks the IP resolved by chttp.ClientIPMiddleware, or the IP of the connection if the middleware did
+// not run, against Config.AllowedIPs
+func (ro *Router) isAllowedIP(r *http.Request) bool {
+	addr := chttp.ClientIP(r.Context())
+	if addr == "" {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return false
+		}
+
+		addr = host
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, ipNet := range ro.allowed {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseIPNet parses an IP or a CIDR range. An IP is treated as a range that only contains itself.
+func parseIPNet(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, ipNet, err := net.ParseCIDR(s)
+		return ipNet, err
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, cerrors.New(nil, "invalid ip", map[string]interface{}{
+			"ip": s,
+		})
+	}
+
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
@@ -0,0 +1,132 @@
+package cdebug_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cconfig/cconfigtest"
+	"github.com/gocopper/copper/cdebug"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func newHandler(t *testing.T, config cdebug.Config) http.Handler {
+	t.Helper()
+
+	router, err := cdebug.NewRouter(cdebug.NewRouterParams{
+		Config: config,
+		Logger: clogger.NewNoop(),
+	})
+	assert.NoError(t, err)
+
+	return chttp.NewHandler(chttp.NewHandlerParams{
+		Routers: []chttp.Router{router},
+		Logger:  clogger.NewNoop(),
+	})
+}
+
+func TestRouter_AllowedIPs(t *testing.T) {
+	t.Parallel()
+
+	handler := newHandler(t, cdebug.Config{
+		Enabled:    true,
+		Path:       "/debug",
+		AllowedIPs: []string{"127.0.0.0/8", "::1"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/pprof/heap?debug=1", nil)
+	req.RemoteAddr = "127.0.0.1:52001"
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "heap profile")
+
+	req = httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+	req.RemoteAddr = "[::1]:52001"
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+	req.RemoteAddr = "203.0.113.7:52001"
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
+
+func TestRouter_DefaultConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := cconfigtest.SetupDirWithConfigs(t, map[string]string{
+		"test.toml": "[cdebug]\nenabled = true\n",
+	})
+
+	loader, err := cconfig.New(cconfig.Path(path.Join(dir, "test.toml")), "")
+	assert.NoError(t, err)
+
+	config, err := cdebug.LoadConfig(loader)
+	assert.NoError(t, err)
+
+	handler := newHandler(t, config)
+
+	// requests forwarded by a local reverse proxy come from a loopback IP
+	req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+	req.RemoteAddr = "127.0.0.1:52001"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
+
+func TestRouter_Token(t *testing.T) {
+	t.Parallel()
+
+	handler := newHandler(t, cdebug.Config{
+		Enabled: true,
+		Path:    "/debug",
+		Token:   "test-token",
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+	req.Header.Set("Authorization", "Bearer test-token")
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "memstats")
+
+	req = httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+	req.Header.Set("Authorization", "Bearer wrong-token")
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
+
+func TestRouter_Disabled(t *testing.T) {
+	t.Parallel()
+
+	handler := newHandler(t, cdebug.Config{Path: "/debug", AllowedIPs: []string{"0.0.0.0/0"}})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestNewRouter_InvalidAllowedIP(t *testing.T) {
+	t.Parallel()
+
+	_, err := cdebug.NewRouter(cdebug.NewRouterParams{
+		Config: cdebug.Config{AllowedIPs: []string{"localhost"}},
+		Logger: clogger.NewNoop(),
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,88 @@
+package cdiag
+
+import (
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	defaultPath                 = "/_copper/diagnostics"
+	defaultRecorderSize         = 100
+	defaultRecorderMaxBodyBytes = 64 << 10
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "cdiag",
+		Description: "cdiag configures the diagnostics endpoint",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("cdiag", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load cdiag config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Path: defaultPath,
+		Recorder: ConfigRecorder{
+			Size:         defaultRecorderSize,
+			MaxBodyBytes: defaultRecorderMaxBodyBytes,
+			RedactHeaders: []string{
+				"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Csrf-Token",
+			},
+			RedactFields: []string{"password", "token", "secret", "api_key", "card_number", "cvc"},
+		},
+	}
+}
+
+// Config configures the diagnostics endpoint. For example:
+//
+//	[cdiag]
+//	enabled = true
+//	path = "/_copper/diagnostics"
+type Config struct {
+	Enabled bool   `toml:"enabled" doc:"Serve the diagnostics endpoint"`
+	Path    string `toml:"path" doc:"Path of the diagnostics endpoint"`
+
+	Recorder ConfigRecorder `toml:"recorder"`
+}
+
+// ConfigRecorder configures the Recorder that captures requests for debugging. It should only be enabled in
+// development or staging. For example:
+//
+//	[cdiag.recorder]
+//	enabled = true
+//	size = 200
+//	file = "tmp/requests.jsonl"
+type ConfigRecorder struct {
+	Enabled bool `toml:"enabled" doc:"Capture requests and responses"`
+
+	// Size is the number of most recent requests that are kept in memory
+	Size int `toml:"size" doc:"Number of requests kept in memory"`
+
+	// File is appended with each captured request as a line of JSON if set
+	File string `toml:"file" doc:"Append captured requests to this file as JSON lines"`
+
+	// MaxBodyBytes is the max size of a captured request or response body. Larger bodies are truncated.
+	MaxBodyBytes int `toml:"max_body_bytes" doc:"Max size of a captured body"`
+
+	// RedactHeaders are the headers whose values are redacted (case-insensitive)
+	RedactHeaders []string `toml:"redact_headers" doc:"Headers whose values are redacted"`
+
+	// RedactFields are the query params, form fields, and JSON fields whose values are redacted. They match any
+	// field whose name contains one of them (case-insensitive).
+	RedactFields []string `toml:"redact_fields" doc:"Query params, form fields, and JSON fields whose values are redacted"`
+
+	// Exclude are path prefixes of requests that are not captured. The diagnostics endpoints are never captured.
+	Exclude []string `toml:"exclude" doc:"Path prefixes of requests that are not captured"`
+}
@@ -0,0 +1,7 @@
+// Package cdiag serves the app's diagnostics (see copper.Diagnostics) over HTTP: the lifecycle hooks in the order
+// their constructors registered them and the timing of the app's startup steps. The endpoint is disabled by default
+// and should only be enabled in development or behind authentication since it reveals the app's internals.
+//
+// Recorder is a chttp.Middleware that captures requests and responses with their secrets redacted. They are served by
+// the diagnostics endpoint and can be exported as a HAR file to reproduce client issues in development or staging.
+package cdiag
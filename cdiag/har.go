@@ -0,0 +1,181 @@
+package cdiag
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// HAR is an HTTP Archive (http://www.softwareishard.com/blog/har-12-spec/) of the captured requests. It can be
+// imported in browser dev tools and HTTP clients to inspect or replay the requests.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the log of a HAR
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator is the app that created a HAR
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a request and its response in a HAR
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+// HARRequest is a request in a HAR
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARResponse is a response in a HAR
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARNameValue is a header, cookie, or query param in a HAR
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is the body of a request in a HAR
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent is the body of a response in a HAR
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+// HARTimings are the timings of a request in a HAR. Only the time spent in the app is known, so it is reported as
+// the wait time.
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HAR returns the captured requests as a HAR, oldest first
+func (rec *Recorder) HAR() HAR {
+	entries := rec.Entries()
+
+	har := HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "copper", Version: "1"},
+		Entries: make([]HAREntry, 0, len(entries)),
+	}}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		har.Log.Entries = append(har.Log.Entries, harEntry(entries[i]))
+	}
+
+	return har
+}
+
+func harEntry(e Entry) HAREntry {
+	req := HARRequest{
+		Method:      e.Method,
+		URL:         e.URL,
+		HTTPVersion: e.Proto,
+		Cookies:     []HARNameValue{},
+		Headers:     harHeaders(e.RequestHeader),
+		QueryString: []HARNameValue{},
+		HeadersSize: -1,
+		BodySize:    len(e.RequestBody),
+	}
+
+	if u, err := url.Parse(e.URL); err == nil {
+		req.QueryString = harValues(u.Query())
+	}
+
+	if e.RequestBody != "" {
+		req.PostData = &HARPostData{
+			MimeType: e.RequestHeader.Get("Content-Type"),
+			Text:     e.RequestBody,
+		}
+	}
+
+	return HAREntry{
+		StartedDateTime: e.StartedAt.Format(time.RFC3339Nano),
+		Time:            e.DurationMillis,
+		Request:         req,
+		Response: HARResponse{
+			Status:      e.StatusCode,
+			StatusText:  http.StatusText(e.StatusCode),
+			HTTPVersion: e.Proto,
+			Cookies:     []HARNameValue{},
+			Headers:     harHeaders(e.ResponseHeader),
+			Content: HARContent{
+				Size:     len(e.ResponseBody),
+				MimeType: e.ResponseHeader.Get("Content-Type"),
+				Text:     e.ResponseBody,
+			},
+			RedirectURL: e.ResponseHeader.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(e.ResponseBody),
+		},
+		Timings: HARTimings{Wait: e.DurationMillis},
+	}
+}
+
+func harHeaders(h http.Header) []HARNameValue {
+	return harValues(url.Values(h))
+}
+
+func harValues(values url.Values) []HARNameValue {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	pairs := make([]HARNameValue, 0, len(values))
+
+	for _, name := range names {
+		for _, v := range values[name] {
+			pairs = append(pairs, HARNameValue{Name: name, Value: v})
+		}
+	}
+
+	return pairs
+}
+
+// harFileName returns the name of the HAR file downloaded from Router
+func harFileName(now time.Time) string {
+	return "requests-" + now.UTC().Format("20060102-150405") + ".har"
+}
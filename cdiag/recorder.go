@@ -0,0 +1,447 @@
+package cdiag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+)
+
+const redactedValue = "REDACTED"
+
+// Placeholders for the bodies that are not captured
+const (
+	binaryBodyOmitted    = "[binary body omitted]"
+	truncatedBodyOmitted = "[truncated body omitted since it could not be redacted]"
+)
+
+// Entry is a request captured by Recorder along with its response. Secrets are redacted (see ConfigRecorder).
+type Entry struct {
+	ID             int64       `json:"id"`
+	StartedAt      time.Time   `json:"started_at"`
+	DurationMillis float64     `json:"duration_ms"`
+	Method         string      `json:"method"`
+	URL            string      `json:"url"`
+	Proto          string      `json:"proto"`
+	RequestHeader  http.Header `json:"request_header"`
+	RequestBody    string      `json:"request_body,omitempty"`
+	StatusCode     int         `json:"status_code"`
+	ResponseHeader http.Header `json:"response_header"`
+	ResponseBody   string      `json:"response_body,omitempty"`
+
+	// Truncated is set if the request or response body was larger than cdiag.recorder.max_body_bytes
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Curl returns a curl command that replays the request. Redacted values must be filled in before running it.
+func (e Entry) Curl() string {
+	var b strings.Builder
+
+	b.WriteString("curl -X " + e.Method + " " + shellQuote(e.URL))
+
+	for name, values := range e.RequestHeader {
+		for _, v := range values {
+			b.WriteString(" -H " + shellQuote(name+": "+v))
+		}
+	}
+
+	if e.RequestBody != "" {
+		b.WriteString(" --data-raw " + shellQuote(e.RequestBody))
+	}
+
+	return b.String()
+}
+
+// NewRecorderParams holds the params needed for NewRecorder
+type NewRecorderParams struct {
+	Config    Config
+	Lifecycle *clifecycle.Lifecycle
+	Logger    clogger.Logger
+}
+
+// NewRecorder creates a new Recorder. If cdiag.recorder.file is set, it is opened for appending until the app stops.
+func NewRecorder(p NewRecorderParams) (*Recorder, error) {
+	config := p.Config.Recorder
+
+	rec := &Recorder{
+		config:        config,
+		basePath:      p.Config.Path,
+		logger:        p.Logger.With(clogger.Module("cdiag")),
+		entries:       make([]Entry, 0, config.Size),
+		redactHeaders: make(map[string]bool, len(config.RedactHeaders)),
+		redactFields:  make([]string, 0, len(config.RedactFields)),
+	}
+
+	for _, h := range config.RedactHeaders {
+		rec.redactHeaders[http.CanonicalHeaderKey(h)] = true
+	}
+
+	for _, f := range config.RedactFields {
+		rec.redactFields = append(rec.redactFields, strings.ToLower(f))
+	}
+
+	if !config.Enabled || config.File == "" {
+		return rec, nil
+	}
+
+	file, err := os.OpenFile(config.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to open recorder file", map[string]interface{}{
+			"file": config.File,
+		})
+	}
+
+	rec.file = file
+
+	p.Lifecycle.OnStop(func(ctx context.Context) error {
+		rec.fileMu.Lock()
+		defer rec.fileMu.Unlock()
+
+		return file.Close()
+	})
+
+	return rec, nil
+}
+
+// Recorder is a chttp.Middleware that captures requests and their responses (headers, bodies, and timing) so that
+// hard-to-debug client issues can be reproduced. The most recent requests are kept in memory and served by Router,
+// including as a HAR file that can be imported in browser dev tools. Secrets in headers, query params, and form or
+// JSON bodies are redacted and binary bodies are omitted.
+// It is disabled unless cdiag.recorder.enabled is set, and should only be enabled in development or staging.
+type Recorder struct {
+	config        ConfigRecorder
+	basePath      string
+	logger        clogger.Logger
+	redactHeaders map[string]bool
+	redactFields  []string
+
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+	lastID  int64
+
+	fileMu sync.Mutex
+	file   *os.File
+}
+
+// Entries returns the captured requests, most recent first
+func (rec *Recorder) Entries() []Entry {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	entries := make([]Entry, 0, len(rec.entries))
+
+	for i := 1; i <= len(rec.entries); i++ {
+		entries = append(entries, rec.entries[(rec.next-i+len(rec.entries))%len(rec.entries)])
+	}
+
+	return entries
+}
+
+// Entry returns the captured request with the given id and false if it is no longer held
+func (rec *Recorder) Entry(id int64) (Entry, bool) {
+	for _, e := range rec.Entries() {
+		if e.ID == id {
+			return e, true
+		}
+	}
+
+	return Entry{}, false
+}
+
+// Reset removes the captured requests from memory
+func (rec *Recorder) Reset() {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.entries = rec.entries[:0]
+	rec.next = 0
+}
+
+// Handle implements the chttp.Middleware interface. See Recorder
+func (rec *Recorder) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !rec.config.Enabled || rec.excluded(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		startedAt := time.Now()
+
+		reqBody, err := io.ReadAll(io.LimitReader(r.Body, int64(rec.config.MaxBodyBytes)+1))
+		if err != nil {
+			rec.logger.Warn("Failed to capture request body", err)
+		}
+
+		// the handler reads the captured bytes followed by the rest of the body
+		r.Body = readCloser{
+			Reader: io.MultiReader(bytes.NewReader(reqBody), r.Body),
+			Closer: r.Body,
+		}
+
+		cw := &captureWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+			maxBytes:       rec.config.MaxBodyBytes,
+		}
+
+		next.ServeHTTP(cw, r)
+
+		rec.add(rec.entry(r, reqBody, cw, startedAt))
+	})
+}
+
+func (rec *Recorder) entry(r *http.Request, reqBody []byte, cw *captureWriter, startedAt time.Time) Entry {
+	u := *r.URL
+	u.Host = r.Host
+	u.Scheme = "http"
+
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+
+	u.RawQuery = rec.redactValues(u.Query()).Encode()
+
+	reqTruncated := len(reqBody) > rec.config.MaxBodyBytes
+	if reqTruncated {
+		reqBody = reqBody[:rec.config.MaxBodyBytes]
+	}
+
+	respHeader := cw.header
+	if respHeader == nil {
+		respHeader = cw.Header()
+	}
+
+	return Entry{
+		StartedAt:      startedAt,
+		DurationMillis: millis(time.Since(startedAt)),
+		Method:         r.Method,
+		URL:            u.String(),
+		Proto:          r.Proto,
+		RequestHeader:  rec.redactHeader(r.Header),
+		RequestBody:    rec.redactBody(r.Header.Get("Content-Type"), reqBody, reqTruncated),
+		StatusCode:     cw.statusCode,
+		ResponseHeader: rec.redactHeader(respHeader),
+		ResponseBody:   rec.redactBody(respHeader.Get("Content-Type"), cw.body.Bytes(), cw.truncated),
+		Truncated:      reqTruncated || cw.truncated,
+	}
+}
+
+func (rec *Recorder) add(e Entry) {
+	rec.mu.Lock()
+
+	rec.lastID++
+	e.ID = rec.lastID
+
+	if len(rec.entries) < rec.config.Size {
+		rec.entries = append(rec.entries, e)
+		rec.next = len(rec.entries) % rec.config.Size
+	} else if rec.config.Size > 0 {
+		rec.entries[rec.next] = e
+		rec.next = (rec.next + 1) % rec.config.Size
+	}
+
+	rec.mu.Unlock()
+
+	if rec.file == nil {
+		return
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		rec.logger.Warn("Failed to encode captured request", err)
+		return
+	}
+
+	rec.fileMu.Lock()
+	defer rec.fileMu.Unlock()
+
+	_, err = rec.file.Write(append(line, '\n'))
+	if err != nil {
+		rec.logger.Warn("Failed to write captured request", err)
+	}
+}
+
+func (rec *Recorder) excluded(path string) bool {
+	for _, prefix := range append([]string{rec.basePath}, rec.config.Exclude...) {
+		prefix = strings.TrimSuffix(prefix, "/")
+
+		if prefix != "" && (path == prefix || strings.HasPrefix(path, prefix+"/")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rec *Recorder) redactHeader(h http.Header) http.Header {
+	redacted := h.Clone()
+
+	for name, values := range redacted {
+		if !rec.redactHeaders[name] {
+			continue
+		}
+
+		for i := range values {
+			values[i] = redactedValue
+		}
+	}
+
+	return redacted
+}
+
+func (rec *Recorder) redactValues(values url.Values) url.Values {
+	for name, vals := range values {
+		if !rec.isSecretField(name) {
+			continue
+		}
+
+		for i := range vals {
+			vals[i] = redactedValue
+		}
+	}
+
+	return values
+}
+
+// redactBody returns the body as text with the values of secret fields redacted if it is a form or JSON. Binary
+// bodies are omitted, and so are truncated form and JSON bodies since they cannot be parsed.
+func (rec *Recorder) redactBody(contentType string, body []byte, truncated bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil || truncated {
+			return truncatedBodyOmitted
+		}
+
+		return rec.redactValues(values).Encode()
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var data interface{}
+
+		err := json.Unmarshal(body, &data)
+		if err != nil || truncated {
+			return truncatedBodyOmitted
+		}
+
+		redacted, err := json.Marshal(rec.redactJSON(data))
+		if err != nil {
+			return truncatedBodyOmitted
+		}
+
+		return string(redacted)
+	case strings.HasPrefix(mediaType, "text/"), strings.HasSuffix(mediaType, "xml"),
+		mediaType == "application/javascript", mediaType == "" && utf8.Valid(body):
+		return string(body)
+	default:
+		return binaryBodyOmitted
+	}
+}
+
+func (rec *Recorder) redactJSON(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if rec.isSecretField(key) {
+				v[key] = redactedValue
+			} else {
+				v[key] = rec.redactJSON(val)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = rec.redactJSON(v[i])
+		}
+	}
+
+	return data
+}
+
+func (rec *Recorder) isSecretField(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, f := range rec.redactFields {
+		if strings.Contains(name, f) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// captureWriter captures the response written to its ResponseWriter
+type captureWriter struct {
+	http.ResponseWriter
+
+	statusCode  int
+	header      http.Header
+	body        bytes.Buffer
+	maxBytes    int
+	truncated   bool
+	wroteHeader bool
+}
+
+func (cw *captureWriter) WriteHeader(statusCode int) {
+	if cw.wroteHeader {
+		return
+	}
+
+	cw.wroteHeader = true
+	cw.statusCode = statusCode
+	cw.header = cw.ResponseWriter.Header().Clone()
+
+	cw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (cw *captureWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	room := cw.maxBytes - cw.body.Len()
+
+	switch {
+	case room >= len(b):
+		cw.body.Write(b)
+	case room > 0:
+		cw.truncated = true
+		cw.body.Write(b[:room])
+	default:
+		cw.truncated = true
+	}
+
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher so that streamed responses can be captured
+func (cw *captureWriter) Flush() {
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,187 @@
+package cdiag_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gocopper/copper/cdiag"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/chttp/chttptest"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRecorder(t *testing.T, config cdiag.Config) (*cdiag.Recorder, http.Handler) {
+	t.Helper()
+
+	lc := clifecycle.New()
+	t.Cleanup(func() { lc.Stop(clogger.NewNoop()) })
+
+	rec, err := cdiag.NewRecorder(cdiag.NewRecorderParams{
+		Config:    config,
+		Lifecycle: lc,
+		Logger:    clogger.NewNoop(),
+	})
+	assert.NoError(t, err)
+
+	handler := chttp.NewHandler(chttp.NewHandlerParams{
+		Routers: []chttp.Router{
+			chttptest.NewRouter([]chttp.Route{{
+				Path:    "/login",
+				Methods: []string{http.MethodPost},
+				Handler: func(w http.ResponseWriter, r *http.Request) {
+					body, err := io.ReadAll(r.Body)
+					assert.NoError(t, err)
+
+					w.Header().Set("Content-Type", "application/json")
+					http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t"})
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(`{"token":"abc","echo":` + string(body) + `}`))
+				},
+			}}),
+			cdiag.NewRouter(cdiag.NewRouterParams{
+				Recorder: rec,
+				RW:       chttptest.NewReaderWriter(t),
+				Config:   config,
+			}),
+		},
+		GlobalMiddlewares: []chttp.Middleware{rec},
+		Logger:            clogger.NewNoop(),
+	})
+
+	return rec, handler
+}
+
+func testRecorderConfig() cdiag.Config {
+	return cdiag.Config{
+		Enabled: true,
+		Path:    "/_copper/diagnostics",
+		Recorder: cdiag.ConfigRecorder{
+			Enabled:       true,
+			Size:          2,
+			MaxBodyBytes:  1024,
+			RedactHeaders: []string{"authorization", "set-cookie"},
+			RedactFields:  []string{"password", "token"},
+		},
+	}
+}
+
+func login(handler http.Handler, body string) {
+	req := httptest.NewRequest(http.MethodPost, "/login?api_token=t1&next=/home", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer t1")
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	rec, handler := newTestRecorder(t, testRecorderConfig())
+
+	login(handler, `{"email":"a@example.com","password":"hunter2"}`)
+
+	entries := rec.Entries()
+	assert.Len(t, entries, 1)
+
+	e := entries[0]
+	assert.Equal(t, http.MethodPost, e.Method)
+	assert.Equal(t, "http://example.com/login?api_token=REDACTED&next=%2Fhome", e.URL)
+	assert.Equal(t, "REDACTED", e.RequestHeader.Get("Authorization"))
+	assert.JSONEq(t, `{"email":"a@example.com","password":"REDACTED"}`, e.RequestBody)
+	assert.Equal(t, http.StatusCreated, e.StatusCode)
+	assert.Equal(t, "REDACTED", e.ResponseHeader.Get("Set-Cookie"))
+	assert.JSONEq(t, `{"token":"REDACTED","echo":{"email":"a@example.com","password":"REDACTED"}}`, e.ResponseBody)
+	assert.Contains(t, e.Curl(), "curl -X POST 'http://example.com/login?api_token=REDACTED&next=%2Fhome'")
+
+	login(handler, `{"n":2}`)
+	login(handler, `{"n":3}`)
+
+	entries = rec.Entries()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, int64(3), entries[0].ID)
+	assert.Equal(t, int64(2), entries[1].ID)
+
+	har := rec.HAR()
+	assert.Equal(t, "1.2", har.Log.Version)
+	assert.Len(t, har.Log.Entries, 2)
+	assert.Equal(t, `{"n":2}`, har.Log.Entries[0].Request.PostData.Text)
+	assert.Equal(t, "Created", har.Log.Entries[0].Response.StatusText)
+}
+
+func TestRecorder_Router(t *testing.T) {
+	t.Parallel()
+
+	rec, handler := newTestRecorder(t, testRecorderConfig())
+
+	login(handler, `{"n":1}`)
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/_copper/diagnostics/requests", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var requests []cdiag.RecordedRequest
+
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&requests))
+	assert.Len(t, requests, 1)
+	assert.Contains(t, requests[0].URL, "/login?")
+	assert.NotEmpty(t, requests[0].Curl)
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/_copper/diagnostics/requests.har", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Header().Get("Content-Disposition"), ".har")
+
+	var har cdiag.HAR
+
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&har))
+	assert.Len(t, har.Log.Entries, 1)
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodDelete, "/_copper/diagnostics/requests", nil))
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Empty(t, rec.Entries())
+}
+
+func TestRecorder_File(t *testing.T) {
+	t.Parallel()
+
+	config := testRecorderConfig()
+	config.Recorder.File = filepath.Join(t.TempDir(), "requests.jsonl")
+	config.Recorder.MaxBodyBytes = 4
+
+	_, handler := newTestRecorder(t, config)
+
+	login(handler, `{"password":"hunter2"}`)
+
+	data, err := os.ReadFile(config.Recorder.File)
+	assert.NoError(t, err)
+
+	var e cdiag.Entry
+
+	assert.NoError(t, json.NewDecoder(bytes.NewReader(data)).Decode(&e))
+	assert.True(t, e.Truncated)
+	assert.NotContains(t, e.RequestBody, "hunter2")
+}
+
+func TestRecorder_Disabled(t *testing.T) {
+	t.Parallel()
+
+	config := testRecorderConfig()
+	config.Recorder.Enabled = false
+
+	rec, handler := newTestRecorder(t, config)
+
+	login(handler, `{"n":1}`)
+
+	assert.Empty(t, rec.Entries())
+	assert.Len(t, cdiag.NewRouter(cdiag.NewRouterParams{Recorder: rec, Config: config}).Routes(), 1)
+}
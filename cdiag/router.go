@@ -0,0 +1,184 @@
+package cdiag
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gocopper/copper"
+	"github.com/gocopper/copper/chttp"
+)
+
+// NewRouterParams holds the params needed for NewRouter
+type NewRouterParams struct {
+	App      *copper.App
+	Recorder *Recorder
+	RW       *chttp.ReaderWriter
+	Config   Config
+}
+
+// NewRouter creates a new Router
+func NewRouter(p NewRouterParams) *Router {
+	return &Router{
+		app:      p.App,
+		recorder: p.Recorder,
+		rw:       p.RW,
+		config:   p.Config,
+	}
+}
+
+// Router is a chttp.Router that serves the app's diagnostics as JSON at Config.Path if Config.Enabled is set. If
+// Config.Recorder.Enabled is also set, the requests captured by Recorder are served at Config.Path/requests and can
+// be downloaded as a HAR file from Config.Path/requests.har.
+type Router struct {
+	app      *copper.App
+	recorder *Recorder
+	rw       *chttp.ReaderWriter
+	config   Config
+}
+
+// Hook is the JSON representation of a clifecycle.Hook
+type Hook struct {
+	Name                  string  `json:"name"`
+	RegisteredAfterMillis float64 `json:"registered_after_ms"`
+	Stopped               bool    `json:"stopped"`
+	StopDurationMillis    float64 `json:"stop_duration_ms,omitempty"`
+	StopError             string  `json:"stop_error,omitempty"`
+}
+
+// StartupStep is the JSON representation of a copper.StartupStep
+type StartupStep struct {
+	Name           string  `json:"name"`
+	DurationMillis float64 `json:"duration_ms"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// Diagnostics is the JSON representation of copper.Diagnostics
+type Diagnostics struct {
+	Hooks   []Hook        `json:"hooks"`
+	Startup []StartupStep `json:"startup"`
+}
+
+// RecordedRequest is the JSON representation of an Entry captured by Recorder
+type RecordedRequest struct {
+	Entry
+
+	// Curl is a curl command that replays the request (see Entry.Curl)
+	Curl string `json:"curl"`
+}
+
+// Routes returns the diagnostics routes or no routes if the endpoint is disabled
+func (ro *Router) Routes() []chttp.Route {
+	if !ro.config.Enabled {
+		return nil
+	}
+
+	routes := []chttp.Route{
+		{
+			Path:    ro.config.Path,
+			Methods: []string{http.MethodGet},
+			Handler: ro.HandleDiagnostics,
+		},
+	}
+
+	if ro.config.Recorder.Enabled && ro.recorder != nil {
+		routes = append(routes,
+			chttp.Route{
+				Path:    ro.config.Path + "/requests",
+				Methods: []string{http.MethodGet},
+				Handler: ro.HandleRequests,
+			},
+			chttp.Route{
+				Path:    ro.config.Path + "/requests",
+				Methods: []string{http.MethodDelete},
+				Handler: ro.HandleResetRequests,
+			},
+			chttp.Route{
+				Path:    ro.config.Path + "/requests.har",
+				Methods: []string{http.MethodGet},
+				Handler: ro.HandleHAR,
+			},
+		)
+	}
+
+	return routes
+}
+
+// HandleRequests responds with the captured requests, most recent first
+func (ro *Router) HandleRequests(w http.ResponseWriter, r *http.Request) {
+	entries := ro.recorder.Entries()
+	resp := make([]RecordedRequest, 0, len(entries))
+
+	for _, e := range entries {
+		resp = append(resp, RecordedRequest{Entry: e, Curl: e.Curl()})
+	}
+
+	ro.rw.WriteJSON(w, chttp.WriteJSONParams{
+		StatusCode: http.StatusOK,
+		Data:       resp,
+	})
+}
+
+// HandleResetRequests removes the captured requests
+func (ro *Router) HandleResetRequests(w http.ResponseWriter, r *http.Request) {
+	ro.recorder.Reset()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleHAR responds with the captured requests as a HAR file
+func (ro *Router) HandleHAR(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Disposition", `attachment; filename="`+harFileName(time.Now())+`"`)
+
+	ro.rw.WriteJSON(w, chttp.WriteJSONParams{
+		StatusCode: http.StatusOK,
+		Data:       ro.recorder.HAR(),
+	})
+}
+
+// HandleDiagnostics responds with the app's diagnostics
+func (ro *Router) HandleDiagnostics(w http.ResponseWriter, r *http.Request) {
+	var (
+		diag = ro.app.Diagnostics()
+		resp = Diagnostics{
+			Hooks:   make([]Hook, 0, len(diag.Hooks)),
+			Startup: make([]StartupStep, 0, len(diag.Startup)),
+		}
+	)
+
+	for _, h := range diag.Hooks {
+		hook := Hook{
+			Name:                  h.Name,
+			RegisteredAfterMillis: millis(h.RegisteredAfter),
+			Stopped:               h.Stopped,
+			StopDurationMillis:    millis(h.StopDuration),
+		}
+
+		if h.StopErr != nil {
+			hook.StopError = h.StopErr.Error()
+		}
+
+		resp.Hooks = append(resp.Hooks, hook)
+	}
+
+	for _, s := range diag.Startup {
+		step := StartupStep{
+			Name:           s.Name,
+			DurationMillis: millis(s.Duration),
+		}
+
+		if s.Err != nil {
+			step.Error = s.Err.Error()
+		}
+
+		resp.Startup = append(resp.Startup, step)
+	}
+
+	ro.rw.WriteJSON(w, chttp.WriteJSONParams{
+		StatusCode: http.StatusOK,
+		Data:       resp,
+	})
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,64 @@
+package cdiag_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocopper/copper"
+	"github.com/gocopper/copper/cdiag"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/chttp/chttptest"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter_HandleDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	app := &copper.App{
+		Lifecycle: clifecycle.New(),
+		Logger:    clogger.NewNoop(),
+	}
+
+	app.Lifecycle.OnStop(func(ctx context.Context) error { return nil })
+
+	assert.NoError(t, app.RunStep("server", func() error { return nil }))
+	assert.Error(t, app.RunStep("worker", func() error { return errors.New("test-err") }))
+
+	handler := chttp.NewHandler(chttp.NewHandlerParams{
+		Routers: []chttp.Router{
+			cdiag.NewRouter(cdiag.NewRouterParams{
+				App:    app,
+				RW:     chttptest.NewReaderWriter(t),
+				Config: cdiag.Config{Enabled: true, Path: "/_copper/diagnostics"},
+			}),
+		},
+		Logger: clogger.NewNoop(),
+	})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/_copper/diagnostics", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var diag cdiag.Diagnostics
+
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&diag))
+	assert.Len(t, diag.Hooks, 1)
+	assert.Equal(t, "github.com/gocopper/copper/cdiag_test.TestRouter_HandleDiagnostics", diag.Hooks[0].Name)
+	assert.Len(t, diag.Startup, 2)
+	assert.Equal(t, "worker", diag.Startup[1].Name)
+	assert.Equal(t, "test-err", diag.Startup[1].Error)
+}
+
+func TestRouter_Disabled(t *testing.T) {
+	t.Parallel()
+
+	router := cdiag.NewRouter(cdiag.NewRouterParams{Config: cdiag.Config{Path: "/_copper/diagnostics"}})
+
+	assert.Empty(t, router.Routes())
+}
@@ -0,0 +1,91 @@
+package cevents
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+)
+
+// Event is a domain event (ex. InvoicePaid). It is encoded as JSON when it is emitted.
+type Event interface {
+	// EventName returns the name of the event (ex. invoice_paid). It is the same for all events of a type and is used
+	// to route the event to its subscribers.
+	EventName() string
+}
+
+// KeyedEvent is an Event that has a key (ex. the invoice id). Brokers that partition topics (ex. Kafka) deliver the
+// events with the same key in order.
+type KeyedEvent interface {
+	Event
+
+	EventKey() string
+}
+
+// NewBus creates a new Bus
+func NewBus(queries *Queries) *Bus {
+	return &Bus{
+		queries: queries,
+		now:     time.Now,
+	}
+}
+
+// Bus emits domain events by saving them in the outbox. Emit must be called with a context that has a database
+// transaction (ex. in a request handled by csql.TxMiddleware) so that the events are saved only if the transaction
+// commits. The Relay publishes them after that.
+type Bus struct {
+	queries *Queries
+	now     func() time.Time
+}
+
+// Emit saves the events in the outbox within the context's database transaction
+func (b *Bus) Emit(ctx context.Context, events ...Event) error {
+	now := b.now()
+	traceID, _ := clogger.FieldsFromCtx(ctx)["traceID"].(string)
+
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return cerrors.New(err, "failed to encode event", map[string]interface{}{
+				"name": event.EventName(),
+			})
+		}
+
+		e := OutboxEvent{
+			ID:            newID(),
+			Name:          event.EventName(),
+			Payload:       string(payload),
+			TraceID:       traceID,
+			Status:        StatusPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+
+		if keyed, ok := event.(KeyedEvent); ok {
+			e.Key = keyed.EventKey()
+		}
+
+		err = b.queries.InsertEvent(ctx, &e)
+		if err != nil {
+			return cerrors.New(err, "failed to save event in the outbox", map[string]interface{}{
+				"name": e.Name,
+			})
+		}
+	}
+
+	return nil
+}
+
+func newID() string {
+	const idBytes = 16
+
+	b := make([]byte, idBytes)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,77 @@
+package cevents
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	defaultPollInterval   = time.Second
+	defaultBatchSize      = 100
+	defaultMaxAttempts    = 10
+	defaultBaseBackoff    = 5 * time.Second
+	defaultMaxBackoff     = 10 * time.Minute
+	defaultPublishTimeout = 30 * time.Second
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "cevents",
+		Description: "cevents configures how events in the outbox are relayed",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("cevents", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load cevents config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		PollInterval:   defaultPollInterval,
+		BatchSize:      defaultBatchSize,
+		MaxAttempts:    defaultMaxAttempts,
+		BaseBackoff:    defaultBaseBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+		PublishTimeout: defaultPublishTimeout,
+	}
+}
+
+// Config configures the Relay. For example:
+//
+//	[cevents]
+//	prefix = "billing."
+//	poll_interval = "500ms"
+type Config struct {
+	// Prefix is prepended to the name of each event to get the topic (cpubsub) or job type (cqueue) it is published
+	// to (ex. billing.invoice_paid)
+	Prefix string `toml:"prefix" doc:"Prepended to event names to get their topic or job type"`
+
+	// PollInterval is how often the outbox is checked for events that are due
+	PollInterval time.Duration `toml:"poll_interval" doc:"How often the outbox is checked for events"`
+
+	// BatchSize is the max number of events published per poll
+	BatchSize int `toml:"batch_size" doc:"Max number of events published per poll"`
+
+	// MaxAttempts is the number of times an event is published before it is marked as failed
+	MaxAttempts int `toml:"max_attempts" doc:"Number of times an event is published before it is marked as failed"`
+
+	// BaseBackoff is the wait time after the first failed attempt. It doubles after each failed attempt up to
+	// MaxBackoff.
+	BaseBackoff time.Duration `toml:"base_backoff" doc:"Wait time after the first failed attempt"`
+	MaxBackoff  time.Duration `toml:"max_backoff" doc:"Max wait time between attempts"`
+
+	// PublishTimeout is how long a relay holds the events it claimed. Events that are still held after it (ex.
+	// because the app stopped while publishing them) are published again.
+	PublishTimeout time.Duration `toml:"publish_timeout" doc:"How long the events claimed by a relay are held"`
+}
@@ -0,0 +1,5 @@
+// Package cevents provides domain events that are published using the transactional outbox pattern. Services emit
+// typed events within their database transaction using Bus, which writes them to an outbox table. The Relay
+// publishes them to cpubsub or cqueue once the transaction commits, so events are never published for changes that
+// were rolled back and are not lost if the app stops before publishing them.
+package cevents
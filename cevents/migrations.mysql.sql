@@ -0,0 +1,20 @@
+-- +migrate Up
+create table cevents_outbox (
+    id varchar(64) primary key,
+    name varchar(255) not null,
+    event_key varchar(255) not null default '',
+    payload mediumtext not null,
+    trace_id varchar(64) not null default '',
+    lease_id varchar(64) not null default '',
+    status varchar(32) not null,
+    attempts integer not null default 0,
+    next_attempt_at datetime(6) not null,
+    last_error text not null,
+    created_at datetime(6) not null,
+    updated_at datetime(6) not null
+);
+
+create index cevents_outbox_pending_idx on cevents_outbox (status, next_attempt_at);
+
+-- +migrate Down
+drop table cevents_outbox;
@@ -0,0 +1,20 @@
+-- +migrate Up
+create table cevents_outbox (
+    id text primary key,
+    name text not null,
+    event_key text not null default '',
+    payload text not null,
+    trace_id text not null default '',
+    lease_id text not null default '',
+    status text not null,
+    attempts integer not null default 0,
+    next_attempt_at timestamp not null,
+    last_error text not null default '',
+    created_at timestamp not null,
+    updated_at timestamp not null
+);
+
+create index cevents_outbox_pending_idx on cevents_outbox (status, next_attempt_at);
+
+-- +migrate Down
+drop table cevents_outbox;
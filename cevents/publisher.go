@@ -0,0 +1,101 @@
+package cevents
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gocopper/copper/cpubsub"
+	"github.com/gocopper/copper/cqueue"
+)
+
+// Headers set on each event published to cpubsub
+const (
+	HeaderEventID   = "Event-Id"
+	HeaderEventName = "Event-Name"
+)
+
+// Publisher publishes the events relayed from the outbox. Apps bind it to the PubSubPublisher or the
+// QueuePublisher. Events may be published more than once (ex. if the app stops after publishing an event but before
+// removing it from the outbox), so their handlers should be idempotent.
+type Publisher interface {
+	Publish(ctx context.Context, e *OutboxEvent) error
+}
+
+// NewPubSubPublisher creates a new PubSubPublisher
+func NewPubSubPublisher(ps *cpubsub.PubSub, config Config) *PubSubPublisher {
+	return &PubSubPublisher{ps: ps, prefix: config.Prefix}
+}
+
+// PubSubPublisher is a Publisher that publishes each event to the cpubsub topic named after it. See Subscribe.
+type PubSubPublisher struct {
+	ps     *cpubsub.PubSub
+	prefix string
+}
+
+// Publish publishes the event to its topic
+func (p *PubSubPublisher) Publish(ctx context.Context, e *OutboxEvent) error {
+	return p.ps.Publish(ctx, p.prefix+e.Name, cpubsub.Message{
+		Key:  e.Key,
+		Data: []byte(e.Payload),
+		Headers: map[string]string{
+			HeaderEventID:   e.ID,
+			HeaderEventName: e.Name,
+		},
+	})
+}
+
+// NewQueuePublisher creates a new QueuePublisher
+func NewQueuePublisher(q *cqueue.Queue, config Config) *QueuePublisher {
+	return &QueuePublisher{q: q, prefix: config.Prefix}
+}
+
+// QueuePublisher is a Publisher that enqueues a cqueue job for each event with a job type named after it. See
+// Register.
+type QueuePublisher struct {
+	q      *cqueue.Queue
+	prefix string
+}
+
+// Publish enqueues a job with the event as its payload
+func (p *QueuePublisher) Publish(ctx context.Context, e *OutboxEvent) error {
+	_, err := p.q.Enqueue(ctx, p.prefix+e.Name, json.RawMessage(e.Payload))
+
+	return err
+}
+
+// Subscribe registers fn to process the events of type T published by the PubSubPublisher as part of the consumer
+// group. The event name is read from the zero value of T, so T should implement Event with a value receiver. For
+// example:
+//
+//	type InvoicePaid struct {
+//		InvoiceID string
+//	}
+//
+//	func (InvoicePaid) EventName() string { return "invoice_paid" }
+//
+//	cevents.Subscribe(ps, config, "emails", func(ctx context.Context, e InvoicePaid) error {
+//		..
+//	})
+func Subscribe[T Event](ps *cpubsub.PubSub, config Config, group string,
+	fn func(ctx context.Context, event T) error) {
+	var zero T
+
+	ps.Subscribe(config.Prefix+zero.EventName(), group, func(ctx context.Context, msg *cpubsub.Message) error {
+		var event T
+
+		err := msg.Decode(&event)
+		if err != nil {
+			return err
+		}
+
+		return fn(ctx, event)
+	})
+}
+
+// Register registers fn as the handler of the events of type T enqueued by the QueuePublisher. See Subscribe for
+// how the event name is read.
+func Register[T Event](q *cqueue.Queue, config Config, fn func(ctx context.Context, event T) error) {
+	var zero T
+
+	cqueue.Register(q, config.Prefix+zero.EventName(), fn)
+}
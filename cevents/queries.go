@@ -0,0 +1,157 @@
+package cevents
+
+import (
+	"context"
+	"embed"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/csql"
+)
+
+// Migrations holds the database schema of the outbox. Register them using csql.RegisterMigrations so that they are
+// applied by csql.Migrator along with the app's migrations:
+//
+//	csql.RegisterMigrations(csql.ModuleMigrations{Module: "cevents", FS: cevents.Migrations})
+//
+// MySQL uses the variant in migrations.mysql.sql (see csql.ModuleMigrations).
+//
+//go:embed migrations.sql migrations.mysql.sql
+var Migrations embed.FS
+
+// Outbox statuses
+const (
+	StatusPending = "pending"
+	StatusFailed  = "failed"
+)
+
+// OutboxEvent is an event saved in the outbox until it is published
+type OutboxEvent struct {
+	ID            string    `db:"id"`
+	Name          string    `db:"name"`
+	Key           string    `db:"event_key"`
+	Payload       string    `db:"payload"`
+	TraceID       string    `db:"trace_id"`
+	LeaseID       string    `db:"lease_id"`
+	Status        string    `db:"status"`
+	Attempts      int       `db:"attempts"`
+	NextAttemptAt time.Time `db:"next_attempt_at"`
+	LastError     string    `db:"last_error"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+// NewQueries creates a new Queries
+func NewQueries(querier csql.Querier) *Queries {
+	return &Queries{querier: querier}
+}
+
+// Queries holds the database queries for the outbox
+type Queries struct {
+	querier csql.Querier
+}
+
+// InsertEvent saves a new event in the outbox
+func (q *Queries) InsertEvent(ctx context.Context, e *OutboxEvent) error {
+	const query = `
+	insert into cevents_outbox (id, name, event_key, payload, trace_id, status, attempts, next_attempt_at,
+		last_error, created_at, updated_at)
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := q.querier.Exec(ctx, query, e.ID, e.Name, e.Key, e.Payload, e.TraceID, e.Status, e.Attempts,
+		e.NextAttemptAt, e.LastError, e.CreatedAt, e.UpdatedAt)
+
+	return err
+}
+
+// ClaimPendingEvents holds up to limit pending events that are due until leaseUntil and returns them, oldest first.
+// An event can only be claimed by one relay at a time: each claim sets a new lease id on the condition that the
+// event's lease id did not change since it was selected.
+func (q *Queries) ClaimPendingEvents(ctx context.Context, now, leaseUntil time.Time, limit int) ([]OutboxEvent,
+	error) {
+	const query = `
+	select * from cevents_outbox
+	where status = ? and next_attempt_at <= ?
+	order by created_at
+	limit ?`
+
+	var pending []OutboxEvent
+
+	err := q.querier.Select(ctx, &pending, query, StatusPending, now, limit)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to query pending events", nil)
+	}
+
+	var (
+		claimed = make([]OutboxEvent, 0, len(pending))
+		leaseID = newID()
+	)
+
+	const claimQuery = `
+	update cevents_outbox
+	set lease_id = ?, next_attempt_at = ?, updated_at = ?
+	where id = ? and lease_id = ?`
+
+	for i := range pending {
+		res, err := q.querier.Exec(ctx, claimQuery, leaseID, leaseUntil, now, pending[i].ID, pending[i].LeaseID)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to claim event", map[string]interface{}{
+				"id": pending[i].ID,
+			})
+		}
+
+		if n, _ := res.RowsAffected(); n == 1 {
+			pending[i].LeaseID = leaseID
+			pending[i].NextAttemptAt = leaseUntil
+			claimed = append(claimed, pending[i])
+		}
+	}
+
+	return claimed, nil
+}
+
+// DeleteEvent removes a published event from the outbox
+func (q *Queries) DeleteEvent(ctx context.Context, id string) error {
+	_, err := q.querier.Exec(ctx, `delete from cevents_outbox where id = ?`, id)
+
+	return err
+}
+
+// UpdateEvent saves the status and attempt details of an event
+func (q *Queries) UpdateEvent(ctx context.Context, e *OutboxEvent) error {
+	const query = `
+	update cevents_outbox
+	set status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, updated_at = ?
+	where id = ?`
+
+	_, err := q.querier.Exec(ctx, query, e.Status, e.Attempts, e.NextAttemptAt, e.LastError, e.UpdatedAt, e.ID)
+
+	return err
+}
+
+// ListFailedEvents returns the most recent events that failed to be published
+func (q *Queries) ListFailedEvents(ctx context.Context, limit int) ([]OutboxEvent, error) {
+	const query = `
+	select * from cevents_outbox
+	where status = ?
+	order by updated_at desc
+	limit ?`
+
+	var events []OutboxEvent
+
+	err := q.querier.Select(ctx, &events, query, StatusFailed, limit)
+
+	return events, err
+}
+
+// RetryEvent marks a failed event as pending so that it is published again
+func (q *Queries) RetryEvent(ctx context.Context, id string, now time.Time) error {
+	const query = `
+	update cevents_outbox
+	set status = ?, attempts = 0, next_attempt_at = ?, updated_at = ?
+	where id = ? and status = ?`
+
+	_, err := q.querier.Exec(ctx, query, StatusPending, now, now, id, StatusFailed)
+
+	return err
+}
@@ -0,0 +1,208 @@
+package cevents
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/csql"
+)
+
+// NewRelayParams holds the params needed for NewRelay
+type NewRelayParams struct {
+	DB        *sql.DB
+	Queries   *Queries
+	Publisher Publisher
+	Lifecycle *clifecycle.Lifecycle
+	Config    Config
+	SQLConfig csql.Config
+	Logger    clogger.Logger
+}
+
+// NewRelay creates a new Relay
+func NewRelay(p NewRelayParams) *Relay {
+	return &Relay{
+		db:        p.DB,
+		queries:   p.Queries,
+		publisher: p.Publisher,
+		lc:        p.Lifecycle,
+		config:    p.Config,
+		dialect:   p.SQLConfig.Dialect,
+		logger:    p.Logger.With(clogger.Module("cevents")),
+		now:       time.Now,
+	}
+}
+
+// Relay publishes the events in the outbox using the Publisher in the order they were emitted. Published events are
+// removed from the outbox. Events that fail to be published are retried with exponential backoff until
+// Config.MaxAttempts is reached, after which they are marked as failed and kept in the outbox (see RetryFailed).
+// Multiple instances of the app can run a Relay since each event is claimed by one relay at a time.
+type Relay struct {
+	db        *sql.DB
+	queries   *Queries
+	publisher Publisher
+	lc        *clifecycle.Lifecycle
+	config    Config
+	dialect   string
+	logger    clogger.Logger
+	now       func() time.Time
+}
+
+// Run starts polling the outbox in the background. The relay stops when the app's lifecycle stops.
+func (r *Relay) Run() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	r.lc.OnStop(func(stopCtx context.Context) error {
+		r.logger.Info("Stopping event relay..")
+		cancel()
+
+		select {
+		case <-done:
+			return nil
+		case <-stopCtx.Done():
+			return stopCtx.Err()
+		}
+	})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(r.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			n, err := r.ProcessPending(ctx)
+			if err != nil {
+				r.logger.Error("Failed to relay events", err)
+			}
+
+			// a full batch means that there may be more events that are due
+			if err == nil && n == r.config.BatchSize {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stage implements clifecycle.Staged. The relay starts once the migrations have run and the servers are listening.
+func (r *Relay) Stage() clifecycle.Stage {
+	return clifecycle.Stage{
+		Name:  clifecycle.StageWorkers,
+		After: []string{clifecycle.StageMigrations, clifecycle.StageSeeds, clifecycle.StageHTTP},
+	}
+}
+
+// ProcessPending publishes a batch of pending events that are due and returns the number of events attempted
+func (r *Relay) ProcessPending(ctx context.Context) (int, error) {
+	var events []OutboxEvent
+
+	err := r.inTx(ctx, func(ctx context.Context) error {
+		var (
+			err error
+			now = r.now()
+		)
+
+		events, err = r.queries.ClaimPendingEvents(ctx, now, now.Add(r.config.PublishTimeout), r.config.BatchSize)
+
+		return err
+	})
+	if err != nil {
+		return 0, cerrors.New(err, "failed to claim pending events", nil)
+	}
+
+	for i := range events {
+		err = r.publish(ctx, &events[i])
+		if err != nil {
+			return i, cerrors.New(err, "failed to update event", map[string]interface{}{
+				"id": events[i].ID,
+			})
+		}
+	}
+
+	return len(events), nil
+}
+
+// RetryFailed marks the failed event with the given id as pending so that it is published again
+func (r *Relay) RetryFailed(ctx context.Context, id string) error {
+	return r.inTx(ctx, func(ctx context.Context) error {
+		return r.queries.RetryEvent(ctx, id, r.now())
+	})
+}
+
+// publish publishes the event and removes it from the outbox, or records the failed attempt
+func (r *Relay) publish(ctx context.Context, e *OutboxEvent) error {
+	publishCtx := ctx
+	if e.TraceID != "" {
+		publishCtx = clogger.CtxWithFields(ctx, map[string]interface{}{"traceID": e.TraceID})
+	}
+
+	pubErr := r.publisher.Publish(publishCtx, e)
+
+	return r.inTx(ctx, func(ctx context.Context) error {
+		if pubErr == nil {
+			return r.queries.DeleteEvent(ctx, e.ID)
+		}
+
+		e.Attempts++
+		e.LastError = pubErr.Error()
+		e.UpdatedAt = r.now()
+
+		log := r.logger.WithTags(map[string]interface{}{
+			"eventID": e.ID,
+			"name":    e.Name,
+			"attempt": e.Attempts,
+		})
+
+		if e.Attempts >= r.config.MaxAttempts {
+			e.Status = StatusFailed
+
+			log.Error("Event could not be published", pubErr)
+		} else {
+			e.NextAttemptAt = e.UpdatedAt.Add(r.backoff(e.Attempts))
+
+			log.Warn("Failed to publish event; will retry", pubErr)
+		}
+
+		return r.queries.UpdateEvent(ctx, e)
+	})
+}
+
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := r.config.BaseBackoff
+	for i := 1; i < attempts && d < r.config.MaxBackoff; i++ {
+		d *= 2
+	}
+
+	if d > r.config.MaxBackoff {
+		return r.config.MaxBackoff
+	}
+
+	return d
+}
+
+func (r *Relay) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, tx, err := csql.CtxWithTx(ctx, r.db, r.dialect)
+	if err != nil {
+		return err
+	}
+
+	err = fn(ctx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
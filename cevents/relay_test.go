@@ -0,0 +1,224 @@
+package cevents_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/cevents"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cqueue"
+	"github.com/gocopper/copper/cqueue/cqueuetest"
+	"github.com/gocopper/copper/csql"
+	"github.com/gocopper/copper/csql/csqltest"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+type invoicePaid struct {
+	InvoiceID string `json:"invoice_id"`
+}
+
+func (invoicePaid) EventName() string { return "invoice_paid" }
+
+func (e invoicePaid) EventKey() string { return e.InvoiceID }
+
+type fakePublisher struct {
+	err    error
+	events []cevents.OutboxEvent
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, e *cevents.OutboxEvent) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	p.events = append(p.events, *e)
+
+	return nil
+}
+
+type testOutbox struct {
+	db        *sql.DB
+	sqlConfig csql.Config
+	bus       *cevents.Bus
+	queries   *cevents.Queries
+}
+
+func newTestOutbox(t *testing.T) *testOutbox {
+	t.Helper()
+
+	var (
+		db      = csqltest.NewDB(t, csqltest.Options{Migrations: csql.Migrations(cevents.Migrations)})
+		queries = cevents.NewQueries(db.Querier)
+	)
+
+	return &testOutbox{
+		db:        db.DB,
+		sqlConfig: db.Config,
+		bus:       cevents.NewBus(queries),
+		queries:   queries,
+	}
+}
+
+func (o *testOutbox) relay(publisher cevents.Publisher, config cevents.Config) *cevents.Relay {
+	return cevents.NewRelay(cevents.NewRelayParams{
+		DB:        o.db,
+		Queries:   o.queries,
+		Publisher: publisher,
+		Lifecycle: clifecycle.New(),
+		Config:    config,
+		SQLConfig: o.sqlConfig,
+		Logger:    clogger.NewNoop(),
+	})
+}
+
+func (o *testOutbox) emit(t *testing.T, commit bool, events ...cevents.Event) {
+	t.Helper()
+
+	ctx, tx, err := csql.CtxWithTx(context.Background(), o.db, o.sqlConfig.Dialect)
+	assert.NoError(t, err)
+
+	assert.NoError(t, o.bus.Emit(ctx, events...))
+
+	if commit {
+		assert.NoError(t, tx.Commit())
+	} else {
+		assert.NoError(t, tx.Rollback())
+	}
+}
+
+func testConfig() cevents.Config {
+	return cevents.Config{
+		BatchSize:      10,
+		MaxAttempts:    2,
+		BaseBackoff:    time.Minute,
+		MaxBackoff:     time.Hour,
+		PublishTimeout: time.Minute,
+	}
+}
+
+func TestRelay_ProcessPending(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx       = context.Background()
+		outbox    = newTestOutbox(t)
+		publisher = &fakePublisher{}
+		relay     = outbox.relay(publisher, testConfig())
+	)
+
+	outbox.emit(t, false, invoicePaid{InvoiceID: "rolled-back"})
+	outbox.emit(t, true, invoicePaid{InvoiceID: "inv1"}, invoicePaid{InvoiceID: "inv2"})
+
+	n, err := relay.ProcessPending(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	assert.Len(t, publisher.events, 2)
+	assert.Equal(t, "invoice_paid", publisher.events[0].Name)
+	assert.Equal(t, "inv1", publisher.events[0].Key)
+	assert.JSONEq(t, `{"invoice_id":"inv1"}`, publisher.events[0].Payload)
+
+	n, err = relay.ProcessPending(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestRelay_ProcessPending_Retry(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx       = context.Background()
+		outbox    = newTestOutbox(t)
+		publisher = &fakePublisher{err: errors.New("broker is down")}
+		config    = testConfig()
+	)
+
+	config.BaseBackoff = 0
+
+	relay := outbox.relay(publisher, config)
+
+	outbox.emit(t, true, invoicePaid{InvoiceID: "inv1"})
+
+	for i := 0; i < 2; i++ {
+		n, err := relay.ProcessPending(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+	}
+
+	n, err := relay.ProcessPending(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	ctx, tx, err := csql.CtxWithTx(ctx, outbox.db, outbox.sqlConfig.Dialect)
+	assert.NoError(t, err)
+
+	failed, err := outbox.queries.ListFailedEvents(ctx, 10)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+
+	assert.Len(t, failed, 1)
+	assert.Equal(t, 2, failed[0].Attempts)
+	assert.Equal(t, "broker is down", failed[0].LastError)
+
+	publisher.err = nil
+
+	assert.NoError(t, relay.RetryFailed(context.Background(), failed[0].ID))
+
+	n, err = relay.ProcessPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Len(t, publisher.events, 1)
+}
+
+func TestQueuePublisher(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		outbox  = newTestOutbox(t)
+		backend = cqueuetest.NewBackend()
+		config  = cevents.Config{Prefix: "billing."}
+		queue   = cqueue.NewQueue(cqueue.NewQueueParams{Backend: backend, Config: cqueue.Config{MaxAttempts: 1}})
+		relay   = outbox.relay(cevents.NewQueuePublisher(queue, config), testConfig())
+	)
+
+	outbox.emit(t, true, invoicePaid{InvoiceID: "inv1"})
+
+	_, err := relay.ProcessPending(ctx)
+	assert.NoError(t, err)
+
+	assert.Len(t, backend.JobsOfType("billing.invoice_paid"), 1)
+
+	var handled invoicePaid
+
+	cevents.Register(queue, config, func(ctx context.Context, e invoicePaid) error {
+		handled = e
+		return nil
+	})
+
+	worker := cqueue.NewWorker(cqueue.NewWorkerParams{
+		Queue:     queue,
+		Backend:   backend,
+		Lifecycle: clifecycle.New(),
+		Config:    cqueue.Config{MaxAttempts: 1, JobTimeout: time.Minute},
+		Logger:    clogger.NewNoop(),
+	})
+
+	n, err := worker.ProcessPending(ctx, cqueue.DefaultQueue)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "inv1", handled.InvoiceID)
+}
+
+func TestBus_Emit_NoTx(t *testing.T) {
+	t.Parallel()
+
+	outbox := newTestOutbox(t)
+
+	assert.Error(t, outbox.bus.Emit(context.Background(), invoicePaid{InvoiceID: "inv1"}))
+}
@@ -0,0 +1,17 @@
+package cevents
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup. Apps must also bind Publisher to the PubSubPublisher or the
+// QueuePublisher, ex. using wire.Bind(new(cevents.Publisher), new(*cevents.PubSubPublisher)).
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+	NewQueries,
+	NewBus,
+
+	NewPubSubPublisher,
+	NewQueuePublisher,
+
+	NewRelay,
+	wire.Struct(new(NewRelayParams), "*"),
+)
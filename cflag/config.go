@@ -0,0 +1,94 @@
+package cflag
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Providers supported by NewProvider
+const (
+	ProviderConfig       = "config"
+	ProviderUnleash      = "unleash"
+	ProviderLaunchDarkly = "launchdarkly"
+)
+
+const defaultRefreshInterval = 30 * time.Second
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "cflag",
+		Description: "cflag configures feature flags",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("cflag", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load cflag config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Provider:        ProviderConfig,
+		RefreshInterval: defaultRefreshInterval,
+	}
+}
+
+// Config configures the feature flags. For example:
+//
+//	[cflag.flags.new_checkout]
+//	percentage = 25
+//	users = ["6f1c3a2e-..."]
+//
+//	[cflag.flags.dark_mode]
+//	enabled = true
+type Config struct {
+	// Provider is one of config, unleash, or launchdarkly
+	Provider string `toml:"provider" valid:"in(config|unleash|launchdarkly)" doc:"config, unleash, or launchdarkly"`
+
+	// RefreshInterval is how often flags are fetched from a remote provider
+	RefreshInterval time.Duration `toml:"refresh_interval" doc:"How often flags are fetched from a remote provider"`
+
+	// Flags are the flags used by the config provider
+	Flags map[string]ConfigFlag `toml:"flags"`
+
+	Unleash      ConfigUnleash      `toml:"unleash"`
+	LaunchDarkly ConfigLaunchDarkly `toml:"launchdarkly"`
+}
+
+// ConfigFlag defines a flag for the config provider. A flag is enabled for a subject if any of the following is true:
+//   - Enabled is true
+//   - The subject's user id is in Users
+//   - The subject has one of the attribute values in Attributes (ex. plan = ["pro"])
+//   - The subject's user id falls in the Percentage rollout
+type ConfigFlag struct {
+	Enabled    bool                `toml:"enabled"`
+	Percentage float64             `toml:"percentage" valid:"range(0|100)"`
+	Users      []string            `toml:"users"`
+	Attributes map[string][]string `toml:"attributes"`
+}
+
+// ConfigUnleash configures the Unleash provider
+type ConfigUnleash struct {
+	// URL is the Unleash API URL (ex. https://unleash.example.com/api)
+	URL     string `toml:"url"`
+	APIKey  string `toml:"api_key"`
+	AppName string `toml:"app_name"`
+}
+
+// ConfigLaunchDarkly configures the LaunchDarkly provider
+type ConfigLaunchDarkly struct {
+	SDKKey string `toml:"sdk_key"`
+
+	// BaseURL overrides the LaunchDarkly SDK API URL (ex. for a relay proxy)
+	BaseURL string `toml:"base_url"`
+}
@@ -0,0 +1,3 @@
+// Package cflag provides feature flags that are evaluated per request. Flags can be defined in the app config or
+// fetched from a remote provider (Unleash or LaunchDarkly). See Flags.
+package cflag
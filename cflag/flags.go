@@ -0,0 +1,161 @@
+package cflag
+
+import (
+	"context"
+	"hash/fnv"
+	"net/http"
+	"sync"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+)
+
+type ctxKey string
+
+const (
+	ctxSubjectKey = ctxKey("cflag/subject")
+	ctxCacheKey   = ctxKey("cflag/cache")
+)
+
+// Subject is who a flag is evaluated for, usually the user making the request
+type Subject struct {
+	// UserID is used for targeting and to keep percentage rollouts sticky (ex. the user's uuid)
+	UserID string
+
+	// Attributes are used for targeting (ex. plan = pro)
+	Attributes map[string]string
+}
+
+// WithSubject returns a context that evaluates flags for the given subject. Auth middlewares should call it once the
+// user is known.
+func WithSubject(ctx context.Context, subject Subject) context.Context {
+	return context.WithValue(ctx, ctxSubjectKey, subject)
+}
+
+// WithUserID is a shorthand for WithSubject with only a user id
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return WithSubject(ctx, Subject{UserID: userID})
+}
+
+// SubjectFromCtx returns the subject set using WithSubject
+func SubjectFromCtx(ctx context.Context) Subject {
+	subject, _ := ctx.Value(ctxSubjectKey).(Subject)
+	return subject
+}
+
+// Provider evaluates flags for a subject
+type Provider interface {
+	Evaluate(ctx context.Context, name string, subject Subject) (bool, error)
+}
+
+// NewFlagsParams holds the params needed to create Flags
+type NewFlagsParams struct {
+	Provider Provider
+	Logger   clogger.Logger
+}
+
+// NewFlags creates Flags that evaluates flags using the given provider
+func NewFlags(p NewFlagsParams) *Flags {
+	return &Flags{
+		provider: p.Provider,
+		logger:   p.Logger.With(clogger.Module("cflag")),
+	}
+}
+
+// Flags evaluates feature flags for the subject in the context (see WithSubject). Within a request that goes through
+// the Middleware, a flag is evaluated once so that it does not change in the middle of the request.
+type Flags struct {
+	provider Provider
+	logger   clogger.Logger
+}
+
+// Enabled returns true if the flag is enabled for the subject in ctx. If the flag cannot be evaluated, the error is
+// logged and the flag is disabled.
+func (f *Flags) Enabled(ctx context.Context, name string) bool {
+	subject := SubjectFromCtx(ctx)
+
+	cache, _ := ctx.Value(ctxCacheKey).(*evalCache)
+	if cache != nil {
+		if enabled, ok := cache.get(name, subject.UserID); ok {
+			return enabled
+		}
+	}
+
+	enabled, err := f.provider.Evaluate(ctx, name, subject)
+	if err != nil {
+		f.logger.WithFields(map[string]interface{}{
+			"flag": name,
+		}).Error("Failed to evaluate feature flag", err)
+
+		enabled = false
+	}
+
+	if cache != nil {
+		cache.set(name, subject.UserID, enabled)
+	}
+
+	return enabled
+}
+
+// NewMiddleware creates a middleware that keeps flag evaluations consistent for the duration of each request
+func NewMiddleware() chttp.Middleware {
+	return chttp.HandleMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), ctxCacheKey, &evalCache{vals: make(map[string]bool)})
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+}
+
+// NewHTMLRenderFunc creates a "flag" template func that can be used in HTML templates like so:
+//
+//	{{ if flag "new_checkout" }} ... {{ end }}
+func NewHTMLRenderFunc(flags *Flags) chttp.HTMLRenderFunc {
+	return chttp.HTMLRenderFunc{
+		Name: "flag",
+		Func: func(r *http.Request) interface{} {
+			return func(name string) bool {
+				return flags.Enabled(r.Context(), name)
+			}
+		},
+	}
+}
+
+type evalCache struct {
+	mu   sync.Mutex
+	vals map[string]bool
+}
+
+func (c *evalCache) get(name, userID string) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	enabled, ok := c.vals[name+"\x00"+userID]
+
+	return enabled, ok
+}
+
+func (c *evalCache) set(name, userID string, enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.vals[name+"\x00"+userID] = enabled
+}
+
+// inRollout returns true if the user falls within the given percentage (0-100) of the flag's rollout. The result is
+// stable for a flag and user.
+func inRollout(flag, userID string, percentage float64) bool {
+	if percentage >= 100 { //nolint:gomnd
+		return true
+	}
+
+	if userID == "" || percentage <= 0 {
+		return false
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(flag + ":" + userID))
+
+	return float64(h.Sum32()%10000)/100 < percentage //nolint:gomnd
+}
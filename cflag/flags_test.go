@@ -0,0 +1,115 @@
+package cflag_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocopper/copper/cflag"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingProvider struct {
+	calls   int
+	enabled bool
+}
+
+func (p *countingProvider) Evaluate(_ context.Context, _ string, _ cflag.Subject) (bool, error) {
+	p.calls++
+	return p.enabled, nil
+}
+
+func TestConfigProvider(t *testing.T) {
+	t.Parallel()
+
+	provider := cflag.NewConfigProvider(map[string]cflag.ConfigFlag{
+		"on":       {Enabled: true},
+		"targeted": {Users: []string{"user-1"}, Attributes: map[string][]string{"plan": {"pro"}}},
+		"half":     {Percentage: 50},
+		"all":      {Percentage: 100},
+	})
+
+	eval := func(name string, subject cflag.Subject) bool {
+		enabled, err := provider.Evaluate(context.Background(), name, subject)
+		assert.NoError(t, err)
+
+		return enabled
+	}
+
+	assert.True(t, eval("on", cflag.Subject{}))
+	assert.False(t, eval("unknown", cflag.Subject{}))
+
+	assert.True(t, eval("targeted", cflag.Subject{UserID: "user-1"}))
+	assert.True(t, eval("targeted", cflag.Subject{UserID: "user-2", Attributes: map[string]string{"plan": "pro"}}))
+	assert.False(t, eval("targeted", cflag.Subject{UserID: "user-2"}))
+
+	assert.True(t, eval("all", cflag.Subject{}))
+	assert.False(t, eval("half", cflag.Subject{}))
+
+	enabledCount := 0
+
+	for i := 0; i < 1000; i++ {
+		subject := cflag.Subject{UserID: fmt.Sprintf("user-%d", i)}
+
+		// Rollouts are sticky for a user
+		assert.Equal(t, eval("half", subject), eval("half", subject))
+
+		if eval("half", subject) {
+			enabledCount++
+		}
+	}
+
+	assert.InDelta(t, 500, enabledCount, 75)
+}
+
+func TestFlags_EnabledPerRequest(t *testing.T) {
+	t.Parallel()
+
+	var (
+		provider = &countingProvider{enabled: true}
+		flags    = cflag.NewFlags(cflag.NewFlagsParams{Provider: provider, Logger: clogger.NewNoop()})
+		results  []bool
+	)
+
+	handler := cflag.NewMiddleware().Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := cflag.WithUserID(r.Context(), "user-1")
+
+		results = append(results, flags.Enabled(ctx, "new_checkout"))
+
+		provider.enabled = false
+
+		results = append(results, flags.Enabled(ctx, "new_checkout"))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, []bool{true, true}, results)
+	assert.Equal(t, 1, provider.calls)
+	assert.False(t, flags.Enabled(context.Background(), "new_checkout"))
+}
+
+func TestNewHTMLRenderFunc(t *testing.T) {
+	t.Parallel()
+
+	var (
+		flags = cflag.NewFlags(cflag.NewFlagsParams{
+			Provider: cflag.NewConfigProvider(map[string]cflag.ConfigFlag{"dark_mode": {Enabled: true}}),
+			Logger:   clogger.NewNoop(),
+		})
+		renderFunc = cflag.NewHTMLRenderFunc(flags)
+		req        = httptest.NewRequest(http.MethodGet, "/", nil)
+		buf        bytes.Buffer
+	)
+
+	tmpl := template.Must(template.New("page").
+		Funcs(template.FuncMap{renderFunc.Name: renderFunc.Func(req)}).
+		Parse(`{{ if flag "dark_mode" }}dark{{ end }}{{ if flag "other" }}other{{ end }}`))
+
+	assert.NoError(t, tmpl.Execute(&buf, nil))
+	assert.Equal(t, "dark", buf.String())
+}
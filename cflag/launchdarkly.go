@@ -0,0 +1,180 @@
+package cflag
+
+import (
+	"context"
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	defaultLaunchDarklyBaseURL = "https://sdk.launchdarkly.com"
+	launchDarklyBucketScale    = float64(0xFFFFFFFFFFFFFFF)
+	launchDarklyWeightScale    = 100000
+)
+
+// NewLaunchDarklyProvider creates a LaunchDarklyProvider. Call Refresh to fetch the flags (see NewProvider).
+func NewLaunchDarklyProvider(config ConfigLaunchDarkly) *LaunchDarklyProvider {
+	if config.BaseURL == "" {
+		config.BaseURL = defaultLaunchDarklyBaseURL
+	}
+
+	return &LaunchDarklyProvider{
+		config: config,
+		client: newHTTPClient(),
+	}
+}
+
+// LaunchDarklyProvider evaluates boolean flags fetched using LaunchDarkly's server-side SDK polling API. It supports
+// the flag's on/off state, individual user targets, and the default (fallthrough) variation or percentage rollout.
+// Targeting rules are not supported and are skipped, so flags that rely on them fall through to the default rule.
+type LaunchDarklyProvider struct {
+	config ConfigLaunchDarkly
+	client *http.Client
+
+	mu    sync.RWMutex
+	flags map[string]launchDarklyFlag
+}
+
+type launchDarklyFlag struct {
+	Key          string               `json:"key"`
+	On           bool                 `json:"on"`
+	Salt         string               `json:"salt"`
+	OffVariation *int                 `json:"offVariation"`
+	Variations   []interface{}        `json:"variations"`
+	Targets      []launchDarklyTarget `json:"targets"`
+	Fallthrough  struct {
+		Variation *int `json:"variation"`
+		Rollout   *struct {
+			Variations []struct {
+				Variation int `json:"variation"`
+				Weight    int `json:"weight"`
+			} `json:"variations"`
+		} `json:"rollout"`
+	} `json:"fallthrough"`
+}
+
+type launchDarklyTarget struct {
+	Values    []string `json:"values"`
+	Variation int      `json:"variation"`
+}
+
+// Refresh fetches the current flags from LaunchDarkly
+func (p *LaunchDarklyProvider) Refresh(ctx context.Context) error {
+	if p.config.SDKKey == "" {
+		return cerrors.New(nil, "launchdarkly sdk key is not set", nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(p.config.BaseURL, "/")+"/sdk/latest-flags", nil)
+	if err != nil {
+		return cerrors.New(err, "failed to create launchdarkly request", nil)
+	}
+
+	req.Header.Set("Authorization", p.config.SDKKey)
+
+	body, err := doRequest(p.client, req)
+	if err != nil {
+		return err
+	}
+
+	var flags map[string]launchDarklyFlag
+
+	err = json.Unmarshal(body, &flags)
+	if err != nil {
+		return cerrors.New(err, "failed to decode launchdarkly flags", nil)
+	}
+
+	p.mu.Lock()
+	p.flags = flags
+	p.mu.Unlock()
+
+	return nil
+}
+
+// Evaluate evaluates the flag for the subject. The flag is enabled if the resulting variation is true.
+func (p *LaunchDarklyProvider) Evaluate(_ context.Context, name string, subject Subject) (bool, error) {
+	p.mu.RLock()
+	flag, ok := p.flags[name]
+	p.mu.RUnlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	return flag.variationValue(flag.variation(subject.UserID)), nil
+}
+
+// variation returns the index of the flag variation served to the user or -1 if no variation is served
+func (f *launchDarklyFlag) variation(userKey string) int {
+	if !f.On {
+		if f.OffVariation == nil {
+			return -1
+		}
+
+		return *f.OffVariation
+	}
+
+	for _, t := range f.Targets {
+		for _, v := range t.Values {
+			if userKey != "" && v == userKey {
+				return t.Variation
+			}
+		}
+	}
+
+	if f.Fallthrough.Variation != nil {
+		return *f.Fallthrough.Variation
+	}
+
+	if f.Fallthrough.Rollout == nil {
+		return -1
+	}
+
+	var (
+		bucket = launchDarklyBucket(f.Key, f.Salt, userKey)
+		sum    float64
+	)
+
+	for _, v := range f.Fallthrough.Rollout.Variations {
+		sum += float64(v.Weight) / launchDarklyWeightScale
+		if bucket < sum {
+			return v.Variation
+		}
+	}
+
+	// Weights may not add up to exactly 100% so the last variation is used for the remaining buckets
+	if n := len(f.Fallthrough.Rollout.Variations); n > 0 {
+		return f.Fallthrough.Rollout.Variations[n-1].Variation
+	}
+
+	return -1
+}
+
+func (f *launchDarklyFlag) variationValue(i int) bool {
+	if i < 0 || i >= len(f.Variations) {
+		return false
+	}
+
+	enabled, _ := f.Variations[i].(bool)
+
+	return enabled
+}
+
+// launchDarklyBucket returns the user's bucket (0-1) for a percentage rollout, the same way LaunchDarkly's SDKs do
+func launchDarklyBucket(key, salt, userKey string) float64 {
+	sum := sha1.Sum([]byte(key + "." + salt + "." + userKey)) //nolint:gosec
+
+	val, err := strconv.ParseInt(hex.EncodeToString(sum[:])[:15], 16, 64)
+	if err != nil {
+		return 0
+	}
+
+	return float64(val) / launchDarklyBucketScale
+}
@@ -0,0 +1,140 @@
+package cflag
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+)
+
+const remoteFetchTimeout = 10 * time.Second
+
+// NewProviderParams holds the params needed to create a Provider
+type NewProviderParams struct {
+	Config    Config
+	Lifecycle *clifecycle.Lifecycle
+	Logger    clogger.Logger
+}
+
+// NewProvider creates the Provider set in the config. Remote providers fetch the flags once before returning and
+// then refresh them in the background until the lifecycle stops.
+func NewProvider(p NewProviderParams) (Provider, error) {
+	switch p.Config.Provider {
+	case ProviderConfig, "":
+		return NewConfigProvider(p.Config.Flags), nil
+	case ProviderUnleash:
+		provider := NewUnleashProvider(p.Config.Unleash)
+
+		startPolling(p, provider.Refresh)
+
+		return provider, nil
+	case ProviderLaunchDarkly:
+		provider := NewLaunchDarklyProvider(p.Config.LaunchDarkly)
+
+		startPolling(p, provider.Refresh)
+
+		return provider, nil
+	default:
+		return nil, cerrors.New(nil, "unknown feature flag provider", map[string]interface{}{
+			"provider": p.Config.Provider,
+		})
+	}
+}
+
+// NewConfigProvider creates a Provider that evaluates the flags defined in the app config. Flags that are not defined
+// are disabled.
+func NewConfigProvider(flags map[string]ConfigFlag) *ConfigProvider {
+	return &ConfigProvider{flags: flags}
+}
+
+// ConfigProvider evaluates flags defined in the app config. See ConfigFlag.
+type ConfigProvider struct {
+	flags map[string]ConfigFlag
+}
+
+// Evaluate evaluates the flag for the subject
+func (p *ConfigProvider) Evaluate(_ context.Context, name string, subject Subject) (bool, error) {
+	flag, ok := p.flags[name]
+	if !ok {
+		return false, nil
+	}
+
+	if flag.Enabled {
+		return true, nil
+	}
+
+	for _, user := range flag.Users {
+		if subject.UserID != "" && user == subject.UserID {
+			return true, nil
+		}
+	}
+
+	for attr, vals := range flag.Attributes {
+		subjectVal, ok := subject.Attributes[attr]
+		if !ok {
+			continue
+		}
+
+		for _, v := range vals {
+			if v == subjectVal {
+				return true, nil
+			}
+		}
+	}
+
+	return inRollout(name, subject.UserID, flag.Percentage), nil
+}
+
+// startPolling fetches the flags using refresh and then keeps refreshing them at the configured interval. Errors are
+// logged so that the app can start (with every flag disabled) while the provider is down.
+func startPolling(p NewProviderParams, refresh func(ctx context.Context) error) {
+	var (
+		interval = p.Config.RefreshInterval
+		done     = make(chan struct{})
+		logger   = p.Logger.With(clogger.Module("cflag")).WithFields(map[string]interface{}{
+			"provider": p.Config.Provider,
+		})
+	)
+
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+
+	doRefresh := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), remoteFetchTimeout)
+		defer cancel()
+
+		err := refresh(ctx)
+		if err != nil {
+			logger.Error("Failed to refresh feature flags", err)
+		}
+	}
+
+	doRefresh()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				doRefresh()
+			}
+		}
+	}()
+
+	p.Lifecycle.OnStop(func(ctx context.Context) error {
+		close(done)
+		return nil
+	})
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: remoteFetchTimeout}
+}
@@ -0,0 +1,111 @@
+package cflag_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocopper/copper/cflag"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProvider_Unleash(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/client/features", r.URL.Path)
+		assert.Equal(t, "api-key", r.Header.Get("Authorization"))
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"features": []map[string]interface{}{
+				{"name": "everyone", "enabled": true, "strategies": []map[string]interface{}{{"name": "default"}}},
+				{"name": "disabled", "enabled": false, "strategies": []map[string]interface{}{{"name": "default"}}},
+				{"name": "beta", "enabled": true, "strategies": []map[string]interface{}{{
+					"name":       "userWithId",
+					"parameters": map[string]string{"userIds": "user-1, user-2"},
+				}}},
+				{"name": "rollout", "enabled": true, "strategies": []map[string]interface{}{{
+					"name":       "flexibleRollout",
+					"parameters": map[string]string{"rollout": "100", "stickiness": "userId"},
+					"constraints": []map[string]interface{}{{
+						"contextName": "plan",
+						"operator":    "IN",
+						"values":      []string{"pro"},
+					}},
+				}}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	lc := clifecycle.New()
+	defer lc.Stop(clogger.NewNoop())
+
+	provider, err := cflag.NewProvider(cflag.NewProviderParams{
+		Config: cflag.Config{
+			Provider: cflag.ProviderUnleash,
+			Unleash:  cflag.ConfigUnleash{URL: server.URL + "/api", APIKey: "api-key", AppName: "test"},
+		},
+		Lifecycle: lc,
+		Logger:    clogger.NewNoop(),
+	})
+	assert.NoError(t, err)
+
+	eval := func(name string, subject cflag.Subject) bool {
+		enabled, err := provider.Evaluate(context.Background(), name, subject)
+		assert.NoError(t, err)
+
+		return enabled
+	}
+
+	assert.True(t, eval("everyone", cflag.Subject{}))
+	assert.False(t, eval("disabled", cflag.Subject{}))
+	assert.True(t, eval("beta", cflag.Subject{UserID: "user-2"}))
+	assert.False(t, eval("beta", cflag.Subject{UserID: "user-3"}))
+	assert.True(t, eval("rollout", cflag.Subject{UserID: "user-3", Attributes: map[string]string{"plan": "pro"}}))
+	assert.False(t, eval("rollout", cflag.Subject{UserID: "user-3", Attributes: map[string]string{"plan": "free"}}))
+	assert.False(t, eval("rollout", cflag.Subject{Attributes: map[string]string{"plan": "pro"}}))
+}
+
+func TestLaunchDarklyProvider(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/sdk/latest-flags", r.URL.Path)
+		assert.Equal(t, "sdk-key", r.Header.Get("Authorization"))
+
+		_, _ = w.Write([]byte(`{
+			"off": {"key": "off", "on": false, "offVariation": 1, "variations": [true, false]},
+			"targeted": {
+				"key": "targeted", "on": true, "variations": [true, false],
+				"targets": [{"values": ["user-1"], "variation": 0}],
+				"fallthrough": {"variation": 1}
+			},
+			"rollout": {
+				"key": "rollout", "on": true, "salt": "salt", "variations": [true, false],
+				"fallthrough": {"rollout": {"variations": [{"variation": 0, "weight": 100000}, {"variation": 1, "weight": 0}]}}
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	provider := cflag.NewLaunchDarklyProvider(cflag.ConfigLaunchDarkly{SDKKey: "sdk-key", BaseURL: server.URL})
+	assert.NoError(t, provider.Refresh(context.Background()))
+
+	eval := func(name string, subject cflag.Subject) bool {
+		enabled, err := provider.Evaluate(context.Background(), name, subject)
+		assert.NoError(t, err)
+
+		return enabled
+	}
+
+	assert.False(t, eval("off", cflag.Subject{UserID: "user-1"}))
+	assert.True(t, eval("targeted", cflag.Subject{UserID: "user-1"}))
+	assert.False(t, eval("targeted", cflag.Subject{UserID: "user-2"}))
+	assert.True(t, eval("rollout", cflag.Subject{UserID: "user-2"}))
+	assert.False(t, eval("unknown", cflag.Subject{UserID: "user-2"}))
+}
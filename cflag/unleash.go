@@ -0,0 +1,264 @@
+package cflag
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// NewUnleashProvider creates an UnleashProvider. Call Refresh to fetch the flags (see NewProvider).
+func NewUnleashProvider(config ConfigUnleash) *UnleashProvider {
+	return &UnleashProvider{
+		config: config,
+		client: newHTTPClient(),
+	}
+}
+
+// UnleashProvider evaluates flags fetched from Unleash's client API. It supports the default, userWithId,
+// flexibleRollout, and gradualRolloutUserId strategies, along with IN and NOT_IN constraints on the user id and
+// subject attributes. Strategies that are not supported never match.
+type UnleashProvider struct {
+	config ConfigUnleash
+	client *http.Client
+
+	mu       sync.RWMutex
+	features map[string]unleashFeature
+}
+
+type unleashFeature struct {
+	Name       string            `json:"name"`
+	Enabled    bool              `json:"enabled"`
+	Strategies []unleashStrategy `json:"strategies"`
+}
+
+type unleashStrategy struct {
+	Name        string              `json:"name"`
+	Parameters  map[string]string   `json:"parameters"`
+	Constraints []unleashConstraint `json:"constraints"`
+}
+
+type unleashConstraint struct {
+	ContextName string   `json:"contextName"`
+	Operator    string   `json:"operator"`
+	Values      []string `json:"values"`
+}
+
+// Refresh fetches the current flags from Unleash
+func (p *UnleashProvider) Refresh(ctx context.Context) error {
+	if p.config.URL == "" {
+		return cerrors.New(nil, "unleash url is not set", nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.config.URL, "/")+"/client/features", nil)
+	if err != nil {
+		return cerrors.New(err, "failed to create unleash request", nil)
+	}
+
+	req.Header.Set("Authorization", p.config.APIKey)
+	req.Header.Set("UNLEASH-APPNAME", p.config.AppName)
+
+	body, err := doRequest(p.client, req)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Features []unleashFeature `json:"features"`
+	}
+
+	err = json.Unmarshal(body, &resp)
+	if err != nil {
+		return cerrors.New(err, "failed to decode unleash features", nil)
+	}
+
+	features := make(map[string]unleashFeature, len(resp.Features))
+	for _, f := range resp.Features {
+		features[f.Name] = f
+	}
+
+	p.mu.Lock()
+	p.features = features
+	p.mu.Unlock()
+
+	return nil
+}
+
+// Evaluate evaluates the flag for the subject. A flag is enabled if it is enabled in Unleash and any of its
+// strategies match.
+func (p *UnleashProvider) Evaluate(_ context.Context, name string, subject Subject) (bool, error) {
+	p.mu.RLock()
+	feature, ok := p.features[name]
+	p.mu.RUnlock()
+
+	if !ok || !feature.Enabled {
+		return false, nil
+	}
+
+	// A feature without strategies is enabled for everyone
+	if len(feature.Strategies) == 0 {
+		return true, nil
+	}
+
+	for _, s := range feature.Strategies {
+		if unleashConstraintsMatch(s.Constraints, subject) && unleashStrategyMatches(name, s, subject) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func unleashStrategyMatches(flag string, s unleashStrategy, subject Subject) bool {
+	switch s.Name {
+	case "default":
+		return true
+	case "userWithId":
+		for _, id := range strings.Split(s.Parameters["userIds"], ",") {
+			if subject.UserID != "" && strings.TrimSpace(id) == subject.UserID {
+				return true
+			}
+		}
+
+		return false
+	case "flexibleRollout", "gradualRolloutUserId":
+		percentage := s.Parameters["rollout"]
+		if s.Name == "gradualRolloutUserId" {
+			percentage = s.Parameters["percentage"]
+		}
+
+		groupID := s.Parameters["groupId"]
+		if groupID == "" {
+			groupID = flag
+		}
+
+		rollout, err := strconv.Atoi(percentage)
+		if err != nil || subject.UserID == "" {
+			return false
+		}
+
+		return unleashNormalizedHash(groupID, subject.UserID) <= uint32(rollout)
+	default:
+		return false
+	}
+}
+
+func unleashConstraintsMatch(constraints []unleashConstraint, subject Subject) bool {
+	for _, c := range constraints {
+		val, ok := subject.Attributes[c.ContextName]
+		if c.ContextName == "userId" {
+			val, ok = subject.UserID, subject.UserID != ""
+		}
+
+		in := false
+
+		for _, v := range c.Values {
+			if ok && v == val {
+				in = true
+				break
+			}
+		}
+
+		switch c.Operator {
+		case "IN":
+			if !in {
+				return false
+			}
+		case "NOT_IN":
+			if in {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// unleashNormalizedHash returns a number between 1 and 100 for the user in the group, the same way Unleash's SDKs do
+// so that users get the same result as in other services.
+func unleashNormalizedHash(groupID, userID string) uint32 {
+	return murmur3([]byte(groupID+":"+userID), 0)%100 + 1 //nolint:gomnd
+}
+
+// murmur3 computes the 32-bit MurmurHash3 (x86) of data
+func murmur3(data []byte, seed uint32) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+
+	h := seed
+	nblocks := len(data) / 4 //nolint:gomnd
+
+	for i := 0; i < nblocks; i++ {
+		k := uint32(data[i*4]) | uint32(data[i*4+1])<<8 | uint32(data[i*4+2])<<16 | uint32(data[i*4+3])<<24
+
+		k *= c1
+		k = (k << 15) | (k >> 17)
+		k *= c2
+
+		h ^= k
+		h = (h << 13) | (h >> 19)
+		h = h*5 + 0xe6546b64
+	}
+
+	var (
+		tail = data[nblocks*4:]
+		k    uint32
+	)
+
+	switch len(tail) {
+	case 3: //nolint:gomnd
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2: //nolint:gomnd
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = (k << 15) | (k >> 17)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+
+	return h
+}
+
+func doRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to send request", map[string]interface{}{
+			"url": req.URL.String(),
+		})
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to read response", nil)
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, cerrors.New(nil, "request failed", map[string]interface{}{
+			"url":        req.URL.String(),
+			"statusCode": resp.StatusCode,
+		})
+	}
+
+	return body, nil
+}
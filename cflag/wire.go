@@ -0,0 +1,13 @@
+package cflag
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+	NewProvider,
+	wire.Struct(new(NewProviderParams), "*"),
+
+	NewFlags,
+	wire.Struct(new(NewFlagsParams), "*"),
+)
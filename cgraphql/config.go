@@ -0,0 +1,53 @@
+package cgraphql
+
+import (
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	defaultPath         = "/graphql"
+	defaultGraphiQLPath = "/graphiql"
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "cgraphql",
+		Description: "cgraphql configures the GraphQL endpoint",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("cgraphql", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load cgraphql config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Path:         defaultPath,
+		GraphiQLPath: defaultGraphiQLPath,
+	}
+}
+
+// Config configures the GraphQL endpoint. GraphiQL is usually only enabled in the dev config. For example:
+//
+//	[cgraphql]
+//	path = "/graphql"
+//
+//	# config/dev.toml
+//	[cgraphql]
+//	graphiql = true
+type Config struct {
+	Path string `toml:"path" doc:"Path of the GraphQL endpoint"`
+
+	GraphiQL     bool   `toml:"graphiql" doc:"Serve the GraphiQL explorer"`
+	GraphiQLPath string `toml:"graphiql_path" doc:"Path of the GraphiQL explorer"`
+}
@@ -0,0 +1,4 @@
+// Package cgraphql mounts a GraphQL server (ex. a gqlgen handler.Server) on the app's chttp handler so that GraphQL
+// requests go through the same middlewares as the other routes. It creates the app's dataloaders once per request,
+// exposes the request's user to resolvers, and can serve a GraphiQL explorer in dev.
+package cgraphql
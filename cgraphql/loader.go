@@ -0,0 +1,201 @@
+package cgraphql
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultLoaderWait = 2 * time.Millisecond
+
+// LoadersFunc creates the app's dataloaders. It is called once per GraphQL request so that loaders batch and cache
+// the loads of a single request only. Resolvers read them using LoadersFromCtx. For example:
+//
+//	type Loaders struct {
+//		UserByID *cgraphql.Loader[string, *User]
+//	}
+//
+//	func NewLoadersFunc(users *users.Queries) cgraphql.LoadersFunc {
+//		return func(ctx context.Context) interface{} {
+//			return &Loaders{UserByID: cgraphql.NewLoader(users.GetUsersByIDs, cgraphql.LoaderOptions{})}
+//		}
+//	}
+type LoadersFunc func(ctx context.Context) interface{}
+
+// NoLoaders is a LoadersFunc for apps that do not use dataloaders
+func NoLoaders(ctx context.Context) interface{} {
+	return nil
+}
+
+// LoadersFromCtx returns the loaders created by the app's LoadersFunc for the GraphQL request
+func LoadersFromCtx(ctx context.Context) interface{} {
+	return ctx.Value(ctxLoadersKey)
+}
+
+// CtxWithLoaders returns a context that holds the loaders returned by LoadersFromCtx. It can be used to test
+// resolvers.
+func CtxWithLoaders(ctx context.Context, loaders interface{}) context.Context {
+	return context.WithValue(ctx, ctxLoadersKey, loaders)
+}
+
+// BatchFunc fetches the values of keys. Keys that are missing from the returned map are loaded as the zero value.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// LoaderOptions configures a Loader
+type LoaderOptions struct {
+	// Wait is how long a Loader waits for more keys before fetching a batch (default: 2ms)
+	Wait time.Duration
+
+	// MaxBatch is the max number of keys fetched at once. If it is 0, batches are not limited.
+	MaxBatch int
+}
+
+// NewLoader creates a Loader that fetches keys using fetch
+func NewLoader[K comparable, V any](fetch BatchFunc[K, V], opts LoaderOptions) *Loader[K, V] {
+	if opts.Wait <= 0 {
+		opts.Wait = defaultLoaderWait
+	}
+
+	return &Loader[K, V]{
+		fetch: fetch,
+		opts:  opts,
+		cache: make(map[K]*loaderResult[V]),
+	}
+}
+
+// Loader batches the loads of concurrent resolvers into a single fetch and caches the results, which avoids N+1
+// queries when resolving lists. Since results are cached for the loader's lifetime, loaders should be created per
+// request using LoadersFunc. Failed loads are not cached.
+type Loader[K comparable, V any] struct {
+	fetch BatchFunc[K, V]
+	opts  LoaderOptions
+
+	mu    sync.Mutex
+	cache map[K]*loaderResult[V]
+	batch *loaderBatch[K, V]
+}
+
+type loaderResult[V any] struct {
+	done chan struct{}
+	val  V
+	err  error
+}
+
+type loaderBatch[K comparable, V any] struct {
+	ctx     context.Context
+	keys    []K
+	results []*loaderResult[V]
+	timer   *time.Timer
+}
+
+// Load returns the value of key. It waits for the batch the key is added to.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	return l.wait(ctx, l.enqueue(ctx, key))
+}
+
+// LoadMany returns the values of keys in order. All keys are fetched in the same batch if possible.
+func (l *Loader[K, V]) LoadMany(ctx context.Context, keys []K) ([]V, error) {
+	results := make([]*loaderResult[V], len(keys))
+	for i := range keys {
+		results[i] = l.enqueue(ctx, keys[i])
+	}
+
+	vals := make([]V, len(keys))
+
+	for i := range results {
+		val, err := l.wait(ctx, results[i])
+		if err != nil {
+			return nil, err
+		}
+
+		vals[i] = val
+	}
+
+	return vals, nil
+}
+
+// Prime caches the value of key so that loading it does not fetch it (ex. after the value was loaded by a query
+// that lists values)
+func (l *Loader[K, V]) Prime(key K, val V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.cache[key]; ok {
+		return
+	}
+
+	done := make(chan struct{})
+	close(done)
+
+	l.cache[key] = &loaderResult[V]{done: done, val: val}
+}
+
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K) *loaderResult[V] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if res, ok := l.cache[key]; ok {
+		return res
+	}
+
+	res := &loaderResult[V]{done: make(chan struct{})}
+	l.cache[key] = res
+
+	if l.batch == nil {
+		b := &loaderBatch[K, V]{ctx: ctx}
+		b.timer = time.AfterFunc(l.opts.Wait, func() { l.dispatch(b) })
+
+		l.batch = b
+	}
+
+	l.batch.keys = append(l.batch.keys, key)
+	l.batch.results = append(l.batch.results, res)
+
+	if l.opts.MaxBatch > 0 && len(l.batch.keys) >= l.opts.MaxBatch {
+		b := l.batch
+		l.batch = nil
+
+		// if the timer already fired, the batch is dispatched by it
+		if b.timer.Stop() {
+			go l.dispatch(b)
+		}
+	}
+
+	return res
+}
+
+func (l *Loader[K, V]) dispatch(b *loaderBatch[K, V]) {
+	l.mu.Lock()
+	if l.batch == b {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	vals, err := l.fetch(b.ctx, b.keys)
+
+	if err != nil {
+		// failed keys are removed from the cache so that they are fetched again by the next load
+		l.mu.Lock()
+		for i := range b.keys {
+			if l.cache[b.keys[i]] == b.results[i] {
+				delete(l.cache, b.keys[i])
+			}
+		}
+		l.mu.Unlock()
+	}
+
+	for i, res := range b.results {
+		res.val, res.err = vals[b.keys[i]], err
+		close(res.done)
+	}
+}
+
+func (l *Loader[K, V]) wait(ctx context.Context, res *loaderResult[V]) (V, error) {
+	select {
+	case <-res.done:
+		return res.val, res.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
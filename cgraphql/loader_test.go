@@ -0,0 +1,111 @@
+package cgraphql_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/gocopper/copper/cgraphql"
+	"github.com/stretchr/testify/assert"
+)
+
+type fetchLog struct {
+	mu      sync.Mutex
+	batches [][]int
+	fail    bool
+}
+
+func (f *fetchLog) fetch(ctx context.Context, keys []int) (map[int]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.batches = append(f.batches, keys)
+
+	if f.fail {
+		return nil, errors.New("test-err")
+	}
+
+	vals := make(map[int]string, len(keys))
+	for _, k := range keys {
+		if k >= 0 {
+			vals[k] = string(rune('a' + k))
+		}
+	}
+
+	return vals, nil
+}
+
+func TestLoader_Batch(t *testing.T) {
+	t.Parallel()
+
+	var (
+		log    fetchLog
+		loader = cgraphql.NewLoader(log.fetch, cgraphql.LoaderOptions{})
+		wg     sync.WaitGroup
+		vals   = make([]string, 5)
+	)
+
+	for i := range vals {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			val, err := loader.Load(context.Background(), i%3)
+			assert.NoError(t, err)
+
+			vals[i] = val
+		}(i)
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, []string{"a", "b", "c", "a", "b"}, vals)
+	assert.Len(t, log.batches, 1)
+	assert.ElementsMatch(t, []int{0, 1, 2}, log.batches[0])
+
+	// loaded keys are cached
+	val, err := loader.Load(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", val)
+	assert.Len(t, log.batches, 1)
+}
+
+func TestLoader_LoadMany(t *testing.T) {
+	t.Parallel()
+
+	var (
+		log    fetchLog
+		loader = cgraphql.NewLoader(log.fetch, cgraphql.LoaderOptions{MaxBatch: 2})
+	)
+
+	loader.Prime(0, "primed")
+
+	vals, err := loader.LoadMany(context.Background(), []int{0, 1, 2, 3, -1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"primed", "b", "c", "d", ""}, vals)
+	assert.ElementsMatch(t, [][]int{{1, 2}, {3, -1}}, log.batches)
+}
+
+func TestLoader_Err(t *testing.T) {
+	t.Parallel()
+
+	var (
+		log    = fetchLog{fail: true}
+		loader = cgraphql.NewLoader(log.fetch, cgraphql.LoaderOptions{})
+	)
+
+	_, err := loader.Load(context.Background(), 1)
+	assert.Error(t, err)
+
+	// failed keys are fetched again
+	log.mu.Lock()
+	log.fail = false
+	log.mu.Unlock()
+
+	val, err := loader.Load(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", val)
+	assert.Len(t, log.batches, 2)
+}
@@ -0,0 +1,151 @@
+package cgraphql
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+)
+
+var graphiQLTemplate = template.Must(template.New("graphiql").Parse(graphiQLHTML)) //nolint:gochecknoglobals
+
+const graphiQLHTML = `<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>GraphiQL</title>
+<link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+<style>body{margin:0;height:100vh}#graphiql{height:100vh}</style>
+</head>
+<body>
+<div id="graphiql"></div>
+<script src="https://unpkg.com/react@18/umd/react.production.min.js" crossorigin></script>
+<script src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js" crossorigin></script>
+<script src="https://unpkg.com/graphiql@3/graphiql.min.js" crossorigin></script>
+<script nonce="{{ .Nonce }}">
+ReactDOM.createRoot(document.getElementById("graphiql")).render(React.createElement(GraphiQL, {
+  fetcher: GraphiQL.createFetcher({url: {{ .Endpoint }}}),
+}));
+</script>
+</body>
+</html>`
+
+// Server executes GraphQL requests. A gqlgen server implements it:
+//
+//	srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: resolver}))
+//
+//	wire.Bind(new(cgraphql.Server), new(*handler.Server))
+type Server interface {
+	http.Handler
+}
+
+// NewRouterParams holds the params needed for NewRouter
+type NewRouterParams struct {
+	Server  Server
+	Loaders LoadersFunc
+	Users   Users
+	RW      *chttp.ReaderWriter
+	Config  Config
+	Logger  clogger.Logger
+}
+
+// NewRouter creates a new Router
+func NewRouter(p NewRouterParams) *Router {
+	loaders := p.Loaders
+	if loaders == nil {
+		loaders = NoLoaders
+	}
+
+	return &Router{
+		server:  p.Server,
+		loaders: loaders,
+		users:   p.Users,
+		rw:      p.RW,
+		config:  p.Config,
+		logger:  p.Logger.With(clogger.Module("cgraphql")),
+	}
+}
+
+// Router is a chttp.Router that serves the GraphQL endpoint:
+//
+//	GET|POST /graphql   executes GraphQL requests using Server
+//	GET      /graphiql  serves the GraphiQL explorer if Config.GraphiQL is set
+//
+// Since it is registered like any other router, GraphQL requests go through the app's global middlewares (ex. the
+// request logger and the database transaction). Before a request is executed, the app's loaders are created and the
+// request's user is stored in the context for resolvers.
+type Router struct {
+	server  Server
+	loaders LoadersFunc
+	users   Users
+	rw      *chttp.ReaderWriter
+	config  Config
+	logger  clogger.Logger
+}
+
+// Routes returns the GraphQL routes
+func (ro *Router) Routes() []chttp.Route {
+	routes := []chttp.Route{
+		{
+			Path:    ro.config.Path,
+			Methods: []string{http.MethodGet, http.MethodPost},
+			Handler: ro.HandleGraphQL,
+		},
+	}
+
+	if ro.config.GraphiQL {
+		routes = append(routes, chttp.Route{
+			// GraphiQL is loaded from unpkg and started by an inline script
+			Middlewares: []chttp.Middleware{chttp.OverrideSecurityHeaders(chttp.ConfigSecurityHeaders{
+				ContentSecurityPolicy: "default-src 'self'; img-src 'self' data:; font-src https: data:; " +
+					"style-src 'self' https: 'unsafe-inline'; script-src https://unpkg.com {nonce}",
+			})},
+			Path:    ro.config.GraphiQLPath,
+			Methods: []string{http.MethodGet},
+			Handler: ro.HandleGraphiQL,
+		})
+	}
+
+	return routes
+}
+
+// HandleGraphQL executes a GraphQL request with the request's user and a new set of loaders in its context
+func (ro *Router) HandleGraphQL(w http.ResponseWriter, r *http.Request) {
+	user, err := ro.users.CurrentUser(r)
+	if err != nil {
+		ro.logger.Error("Failed to get current user of graphql request", err)
+
+		ro.rw.WriteJSON(w, chttp.WriteJSONParams{
+			StatusCode: http.StatusInternalServerError,
+			Data: map[string]interface{}{
+				"errors": []map[string]string{{"message": "internal server error"}},
+			},
+		})
+
+		return
+	}
+
+	ctx := r.Context()
+
+	if user != nil {
+		ctx = CtxWithUser(ctx, user)
+	}
+
+	ctx = CtxWithLoaders(ctx, ro.loaders(ctx))
+
+	ro.server.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// HandleGraphiQL serves the GraphiQL explorer
+func (ro *Router) HandleGraphiQL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	err := graphiQLTemplate.Execute(w, map[string]interface{}{
+		"Endpoint": ro.config.Path,
+		"Nonce":    chttp.CSPNonce(r.Context()),
+	})
+	if err != nil {
+		ro.logger.Error("Failed to render graphiql", err)
+	}
+}
@@ -0,0 +1,164 @@
+package cgraphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gocopper/copper/cgraphql"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/chttp/chttptest"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+type headerUsers struct{}
+
+func (headerUsers) CurrentUser(r *http.Request) (interface{}, error) {
+	switch user := r.Header.Get("X-User"); user {
+	case "":
+		return nil, nil
+	case "broken":
+		return nil, errors.New("test-err")
+	default:
+		return user, nil
+	}
+}
+
+type testLoaders struct {
+	id int
+}
+
+// echoServer is a Server that responds with the request's user and loaders
+func echoServer() cgraphql.Server {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loaders, _ := cgraphql.LoadersFromCtx(r.Context()).(*testLoaders)
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"user":    cgraphql.GetCurrentUser(r.Context()),
+			"loaders": loaders.id,
+		})
+	})
+}
+
+func newTestServer(t *testing.T, config cgraphql.Config) *httptest.Server {
+	t.Helper()
+
+	var created int
+
+	ro := cgraphql.NewRouter(cgraphql.NewRouterParams{
+		Server: echoServer(),
+		Loaders: func(ctx context.Context) interface{} {
+			created++
+			return &testLoaders{id: created}
+		},
+		Users:  headerUsers{},
+		RW:     chttptest.NewReaderWriter(t),
+		Config: config,
+		Logger: clogger.NewNoop(),
+	})
+
+	server := httptest.NewServer(chttp.NewHandler(chttp.NewHandlerParams{
+		Routers: []chttp.Router{ro},
+		Logger:  clogger.NewNoop(),
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func post(t *testing.T, url, user string) (int, map[string]interface{}) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url,
+		strings.NewReader(`{"query":"{ me }"}`))
+	assert.NoError(t, err)
+
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+
+	defer func() { assert.NoError(t, resp.Body.Close()) }()
+
+	var body map[string]interface{}
+
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+	return resp.StatusCode, body
+}
+
+func TestRouter_HandleGraphQL(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, cgraphql.Config{Path: "/graphql"})
+
+	status, body := post(t, server.URL+"/graphql", "user-1")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "user-1", body["user"])
+	assert.Equal(t, float64(1), body["loaders"])
+
+	// loaders are created per request
+	status, body = post(t, server.URL+"/graphql", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Nil(t, body["user"])
+	assert.Equal(t, float64(2), body["loaders"])
+}
+
+func TestRouter_HandleGraphQL_UsersErr(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, cgraphql.Config{Path: "/graphql"})
+
+	status, body := post(t, server.URL+"/graphql", "broken")
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Contains(t, body, "errors")
+}
+
+func TestRouter_GraphiQL(t *testing.T) {
+	t.Parallel()
+
+	disabled := cgraphql.NewRouter(cgraphql.NewRouterParams{
+		Server: echoServer(),
+		Users:  cgraphql.Anonymous{},
+		Config: cgraphql.Config{Path: "/graphql", GraphiQLPath: "/graphiql"},
+		Logger: clogger.NewNoop(),
+	})
+	assert.Len(t, disabled.Routes(), 1)
+
+	server := newTestServer(t, cgraphql.Config{Path: "/api/graphql", GraphiQL: true, GraphiQLPath: "/graphiql"})
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/graphiql", nil)
+	assert.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+
+	defer func() { assert.NoError(t, resp.Body.Close()) }()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+	assert.Contains(t, resp.Header.Get("Content-Security-Policy"), "script-src https://unpkg.com")
+	assert.Contains(t, string(body), `"/api/graphql"`)
+}
+
+func TestRequireUser(t *testing.T) {
+	t.Parallel()
+
+	_, err := cgraphql.RequireUser(context.Background())
+	assert.ErrorIs(t, err, cgraphql.ErrUnauthenticated)
+
+	user, err := cgraphql.RequireUser(cgraphql.CtxWithUser(context.Background(), "user-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", user)
+}
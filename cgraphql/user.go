@@ -0,0 +1,57 @@
+package cgraphql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+type ctxKey string
+
+const (
+	ctxUserKey    = ctxKey("cgraphql/user")
+	ctxLoadersKey = ctxKey("cgraphql/loaders")
+)
+
+// ErrUnauthenticated is returned by RequireUser if the request has no user
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Users gets the user of a request so that resolvers can read it using GetCurrentUser. Apps implement it using the
+// session or user set in the request context by their auth middleware.
+type Users interface {
+	// CurrentUser returns the user of the request or nil if the request is anonymous
+	CurrentUser(r *http.Request) (interface{}, error)
+}
+
+// Anonymous is a Users for apps without authentication. All requests are anonymous.
+type Anonymous struct{}
+
+// CurrentUser implements Users. It always returns nil.
+func (Anonymous) CurrentUser(r *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+// CtxWithUser returns a context that holds the user returned by GetCurrentUser. It can be used to test resolvers.
+func CtxWithUser(ctx context.Context, user interface{}) context.Context {
+	return context.WithValue(ctx, ctxUserKey, user)
+}
+
+// GetCurrentUser returns the user of the GraphQL request, or nil if it is anonymous. For example:
+//
+//	func (r *queryResolver) Me(ctx context.Context) (*model.User, error) {
+//		user, _ := cgraphql.GetCurrentUser(ctx).(*model.User)
+//		return user, nil
+//	}
+func GetCurrentUser(ctx context.Context) interface{} {
+	return ctx.Value(ctxUserKey)
+}
+
+// RequireUser returns the user of the GraphQL request or ErrUnauthenticated if it is anonymous
+func RequireUser(ctx context.Context) (interface{}, error) {
+	user := GetCurrentUser(ctx)
+	if user == nil {
+		return nil, ErrUnauthenticated
+	}
+
+	return user, nil
+}
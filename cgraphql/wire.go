@@ -0,0 +1,11 @@
+package cgraphql
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup. Apps must also provide a Server, LoadersFunc, and Users, or
+// bind NoLoaders and Anonymous.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+	NewRouter,
+	wire.Struct(new(NewRouterParams), "*"),
+)
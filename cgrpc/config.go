@@ -0,0 +1,70 @@
+package cgrpc
+
+import (
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	defaultPort           = 9090
+	defaultMaxRecvMsgSize = 4 << 20
+	defaultGatewayPrefix  = "/api"
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "cgrpc",
+		Description: "cgrpc configures the gRPC server",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("cgrpc", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load cgrpc config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Port:           defaultPort,
+		MaxRecvMsgSize: defaultMaxRecvMsgSize,
+		Gateway: ConfigGateway{
+			Prefix: defaultGatewayPrefix,
+		},
+	}
+}
+
+// Config configures the gRPC server. For example:
+//
+//	[cgrpc]
+//	port = 9090
+//	reflection = true
+//
+//	[cgrpc.gateway]
+//	prefix = "/api"
+type Config struct {
+	// Port the gRPC server listens on
+	Port uint `toml:"port" doc:"Port the gRPC server listens on"`
+
+	// Reflection enables the server reflection service used by tools such as grpcurl
+	Reflection bool `toml:"reflection" doc:"Enables the server reflection service"`
+
+	// MaxRecvMsgSize is the max size of a request message in bytes
+	MaxRecvMsgSize int `toml:"max_recv_msg_size" doc:"Max size of a request message in bytes"`
+
+	Gateway ConfigGateway `toml:"gateway"`
+}
+
+// ConfigGateway configures Gateway
+type ConfigGateway struct {
+	// Prefix is the path that the REST API is served under. It is removed before the request is matched against the
+	// paths in the services' annotations.
+	Prefix string `toml:"prefix"`
+}
@@ -0,0 +1,7 @@
+// Package cgrpc serves gRPC services on the app's lifecycle alongside chttp. Requests go through interceptors that
+// mirror chttp's middleware: request ids and loggers in the context, panic recovery, request logging, and
+// Prometheus metrics. AuthInterceptor authenticates requests using bearer tokens, and Gateway exposes services that
+// have grpc-gateway annotations as a REST API through chttp.
+//
+// cgrpc is a separate Go module so that apps that do not use gRPC do not depend on it.
+package cgrpc
@@ -0,0 +1,104 @@
+package cgrpc
+
+import (
+	"context"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// GatewayService registers the REST handlers of a gRPC service on the gateway. For a service generated by
+// protoc-gen-grpc-gateway, it calls the generated Register<Service>Handler func:
+//
+//	func (s *UsersService) RegisterGateway(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+//		return pb.RegisterUsersHandler(ctx, mux, conn)
+//	}
+type GatewayService interface {
+	RegisterGateway(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+}
+
+// NewGatewayParams holds the params needed to create a Gateway
+type NewGatewayParams struct {
+	Services  []GatewayService
+	Lifecycle *clifecycle.Lifecycle
+	Config    Config
+	Logger    clogger.Logger
+}
+
+// NewGateway creates a new Gateway that proxies requests to the gRPC server on Config.Port. Since requests are sent
+// to the server, they go through the same interceptors (ex. auth) as gRPC calls.
+func NewGateway(p NewGatewayParams) (*Gateway, error) {
+	addr := "localhost:" + strconv.FormatUint(uint64(p.Config.Port), 10)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create grpc client", map[string]interface{}{
+			"addr": addr,
+		})
+	}
+
+	p.Lifecycle.OnStop(func(ctx context.Context) error {
+		return conn.Close()
+	})
+
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithMetadata(func(ctx context.Context, r *http.Request) metadata.MD {
+			// the request id assigned by chttp is forwarded so that logs of the request and call can be correlated
+			return metadata.Pairs(MetadataRequestID, chttp.RequestID(ctx))
+		}),
+	)
+
+	for _, svc := range p.Services {
+		err = svc.RegisterGateway(context.Background(), mux, conn)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to register gateway service", nil)
+		}
+	}
+
+	return &Gateway{
+		mux:    mux,
+		prefix: strings.TrimSuffix(p.Config.Gateway.Prefix, "/"),
+	}, nil
+}
+
+// Gateway is a chttp.Router that serves the REST API of gRPC services annotated with google.api.http options.
+// Routes are served under Config.Gateway.Prefix.
+type Gateway struct {
+	mux    *runtime.ServeMux
+	prefix string
+}
+
+// Routes returns the route that serves the REST API
+func (g *Gateway) Routes() []chttp.Route {
+	return []chttp.Route{
+		{
+			Path:    g.prefix + "/{path:.*}",
+			Handler: http.StripPrefix(g.prefix, g.mux).ServeHTTP,
+		},
+	}
+}
+
+// ServeMux returns the underlying grpc-gateway mux so that custom routes can be added to it
+func (g *Gateway) ServeMux() *runtime.ServeMux {
+	return g.mux
+}
+
+// gatewayHeaderMatcher forwards the trace header along with the headers forwarded by default (ex. Authorization)
+func gatewayHeaderMatcher(key string) (string, bool) {
+	if textproto.CanonicalMIMEHeaderKey(key) == "Traceparent" {
+		return "traceparent", true
+	}
+
+	return runtime.DefaultHeaderMatcher(key)
+}
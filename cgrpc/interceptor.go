@@ -0,0 +1,312 @@
+package cgrpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/clogger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MetadataRequestID is the metadata key used to read and write the request id. It matches chttp.HeaderRequestID so
+// that ids set by a reverse proxy or the gateway are kept.
+const MetadataRequestID = "x-request-id"
+
+type ctxKey string
+
+const (
+	ctxRequestIDKey = ctxKey("cgrpc/request-id")
+	ctxTraceIDKey   = ctxKey("cgrpc/trace-id")
+
+	maxRequestIDLen = 128
+)
+
+// AuthFunc authenticates the bearer token of a call. It returns the context that the call is handled with (ex. one
+// that holds the authenticated user) or an error if the token is not valid.
+type AuthFunc func(ctx context.Context, token string) (context.Context, error)
+
+// RequestID returns the id of the current call. The id is read from the x-request-id metadata if set by the client.
+// Otherwise, it is randomly generated.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxRequestIDKey).(string)
+	return id
+}
+
+// TraceID returns the trace id of the current call. It is read from the W3C traceparent metadata if present.
+// Otherwise, it is the same as the request id.
+func TraceID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxTraceIDKey).(string)
+	return id
+}
+
+// RequestCtxInterceptor assigns a request id and trace id to each call and adds them to the call's context along with
+// the logger so that clogger.FromCtx returns a logger that includes them. The request id is sent back in the
+// response header.
+func RequestCtxInterceptor(logger clogger.Logger) Interceptor {
+	return Interceptor{
+		Unary: func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler) (interface{}, error) {
+			return handler(requestCtx(ctx, logger), req)
+		},
+		Stream: func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo,
+			handler grpc.StreamHandler) error {
+			return handler(srv, &ctxServerStream{ServerStream: ss, ctx: requestCtx(ss.Context(), logger)})
+		},
+	}
+}
+
+// RecoveryInterceptor recovers from panics in handlers, logs them, and responds with codes.Internal
+func RecoveryInterceptor() Interceptor {
+	return Interceptor{
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler) (resp interface{}, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = recovered(ctx, info.FullMethod, r)
+				}
+			}()
+
+			return handler(ctx, req)
+		},
+		Stream: func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+			handler grpc.StreamHandler) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = recovered(ss.Context(), info.FullMethod, r)
+				}
+			}()
+
+			return handler(srv, ss)
+		},
+	}
+}
+
+// LoggingInterceptor logs each call's method, status code, and duration
+func LoggingInterceptor(logger clogger.Logger) Interceptor {
+	log := func(ctx context.Context, method string, start time.Time, err error) {
+		code := status.Code(err)
+
+		l := logger.
+			WithFields(clogger.FieldsFromCtx(ctx)).
+			WithFields(map[string]interface{}{
+				"method":         method,
+				"code":           code.String(),
+				"durationMillis": time.Since(start).Milliseconds(),
+			})
+
+		msg := fmt.Sprintf("%s %s", method, code)
+
+		switch code {
+		case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+			l.Error(msg, err)
+		default:
+			l.Info(msg)
+		}
+	}
+
+	return Interceptor{
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler) (interface{}, error) {
+			start := time.Now()
+			resp, err := handler(ctx, req)
+			log(ctx, info.FullMethod, start, err)
+
+			return resp, err
+		},
+		Stream: func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+			handler grpc.StreamHandler) error {
+			start := time.Now()
+			err := handler(srv, ss)
+			log(ss.Context(), info.FullMethod, start, err)
+
+			return err
+		},
+	}
+}
+
+// MetricsInterceptor records the number and duration of calls (see cgrpc_requests_total and
+// cgrpc_request_duration_seconds)
+func MetricsInterceptor() Interceptor {
+	observe := func(method string, start time.Time, err error) {
+		requestsTotal.WithLabelValues(method, status.Code(err).String()).Inc()
+		requestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	}
+
+	return Interceptor{
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler) (interface{}, error) {
+			start := time.Now()
+			resp, err := handler(ctx, req)
+			observe(info.FullMethod, start, err)
+
+			return resp, err
+		},
+		Stream: func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+			handler grpc.StreamHandler) error {
+			start := time.Now()
+			err := handler(srv, ss)
+			observe(info.FullMethod, start, err)
+
+			return err
+		},
+	}
+}
+
+// AuthInterceptor authenticates calls using the bearer token in their authorization metadata (the same header used
+// by the app's HTTP API, so the gateway forwards it as is). Calls without a valid token are rejected with
+// codes.Unauthenticated. Methods in public (full method names such as /app.Users/SignUp) are not authenticated.
+func AuthInterceptor(authenticate AuthFunc, public ...string) Interceptor {
+	publicMethods := make(map[string]bool, len(public))
+	for _, m := range public {
+		publicMethods[m] = true
+	}
+
+	auth := func(ctx context.Context, method string) (context.Context, error) {
+		if publicMethods[method] {
+			return ctx, nil
+		}
+
+		token := bearerToken(ctx)
+		if token == "" {
+			return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+		}
+
+		authCtx, err := authenticate(ctx, token)
+		if err != nil {
+			clogger.FromCtx(ctx).WithTags(map[string]interface{}{
+				"method": method,
+			}).Warn("Failed to authenticate grpc call", err)
+
+			return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+		}
+
+		return authCtx, nil
+	}
+
+	return Interceptor{
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler) (interface{}, error) {
+			ctx, err := auth(ctx, info.FullMethod)
+			if err != nil {
+				return nil, err
+			}
+
+			return handler(ctx, req)
+		},
+		Stream: func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+			handler grpc.StreamHandler) error {
+			ctx, err := auth(ss.Context(), info.FullMethod)
+			if err != nil {
+				return err
+			}
+
+			return handler(srv, &ctxServerStream{ServerStream: ss, ctx: ctx})
+		},
+	}
+}
+
+func requestCtx(ctx context.Context, logger clogger.Logger) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestID := firstMetadata(md, MetadataRequestID)
+	if requestID == "" || len(requestID) > maxRequestIDLen {
+		requestID = randomHex(16) //nolint:gomnd
+	}
+
+	traceID := traceIDFromHeader(firstMetadata(md, "traceparent"))
+	if traceID == "" {
+		traceID = requestID
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataRequestID, requestID))
+
+	ctx = context.WithValue(ctx, ctxRequestIDKey, requestID)
+	ctx = context.WithValue(ctx, ctxTraceIDKey, traceID)
+	ctx = clogger.CtxWithLogger(ctx, logger)
+	ctx = clogger.CtxWithFields(ctx, map[string]interface{}{
+		"requestID": requestID,
+		"traceID":   traceID,
+	})
+
+	return ctx
+}
+
+func recovered(ctx context.Context, method string, r interface{}) error {
+	log := clogger.FromCtx(ctx).WithTags(map[string]interface{}{
+		"method": method,
+		"stack":  string(debug.Stack()),
+	})
+
+	if err, ok := r.(error); ok {
+		log.Error("Recovered from a panic while handling grpc call", err)
+	} else {
+		log.WithTags(map[string]interface{}{
+			"error": r,
+		}).Error("Recovered from a panic while handling grpc call", nil)
+	}
+
+	return status.Error(codes.Internal, "internal error")
+}
+
+func bearerToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	const prefix = "bearer "
+
+	auth := firstMetadata(md, "authorization")
+	if len(auth) <= len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return ""
+	}
+
+	return strings.TrimSpace(auth[len(prefix):])
+}
+
+func firstMetadata(md metadata.MD, key string) string {
+	vals := md.Get(key)
+	if len(vals) == 0 {
+		return ""
+	}
+
+	return vals[0]
+}
+
+// traceIDFromHeader parses the trace id from a W3C traceparent header (version-traceid-parentid-flags)
+func traceIDFromHeader(traceparent string) string {
+	const traceIDLen = 32
+
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 || len(parts[1]) != traceIDLen { //nolint:gomnd
+		return ""
+	}
+
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return ""
+	}
+
+	return parts[1]
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// ctxServerStream is a grpc.ServerStream with a different context
+type ctxServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *ctxServerStream) Context() context.Context {
+	return s.ctx
+}
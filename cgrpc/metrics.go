@@ -0,0 +1,20 @@
+package cgrpc
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:gochecknoglobals
+		Name: "cgrpc_requests_total",
+		Help: "Number of gRPC calls handled by method and status code",
+	}, []string{"method", "code"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{ //nolint:gochecknoglobals
+		Name:    "cgrpc_request_duration_seconds",
+		Help:    "Duration of gRPC calls by method",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+func init() { //nolint:gochecknoinits
+	prometheus.MustRegister(requestsTotal, requestDuration)
+}
@@ -0,0 +1,166 @@
+package cgrpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+const (
+	listenRetries = 3
+	listenBackoff = time.Second
+)
+
+// Service registers its implementation of a gRPC service on the server. For a service generated by
+// protoc-gen-go-grpc, it calls the generated Register<Service>Server func:
+//
+//	func (s *UsersService) Register(r grpc.ServiceRegistrar) {
+//		pb.RegisterUsersServer(r, s)
+//	}
+type Service interface {
+	Register(r grpc.ServiceRegistrar)
+}
+
+// Interceptor intercepts the unary and streaming calls to the server. Either func can be nil.
+type Interceptor struct {
+	Unary  grpc.UnaryServerInterceptor
+	Stream grpc.StreamServerInterceptor
+}
+
+// NewServerParams holds the params needed to create a server
+type NewServerParams struct {
+	Services     []Service
+	Interceptors []Interceptor
+	Lifecycle    *clifecycle.Lifecycle
+	Config       Config
+	Logger       clogger.Logger
+}
+
+// NewServer creates a new Server with the services registered. The app's interceptors run after the built-in ones
+// (request context, recovery, logging, and metrics), in the order they are provided.
+func NewServer(p NewServerParams) *Server {
+	interceptors := append([]Interceptor{
+		RequestCtxInterceptor(p.Logger),
+		RecoveryInterceptor(),
+		LoggingInterceptor(p.Logger),
+		MetricsInterceptor(),
+	}, p.Interceptors...)
+
+	var (
+		unary  = make([]grpc.UnaryServerInterceptor, 0, len(interceptors))
+		stream = make([]grpc.StreamServerInterceptor, 0, len(interceptors))
+	)
+
+	for _, i := range interceptors {
+		if i.Unary != nil {
+			unary = append(unary, i.Unary)
+		}
+
+		if i.Stream != nil {
+			stream = append(stream, i.Stream)
+		}
+	}
+
+	internal := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+		grpc.MaxRecvMsgSize(p.Config.MaxRecvMsgSize),
+	)
+
+	for _, svc := range p.Services {
+		svc.Register(internal)
+	}
+
+	if p.Config.Reflection {
+		reflection.Register(internal)
+	}
+
+	return &Server{
+		internal: internal,
+		config:   p.Config,
+		logger:   p.Logger.With(clogger.Module("cgrpc")),
+		lc:       p.Lifecycle,
+	}
+}
+
+// Server is a gRPC server that is started by Run and gracefully stopped with the app's lifecycle. Like chttp.Server,
+// it implements copper.Runner so both can be started together (ex. app.Start(httpServer, grpcServer)).
+type Server struct {
+	internal *grpc.Server
+	config   Config
+	logger   clogger.Logger
+	lc       *clifecycle.Lifecycle
+}
+
+// Run starts the server on Config.Port
+func (s *Server) Run() error {
+	addr := ":" + strconv.FormatUint(uint64(s.config.Port), 10)
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return cerrors.New(err, "failed to listen", map[string]interface{}{
+			"addr": addr,
+		})
+	}
+
+	return s.Serve(l)
+}
+
+// Stage implements clifecycle.Staged. The server is part of the http stage so that it starts along with chttp.Server.
+func (s *Server) Stage() clifecycle.Stage {
+	return clifecycle.Stage{
+		Name:    clifecycle.StageHTTP,
+		After:   []string{clifecycle.StageMigrations, clifecycle.StageSeeds},
+		Retries: listenRetries,
+		Backoff: listenBackoff,
+	}
+}
+
+// Serve starts the server on the listener
+func (s *Server) Serve(l net.Listener) error {
+	s.lc.OnStop(func(ctx context.Context) error {
+		s.logger.Info("Shutting down grpc server..")
+
+		done := make(chan struct{})
+
+		go func() {
+			s.internal.GracefulStop()
+			close(done)
+		}()
+
+		// in-flight calls (including long-lived streams) are canceled if they do not finish in time
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			s.internal.Stop()
+			return cerrors.New(ctx.Err(), "grpc calls were still running when the server stopped", nil)
+		}
+	})
+
+	go func() {
+		s.logger.WithTags(map[string]interface{}{
+			"addr": l.Addr().String(),
+		}).Info("Starting grpc server..")
+
+		err := s.internal.Serve(l)
+		if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			s.logger.Error("Server did not close cleanly", err)
+		}
+	}()
+
+	return nil
+}
+
+// GRPCServer returns the underlying grpc.Server
+func (s *Server) GRPCServer() *grpc.Server {
+	return s.internal
+}
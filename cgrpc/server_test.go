@@ -0,0 +1,176 @@
+package cgrpc_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/cgrpc"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type healthService struct {
+	*health.Server
+}
+
+func (s healthService) Register(r grpc.ServiceRegistrar) {
+	grpc_health_v1.RegisterHealthServer(r, s)
+}
+
+type userCtxKey struct{}
+
+func startServer(t *testing.T, interceptors ...cgrpc.Interceptor) grpc_health_v1.HealthClient {
+	t.Helper()
+
+	lc := clifecycle.New()
+	t.Cleanup(func() { lc.Stop(clogger.NewNoop()) })
+
+	server := cgrpc.NewServer(cgrpc.NewServerParams{
+		Services:     []cgrpc.Service{healthService{health.NewServer()}},
+		Interceptors: interceptors,
+		Lifecycle:    lc,
+		Config:       cgrpc.Config{MaxRecvMsgSize: 1 << 20},
+		Logger:       clogger.NewNoop(),
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	assert.NoError(t, server.Serve(l))
+
+	conn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return grpc_health_v1.NewHealthClient(conn)
+}
+
+func TestServer_RequestID(t *testing.T) {
+	t.Parallel()
+
+	client := startServer(t)
+
+	var header metadata.MD
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), cgrpc.MetadataRequestID, "req-1")
+
+	resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{}, grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+	assert.Equal(t, []string{"req-1"}, header.Get(cgrpc.MetadataRequestID))
+}
+
+func TestServer_Recovery(t *testing.T) {
+	t.Parallel()
+
+	client := startServer(t, cgrpc.Interceptor{
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler) (interface{}, error) {
+			panic("boom")
+		},
+	})
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	t.Parallel()
+
+	var user string
+
+	client := startServer(t,
+		cgrpc.AuthInterceptor(func(ctx context.Context, token string) (context.Context, error) {
+			if token != "secret" {
+				return nil, errors.New("invalid token")
+			}
+
+			return context.WithValue(ctx, userCtxKey{}, "alice"), nil
+		}, "/grpc.health.v1.Health/List"),
+		cgrpc.Interceptor{
+			Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+				handler grpc.UnaryHandler) (interface{}, error) {
+				user, _ = ctx.Value(userCtxKey{}).(string)
+				return handler(ctx, req)
+			},
+		},
+	)
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer wrong")
+	_, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	_, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", user)
+
+	// public methods reach the handler without a token
+	_, err = client.List(context.Background(), &grpc_health_v1.HealthListRequest{})
+	assert.NotEqual(t, codes.Unauthenticated, status.Code(err))
+}
+
+type healthGateway struct{}
+
+func (healthGateway) RegisterGateway(_ context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+	client := grpc_health_v1.NewHealthClient(conn)
+
+	return mux.HandlePath(http.MethodGet, "/health", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+		defer cancel()
+
+		resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, _ = w.Write([]byte(resp.Status.String()))
+	})
+}
+
+func TestGateway(t *testing.T) {
+	t.Parallel()
+
+	lc := clifecycle.New()
+	t.Cleanup(func() { lc.Stop(clogger.NewNoop()) })
+
+	gateway, err := cgrpc.NewGateway(cgrpc.NewGatewayParams{
+		Services:  []cgrpc.GatewayService{healthGateway{}},
+		Lifecycle: lc,
+		Config:    cgrpc.Config{Port: 1, Gateway: cgrpc.ConfigGateway{Prefix: "/api"}},
+		Logger:    clogger.NewNoop(),
+	})
+	assert.NoError(t, err)
+
+	handler := chttp.NewHandler(chttp.NewHandlerParams{
+		Routers: []chttp.Router{gateway},
+		Logger:  clogger.NewNoop(),
+	})
+
+	// nothing listens on port 1 so the gateway responds with its error
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
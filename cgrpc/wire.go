@@ -0,0 +1,14 @@
+package cgrpc
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+
+	NewServer,
+	wire.Struct(new(NewServerParams), "*"),
+
+	NewGateway,
+	wire.Struct(new(NewGatewayParams), "*"),
+)
@@ -0,0 +1,4 @@
+// Package chealth provides liveness and readiness checks. Modules register checkers for the dependencies they need
+// (ex. csql registers one for the database) and the app serves them using Router so that load balancers and
+// orchestrators can tell whether the app is able to serve requests.
+package chealth
@@ -0,0 +1,156 @@
+package chealth
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultCheckTimeout is the time each checker has to complete before it is considered failed
+const DefaultCheckTimeout = 5 * time.Second
+
+// Statuses of a CheckResult
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// registered holds the checkers registered using Register
+var registered = struct { //nolint:gochecknoglobals
+	sync.Mutex
+	nextID   int
+	checkers map[int]namedChecker
+}{checkers: make(map[int]namedChecker)}
+
+type namedChecker struct {
+	name    string
+	checker Checker
+}
+
+// Checker checks whether a dependency of the app (ex. the database) is available. Check returns an error if it is
+// not.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is a func that implements the Checker interface
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Register registers a readiness checker under the given name. The app is reported as unready while any registered
+// checker fails. The returned func unregisters the checker and should be called when the dependency is closed (ex.
+// in a lifecycle stop func).
+func Register(name string, checker Checker) func() {
+	registered.Lock()
+	defer registered.Unlock()
+
+	id := registered.nextID
+	registered.nextID++
+
+	registered.checkers[id] = namedChecker{name: name, checker: checker}
+
+	return func() {
+		registered.Lock()
+		defer registered.Unlock()
+
+		delete(registered.checkers, id)
+	}
+}
+
+// Report is the result of running all of the registered checkers
+type Report struct {
+	Ready  bool          `json:"ready"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// CheckResult is the result of running a single checker
+type CheckResult struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Check runs all registered checkers concurrently, each with the given timeout, and returns a report that is ready if
+// all of them pass. If timeout is 0, DefaultCheckTimeout is used.
+func Check(ctx context.Context, timeout time.Duration) Report {
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+
+	registered.Lock()
+	checkers := make([]namedChecker, 0, len(registered.checkers))
+	for _, c := range registered.checkers {
+		checkers = append(checkers, c)
+	}
+	registered.Unlock()
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]CheckResult, len(checkers))
+	)
+
+	for i := range checkers {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			results[i] = runChecker(ctx, checkers[i], timeout)
+		}(i)
+	}
+
+	wg.Wait()
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	report := Report{Ready: true, Checks: results}
+
+	for _, r := range results {
+		if r.Status != StatusOK {
+			report.Ready = false
+		}
+	}
+
+	return report
+}
+
+func runChecker(ctx context.Context, c namedChecker, timeout time.Duration) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var (
+		start = time.Now()
+		errCh = make(chan error, 1)
+	)
+
+	go func() {
+		errCh <- c.checker.Check(ctx)
+	}()
+
+	var err error
+
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Name:       c.name,
+		Status:     StatusOK,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+
+	if err != nil {
+		result.Status = StatusError
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,78 @@
+package chealth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/chealth"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	unregisterOK := chealth.Register("ok", chealth.CheckerFunc(func(ctx context.Context) error {
+		return nil
+	}))
+	defer unregisterOK()
+
+	report := chealth.Check(context.Background(), time.Second)
+	assert.True(t, report.Ready)
+	assert.Equal(t, "ok", report.Checks[0].Name)
+	assert.Equal(t, chealth.StatusOK, report.Checks[0].Status)
+
+	unregisterFailing := chealth.Register("failing", chealth.CheckerFunc(func(ctx context.Context) error {
+		return errors.New("test-err")
+	}))
+
+	report = chealth.Check(context.Background(), time.Second)
+	assert.False(t, report.Ready)
+	assert.Equal(t, "failing", report.Checks[0].Name)
+	assert.Equal(t, "test-err", report.Checks[0].Error)
+
+	unregisterFailing()
+
+	assert.True(t, chealth.Check(context.Background(), time.Second).Ready)
+}
+
+func TestCheck_Timeout(t *testing.T) {
+	unregister := chealth.Register("slow", chealth.CheckerFunc(func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}))
+	defer unregister()
+
+	report := chealth.Check(context.Background(), 10*time.Millisecond)
+	assert.False(t, report.Ready)
+	assert.Equal(t, chealth.StatusError, report.Checks[0].Status)
+}
+
+func TestRouter_HandleReadiness(t *testing.T) {
+	var (
+		router = chealth.NewRouter(clogger.NewNoop())
+		resp   = httptest.NewRecorder()
+	)
+
+	unregister := chealth.Register("failing", chealth.CheckerFunc(func(ctx context.Context) error {
+		return errors.New("test-err")
+	}))
+
+	router.HandleReadiness(resp, httptest.NewRequest(http.MethodGet, chealth.ReadinessPath, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"ready":false`)
+
+	unregister()
+
+	resp = httptest.NewRecorder()
+
+	router.HandleReadiness(resp, httptest.NewRequest(http.MethodGet, chealth.ReadinessPath, nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp = httptest.NewRecorder()
+
+	router.HandleLiveness(resp, httptest.NewRequest(http.MethodGet, chealth.LivenessPath, nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
@@ -0,0 +1,78 @@
+package chealth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+)
+
+// Paths of the health check routes
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// NewRouter creates a new Router
+func NewRouter(logger clogger.Logger) *Router {
+	return &Router{
+		logger: logger.With(clogger.Module("chealth")),
+	}
+}
+
+// Router is a chttp.Router that serves the liveness (/healthz) and readiness (/readyz) checks. The liveness check
+// always succeeds while the app is running. The readiness check runs the registered checkers and responds with a 503
+// if any of them fail (see Register).
+type Router struct {
+	logger clogger.Logger
+}
+
+// Routes returns the health check routes
+func (ro *Router) Routes() []chttp.Route {
+	return []chttp.Route{
+		{
+			Path:    LivenessPath,
+			Methods: []string{http.MethodGet},
+			Handler: ro.HandleLiveness,
+		},
+		{
+			Path:    ReadinessPath,
+			Methods: []string{http.MethodGet},
+			Handler: ro.HandleReadiness,
+		},
+	}
+}
+
+// HandleLiveness responds with a 200
+func (ro *Router) HandleLiveness(w http.ResponseWriter, r *http.Request) {
+	ro.writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+}
+
+// HandleReadiness runs the registered checkers and responds with their report. The response status is 200 if the
+// app is ready and 503 if it is not.
+func (ro *Router) HandleReadiness(w http.ResponseWriter, r *http.Request) {
+	report := Check(r.Context(), DefaultCheckTimeout)
+
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+
+		ro.logger.WithTags(map[string]interface{}{
+			"checks": report.Checks,
+		}).Warn("App is not ready", nil)
+	}
+
+	ro.writeJSON(w, status, report)
+}
+
+func (ro *Router) writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		ro.logger.Error("Failed to write health check response", err)
+	}
+}
@@ -0,0 +1,8 @@
+package chealth
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	NewRouter,
+)
@@ -0,0 +1,142 @@
+package chttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+)
+
+// staticPrefix is the URL path that HTMLRouter serves the static dir at
+const staticPrefix = "/static/"
+
+// NewAssetsParams holds the params needed to create Assets
+type NewAssetsParams struct {
+	StaticDir StaticDir
+	Config    Config
+	Logger    clogger.Logger
+}
+
+// NewAssets creates Assets using the manifest in the static dir. If the static dir does not have a manifest, or
+// chttp.use_local_html is enabled, assets resolve to their unhashed paths.
+func NewAssets(p NewAssetsParams) (*Assets, error) {
+	a := Assets{
+		files:  make(map[string]string),
+		hashed: make(map[string]bool),
+		config: p.Config.Assets,
+	}
+
+	if p.Config.UseLocalHTML || p.StaticDir == nil || p.Config.Assets.Manifest == "" {
+		return &a, nil
+	}
+
+	manifestPath := path.Join("static", p.Config.Assets.Manifest)
+
+	f, err := p.StaticDir.Open(manifestPath)
+	if err != nil {
+		p.Logger.With(clogger.Module("chttp")).WithTags(map[string]interface{}{
+			"manifest": manifestPath,
+		}).Info("Asset manifest not found; serving unhashed assets")
+
+		return &a, nil
+	}
+	defer func() { _ = f.Close() }()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to read asset manifest", map[string]interface{}{
+			"manifest": manifestPath,
+		})
+	}
+
+	err = a.load(data)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to parse asset manifest", map[string]interface{}{
+			"manifest": manifestPath,
+		})
+	}
+
+	return &a, nil
+}
+
+// Assets resolves the names of static files to their content-hashed variants (ex. js/app.js to js/app.3f2a1b.js)
+// using the manifest generated by the app's asset build, so that browsers can cache them forever and still load new
+// versions after a deploy. Templates call it using the asset function:
+//
+//	<script src="{{ asset "js/app.js" }}"></script>
+//
+// The manifest (chttp.assets.manifest) maps the names of the assets to their hashed file names, both relative to
+// the static dir. Both flat manifests ({"js/app.js": "js/app.3f2a1b.js"}) and Vite manifests
+// ({"js/app.js": {"file": "js/app.3f2a1b.js"}}) are supported.
+// HTMLRouter serves the hashed files with far-future cache headers (see chttp.assets.max_age).
+type Assets struct {
+	files  map[string]string
+	hashed map[string]bool
+	config ConfigAssets
+}
+
+// Path returns the URL path of the static file with the given name. If the file is not in the manifest, its
+// unhashed path is returned.
+func (a *Assets) Path(name string) string {
+	name = strings.TrimPrefix(name, "/")
+
+	if a != nil {
+		if file, ok := a.files[name]; ok {
+			return staticPrefix + file
+		}
+	}
+
+	return staticPrefix + name
+}
+
+// isHashed returns true if the static file at the given path (relative to the static dir) is a hashed asset
+func (a *Assets) isHashed(file string) bool {
+	return a != nil && a.hashed[strings.TrimPrefix(file, "/")]
+}
+
+// setCacheHeaders lets browsers cache the static file at the given path forever if it is a hashed asset
+func (a *Assets) setCacheHeaders(w http.ResponseWriter, file string) {
+	if !a.isHashed(file) || a.config.MaxAge <= 0 {
+		return
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", int64(a.config.MaxAge.Seconds())))
+}
+
+func (a *Assets) load(data []byte) error {
+	var manifest map[string]json.RawMessage
+
+	err := json.Unmarshal(data, &manifest)
+	if err != nil {
+		return err
+	}
+
+	for name, raw := range manifest {
+		var entry struct {
+			File string `json:"file"`
+		}
+
+		err = json.Unmarshal(raw, &entry.File)
+		if err != nil {
+			err = json.Unmarshal(raw, &entry)
+		}
+
+		if err != nil || entry.File == "" {
+			return cerrors.New(err, "invalid manifest entry", map[string]interface{}{
+				"name": name,
+			})
+		}
+
+		file := strings.TrimPrefix(entry.File, "/")
+
+		a.files[strings.TrimPrefix(name, "/")] = file
+		a.hashed[file] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package chttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStaticDir(manifest string) fstest.MapFS {
+	return fstest.MapFS{
+		"static/manifest.json":      {Data: []byte(manifest)},
+		"static/js/app.3f2a1b.js":   {Data: []byte("console.log('app')")},
+		"static/css/app.9c8d7e.css": {Data: []byte("body {}")},
+		"static/robots.txt":         {Data: []byte("User-agent: *")},
+	}
+}
+
+func newTestAssetsConfig() chttp.Config {
+	return chttp.Config{
+		Assets: chttp.ConfigAssets{Manifest: "manifest.json", MaxAge: 365 * 24 * time.Hour},
+	}
+}
+
+func TestNewAssets(t *testing.T) {
+	t.Parallel()
+
+	assets, err := chttp.NewAssets(chttp.NewAssetsParams{
+		StaticDir: newTestStaticDir(`{
+			"js/app.js": "js/app.3f2a1b.js",
+			"/css/app.css": {"file": "/css/app.9c8d7e.css", "src": "css/app.css"}
+		}`),
+		Config: newTestAssetsConfig(),
+		Logger: clogger.NewNoop(),
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "/static/js/app.3f2a1b.js", assets.Path("js/app.js"))
+	assert.Equal(t, "/static/css/app.9c8d7e.css", assets.Path("/css/app.css"))
+	assert.Equal(t, "/static/robots.txt", assets.Path("robots.txt"))
+}
+
+func TestNewAssets_Fallback(t *testing.T) {
+	t.Parallel()
+
+	config := newTestAssetsConfig()
+	config.UseLocalHTML = true
+
+	// in dev, the manifest is not read since the assets are not built
+	dev, err := chttp.NewAssets(chttp.NewAssetsParams{
+		StaticDir: newTestStaticDir(`{"js/app.js": "js/app.3f2a1b.js"}`),
+		Config:    config,
+		Logger:    clogger.NewNoop(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "/static/js/app.js", dev.Path("js/app.js"))
+
+	noManifest, err := chttp.NewAssets(chttp.NewAssetsParams{
+		StaticDir: &chttp.EmptyFS{},
+		Config:    newTestAssetsConfig(),
+		Logger:    clogger.NewNoop(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "/static/js/app.js", noManifest.Path("js/app.js"))
+
+	var nilAssets *chttp.Assets
+	assert.Equal(t, "/static/js/app.js", nilAssets.Path("js/app.js"))
+}
+
+func TestNewAssets_InvalidManifest(t *testing.T) {
+	t.Parallel()
+
+	for _, manifest := range []string{`not json`, `{"js/app.js": 1}`, `{"js/app.js": {"src": "js/app.js"}}`} {
+		_, err := chttp.NewAssets(chttp.NewAssetsParams{
+			StaticDir: newTestStaticDir(manifest),
+			Config:    newTestAssetsConfig(),
+			Logger:    clogger.NewNoop(),
+		})
+		assert.Error(t, err, manifest)
+	}
+}
+
+func TestHTMLRouter_HashedAssets(t *testing.T) {
+	t.Parallel()
+
+	var (
+		staticDir   = newTestStaticDir(`{"js/app.js": "js/app.3f2a1b.js"}`)
+		assets, err = chttp.NewAssets(chttp.NewAssetsParams{
+			StaticDir: staticDir,
+			Config:    newTestAssetsConfig(),
+			Logger:    clogger.NewNoop(),
+		})
+	)
+	assert.NoError(t, err)
+
+	router, err := chttp.NewHTMLRouter(chttp.NewHTMLRouterParams{
+		StaticDir: staticDir,
+		Assets:    assets,
+		Config:    newTestAssetsConfig(),
+	})
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(chttp.NewHandler(chttp.NewHandlerParams{
+		Routers: []chttp.Router{router},
+		Logger:  clogger.NewNoop(),
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/static/js/app.3f2a1b.js")
+	if assert.NoError(t, err) {
+		_ = resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "public, max-age=31536000, immutable", resp.Header.Get("Cache-Control"))
+	}
+
+	resp, err = http.Get(server.URL + "/static/robots.txt")
+	if assert.NoError(t, err) {
+		_ = resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Cache-Control"))
+	}
+}
+
+func TestHTMLRenderer_Asset(t *testing.T) {
+	t.Parallel()
+
+	var (
+		htmlDir = fstest.MapFS{
+			"src/layouts/main.html": {Data: []byte(`<script src="{{ asset "js/app.js" }}"></script>`)},
+			"src/pages/index.html":  {Data: []byte(``)},
+		}
+		staticDir   = newTestStaticDir(`{"js/app.js": "js/app.3f2a1b.js"}`)
+		assets, err = chttp.NewAssets(chttp.NewAssetsParams{
+			StaticDir: staticDir,
+			Config:    newTestAssetsConfig(),
+			Logger:    clogger.NewNoop(),
+		})
+	)
+	assert.NoError(t, err)
+
+	renderer, err := chttp.NewHTMLRenderer(chttp.NewHTMLRendererParams{
+		HTMLDir:   htmlDir,
+		StaticDir: staticDir,
+		Assets:    assets,
+		Logger:    clogger.NewNoop(),
+	})
+	assert.NoError(t, err)
+
+	resp := httptest.NewRecorder()
+
+	chttp.NewReaderWriter(renderer, chttp.Config{}, clogger.NewNoop()).WriteHTML(resp,
+		httptest.NewRequest(http.MethodGet, "/", nil), chttp.WriteHTMLParams{PageTemplate: "index.html"})
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `<script src="/static/js/app.3f2a1b.js"></script>`, resp.Body.String())
+}
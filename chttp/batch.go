@@ -0,0 +1,309 @@
+package chttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+)
+
+// ctxBatchKey marks the context of a batch's sub-requests so that they cannot run nested batches
+const ctxBatchKey = ctxRequest("chttp/batch")
+
+// NewBatchRouterParams holds the params needed for NewBatchRouter
+type NewBatchRouterParams struct {
+	RW     *ReaderWriter
+	Config Config
+	Logger clogger.Logger
+}
+
+// NewBatchRouter creates a new BatchRouter
+func NewBatchRouter(p NewBatchRouterParams) *BatchRouter {
+	config := p.Config.Batch
+
+	if config.Path == "" {
+		config.Path = defaultBatchPath
+	}
+
+	if config.MaxRequests <= 0 {
+		config.MaxRequests = defaultBatchMaxRequests
+	}
+
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = defaultBatchMaxBodyBytes
+	}
+
+	return &BatchRouter{
+		rw:     p.RW,
+		config: config,
+		logger: p.Logger.With(clogger.Module("chttp")),
+	}
+}
+
+// BatchRouter is a Router that serves the batch endpoint at chttp.batch.path if chttp.batch.enabled is set. The
+// endpoint runs an array of sub-requests through the app's handler in order and responds with an array of their
+// responses, which saves round trips for clients on slow networks (ex. mobile apps). For example:
+//
+//	POST /api/batch
+//	[
+//		{"method": "GET", "path": "/api/me"},
+//		{"method": "POST", "path": "/api/posts", "body": {"title": "Hello"}}
+//	]
+//
+// responds with:
+//
+//	[
+//		{"status": 200, "headers": {"Content-Type": "application/json"}, "body": {"id": "u1"}},
+//		{"status": 201, "headers": {"Content-Type": "application/json"}, "body": {"id": "p1"}}
+//	]
+//
+// Sub-requests share the batch request's context and headers (ex. its Cookie and Authorization headers), so they
+// are authenticated as the batch request's user, and run through the same middlewares as regular requests. The
+// batch request's Idempotency-Key header is not shared since it identifies the batch and not its sub-requests. A
+// sub-request's body is sent as JSON unless its headers set another Content-Type. A sub-request that fails does not
+// stop the batch.
+//
+// Since sub-requests can use any method, the batch request must have an application/json Content-Type and, if it
+// has an Origin header, come from the same origin. Cross-site pages cannot send such a request without a CORS
+// preflight, so they cannot use a user's cookies to run sub-requests.
+//
+// The headers of a sub-request's response are only returned in its BatchResponse. A Set-Cookie header of a
+// sub-request is not set on the batch response, so clients that rely on it must not send that request in a batch.
+// BatchRouter must be one of the routers passed to NewHandler, which it uses to run the sub-requests.
+type BatchRouter struct {
+	rw      *ReaderWriter
+	config  ConfigBatch
+	logger  clogger.Logger
+	handler http.Handler
+}
+
+// BatchRequest is a sub-request of a batch
+type BatchRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// BatchResponse is the response to a sub-request of a batch. Its body is embedded as JSON if the response is JSON
+// and as a string otherwise.
+type BatchResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Routes implements Router
+func (ro *BatchRouter) Routes() []Route {
+	if !ro.config.Enabled {
+		return nil
+	}
+
+	return []Route{
+		{
+			Path:    ro.config.Path,
+			Methods: []string{http.MethodPost},
+			Handler: ro.HandleBatch,
+		},
+	}
+}
+
+// setHandler is called by NewHandler with the handler that runs the sub-requests
+func (ro *BatchRouter) setHandler(h http.Handler) {
+	ro.handler = h
+}
+
+// HandleBatch runs the sub-requests in the request's body and responds with their responses
+func (ro *BatchRouter) HandleBatch(w http.ResponseWriter, r *http.Request) {
+	var reqs []BatchRequest
+
+	if r.Context().Value(ctxBatchKey) != nil {
+		ro.rw.WriteJSON(w, WriteJSONParams{
+			StatusCode: http.StatusBadRequest,
+			Data:       cerrors.New(nil, "batches cannot be nested", nil),
+		})
+
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		ro.rw.WriteJSON(w, WriteJSONParams{
+			StatusCode: http.StatusUnsupportedMediaType,
+			Data:       cerrors.New(nil, "batch must have an application/json content type", nil),
+		})
+
+		return
+	}
+
+	if !sameOrigin(r) {
+		ro.rw.WriteJSON(w, WriteJSONParams{
+			StatusCode: http.StatusForbidden,
+			Data:       cerrors.New(nil, "cross-origin batches are not allowed", nil),
+		})
+
+		return
+	}
+
+	err = json.NewDecoder(http.MaxBytesReader(w, r.Body, ro.config.MaxBodyBytes)).Decode(&reqs)
+	if err != nil {
+		ro.rw.WriteJSON(w, WriteJSONParams{
+			StatusCode: http.StatusBadRequest,
+			Data:       cerrors.New(err, "invalid batch", nil),
+		})
+
+		return
+	}
+
+	if len(reqs) > ro.config.MaxRequests {
+		ro.rw.WriteJSON(w, WriteJSONParams{
+			StatusCode: http.StatusBadRequest,
+			Data: cerrors.New(nil, "too many requests in batch", map[string]interface{}{
+				"max": ro.config.MaxRequests,
+			}),
+		})
+
+		return
+	}
+
+	if ro.handler == nil {
+		ro.logger.Error("Failed to run batch", cerrors.New(nil, "batch router was not passed to chttp.NewHandler", nil))
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	resps := make([]BatchResponse, len(reqs))
+	for i := range reqs {
+		resps[i] = ro.run(r, reqs[i])
+	}
+
+	ro.rw.WriteJSON(w, WriteJSONParams{
+		StatusCode: http.StatusOK,
+		Data:       resps,
+	})
+}
+
+func (ro *BatchRouter) run(batchReq *http.Request, br BatchRequest) BatchResponse {
+	if !strings.HasPrefix(br.Path, "/") || strings.HasPrefix(br.Path, "//") {
+		return batchError(http.StatusBadRequest, "path must be an absolute path")
+	}
+
+	method := strings.ToUpper(br.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	ctx := context.WithValue(batchReq.Context(), ctxBatchKey, true)
+
+	req, err := http.NewRequestWithContext(ctx, method, br.Path, bytes.NewReader(br.Body))
+	if err != nil {
+		return batchError(http.StatusBadRequest, "invalid request")
+	}
+
+	if req.URL.Path == ro.config.Path {
+		return batchError(http.StatusBadRequest, "batches cannot be nested")
+	}
+
+	req.Header = batchReq.Header.Clone()
+	req.Header.Del("Content-Length")
+	req.Header.Del("Content-Type")
+	req.Header.Del("Idempotency-Key")
+
+	if len(br.Body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	for k, v := range br.Headers {
+		req.Header.Set(k, v)
+	}
+
+	req.Host = batchReq.Host
+	req.RemoteAddr = batchReq.RemoteAddr
+	req.TLS = batchReq.TLS
+	req.Proto, req.ProtoMajor, req.ProtoMinor = batchReq.Proto, batchReq.ProtoMajor, batchReq.ProtoMinor
+
+	rec := &batchResponseWriter{header: make(http.Header)}
+	ro.handler.ServeHTTP(rec, req)
+
+	return rec.response()
+}
+
+// sameOrigin returns true if the request has no Origin header or its Origin matches the request's host
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || origin == "null" {
+		return origin == ""
+	}
+
+	u, err := url.Parse(origin)
+
+	return err == nil && u.Host == r.Host
+}
+
+func batchError(status int, msg string) BatchResponse {
+	body, _ := json.Marshal(map[string]string{"error": msg})
+
+	return BatchResponse{
+		Status:  status,
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Body:    body,
+	}
+}
+
+// batchResponseWriter records the response to a sub-request
+type batchResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *batchResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *batchResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+func (w *batchResponseWriter) response() BatchResponse {
+	resp := BatchResponse{
+		Status:  w.status,
+		Headers: make(map[string]string, len(w.header)),
+	}
+
+	if resp.Status == 0 {
+		resp.Status = http.StatusOK
+	}
+
+	for k := range w.header {
+		resp.Headers[k] = w.header.Get(k)
+	}
+
+	if w.body.Len() == 0 {
+		return resp
+	}
+
+	body := bytes.TrimSpace(w.body.Bytes())
+	if strings.Contains(w.header.Get("Content-Type"), "json") && json.Valid(body) {
+		resp.Body = body
+		return resp
+	}
+
+	resp.Body, _ = json.Marshal(w.body.String())
+
+	return resp
+}
@@ -0,0 +1,199 @@
+package chttp_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/chttp/chttptest"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBatchHandler(t *testing.T, config chttp.ConfigBatch) http.Handler {
+	t.Helper()
+
+	rw := chttptest.NewReaderWriter(t)
+
+	return chttp.NewHandler(chttp.NewHandlerParams{
+		Routers: []chttp.Router{
+			chttptest.NewRouter([]chttp.Route{
+				{
+					Path:    "/api/me",
+					Methods: []string{http.MethodGet},
+					Handler: func(w http.ResponseWriter, r *http.Request) {
+						rw.WriteJSON(w, chttp.WriteJSONParams{
+							StatusCode: http.StatusOK,
+							Data:       map[string]string{"auth": r.Header.Get("Authorization")},
+						})
+					},
+				},
+				{
+					Path:    "/api/posts",
+					Methods: []string{http.MethodPost},
+					Handler: func(w http.ResponseWriter, r *http.Request) {
+						body, _ := io.ReadAll(r.Body)
+
+						w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+						w.WriteHeader(http.StatusCreated)
+						_, _ = w.Write(body)
+					},
+				},
+				{
+					Path:    "/api/key",
+					Methods: []string{http.MethodGet},
+					Handler: func(w http.ResponseWriter, r *http.Request) {
+						_, _ = w.Write([]byte(r.Header.Get("Idempotency-Key")))
+					},
+				},
+				{
+					Path:    "/text",
+					Methods: []string{http.MethodGet},
+					Handler: func(w http.ResponseWriter, r *http.Request) {
+						_, _ = w.Write([]byte("hello"))
+					},
+				},
+			}),
+			chttp.NewBatchRouter(chttp.NewBatchRouterParams{
+				RW:     rw,
+				Config: chttp.Config{Batch: config},
+				Logger: clogger.NewNoop(),
+			}),
+		},
+		Logger: clogger.NewNoop(),
+	})
+}
+
+func newBatchRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	return req
+}
+
+func TestBatchRouter_HandleBatch(t *testing.T) {
+	t.Parallel()
+
+	handler := newBatchHandler(t, chttp.ConfigBatch{Enabled: true})
+
+	req := newBatchRequest(`[
+		{"method": "GET", "path": "/api/me"},
+		{"method": "POST", "path": "/api/posts", "body": {"title": "Hello"}},
+		{"method": "GET", "path": "/text"},
+		{"method": "GET", "path": "/missing"},
+		{"method": "POST", "path": "/api/batch", "body": []},
+		{"method": "GET", "path": "/api/key"}
+	]`)
+	req.Header.Set("Authorization", "Bearer test-token")
+	req.Header.Set("Idempotency-Key", "k1")
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var resps []chttp.BatchResponse
+
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&resps))
+	assert.Len(t, resps, 6)
+
+	assert.Equal(t, http.StatusOK, resps[0].Status)
+	assert.JSONEq(t, `{"auth": "Bearer test-token"}`, string(resps[0].Body))
+
+	assert.Equal(t, http.StatusCreated, resps[1].Status)
+	assert.Equal(t, "application/json", resps[1].Headers["Content-Type"])
+	assert.JSONEq(t, `{"title": "Hello"}`, string(resps[1].Body))
+
+	assert.Equal(t, http.StatusOK, resps[2].Status)
+	assert.Equal(t, `"hello"`, string(resps[2].Body))
+
+	assert.Equal(t, http.StatusNotFound, resps[3].Status)
+	assert.Equal(t, http.StatusBadRequest, resps[4].Status)
+
+	// the batch's idempotency key is not sent with its sub-requests
+	assert.Equal(t, http.StatusOK, resps[5].Status)
+	assert.Empty(t, resps[5].Body)
+}
+
+func TestBatchRouter_HandleBatch_CrossSite(t *testing.T) {
+	t.Parallel()
+
+	handler := newBatchHandler(t, chttp.ConfigBatch{Enabled: true})
+
+	// a cross-site form can post text/plain without a preflight
+	req := httptest.NewRequest(http.MethodPost, "/api/batch", strings.NewReader(`[{"path": "/api/me"}]`))
+	req.Header.Set("Content-Type", "text/plain")
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, resp.Code)
+
+	req = newBatchRequest(`[{"path": "/api/me"}]`)
+	req.Header.Set("Origin", "https://evil.example.com")
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	req = newBatchRequest(`[{"path": "/api/me"}]`)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Origin", "http://example.com")
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestBatchRouter_HandleBatch_Nested(t *testing.T) {
+	t.Parallel()
+
+	handler := newBatchHandler(t, chttp.ConfigBatch{Enabled: true})
+
+	// the encoded path is decoded by the mux, so it must be rejected even though it does not match the batch path
+	for _, path := range []string{"/api/%62atch", "/api/batch?x=1"} {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, newBatchRequest(`[
+			{"method": "POST", "path": "`+path+`", "body": [{"method": "GET", "path": "/api/me"}]}
+		]`))
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var resps []chttp.BatchResponse
+
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &resps))
+
+		if assert.Len(t, resps, 1, path) {
+			assert.Equal(t, http.StatusBadRequest, resps[0].Status, path)
+			assert.JSONEq(t, `{"error": "batches cannot be nested"}`, string(resps[0].Body), path)
+		}
+	}
+}
+
+func TestBatchRouter_HandleBatch_TooManyRequests(t *testing.T) {
+	t.Parallel()
+
+	handler := newBatchHandler(t, chttp.ConfigBatch{Enabled: true, MaxRequests: 1})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, newBatchRequest(`[
+		{"path": "/text"},
+		{"path": "/text"}
+	]`))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, newBatchRequest(`{}`))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestBatchRouter_Disabled(t *testing.T) {
+	t.Parallel()
+
+	handler := newBatchHandler(t, chttp.ConfigBatch{})
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, newBatchRequest(`[]`))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
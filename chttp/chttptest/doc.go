@@ -1,2 +1,3 @@
-// Package chttptest provides utility functions that are useful when testing chttp
+// Package chttptest provides utility functions that are useful when testing chttp, and a Server that runs an app's
+// HTTP handler for end-to-end tests
 package chttptest
@@ -0,0 +1,426 @@
+package chttptest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gocopper/copper"
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/csql/csqltest"
+	"github.com/stretchr/testify/assert"
+)
+
+// userHeader holds the token of the user set using Client.AsUser. It is removed before the request is handled.
+const userHeader = "X-Chttptest-User"
+
+// ServerOptions configures NewServer
+type ServerOptions struct {
+	// Config is the app's config in TOML format (ex. the contents of config/test.toml)
+	Config string
+
+	// DB creates a test database using csqltest and overrides the app's csql config to use it. If it is nil, the app
+	// uses the database in Config, if any.
+	DB *csqltest.Options
+
+	// Handler creates the app's HTTP handler. It is usually a wire injector in the test package that is built with
+	// the modules under test (ex. func InitTestHandler(app *copper.App) (http.Handler, error)).
+	Handler func(app *copper.App) (http.Handler, error)
+
+	// CtxWithUser returns a context that is authenticated as user (ex. the auth module's func that sets the user in
+	// the request context). It is used by the requests made with Client.AsUser.
+	CtxWithUser func(ctx context.Context, user interface{}) context.Context
+}
+
+// Server is a running app that end-to-end tests make requests to. See NewServer.
+type Server struct {
+	URL string
+	App *copper.App
+
+	// DB is the test database (nil if ServerOptions.DB is not set)
+	DB *csqltest.DB
+
+	t           testing.TB
+	ctxWithUser func(ctx context.Context, user interface{}) context.Context
+
+	mu    sync.Mutex
+	users map[string]interface{}
+}
+
+// NewServer boots the app's HTTP handler against the test config and database, and starts a test HTTP server for it.
+// The server is closed and the app's lifecycle is stopped when the test ends. For example:
+//
+//	server := chttptest.NewServer(t, chttptest.ServerOptions{
+//		Config:  `[cmailer]
+//	provider = "log"`,
+//		DB:      &csqltest.Options{Migrations: migrations.Migrations},
+//		Handler: InitTestHandler,
+//		CtxWithUser: func(ctx context.Context, user interface{}) context.Context {
+//			return auth.CtxWithUser(ctx, user.(auth.User))
+//		},
+//	})
+//
+//	server.Client().AsUser(user).Get("/api/posts").AssertStatus(http.StatusOK)
+func NewServer(t testing.TB, opts ServerOptions) *Server {
+	t.Helper()
+
+	s := &Server{
+		t:           t,
+		ctxWithUser: opts.CtxWithUser,
+		users:       make(map[string]interface{}),
+	}
+
+	var overrides []string
+
+	if opts.DB != nil {
+		s.DB = csqltest.NewDB(t, *opts.DB)
+
+		overrides = append(overrides,
+			"csql.dialect="+quote(s.DB.Config.Dialect),
+			"csql.dsn="+quote(s.DB.Config.DSN),
+		)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "test.toml")
+
+	err := os.WriteFile(configPath, []byte(opts.Config), 0o600)
+	if err != nil {
+		t.Fatalf("chttptest: failed to write test config: %v", err)
+	}
+
+	loader, err := cconfig.New(cconfig.Path(configPath), cconfig.Overrides(strings.Join(overrides, ";")))
+	if err != nil {
+		t.Fatalf("chttptest: failed to load test config: %v", err)
+	}
+
+	logger := clogger.NewNoop()
+
+	s.App = &copper.App{
+		Lifecycle: clifecycle.New(),
+		Config:    loader,
+		Logger:    logger,
+	}
+
+	t.Cleanup(func() {
+		s.App.Lifecycle.Stop(logger)
+	})
+
+	handler, err := opts.Handler(s.App)
+	if err != nil {
+		t.Fatalf("chttptest: failed to create app handler: %v", err)
+	}
+
+	server := httptest.NewServer(s.withUser(handler))
+	t.Cleanup(server.Close)
+
+	s.URL = server.URL
+
+	return s
+}
+
+// Client returns a new client with its own cookie jar so that cookies set by the app (ex. a session) are sent with
+// the client's next requests
+func (s *Server) Client() *Client {
+	jar, _ := cookiejar.New(nil)
+
+	return &Client{
+		t:      s.t,
+		server: s,
+		http:   &http.Client{Jar: jar},
+	}
+}
+
+// withUser authenticates the requests made using Client.AsUser
+func (s *Server) withUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(userHeader)
+		if token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		r.Header.Del(userHeader)
+
+		s.mu.Lock()
+		user, ok := s.users[token]
+		s.mu.Unlock()
+
+		if !ok {
+			http.Error(w, "chttptest: unknown user", http.StatusInternalServerError)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(s.ctxWithUser(r.Context(), user)))
+	})
+}
+
+func (s *Server) addUser(user interface{}) string {
+	b := make([]byte, 8) //nolint:gomnd
+	_, _ = rand.Read(b)
+
+	token := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	s.users[token] = user
+	s.mu.Unlock()
+
+	return token
+}
+
+// Client makes requests to a Server. Request errors fail the test.
+type Client struct {
+	t      testing.TB
+	server *Server
+	http   *http.Client
+	token  string
+	header http.Header
+}
+
+// AsUser returns a client that makes requests authenticated as user (see ServerOptions.CtxWithUser). It shares the
+// client's cookie jar.
+func (c *Client) AsUser(user interface{}) *Client {
+	c.t.Helper()
+
+	if c.server.ctxWithUser == nil {
+		c.t.Fatal("chttptest: ServerOptions.CtxWithUser must be set to use AsUser")
+	}
+
+	clone := *c
+	clone.token = c.server.addUser(user)
+
+	return &clone
+}
+
+// WithHeader returns a client that sets the header on each request. It shares the client's cookie jar.
+func (c *Client) WithHeader(key, val string) *Client {
+	clone := *c
+	clone.header = c.header.Clone()
+
+	if clone.header == nil {
+		clone.header = make(http.Header)
+	}
+
+	clone.header.Set(key, val)
+
+	return &clone
+}
+
+// Get makes a GET request to path
+func (c *Client) Get(path string) *Response {
+	c.t.Helper()
+
+	return c.Do(c.NewRequest(http.MethodGet, path, nil))
+}
+
+// Post makes a POST request to path with body encoded as JSON
+func (c *Client) Post(path string, body interface{}) *Response {
+	c.t.Helper()
+
+	return c.Do(c.newJSONRequest(http.MethodPost, path, body))
+}
+
+// Put makes a PUT request to path with body encoded as JSON
+func (c *Client) Put(path string, body interface{}) *Response {
+	c.t.Helper()
+
+	return c.Do(c.newJSONRequest(http.MethodPut, path, body))
+}
+
+// Patch makes a PATCH request to path with body encoded as JSON
+func (c *Client) Patch(path string, body interface{}) *Response {
+	c.t.Helper()
+
+	return c.Do(c.newJSONRequest(http.MethodPatch, path, body))
+}
+
+// Delete makes a DELETE request to path
+func (c *Client) Delete(path string) *Response {
+	c.t.Helper()
+
+	return c.Do(c.NewRequest(http.MethodDelete, path, nil))
+}
+
+// PostForm makes a POST request to path with the form values (ex. to submit an HTML login form)
+func (c *Client) PostForm(path string, form url.Values) *Response {
+	c.t.Helper()
+
+	req := c.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return c.Do(req)
+}
+
+// NewRequest creates a request to the path on the server
+func (c *Client) NewRequest(method, path string, body io.Reader) *http.Request {
+	c.t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), method, c.server.URL+path, body)
+	if err != nil {
+		c.t.Fatalf("chttptest: failed to create request: %v", err)
+	}
+
+	return req
+}
+
+// Do makes the request and reads its response
+func (c *Client) Do(req *http.Request) *Response {
+	c.t.Helper()
+
+	for key, vals := range c.header {
+		req.Header[key] = vals
+	}
+
+	if c.token != "" {
+		req.Header.Set(userHeader, c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		c.t.Fatalf("chttptest: request failed: %v", err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("chttptest: failed to read response body: %v", err)
+	}
+
+	return &Response{
+		Response: resp,
+		Body:     body,
+		t:        c.t,
+	}
+}
+
+func (c *Client) newJSONRequest(method, path string, body interface{}) *http.Request {
+	c.t.Helper()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		c.t.Fatalf("chttptest: failed to encode request body: %v", err)
+	}
+
+	req := c.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", ContentTypeApplicationJSON)
+
+	return req
+}
+
+// Response is a response from the server with its body read
+type Response struct {
+	*http.Response
+
+	Body []byte
+
+	t testing.TB
+}
+
+// AssertStatus asserts that the response has the status code
+func (r *Response) AssertStatus(code int) *Response {
+	r.t.Helper()
+
+	assert.Equal(r.t, code, r.StatusCode, "unexpected status code; body: %s", r.Body)
+
+	return r
+}
+
+// AssertJSON asserts that the response body is JSON equal to expected. expected may be a JSON string or a value
+// that is encoded to JSON (ex. a map or a struct).
+func (r *Response) AssertJSON(expected interface{}) *Response {
+	r.t.Helper()
+
+	expectedJSON, ok := expected.(string)
+	if !ok {
+		data, err := json.Marshal(expected)
+		if !assert.NoError(r.t, err) {
+			return r
+		}
+
+		expectedJSON = string(data)
+	}
+
+	assert.JSONEq(r.t, expectedJSON, string(r.Body))
+
+	return r
+}
+
+// AssertJSONPath asserts that the value at the dot separated path in the JSON body (ex. "items.0.name") is JSON equal
+// to expected
+func (r *Response) AssertJSONPath(path string, expected interface{}) *Response {
+	r.t.Helper()
+
+	var body interface{}
+	if !assert.NoError(r.t, json.Unmarshal(r.Body, &body), "body is not JSON: %s", r.Body) {
+		return r
+	}
+
+	actual, ok := jsonPath(body, path)
+	if !assert.True(r.t, ok, "path %q does not exist in body: %s", path, r.Body) {
+		return r
+	}
+
+	expectedJSON, _ := json.Marshal(expected)
+	actualJSON, _ := json.Marshal(actual)
+
+	assert.JSONEq(r.t, string(expectedJSON), string(actualJSON), "unexpected value at %q", path)
+
+	return r
+}
+
+// DecodeJSON decodes the JSON body into dest
+func (r *Response) DecodeJSON(dest interface{}) {
+	r.t.Helper()
+
+	assert.NoError(r.t, json.Unmarshal(r.Body, dest), "body is not JSON: %s", r.Body)
+}
+
+func jsonPath(val interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return val, true
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch v := val.(type) {
+		case map[string]interface{}:
+			next, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+
+			val = next
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+
+			val = v[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return val, true
+}
+
+func quote(s string) string {
+	data, _ := json.Marshal(s)
+
+	return string(data)
+}
@@ -0,0 +1,154 @@
+package chttptest_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gocopper/copper"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/chttp/chttptest"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/csql"
+	"github.com/gocopper/copper/csql/csqltest"
+	"github.com/stretchr/testify/assert"
+)
+
+type ctxKey string
+
+type testUser struct {
+	Name string
+}
+
+type greetingConfig struct {
+	Message string `toml:"message"`
+}
+
+func newTestHandler(app *copper.App) (http.Handler, error) {
+	var greeting greetingConfig
+
+	err := app.Config.Load("greeting", &greeting)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := csql.LoadConfig(app.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := csql.NewDBConnection(app.Lifecycle, config, app.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	querier := csql.NewQuerier(db, config)
+	rw := chttp.NewReaderWriter(nil, chttp.Config{}, clogger.NewNoop())
+
+	return chttp.NewHandler(chttp.NewHandlerParams{
+		Routers: []chttp.Router{chttptest.NewRouter([]chttp.Route{
+			{
+				Path:    "/greeting",
+				Methods: []string{http.MethodGet},
+				Handler: func(w http.ResponseWriter, r *http.Request) {
+					user, _ := r.Context().Value(ctxKey("user")).(testUser)
+
+					var items []string
+
+					err := querier.Select(r.Context(), &items, "select name from items order by name")
+					if err != nil {
+						rw.WriteJSON(w, chttp.WriteJSONParams{StatusCode: http.StatusInternalServerError, Data: err})
+						return
+					}
+
+					rw.WriteJSON(w, chttp.WriteJSONParams{
+						Data: map[string]interface{}{
+							"message": greeting.Message,
+							"user":    user.Name,
+							"items":   items,
+						},
+					})
+				},
+			},
+			{
+				Path:    "/login",
+				Methods: []string{http.MethodPost},
+				Handler: func(w http.ResponseWriter, r *http.Request) {
+					http.SetCookie(w, &http.Cookie{Name: "session", Value: r.FormValue("name"), Path: "/"})
+					w.WriteHeader(http.StatusNoContent)
+				},
+			},
+			{
+				Path:    "/session",
+				Methods: []string{http.MethodGet},
+				Handler: func(w http.ResponseWriter, r *http.Request) {
+					cookie, err := r.Cookie("session")
+					if err != nil {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+
+					rw.WriteJSON(w, chttp.WriteJSONParams{Data: map[string]string{"name": cookie.Value}})
+				},
+			},
+		})},
+		GlobalMiddlewares: []chttp.Middleware{csql.NewTxMiddleware(db, config, app.Logger)},
+		Logger:            clogger.NewNoop(),
+	}), nil
+}
+
+func newTestServer(t *testing.T) *chttptest.Server {
+	t.Helper()
+
+	server := chttptest.NewServer(t, chttptest.ServerOptions{
+		Config:  "[greeting]\nmessage = \"hello\"\n",
+		DB:      &csqltest.Options{},
+		Handler: newTestHandler,
+		CtxWithUser: func(ctx context.Context, user interface{}) context.Context {
+			return context.WithValue(ctx, ctxKey("user"), user)
+		},
+	})
+
+	_, err := server.DB.DB.Exec("create table items (name text); insert into items (name) values ('a'), ('b')")
+	assert.NoError(t, err)
+
+	return server
+}
+
+func TestServer_Client(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+
+	server.Client().
+		Get("/greeting").
+		AssertStatus(http.StatusOK).
+		AssertJSON(`{"message": "hello", "user": "", "items": ["a", "b"]}`)
+
+	server.Client().
+		AsUser(testUser{Name: "alice"}).
+		Get("/greeting").
+		AssertStatus(http.StatusOK).
+		AssertJSONPath("user", "alice").
+		AssertJSONPath("items.1", "b")
+}
+
+func TestServer_Client_Cookies(t *testing.T) {
+	t.Parallel()
+
+	var (
+		server = newTestServer(t)
+		client = server.Client()
+	)
+
+	client.Get("/session").AssertStatus(http.StatusUnauthorized)
+	client.PostForm("/login", url.Values{"name": {"bob"}}).AssertStatus(http.StatusNoContent)
+
+	var session map[string]string
+
+	client.Get("/session").AssertStatus(http.StatusOK).DecodeJSON(&session)
+	assert.Equal(t, "bob", session["name"])
+
+	server.Client().Get("/session").AssertStatus(http.StatusUnauthorized)
+}
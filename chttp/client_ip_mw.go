@@ -0,0 +1,244 @@
+package chttp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+)
+
+const (
+	ctxClientIPKey = ctxRequest("chttp/client-ip")
+	ctxGeoKey      = ctxRequest("chttp/geo")
+)
+
+// ClientIP returns the IP of the client that sent the current request as resolved by ClientIPMiddleware, or an
+// empty string if the middleware did not run
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(ctxClientIPKey).(string)
+	return ip
+}
+
+// NewClientIPMiddlewareParams holds the params needed for NewClientIPMiddleware
+type NewClientIPMiddlewareParams struct {
+	Config Config
+	Logger clogger.Logger
+}
+
+// NewClientIPMiddleware creates a new ClientIPMiddleware. It returns an error if one of
+// chttp.client_ip.trusted_proxies is not an IP or a CIDR range.
+func NewClientIPMiddleware(p NewClientIPMiddlewareParams) (*ClientIPMiddleware, error) {
+	var (
+		config  = p.Config.ClientIP
+		headers = make([]string, len(config.ProxyHeaders))
+		trusted = make([]*net.IPNet, len(config.TrustedProxies))
+	)
+
+	for i, h := range config.ProxyHeaders {
+		headers[i] = http.CanonicalHeaderKey(h)
+	}
+
+	for i, proxy := range config.TrustedProxies {
+		ipNet, err := parseIPNet(proxy)
+		if err != nil {
+			return nil, cerrors.New(err, "invalid trusted proxy", map[string]interface{}{
+				"proxy": proxy,
+			})
+		}
+
+		trusted[i] = ipNet
+	}
+
+	if len(headers) > 0 && len(trusted) == 0 {
+		p.Logger.Warn("chttp.client_ip.proxy_headers are only read from unix socket connections since "+
+			"chttp.client_ip.trusted_proxies is empty", nil)
+	}
+
+	return &ClientIPMiddleware{
+		headers: headers,
+		trusted: trusted,
+		logger:  p.Logger.With(clogger.Module("chttp")),
+	}, nil
+}
+
+// ClientIPMiddleware resolves the IP of the client that sent each request and adds it to the request context (see
+// ClientIP) and to its log fields. Should be added before the other global middlewares so that they can use the IP.
+//
+// If the request is sent by one of chttp.client_ip.trusted_proxies, the IP is read from the first of
+// chttp.client_ip.proxy_headers that holds one. Headers that hold a list of IPs (ex. X-Forwarded-For) are read from
+// right to left, skipping the trusted proxies, so that the IPs prepended by the client are ignored. Otherwise, or if
+// none of the headers hold an IP, the IP of the connection is used. Connections without an IP (ex. over a unix socket
+// from a local proxy) are trusted.
+type ClientIPMiddleware struct {
+	headers []string
+	trusted []*net.IPNet
+	logger  clogger.Logger
+}
+
+// Handle adds the client IP to the request context
+func (mw *ClientIPMiddleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := mw.resolve(r)
+
+		ctx := context.WithValue(r.Context(), ctxClientIPKey, ip)
+		ctx = clogger.CtxWithFields(ctx, map[string]interface{}{
+			"clientIP": ip,
+		})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (mw *ClientIPMiddleware) resolve(r *http.Request) string {
+	remoteIP := parseIP(r.RemoteAddr)
+
+	if remoteIP != nil && !mw.isTrusted(remoteIP) {
+		return remoteIP.String()
+	}
+
+	for _, h := range mw.headers {
+		var (
+			values = strings.Split(strings.Join(r.Header.Values(h), ","), ",")
+			found  net.IP
+		)
+
+		for i := len(values) - 1; i >= 0; i-- {
+			ip := parseIP(values[i])
+			if ip == nil {
+				break
+			}
+
+			found = ip
+
+			if !mw.isTrusted(ip) {
+				break
+			}
+		}
+
+		if found != nil {
+			return found.String()
+		}
+	}
+
+	if remoteIP != nil {
+		return remoteIP.String()
+	}
+
+	return ""
+}
+
+func (mw *ClientIPMiddleware) isTrusted(ip net.IP) bool {
+	for _, ipNet := range mw.trusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseIPNet parses a CIDR range or a single IP
+func parseIPNet(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, &net.ParseError{Type: "IP address", Text: s}
+		}
+
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(s)
+
+	return ipNet, err
+}
+
+// parseIP parses an IP with an optional port (ex. 203.0.113.7, 203.0.113.7:4312, or [2001:db8::1]:4312)
+func parseIP(s string) net.IP {
+	s = strings.TrimSpace(s)
+
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+
+	return net.ParseIP(strings.Trim(s, "[]"))
+}
+
+// Geo is the location of a client IP returned by a GeoResolver
+type Geo struct {
+	// CountryCode is the ISO 3166-1 alpha-2 code of the country (ex. US)
+	CountryCode string
+	Country     string
+	Region      string
+	City        string
+	TimeZone    string
+	Latitude    float64
+	Longitude   float64
+}
+
+// GeoResolver returns the location of an IP (ex. using a MaxMind GeoIP2 database). Resolvers that call a remote
+// service should cache their results since they are called for every request.
+type GeoResolver interface {
+	Resolve(ctx context.Context, ip net.IP) (Geo, error)
+}
+
+// GeoFromCtx returns the location of the client that sent the current request as resolved by GeoMiddleware. It
+// returns false if the location is unknown.
+func GeoFromCtx(ctx context.Context) (Geo, bool) {
+	geo, ok := ctx.Value(ctxGeoKey).(Geo)
+	return geo, ok
+}
+
+// NewGeoMiddlewareParams holds the params needed for NewGeoMiddleware
+type NewGeoMiddlewareParams struct {
+	Resolver GeoResolver
+	Logger   clogger.Logger
+}
+
+// NewGeoMiddleware creates a new GeoMiddleware
+func NewGeoMiddleware(p NewGeoMiddlewareParams) *GeoMiddleware {
+	return &GeoMiddleware{
+		resolver: p.Resolver,
+		logger:   p.Logger.With(clogger.Module("chttp")),
+	}
+}
+
+// GeoMiddleware resolves the location of the client IP using a GeoResolver and adds it to the request context (see
+// GeoFromCtx). It must be added after ClientIPMiddleware. Requests whose location cannot be resolved are handled
+// without one.
+type GeoMiddleware struct {
+	resolver GeoResolver
+	logger   clogger.Logger
+}
+
+// Handle adds the location of the client IP to the request context
+func (mw *GeoMiddleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := net.ParseIP(ClientIP(r.Context()))
+		if ip == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		geo, err := mw.resolver.Resolve(r.Context(), ip)
+		if err != nil {
+			mw.logger.WithTags(map[string]interface{}{
+				"ip": ip.String(),
+			}).Warn("Failed to resolve location of client ip", err)
+
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxGeoKey, geo)))
+	})
+}
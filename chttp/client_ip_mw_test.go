@@ -0,0 +1,158 @@
+package chttp_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveClientIP(t *testing.T, mw chttp.Middleware, remoteAddr string, header http.Header) string {
+	t.Helper()
+
+	var ip string
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	mw.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip = chttp.ClientIP(r.Context())
+
+		assert.Equal(t, ip, clogger.FieldsFromCtx(r.Context())["clientIP"])
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	return ip
+}
+
+func newClientIPMiddleware(t *testing.T, config chttp.ConfigClientIP) *chttp.ClientIPMiddleware {
+	t.Helper()
+
+	mw, err := chttp.NewClientIPMiddleware(chttp.NewClientIPMiddlewareParams{
+		Config: chttp.Config{ClientIP: config},
+		Logger: clogger.NewNoop(),
+	})
+	assert.NoError(t, err)
+
+	return mw
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		noProxy = newClientIPMiddleware(t, chttp.ConfigClientIP{})
+		proxied = newClientIPMiddleware(t, chttp.ConfigClientIP{
+			ProxyHeaders:   []string{"x-real-ip", "X-Forwarded-For"},
+			TrustedProxies: []string{"192.0.2.0/24", "2001:db8::1"},
+		})
+		xff = http.Header{"X-Forwarded-For": {"198.51.100.1, 203.0.113.7"}}
+	)
+
+	assert.Equal(t, "192.0.2.1", serveClientIP(t, noProxy, "192.0.2.1:4312", xff))
+	assert.Equal(t, "2001:db8::1", serveClientIP(t, noProxy, "[2001:db8::1]:4312", nil))
+	assert.Equal(t, "", serveClientIP(t, noProxy, "@", nil))
+
+	assert.Equal(t, "203.0.113.7", serveClientIP(t, proxied, "192.0.2.1:4312", xff))
+	assert.Equal(t, "203.0.113.7", serveClientIP(t, proxied, "[2001:db8::1]:4312", xff))
+	assert.Equal(t, "203.0.113.7", serveClientIP(t, proxied, "192.0.2.1:4312", http.Header{
+		"X-Forwarded-For": {"198.51.100.1", "203.0.113.7"},
+	}))
+	assert.Equal(t, "198.51.100.9", serveClientIP(t, proxied, "192.0.2.1:4312", http.Header{
+		"X-Real-Ip":       {"198.51.100.9"},
+		"X-Forwarded-For": {"203.0.113.7"},
+	}))
+	assert.Equal(t, "192.0.2.1", serveClientIP(t, proxied, "192.0.2.1:4312", http.Header{
+		"X-Forwarded-For": {"not-an-ip"},
+	}))
+}
+
+func TestClientIPMiddleware_TrustedProxies(t *testing.T) {
+	t.Parallel()
+
+	mw := newClientIPMiddleware(t, chttp.ConfigClientIP{
+		ProxyHeaders:   []string{"X-Forwarded-For"},
+		TrustedProxies: []string{"10.0.0.0/8"},
+	})
+
+	// proxy headers sent by untrusted clients are ignored
+	assert.Equal(t, "203.0.113.7", serveClientIP(t, mw, "203.0.113.7:4312", http.Header{
+		"X-Forwarded-For": {"198.51.100.1"},
+	}))
+
+	// trusted proxies in the chain are skipped, along with the IPs prepended by the client
+	assert.Equal(t, "203.0.113.7", serveClientIP(t, mw, "10.0.0.1:4312", http.Header{
+		"X-Forwarded-For": {"198.51.100.1, 203.0.113.7, 10.0.0.3, 10.0.0.2"},
+	}))
+
+	// the leftmost proxy is used if the chain only holds trusted proxies
+	assert.Equal(t, "10.0.0.3", serveClientIP(t, mw, "10.0.0.1:4312", http.Header{
+		"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"},
+	}))
+
+	// unix socket connections are trusted
+	assert.Equal(t, "203.0.113.7", serveClientIP(t, mw, "@", http.Header{
+		"X-Forwarded-For": {"203.0.113.7"},
+	}))
+
+	_, err := chttp.NewClientIPMiddleware(chttp.NewClientIPMiddlewareParams{
+		Config: chttp.Config{ClientIP: chttp.ConfigClientIP{TrustedProxies: []string{"10.0.0.0/33"}}},
+		Logger: clogger.NewNoop(),
+	})
+	assert.Error(t, err)
+}
+
+type testGeoResolver map[string]chttp.Geo
+
+func (r testGeoResolver) Resolve(ctx context.Context, ip net.IP) (chttp.Geo, error) {
+	geo, ok := r[ip.String()]
+	if !ok {
+		return chttp.Geo{}, errors.New("unknown ip")
+	}
+
+	return geo, nil
+}
+
+func TestGeoMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		clientIP = newClientIPMiddleware(t, chttp.ConfigClientIP{})
+		geo      = chttp.NewGeoMiddleware(chttp.NewGeoMiddlewareParams{
+			Resolver: testGeoResolver{"192.0.2.1": {CountryCode: "US", City: "Denver"}},
+			Logger:   clogger.NewNoop(),
+		})
+	)
+
+	serve := func(remoteAddr string) (chttp.Geo, bool) {
+		var (
+			got chttp.Geo
+			ok  bool
+		)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+
+		clientIP.Handle(geo.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok = chttp.GeoFromCtx(r.Context())
+		}))).ServeHTTP(httptest.NewRecorder(), req)
+
+		return got, ok
+	}
+
+	got, ok := serve("192.0.2.1:4312")
+	assert.True(t, ok)
+	assert.Equal(t, chttp.Geo{CountryCode: "US", City: "Denver"}, got)
+
+	_, ok = serve("192.0.2.2:4312")
+	assert.False(t, ok)
+}
@@ -1,13 +1,35 @@
 package chttp
 
 import (
+	"time"
+
 	"github.com/gocopper/copper/cconfig"
 	"github.com/gocopper/copper/cerrors"
 )
 
+const (
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultMaxBodyBytes      = 1 << 20
+
+	defaultBatchPath         = "/api/batch"
+	defaultBatchMaxRequests  = 20
+	defaultBatchMaxBodyBytes = 1 << 20
+
+	defaultAssetsManifest = "manifest.json"
+	defaultAssetsMaxAge   = 365 * 24 * time.Hour
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "chttp",
+		Description: "chttp configures the HTTP server",
+		Default:     defaultConfig(),
+	})
+}
+
 // LoadConfig loads Config from app's config
 func LoadConfig(appConfig cconfig.Loader) (Config, error) {
-	var config Config
+	config := defaultConfig()
 
 	err := appConfig.Load("chttp", &config)
 	if err != nil {
@@ -17,10 +39,138 @@ func LoadConfig(appConfig cconfig.Loader) (Config, error) {
 	return config, nil
 }
 
+func defaultConfig() Config {
+	return Config{
+		ReadHeaderTimeout: defaultReadHeaderTimeout,
+		MaxBodyBytes:      defaultMaxBodyBytes,
+		Batch: ConfigBatch{
+			Path:         defaultBatchPath,
+			MaxRequests:  defaultBatchMaxRequests,
+			MaxBodyBytes: defaultBatchMaxBodyBytes,
+		},
+		Assets: ConfigAssets{
+			Manifest: defaultAssetsManifest,
+			MaxAge:   defaultAssetsMaxAge,
+		},
+	}
+}
+
 // Config holds the params needed to configure Server
 type Config struct {
-	Port                    uint `default:"7501"`
-	UseLocalHTML            bool `toml:"use_local_html"`
-	RenderHTMLError         bool `toml:"render_html_error"`
-	EnableSinglePageRouting bool `toml:"enable_single_page_routing"`
+	Port uint `default:"7501" valid:"range(1|65535)" doc:"Port the HTTP server listens on"`
+
+	// Listen holds the addresses the server listens on. Each address is of the form scheme://value where scheme is
+	// one of tcp, unix, systemd, or fd (ex. "tcp://:7501", "unix:///var/run/app.sock", "systemd://", "fd://3"). If
+	// empty, the server listens on Port.
+	Listen []string `toml:"listen"`
+
+	// The timeouts are passed to http.Server. A timeout of 0 disables it. WriteTimeout should be left disabled if the
+	// app streams responses (ex. server-sent events).
+	ReadTimeout       time.Duration `toml:"read_timeout" doc:"Max duration of reading a request, including its body"`
+	ReadHeaderTimeout time.Duration `toml:"read_header_timeout" doc:"Max duration of reading a request's headers"`
+	WriteTimeout      time.Duration `toml:"write_timeout" doc:"Max duration of writing a response"`
+	IdleTimeout       time.Duration `toml:"idle_timeout" doc:"Max time a keep-alive connection waits for the next request"`
+	MaxHeaderBytes    int           `toml:"max_header_bytes" doc:"Max size of a request's headers (1MB if 0)"`
+
+	// MaxBodyBytes is the max size of a JSON body read using ReaderWriter.ReadJSON
+	MaxBodyBytes int64 `toml:"max_body_bytes" doc:"Max size of a JSON request body (1MB if 0)"`
+
+	DisableKeepAlives bool `toml:"disable_keep_alives" doc:"Close each connection after its response"`
+
+	// KeepAlivePeriod is the period of the TCP keep-alive probes sent on the connections of tcp listeners. If it is
+	// 0, Go's default is used. If it is negative, probes are not sent.
+	KeepAlivePeriod time.Duration `toml:"keep_alive_period" doc:"Period of TCP keep-alive probes (disabled if negative)"`
+
+	UseLocalHTML            bool `toml:"use_local_html" doc:"Read HTML templates from disk instead of the embedded files"`
+	RenderHTMLError         bool `toml:"render_html_error" doc:"Render errors in HTML responses (dev only)"`
+	EnableSinglePageRouting bool `toml:"enable_single_page_routing" doc:"Serve index.html for unknown paths"`
+
+	ClientIP        ConfigClientIP        `toml:"client_ip"`
+	SecurityHeaders ConfigSecurityHeaders `toml:"security_headers"`
+	Maintenance     ConfigMaintenance     `toml:"maintenance"`
+	Batch           ConfigBatch           `toml:"batch"`
+	Assets          ConfigAssets          `toml:"assets"`
+}
+
+// ConfigClientIP configures how ClientIPMiddleware resolves the IP of the client. For example:
+//
+//	[chttp.client_ip]
+//	proxy_headers = ["X-Forwarded-For", "X-Real-IP"]
+//	trusted_proxies = ["10.0.0.0/8"]
+type ConfigClientIP struct {
+	// ProxyHeaders are the headers that the app's reverse proxies set to the client's IP (ex. X-Forwarded-For,
+	// X-Real-IP), in order of preference. They are only read from requests sent by TrustedProxies.
+	ProxyHeaders []string `toml:"proxy_headers" doc:"Headers set by the reverse proxies to the client IP"`
+
+	// TrustedProxies are the IPs and CIDR ranges (ex. 10.0.0.0/8) of the app's reverse proxies. Requests sent by
+	// other IPs are attributed to the IP of the connection since their ProxyHeaders could be spoofed. To trust every
+	// IP, which is only safe if the app cannot be reached without going through a proxy, use 0.0.0.0/0 and ::/0.
+	TrustedProxies []string `toml:"trusted_proxies" doc:"IPs and CIDR ranges of the reverse proxies"`
+}
+
+// ConfigSecurityHeaders configures the headers set by SecurityHeadersMiddleware. Headers that are not set use a
+// default value, and headers set to "-" are not sent. In the Content-Security-Policy, {nonce} is replaced by the
+// request's nonce (see CSPNonce). For example:
+//
+//	[chttp.security_headers]
+//	content_security_policy = "default-src 'self'; script-src 'self' https://cdn.example.com {nonce}"
+//	strict_transport_security = "max-age=63072000; includeSubDomains; preload"
+//	frame_options = "-"
+type ConfigSecurityHeaders struct {
+	Disabled bool `toml:"disabled" doc:"Do not set the security headers"`
+
+	ContentSecurityPolicy   string `toml:"content_security_policy" doc:"Content-Security-Policy header"`
+	StrictTransportSecurity string `toml:"strict_transport_security" doc:"Strict-Transport-Security header"`
+	ContentTypeOptions      string `toml:"content_type_options" doc:"X-Content-Type-Options header"`
+	ReferrerPolicy          string `toml:"referrer_policy" doc:"Referrer-Policy header"`
+	FrameOptions            string `toml:"frame_options" doc:"X-Frame-Options header"`
+}
+
+// ConfigMaintenance configures maintenance mode (see MaintenanceMiddleware). For example:
+//
+//	[chttp.maintenance]
+//	enabled = true
+//	message = "We are upgrading the database and will be back in a few minutes."
+//	retry_after = "5m"
+type ConfigMaintenance struct {
+	Enabled bool `toml:"enabled" doc:"Respond with a 503 to the routes that are not exempt"`
+
+	// File enables maintenance mode while it exists (ex. touch /var/run/app/maintenance during a deploy)
+	File string `toml:"file" doc:"Maintenance mode is enabled while this file exists"`
+
+	// Exempt holds the path prefixes that are served during maintenance. If it is not set, the health checks
+	// (/healthz, /readyz) and the admin dashboard (/admin) are exempt.
+	Exempt []string `toml:"exempt" doc:"Path prefixes that are served during maintenance"`
+
+	Message    string        `toml:"message" doc:"Message shown during maintenance"`
+	RetryAfter time.Duration `toml:"retry_after" doc:"Sent in the Retry-After header during maintenance"`
+
+	// Page is the HTML page template (ex. maintenance.html) rendered for HTML requests with the message as its data.
+	// If it is not set, a built-in page is used.
+	Page string `toml:"page" doc:"HTML page template rendered for HTML requests"`
+}
+
+// ConfigBatch configures the batch endpoint (see BatchRouter). For example:
+//
+//	[chttp.batch]
+//	enabled = true
+//	max_requests = 10
+type ConfigBatch struct {
+	Enabled      bool   `toml:"enabled" doc:"Serve the batch endpoint"`
+	Path         string `toml:"path" doc:"Path of the batch endpoint"`
+	MaxRequests  int    `toml:"max_requests" doc:"Max number of sub-requests in a batch"`
+	MaxBodyBytes int64  `toml:"max_body_bytes" doc:"Max size of a batch request's body"`
+}
+
+// ConfigAssets configures how static files are resolved to their content-hashed variants (see Assets). For example:
+//
+//	[chttp.assets]
+//	manifest = ".vite/manifest.json"
+type ConfigAssets struct {
+	// Manifest is the path of the asset manifest relative to the static dir. It is read from the static dir at the
+	// same path that HTMLRouter serves it at (ex. /static/manifest.json).
+	Manifest string `toml:"manifest" doc:"Path of the asset manifest in the static dir"`
+
+	// MaxAge is how long browsers cache the hashed assets. If it is 0, no cache headers are set.
+	MaxAge time.Duration `toml:"max_age" doc:"How long browsers cache hashed assets"`
 }
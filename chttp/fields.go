@@ -0,0 +1,153 @@
+package chttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// FieldsParam is the query param that clients use to select the fields of a JSON response written with
+// WriteJSONParams.Request (ex. ?fields=id,name,author.name)
+const FieldsParam = "fields"
+
+// maxFields is the max number of fields that can be selected by a request
+const maxFields = 64
+
+// fieldSet holds the selected fields of a JSON object. A field whose value is nil is selected whole, otherwise only
+// the fields in its value are selected.
+type fieldSet map[string]fieldSet
+
+// requestFields parses the fields in the request's fields query param. Fields are separated by commas and nested
+// fields are selected using dots. If allowed is not empty, only the fields in it (and their nested fields) can be
+// selected. If the request does not select any fields, a nil fieldSet is returned.
+func requestFields(r *http.Request, allowed []string) (fieldSet, error) {
+	param := r.URL.Query().Get(FieldsParam)
+	if strings.TrimSpace(param) == "" {
+		return nil, nil
+	}
+
+	return parseFields(param, allowed)
+}
+
+// selectFields prunes data to the fields in the fieldSet. If data is an array, the fields are selected from each of
+// its items.
+func selectFields(data interface{}, fields fieldSet) (interface{}, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	err = dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	return fields.prune(v), nil
+}
+
+func parseFields(param string, allowed []string) (fieldSet, error) {
+	var (
+		fields = make(fieldSet)
+		n      = 0
+	)
+
+	for _, field := range strings.Split(param, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		n++
+		if n > maxFields {
+			return nil, fmt.Errorf("too many fields; max is %d", maxFields)
+		}
+
+		path := strings.Split(field, ".")
+		for i := range path {
+			if path[i] == "" {
+				return nil, fmt.Errorf("invalid field: %s", field)
+			}
+		}
+
+		if !isFieldAllowed(field, allowed) {
+			return nil, fmt.Errorf("unknown field: %s", field)
+		}
+
+		fields.add(path)
+	}
+
+	return fields, nil
+}
+
+func isFieldAllowed(field string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	for i := range allowed {
+		if field == allowed[i] || strings.HasPrefix(field, allowed[i]+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s fieldSet) add(path []string) {
+	sub, ok := s[path[0]]
+
+	if len(path) == 1 {
+		s[path[0]] = nil
+		return
+	}
+
+	// the field is already selected whole
+	if ok && sub == nil {
+		return
+	}
+
+	if !ok {
+		sub = make(fieldSet)
+		s[path[0]] = sub
+	}
+
+	sub.add(path[1:])
+}
+
+func (s fieldSet) prune(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(s))
+
+		for name, sub := range s {
+			fieldVal, ok := val[name]
+			if !ok {
+				continue
+			}
+
+			if sub == nil {
+				out[name] = fieldVal
+			} else {
+				out[name] = sub.prune(fieldVal)
+			}
+		}
+
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i := range val {
+			out[i] = s.prune(val[i])
+		}
+
+		return out
+	default:
+		return v
+	}
+}
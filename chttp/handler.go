@@ -50,6 +50,7 @@ func NewHandler(p NewHandlerParams) http.Handler {
 
 		handler = setRoutePathInCtxMiddleware(route.Path).Handle(handler)
 		handler = panicLoggerMiddleware(p.Logger).Handle(handler)
+		handler = setRequestCtxMiddleware(p.Logger).Handle(handler)
 
 		muxRoute := muxRouter.Handle(route.Path, handler)
 
@@ -60,6 +61,12 @@ func NewHandler(p NewHandlerParams) http.Handler {
 
 	muxHandler.Handle("/", muxRouter)
 
+	for _, router := range p.Routers {
+		if br, ok := router.(*BatchRouter); ok {
+			br.setHandler(muxHandler)
+		}
+	}
+
 	return muxHandler
 }
 
@@ -26,6 +26,7 @@ type (
 	HTMLRenderer struct {
 		htmlDir     HTMLDir
 		staticDir   StaticDir
+		assets      *Assets
 		renderFuncs []HTMLRenderFunc
 	}
 
@@ -43,6 +44,7 @@ type (
 	NewHTMLRendererParams struct {
 		HTMLDir     HTMLDir
 		StaticDir   StaticDir
+		Assets      *Assets
 		RenderFuncs []HTMLRenderFunc
 		Config      Config
 		Logger      clogger.Logger
@@ -55,6 +57,7 @@ func NewHTMLRenderer(p NewHTMLRendererParams) (*HTMLRenderer, error) {
 	hr := HTMLRenderer{
 		htmlDir:     p.HTMLDir,
 		staticDir:   p.StaticDir,
+		assets:      p.Assets,
 		renderFuncs: p.RenderFuncs,
 	}
 
@@ -72,7 +75,9 @@ func NewHTMLRenderer(p NewHTMLRendererParams) (*HTMLRenderer, error) {
 
 func (r *HTMLRenderer) funcMap(req *http.Request) template.FuncMap {
 	var funcMap = template.FuncMap{
-		"partial": r.partial(req),
+		"partial":  r.partial(req),
+		"cspNonce": func() string { return CSPNonce(req.Context()) },
+		"asset":    r.assets.Path,
 	}
 
 	for i := range r.renderFuncs {
@@ -10,12 +10,14 @@ type (
 	HTMLRouter struct {
 		rw        *ReaderWriter
 		staticDir StaticDir
+		assets    *Assets
 		config    Config
 	}
 
 	// NewHTMLRouterParams holds the params needed to instantiate a new Router
 	NewHTMLRouterParams struct {
 		StaticDir StaticDir
+		Assets    *Assets
 		RW        *ReaderWriter
 		Config    Config
 	}
@@ -26,6 +28,7 @@ func NewHTMLRouter(p NewHTMLRouterParams) (*HTMLRouter, error) {
 	return &HTMLRouter{
 		rw:        p.RW,
 		staticDir: p.StaticDir,
+		assets:    p.Assets,
 		config:    p.Config,
 	}, nil
 }
@@ -52,8 +55,10 @@ func (ro *HTMLRouter) Routes() []Route {
 }
 
 // HandleStaticFile serves the requested static file as found in the web/public directory. In non-dev env, the static
-// files are embedded in the binary.
+// files are embedded in the binary. Hashed assets (see Assets) are served with far-future cache headers.
 func (ro *HTMLRouter) HandleStaticFile(w http.ResponseWriter, r *http.Request) {
+	ro.assets.setCacheHeaders(w, URLParams(r)["path"])
+
 	if ro.config.UseLocalHTML {
 		http.ServeFile(w, r, path.Join("web", "public", URLParams(r)["path"]))
 		return
@@ -0,0 +1,158 @@
+package chttp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Listener address schemes supported by the chttp.listen config option.
+const (
+	ListenSchemeTCP     = "tcp"
+	ListenSchemeUnix    = "unix"
+	ListenSchemeSystemd = "systemd"
+	ListenSchemeFD      = "fd"
+)
+
+// systemdListenFDsStart is the first file descriptor passed by systemd socket activation.
+// See sd_listen_fds(3).
+const systemdListenFDsStart = 3
+
+// listenAddrs returns the list of addresses the server should listen on. If no addresses are configured, it falls
+// back to a single TCP listener on the configured port.
+func (c Config) listenAddrs() []string {
+	if len(c.Listen) > 0 {
+		return c.Listen
+	}
+
+	return []string{fmt.Sprintf("%s://:%d", ListenSchemeTCP, c.Port)}
+}
+
+// listen opens the listener(s) for the given address. The address is of the form scheme://value where scheme is one
+// of tcp, unix, systemd, or fd. For example:
+//
+//	tcp://:7501
+//	unix:///var/run/app.sock
+//	systemd://        (all sockets passed in by systemd)
+//	systemd://http    (sockets named 'http' via FileDescriptorName=)
+//	fd://3            (a socket inherited from the parent process)
+//
+// Inherited sockets allow zero-downtime restarts behind a process manager (ex. einhorn, or a supervisor that passes
+// its listening socket to each new process) since the socket stays open while the app restarts.
+// An address without a scheme is treated as a TCP address. The connections of TCP listeners use keepAlive as their
+// keep-alive period (see net.ListenConfig).
+func listen(addr string, keepAlive time.Duration) ([]net.Listener, error) {
+	scheme, value := ListenSchemeTCP, addr
+	if i := strings.Index(addr, "://"); i >= 0 {
+		scheme, value = addr[:i], addr[i+len("://"):]
+	}
+
+	switch scheme {
+	case ListenSchemeTCP:
+		l, err := (&net.ListenConfig{KeepAlive: keepAlive}).Listen(context.Background(), "tcp", value)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to listen on tcp address", map[string]interface{}{
+				"addr": value,
+			})
+		}
+
+		return []net.Listener{l}, nil
+	case ListenSchemeUnix:
+		// Remove a stale socket file left behind by a previous run that did not exit cleanly
+		if fi, err := os.Stat(value); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(value)
+		}
+
+		l, err := net.Listen("unix", value)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to listen on unix socket", map[string]interface{}{
+				"path": value,
+			})
+		}
+
+		return []net.Listener{l}, nil
+	case ListenSchemeSystemd:
+		return systemdListeners(value)
+	case ListenSchemeFD:
+		return fdListener(value)
+	default:
+		return nil, cerrors.New(nil, "unsupported listen scheme", map[string]interface{}{
+			"addr": addr,
+		})
+	}
+}
+
+// systemdListeners returns the listeners passed in by systemd socket activation. If name is not empty, only the
+// sockets with the matching name (as set by FileDescriptorName= in the .socket unit) are returned.
+func systemdListeners(name string) ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, cerrors.New(nil, "no sockets passed in by systemd for this process", nil)
+	}
+
+	nfds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || nfds <= 0 {
+		return nil, cerrors.New(err, "invalid LISTEN_FDS", map[string]interface{}{
+			"LISTEN_FDS": os.Getenv("LISTEN_FDS"),
+		})
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	listeners := make([]net.Listener, 0, nfds)
+
+	for i := 0; i < nfds; i++ {
+		if name != "" && (i >= len(names) || names[i] != name) {
+			continue
+		}
+
+		f := os.NewFile(uintptr(systemdListenFDsStart+i), fmt.Sprintf("systemd-fd-%d", i))
+
+		l, err := net.FileListener(f)
+		_ = f.Close()
+
+		if err != nil {
+			return nil, cerrors.New(err, "failed to create listener from systemd socket", map[string]interface{}{
+				"fd": systemdListenFDsStart + i,
+			})
+		}
+
+		listeners = append(listeners, l)
+	}
+
+	if len(listeners) == 0 {
+		return nil, cerrors.New(nil, "no systemd sockets matched the given name", map[string]interface{}{
+			"name": name,
+		})
+	}
+
+	return listeners, nil
+}
+
+// fdListener returns a listener for the socket with the file descriptor fd that was inherited from the parent process
+func fdListener(fd string) ([]net.Listener, error) {
+	n, err := strconv.Atoi(fd)
+	if err != nil || n < systemdListenFDsStart {
+		return nil, cerrors.New(err, "invalid listener file descriptor", map[string]interface{}{
+			"fd": fd,
+		})
+	}
+
+	f := os.NewFile(uintptr(n), "fd-"+fd)
+
+	l, err := net.FileListener(f)
+	_ = f.Close()
+
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create listener from inherited socket", map[string]interface{}{
+			"fd": n,
+		})
+	}
+
+	return []net.Listener{l}, nil
+}
@@ -0,0 +1,225 @@
+package chttp
+
+import (
+	"html/template"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/clogger"
+)
+
+const (
+	defaultMaintenanceMessage = "The app is down for maintenance. Please try again later."
+
+	// maintenanceFileCheckInterval is how long the existence of ConfigMaintenance.File is cached for
+	maintenanceFileCheckInterval = time.Second
+)
+
+var (
+	defaultMaintenanceExempt = []string{"/healthz", "/readyz", "/admin"} //nolint:gochecknoglobals
+
+	maintenanceTemplate = template.Must(template.New("maintenance").Parse(maintenanceHTML)) //nolint:gochecknoglobals
+)
+
+const maintenanceHTML = `<!doctype html>
+<html>
+<head><meta charset="utf-8"><title>Down for maintenance</title>
+<style>body{font-family:system-ui,sans-serif;display:flex;align-items:center;justify-content:center;min-height:90vh;
+color:#222}main{max-width:32rem;text-align:center}</style></head>
+<body><main><h1>Down for maintenance</h1><p>{{ .Message }}</p></main></body>
+</html>`
+
+// MaintenanceStatus describes which switches currently enable maintenance mode
+type MaintenanceStatus struct {
+	// Runtime is set while maintenance mode is enabled using MaintenanceMiddleware.Enable
+	Runtime bool
+
+	// Config is set while chttp.maintenance.enabled is set
+	Config bool
+
+	// File is set while chttp.maintenance.file exists
+	File bool
+}
+
+// Enabled returns true if any of the switches enables maintenance mode
+func (s MaintenanceStatus) Enabled() bool {
+	return s.Runtime || s.Config || s.File
+}
+
+// NewMaintenanceMiddlewareParams holds the params needed for NewMaintenanceMiddleware
+type NewMaintenanceMiddlewareParams struct {
+	Config       Config
+	ConfigLoader cconfig.Loader
+	RW           *ReaderWriter
+	Logger       clogger.Logger
+}
+
+// NewMaintenanceMiddleware creates a new MaintenanceMiddleware. If ConfigLoader is set, the middleware follows the
+// changes to chttp.maintenance when the config is reloaded.
+func NewMaintenanceMiddleware(p NewMaintenanceMiddlewareParams) (*MaintenanceMiddleware, error) {
+	mw := &MaintenanceMiddleware{
+		rw:     p.RW,
+		logger: p.Logger.With(clogger.Module("chttp")),
+		config: p.Config.Maintenance,
+		now:    time.Now,
+	}
+
+	if p.ConfigLoader != nil {
+		err := p.ConfigLoader.Watch("chttp", func(c Config) {
+			mw.mu.Lock()
+			defer mw.mu.Unlock()
+
+			mw.config = c.Maintenance
+			mw.fileCheckedAt = time.Time{}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return mw, nil
+}
+
+// MaintenanceMiddleware responds with a 503 to all requests except the exempt ones while maintenance mode is enabled.
+// Maintenance mode is enabled by any of:
+//   - the chttp.maintenance.enabled config, which can be changed without a restart by reloading the config
+//   - the file at chttp.maintenance.file, which is checked at most once per second
+//   - Enable, which can be called by an admin endpoint (see cadmin.MaintenancePanel)
+//
+// HTML requests get an HTML page and other requests get a JSON error.
+type MaintenanceMiddleware struct {
+	rw     *ReaderWriter
+	logger clogger.Logger
+	now    func() time.Time
+
+	mu            sync.Mutex
+	config        ConfigMaintenance
+	runtime       bool
+	fileExists    bool
+	fileCheckedAt time.Time
+}
+
+// Enable turns on maintenance mode until Disable is called
+func (mw *MaintenanceMiddleware) Enable() {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	mw.runtime = true
+}
+
+// Disable turns off maintenance mode if it was turned on using Enable. Maintenance mode stays on while the config or
+// the file enables it.
+func (mw *MaintenanceMiddleware) Disable() {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	mw.runtime = false
+}
+
+// Status returns which switches currently enable maintenance mode
+func (mw *MaintenanceMiddleware) Status() MaintenanceStatus {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	return mw.status()
+}
+
+// Handle responds with a 503 if maintenance mode is enabled and the request's path is not exempt
+func (mw *MaintenanceMiddleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mw.mu.Lock()
+		var (
+			enabled = mw.status().Enabled()
+			config  = mw.config
+		)
+		mw.mu.Unlock()
+
+		if !enabled || isMaintenanceExempt(config, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		mw.write(w, r, config)
+	})
+}
+
+// status returns the maintenance status. It must be called with the lock held.
+func (mw *MaintenanceMiddleware) status() MaintenanceStatus {
+	if mw.config.File != "" && mw.now().Sub(mw.fileCheckedAt) >= maintenanceFileCheckInterval {
+		_, err := os.Stat(mw.config.File)
+
+		mw.fileExists = err == nil
+		mw.fileCheckedAt = mw.now()
+	}
+
+	return MaintenanceStatus{
+		Runtime: mw.runtime,
+		Config:  mw.config.Enabled,
+		File:    mw.config.File != "" && mw.fileExists,
+	}
+}
+
+func (mw *MaintenanceMiddleware) write(w http.ResponseWriter, r *http.Request, config ConfigMaintenance) {
+	message := config.Message
+	if message == "" {
+		message = defaultMaintenanceMessage
+	}
+
+	if config.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(config.RetryAfter.Seconds())))
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		mw.rw.WriteJSON(w, WriteJSONParams{
+			StatusCode: http.StatusServiceUnavailable,
+			Data:       map[string]string{"error": message},
+		})
+
+		return
+	}
+
+	data := map[string]interface{}{"Message": message}
+
+	if config.Page != "" {
+		mw.rw.WriteHTML(w, r, WriteHTMLParams{
+			StatusCode:   http.StatusServiceUnavailable,
+			PageTemplate: config.Page,
+			Data:         data,
+		})
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusServiceUnavailable)
+
+	err := maintenanceTemplate.Execute(w, data)
+	if err != nil {
+		mw.logger.Error("Failed to render maintenance page", err)
+	}
+}
+
+// isMaintenanceExempt returns true if the path is one of the exempt paths or is under one of them
+func isMaintenanceExempt(config ConfigMaintenance, path string) bool {
+	exempt := config.Exempt
+	if exempt == nil {
+		exempt = defaultMaintenanceExempt
+	}
+
+	for _, prefix := range exempt {
+		prefix = strings.TrimSuffix(prefix, "/")
+
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
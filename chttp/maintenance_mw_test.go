@@ -0,0 +1,104 @@
+package chttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/chttp/chttptest"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenanceMiddleware(t *testing.T) {
+	t.Parallel()
+
+	mw := newMaintenanceMiddleware(t, chttp.ConfigMaintenance{RetryAfter: 5 * time.Minute})
+
+	assert.Equal(t, http.StatusOK, serveMaintenance(mw, "/users", "").Code)
+
+	mw.Enable()
+	assert.True(t, mw.Status().Enabled())
+
+	resp := serveMaintenance(mw, "/users", "application/json")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Equal(t, "300", resp.Header().Get("Retry-After"))
+	assert.Contains(t, resp.Body.String(), "down for maintenance")
+
+	resp = serveMaintenance(mw, "/users", "text/html,application/xhtml+xml")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.True(t, strings.HasPrefix(resp.Header().Get("Content-Type"), "text/html"))
+	assert.Contains(t, resp.Body.String(), "<h1>Down for maintenance</h1>")
+
+	assert.Equal(t, http.StatusOK, serveMaintenance(mw, "/healthz", "").Code)
+	assert.Equal(t, http.StatusOK, serveMaintenance(mw, "/admin/jobs", "").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, serveMaintenance(mw, "/administrators", "").Code)
+
+	mw.Disable()
+	assert.Equal(t, http.StatusOK, serveMaintenance(mw, "/users", "").Code)
+}
+
+func TestMaintenanceMiddleware_Config(t *testing.T) {
+	t.Parallel()
+
+	mw := newMaintenanceMiddleware(t, chttp.ConfigMaintenance{
+		Enabled: true,
+		Exempt:  []string{"/status"},
+		Message: "Back soon",
+	})
+
+	mw.Disable()
+
+	resp := serveMaintenance(mw, "/users", "")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.JSONEq(t, `{"error": "Back soon"}`, resp.Body.String())
+
+	assert.Equal(t, http.StatusOK, serveMaintenance(mw, "/status", "").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, serveMaintenance(mw, "/healthz", "").Code)
+}
+
+func TestMaintenanceMiddleware_File(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "maintenance")
+
+	assert.NoError(t, os.WriteFile(file, nil, 0o600))
+
+	mw := newMaintenanceMiddleware(t, chttp.ConfigMaintenance{File: file})
+
+	assert.Equal(t, chttp.MaintenanceStatus{File: true}, mw.Status())
+	assert.Equal(t, http.StatusServiceUnavailable, serveMaintenance(mw, "/users", "").Code)
+}
+
+func newMaintenanceMiddleware(t *testing.T, config chttp.ConfigMaintenance) *chttp.MaintenanceMiddleware {
+	t.Helper()
+
+	mw, err := chttp.NewMaintenanceMiddleware(chttp.NewMaintenanceMiddlewareParams{
+		Config: chttp.Config{Maintenance: config},
+		RW:     chttptest.NewReaderWriter(t),
+		Logger: clogger.NewNoop(),
+	})
+	assert.NoError(t, err)
+
+	return mw
+}
+
+func serveMaintenance(mw *chttp.MaintenanceMiddleware, path, accept string) *httptest.ResponseRecorder {
+	var (
+		resp = httptest.NewRecorder()
+		req  = httptest.NewRequest(http.MethodGet, path, nil)
+	)
+
+	req.Header.Set("Accept", accept)
+
+	mw.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})).ServeHTTP(resp, req)
+
+	return resp
+}
@@ -11,9 +11,11 @@ func panicLoggerMiddleware(logger clogger.Logger) Middleware {
 	mw := func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
-				log := logger.WithTags(map[string]interface{}{
-					"path": r.URL.Path,
-				})
+				log := logger.
+					WithFields(clogger.FieldsFromCtx(r.Context())).
+					WithFields(map[string]interface{}{
+						"path": r.URL.Path,
+					})
 
 				switch r := recover().(type) {
 				case nil:
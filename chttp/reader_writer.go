@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"html/template"
 	"net/http"
+	"sort"
 
 	"github.com/gorilla/mux"
 
@@ -28,6 +29,13 @@ type (
 	WriteJSONParams struct {
 		StatusCode int
 		Data       interface{}
+
+		// Request, if set, lets the client select the fields of a successful response using the fields query param
+		// (see FieldsParam). Requests that select invalid fields get a BadRequest response.
+		Request *http.Request
+
+		// Fields is the allow-list of fields that the client can select. If it is empty, any field can be selected.
+		Fields []string
 	}
 
 	// ReaderWriter provides functions to read data from HTTP requests and write response bodies in various formats
@@ -49,13 +57,37 @@ func NewReaderWriter(html *HTMLRenderer, config Config, logger clogger.Logger) *
 	return &ReaderWriter{
 		html:   html,
 		config: config,
-		logger: logger,
+		logger: logger.With(clogger.Module("chttp")),
 	}
 }
 
 // WriteJSON writes a JSON response to the http.ResponseWriter. It can be configured with status code and data using
 // WriteJSONParams.
 func (rw *ReaderWriter) WriteJSON(w http.ResponseWriter, p WriteJSONParams) {
+	if p.Request != nil && p.StatusCode < http.StatusMultipleChoices {
+		fields, err := requestFields(p.Request, p.Fields)
+		if err != nil {
+			rw.WriteJSON(w, WriteJSONParams{
+				StatusCode: http.StatusBadRequest,
+				Data:       err,
+			})
+
+			return
+		}
+
+		if fields != nil {
+			data, err := selectFields(p.Data, fields)
+			if err != nil {
+				rw.logger.Error("Failed to marshal response as json", err)
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			p.Data = data
+		}
+	}
+
 	if p.StatusCode > 0 {
 		w.WriteHeader(p.StatusCode)
 	}
@@ -90,11 +122,17 @@ func (rw *ReaderWriter) WriteJSON(w http.ResponseWriter, p WriteJSONParams) {
 
 // ReadJSON reads JSON from the http.Request into the body var. If the body struct has validate tags on it, the
 // struct is also validated. If the validation fails, a BadRequest response is sent back and the function returns
-// false.
+// false. Bodies larger than chttp.max_body_bytes are rejected. Only the names of the fields that failed validation
+// are logged since the body may hold sensitive values (ex. passwords).
 func (rw *ReaderWriter) ReadJSON(w http.ResponseWriter, req *http.Request, body interface{}) bool {
 	url := req.URL.String()
 
-	err := json.NewDecoder(req.Body).Decode(body)
+	maxBodyBytes := rw.config.MaxBodyBytes
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = defaultMaxBodyBytes
+	}
+
+	err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxBodyBytes)).Decode(body)
 	if err != nil {
 		rw.logger.Warn("Failed to read body", cerrors.New(err, "invalid json", map[string]interface{}{
 			"url": url,
@@ -110,8 +148,16 @@ func (rw *ReaderWriter) ReadJSON(w http.ResponseWriter, req *http.Request, body
 
 	ok, err := govalidator.ValidateStruct(body)
 	if !ok {
-		rw.logger.Warn("Failed to read body", cerrors.New(err, "data validation failed", map[string]interface{}{
-			"url": url,
+		fields := make([]string, 0)
+		for field := range govalidator.ErrorsByField(err) {
+			fields = append(fields, field)
+		}
+
+		sort.Strings(fields)
+
+		rw.logger.Warn("Failed to read body", cerrors.New(nil, "data validation failed", map[string]interface{}{
+			"url":    url,
+			"fields": fields,
 		}))
 
 		rw.WriteJSON(w, WriteJSONParams{
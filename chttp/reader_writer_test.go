@@ -3,6 +3,7 @@ package chttp_test
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -10,6 +11,7 @@ import (
 	"github.com/gocopper/copper/chttp/chttptest"
 
 	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -68,6 +70,55 @@ func TestReaderWriter_ReadJSON_Validator(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestReaderWriter_ReadJSON_Validator_LogsFields(t *testing.T) {
+	t.Parallel()
+
+	var (
+		logs []clogger.RecordedLog
+		body struct {
+			Email    string `json:"email" valid:"email"`
+			Password string `json:"password" valid:"length(8|64)"`
+		}
+	)
+
+	rw := chttp.NewReaderWriter(nil, chttp.Config{}, clogger.NewRecorder(&logs))
+
+	ok := rw.ReadJSON(
+		httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodGet, "/", bytes.NewReader([]byte(`{"email": "value", "password": "hunter2"}`))),
+		&body,
+	)
+
+	assert.False(t, ok)
+
+	if assert.Len(t, logs, 1) {
+		assert.Nil(t, logs[0].Tags["body"])
+		assert.NotContains(t, fmt.Sprint(logs[0]), "hunter2")
+		assert.Contains(t, logs[0].Error.Error(), "[email password]")
+		assert.Equal(t, "chttp", logs[0].Tags[clogger.ModuleKey])
+	}
+}
+
+func TestReaderWriter_ReadJSON_TooLarge(t *testing.T) {
+	t.Parallel()
+
+	var body struct {
+		Key string `json:"key"`
+	}
+
+	rw := chttp.NewReaderWriter(nil, chttp.Config{MaxBodyBytes: 16}, clogger.NewNoop())
+	resp := httptest.NewRecorder()
+
+	ok := rw.ReadJSON(
+		resp,
+		httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"key": "a long value that is over the limit"}`))),
+		&body,
+	)
+
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
 func TestReaderWriter_WriteJSON_Data(t *testing.T) {
 	t.Parallel()
 
@@ -119,6 +170,102 @@ func TestReaderWriter_WriteJSON_Error(t *testing.T) {
 	assert.Contains(t, resp.Body.String(), `{"error":"test-err"}`)
 }
 
+func TestReaderWriter_WriteJSON_Fields(t *testing.T) {
+	t.Parallel()
+
+	type author struct {
+		ID    int    `json:"id"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+
+	type post struct {
+		ID     int64  `json:"id"`
+		Title  string `json:"title"`
+		Body   string `json:"body"`
+		Author author `json:"author"`
+	}
+
+	var (
+		rw    = chttptest.NewReaderWriter(t)
+		posts = []post{
+			{ID: 9007199254740993, Title: "Hello", Body: "..", Author: author{ID: 1, Name: "Alice", Email: "a@x.com"}},
+			{ID: 2, Title: "World", Body: "..", Author: author{ID: 2, Name: "Bob", Email: "b@x.com"}},
+		}
+	)
+
+	write := func(query string, allowed []string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+
+		rw.WriteJSON(resp, chttp.WriteJSONParams{
+			Data:    posts,
+			Request: httptest.NewRequest(http.MethodGet, "/posts"+query, nil),
+			Fields:  allowed,
+		})
+
+		return resp
+	}
+
+	resp := write("?fields=id,author.name,unknown", nil)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `[
+		{"id": 9007199254740993, "author": {"name": "Alice"}},
+		{"id": 2, "author": {"name": "Bob"}}
+	]`, resp.Body.String())
+
+	resp = write("?fields=author.name,author", nil)
+	assert.JSONEq(t, `[{"author": {"id": 1, "name": "Alice", "email": "a@x.com"}}, {"author": {"id": 2, "name": "Bob", "email": "b@x.com"}}]`, resp.Body.String())
+
+	resp = write("", nil)
+	assert.Contains(t, resp.Body.String(), `"body":".."`)
+
+	resp = write("?fields=title,author.name", []string{"id", "title", "author.name"})
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `[{"title": "Hello", "author": {"name": "Alice"}}, {"title": "World", "author": {"name": "Bob"}}]`,
+		resp.Body.String())
+
+	resp = write("?fields=author", []string{"id", "title", "author.name"})
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "unknown field: author")
+
+	resp = write("?fields=author..name", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestReaderWriter_WriteJSON_Fields_Error(t *testing.T) {
+	t.Parallel()
+
+	rw := chttptest.NewReaderWriter(t)
+	resp := httptest.NewRecorder()
+
+	rw.WriteJSON(resp, chttp.WriteJSONParams{
+		StatusCode: http.StatusNotFound,
+		Data:       map[string]string{"error": "not found"},
+		Request:    httptest.NewRequest(http.MethodGet, "/?fields=id", nil),
+	})
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.Contains(t, resp.Body.String(), `{"error":"not found"}`)
+}
+
+func TestReaderWriter_WriteJSON_Fields_MarshalErr(t *testing.T) {
+	t.Parallel()
+
+	var logs []clogger.RecordedLog
+
+	rw := chttp.NewReaderWriter(nil, chttp.Config{}, clogger.NewRecorder(&logs))
+	resp := httptest.NewRecorder()
+
+	rw.WriteJSON(resp, chttp.WriteJSONParams{
+		Data:    map[string]interface{}{"id": 1, "ch": make(chan int)},
+		Request: httptest.NewRequest(http.MethodGet, "/?fields=id", nil),
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "chan")
+	assert.Len(t, logs, 1)
+}
+
 func TestReaderWriter_WriteHTML(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,92 @@
+package chttp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gocopper/copper/clogger"
+)
+
+// HeaderRequestID is the header used to read (from a reverse proxy) and write the request id
+const HeaderRequestID = "X-Request-ID"
+
+type ctxRequest string
+
+const (
+	ctxRequestIDKey = ctxRequest("chttp/request-id")
+	ctxTraceIDKey   = ctxRequest("chttp/trace-id")
+
+	maxRequestIDLen = 128
+)
+
+// setRequestCtxMiddleware assigns a request id and trace id to each request and adds them to the request context
+// along with the logger so that clogger.FromCtx returns a logger that includes them.
+func setRequestCtxMiddleware(logger clogger.Logger) Middleware {
+	var mw = func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(HeaderRequestID)
+			if requestID == "" || len(requestID) > maxRequestIDLen {
+				requestID = randomHex(16) //nolint:gomnd
+			}
+
+			traceID := traceIDFromHeader(r.Header.Get("traceparent"))
+			if traceID == "" {
+				traceID = requestID
+			}
+
+			w.Header().Set(HeaderRequestID, requestID)
+
+			ctx := context.WithValue(r.Context(), ctxRequestIDKey, requestID)
+			ctx = context.WithValue(ctx, ctxTraceIDKey, traceID)
+			ctx = clogger.CtxWithLogger(ctx, logger)
+			ctx = clogger.CtxWithFields(ctx, map[string]interface{}{
+				"requestID": requestID,
+				"traceID":   traceID,
+			})
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+
+	return HandleMiddleware(mw)
+}
+
+// RequestID returns the id of the current request. The id is read from the X-Request-ID header if set by a reverse
+// proxy. Otherwise, it is randomly generated.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxRequestIDKey).(string)
+	return id
+}
+
+// TraceID returns the trace id of the current request. It is read from the W3C traceparent header if present.
+// Otherwise, it is the same as the request id.
+func TraceID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxTraceIDKey).(string)
+	return id
+}
+
+// traceIDFromHeader parses the trace id from a W3C traceparent header (version-traceid-parentid-flags)
+func traceIDFromHeader(traceparent string) string {
+	const traceIDLen = 32
+
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 || len(parts[1]) != traceIDLen { //nolint:gomnd
+		return ""
+	}
+
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return ""
+	}
+
+	return parts[1]
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
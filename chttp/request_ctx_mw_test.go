@@ -0,0 +1,86 @@
+package chttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/chttp/chttptest"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestCtxMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		logs    = make([]clogger.RecordedLog, 0)
+		traceID string
+
+		router = chttptest.NewRouter([]chttp.Route{
+			{
+				Path:    "/",
+				Methods: []string{http.MethodGet},
+				Handler: func(w http.ResponseWriter, r *http.Request) {
+					traceID = chttp.TraceID(r.Context())
+					clogger.FromCtx(r.Context()).Info("test info log")
+				},
+			},
+		})
+
+		handler = chttp.NewHandler(chttp.NewHandlerParams{
+			Routers: []chttp.Router{router},
+			Logger:  clogger.NewRecorder(&logs),
+		})
+	)
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	assert.NoError(t, err)
+
+	req.Header.Set(chttp.HeaderRequestID, "req-1")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, "req-1", resp.Header.Get(chttp.HeaderRequestID))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, "req-1", logs[0].Tags["requestID"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", logs[0].Tags["traceID"])
+}
+
+func TestRequestCtxMiddleware_GeneratedRequestID(t *testing.T) {
+	t.Parallel()
+
+	var requestID string
+
+	router := chttptest.NewRouter([]chttp.Route{
+		{
+			Path:    "/",
+			Methods: []string{http.MethodGet},
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				requestID = chttp.RequestID(r.Context())
+			},
+		},
+	})
+
+	server := httptest.NewServer(chttp.NewHandler(chttp.NewHandlerParams{
+		Routers: []chttp.Router{router},
+		Logger:  clogger.NewNoop(),
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL) //nolint:noctx
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+
+	assert.Len(t, requestID, 32)
+	assert.Equal(t, requestID, resp.Header.Get(chttp.HeaderRequestID))
+}
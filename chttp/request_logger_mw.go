@@ -14,7 +14,7 @@ var errRWIsNotHijacker = errors.New("internal response writer is not http.Hijack
 
 // NewRequestLoggerMiddleware creates a new RequestLoggerMiddleware.
 func NewRequestLoggerMiddleware(logger clogger.Logger) *RequestLoggerMiddleware {
-	return &RequestLoggerMiddleware{logger: logger}
+	return &RequestLoggerMiddleware{logger: logger.With(clogger.Module("chttp"))}
 }
 
 // RequestLoggerMiddleware logs each request's HTTP method, path, and status code along with user uuid
@@ -48,7 +48,10 @@ func (mw *RequestLoggerMiddleware) Handle(next http.Handler) http.Handler {
 
 		tags["statusCode"] = loggerRw.statusCode
 
-		mw.logger.WithTags(tags).Info(fmt.Sprintf("%s %s %d", r.Method, r.URL.Path, loggerRw.statusCode))
+		mw.logger.
+			WithFields(clogger.FieldsFromCtx(r.Context())).
+			WithFields(tags).
+			Info(fmt.Sprintf("%s %s %d", r.Method, r.URL.Path, loggerRw.statusCode))
 	})
 }
 
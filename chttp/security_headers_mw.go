@@ -0,0 +1,166 @@
+package chttp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+const (
+	ctxCSPNonceKey = ctxRequest("chttp/csp-nonce")
+
+	// cspNoncePlaceholder is replaced by the request's nonce in the Content-Security-Policy
+	cspNoncePlaceholder = "{nonce}"
+
+	// omitSecurityHeader is the value of a security header that is not sent
+	omitSecurityHeader = "-"
+)
+
+// defaultSecurityHeaders are the values of the headers that are not configured. Inline styles are allowed since they
+// are used by the built-in pages (ex. the admin dashboard), while inline scripts need the request's nonce.
+func defaultSecurityHeaders() ConfigSecurityHeaders {
+	return ConfigSecurityHeaders{
+		ContentSecurityPolicy: "default-src 'self'; base-uri 'self'; font-src 'self' https: data:; " +
+			"form-action 'self'; frame-ancestors 'self'; img-src 'self' data:; object-src 'none'; " +
+			"script-src 'self' " + cspNoncePlaceholder + "; style-src 'self' https: 'unsafe-inline'",
+		StrictTransportSecurity: "max-age=31536000",
+		ContentTypeOptions:      "nosniff",
+		ReferrerPolicy:          "strict-origin-when-cross-origin",
+		FrameOptions:            "SAMEORIGIN",
+	}
+}
+
+// CSPNonce returns the nonce of the current request that can be used by inline scripts allowed by the
+// Content-Security-Policy set by SecurityHeadersMiddleware. It is available in HTML templates as cspNonce:
+//
+//	<script nonce="{{ cspNonce }}">..</script>
+//
+// It returns an empty string if the middleware did not run.
+func CSPNonce(ctx context.Context) string {
+	nonce, _ := ctx.Value(ctxCSPNonceKey).(string)
+	return nonce
+}
+
+// NewSecurityHeadersMiddlewareParams holds the params needed for NewSecurityHeadersMiddleware
+type NewSecurityHeadersMiddlewareParams struct {
+	Config Config
+}
+
+// NewSecurityHeadersMiddleware creates a new SecurityHeadersMiddleware
+func NewSecurityHeadersMiddleware(p NewSecurityHeadersMiddlewareParams) *SecurityHeadersMiddleware {
+	var (
+		config   = p.Config.SecurityHeaders
+		defaults = defaultSecurityHeaders()
+		headers  = securityHeaders(config)
+	)
+
+	for i, h := range securityHeaders(defaults) {
+		if headers[i].value == "" {
+			headers[i].value = h.value
+		}
+	}
+
+	return &SecurityHeadersMiddleware{
+		disabled: config.Disabled,
+		headers:  headers,
+	}
+}
+
+// SecurityHeadersMiddleware sets the Content-Security-Policy, Strict-Transport-Security, X-Content-Type-Options,
+// Referrer-Policy, and X-Frame-Options headers configured by chttp.security_headers on every response. A random
+// nonce is generated for each request so that the policy can allow inline scripts (see CSPNonce). Routes can
+// override the headers using OverrideSecurityHeaders.
+type SecurityHeadersMiddleware struct {
+	disabled bool
+	headers  []securityHeader
+}
+
+type securityHeader struct {
+	name  string
+	value string
+}
+
+// Handle sets the security headers on the response and adds the request's nonce to its context
+func (mw *SecurityHeadersMiddleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if mw.disabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		nonce := randomNonce()
+
+		setSecurityHeaders(w.Header(), mw.headers, nonce)
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxCSPNonceKey, nonce)))
+	})
+}
+
+// OverrideSecurityHeaders returns a route middleware that overrides the headers set by SecurityHeadersMiddleware.
+// Headers that are not set in overrides are left as is, headers set to "-" are removed, and all of them are removed
+// if overrides.Disabled is set. For example, to allow a page to be embedded by another site:
+//
+//	chttp.Route{
+//		Middlewares: []chttp.Middleware{chttp.OverrideSecurityHeaders(chttp.ConfigSecurityHeaders{
+//			ContentSecurityPolicy: "frame-ancestors https://partner.example.com",
+//			FrameOptions:          "-",
+//		})},
+//		Path:    "/embed",
+//		Methods: []string{http.MethodGet},
+//		Handler: ro.HandleEmbed,
+//	}
+func OverrideSecurityHeaders(overrides ConfigSecurityHeaders) Middleware {
+	headers := securityHeaders(overrides)
+
+	return HandleMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if overrides.Disabled {
+				for _, h := range headers {
+					w.Header().Del(h.name)
+				}
+			} else {
+				setSecurityHeaders(w.Header(), headers, CSPNonce(r.Context()))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	})
+}
+
+func securityHeaders(c ConfigSecurityHeaders) []securityHeader {
+	return []securityHeader{
+		{name: "Content-Security-Policy", value: c.ContentSecurityPolicy},
+		{name: "Strict-Transport-Security", value: c.StrictTransportSecurity},
+		{name: "X-Content-Type-Options", value: c.ContentTypeOptions},
+		{name: "Referrer-Policy", value: c.ReferrerPolicy},
+		{name: "X-Frame-Options", value: c.FrameOptions},
+	}
+}
+
+// setSecurityHeaders sets the headers with a value, removes the ones set to "-", and replaces the nonce placeholder
+func setSecurityHeaders(header http.Header, headers []securityHeader, nonce string) {
+	nonceSource := ""
+	if nonce != "" {
+		nonceSource = "'nonce-" + nonce + "'"
+	}
+
+	for _, h := range headers {
+		switch h.value {
+		case "":
+		case omitSecurityHeader:
+			header.Del(h.name)
+		default:
+			value := strings.ReplaceAll(h.value, cspNoncePlaceholder, nonceSource)
+			header.Set(h.name, strings.Join(strings.Fields(value), " "))
+		}
+	}
+}
+
+func randomNonce() string {
+	b := make([]byte, 16) //nolint:gomnd
+	_, _ = rand.Read(b)
+
+	return base64.StdEncoding.EncodeToString(b)
+}
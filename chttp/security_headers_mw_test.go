@@ -0,0 +1,87 @@
+package chttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveSecurityHeaders(mws ...chttp.Middleware) (http.Header, string) {
+	var (
+		nonce   string
+		handler = http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nonce = chttp.CSPNonce(r.Context())
+		}))
+	)
+
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i].Handle(handler)
+	}
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	return resp.Header(), nonce
+}
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	t.Parallel()
+
+	header, nonce := serveSecurityHeaders(chttp.NewSecurityHeadersMiddleware(chttp.NewSecurityHeadersMiddlewareParams{}))
+
+	assert.NotEmpty(t, nonce)
+	assert.Contains(t, header.Get("Content-Security-Policy"), "script-src 'self' 'nonce-"+nonce+"';")
+	assert.Equal(t, "max-age=31536000", header.Get("Strict-Transport-Security"))
+	assert.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+	assert.Equal(t, "strict-origin-when-cross-origin", header.Get("Referrer-Policy"))
+	assert.Equal(t, "SAMEORIGIN", header.Get("X-Frame-Options"))
+
+	_, nextNonce := serveSecurityHeaders(chttp.NewSecurityHeadersMiddleware(chttp.NewSecurityHeadersMiddlewareParams{}))
+	assert.NotEqual(t, nonce, nextNonce)
+}
+
+func TestSecurityHeadersMiddleware_Config(t *testing.T) {
+	t.Parallel()
+
+	mw := chttp.NewSecurityHeadersMiddleware(chttp.NewSecurityHeadersMiddlewareParams{
+		Config: chttp.Config{SecurityHeaders: chttp.ConfigSecurityHeaders{
+			ContentSecurityPolicy: `default-src 'self';
+				script-src 'self' {nonce}`,
+			FrameOptions: "-",
+		}},
+	})
+
+	header, nonce := serveSecurityHeaders(mw)
+	assert.Equal(t, "default-src 'self'; script-src 'self' 'nonce-"+nonce+"'", header.Get("Content-Security-Policy"))
+	assert.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+	assert.NotContains(t, header, "X-Frame-Options")
+
+	disabled := chttp.NewSecurityHeadersMiddleware(chttp.NewSecurityHeadersMiddlewareParams{
+		Config: chttp.Config{SecurityHeaders: chttp.ConfigSecurityHeaders{Disabled: true}},
+	})
+
+	header, nonce = serveSecurityHeaders(disabled)
+	assert.Empty(t, header)
+	assert.Empty(t, nonce)
+}
+
+func TestOverrideSecurityHeaders(t *testing.T) {
+	t.Parallel()
+
+	mw := chttp.NewSecurityHeadersMiddleware(chttp.NewSecurityHeadersMiddlewareParams{})
+
+	header, nonce := serveSecurityHeaders(mw, chttp.OverrideSecurityHeaders(chttp.ConfigSecurityHeaders{
+		ContentSecurityPolicy: "frame-ancestors https://partner.example.com; script-src {nonce}",
+		FrameOptions:          "-",
+	}))
+	assert.Equal(t, "frame-ancestors https://partner.example.com; script-src 'nonce-"+nonce+"'",
+		header.Get("Content-Security-Policy"))
+	assert.NotContains(t, header, "X-Frame-Options")
+	assert.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+
+	header, _ = serveSecurityHeaders(mw, chttp.OverrideSecurityHeaders(chttp.ConfigSecurityHeaders{Disabled: true}))
+	assert.Empty(t, header)
+}
@@ -3,13 +3,20 @@ package chttp
 import (
 	"context"
 	"errors"
-	"fmt"
+	"net"
 	"net/http"
+	"time"
 
+	"github.com/gocopper/copper/cerrors"
 	"github.com/gocopper/copper/clifecycle"
 	"github.com/gocopper/copper/clogger"
 )
 
+const (
+	listenRetries = 3
+	listenBackoff = time.Second
+)
+
 // NewServerParams holds the params needed to create a server.
 type NewServerParams struct {
 	Handler   http.Handler
@@ -23,7 +30,7 @@ func NewServer(p NewServerParams) *Server {
 	return &Server{
 		handler:  p.Handler,
 		config:   p.Config,
-		logger:   p.Logger,
+		logger:   p.Logger.With(clogger.Module("chttp")),
 		lc:       p.Lifecycle,
 		internal: http.Server{},
 	}
@@ -39,10 +46,32 @@ type Server struct {
 	internal http.Server
 }
 
-// Run configures an HTTP server using the provided app config and starts it.
+// Run configures an HTTP server using the provided app config and starts it. The server listens on every address
+// configured in Config.Listen (or Config.Port if none are configured).
 func (s *Server) Run() error {
-	s.internal.Addr = fmt.Sprintf(":%d", s.config.Port)
 	s.internal.Handler = s.handler
+	s.internal.ReadTimeout = s.config.ReadTimeout
+	s.internal.ReadHeaderTimeout = s.config.ReadHeaderTimeout
+	s.internal.WriteTimeout = s.config.WriteTimeout
+	s.internal.IdleTimeout = s.config.IdleTimeout
+	s.internal.MaxHeaderBytes = s.config.MaxHeaderBytes
+	s.internal.SetKeepAlivesEnabled(!s.config.DisableKeepAlives)
+
+	listeners := make([]net.Listener, 0)
+	for _, addr := range s.config.listenAddrs() {
+		l, err := listen(addr, s.config.KeepAlivePeriod)
+		if err != nil {
+			for i := range listeners {
+				_ = listeners[i].Close()
+			}
+
+			return cerrors.New(err, "failed to listen", map[string]interface{}{
+				"addr": addr,
+			})
+		}
+
+		listeners = append(listeners, l...)
+	}
 
 	s.lc.OnStop(func(ctx context.Context) error {
 		s.logger.Info("Shutting down http server..")
@@ -50,16 +79,32 @@ func (s *Server) Run() error {
 		return s.internal.Shutdown(ctx)
 	})
 
-	go func() {
-		s.logger.
-			WithTags(map[string]interface{}{"port": s.config.Port}).
-			Info("Starting http server..")
+	for i := range listeners {
+		go func(l net.Listener) {
+			s.logger.
+				WithTags(map[string]interface{}{
+					"network": l.Addr().Network(),
+					"addr":    l.Addr().String(),
+				}).
+				Info("Starting http server..")
 
-		err := s.internal.ListenAndServe()
-		if err != nil && !errors.Is(err, http.ErrServerClosed) {
-			s.logger.Error("Server did not close cleanly", err)
-		}
-	}()
+			err := s.internal.Serve(l)
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				s.logger.Error("Server did not close cleanly", err)
+			}
+		}(listeners[i])
+	}
 
 	return nil
 }
+
+// Stage implements clifecycle.Staged. The server starts listening once the migrations have run. Listening is retried
+// since the previous process of the app may still hold the port while it shuts down.
+func (s *Server) Stage() clifecycle.Stage {
+	return clifecycle.Stage{
+		Name:    clifecycle.StageHTTP,
+		After:   []string{clifecycle.StageMigrations, clifecycle.StageSeeds},
+		Retries: listenRetries,
+		Backoff: listenBackoff,
+	}
+}
@@ -1,7 +1,11 @@
 package chttp_test
 
 import (
+	"context"
+	"net"
 	"net/http"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -45,3 +49,104 @@ func TestServer_Run(t *testing.T) {
 	_, err = http.Get("http://127.0.0.1:8999") //nolint:noctx,bodyclose
 	assert.EqualError(t, err, "Get \"http://127.0.0.1:8999\": dial tcp 127.0.0.1:8999: connect: connection refused")
 }
+
+func TestServer_Run_MultipleListeners(t *testing.T) {
+	t.Parallel()
+
+	var (
+		logger   = clogger.NewNoop()
+		lc       = clifecycle.New()
+		sockPath = filepath.Join(t.TempDir(), "chttp.sock")
+	)
+
+	server := chttp.NewServer(chttp.NewServerParams{
+		Handler: http.NotFoundHandler(),
+		Config: chttp.Config{
+			Listen: []string{"tcp://127.0.0.1:8998", "unix://" + sockPath},
+		},
+		Logger:    logger,
+		Lifecycle: lc,
+	})
+
+	assert.NoError(t, server.Run())
+
+	resp, err := http.Get("http://127.0.0.1:8998") //nolint:noctx
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	unixClient := http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", sockPath)
+			},
+		},
+	}
+
+	resp, err = unixClient.Get("http://unix") //nolint:noctx
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	lc.Stop(logger)
+}
+
+func TestServer_Run_InvalidListenScheme(t *testing.T) {
+	t.Parallel()
+
+	server := chttp.NewServer(chttp.NewServerParams{
+		Handler:   http.NotFoundHandler(),
+		Config:    chttp.Config{Listen: []string{"udp://:8997"}},
+		Logger:    clogger.NewNoop(),
+		Lifecycle: clifecycle.New(),
+	})
+
+	assert.Error(t, server.Run())
+}
+
+func TestServer_Run_MaxHeaderBytes(t *testing.T) {
+	t.Parallel()
+
+	var (
+		logger = clogger.NewNoop()
+		lc     = clifecycle.New()
+	)
+
+	server := chttp.NewServer(chttp.NewServerParams{
+		Handler: http.NotFoundHandler(),
+		Config: chttp.Config{
+			Listen:            []string{"tcp://127.0.0.1:8996"},
+			ReadHeaderTimeout: time.Second,
+			MaxHeaderBytes:    1 << 10,
+		},
+		Logger:    logger,
+		Lifecycle: lc,
+	})
+
+	assert.NoError(t, server.Run())
+
+	req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1:8996", nil) //nolint:noctx
+	assert.NoError(t, err)
+
+	req.Header.Set("X-Large", strings.Repeat("a", 8<<10))
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, resp.StatusCode)
+
+	lc.Stop(logger)
+}
+
+func TestServer_Run_InvalidFD(t *testing.T) {
+	t.Parallel()
+
+	server := chttp.NewServer(chttp.NewServerParams{
+		Handler:   http.NotFoundHandler(),
+		Config:    chttp.Config{Listen: []string{"fd://stdin"}},
+		Logger:    clogger.NewNoop(),
+		Lifecycle: clifecycle.New(),
+	})
+
+	assert.Error(t, server.Run())
+}
@@ -0,0 +1,58 @@
+//go:build !windows
+// +build !windows
+
+package chttp_test
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_Run_InheritedFD(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	defer func() { _ = l.Close() }()
+
+	// duplicate the socket's file descriptor as a process manager would before starting the app
+	rc, err := l.(*net.TCPListener).SyscallConn()
+	assert.NoError(t, err)
+
+	var fd int
+
+	assert.NoError(t, rc.Control(func(s uintptr) {
+		fd, err = syscall.Dup(int(s))
+	}))
+	assert.NoError(t, err)
+
+	var (
+		logger = clogger.NewNoop()
+		lc     = clifecycle.New()
+	)
+
+	server := chttp.NewServer(chttp.NewServerParams{
+		Handler:   http.NotFoundHandler(),
+		Config:    chttp.Config{Listen: []string{fmt.Sprintf("fd://%d", fd)}},
+		Logger:    logger,
+		Lifecycle: lc,
+	})
+
+	assert.NoError(t, server.Run())
+
+	resp, err := http.Get("http://" + l.Addr().String()) //nolint:noctx
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	lc.Stop(logger)
+}
@@ -9,12 +9,22 @@ var WireModule = wire.NewSet( //nolint:gochecknoglobals
 	LoadConfig,
 	NewReaderWriter,
 	NewRequestLoggerMiddleware,
+	wire.Struct(new(NewClientIPMiddlewareParams), "*"),
+	NewClientIPMiddleware,
+	wire.Struct(new(NewSecurityHeadersMiddlewareParams), "*"),
+	NewSecurityHeadersMiddleware,
+	wire.Struct(new(NewMaintenanceMiddlewareParams), "*"),
+	NewMaintenanceMiddleware,
 	wire.Struct(new(NewServerParams), "*"),
 	NewServer,
 	wire.Struct(new(NewHTMLRouterParams), "*"),
 	NewHTMLRouter,
 	wire.Struct(new(NewHTMLRendererParams), "*"),
 	NewHTMLRenderer,
+	wire.Struct(new(NewAssetsParams), "*"),
+	NewAssets,
+	wire.Struct(new(NewBatchRouterParams), "*"),
+	NewBatchRouter,
 )
 
 // WireModuleEmptyHTML provides empty/default values for html and static dirs. This can be used to satisfy
@@ -24,3 +34,9 @@ var WireModuleEmptyHTML = wire.NewSet( //nolint:gochecknoglobals
 	wire.InterfaceValue(new(StaticDir), &EmptyFS{}),
 	wire.Value([]HTMLRenderFunc{}),
 )
+
+// WireModuleGeo provides GeoMiddleware. Apps that use it must also provide a GeoResolver.
+var WireModuleGeo = wire.NewSet( //nolint:gochecknoglobals
+	wire.Struct(new(NewGeoMiddlewareParams), "*"),
+	NewGeoMiddleware,
+)
@@ -0,0 +1,75 @@
+package cidempotency
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	defaultTTL              = 24 * time.Hour
+	defaultLockTTL          = time.Minute
+	defaultMaxResponseBytes = 1 << 20
+	defaultMaxRequestBytes  = 1 << 20
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "cidempotency",
+		Description: "cidempotency configures how responses to requests with an Idempotency-Key are stored",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("cidempotency", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load cidempotency config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Methods:          []string{http.MethodPost, http.MethodPatch},
+		TTL:              defaultTTL,
+		LockTTL:          defaultLockTTL,
+		MaxResponseBytes: defaultMaxResponseBytes,
+		MaxRequestBytes:  defaultMaxRequestBytes,
+	}
+}
+
+// Config configures Middleware. For example:
+//
+//	[cidempotency]
+//	ttl = "48h"
+//	required = true
+type Config struct {
+	// Methods are the request methods that honor the Idempotency-Key header. Requests with other methods are passed
+	// through as-is.
+	Methods []string `toml:"methods" doc:"Request methods that honor the Idempotency-Key header"`
+
+	// Required rejects requests without an Idempotency-Key header with a 400
+	Required bool `toml:"required" doc:"Reject requests without an Idempotency-Key header"`
+
+	// TTL is how long responses are stored and replayed for
+	TTL time.Duration `toml:"ttl" doc:"How long responses are replayed for"`
+
+	// LockTTL is how long a key is held while its first request is handled. Retries that arrive before the first
+	// request finishes get a 409. If the app crashes while handling the request, the key is released after LockTTL.
+	LockTTL time.Duration `toml:"lock_ttl" doc:"How long a key is held while its first request is handled"`
+
+	// MaxResponseBytes is the max size of a stored response. Larger responses are not stored and their retries get a
+	// 409 instead of being handled again.
+	MaxResponseBytes int `toml:"max_response_bytes" doc:"Max size of a stored response"`
+
+	// MaxRequestBytes is the max size of the body of a request with an Idempotency-Key. The body is read to
+	// fingerprint the request, so larger requests are rejected with a 413.
+	MaxRequestBytes int64 `toml:"max_request_bytes" doc:"Max size of a request's body"`
+}
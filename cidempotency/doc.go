@@ -0,0 +1,4 @@
+// Package cidempotency protects unsafe API requests (ex. payments) from being handled twice when clients retry them.
+// Middleware stores the first response to each request with an Idempotency-Key header and replays it for retries
+// with the same key, route, and user.
+package cidempotency
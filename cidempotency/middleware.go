@@ -0,0 +1,328 @@
+package cidempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+)
+
+// Headers used by Middleware
+const (
+	// HeaderKey holds the client-generated key of a request (ex. a UUID) that is the same for all of its retries
+	HeaderKey = "Idempotency-Key"
+
+	// HeaderReplayed is set to true on replayed responses
+	HeaderReplayed = "Idempotent-Replayed"
+)
+
+// maxKeyLength is the max length of an Idempotency-Key
+const maxKeyLength = 255
+
+// Users identifies the user of a request so that keys are scoped per user. Apps implement it using the user set in
+// the request context by their auth middleware.
+type Users interface {
+	// UserID returns the id of the request's user or an empty string if the request is anonymous
+	UserID(r *http.Request) string
+}
+
+// Anonymous is a Users that does not identify users. It can be used by apps whose idempotent routes are not tied to
+// users, in which case keys are only scoped per route.
+type Anonymous struct{}
+
+// UserID implements Users. It always returns an empty string.
+func (Anonymous) UserID(r *http.Request) string {
+	return ""
+}
+
+// NewMiddlewareParams holds the params needed for NewMiddleware
+type NewMiddlewareParams struct {
+	Store  Store
+	Users  Users
+	RW     *chttp.ReaderWriter
+	Config Config
+	Logger clogger.Logger
+}
+
+// NewMiddleware creates a new Middleware
+func NewMiddleware(p NewMiddlewareParams) *Middleware {
+	methods := make(map[string]bool, len(p.Config.Methods))
+	for _, m := range p.Config.Methods {
+		methods[strings.ToUpper(m)] = true
+	}
+
+	return &Middleware{
+		store:   p.Store,
+		users:   p.Users,
+		rw:      p.RW,
+		config:  p.Config,
+		methods: methods,
+		logger:  p.Logger.With(clogger.Module("cidempotency")),
+	}
+}
+
+// Middleware is a chttp.Middleware that honors the Idempotency-Key header on unsafe requests (POST and PATCH by
+// default). The first response to a key is stored with the key, the route, and the user, and is replayed for retries
+// within cidempotency.ttl:
+//   - retries that arrive while the first request is being handled get a 409
+//   - retries with a different method, URL, or body get a 422 since the key was reused for another request
+//   - responses with a 5xx status are not stored so that the request can be retried
+//   - responses larger than cidempotency.max_response_bytes are not stored, and their retries get a 409 since the
+//     request was already handled
+//
+// It should run after the app's auth middleware so that Users can read the user, and is usually added to the routes
+// that need it (ex. payments) rather than globally.
+type Middleware struct {
+	store   Store
+	users   Users
+	rw      *chttp.ReaderWriter
+	config  Config
+	methods map[string]bool
+	logger  clogger.Logger
+}
+
+// Handle implements the chttp.Middleware interface. See Middleware
+func (m *Middleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.methods[r.Method] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		idemKey := r.Header.Get(HeaderKey)
+
+		switch {
+		case idemKey == "" && m.config.Required:
+			m.writeError(w, http.StatusBadRequest, "The Idempotency-Key header is required")
+			return
+		case idemKey == "":
+			next.ServeHTTP(w, r)
+			return
+		case len(idemKey) > maxKeyLength:
+			m.writeError(w, http.StatusBadRequest, "The Idempotency-Key header is too long")
+			return
+		}
+
+		maxRequestBytes := m.config.MaxRequestBytes
+		if maxRequestBytes <= 0 {
+			maxRequestBytes = defaultMaxRequestBytes
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+		if err != nil && int64(len(body)) >= maxRequestBytes {
+			m.writeError(w, http.StatusRequestEntityTooLarge, "The request body is too large")
+			return
+		}
+
+		if err != nil {
+			m.writeError(w, http.StatusBadRequest, "Failed to read the request body")
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var (
+			ctx         = r.Context()
+			key         = hash(m.users.UserID(r), chttp.RawRoutePath(r), idemKey)
+			fingerprint = hash(r.Method, r.URL.RequestURI(), string(body))
+			logger      = m.logger.WithTags(map[string]interface{}{
+				"idempotencyKey": idemKey,
+			})
+		)
+
+		resp, ok, err := m.store.Get(ctx, key)
+		if err != nil {
+			logger.Error("Failed to get stored response", err)
+			m.writeError(w, http.StatusInternalServerError, "Internal server error")
+
+			return
+		}
+
+		if ok {
+			m.replay(w, resp, fingerprint)
+			return
+		}
+
+		claimed, err := m.store.Claim(ctx, key, m.config.LockTTL)
+		if err != nil {
+			logger.Error("Failed to claim idempotency key", err)
+			m.writeError(w, http.StatusInternalServerError, "Internal server error")
+
+			return
+		}
+
+		if !claimed {
+			// the first request may have stored its response after the key was checked
+			resp, ok, err = m.store.Get(ctx, key)
+			if err != nil {
+				logger.Error("Failed to get stored response", err)
+				m.writeError(w, http.StatusInternalServerError, "Internal server error")
+
+				return
+			}
+
+			if ok {
+				m.replay(w, resp, fingerprint)
+				return
+			}
+
+			m.writeError(w, http.StatusConflict, "A request with this Idempotency-Key is already being handled")
+
+			return
+		}
+
+		var (
+			rec   = &recorder{ResponseWriter: w, statusCode: http.StatusOK, maxBytes: m.config.MaxResponseBytes}
+			saved = false
+
+			// a client that disconnects is likely to retry, so the response must be stored even if the request's
+			// context is canceled
+			storeCtx = detachedCtx{ctx}
+		)
+
+		// the key is released if the response is not stored, including when the handler panics
+		defer func() {
+			if saved {
+				return
+			}
+
+			err := m.store.Release(storeCtx, key)
+			if err != nil {
+				logger.Error("Failed to release idempotency key", err)
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+
+		if rec.statusCode >= http.StatusInternalServerError {
+			return
+		}
+
+		resp = &Response{
+			StatusCode:  rec.statusCode,
+			Header:      rec.header,
+			Body:        rec.body.Bytes(),
+			Fingerprint: fingerprint,
+		}
+
+		if rec.tooLarge {
+			resp = errorResponse(http.StatusConflict,
+				"The request with this Idempotency-Key was handled but its response is too large to be replayed",
+				fingerprint)
+		}
+
+		err = m.store.Save(storeCtx, key, *resp, m.config.TTL)
+		if err != nil {
+			logger.Error("Failed to store response", err)
+			return
+		}
+
+		saved = true
+	})
+}
+
+func (m *Middleware) replay(w http.ResponseWriter, resp *Response, fingerprint string) {
+	if resp.Fingerprint != fingerprint {
+		m.writeError(w, http.StatusUnprocessableEntity,
+			"The Idempotency-Key has already been used for a different request")
+
+		return
+	}
+
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+
+	w.Header().Set(HeaderReplayed, "true")
+	w.WriteHeader(resp.StatusCode)
+
+	_, _ = w.Write(resp.Body)
+}
+
+func (m *Middleware) writeError(w http.ResponseWriter, statusCode int, msg string) {
+	m.rw.WriteJSON(w, chttp.WriteJSONParams{
+		StatusCode: statusCode,
+		Data:       map[string]string{"error": msg},
+	})
+}
+
+// errorResponse creates a Response with a JSON error that is stored in place of a response that cannot be replayed
+func errorResponse(statusCode int, msg, fingerprint string) *Response {
+	body, _ := json.Marshal(map[string]string{"error": msg})
+
+	return &Response{
+		StatusCode:  statusCode,
+		Header:      http.Header{"Content-Type": []string{"application/json"}},
+		Body:        append(body, '\n'),
+		Fingerprint: fingerprint,
+	}
+}
+
+// detachedCtx holds the values of its parent context (ex. its logger) but is never canceled
+type detachedCtx struct {
+	context.Context //nolint:containedctx
+}
+
+func (detachedCtx) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedCtx) Done() <-chan struct{}       { return nil }
+func (detachedCtx) Err() error                  { return nil }
+
+// recorder records the response written to its ResponseWriter so that it can be stored
+type recorder struct {
+	http.ResponseWriter
+
+	statusCode  int
+	header      http.Header
+	body        bytes.Buffer
+	maxBytes    int
+	tooLarge    bool
+	wroteHeader bool
+}
+
+func (rec *recorder) WriteHeader(statusCode int) {
+	if rec.wroteHeader {
+		return
+	}
+
+	rec.wroteHeader = true
+	rec.statusCode = statusCode
+	rec.header = rec.ResponseWriter.Header().Clone()
+
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+
+	if !rec.tooLarge {
+		if rec.maxBytes > 0 && rec.body.Len()+len(b) > rec.maxBytes {
+			rec.tooLarge = true
+			rec.body.Reset()
+		} else {
+			rec.body.Write(b)
+		}
+	}
+
+	return rec.ResponseWriter.Write(b)
+}
+
+func hash(parts ...string) string {
+	h := sha256.New()
+
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,347 @@
+package cidempotency_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/chttp/chttptest"
+	"github.com/gocopper/copper/cidempotency"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+type headerUsers struct{}
+
+func (headerUsers) UserID(r *http.Request) string {
+	return r.Header.Get("X-User")
+}
+
+// racyStore is a Store whose Get misses once after miss is set, as if the response was stored by another request
+// between Get and Claim
+type racyStore struct {
+	cidempotency.Store
+
+	miss int32
+}
+
+func (s *racyStore) Get(ctx context.Context, key string) (*cidempotency.Response, bool, error) {
+	if atomic.CompareAndSwapInt32(&s.miss, 1, 0) {
+		return nil, false, nil
+	}
+
+	return s.Store.Get(ctx, key)
+}
+
+// ctxStore is a Store that fails like a shared store when its context is canceled. saved is closed once a
+// response is saved.
+type ctxStore struct {
+	cidempotency.Store
+
+	saved chan struct{}
+}
+
+func (s *ctxStore) Save(ctx context.Context, key string, resp cidempotency.Response, ttl time.Duration) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	defer close(s.saved)
+
+	return s.Store.Save(ctx, key, resp, ttl)
+}
+
+func (s *ctxStore) Release(ctx context.Context, key string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return s.Store.Release(ctx, key)
+}
+
+func newTestServer(t *testing.T, config cidempotency.Config, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	return newTestServerWithStore(t, config, cidempotency.NewMemoryStore(), handler)
+}
+
+func newTestServerWithStore(t *testing.T, config cidempotency.Config, store cidempotency.Store,
+	handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	mw := cidempotency.NewMiddleware(cidempotency.NewMiddlewareParams{
+		Store:  store,
+		Users:  headerUsers{},
+		RW:     chttptest.NewReaderWriter(t),
+		Config: config,
+		Logger: clogger.NewNoop(),
+	})
+
+	server := httptest.NewServer(chttp.NewHandler(chttp.NewHandlerParams{
+		Routers: []chttp.Router{chttptest.NewRouter([]chttp.Route{{
+			Middlewares: []chttp.Middleware{mw},
+			Path:        "/payments",
+			Methods:     []string{http.MethodGet, http.MethodPost},
+			Handler:     handler,
+		}})},
+		Logger: clogger.NewNoop(),
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func post(t *testing.T, server *httptest.Server, key, user, body string) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL+"/payments",
+		strings.NewReader(body))
+	assert.NoError(t, err)
+
+	if key != "" {
+		req.Header.Set(cidempotency.HeaderKey, key)
+	}
+
+	req.Header.Set("X-User", user)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	return resp, string(respBody)
+}
+
+func testConfig() cidempotency.Config {
+	return cidempotency.Config{
+		Methods:          []string{http.MethodPost},
+		TTL:              time.Hour,
+		LockTTL:          time.Minute,
+		MaxResponseBytes: 1024,
+	}
+}
+
+func TestMiddleware_Replay(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+
+	server := newTestServer(t, testConfig(), func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+
+		w.Header().Set("X-Payment", "p1")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"call":` + strconv.Itoa(int(n)) + `}`))
+	})
+
+	resp, body := post(t, server, "k1", "alice", `{"amount":10}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, `{"call":1}`, body)
+	assert.Empty(t, resp.Header.Get(cidempotency.HeaderReplayed))
+
+	resp, body = post(t, server, "k1", "alice", `{"amount":10}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, `{"call":1}`, body)
+	assert.Equal(t, "p1", resp.Header.Get("X-Payment"))
+	assert.Equal(t, "true", resp.Header.Get(cidempotency.HeaderReplayed))
+
+	resp, _ = post(t, server, "k1", "alice", `{"amount":20}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	_, body = post(t, server, "k1", "bob", `{"amount":10}`)
+	assert.Equal(t, `{"call":2}`, body)
+
+	_, body = post(t, server, "", "alice", `{"amount":10}`)
+	assert.Equal(t, `{"call":3}`, body)
+}
+
+func TestMiddleware_ReplaySavedBeforeClaim(t *testing.T) {
+	t.Parallel()
+
+	var (
+		calls  int32
+		store  = &racyStore{Store: cidempotency.NewMemoryStore()}
+		server = newTestServerWithStore(t, testConfig(), store, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusCreated)
+		})
+	)
+
+	resp, _ := post(t, server, "k1", "alice", `{"amount":10}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	atomic.StoreInt32(&store.miss, 1)
+
+	resp, _ = post(t, server, "k1", "alice", `{"amount":10}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get(cidempotency.HeaderReplayed))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestMiddleware_RequestTooLarge(t *testing.T) {
+	t.Parallel()
+
+	config := testConfig()
+	config.MaxRequestBytes = 16
+
+	server := newTestServer(t, config, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	resp, _ := post(t, server, "k1", "alice", `{"amount":10,"note":"over the limit"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	resp, _ = post(t, server, "k2", "alice", `{"amount":10}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
+
+func TestMiddleware_ResponseTooLarge(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+
+	server := newTestServer(t, testConfig(), func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(strings.Repeat("x", 2048)))
+	})
+
+	resp, body := post(t, server, "k1", "alice", `{"amount":10}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Len(t, body, 2048)
+
+	// the request was handled, so its retry must not be handled again
+	resp, _ = post(t, server, "k1", "alice", `{"amount":10}`)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	resp, _ = post(t, server, "k1", "alice", `{"amount":20}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+}
+
+func TestMiddleware_ClientDisconnected(t *testing.T) {
+	t.Parallel()
+
+	var (
+		calls   int32
+		started = make(chan struct{})
+		store   = &ctxStore{Store: cidempotency.NewMemoryStore(), saved: make(chan struct{})}
+	)
+
+	server := newTestServerWithStore(t, testConfig(), store, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-r.Context().Done()
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/payments", strings.NewReader(""))
+	assert.NoError(t, err)
+	req.Header.Set(cidempotency.HeaderKey, "k1")
+	req.Header.Set("X-User", "alice")
+
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	_, err = http.DefaultClient.Do(req)
+	assert.Error(t, err)
+
+	select {
+	case <-store.saved:
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "response was not saved")
+	}
+
+	resp, _ := post(t, server, "k1", "alice", "")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get(cidempotency.HeaderReplayed))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestMiddleware_ServerError(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+
+	server := newTestServer(t, testConfig(), func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	resp, _ := post(t, server, "k1", "alice", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	resp, _ = post(t, server, "k1", "alice", "")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestMiddleware_InProgress(t *testing.T) {
+	t.Parallel()
+
+	var (
+		started = make(chan struct{})
+		finish  = make(chan struct{})
+	)
+
+	server := newTestServer(t, testConfig(), func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-finish
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	done := make(chan int)
+
+	go func() {
+		resp, _ := post(t, server, "k1", "alice", "")
+		done <- resp.StatusCode
+	}()
+
+	<-started
+
+	resp, _ := post(t, server, "k1", "alice", "")
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	close(finish)
+	assert.Equal(t, http.StatusCreated, <-done)
+}
+
+func TestMiddleware_Required(t *testing.T) {
+	t.Parallel()
+
+	config := testConfig()
+	config.Required = true
+
+	server := newTestServer(t, config, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	resp, _ := post(t, server, "", "alice", "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err := http.Get(server.URL + "/payments") //nolint:noctx
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
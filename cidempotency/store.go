@@ -0,0 +1,136 @@
+package cidempotency
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Response is a stored response that is replayed for retried requests
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	// Fingerprint is a hash of the request that created the response. Retries must send the same request.
+	Fingerprint string
+}
+
+// Store holds the responses to requests with an Idempotency-Key. Implementations must make sure that a key is only
+// claimed once at a time so that concurrent retries are not handled twice.
+type Store interface {
+	// Get returns the stored response of the key and false if there is none or it expired
+	Get(ctx context.Context, key string) (*Response, bool, error)
+
+	// Claim marks the key as being handled until ttl expires. It returns false if the key is already claimed or has a
+	// stored response.
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// Save stores the response of a claimed key until ttl expires
+	Save(ctx context.Context, key string, resp Response, ttl time.Duration) error
+
+	// Release removes the claim on a key so that it can be retried (ex. when the handler fails)
+	Release(ctx context.Context, key string) error
+}
+
+// memorySweepInterval is the min time between two sweeps of the expired entries of a memory store
+const memorySweepInterval = time.Minute
+
+// NewMemoryStore returns an in-memory implementation of Store. It is suitable for single instance deployments and
+// tests. Multi-instance deployments should use a shared store.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		entries: make(map[string]memoryEntry),
+		now:     time.Now,
+	}
+}
+
+// memoryStore expires entries when their key is accessed and sweeps the other expired entries at most once every
+// memorySweepInterval so that requests do not scan every entry.
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+	sweptAt time.Time
+}
+
+type memoryEntry struct {
+	resp      *Response
+	expiresAt time.Time
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string) (*Response, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entry(key)
+	if !ok || entry.resp == nil {
+		return nil, false, nil
+	}
+
+	return entry.resp, true, nil
+}
+
+func (s *memoryStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	if now.Sub(s.sweptAt) >= memorySweepInterval {
+		s.sweep(now)
+	}
+
+	if _, ok := s.entry(key); ok {
+		return false, nil
+	}
+
+	s.entries[key] = memoryEntry{expiresAt: now.Add(ttl)}
+
+	return true, nil
+}
+
+func (s *memoryStore) Save(ctx context.Context, key string, resp Response, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryEntry{resp: &resp, expiresAt: s.now().Add(ttl)}
+
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+
+	return nil
+}
+
+// sweep deletes the expired entries. It must be called with the lock held.
+func (s *memoryStore) sweep(now time.Time) {
+	for k, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+
+	s.sweptAt = now
+}
+
+// entry returns the unexpired entry of the key. It must be called with the lock held.
+func (s *memoryStore) entry(key string) (memoryEntry, bool) {
+	entry, ok := s.entries[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+
+	if !s.now().Before(entry.expiresAt) {
+		delete(s.entries, key)
+		return memoryEntry{}, false
+	}
+
+	return entry, true
+}
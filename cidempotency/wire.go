@@ -0,0 +1,12 @@
+package cidempotency
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup. Apps must also provide Users, or bind Anonymous if their
+// idempotent routes are not tied to users.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+	NewMemoryStore,
+	NewMiddleware,
+	wire.Struct(new(NewMiddlewareParams), "*"),
+)
@@ -2,6 +2,8 @@ package clifecycle
 
 import (
 	"context"
+	"runtime"
+	"sync"
 	"time"
 )
 
@@ -13,6 +15,7 @@ func New() *Lifecycle {
 	return &Lifecycle{
 		onStop:      make([]func(ctx context.Context) error, 0),
 		stopTimeout: defaultStopTimeout,
+		createdAt:   time.Now(),
 	}
 }
 
@@ -22,28 +25,119 @@ func New() *Lifecycle {
 // Packages such as chttp use Lifecycle to gracefully stop the HTTP
 // server before the app exits.
 type Lifecycle struct {
+	mu          sync.Mutex
 	onStop      []func(ctx context.Context) error
+	hooks       []Hook
 	stopTimeout time.Duration
+	createdAt   time.Time
+}
+
+// Hook describes a stop func registered using OnStop. Hooks are used to diagnose the app's startup and shutdown.
+type Hook struct {
+	// Name is the func that registered the hook (ex. github.com/gocopper/copper/csql.NewDBConnection)
+	Name string
+
+	// RegisteredAfter is the time between the creation of the lifecycle and the registration of the hook. Since the
+	// lifecycle is one of the first dependencies created, it shows how long the app took to reach the func that
+	// registered the hook.
+	RegisteredAfter time.Duration
+
+	// Stopped is set once the hook has run along with its duration and error
+	Stopped      bool
+	StopDuration time.Duration
+	StopErr      error
 }
 
 // OnStop registers the provided fn to run before the app exits. The fn
 // is given a context with a deadline. Once the deadline expires, the
 // app may exit forcefully.
 func (lc *Lifecycle) OnStop(fn func(ctx context.Context) error) {
+	hook := Hook{
+		Name:            callerName(),
+		RegisteredAfter: time.Since(lc.createdAt),
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
 	lc.onStop = append(lc.onStop, fn)
+	lc.hooks = append(lc.hooks, hook)
+}
+
+// Hooks returns the registered stop funcs in the order they were registered
+func (lc *Lifecycle) Hooks() []Hook {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	hooks := make([]Hook, len(lc.hooks))
+	copy(hooks, lc.hooks)
+
+	return hooks
 }
 
 // Stop runs all of the registered stop funcs in order along with a
 // context with a configured timeout.
 func (lc *Lifecycle) Stop(logger Logger) {
-	for _, fn := range lc.onStop {
+	lc.mu.Lock()
+	onStop := lc.onStop
+	lc.mu.Unlock()
+
+	for i, fn := range onStop {
 		ctx, cancel := context.WithTimeout(context.Background(), lc.stopTimeout)
 
+		start := time.Now()
+
 		err := fn(ctx)
 		if err != nil {
 			logger.Error("Failed to run cleanup func", err)
 		}
 
 		cancel()
+
+		lc.mu.Lock()
+		lc.hooks[i].Stopped = true
+		lc.hooks[i].StopDuration = time.Since(start)
+		lc.hooks[i].StopErr = err
+		lc.mu.Unlock()
 	}
 }
+
+// callerName returns the name of the func that called OnStop. Closures are reported as the func they are defined in.
+func callerName() string {
+	pc, _, _, ok := runtime.Caller(2) //nolint:gomnd
+	if !ok {
+		return "unknown"
+	}
+
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "unknown"
+	}
+
+	name := fn.Name()
+
+	for {
+		i := lastIndexOfFuncSuffix(name)
+		if i == -1 {
+			return name
+		}
+
+		name = name[:i]
+	}
+}
+
+// lastIndexOfFuncSuffix returns the index of a closure's suffix (ex. .func1) in name or -1 if it has none
+func lastIndexOfFuncSuffix(name string) int {
+	i := len(name)
+	for i > 0 && name[i-1] >= '0' && name[i-1] <= '9' {
+		i--
+	}
+
+	const suffix = ".func"
+
+	if i == len(name) || i < len(suffix) || name[i-len(suffix):i] != suffix {
+		return -1
+	}
+
+	return i - len(suffix)
+}
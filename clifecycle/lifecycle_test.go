@@ -0,0 +1,40 @@
+package clifecycle_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func registerHook(lc *clifecycle.Lifecycle, err error) {
+	lc.OnStop(func(ctx context.Context) error {
+		return err
+	})
+}
+
+func TestLifecycle_Hooks(t *testing.T) {
+	t.Parallel()
+
+	lc := clifecycle.New()
+
+	registerHook(lc, nil)
+	registerHook(lc, errors.New("test-err"))
+
+	hooks := lc.Hooks()
+	assert.Len(t, hooks, 2)
+	assert.Equal(t, "github.com/gocopper/copper/clifecycle_test.registerHook", hooks[0].Name)
+	assert.False(t, hooks[0].Stopped)
+	assert.True(t, hooks[0].RegisteredAfter <= hooks[1].RegisteredAfter)
+
+	lc.Stop(clogger.NewNoop())
+
+	hooks = lc.Hooks()
+	assert.True(t, hooks[0].Stopped)
+	assert.NoError(t, hooks[0].StopErr)
+	assert.True(t, hooks[1].Stopped)
+	assert.EqualError(t, hooks[1].StopErr, "test-err")
+}
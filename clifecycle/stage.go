@@ -0,0 +1,39 @@
+package clifecycle
+
+import "time"
+
+// Names of the startup stages used by Copper's runners. The app's dependencies (ex. the database connection, which is
+// pinged with retries by csql.NewDBConnection) are created before any stage starts, so the stages order what runs
+// once the app is wired: migrations, then the servers that accept requests, then the background workers.
+const (
+	StageMigrations = "migrations"
+	StageSeeds      = "seeds"
+	StageHTTP       = "http"
+	StageWorkers    = "workers"
+)
+
+// Stage describes when and how a runner is started by the app (see copper.App.StartRunners)
+type Stage struct {
+	// Name of the stage. Runners that share a name are part of the same stage, which completes once all of them have
+	// started.
+	Name string
+
+	// After are the stages that must complete before this one starts. Stages that none of the app's runners are part
+	// of are ignored, so a worker process that does not run the http stage still starts its workers.
+	After []string
+
+	// Timeout is how long the stage's runner has to start, including its retries. If it is 0, the app's default
+	// startup timeout is used.
+	Timeout time.Duration
+
+	// Retries is how many more times the runner is started if it fails (ex. when an external dependency is not
+	// reachable yet). The wait between each attempt starts at Backoff and doubles.
+	Retries int
+	Backoff time.Duration
+}
+
+// Staged is implemented by runners that declare their startup stage. Runners that do not implement it are started
+// in the order they are given, without dependencies.
+type Staged interface {
+	Stage() Stage
+}
@@ -0,0 +1,208 @@
+package clogger
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/jmoiron/sqlx"
+)
+
+// Outcomes of an AuditEvent
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+const auditFilePerms = 0600
+
+var auditTableNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`) //nolint:gochecknoglobals
+
+// AuditEvent is a security-relevant event such as a login, a permission change, or an export of user data
+type AuditEvent struct {
+	ID      string                 `json:"id"`
+	Time    time.Time              `json:"time"`
+	Action  string                 `json:"action"`
+	Actor   string                 `json:"actor,omitempty"`
+	Target  string                 `json:"target,omitempty"`
+	Outcome string                 `json:"outcome,omitempty"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// AuditSink durably stores audit events. WriteAudit must only return once the event is persisted.
+type AuditSink interface {
+	WriteAudit(ctx context.Context, event AuditEvent) error
+	Close() error
+}
+
+// NewAuditLogger creates an AuditLogger that writes to the given sink
+func NewAuditLogger(sink AuditSink) *AuditLogger {
+	return &AuditLogger{sink: sink}
+}
+
+// AuditLogger writes audit events to a dedicated sink. Unlike Logger, which is best-effort, each call to Log blocks
+// until the event is persisted and returns an error if it could not be. Callers should treat that error as a failure
+// of the audited operation.
+type AuditLogger struct {
+	sink AuditSink
+}
+
+// Log persists the event. The event's ID and Time are set if empty, and fields from the context (ex. request and
+// trace ids added by chttp) are attached to it.
+func (l *AuditLogger) Log(ctx context.Context, event AuditEvent) error {
+	if event.Action == "" {
+		return cerrors.New(nil, "audit event action is required", nil)
+	}
+
+	if event.ID == "" {
+		event.ID = newAuditEventID()
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	event.Fields = mergeTags(FieldsFromCtx(ctx), event.Fields)
+
+	err := l.sink.WriteAudit(ctx, event)
+	if err != nil {
+		return cerrors.New(err, "failed to write audit event", map[string]interface{}{
+			"action": event.Action,
+		})
+	}
+
+	return nil
+}
+
+// Close closes the underlying sink
+func (l *AuditLogger) Close() error {
+	return l.sink.Close()
+}
+
+// NewAuditFileSink creates an AuditSink that appends events as JSON lines to the file at path. Each write is
+// fsync'd before it returns.
+func NewAuditFileSink(path string) (AuditSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, auditFilePerms)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to open audit log file", map[string]interface{}{
+			"path": path,
+		})
+	}
+
+	return &auditFileSink{file: f}, nil
+}
+
+type auditFileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func (s *auditFileSink) WriteAudit(_ context.Context, event AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return cerrors.New(err, "failed to encode audit event", nil)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.file.Write(append(line, '\n'))
+	if err != nil {
+		return cerrors.New(err, "failed to write audit event to file", nil)
+	}
+
+	err = s.file.Sync()
+	if err != nil {
+		return cerrors.New(err, "failed to sync audit log file", nil)
+	}
+
+	return nil
+}
+
+func (s *auditFileSink) Close() error {
+	return s.file.Close()
+}
+
+// NewAuditSQLSink creates an AuditSink that inserts events into the given table. The table is created if it does not
+// exist. Each event is inserted in its own transaction that is committed before WriteAudit returns. The dialect
+// (ex. postgres, sqlite3, mysql) is used to pick the query placeholder style.
+func NewAuditSQLSink(ctx context.Context, db *sql.DB, dialect, table string) (AuditSink, error) {
+	if !auditTableNameRegexp.MatchString(table) {
+		return nil, cerrors.New(nil, "invalid audit table name", map[string]interface{}{
+			"table": table,
+		})
+	}
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id VARCHAR(64) PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	action VARCHAR(255) NOT NULL,
+	actor VARCHAR(255) NOT NULL,
+	target VARCHAR(255) NOT NULL,
+	outcome VARCHAR(32) NOT NULL,
+	fields TEXT NOT NULL
+)`, table))
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create audit table", map[string]interface{}{
+			"table": table,
+		})
+	}
+
+	return &auditSQLSink{
+		db: db,
+		query: sqlx.Rebind(sqlx.BindType(dialect), fmt.Sprintf(
+			"INSERT INTO %s (id, created_at, action, actor, target, outcome, fields) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			table,
+		)),
+	}, nil
+}
+
+type auditSQLSink struct {
+	db    *sql.DB
+	query string
+}
+
+func (s *auditSQLSink) WriteAudit(ctx context.Context, event AuditEvent) error {
+	fields, err := json.Marshal(event.Fields)
+	if err != nil {
+		return cerrors.New(err, "failed to encode audit event fields", nil)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return cerrors.New(err, "failed to begin audit tx", nil)
+	}
+
+	_, err = tx.ExecContext(ctx, s.query,
+		event.ID, event.Time, event.Action, event.Actor, event.Target, event.Outcome, string(fields))
+	if err != nil {
+		_ = tx.Rollback()
+		return cerrors.New(err, "failed to insert audit event", nil)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return cerrors.New(err, "failed to commit audit event", nil)
+	}
+
+	return nil
+}
+
+func (s *auditSQLSink) Close() error {
+	return nil
+}
+
+func newAuditEventID() string {
+	b := make([]byte, 16) //nolint:gomnd
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,94 @@
+package clogger_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gocopper/copper/clogger"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLogger_File(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	sink, err := clogger.NewAuditFileSink(path)
+	assert.NoError(t, err)
+
+	var (
+		audit = clogger.NewAuditLogger(sink)
+		ctx   = clogger.CtxWithFields(context.Background(), map[string]interface{}{"requestID": "req-1"})
+	)
+
+	err = audit.Log(ctx, clogger.AuditEvent{
+		Action:  "user.login",
+		Actor:   "user-1",
+		Outcome: clogger.AuditOutcomeSuccess,
+		Fields:  map[string]interface{}{"ip": "127.0.0.1"},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, audit.Close())
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	var event clogger.AuditEvent
+
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(string(data))), &event))
+	assert.NotEmpty(t, event.ID)
+	assert.False(t, event.Time.IsZero())
+	assert.Equal(t, "user.login", event.Action)
+	assert.Equal(t, "user-1", event.Actor)
+	assert.Equal(t, map[string]interface{}{"ip": "127.0.0.1", "requestID": "req-1"}, event.Fields)
+}
+
+func TestAuditLogger_MissingAction(t *testing.T) {
+	t.Parallel()
+
+	sink, err := clogger.NewAuditFileSink(filepath.Join(t.TempDir(), "audit.log"))
+	assert.NoError(t, err)
+
+	err = clogger.NewAuditLogger(sink).Log(context.Background(), clogger.AuditEvent{})
+	assert.Error(t, err)
+}
+
+func TestAuditLogger_SQL(t *testing.T) {
+	t.Parallel()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+
+	db.SetMaxOpenConns(1)
+
+	sink, err := clogger.NewAuditSQLSink(context.Background(), db, "sqlite3", "audit_events")
+	assert.NoError(t, err)
+
+	err = clogger.NewAuditLogger(sink).Log(context.Background(), clogger.AuditEvent{
+		Action: "role.granted",
+		Actor:  "admin-1",
+		Target: "user-1",
+	})
+	assert.NoError(t, err)
+
+	var action, target, fields string
+
+	err = db.QueryRow("SELECT action, target, fields FROM audit_events").Scan(&action, &target, &fields)
+	assert.NoError(t, err)
+	assert.Equal(t, "role.granted", action)
+	assert.Equal(t, "user-1", target)
+	assert.Equal(t, "{}", fields)
+}
+
+func TestNewAuditSQLSink_InvalidTable(t *testing.T) {
+	t.Parallel()
+
+	_, err := clogger.NewAuditSQLSink(context.Background(), nil, "sqlite3", "audit; DROP TABLE users")
+	assert.Error(t, err)
+}
@@ -0,0 +1,2 @@
+// Package cloggertest provides a clogger.Logger implementation that records logs so tests can assert on them
+package cloggertest
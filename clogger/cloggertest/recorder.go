@@ -0,0 +1,167 @@
+package cloggertest
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/gocopper/copper/clogger"
+)
+
+// Entry is a single log captured by Recorder
+type Entry struct {
+	Level   clogger.Level
+	Message string
+	Fields  map[string]interface{}
+	Error   error
+}
+
+// NewRecorder creates a Recorder with no entries. Pass it to the module under test as its clogger.Logger.
+func NewRecorder() *Recorder {
+	return &Recorder{
+		log:    &recorderLog{},
+		fields: make(map[string]interface{}),
+	}
+}
+
+// Recorder is a clogger.Logger that keeps every log in memory. Loggers derived from it using With or WithFields
+// record into the same set of entries, so a module's logs can be asserted on from the Recorder passed to it.
+type Recorder struct {
+	log    *recorderLog
+	fields map[string]interface{}
+}
+
+type recorderLog struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// WithFields returns a Logger that records the given fields with every log
+func (r *Recorder) WithFields(fields map[string]interface{}) clogger.Logger {
+	merged := make(map[string]interface{}, len(r.fields)+len(fields))
+
+	for k, v := range r.fields {
+		merged[k] = v
+	}
+
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	return &Recorder{
+		log:    r.log,
+		fields: merged,
+	}
+}
+
+// With returns a Logger that records the given fields with every log
+func (r *Recorder) With(fields ...clogger.Field) clogger.Logger {
+	m := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		m[f.Key] = f.Value
+	}
+
+	return r.WithFields(m)
+}
+
+// WithTags is an alias of WithFields
+func (r *Recorder) WithTags(tags map[string]interface{}) clogger.Logger {
+	return r.WithFields(tags)
+}
+
+// Debug records a debug log
+func (r *Recorder) Debug(msg string) {
+	r.record(clogger.LevelDebug, msg, nil)
+}
+
+// Info records an info log
+func (r *Recorder) Info(msg string) {
+	r.record(clogger.LevelInfo, msg, nil)
+}
+
+// Warn records a warn log
+func (r *Recorder) Warn(msg string, err error) {
+	r.record(clogger.LevelWarn, msg, err)
+}
+
+// Error records an error log
+func (r *Recorder) Error(msg string, err error) {
+	r.record(clogger.LevelError, msg, err)
+}
+
+// Entries returns the recorded entries with the given levels in the order they were logged. If no levels are given,
+// all entries are returned.
+func (r *Recorder) Entries(levels ...clogger.Level) []Entry {
+	r.log.mu.Lock()
+	defer r.log.mu.Unlock()
+
+	entries := make([]Entry, 0, len(r.log.entries))
+
+	for _, e := range r.log.entries {
+		if len(levels) > 0 && !hasLevel(levels, e.Level) {
+			continue
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+// Messages returns the messages of all recorded entries in the order they were logged
+func (r *Recorder) Messages() []string {
+	entries := r.Entries()
+	messages := make([]string, len(entries))
+
+	for i := range entries {
+		messages[i] = entries[i].Message
+	}
+
+	return messages
+}
+
+// ContainsMessage returns true if any recorded entry's message contains msg
+func (r *Recorder) ContainsMessage(msg string) bool {
+	_, ok := r.FindMessage(msg)
+	return ok
+}
+
+// FindMessage returns the first recorded entry whose message contains msg
+func (r *Recorder) FindMessage(msg string) (Entry, bool) {
+	for _, e := range r.Entries() {
+		if strings.Contains(e.Message, msg) {
+			return e, true
+		}
+	}
+
+	return Entry{}, false
+}
+
+// Reset removes all recorded entries
+func (r *Recorder) Reset() {
+	r.log.mu.Lock()
+	defer r.log.mu.Unlock()
+
+	r.log.entries = nil
+}
+
+func (r *Recorder) record(lvl clogger.Level, msg string, err error) {
+	r.log.mu.Lock()
+	defer r.log.mu.Unlock()
+
+	r.log.entries = append(r.log.entries, Entry{
+		Level:   lvl,
+		Message: msg,
+		Fields:  r.fields,
+		Error:   err,
+	})
+}
+
+func hasLevel(levels []clogger.Level, lvl clogger.Level) bool {
+	for _, l := range levels {
+		if l == lvl {
+			return true
+		}
+	}
+
+	return false
+}
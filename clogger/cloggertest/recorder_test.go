@@ -0,0 +1,41 @@
+package cloggertest_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/clogger/cloggertest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	var (
+		recorder                = cloggertest.NewRecorder()
+		logger   clogger.Logger = recorder
+		testErr                 = errors.New("test-err")
+	)
+
+	logger.Info("server started")
+	logger.With(clogger.String("job", "send-email")).Error("job failed", testErr)
+	logger.WithFields(map[string]interface{}{"attempt": 2}).Warn("retrying job", testErr)
+
+	assert.Equal(t, []string{"server started", "job failed", "retrying job"}, recorder.Messages())
+	assert.Len(t, recorder.Entries(clogger.LevelError, clogger.LevelWarn), 2)
+	assert.True(t, recorder.ContainsMessage("job failed"))
+	assert.False(t, recorder.ContainsMessage("server stopped"))
+
+	entry, ok := recorder.FindMessage("job failed")
+	assert.True(t, ok)
+	assert.Equal(t, cloggertest.Entry{
+		Level:   clogger.LevelError,
+		Message: "job failed",
+		Fields:  map[string]interface{}{"job": "send-email"},
+		Error:   testErr,
+	}, entry)
+
+	recorder.Reset()
+	assert.Empty(t, recorder.Entries())
+}
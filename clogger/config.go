@@ -1,6 +1,8 @@
 package clogger
 
 import (
+	"time"
+
 	"github.com/gocopper/copper/cconfig"
 	"github.com/gocopper/copper/cerrors"
 )
@@ -14,6 +16,14 @@ const (
 	FormatJSON  = Format("json")
 )
 
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "clogger",
+		Description: "clogger configures the app's logs",
+		Default:     Config{Format: FormatPlain},
+	})
+}
+
 // LoadConfig loads Config from app's config
 func LoadConfig(appConfig cconfig.Loader) (Config, error) {
 	var config Config
@@ -27,12 +37,134 @@ func LoadConfig(appConfig cconfig.Loader) (Config, error) {
 		config.Format = FormatPlain
 	}
 
+	_, err = NewLevels(config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "invalid log levels in clogger config", nil)
+	}
+
+	_, err = NewRedactor(config.Redact)
+	if err != nil {
+		return Config{}, cerrors.New(err, "invalid redaction config in clogger config", nil)
+	}
+
 	return config, nil
 }
 
 // Config holds the params needed to configure Logger
 type Config struct {
-	Out    string `toml:"out"`
-	Err    string `toml:"err"`
+	Out    string `toml:"out" doc:"File that debug and info logs are written to (default: stdout)"`
+	Err    string `toml:"err" doc:"File that warn and error logs are written to (default: stderr)"`
+	Format Format `toml:"format" doc:"plain or json"`
+
+	// Level is the minimum level (debug, info, warn, or error) of logs that are written. Defaults to debug.
+	Level string `toml:"level"`
+
+	// Modules overrides Level for the given modules (ex. csql = "warn"). See Module.
+	Modules map[string]string `toml:"modules"`
+
+	// Sinks configures the destinations logs are written to. If empty, logs are written to Out and Err.
+	Sinks []ConfigSink `toml:"sinks"`
+
+	// Sampling limits how often repetitive logs are written. It is disabled by default.
+	Sampling ConfigSampling `toml:"sampling"`
+
+	// Redact masks sensitive data such as passwords and card numbers in logs. See Redactor.
+	Redact ConfigRedact `toml:"redact"`
+}
+
+// ConfigRedact configures the Redactor used by Logger. By default, DefaultRedactKeys and DefaultRedactPatterns are
+// redacted. Apps can redact additional field names and patterns.
+//
+//	[clogger.redact]
+//	keys = ["dob", "phone"]
+//	patterns = ['\d{3}-\d{2}-\d{4}']
+type ConfigRedact struct {
+	// Keys are additional field names that are redacted
+	Keys []string `toml:"keys"`
+
+	// Patterns are additional regular expressions that are masked in messages, errors, and string values
+	Patterns []string `toml:"patterns"`
+
+	// DisableDefaults disables DefaultRedactKeys and DefaultRedactPatterns
+	DisableDefaults bool `toml:"disable_defaults"`
+}
+
+// ConfigSampling configures log sampling. Logs with the same level and message are sampled together regardless of
+// their fields. In each interval, the first First logs are written and then every Thereafter-th log. The number of
+// suppressed logs is written as a summary log ("N similar logs suppressed") once the interval ends.
+//
+//	[clogger.sampling]
+//	interval = "1s"
+//	first = 10
+//	thereafter = 100
+type ConfigSampling struct {
+	// Interval is the sampling window. Zero disables sampling.
+	Interval time.Duration `toml:"interval"`
+
+	// First is the number of logs that are always written in each interval
+	First int `toml:"first"`
+
+	// Thereafter writes every Thereafter-th log after First. Zero suppresses all logs after First.
+	Thereafter int `toml:"thereafter"`
+}
+
+// Sink types supported by ConfigSink
+const (
+	SinkTypeStdout = "stdout"
+	SinkTypeStderr = "stderr"
+	SinkTypeFile   = "file"
+	SinkTypeSyslog = "syslog"
+	SinkTypeLoki   = "loki"
+	SinkTypeOTLP   = "otlp"
+	SinkTypeSentry = "sentry"
+)
+
+// ConfigSink configures a single log sink. Only the options relevant to the sink's Type are used.
+//
+//	[[clogger.sinks]]
+//	type = "file"
+//	path = "/var/log/app.log"
+//	max_size_mb = 100
+//	max_backups = 5
+//
+//	[[clogger.sinks]]
+//	type = "loki"
+//	url = "http://loki:3100/loki/api/v1/push"
+//	labels = { app = "myapp" }
+//
+//	[[clogger.sinks]]
+//	type = "sentry"
+//	dsn = "https://key@o0.ingest.sentry.io/0"
+//	environment = "production"
+type ConfigSink struct {
+	// Type is one of stdout, stderr, file, syslog, loki, otlp, or sentry
+	Type string `toml:"type"`
+
+	// Format overrides Config.Format for the stdout, stderr, file, and syslog sinks
 	Format Format `toml:"format"`
+
+	// File sink options. See RotatingFileConfig.
+	Path        string        `toml:"path"`
+	MaxSizeMB   int           `toml:"max_size_mb"`
+	RotateEvery time.Duration `toml:"rotate_every"`
+	MaxBackups  int           `toml:"max_backups"`
+	MaxAge      time.Duration `toml:"max_age"`
+
+	// Syslog sink options. An empty Network and Address logs to the local syslog daemon.
+	Network string `toml:"network"`
+	Address string `toml:"address"`
+	Tag     string `toml:"tag"`
+
+	// Loki and OTLP sink options. See HTTPSinkConfig.
+	URL           string            `toml:"url"`
+	Headers       map[string]string `toml:"headers"`
+	Labels        map[string]string `toml:"labels"`
+	BatchSize     int               `toml:"batch_size"`
+	FlushInterval time.Duration     `toml:"flush_interval"`
+
+	// Sentry sink options. See SentryConfig. The sentry sink is disabled if DSN is empty, so it can be configured in
+	// the base config and enabled only by setting the dsn in the production config.
+	DSN         string `toml:"dsn"`
+	Release     string `toml:"release"`
+	Environment string `toml:"environment"`
 }
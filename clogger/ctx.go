@@ -0,0 +1,107 @@
+package clogger
+
+import (
+	"context"
+	"runtime/debug"
+)
+
+type ctxKey string
+
+const ctxLoggerKey = ctxKey("clogger/logger")
+
+type ctxLogger struct {
+	logger Logger
+	fields map[string]interface{}
+}
+
+// CtxWithLogger returns a context that holds the given logger. Use FromCtx to retrieve it along with any fields added
+// to the context using CtxWithFields.
+func CtxWithLogger(ctx context.Context, logger Logger) context.Context {
+	existing, _ := ctx.Value(ctxLoggerKey).(ctxLogger)
+
+	return context.WithValue(ctx, ctxLoggerKey, ctxLogger{
+		logger: logger,
+		fields: existing.fields,
+	})
+}
+
+// CtxWithFields returns a context with the given fields added to the existing context fields. Any logger retrieved
+// using FromCtx includes these fields. For example, chttp adds the request and trace id to each request's context
+// and an auth middleware may add the authenticated user's uuid.
+func CtxWithFields(ctx context.Context, fields map[string]interface{}) context.Context {
+	existing, _ := ctx.Value(ctxLoggerKey).(ctxLogger)
+
+	return context.WithValue(ctx, ctxLoggerKey, ctxLogger{
+		logger: existing.logger,
+		fields: mergeTags(existing.fields, fields),
+	})
+}
+
+// FieldsFromCtx returns the fields added to the context using CtxWithFields
+func FieldsFromCtx(ctx context.Context) map[string]interface{} {
+	existing, _ := ctx.Value(ctxLoggerKey).(ctxLogger)
+
+	return mergeTags(existing.fields, nil)
+}
+
+// FromCtx returns the logger in the context (see CtxWithLogger) with the context fields attached to it (see
+// CtxWithFields). If the context does not have a logger, a console logger is returned so that logs are not lost.
+func FromCtx(ctx context.Context) Logger {
+	existing, _ := ctx.Value(ctxLoggerKey).(ctxLogger)
+
+	logger := existing.logger
+	if logger == nil {
+		logger = New()
+	}
+
+	return logger.WithFields(existing.fields)
+}
+
+// LogSnapshot is a copy of a context's logger and fields. It can be used to keep async work spawned from a request
+// (ex. goroutines or queued jobs) correlated with the request's logs. Fields can be serialized (ex. in a job's
+// payload) and restored using CtxWithFields.
+type LogSnapshot struct {
+	Fields map[string]interface{} `json:"fields"`
+
+	logger Logger
+}
+
+// SnapshotFromCtx captures the logger and fields in the context
+func SnapshotFromCtx(ctx context.Context) LogSnapshot {
+	existing, _ := ctx.Value(ctxLoggerKey).(ctxLogger)
+
+	return LogSnapshot{
+		Fields: mergeTags(existing.fields, nil),
+		logger: existing.logger,
+	}
+}
+
+// Ctx returns a context derived from parent that holds the snapshot's logger and fields. Unlike the context the
+// snapshot was taken from, the returned context is not cancelled when the request ends and does not carry any of the
+// request's other values (ex. a database transaction).
+func (s LogSnapshot) Ctx(parent context.Context) context.Context {
+	if s.logger != nil {
+		parent = CtxWithLogger(parent, s.logger)
+	}
+
+	return CtxWithFields(parent, s.Fields)
+}
+
+// Go runs fn in a new goroutine with a background context that holds the logging context of ctx. Panics in fn are
+// recovered and logged with the same context so they can be traced back to the request that spawned the goroutine.
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	bgCtx := SnapshotFromCtx(ctx).Ctx(context.Background())
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				FromCtx(bgCtx).WithFields(map[string]interface{}{
+					"panic": r,
+					"stack": string(debug.Stack()),
+				}).Error("Recovered from a panic in background goroutine", nil)
+			}
+		}()
+
+		fn(bgCtx)
+	}()
+}
@@ -0,0 +1,96 @@
+package clogger_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/clogger/cloggertest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromCtx(t *testing.T) {
+	t.Parallel()
+
+	var (
+		logs   = make([]clogger.RecordedLog, 0)
+		logger = clogger.NewRecorder(&logs)
+		ctx    = context.Background()
+	)
+
+	ctx = clogger.CtxWithFields(ctx, map[string]interface{}{"requestID": "r1"})
+	ctx = clogger.CtxWithLogger(ctx, logger)
+	ctx = clogger.CtxWithFields(ctx, map[string]interface{}{"userUUID": "u1"})
+
+	clogger.FromCtx(ctx).Info("test info log")
+
+	assert.Len(t, logs, 1)
+	assert.Equal(t, map[string]interface{}{
+		"requestID": "r1",
+		"userUUID":  "u1",
+	}, logs[0].Tags)
+	assert.Equal(t, logs[0].Tags, clogger.FieldsFromCtx(ctx))
+}
+
+func TestFromCtx_NoLogger(t *testing.T) {
+	t.Parallel()
+
+	assert.NotNil(t, clogger.FromCtx(context.Background()))
+}
+
+func TestSnapshotFromCtx(t *testing.T) {
+	t.Parallel()
+
+	var (
+		logs   = make([]clogger.RecordedLog, 0)
+		logger = clogger.NewRecorder(&logs)
+	)
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	reqCtx = clogger.CtxWithLogger(reqCtx, logger)
+	reqCtx = clogger.CtxWithFields(reqCtx, map[string]interface{}{"requestID": "r1"})
+
+	snapshot := clogger.SnapshotFromCtx(reqCtx)
+	cancel()
+
+	ctx := snapshot.Ctx(context.Background())
+	assert.NoError(t, ctx.Err())
+
+	clogger.FromCtx(ctx).Info("test info log")
+
+	assert.Len(t, logs, 1)
+	assert.Equal(t, map[string]interface{}{"requestID": "r1"}, logs[0].Tags)
+}
+
+func TestGo(t *testing.T) {
+	t.Parallel()
+
+	var (
+		recorder = cloggertest.NewRecorder()
+		done     = make(chan struct{})
+		ctx      = clogger.CtxWithFields(
+			clogger.CtxWithLogger(context.Background(), recorder),
+			map[string]interface{}{"requestID": "r1"},
+		)
+	)
+
+	clogger.Go(ctx, func(ctx context.Context) {
+		defer close(done)
+
+		clogger.FromCtx(ctx).Info("sending email")
+		panic("smtp down")
+	})
+
+	<-done
+
+	assert.Eventually(t, func() bool {
+		return len(recorder.Entries()) == 2
+	}, time.Second, time.Millisecond)
+
+	entries := recorder.Entries()
+	assert.Equal(t, "r1", entries[0].Fields["requestID"])
+	assert.Equal(t, clogger.LevelError, entries[1].Level)
+	assert.Equal(t, "r1", entries[1].Fields["requestID"])
+	assert.Equal(t, "smtp down", entries[1].Fields["panic"])
+}
@@ -0,0 +1,71 @@
+package clogger
+
+import (
+	"time"
+)
+
+// Field is a single structured key-value pair that is attached to a log. Use the typed helpers (String, Int,
+// Err, etc.) to create fields and attach them to a Logger using With.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// String creates a Field with a string value
+func String(key, val string) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Int creates a Field with an int value
+func Int(key string, val int) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Int64 creates a Field with an int64 value
+func Int64(key string, val int64) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Float64 creates a Field with a float64 value
+func Float64(key string, val float64) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Bool creates a Field with a bool value
+func Bool(key string, val bool) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Duration creates a Field with a time.Duration value. The duration is logged in its human-readable form (ex. 1.5s).
+func Duration(key string, val time.Duration) Field {
+	return Field{Key: key, Value: val.String()}
+}
+
+// Time creates a Field with a time.Time value. The time is logged in RFC3339 format.
+func Time(key string, val time.Time) Field {
+	return Field{Key: key, Value: val.Format(time.RFC3339Nano)}
+}
+
+// Err creates a Field with the "error" key. Prefer the err param of Warn and Error to log the primary error of a
+// log. Use Err to attach a secondary error.
+func Err(err error) Field {
+	if err == nil {
+		return Field{Key: "error", Value: nil}
+	}
+
+	return Field{Key: "error", Value: err.Error()}
+}
+
+// Any creates a Field with an arbitrary value
+func Any(key string, val interface{}) Field {
+	return Field{Key: key, Value: val}
+}
+
+func fieldsToMap(fields []Field) map[string]interface{} {
+	m := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		m[f.Key] = f.Value
+	}
+
+	return m
+}
@@ -0,0 +1,27 @@
+package clogger_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFields(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	assert.Equal(t, clogger.Field{Key: "k", Value: "v"}, clogger.String("k", "v"))
+	assert.Equal(t, clogger.Field{Key: "k", Value: 1}, clogger.Int("k", 1))
+	assert.Equal(t, clogger.Field{Key: "k", Value: int64(1)}, clogger.Int64("k", 1))
+	assert.Equal(t, clogger.Field{Key: "k", Value: 1.5}, clogger.Float64("k", 1.5))
+	assert.Equal(t, clogger.Field{Key: "k", Value: true}, clogger.Bool("k", true))
+	assert.Equal(t, clogger.Field{Key: "k", Value: "1.5s"}, clogger.Duration("k", 1500*time.Millisecond))
+	assert.Equal(t, clogger.Field{Key: "k", Value: "2022-01-02T03:04:05Z"}, clogger.Time("k", ts))
+	assert.Equal(t, clogger.Field{Key: "error", Value: "test-err"}, clogger.Err(errors.New("test-err"))) //nolint:goerr113
+	assert.Equal(t, clogger.Field{Key: "error", Value: nil}, clogger.Err(nil))
+	assert.Equal(t, clogger.Field{Key: "k", Value: []int{1}}, clogger.Any("k", []int{1}))
+}
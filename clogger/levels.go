@@ -0,0 +1,222 @@
+package clogger
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// ModuleKey is the field key used to identify the module (ex. csql, chttp) that a log belongs to. The log level of
+// a Logger can be overridden per module using this field. Copper's packages tag their loggers with their package
+// name. See Module.
+const ModuleKey = "module"
+
+// Module creates a field that identifies the module a Logger belongs to. For example,
+// logger.With(clogger.Module("csql")) returns a Logger whose level can be configured using:
+//
+//	[clogger.modules]
+//	csql = "warn"
+func Module(name string) Field {
+	return Field{Key: ModuleKey, Value: name}
+}
+
+// ParseLevel parses a level name (debug, info, warn, or error) into a Level
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return 0, cerrors.New(nil, "invalid log level", map[string]interface{}{
+			"level": name,
+		})
+	}
+}
+
+// NewLevels creates Levels with the global and per-module levels set in the config
+func NewLevels(config Config) (*Levels, error) {
+	var lv Levels
+
+	err := lv.Load(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lv, nil
+}
+
+// Levels holds the minimum log level globally and per-module. Logs below the configured level are dropped. Levels is
+// safe for concurrent use and can be changed at runtime (see ServeHTTP and ReloadOnSIGHUP).
+type Levels struct {
+	mu      sync.RWMutex
+	level   Level
+	modules map[string]Level
+}
+
+// Load replaces the global and per-module levels with the ones set in the config
+func (lv *Levels) Load(config Config) error {
+	level := LevelDebug
+
+	if config.Level != "" {
+		var err error
+
+		level, err = ParseLevel(config.Level)
+		if err != nil {
+			return err
+		}
+	}
+
+	modules := make(map[string]Level, len(config.Modules))
+
+	for module, name := range config.Modules {
+		moduleLevel, err := ParseLevel(name)
+		if err != nil {
+			return cerrors.New(err, "invalid module log level", map[string]interface{}{
+				"module": module,
+			})
+		}
+
+		modules[module] = moduleLevel
+	}
+
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+
+	lv.level = level
+	lv.modules = modules
+
+	return nil
+}
+
+// SetLevel sets the global log level
+func (lv *Levels) SetLevel(level Level) {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+
+	lv.level = level
+}
+
+// SetModuleLevel overrides the log level for the given module
+func (lv *Levels) SetModuleLevel(module string, level Level) {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+
+	if lv.modules == nil {
+		lv.modules = make(map[string]Level)
+	}
+
+	lv.modules[module] = level
+}
+
+// Enabled returns true if a log at the given level should be written for the given module
+func (lv *Levels) Enabled(module string, level Level) bool {
+	lv.mu.RLock()
+	defer lv.mu.RUnlock()
+
+	if moduleLevel, ok := lv.modules[module]; ok && module != "" {
+		return level >= moduleLevel
+	}
+
+	return level >= lv.level
+}
+
+// Config returns the current levels in the same format as Config
+func (lv *Levels) Config() Config {
+	lv.mu.RLock()
+	defer lv.mu.RUnlock()
+
+	config := Config{
+		Level:   strings.ToLower(lv.level.String()),
+		Modules: make(map[string]string, len(lv.modules)),
+	}
+
+	for module, level := range lv.modules {
+		config.Modules[module] = strings.ToLower(level.String())
+	}
+
+	return config
+}
+
+// ServeHTTP can be mounted as a (protected) HTTP endpoint to view and change log levels at runtime. Apps that use
+// cadmin can use its LogLevelsPanel instead. A GET request
+// returns the current levels. A PUT or POST request with a JSON body such as {"level": "info", "modules":
+// {"csql": "debug"}} replaces the levels.
+func (lv *Levels) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var body struct {
+			Level   string            `json:"level"`
+			Modules map[string]string `json:"modules"`
+		}
+
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+
+		err = lv.Load(Config{Level: body.Level, Modules: body.Modules})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	config := lv.Config()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"level":   config.Level,
+		"modules": config.Modules,
+	})
+}
+
+// ReloadOnSIGHUP reloads the levels using the provided load func each time the process receives a SIGHUP signal.
+// The returned func stops listening for the signal.
+func (lv *Levels) ReloadOnSIGHUP(load func() (Config, error), logger Logger) func() {
+	sig := make(chan os.Signal, 1)
+	done := make(chan struct{})
+
+	signal.Notify(sig, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-sig:
+				config, err := load()
+				if err == nil {
+					err = lv.Load(config)
+				}
+
+				if err != nil {
+					logger.Error("Failed to reload log levels", err)
+					continue
+				}
+
+				logger.Info("Reloaded log levels")
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
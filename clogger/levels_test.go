@@ -0,0 +1,106 @@
+package clogger_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLevel(t *testing.T) {
+	t.Parallel()
+
+	for name, level := range map[string]clogger.Level{
+		"debug":   clogger.LevelDebug,
+		"INFO":    clogger.LevelInfo,
+		"warn":    clogger.LevelWarn,
+		"warning": clogger.LevelWarn,
+		"error":   clogger.LevelError,
+	} {
+		parsed, err := clogger.ParseLevel(name)
+		assert.NoError(t, err)
+		assert.Equal(t, level, parsed)
+	}
+
+	_, err := clogger.ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestLevels_Enabled(t *testing.T) {
+	t.Parallel()
+
+	levels, err := clogger.NewLevels(clogger.Config{
+		Level:   "warn",
+		Modules: map[string]string{"csql": "debug"},
+	})
+	assert.NoError(t, err)
+
+	assert.False(t, levels.Enabled("", clogger.LevelInfo))
+	assert.True(t, levels.Enabled("", clogger.LevelError))
+	assert.True(t, levels.Enabled("csql", clogger.LevelDebug))
+	assert.False(t, levels.Enabled("chttp", clogger.LevelInfo))
+
+	levels.SetModuleLevel("chttp", clogger.LevelInfo)
+	assert.True(t, levels.Enabled("chttp", clogger.LevelInfo))
+
+	levels.SetLevel(clogger.LevelDebug)
+	assert.True(t, levels.Enabled("", clogger.LevelDebug))
+}
+
+func TestNewWithLevels(t *testing.T) {
+	t.Parallel()
+
+	levels, err := clogger.NewLevels(clogger.Config{
+		Level:   "info",
+		Modules: map[string]string{"csql": "error"},
+	})
+	assert.NoError(t, err)
+
+	out := filepath.Join(t.TempDir(), "out.log")
+
+	logger, err := clogger.NewWithLevels(clogger.Config{Out: out, Err: out, Format: clogger.FormatPlain}, levels)
+	assert.NoError(t, err)
+
+	logger.Debug("debug log")
+	logger.Info("info log")
+	logger.With(clogger.Module("csql")).Warn("csql warn log", nil)
+	logger.With(clogger.Module("csql")).Error("csql error log", nil)
+
+	levels.SetLevel(clogger.LevelDebug)
+	logger.Debug("debug log after change")
+
+	logs, err := ioutil.ReadFile(out)
+	assert.NoError(t, err)
+
+	assert.NotContains(t, string(logs), "[DEBUG] debug log\n")
+	assert.Contains(t, string(logs), "info log")
+	assert.NotContains(t, string(logs), "csql warn log")
+	assert.Contains(t, string(logs), "csql error log")
+	assert.Contains(t, string(logs), "debug log after change")
+}
+
+func TestLevels_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	levels, err := clogger.NewLevels(clogger.Config{})
+	assert.NoError(t, err)
+
+	resp := httptest.NewRecorder()
+	levels.ServeHTTP(resp, httptest.NewRequest(http.MethodPut, "/",
+		strings.NewReader(`{"level":"error","modules":{"csql":"debug"}}`)))
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"level":"error","modules":{"csql":"debug"}}`, resp.Body.String())
+	assert.False(t, levels.Enabled("", clogger.LevelWarn))
+	assert.True(t, levels.Enabled("csql", clogger.LevelDebug))
+
+	resp = httptest.NewRecorder()
+	levels.ServeHTTP(resp, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"level":"loud"}`)))
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
@@ -1,18 +1,26 @@
 package clogger
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
+	"fmt"
 	"io"
-	"log"
 	"os"
 	"time"
 
 	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clifecycle"
 )
 
-// Logger can be used to log messages and errors.
+// Logger can be used to log messages and errors along with structured fields. Fields are emitted as JSON keys
+// when using FormatJSON (recommended in production) and as human-readable key=value pairs when using FormatPlain.
 type Logger interface {
+	// WithFields returns a Logger that attaches the given fields to every log.
+	WithFields(fields map[string]interface{}) Logger
+
+	// With is similar to WithFields but accepts typed fields created using helpers such as String, Int, and Err.
+	With(fields ...Field) Logger
+
+	// WithTags is an alias of WithFields.
 	WithTags(tags map[string]interface{}) Logger
 
 	Debug(msg string)
@@ -28,116 +36,153 @@ func New() Logger {
 
 // NewWithConfig creates a Logger based on the provided config.
 func NewWithConfig(config Config) (Logger, error) {
-	const LogFilePerms = 0666
-
-	var (
-		outFile io.Writer = os.Stdout
-		errFile io.Writer = os.Stderr
-		err     error
-	)
-
-	if config.Out != "" {
-		outFile, err = os.OpenFile(config.Out, os.O_APPEND|os.O_CREATE|os.O_WRONLY, LogFilePerms)
-		if err != nil {
-			return nil, cerrors.New(err, "failed to open log file", map[string]interface{}{
-				"path": config.Out,
-			})
-		}
+	levels, err := NewLevels(config)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create log levels", nil)
 	}
 
-	if config.Out == config.Err {
-		errFile = outFile
-	} else if config.Err != "" {
-		errFile, err = os.OpenFile(config.Err, os.O_APPEND|os.O_CREATE|os.O_WRONLY, LogFilePerms)
-		if err != nil {
-			return nil, cerrors.New(err, "failed to open error log file", map[string]interface{}{
-				"path": config.Err,
-			})
-		}
+	return NewWithLevels(config, levels)
+}
+
+// NewWithLevels creates a Logger based on the provided config that only writes logs enabled by the given levels.
+// Since levels can be changed at runtime, the Logger's levels can be changed without restarting the app.
+// Sinks that buffer logs (ex. loki, otlp) are not flushed when the app exits. Use NewWithSinks to flush them.
+func NewWithLevels(config Config, levels *Levels) (Logger, error) {
+	return NewWithSinks(config, levels, clifecycle.New())
+}
+
+// NewWithSinks creates a Logger that writes to the sinks configured in Config.Sinks. If no sinks are configured,
+// the logs are written to Config.Out and Config.Err (stdout and stderr by default). The sinks are flushed and closed
+// when the lifecycle stops.
+func NewWithSinks(config Config, levels *Levels, lc *clifecycle.Lifecycle) (Logger, error) {
+	redactor, err := NewRedactor(config.Redact)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create log redactor", nil)
 	}
 
-	return NewWithWriters(outFile, errFile, config.Format), nil
+	sinks, err := NewSinks(config)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create log sinks", nil)
+	}
+
+	l := &logger{
+		sinks:    sinks,
+		tags:     make(map[string]interface{}),
+		levels:   levels,
+		sampler:  newSampler(config.Sampling),
+		redactor: redactor,
+	}
+
+	lc.OnStop(func(_ context.Context) error {
+		if l.sampler != nil {
+			for _, summary := range l.sampler.Flush() {
+				l.write(summary)
+			}
+		}
+
+		for i := range sinks {
+			err := sinks[i].Close()
+			if err != nil {
+				return cerrors.New(err, "failed to close log sink", nil)
+			}
+		}
+
+		return nil
+	})
+
+	return l, nil
 }
 
 // NewWithWriters creates a Logger that uses the provided writers. out is
 // used for debug and info levels. err is used for warn and error levels.
 func NewWithWriters(out, err io.Writer, format Format) Logger {
 	return &logger{
-		out:    out,
-		err:    err,
-		tags:   make(map[string]interface{}),
-		format: format,
+		sinks: []Sink{NewWriterSink(out, err, format)},
+		tags:  make(map[string]interface{}),
 	}
 }
 
 type logger struct {
-	out    io.Writer
-	err    io.Writer
-	tags   map[string]interface{}
-	format Format
+	sinks    []Sink
+	tags     map[string]interface{}
+	levels   *Levels
+	sampler  *sampler
+	redactor *Redactor
 }
 
-func (l *logger) WithTags(tags map[string]interface{}) Logger {
+func (l *logger) WithFields(fields map[string]interface{}) Logger {
 	return &logger{
-		out:    l.out,
-		err:    l.err,
-		tags:   mergeTags(l.tags, tags),
-		format: l.format,
+		sinks:    l.sinks,
+		tags:     mergeTags(l.tags, fields),
+		levels:   l.levels,
+		sampler:  l.sampler,
+		redactor: l.redactor,
 	}
 }
 
+func (l *logger) With(fields ...Field) Logger {
+	return l.WithFields(fieldsToMap(fields))
+}
+
+func (l *logger) WithTags(tags map[string]interface{}) Logger {
+	return l.WithFields(tags)
+}
+
 func (l *logger) Debug(msg string) {
-	l.log(l.out, LevelDebug, errors.New(msg)) //nolint:goerr113
+	l.log(LevelDebug, msg, nil)
 }
 
 func (l *logger) Info(msg string) {
-	l.log(l.out, LevelInfo, errors.New(msg)) //nolint:goerr113
+	l.log(LevelInfo, msg, nil)
 }
 
 func (l *logger) Warn(msg string, err error) {
-	l.log(l.err, LevelWarn, cerrors.New(err, msg, nil))
+	l.log(LevelWarn, msg, err)
 }
 
 func (l *logger) Error(msg string, err error) {
-	l.log(l.err, LevelError, cerrors.New(err, msg, nil))
+	l.log(LevelError, msg, err)
 }
 
-func (l *logger) log(dest io.Writer, lvl Level, err error) {
-	switch l.format {
-	case FormatJSON:
-		l.logJSON(dest, lvl, err)
-	case FormatPlain:
-		fallthrough
-	default:
-		l.logPlain(dest, lvl, err)
+func (l *logger) log(lvl Level, msg string, err error) {
+	if l.levels != nil {
+		module, _ := l.tags[ModuleKey].(string)
+		if !l.levels.Enabled(module, lvl) {
+			return
+		}
 	}
-}
 
-func (l *logger) logJSON(dest io.Writer, lvl Level, err error) {
-	var dict = map[string]interface{}{
-		"ts":    time.Now().Format(time.RFC3339),
-		"level": lvl.String(),
+	entry := Entry{
+		Time:    time.Now(),
+		Level:   lvl,
+		Message: msg,
+		Error:   err,
+		Fields:  l.tags,
 	}
 
-	dict = mergeTags(dict, l.tags)
-
-	switch cerr := err.(type) {
-	case cerrors.Error:
-		dict["msg"] = cerr.Message
-
-		dict = mergeTags(dict, cerr.Tags)
+	if l.sampler != nil {
+		ok, summary := l.sampler.Sample(entry)
+		if summary != nil {
+			l.write(*summary)
+		}
 
-		if cerr.Cause != nil {
-			dict["error"] = cerr.Cause.Error()
+		if !ok {
+			return
 		}
-	default:
-		dict["msg"] = err.Error()
 	}
 
-	jsonStr, _ := json.Marshal(dict)
-	_, _ = dest.Write([]byte(string(jsonStr) + "\n"))
+	l.write(entry)
 }
 
-func (l *logger) logPlain(dest io.Writer, lvl Level, err error) {
-	log.New(dest, "", log.LstdFlags).Printf("[%s] %s", lvl.String(), cerrors.WithTags(err, l.tags).Error())
+func (l *logger) write(entry Entry) {
+	if l.redactor != nil {
+		entry = l.redactor.Entry(entry)
+	}
+
+	for i := range l.sinks {
+		sinkErr := l.sinks[i].Write(entry)
+		if sinkErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "clogger: failed to write log to sink: %v\n", sinkErr)
+		}
+	}
 }
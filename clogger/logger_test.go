@@ -2,10 +2,12 @@ package clogger_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gocopper/copper/clogger"
 	"github.com/stretchr/testify/assert"
@@ -152,3 +154,39 @@ func TestLogger_Error(t *testing.T) {
 
 	assert.Contains(t, buf.String(), "[ERROR] test error log because\n> test-error")
 }
+
+func TestLogger_With_JSON(t *testing.T) {
+	t.Parallel()
+
+	var (
+		buf    bytes.Buffer
+		logger = clogger.NewWithWriters(&buf, &buf, clogger.FormatJSON)
+	)
+
+	logger.
+		WithFields(map[string]interface{}{"user": "u1"}).
+		With(clogger.Int("count", 2), clogger.Duration("took", time.Second)).
+		Info("test info log")
+
+	var entry map[string]interface{}
+
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "INFO", entry["level"])
+	assert.Equal(t, "test info log", entry["msg"])
+	assert.Equal(t, "u1", entry["user"])
+	assert.Equal(t, float64(2), entry["count"])
+	assert.Equal(t, "1s", entry["took"])
+}
+
+func TestLogger_With_Plain(t *testing.T) {
+	t.Parallel()
+
+	var (
+		buf    bytes.Buffer
+		logger = clogger.NewWithWriters(&buf, &buf, clogger.FormatPlain)
+	)
+
+	logger.With(clogger.String("user", "u1")).Info("test info log")
+
+	assert.Contains(t, buf.String(), "[INFO] test info log where user=u1")
+}
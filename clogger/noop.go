@@ -8,6 +8,14 @@ func NewNoop() Logger {
 
 type noop struct{}
 
+func (l *noop) WithFields(fields map[string]interface{}) Logger {
+	return l
+}
+
+func (l *noop) With(fields ...Field) Logger {
+	return l
+}
+
 func (l *noop) WithTags(tags map[string]interface{}) Logger {
 	return l
 }
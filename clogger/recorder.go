@@ -23,13 +23,21 @@ type recorder struct {
 	tags map[string]interface{}
 }
 
-func (l *recorder) WithTags(tags map[string]interface{}) Logger {
+func (l *recorder) WithFields(fields map[string]interface{}) Logger {
 	return &recorder{
 		Logs: l.Logs,
-		tags: mergeTags(l.tags, tags),
+		tags: mergeTags(l.tags, fields),
 	}
 }
 
+func (l *recorder) With(fields ...Field) Logger {
+	return l.WithFields(fieldsToMap(fields))
+}
+
+func (l *recorder) WithTags(tags map[string]interface{}) Logger {
+	return l.WithFields(tags)
+}
+
 func (l *recorder) Debug(msg string) {
 	*l.Logs = append(*l.Logs, RecordedLog{
 		Level: LevelDebug,
@@ -123,3 +123,23 @@ func TestRecorder_Error(t *testing.T) {
 	assert.Empty(t, log.Tags)
 	assert.EqualError(t, log.Error, "test-err")
 }
+
+func TestRecorder_With(t *testing.T) {
+	t.Parallel()
+
+	var (
+		logs   = make([]clogger.RecordedLog, 0)
+		logger = clogger.NewRecorder(&logs)
+	)
+
+	logger.
+		WithFields(map[string]interface{}{"key": "val"}).
+		With(clogger.Int("key2", 2)).
+		Info("test info log")
+
+	assert.Len(t, logs, 1)
+	assert.Equal(t, map[string]interface{}{
+		"key":  "val",
+		"key2": 2,
+	}, logs[0].Tags)
+}
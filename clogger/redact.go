@@ -0,0 +1,205 @@
+package clogger
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// RedactedValue replaces the value of redacted fields and the parts of messages that match a redaction pattern
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactKeys are the field names that are redacted by default. A field is redacted if its name contains any of
+// these keys, ignoring case, underscores, and dashes (ex. "userPassword" and "X-Auth-Token" are both redacted).
+var DefaultRedactKeys = []string{ //nolint:gochecknoglobals
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"apikey",
+	"authorization",
+	"cookie",
+	"cardnumber",
+	"cvv",
+	"ssn",
+}
+
+// DefaultRedactPatterns are the patterns that are masked in log messages, errors, and string field values by default.
+// In addition to these patterns, numbers that look like card numbers (13-19 digits that pass the Luhn check) are
+// masked unless defaults are disabled.
+var DefaultRedactPatterns = []string{ //nolint:gochecknoglobals
+	`(?i)bearer\s+[a-z0-9\-._~+/]+=*`,
+}
+
+var cardNumberRegexp = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`) //nolint:gochecknoglobals
+
+// NewRedactor creates a Redactor based on the given config. Unless DisableDefaults is set, DefaultRedactKeys and
+// DefaultRedactPatterns are used along with the configured keys and patterns.
+func NewRedactor(config ConfigRedact) (*Redactor, error) {
+	var (
+		keys     = config.Keys
+		patterns = config.Patterns
+	)
+
+	if !config.DisableDefaults {
+		keys = append(append([]string{}, DefaultRedactKeys...), keys...)
+		patterns = append(append([]string{}, DefaultRedactPatterns...), patterns...)
+	}
+
+	r := &Redactor{
+		keys:        make([]string, 0, len(keys)),
+		patterns:    make([]*regexp.Regexp, 0, len(patterns)),
+		cardNumbers: !config.DisableDefaults,
+	}
+
+	for _, k := range keys {
+		r.keys = append(r.keys, normalizeRedactKey(k))
+	}
+
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, cerrors.New(err, "invalid redaction pattern", map[string]interface{}{
+				"pattern": p,
+			})
+		}
+
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+// Redactor masks sensitive data in logs. Fields with sensitive names are replaced with RedactedValue and sensitive
+// patterns (ex. card numbers) are masked in messages, errors, and string values.
+type Redactor struct {
+	keys        []string
+	patterns    []*regexp.Regexp
+	cardNumbers bool
+}
+
+// Fields returns a copy of fields with sensitive values redacted. Nested maps and slices (ex. a decoded JSON request
+// body) are redacted recursively.
+func (r *Redactor) Fields(fields map[string]interface{}) map[string]interface{} {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	redacted := make(map[string]interface{}, len(fields))
+
+	for k, v := range fields {
+		if r.IsSensitiveKey(k) {
+			redacted[k] = RedactedValue
+			continue
+		}
+
+		redacted[k] = r.value(v)
+	}
+
+	return redacted
+}
+
+// String masks the parts of s that match a redaction pattern
+func (r *Redactor) String(s string) string {
+	if r.cardNumbers {
+		s = cardNumberRegexp.ReplaceAllStringFunc(s, func(match string) string {
+			if !isLuhnValid(match) {
+				return match
+			}
+
+			return RedactedValue
+		})
+	}
+
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, RedactedValue)
+	}
+
+	return s
+}
+
+// IsSensitiveKey returns true if values with the given field name should be redacted
+func (r *Redactor) IsSensitiveKey(key string) bool {
+	normalized := normalizeRedactKey(key)
+
+	for _, k := range r.keys {
+		if k != "" && strings.Contains(normalized, k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Entry returns a copy of the entry with its message, error, and fields redacted
+func (r *Redactor) Entry(entry Entry) Entry {
+	entry.Message = r.String(entry.Message)
+	entry.Fields = r.Fields(entry.Fields)
+
+	if entry.Error != nil {
+		msg := entry.Error.Error()
+		if redacted := r.String(msg); redacted != msg {
+			entry.Error = redactedError(redacted)
+		}
+	}
+
+	return entry
+}
+
+func (r *Redactor) value(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return r.String(val)
+	case error:
+		return r.String(val.Error())
+	case map[string]interface{}:
+		return r.Fields(val)
+	case []interface{}:
+		redacted := make([]interface{}, len(val))
+		for i := range val {
+			redacted[i] = r.value(val[i])
+		}
+
+		return redacted
+	default:
+		return v
+	}
+}
+
+func normalizeRedactKey(key string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+}
+
+// isLuhnValid returns true if the digits in s pass the Luhn checksum used by card numbers
+func isLuhnValid(s string) bool {
+	var (
+		sum    int
+		double bool
+	)
+
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+
+		d := int(s[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 { //nolint:gomnd
+				d -= 9
+			}
+		}
+
+		sum += d
+		double = !double
+	}
+
+	return sum%10 == 0 //nolint:gomnd
+}
+
+type redactedError string
+
+func (e redactedError) Error() string {
+	return string(e)
+}
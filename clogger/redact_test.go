@@ -0,0 +1,103 @@
+package clogger_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor_Fields(t *testing.T) {
+	t.Parallel()
+
+	r, err := clogger.NewRedactor(clogger.ConfigRedact{Keys: []string{"dob"}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"email":         "test@example.com",
+		"userPassword":  clogger.RedactedValue,
+		"X-Auth-Token":  clogger.RedactedValue,
+		"dob":           clogger.RedactedValue,
+		"count":         5,
+		"authorization": clogger.RedactedValue,
+		"body": map[string]interface{}{
+			"card_number": clogger.RedactedValue,
+			"items": []interface{}{
+				map[string]interface{}{"api_key": clogger.RedactedValue, "name": "item"},
+			},
+		},
+	}, r.Fields(map[string]interface{}{
+		"email":         "test@example.com",
+		"userPassword":  "hunter2",
+		"X-Auth-Token":  "abc",
+		"dob":           "2000-01-01",
+		"count":         5,
+		"authorization": "Basic dXNlcjpwYXNz",
+		"body": map[string]interface{}{
+			"card_number": "4242424242424242",
+			"items": []interface{}{
+				map[string]interface{}{"api_key": "key", "name": "item"},
+			},
+		},
+	}))
+}
+
+func TestRedactor_String(t *testing.T) {
+	t.Parallel()
+
+	r, err := clogger.NewRedactor(clogger.ConfigRedact{Patterns: []string{`\d{3}-\d{2}-\d{4}`}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "charged card [REDACTED]", r.String("charged card 4242 4242 4242 4242"))
+	assert.Equal(t, "order 1234567890123 created", r.String("order 1234567890123 created"))
+	assert.Equal(t, "header [REDACTED]", r.String("header Bearer eyJhbGciOi.J9.abc"))
+	assert.Equal(t, "ssn [REDACTED]", r.String("ssn 123-45-6789"))
+}
+
+func TestRedactor_DisableDefaults(t *testing.T) {
+	t.Parallel()
+
+	r, err := clogger.NewRedactor(clogger.ConfigRedact{DisableDefaults: true})
+	assert.NoError(t, err)
+
+	assert.False(t, r.IsSensitiveKey("password"))
+	assert.Equal(t, "4242424242424242", r.String("4242424242424242"))
+}
+
+func TestNewRedactor_InvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	_, err := clogger.NewRedactor(clogger.ConfigRedact{Patterns: []string{"("}})
+	assert.Error(t, err)
+}
+
+func TestLogger_Redact(t *testing.T) {
+	t.Parallel()
+
+	var (
+		lc   = clifecycle.New()
+		path = filepath.Join(t.TempDir(), "app.log")
+	)
+
+	logger, err := clogger.NewWithSinks(clogger.Config{
+		Format: clogger.FormatJSON,
+		Sinks:  []clogger.ConfigSink{{Type: clogger.SinkTypeFile, Path: path}},
+	}, nil, lc)
+	assert.NoError(t, err)
+
+	logger.
+		With(clogger.String("password", "hunter2")).
+		Error("failed to charge", errors.New("card 4242424242424242 declined"))
+
+	lc.Stop(clogger.NewNoop())
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2")
+	assert.NotContains(t, string(data), "4242424242424242")
+	assert.Contains(t, string(data), `"error":"card [REDACTED] declined"`)
+}
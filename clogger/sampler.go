@@ -0,0 +1,134 @@
+package clogger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SampledMessageKey and SuppressedKey are the fields attached to the summary log written when similar logs were
+// suppressed by sampling.
+const (
+	SampledMessageKey = "sampledMessage"
+	SuppressedKey     = "suppressed"
+)
+
+const samplerMaxKeys = 10000
+
+// newSampler creates a sampler based on the given config. If sampling is disabled, it returns nil.
+func newSampler(config ConfigSampling) *sampler {
+	if config.Interval <= 0 {
+		return nil
+	}
+
+	return &sampler{
+		config: config,
+		counts: make(map[sampleKey]*sampleCount),
+		now:    time.Now,
+	}
+}
+
+// sampler limits how often logs with the same level and message are written. In each interval, the first
+// config.First logs are written and then every config.Thereafter-th log. When a new interval starts, a summary with
+// the number of suppressed logs is written.
+type sampler struct {
+	mu     sync.Mutex
+	config ConfigSampling
+	counts map[sampleKey]*sampleCount
+	now    func() time.Time
+}
+
+type sampleKey struct {
+	level   Level
+	message string
+}
+
+type sampleCount struct {
+	windowStart time.Time
+	n           int
+	suppressed  int
+}
+
+// Sample returns true if the entry should be written. If logs with the same message were suppressed in a previous
+// interval, it also returns a summary entry that should be written before it.
+func (s *sampler) Sample(entry Entry) (bool, *Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		now     = s.now()
+		key     = sampleKey{level: entry.Level, message: entry.Message}
+		summary *Entry
+	)
+
+	c, ok := s.counts[key]
+	if !ok {
+		if len(s.counts) >= samplerMaxKeys {
+			s.purge(now)
+		}
+
+		c = &sampleCount{windowStart: now}
+		s.counts[key] = c
+	}
+
+	if now.Sub(c.windowStart) >= s.config.Interval {
+		summary = suppressedSummary(key, c.suppressed, now)
+		c.windowStart, c.n, c.suppressed = now, 0, 0
+	}
+
+	c.n++
+
+	if c.n <= s.config.First || (s.config.Thereafter > 0 && (c.n-s.config.First)%s.config.Thereafter == 0) {
+		return true, summary
+	}
+
+	c.suppressed++
+
+	return false, summary
+}
+
+// Flush returns the summaries for all logs that were suppressed in the current interval and resets the counts
+func (s *sampler) Flush() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		now       = s.now()
+		summaries []Entry
+	)
+
+	for key, c := range s.counts {
+		if summary := suppressedSummary(key, c.suppressed, now); summary != nil {
+			summaries = append(summaries, *summary)
+		}
+
+		delete(s.counts, key)
+	}
+
+	return summaries
+}
+
+// purge removes the counts for keys whose interval has ended without any suppressed logs
+func (s *sampler) purge(now time.Time) {
+	for key, c := range s.counts {
+		if c.suppressed == 0 && now.Sub(c.windowStart) >= s.config.Interval {
+			delete(s.counts, key)
+		}
+	}
+}
+
+func suppressedSummary(key sampleKey, suppressed int, now time.Time) *Entry {
+	if suppressed == 0 {
+		return nil
+	}
+
+	return &Entry{
+		Time:    now,
+		Level:   key.level,
+		Message: fmt.Sprintf("%d similar logs suppressed", suppressed),
+		Fields: map[string]interface{}{
+			SampledMessageKey: key.message,
+			SuppressedKey:     suppressed,
+		},
+	}
+}
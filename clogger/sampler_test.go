@@ -0,0 +1,56 @@
+package clogger_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_Sampling(t *testing.T) {
+	t.Parallel()
+
+	var (
+		lc   = clifecycle.New()
+		path = filepath.Join(t.TempDir(), "app.log")
+	)
+
+	logger, err := clogger.NewWithSinks(clogger.Config{
+		Format: clogger.FormatPlain,
+		Sinks: []clogger.ConfigSink{
+			{Type: clogger.SinkTypeFile, Path: path},
+		},
+		Sampling: clogger.ConfigSampling{
+			Interval:   time.Hour,
+			First:      2,
+			Thereafter: 3,
+		},
+	}, nil, lc)
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		logger.With(clogger.Int("i", i)).Info("noisy log")
+	}
+
+	logger.Info("other log")
+
+	lc.Stop(clogger.NewNoop())
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 6)
+	assert.Contains(t, lines[0], "noisy log where i=0")
+	assert.Contains(t, lines[1], "noisy log where i=1")
+	assert.Contains(t, lines[2], "noisy log where i=4")
+	assert.Contains(t, lines[3], "noisy log where i=7")
+	assert.Contains(t, lines[4], "other log")
+	assert.Contains(t, lines[5], "6 similar logs suppressed where")
+	assert.Contains(t, lines[5], "sampledMessage=noisy log")
+}
@@ -0,0 +1,257 @@
+package clogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Entry is a single log that is written to a Sink
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Error   error
+	Fields  map[string]interface{}
+}
+
+// Sink is a destination for logs such as a file, syslog, or a log aggregation service. A Logger can write to
+// multiple sinks.
+type Sink interface {
+	// Write writes the entry to the sink. Sinks may buffer entries and write them asynchronously.
+	Write(entry Entry) error
+
+	// Close flushes any buffered entries and releases the sink's resources.
+	Close() error
+}
+
+// NewSinks creates the sinks configured in config.Sinks. If no sinks are configured, it returns a single sink that
+// writes to config.Out and config.Err (stdout and stderr by default). Sinks that are disabled by config (ex. a sentry
+// sink without a dsn) are skipped.
+func NewSinks(config Config) ([]Sink, error) {
+	if len(config.Sinks) == 0 {
+		sink, err := newOutErrSink(config)
+		if err != nil {
+			return nil, err
+		}
+
+		return []Sink{sink}, nil
+	}
+
+	sinks := make([]Sink, 0, len(config.Sinks))
+
+	for i := range config.Sinks {
+		sink, err := newSink(config.Sinks[i], config.Format)
+		if err != nil {
+			for j := range sinks {
+				_ = sinks[j].Close()
+			}
+
+			return nil, cerrors.New(err, "failed to create log sink", map[string]interface{}{
+				"type": config.Sinks[i].Type,
+			})
+		}
+
+		if sink == nil {
+			continue
+		}
+
+		sinks = append(sinks, sink)
+	}
+
+	return sinks, nil
+}
+
+func newSink(config ConfigSink, defaultFormat Format) (Sink, error) {
+	format := config.Format
+	if format == "" {
+		format = defaultFormat
+	}
+
+	httpConfig := HTTPSinkConfig{
+		URL:           config.URL,
+		Headers:       config.Headers,
+		Labels:        config.Labels,
+		BatchSize:     config.BatchSize,
+		FlushInterval: config.FlushInterval,
+	}
+
+	switch config.Type {
+	case SinkTypeStdout:
+		return NewWriterSink(os.Stdout, os.Stdout, format), nil
+	case SinkTypeStderr:
+		return NewWriterSink(os.Stderr, os.Stderr, format), nil
+	case SinkTypeFile:
+		f, err := NewRotatingFile(RotatingFileConfig{
+			Path:        config.Path,
+			MaxSizeMB:   config.MaxSizeMB,
+			RotateEvery: config.RotateEvery,
+			MaxBackups:  config.MaxBackups,
+			MaxAge:      config.MaxAge,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return NewWriterSink(f, f, format), nil
+	case SinkTypeSyslog:
+		return NewSyslogSink(config.Network, config.Address, config.Tag, format)
+	case SinkTypeLoki:
+		if config.URL == "" {
+			return nil, cerrors.New(nil, "url is required for loki sink", nil)
+		}
+
+		return NewLokiSink(httpConfig), nil
+	case SinkTypeOTLP:
+		if config.URL == "" {
+			return nil, cerrors.New(nil, "url is required for otlp sink", nil)
+		}
+
+		return NewOTLPSink(httpConfig), nil
+	case SinkTypeSentry:
+		if config.DSN == "" {
+			return nil, nil
+		}
+
+		return NewSentrySink(SentryConfig{
+			DSN:         config.DSN,
+			Release:     config.Release,
+			Environment: config.Environment,
+		})
+	default:
+		return nil, cerrors.New(nil, "unknown log sink type", map[string]interface{}{
+			"type": config.Type,
+		})
+	}
+}
+
+func newOutErrSink(config Config) (Sink, error) {
+	var (
+		outFile io.Writer = os.Stdout
+		errFile io.Writer = os.Stderr
+		err     error
+	)
+
+	if config.Out != "" {
+		outFile, err = os.OpenFile(config.Out, os.O_APPEND|os.O_CREATE|os.O_WRONLY, logFilePerms)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to open log file", map[string]interface{}{
+				"path": config.Out,
+			})
+		}
+	}
+
+	if config.Out == config.Err {
+		errFile = outFile
+	} else if config.Err != "" {
+		errFile, err = os.OpenFile(config.Err, os.O_APPEND|os.O_CREATE|os.O_WRONLY, logFilePerms)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to open error log file", map[string]interface{}{
+				"path": config.Err,
+			})
+		}
+	}
+
+	return NewWriterSink(outFile, errFile, config.Format), nil
+}
+
+// NewWriterSink creates a Sink that formats each entry and writes it to out (debug and info levels) or err (warn and
+// error levels).
+func NewWriterSink(out, err io.Writer, format Format) Sink {
+	return &writerSink{
+		out:    out,
+		err:    err,
+		format: format,
+	}
+}
+
+type writerSink struct {
+	mu     sync.Mutex
+	out    io.Writer
+	err    io.Writer
+	format Format
+}
+
+func (s *writerSink) Write(entry Entry) error {
+	dest := s.out
+	if entry.Level >= LevelWarn {
+		dest = s.err
+	}
+
+	line := formatEntry(entry, s.format)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := dest.Write(line)
+
+	return err
+}
+
+func (s *writerSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, w := range []io.Writer{s.out, s.err} {
+		if w == os.Stdout || w == os.Stderr {
+			continue
+		}
+
+		if c, ok := w.(io.Closer); ok {
+			_ = c.Close()
+		}
+	}
+
+	return nil
+}
+
+// formatEntry formats the entry as a single line in the given format
+func formatEntry(entry Entry, format Format) []byte {
+	switch format {
+	case FormatJSON:
+		return formatEntryJSON(entry)
+	case FormatPlain:
+		fallthrough
+	default:
+		return formatEntryPlain(entry)
+	}
+}
+
+func formatEntryJSON(entry Entry) []byte {
+	var dict = map[string]interface{}{
+		"ts":    entry.Time.Format(time.RFC3339),
+		"level": entry.Level.String(),
+	}
+
+	dict = mergeTags(dict, entry.Fields)
+	dict["msg"] = entry.Message
+
+	if entry.Error != nil {
+		dict["error"] = entry.Error.Error()
+	}
+
+	for k, v := range dict {
+		if vErr, ok := v.(error); ok {
+			dict[k] = vErr.Error()
+		}
+	}
+
+	jsonStr, _ := json.Marshal(dict)
+
+	return []byte(string(jsonStr) + "\n")
+}
+
+func formatEntryPlain(entry Entry) []byte {
+	msg := cerrors.Error{
+		Message: entry.Message,
+		Tags:    entry.Fields,
+		Cause:   entry.Error,
+	}
+
+	return []byte(fmt.Sprintf("%s [%s] %s\n", entry.Time.Format("2006/01/02 15:04:05"), entry.Level.String(), msg.Error()))
+}
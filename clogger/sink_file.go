@@ -0,0 +1,173 @@
+package clogger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	logFilePerms          = 0666
+	rotatedFileTimeFormat = "20060102T150405.000"
+	bytesPerMB            = 1024 * 1024
+)
+
+// RotatingFileConfig configures a RotatingFile
+type RotatingFileConfig struct {
+	// Path is the path of the active log file. Rotated files are saved next to it as <path>.<timestamp>.
+	Path string
+
+	// MaxSizeMB rotates the file once it reaches the given size. Zero disables size-based rotation.
+	MaxSizeMB int
+
+	// RotateEvery rotates the file after the given interval (ex. 24h). Zero disables time-based rotation.
+	RotateEvery time.Duration
+
+	// MaxBackups is the max number of rotated files that are kept. Zero keeps all rotated files.
+	MaxBackups int
+
+	// MaxAge deletes rotated files older than the given duration. Zero keeps rotated files regardless of age.
+	MaxAge time.Duration
+}
+
+// NewRotatingFile opens (or creates) the log file at config.Path and returns a RotatingFile that writes to it.
+func NewRotatingFile(config RotatingFileConfig) (*RotatingFile, error) {
+	f := &RotatingFile{
+		config: config,
+		now:    time.Now,
+	}
+
+	err := f.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// RotatingFile is an io.WriteCloser that writes to a file and rotates it based on size and/or time. Old files are
+// deleted based on the configured retention.
+type RotatingFile struct {
+	mu       sync.Mutex
+	config   RotatingFileConfig
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	now      func() time.Time
+}
+
+// Write writes p to the active file, rotating it first if needed.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.shouldRotate(len(p)) {
+		err := f.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+// Close closes the active file
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.file.Close()
+}
+
+func (f *RotatingFile) shouldRotate(n int) bool {
+	if f.config.MaxSizeMB > 0 && f.size+int64(n) > int64(f.config.MaxSizeMB)*bytesPerMB && f.size > 0 {
+		return true
+	}
+
+	if f.config.RotateEvery > 0 && f.now().Sub(f.openedAt) >= f.config.RotateEvery {
+		return true
+	}
+
+	return false
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.config.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, logFilePerms)
+	if err != nil {
+		return cerrors.New(err, "failed to open log file", map[string]interface{}{
+			"path": f.config.Path,
+		})
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return cerrors.New(err, "failed to stat log file", map[string]interface{}{
+			"path": f.config.Path,
+		})
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.now()
+
+	return nil
+}
+
+func (f *RotatingFile) rotate() error {
+	err := f.file.Close()
+	if err != nil {
+		return cerrors.New(err, "failed to close log file before rotation", nil)
+	}
+
+	rotatedPath := f.config.Path + "." + f.now().UTC().Format(rotatedFileTimeFormat)
+
+	err = os.Rename(f.config.Path, rotatedPath)
+	if err != nil {
+		return cerrors.New(err, "failed to rename log file", map[string]interface{}{
+			"path": rotatedPath,
+		})
+	}
+
+	err = f.open()
+	if err != nil {
+		return err
+	}
+
+	return f.removeOldBackups()
+}
+
+func (f *RotatingFile) removeOldBackups() error {
+	backups, err := filepath.Glob(f.config.Path + ".*")
+	if err != nil {
+		return cerrors.New(err, "failed to list rotated log files", nil)
+	}
+
+	// The timestamp suffix sorts chronologically, so the newest backups are at the end
+	sort.Strings(backups)
+
+	for i, backup := range backups {
+		ts, err := time.Parse(rotatedFileTimeFormat, strings.TrimPrefix(backup, f.config.Path+"."))
+		if err != nil {
+			continue
+		}
+
+		tooMany := f.config.MaxBackups > 0 && i < len(backups)-f.config.MaxBackups
+		tooOld := f.config.MaxAge > 0 && f.now().Sub(ts) > f.config.MaxAge
+
+		if tooMany || tooOld {
+			_ = os.Remove(backup)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package clogger_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotatingFile_MaxSize(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	f, err := clogger.NewRotatingFile(clogger.RotatingFileConfig{
+		Path:       path,
+		MaxSizeMB:  1,
+		MaxBackups: 2,
+	})
+	assert.NoError(t, err)
+
+	line := []byte(strings.Repeat("a", 600*1024) + "\n")
+
+	for i := 0; i < 4; i++ {
+		_, err = f.Write(line)
+		assert.NoError(t, err)
+
+		// rotated files are named using a millisecond timestamp
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	assert.NoError(t, f.Close())
+
+	backups, err := filepath.Glob(path + ".*")
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2)
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, line, data)
+}
+
+func TestRotatingFile_RotateEvery(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	f, err := clogger.NewRotatingFile(clogger.RotatingFileConfig{
+		Path:        path,
+		RotateEvery: 10 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+
+	_, err = f.Write([]byte("first\n"))
+	assert.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, err = f.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	backups, err := filepath.Glob(path + ".*")
+	assert.NoError(t, err)
+	assert.Len(t, backups, 1)
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "second\n", string(data))
+}
@@ -0,0 +1,330 @@
+package clogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	defaultHTTPSinkBatchSize     = 100
+	defaultHTTPSinkFlushInterval = 2 * time.Second
+	defaultHTTPSinkTimeout       = 10 * time.Second
+	httpSinkMaxBufferedEntries   = 10000
+)
+
+// HTTPSinkConfig configures a Sink that pushes batches of logs to a log aggregation service over HTTP
+type HTTPSinkConfig struct {
+	// URL is the full push URL (ex. http://loki:3100/loki/api/v1/push or http://collector:4318/v1/logs)
+	URL string
+
+	// Headers are sent with each push request (ex. Authorization)
+	Headers map[string]string
+
+	// Labels are attached to every log. They are sent as stream labels to Loki and as resource attributes to OTLP.
+	Labels map[string]string
+
+	// BatchSize is the max number of logs sent in a single request
+	BatchSize int
+
+	// FlushInterval is the max time logs are buffered before they are sent
+	FlushInterval time.Duration
+}
+
+// NewLokiSink creates a Sink that pushes logs to Grafana Loki using its JSON push API.
+// See https://grafana.com/docs/loki/latest/reference/loki-http-api/#ingest-logs
+func NewLokiSink(config HTTPSinkConfig) Sink {
+	return newHTTPBatchSink(config, func(entries []Entry) ([]byte, error) {
+		return encodeLokiPush(entries, config.Labels)
+	})
+}
+
+// NewOTLPSink creates a Sink that exports logs to an OpenTelemetry collector using OTLP/HTTP with JSON encoding.
+// See https://opentelemetry.io/docs/specs/otlp/#otlphttp
+func NewOTLPSink(config HTTPSinkConfig) Sink {
+	return newHTTPBatchSink(config, func(entries []Entry) ([]byte, error) {
+		return encodeOTLPLogs(entries, config.Labels)
+	})
+}
+
+func newHTTPBatchSink(config HTTPSinkConfig, encode func([]Entry) ([]byte, error)) *httpBatchSink {
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultHTTPSinkBatchSize
+	}
+
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultHTTPSinkFlushInterval
+	}
+
+	s := &httpBatchSink{
+		config:  config,
+		encode:  encode,
+		client:  &http.Client{Timeout: defaultHTTPSinkTimeout},
+		flush:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go s.run()
+
+	return s
+}
+
+type httpBatchSink struct {
+	mu      sync.Mutex
+	buf     []Entry
+	config  HTTPSinkConfig
+	encode  func([]Entry) ([]byte, error)
+	client  *http.Client
+	flush   chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func (s *httpBatchSink) Write(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.buf) >= httpSinkMaxBufferedEntries {
+		return cerrors.New(nil, "log sink buffer is full; dropping log", map[string]interface{}{
+			"url": s.config.URL,
+		})
+	}
+
+	s.buf = append(s.buf, entry)
+
+	if len(s.buf) >= s.config.BatchSize {
+		select {
+		case s.flush <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (s *httpBatchSink) Close() error {
+	close(s.done)
+	<-s.stopped
+
+	return s.send()
+}
+
+func (s *httpBatchSink) run() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		case <-s.flush:
+		}
+
+		err := s.send()
+		if err != nil {
+			// The sink cannot log its own errors without risking a loop, so they are reported on stderr
+			_, _ = fmt.Fprintf(os.Stderr, "clogger: failed to push logs: %v\n", err)
+		}
+	}
+}
+
+func (s *httpBatchSink) send() error {
+	for {
+		s.mu.Lock()
+		n := len(s.buf)
+		if n > s.config.BatchSize {
+			n = s.config.BatchSize
+		}
+
+		batch := s.buf[:n]
+		s.buf = s.buf[n:]
+		s.mu.Unlock()
+
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := s.post(batch)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *httpBatchSink) post(batch []Entry) error {
+	body, err := s.encode(batch)
+	if err != nil {
+		return cerrors.New(err, "failed to encode logs", nil)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return cerrors.New(err, "failed to create log push request", nil)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for k, v := range s.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return cerrors.New(err, "failed to push logs", map[string]interface{}{
+			"url": s.config.URL,
+		})
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return cerrors.New(nil, "log push request failed", map[string]interface{}{
+			"url":        s.config.URL,
+			"statusCode": resp.StatusCode,
+		})
+	}
+
+	return nil
+}
+
+func encodeLokiPush(entries []Entry, labels map[string]string) ([]byte, error) {
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+
+	streams := make(map[Level]*stream)
+
+	for _, e := range entries {
+		st, ok := streams[e.Level]
+		if !ok {
+			streamLabels := map[string]string{"level": strings.ToLower(e.Level.String())}
+			for k, v := range labels {
+				streamLabels[k] = v
+			}
+
+			st = &stream{Stream: streamLabels}
+			streams[e.Level] = st
+		}
+
+		st.Values = append(st.Values, [2]string{
+			strconv.FormatInt(e.Time.UnixNano(), 10),
+			strings.TrimSuffix(string(formatEntryJSON(e)), "\n"),
+		})
+	}
+
+	levels := make([]Level, 0, len(streams))
+	for lvl := range streams {
+		levels = append(levels, lvl)
+	}
+
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+
+	push := struct {
+		Streams []*stream `json:"streams"`
+	}{}
+
+	for _, lvl := range levels {
+		push.Streams = append(push.Streams, streams[lvl])
+	}
+
+	return json.Marshal(push)
+}
+
+func encodeOTLPLogs(entries []Entry, labels map[string]string) ([]byte, error) {
+	type anyValue struct {
+		StringValue string `json:"stringValue"`
+	}
+
+	type keyValue struct {
+		Key   string   `json:"key"`
+		Value anyValue `json:"value"`
+	}
+
+	type logRecord struct {
+		TimeUnixNano   string     `json:"timeUnixNano"`
+		SeverityNumber int        `json:"severityNumber"`
+		SeverityText   string     `json:"severityText"`
+		Body           anyValue   `json:"body"`
+		Attributes     []keyValue `json:"attributes"`
+	}
+
+	toKeyValues := func(m map[string]interface{}) []keyValue {
+		kvs := make([]keyValue, 0, len(m))
+		for k, v := range m {
+			kvs = append(kvs, keyValue{Key: k, Value: anyValue{StringValue: fmt.Sprintf("%v", v)}})
+		}
+
+		sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+
+		return kvs
+	}
+
+	records := make([]logRecord, 0, len(entries))
+
+	for _, e := range entries {
+		attrs := mergeTags(e.Fields, nil)
+		if e.Error != nil {
+			attrs["exception.message"] = e.Error.Error()
+		}
+
+		records = append(records, logRecord{
+			TimeUnixNano:   strconv.FormatInt(e.Time.UnixNano(), 10),
+			SeverityNumber: otlpSeverityNumber(e.Level),
+			SeverityText:   e.Level.String(),
+			Body:           anyValue{StringValue: e.Message},
+			Attributes:     toKeyValues(attrs),
+		})
+	}
+
+	resourceAttrs := make(map[string]interface{}, len(labels))
+	for k, v := range labels {
+		resourceAttrs[k] = v
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"resourceLogs": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": toKeyValues(resourceAttrs),
+				},
+				"scopeLogs": []interface{}{
+					map[string]interface{}{
+						"scope":      map[string]string{"name": "clogger"},
+						"logRecords": records,
+					},
+				},
+			},
+		},
+	})
+}
+
+// otlpSeverityNumber maps a Level to the OTLP severity number.
+// See https://opentelemetry.io/docs/specs/otel/logs/data-model/#field-severitynumber
+func otlpSeverityNumber(l Level) int {
+	switch l {
+	case LevelDebug:
+		return 5 //nolint:gomnd
+	case LevelInfo:
+		return 9 //nolint:gomnd
+	case LevelWarn:
+		return 13 //nolint:gomnd
+	case LevelError:
+		return 17 //nolint:gomnd
+	default:
+		return 0
+	}
+}
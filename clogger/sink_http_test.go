@@ -0,0 +1,110 @@
+package clogger_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+type pushRecorder struct {
+	mu     sync.Mutex
+	bodies []map[string]interface{}
+	auth   string
+}
+
+func (r *pushRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var body map[string]interface{}
+
+	_ = json.NewDecoder(req.Body).Decode(&body)
+
+	r.mu.Lock()
+	r.bodies = append(r.bodies, body)
+	r.auth = req.Header.Get("Authorization")
+	r.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestLokiSink(t *testing.T) {
+	t.Parallel()
+
+	var rec pushRecorder
+
+	server := httptest.NewServer(&rec)
+	defer server.Close()
+
+	sink := clogger.NewLokiSink(clogger.HTTPSinkConfig{
+		URL:           server.URL,
+		Headers:       map[string]string{"Authorization": "Bearer token"},
+		Labels:        map[string]string{"app": "test"},
+		FlushInterval: time.Hour,
+	})
+
+	assert.NoError(t, sink.Write(clogger.Entry{Time: time.Now(), Level: clogger.LevelInfo, Message: "hello"}))
+	assert.NoError(t, sink.Write(clogger.Entry{
+		Time:    time.Now(),
+		Level:   clogger.LevelError,
+		Message: "failed",
+		Error:   errors.New("test-err"),
+	}))
+	assert.NoError(t, sink.Close())
+
+	assert.Len(t, rec.bodies, 1)
+	assert.Equal(t, "Bearer token", rec.auth)
+
+	streams := rec.bodies[0]["streams"].([]interface{})
+	assert.Len(t, streams, 2)
+
+	info := streams[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"app": "test", "level": "info"}, info["stream"])
+
+	var line map[string]interface{}
+
+	value := info["values"].([]interface{})[0].([]interface{})
+	assert.NoError(t, json.Unmarshal([]byte(value[1].(string)), &line))
+	assert.Equal(t, "hello", line["msg"])
+}
+
+func TestOTLPSink(t *testing.T) {
+	t.Parallel()
+
+	var rec pushRecorder
+
+	server := httptest.NewServer(&rec)
+	defer server.Close()
+
+	sink := clogger.NewOTLPSink(clogger.HTTPSinkConfig{
+		URL:           server.URL,
+		Labels:        map[string]string{"service.name": "test"},
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+	})
+
+	assert.NoError(t, sink.Write(clogger.Entry{
+		Time:    time.Now(),
+		Level:   clogger.LevelWarn,
+		Message: "slow",
+		Fields:  map[string]interface{}{"ms": 100},
+	}))
+	assert.NoError(t, sink.Close())
+
+	assert.Len(t, rec.bodies, 1)
+
+	resourceLogs := rec.bodies[0]["resourceLogs"].([]interface{})[0].(map[string]interface{})
+	scopeLogs := resourceLogs["scopeLogs"].([]interface{})[0].(map[string]interface{})
+	record := scopeLogs["logRecords"].([]interface{})[0].(map[string]interface{})
+
+	assert.Equal(t, "WARN", record["severityText"])
+	assert.Equal(t, float64(13), record["severityNumber"])
+	assert.Equal(t, map[string]interface{}{"stringValue": "slow"}, record["body"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"key": "ms", "value": map[string]interface{}{"stringValue": "100"}},
+	}, record["attributes"])
+}
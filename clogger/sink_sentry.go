@@ -0,0 +1,246 @@
+package clogger
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Field keys that are reported as Sentry context instead of extra data
+const (
+	UserIDKey    = "userID"
+	requestIDKey = "requestID"
+	traceIDKey   = "traceID"
+	pathKey      = "path"
+	stackKey     = "stack"
+)
+
+const (
+	sentryQueueSize = 256
+	sentryTimeout   = 10 * time.Second
+)
+
+// UserID creates a Field with the id of the user that is reported along with errors to Sentry
+func UserID(id string) Field {
+	return Field{Key: UserIDKey, Value: id}
+}
+
+// SentryConfig configures a Sink that reports errors to Sentry or a Sentry-compatible service (ex. GlitchTip)
+type SentryConfig struct {
+	// DSN is the project's client key (ex. https://<key>@o0.ingest.sentry.io/<project>)
+	DSN string
+
+	// Release and Environment are attached to each reported error
+	Release     string
+	Environment string
+}
+
+// NewSentrySink creates a Sink that reports error logs to Sentry. Errors are sent asynchronously in the background,
+// so logging is never blocked on the network. Recovered panics logged by chttp are reported along with their stack.
+func NewSentrySink(config SentryConfig) (Sink, error) {
+	endpoint, key, err := parseSentryDSN(config.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &sentrySink{
+		config:   config,
+		endpoint: endpoint,
+		key:      key,
+		client:   &http.Client{Timeout: sentryTimeout},
+		events:   make(chan Entry, sentryQueueSize),
+	}
+
+	s.wg.Add(1)
+
+	go s.run()
+
+	return s, nil
+}
+
+type sentrySink struct {
+	config   SentryConfig
+	endpoint string
+	key      string
+	client   *http.Client
+	events   chan Entry
+	wg       sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func (s *sentrySink) Write(entry Entry) error {
+	if entry.Level < LevelError {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil
+	}
+
+	select {
+	case s.events <- entry:
+		return nil
+	default:
+		return cerrors.New(nil, "sentry queue is full; dropping error", nil)
+	}
+}
+
+func (s *sentrySink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	return nil
+}
+
+func (s *sentrySink) run() {
+	defer s.wg.Done()
+
+	for entry := range s.events {
+		err := s.send(entry)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "clogger: failed to report error to sentry: %v\n", err)
+		}
+	}
+}
+
+func (s *sentrySink) send(entry Entry) error {
+	body, err := json.Marshal(s.event(entry))
+	if err != nil {
+		return cerrors.New(err, "failed to encode sentry event", nil)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return cerrors.New(err, "failed to create sentry request", nil)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf(
+		"Sentry sentry_version=7, sentry_client=copper/1.0, sentry_key=%s", s.key,
+	))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return cerrors.New(err, "failed to send sentry event", nil)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return cerrors.New(nil, "sentry rejected event", map[string]interface{}{
+			"statusCode": resp.StatusCode,
+		})
+	}
+
+	return nil
+}
+
+// event builds a Sentry event payload. See https://develop.sentry.dev/sdk/event-payloads/
+func (s *sentrySink) event(entry Entry) map[string]interface{} {
+	var (
+		tags  = make(map[string]string)
+		extra = make(map[string]interface{})
+		event = map[string]interface{}{
+			"event_id":  newSentryEventID(),
+			"timestamp": entry.Time.UTC().Format(time.RFC3339Nano),
+			"level":     strings.ToLower(entry.Level.String()),
+			"logger":    "clogger",
+			"platform":  "go",
+			"message":   map[string]string{"formatted": entry.Message},
+		}
+	)
+
+	if s.config.Release != "" {
+		event["release"] = s.config.Release
+	}
+
+	if s.config.Environment != "" {
+		event["environment"] = s.config.Environment
+	}
+
+	for k, v := range entry.Fields {
+		switch k {
+		case UserIDKey:
+			event["user"] = map[string]interface{}{"id": v}
+		case requestIDKey, traceIDKey, ModuleKey:
+			tags[k] = fmt.Sprintf("%v", v)
+		case pathKey:
+			event["request"] = map[string]interface{}{"url": v}
+		default:
+			extra[k] = fmt.Sprintf("%v", v)
+		}
+	}
+
+	errValue := entry.Fields["error"]
+	if entry.Error != nil {
+		errValue = entry.Error.Error()
+	}
+
+	if errValue != nil {
+		exception := map[string]interface{}{
+			"type":  entry.Message,
+			"value": fmt.Sprintf("%v", errValue),
+		}
+
+		if _, ok := entry.Fields[stackKey]; ok {
+			exception["mechanism"] = map[string]interface{}{"type": "panic", "handled": false}
+		}
+
+		event["exception"] = map[string]interface{}{"values": []interface{}{exception}}
+	}
+
+	event["tags"] = tags
+	event["extra"] = extra
+
+	return event
+}
+
+// parseSentryDSN returns the store endpoint and public key from a DSN of the form
+// {PROTOCOL}://{PUBLIC_KEY}@{HOST}{PATH}/{PROJECT_ID}
+func parseSentryDSN(dsn string) (string, string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", cerrors.New(err, "invalid sentry dsn", nil)
+	}
+
+	if u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return "", "", cerrors.New(nil, "invalid sentry dsn", nil)
+	}
+
+	i := strings.LastIndex(u.Path, "/")
+	if i < 0 || i == len(u.Path)-1 {
+		return "", "", cerrors.New(nil, "sentry dsn is missing project id", nil)
+	}
+
+	endpoint := fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, u.Path[:i], u.Path[i+1:])
+
+	return endpoint, u.User.Username(), nil
+}
+
+func newSentryEventID() string {
+	b := make([]byte, 16) //nolint:gomnd
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
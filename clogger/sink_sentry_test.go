@@ -0,0 +1,97 @@
+package clogger_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSentrySink(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu     sync.Mutex
+		paths  []string
+		auth   string
+		events []map[string]interface{}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event map[string]interface{}
+
+		_ = json.NewDecoder(r.Body).Decode(&event)
+
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		auth = r.Header.Get("X-Sentry-Auth")
+		events = append(events, event)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	sink, err := clogger.NewSentrySink(clogger.SentryConfig{
+		DSN:         strings.Replace(server.URL, "http://", "http://pubkey@", 1) + "/42",
+		Release:     "v1.0.0",
+		Environment: "production",
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, sink.Write(clogger.Entry{Time: time.Now(), Level: clogger.LevelWarn, Message: "ignored"}))
+	assert.NoError(t, sink.Write(clogger.Entry{
+		Time:    time.Now(),
+		Level:   clogger.LevelError,
+		Message: "failed to charge card",
+		Error:   errors.New("card declined"),
+		Fields: map[string]interface{}{
+			clogger.UserIDKey: "user-1",
+			"requestID":       "req-1",
+			"amount":          100,
+		},
+	}))
+	assert.NoError(t, sink.Close())
+
+	assert.Equal(t, []string{"/api/42/store/"}, paths)
+	assert.Contains(t, auth, "sentry_key=pubkey")
+	assert.Len(t, events, 1)
+
+	event := events[0]
+	assert.Equal(t, "error", event["level"])
+	assert.Equal(t, "v1.0.0", event["release"])
+	assert.Equal(t, "production", event["environment"])
+	assert.Equal(t, map[string]interface{}{"id": "user-1"}, event["user"])
+	assert.Equal(t, map[string]interface{}{"requestID": "req-1"}, event["tags"])
+	assert.Equal(t, map[string]interface{}{"amount": "100"}, event["extra"])
+	assert.Equal(t, map[string]interface{}{
+		"values": []interface{}{
+			map[string]interface{}{"type": "failed to charge card", "value": "card declined"},
+		},
+	}, event["exception"])
+}
+
+func TestNewSentrySink_InvalidDSN(t *testing.T) {
+	t.Parallel()
+
+	_, err := clogger.NewSentrySink(clogger.SentryConfig{DSN: "https://o0.ingest.sentry.io/"})
+	assert.Error(t, err)
+}
+
+func TestNewSinks_SentryWithoutDSN(t *testing.T) {
+	t.Parallel()
+
+	sinks, err := clogger.NewSinks(clogger.Config{
+		Sinks: []clogger.ConfigSink{
+			{Type: clogger.SinkTypeStdout},
+			{Type: clogger.SinkTypeSentry},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, sinks, 1)
+}
@@ -0,0 +1,50 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package clogger
+
+import (
+	"log/syslog"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// NewSyslogSink creates a Sink that writes to the syslog daemon at the given network address (ex. udp,
+// localhost:514). If network and addr are empty, the local syslog daemon is used.
+func NewSyslogSink(network, addr, tag string, format Format) (Sink, error) {
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to connect to syslog", map[string]interface{}{
+			"network": network,
+			"addr":    addr,
+		})
+	}
+
+	return &syslogSink{w: w, format: format}, nil
+}
+
+type syslogSink struct {
+	w      *syslog.Writer
+	format Format
+}
+
+func (s *syslogSink) Write(entry Entry) error {
+	msg := string(formatEntry(entry, s.format))
+
+	switch entry.Level {
+	case LevelDebug:
+		return s.w.Debug(msg)
+	case LevelInfo:
+		return s.w.Info(msg)
+	case LevelWarn:
+		return s.w.Warning(msg)
+	case LevelError:
+		return s.w.Err(msg)
+	default:
+		return s.w.Info(msg)
+	}
+}
+
+func (s *syslogSink) Close() error {
+	return s.w.Close()
+}
@@ -0,0 +1,11 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package clogger
+
+import "github.com/gocopper/copper/cerrors"
+
+// NewSyslogSink is not supported on this platform and always returns an error.
+func NewSyslogSink(network, addr, tag string, format Format) (Sink, error) {
+	return nil, cerrors.New(nil, "syslog is not supported on this platform", nil)
+}
@@ -0,0 +1,52 @@
+package clogger_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSinks_Default(t *testing.T) {
+	t.Parallel()
+
+	sinks, err := clogger.NewSinks(clogger.Config{})
+	assert.NoError(t, err)
+	assert.Len(t, sinks, 1)
+}
+
+func TestNewSinks_UnknownType(t *testing.T) {
+	t.Parallel()
+
+	_, err := clogger.NewSinks(clogger.Config{
+		Sinks: []clogger.ConfigSink{{Type: "carrier-pigeon"}},
+	})
+	assert.Error(t, err)
+}
+
+func TestNewWithSinks_File(t *testing.T) {
+	t.Parallel()
+
+	var (
+		lc   = clifecycle.New()
+		path = filepath.Join(t.TempDir(), "app.log")
+	)
+
+	logger, err := clogger.NewWithSinks(clogger.Config{
+		Format: clogger.FormatJSON,
+		Sinks: []clogger.ConfigSink{
+			{Type: clogger.SinkTypeFile, Path: path},
+		},
+	}, nil, lc)
+	assert.NoError(t, err)
+
+	logger.Info("test log")
+	lc.Stop(clogger.NewNoop())
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"msg":"test log"`)
+}
@@ -25,6 +25,11 @@ func NewZapLogger(config Config, lc *clifecycle.Lifecycle) (Logger, error) {
 		errOutPath = config.Err
 	}
 
+	levels, err := NewLevels(config)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create log levels", nil)
+	}
+
 	encoderConfig := zap.NewDevelopmentEncoderConfig()
 	if config.Format == FormatJSON {
 		encoderConfig = zap.NewProductionEncoderConfig()
@@ -52,35 +57,68 @@ func NewZapLogger(config Config, lc *clifecycle.Lifecycle) (Logger, error) {
 	})
 
 	return &zapLogger{
-		zap:  z.Sugar(),
-		tags: make(map[string]interface{}),
+		zap:    z.Sugar(),
+		tags:   make(map[string]interface{}),
+		levels: levels,
 	}, nil
 }
 
 type zapLogger struct {
-	zap  *zap.SugaredLogger
-	tags map[string]interface{}
+	zap    *zap.SugaredLogger
+	tags   map[string]interface{}
+	levels *Levels
 }
 
-func (l *zapLogger) WithTags(tags map[string]interface{}) Logger {
+func (l *zapLogger) WithFields(fields map[string]interface{}) Logger {
 	return &zapLogger{
-		zap:  l.zap,
-		tags: mergeTags(l.tags, tags),
+		zap:    l.zap,
+		tags:   mergeTags(l.tags, fields),
+		levels: l.levels,
 	}
 }
 
+func (l *zapLogger) With(fields ...Field) Logger {
+	return l.WithFields(fieldsToMap(fields))
+}
+
+func (l *zapLogger) WithTags(tags map[string]interface{}) Logger {
+	return l.WithFields(tags)
+}
+
+func (l *zapLogger) enabled(lvl Level) bool {
+	module, _ := l.tags[ModuleKey].(string)
+
+	return l.levels.Enabled(module, lvl)
+}
+
 func (l *zapLogger) Debug(msg string) {
+	if !l.enabled(LevelDebug) {
+		return
+	}
+
 	l.zap.Debugw(msg, tagsToKVs(l.tags)...)
 }
 
 func (l *zapLogger) Info(msg string) {
+	if !l.enabled(LevelInfo) {
+		return
+	}
+
 	l.zap.Infow(msg, tagsToKVs(l.tags)...)
 }
 
 func (l *zapLogger) Warn(msg string, err error) {
+	if !l.enabled(LevelWarn) {
+		return
+	}
+
 	l.zap.With("error", err).Warnw(msg, tagsToKVs(l.tags)...)
 }
 
 func (l *zapLogger) Error(msg string, err error) {
+	if !l.enabled(LevelError) {
+		return
+	}
+
 	l.zap.With("error", err).Errorw(msg, tagsToKVs(l.tags)...)
 }
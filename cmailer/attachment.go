@@ -0,0 +1,110 @@
+package cmailer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Attachment is a file attached to a message. Its content is read when the message is sent or queued in the Outbox.
+type Attachment struct {
+	Filename string
+
+	// ContentType of the file (default: detected from the filename or the content)
+	ContentType string
+
+	Content io.Reader
+
+	data []byte
+}
+
+type attachmentJSON struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// Bytes returns the content of the attachment once it has been read by the Mailer or the Outbox
+func (a Attachment) Bytes() []byte {
+	return a.data
+}
+
+// MarshalJSON encodes the attachment along with its content so that messages can be stored in the Outbox
+func (a Attachment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(attachmentJSON{
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Data:        a.data,
+	})
+}
+
+// UnmarshalJSON decodes an attachment encoded by MarshalJSON
+func (a *Attachment) UnmarshalJSON(b []byte) error {
+	var j attachmentJSON
+
+	err := json.Unmarshal(b, &j)
+	if err != nil {
+		return err
+	}
+
+	a.Filename = j.Filename
+	a.ContentType = j.ContentType
+	a.Content = bytes.NewReader(j.Data)
+	a.data = j.Data
+
+	return nil
+}
+
+// readAttachments reads the content of the message's attachments into memory so that it can be sent (or retried)
+// by any provider. The attachments are copied so that the caller's message is not modified.
+func readAttachments(msg Message) (Message, error) {
+	if len(msg.Attachments) == 0 {
+		return msg, nil
+	}
+
+	attachments := make([]Attachment, len(msg.Attachments))
+
+	for i, a := range msg.Attachments {
+		if a.Filename == "" {
+			return Message{}, cerrors.New(nil, "attachment has no filename", nil)
+		}
+
+		if a.data == nil {
+			if a.Content == nil {
+				return Message{}, cerrors.New(nil, "attachment has no content", map[string]interface{}{
+					"filename": a.Filename,
+				})
+			}
+
+			data, err := ioutil.ReadAll(a.Content)
+			if err != nil {
+				return Message{}, cerrors.New(err, "failed to read attachment", map[string]interface{}{
+					"filename": a.Filename,
+				})
+			}
+
+			a.data = data
+			a.Content = bytes.NewReader(data)
+		}
+
+		if a.ContentType == "" {
+			a.ContentType = mime.TypeByExtension(path.Ext(a.Filename))
+		}
+
+		if a.ContentType == "" {
+			a.ContentType = http.DetectContentType(a.data)
+		}
+
+		attachments[i] = a
+	}
+
+	msg.Attachments = attachments
+
+	return msg, nil
+}
@@ -0,0 +1,461 @@
+package cmailer
+
+import (
+	"context"
+	"database/sql"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cqueue"
+	"github.com/gocopper/copper/csql"
+)
+
+// BulkJobType is the type of the cqueue jobs that send the batches of a campaign
+const BulkJobType = "cmailer_bulk_batch"
+
+// BulkSend is a templated message sent to a list of recipients using Bulk.Send
+type BulkSend struct {
+	// Name identifies the campaign in its progress reports (ex. weekly-digest-2024-06-01)
+	Name string
+
+	// Message holds the fields shared by all recipients (ex. From, ReplyTo, Headers). Its To field is set for each
+	// recipient and its bodies are rendered from Template.
+	Message Message
+
+	// Template is rendered for each recipient. Its Data and Locale are ignored in favor of Data and the recipient's.
+	Template Template
+
+	// Data is the template data shared by all recipients. It is merged with each recipient's Vars.
+	Data map[string]interface{}
+
+	Recipients []Recipient
+}
+
+// Recipient is an addressee of a campaign. The template is rendered with its Vars merged into BulkSend.Data, along
+// with the recipient itself as .Recipient.
+type Recipient struct {
+	Email  string                 `json:"email"`
+	Name   string                 `json:"name,omitempty"`
+	Locale string                 `json:"locale,omitempty"`
+	Vars   map[string]interface{} `json:"vars,omitempty"`
+}
+
+// bulkBatch is the payload of a BulkJobType job
+type bulkBatch struct {
+	CampaignID string                 `json:"campaign_id"`
+	Message    Message                `json:"message"`
+	Template   Template               `json:"template"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	Recipients []Recipient            `json:"recipients"`
+}
+
+// NewBulkParams holds the params needed for NewBulk
+type NewBulkParams struct {
+	DB        *sql.DB
+	Queries   *Queries
+	Mailer    *Mailer
+	Queue     *cqueue.Queue
+	Config    Config
+	SQLConfig csql.Config
+	Logger    clogger.Logger
+}
+
+// NewBulk creates a new Bulk and registers the handler of its jobs on the queue
+func NewBulk(p NewBulkParams) *Bulk {
+	rateLimit := p.Config.Bulk.RateLimit
+	if rateLimit <= 0 {
+		rateLimit = defaultBulkRateLimits[p.Config.Provider]
+	}
+
+	b := &Bulk{
+		db:      p.DB,
+		queries: p.Queries,
+		mailer:  p.Mailer,
+		queue:   p.Queue,
+		config:  p.Config.Bulk,
+		dialect: p.SQLConfig.Dialect,
+		limiter: newRateLimiter(rateLimit),
+		logger:  p.Logger.With(clogger.Module("cmailer")),
+		now:     time.Now,
+	}
+
+	cqueue.Register(p.Queue, BulkJobType, b.sendBatch)
+
+	return b
+}
+
+// Bulk sends templated messages to large lists of recipients (ex. newsletters or product announcements). A campaign
+// is split into batches of cmailer.bulk.batch_size recipients that are sent by cqueue jobs, so the worker that runs
+// the jobs must create a Bulk for its handler to be registered. Sends are throttled to cmailer.bulk.rate_limit and
+// skip the addresses in the suppression list. Failed sends are counted in the campaign's progress and are not
+// retried so that recipients never get the same message twice.
+// Send, Progress, Cancel, and the suppression list methods must be called with a context that has a database
+// transaction (see csql.CtxWithTx).
+type Bulk struct {
+	db      *sql.DB
+	queries *Queries
+	mailer  *Mailer
+	queue   *cqueue.Queue
+	config  ConfigBulk
+	dialect string
+	limiter *rateLimiter
+	logger  clogger.Logger
+	now     func() time.Time
+}
+
+// Send starts a campaign and enqueues the jobs that send it. Duplicate recipients are only sent to once. The
+// returned campaign's id can be used to check its progress.
+func (b *Bulk) Send(ctx context.Context, send BulkSend) (*Campaign, error) {
+	if send.Template.Name == "" {
+		return nil, cerrors.New(nil, "campaign has no template", map[string]interface{}{
+			"name": send.Name,
+		})
+	}
+
+	recipients, err := uniqueRecipients(send.Recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(recipients) == 0 {
+		return nil, cerrors.New(nil, "campaign has no recipients", map[string]interface{}{
+			"name": send.Name,
+		})
+	}
+
+	now := b.now()
+	campaign := Campaign{
+		ID:        newID(),
+		Name:      send.Name,
+		Status:    CampaignStatusSending,
+		Total:     len(recipients),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err = b.queries.InsertCampaign(ctx, &campaign)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to insert campaign", map[string]interface{}{
+			"name": send.Name,
+		})
+	}
+
+	send.Message.To = nil
+	send.Template.Data = nil
+	send.Template.Locale = ""
+
+	err = b.enqueue(ctx, bulkBatch{
+		CampaignID: campaign.ID,
+		Message:    send.Message,
+		Template:   send.Template,
+		Data:       send.Data,
+		Recipients: recipients,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &campaign, nil
+}
+
+// Progress returns the campaign with the given id along with its progress
+func (b *Bulk) Progress(ctx context.Context, id string) (*Campaign, error) {
+	campaign, err := b.queries.GetCampaign(ctx, id)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to get campaign", map[string]interface{}{
+			"id": id,
+		})
+	}
+
+	return campaign, nil
+}
+
+// Campaigns returns the most recent campaigns
+func (b *Bulk) Campaigns(ctx context.Context, limit int) ([]Campaign, error) {
+	campaigns, err := b.queries.ListCampaigns(ctx, limit)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to list campaigns", nil)
+	}
+
+	return campaigns, nil
+}
+
+// Cancel stops a campaign that is sending. The batches that have not started are not sent, but a batch that is
+// sending finishes.
+func (b *Bulk) Cancel(ctx context.Context, id string) error {
+	ok, err := b.queries.UpdateCampaignStatus(ctx, id, CampaignStatusSending, CampaignStatusCanceled, b.now())
+	if err != nil {
+		return cerrors.New(err, "failed to update campaign status", map[string]interface{}{
+			"id": id,
+		})
+	}
+
+	if !ok {
+		return cerrors.New(nil, "only campaigns that are sending can be canceled", map[string]interface{}{
+			"id": id,
+		})
+	}
+
+	return nil
+}
+
+// Suppress adds an email address to the suppression list so that campaigns are no longer sent to it (ex. when it
+// unsubscribes or hard bounces). Transactional emails sent using Mailer are not affected.
+func (b *Bulk) Suppress(ctx context.Context, email, reason string) error {
+	err := b.queries.InsertSuppression(ctx, &Suppression{
+		Email:     normalizeEmail(email),
+		Reason:    reason,
+		CreatedAt: b.now(),
+	})
+	if err != nil {
+		return cerrors.New(err, "failed to insert suppression", map[string]interface{}{
+			"reason": reason,
+		})
+	}
+
+	return nil
+}
+
+// Unsuppress removes an email address from the suppression list
+func (b *Bulk) Unsuppress(ctx context.Context, email string) error {
+	err := b.queries.DeleteSuppression(ctx, normalizeEmail(email))
+	if err != nil {
+		return cerrors.New(err, "failed to delete suppression", nil)
+	}
+
+	return nil
+}
+
+// IsSuppressed returns true if the email address is in the suppression list
+func (b *Bulk) IsSuppressed(ctx context.Context, email string) (bool, error) {
+	suppressions, err := b.queries.ListSuppressions(ctx, []string{normalizeEmail(email)})
+	if err != nil {
+		return false, cerrors.New(err, "failed to list suppressions", nil)
+	}
+
+	return len(suppressions) > 0, nil
+}
+
+// enqueue splits the batch into jobs of cmailer.bulk.batch_size recipients
+func (b *Bulk) enqueue(ctx context.Context, batch bulkBatch) error {
+	size := b.config.BatchSize
+	if size <= 0 {
+		size = defaultBulkBatchSize
+	}
+
+	recipients := batch.Recipients
+
+	for start := 0; start < len(recipients); start += size {
+		end := start + size
+		if end > len(recipients) {
+			end = len(recipients)
+		}
+
+		batch.Recipients = recipients[start:end]
+
+		_, err := b.queue.EnqueueWithOptions(ctx, BulkJobType, batch, cqueue.EnqueueOptions{Queue: b.config.Queue})
+		if err != nil {
+			return cerrors.New(err, "failed to enqueue campaign batch", map[string]interface{}{
+				"campaignID": batch.CampaignID,
+			})
+		}
+	}
+
+	return nil
+}
+
+// sendBatch is the handler of BulkJobType jobs. If the worker stops in the middle of the batch, the progress so far is
+// saved and the remaining recipients are enqueued as a new batch.
+func (b *Bulk) sendBatch(ctx context.Context, batch bulkBatch) error {
+	var (
+		campaign   *Campaign
+		suppressed map[string]bool
+	)
+
+	err := b.inTx(ctx, func(ctx context.Context) error {
+		var err error
+
+		campaign, err = b.queries.GetCampaign(ctx, batch.CampaignID)
+		if err != nil {
+			return cerrors.New(err, "failed to get campaign", nil)
+		}
+
+		suppressed, err = b.suppressed(ctx, batch.Recipients)
+
+		return err
+	})
+	if err != nil {
+		return cerrors.New(err, "failed to load campaign batch", map[string]interface{}{
+			"campaignID": batch.CampaignID,
+		})
+	}
+
+	if campaign.Status != CampaignStatusSending {
+		return nil
+	}
+
+	var (
+		progress CampaignProgress
+		log      = b.logger.WithTags(map[string]interface{}{
+			"campaignID": campaign.ID,
+			"campaign":   campaign.Name,
+		})
+		i int
+	)
+
+	for ; i < len(batch.Recipients) && ctx.Err() == nil; i++ {
+		r := batch.Recipients[i]
+
+		if suppressed[normalizeEmail(r.Email)] {
+			progress.Suppressed++
+			continue
+		}
+
+		err = b.send(ctx, batch, r)
+		if err != nil && ctx.Err() != nil {
+			// the worker is stopping so the recipient is sent to by the remaining batch
+			break
+		}
+
+		if err != nil {
+			progress.Failed++
+			progress.LastError = err.Error()
+
+			log.WithTags(map[string]interface{}{
+				"to": r.Email,
+			}).Warn("Failed to send campaign email", err)
+
+			continue
+		}
+
+		progress.Sent++
+	}
+
+	remaining := batch
+	remaining.Recipients = batch.Recipients[i:]
+
+	// the progress is saved even if the worker is stopping so that the sent recipients are not sent to again
+	err = b.inTx(context.Background(), func(ctx context.Context) error {
+		err := b.queries.AddCampaignProgress(ctx, campaign.ID, progress, b.now())
+		if err != nil {
+			return cerrors.New(err, "failed to save campaign progress", nil)
+		}
+
+		if len(remaining.Recipients) == 0 {
+			return nil
+		}
+
+		return b.enqueue(ctx, remaining)
+	})
+	if err != nil {
+		return cerrors.New(err, "failed to save campaign batch", map[string]interface{}{
+			"campaignID": campaign.ID,
+		})
+	}
+
+	return nil
+}
+
+func (b *Bulk) send(ctx context.Context, batch bulkBatch, r Recipient) error {
+	data := make(map[string]interface{}, len(batch.Data)+len(r.Vars)+1)
+
+	for k, v := range batch.Data {
+		data[k] = v
+	}
+
+	for k, v := range r.Vars {
+		data[k] = v
+	}
+
+	data["Recipient"] = r
+
+	tmpl := batch.Template
+	tmpl.Data = data
+	tmpl.Locale = r.Locale
+
+	msg := batch.Message
+	msg.To = []string{(&mail.Address{Name: r.Name, Address: r.Email}).String()}
+
+	msg, err := b.mailer.render(ctx, msg, tmpl)
+	if err != nil {
+		return err
+	}
+
+	err = b.limiter.wait(ctx)
+	if err != nil {
+		return cerrors.New(err, "context finished while waiting for the bulk rate limit", nil)
+	}
+
+	return b.mailer.Send(ctx, msg)
+}
+
+// suppressed returns the normalized addresses of the recipients that are in the suppression list
+func (b *Bulk) suppressed(ctx context.Context, recipients []Recipient) (map[string]bool, error) {
+	emails := make([]string, len(recipients))
+	for i := range recipients {
+		emails[i] = normalizeEmail(recipients[i].Email)
+	}
+
+	suppressions, err := b.queries.ListSuppressions(ctx, emails)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to list suppressions", nil)
+	}
+
+	suppressed := make(map[string]bool, len(suppressions))
+	for i := range suppressions {
+		suppressed[suppressions[i].Email] = true
+	}
+
+	return suppressed, nil
+}
+
+func (b *Bulk) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, tx, err := csql.CtxWithTx(ctx, b.db, b.dialect)
+	if err != nil {
+		return err
+	}
+
+	err = fn(ctx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// uniqueRecipients validates the recipients' addresses and removes the duplicates
+func uniqueRecipients(recipients []Recipient) ([]Recipient, error) {
+	var (
+		unique = make([]Recipient, 0, len(recipients))
+		seen   = make(map[string]bool, len(recipients))
+	)
+
+	for _, r := range recipients {
+		addr, err := mail.ParseAddress(r.Email)
+		if err != nil {
+			return nil, cerrors.New(err, "invalid recipient email address", map[string]interface{}{
+				"address": r.Email,
+			})
+		}
+
+		r.Email = addr.Address
+
+		if seen[normalizeEmail(r.Email)] {
+			continue
+		}
+
+		seen[normalizeEmail(r.Email)] = true
+
+		unique = append(unique, r)
+	}
+
+	return unique, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
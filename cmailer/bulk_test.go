@@ -0,0 +1,164 @@
+package cmailer_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cmailer"
+	"github.com/gocopper/copper/cqueue"
+	"github.com/gocopper/copper/cqueue/cqueuetest"
+	"github.com/gocopper/copper/csql"
+	"github.com/gocopper/copper/csql/csqltest"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+type bulkTestProvider struct {
+	sent    []cmailer.Message
+	failFor string
+}
+
+func (p *bulkTestProvider) Send(ctx context.Context, msg cmailer.Message) error {
+	if strings.Contains(msg.To[0], p.failFor) {
+		return errors.New("mailbox unavailable")
+	}
+
+	p.sent = append(p.sent, msg)
+
+	return nil
+}
+
+func TestBulk_Send(t *testing.T) {
+	t.Parallel()
+
+	testDB := csqltest.NewDB(t, csqltest.Options{Migrations: csql.Migrations(cmailer.Migrations)})
+
+	var (
+		db        = testDB.DB
+		sqlConfig = testDB.Config
+		err       error
+	)
+
+	var (
+		provider = bulkTestProvider{failFor: "dave@"}
+		config   = cmailer.Config{
+			From: "hello@example.com",
+			Bulk: cmailer.ConfigBulk{Queue: cqueue.DefaultQueue, BatchSize: 2},
+		}
+		queueConfig = cqueue.Config{MaxAttempts: 1, JobTimeout: time.Minute}
+		backend     = cqueuetest.NewBackend()
+		queue       = cqueue.NewQueue(cqueue.NewQueueParams{Backend: backend, Config: queueConfig})
+		worker      = cqueue.NewWorker(cqueue.NewWorkerParams{
+			Queue:     queue,
+			Backend:   backend,
+			Lifecycle: clifecycle.New(),
+			Config:    queueConfig,
+			Logger:    clogger.NewNoop(),
+		})
+		bulk = cmailer.NewBulk(cmailer.NewBulkParams{
+			DB:      db,
+			Queries: cmailer.NewQueries(testDB.Querier),
+			Mailer: cmailer.NewMailer(cmailer.NewMailerParams{
+				Config:    config,
+				Provider:  &provider,
+				Templates: newTestTemplates(),
+				Logger:    clogger.NewNoop(),
+			}),
+			Queue:     queue,
+			Config:    config,
+			SQLConfig: sqlConfig,
+			Logger:    clogger.NewNoop(),
+		})
+		campaign, canceled *cmailer.Campaign
+	)
+
+	inTx := func(fn func(ctx context.Context)) {
+		ctx, tx, err := csql.CtxWithTx(context.Background(), db, "sqlite3")
+		assert.NoError(t, err)
+
+		fn(ctx)
+
+		assert.NoError(t, tx.Commit())
+	}
+
+	processAll := func() {
+		for {
+			n, err := worker.ProcessPending(context.Background(), cqueue.DefaultQueue)
+			assert.NoError(t, err)
+
+			if n == 0 {
+				return
+			}
+		}
+	}
+
+	inTx(func(ctx context.Context) {
+		assert.NoError(t, bulk.Suppress(ctx, "Bob@Example.com", "unsubscribed"))
+		assert.NoError(t, bulk.Suppress(ctx, "bob@example.com", "bounced"))
+
+		suppressed, err := bulk.IsSuppressed(ctx, "bob@example.com")
+		assert.NoError(t, err)
+		assert.True(t, suppressed)
+
+		campaign, err = bulk.Send(ctx, cmailer.BulkSend{
+			Name:     "welcome",
+			Template: cmailer.Template{Name: "welcome"},
+			Data:     map[string]interface{}{"Name": "there"},
+			Recipients: []cmailer.Recipient{
+				{Email: "alice@example.com", Name: "Alice", Vars: map[string]interface{}{"Name": "Alice"}},
+				{Email: "bob@example.com"},
+				{Email: "carol@example.com", Locale: "fr", Vars: map[string]interface{}{"Name": "Carol"}},
+				{Email: "Alice <alice@example.com>"},
+				{Email: "dave@example.com"},
+			},
+		})
+		assert.NoError(t, err)
+
+		canceled, err = bulk.Send(ctx, cmailer.BulkSend{
+			Name:       "canceled",
+			Template:   cmailer.Template{Name: "welcome"},
+			Recipients: []cmailer.Recipient{{Email: "erin@example.com"}},
+		})
+		assert.NoError(t, err)
+
+		assert.NoError(t, bulk.Cancel(ctx, canceled.ID))
+
+		_, err = bulk.Send(ctx, cmailer.BulkSend{Name: "invalid", Template: cmailer.Template{Name: "welcome"}})
+		assert.Error(t, err)
+	})
+
+	assert.Equal(t, 4, campaign.Total)
+	assert.Len(t, backend.JobsOfType(cmailer.BulkJobType), 3)
+
+	processAll()
+
+	assert.Len(t, provider.sent, 2)
+	assert.Equal(t, []string{`"Alice" <alice@example.com>`}, provider.sent[0].To)
+	assert.Equal(t, "Welcome, Alice & co", provider.sent[0].Subject)
+	assert.Equal(t, []string{"<carol@example.com>"}, provider.sent[1].To)
+	assert.Equal(t, "Bienvenue Carol", provider.sent[1].Subject)
+
+	inTx(func(ctx context.Context) {
+		campaign, err = bulk.Progress(ctx, campaign.ID)
+		assert.NoError(t, err)
+
+		canceled, err = bulk.Progress(ctx, canceled.ID)
+		assert.NoError(t, err)
+	})
+
+	assert.Equal(t, cmailer.CampaignStatusCompleted, campaign.Status)
+	assert.Equal(t, 2, campaign.Sent)
+	assert.Equal(t, 1, campaign.Failed)
+	assert.Equal(t, 1, campaign.Suppressed)
+	assert.Equal(t, 0, campaign.Pending())
+	assert.Contains(t, campaign.LastError, "mailbox unavailable")
+	assert.True(t, campaign.CompletedAt.Valid)
+
+	assert.Equal(t, cmailer.CampaignStatusCanceled, canceled.Status)
+	assert.Equal(t, 1, canceled.Pending())
+}
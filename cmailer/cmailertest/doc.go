@@ -0,0 +1,3 @@
+// Package cmailertest provides a cmailer.Provider that captures sent emails in memory so that tests can assert on
+// them, along with a web UI to browse the captured emails during development.
+package cmailertest
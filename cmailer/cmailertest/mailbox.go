@@ -0,0 +1,173 @@
+package cmailertest
+
+import (
+	"context"
+	"net/mail"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cmailer"
+)
+
+var linkRegexp = regexp.MustCompile(`https?://[^\s"'<>)]+`) //nolint:gochecknoglobals
+
+// Email is a message captured by Mailbox
+type Email struct {
+	// ID is the position of the email in the mailbox, starting at 1
+	ID     int
+	SentAt time.Time
+	cmailer.Message
+}
+
+// NewMailbox creates an empty Mailbox
+func NewMailbox() *Mailbox {
+	return &Mailbox{}
+}
+
+// Mailbox is a cmailer.Provider that keeps sent messages in memory instead of sending them. Use it as the mailer's
+// provider in tests:
+//
+//	mailbox := cmailertest.NewMailbox()
+//	mailer := cmailer.NewMailer(cmailer.NewMailerParams{Config: config, Provider: mailbox, Logger: logger})
+//
+//	// sign up..
+//
+//	email, ok := mailbox.LastTo("alice@example.com")
+//	link := cmailertest.Links(email.Message)[0]
+type Mailbox struct {
+	mu     sync.Mutex
+	emails []Email
+	err    error
+}
+
+// SetErr makes Send return err instead of capturing the message (ex. to test how a handler deals with a provider
+// outage). Pass nil to capture messages again.
+func (m *Mailbox) SetErr(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.err = err
+}
+
+// Send captures the message
+func (m *Mailbox) Send(ctx context.Context, msg cmailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+
+	m.emails = append(m.emails, Email{
+		ID:      len(m.emails) + 1,
+		SentAt:  time.Now(),
+		Message: msg,
+	})
+
+	return nil
+}
+
+// Emails returns the captured emails in the order they were sent
+func (m *Mailbox) Emails() []Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Email(nil), m.emails...)
+}
+
+// Len returns the number of captured emails
+func (m *Mailbox) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.emails)
+}
+
+// Get returns the email with the given id
+func (m *Mailbox) Get(id int) (Email, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id < 1 || id > len(m.emails) {
+		return Email{}, false
+	}
+
+	return m.emails[id-1], true
+}
+
+// Last returns the most recent email
+func (m *Mailbox) Last() (Email, bool) {
+	return m.findLast(func(Email) bool { return true })
+}
+
+// LastTo returns the most recent email sent to the given address as a To, Cc, or Bcc recipient. Display names are
+// ignored and addresses are compared case-insensitively.
+func (m *Mailbox) LastTo(address string) (Email, bool) {
+	return m.findLast(func(e Email) bool {
+		for _, list := range [][]string{e.To, e.Cc, e.Bcc} {
+			for _, addr := range list {
+				if strings.EqualFold(bareAddress(addr), bareAddress(address)) {
+					return true
+				}
+			}
+		}
+
+		return false
+	})
+}
+
+// Find returns the most recent email with the given subject
+func (m *Mailbox) Find(subject string) (Email, bool) {
+	return m.findLast(func(e Email) bool {
+		return e.Subject == subject
+	})
+}
+
+// Reset removes all captured emails
+func (m *Mailbox) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.emails = nil
+}
+
+func (m *Mailbox) findLast(match func(e Email) bool) (Email, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.emails) - 1; i >= 0; i-- {
+		if match(m.emails[i]) {
+			return m.emails[i], true
+		}
+	}
+
+	return Email{}, false
+}
+
+// Links returns the http(s) links in the message's plain text body, or its HTML body if it has no plain text body.
+// It can be used to follow verification and password reset links.
+func Links(msg cmailer.Message) []string {
+	body := msg.PlainBody
+	if body == "" {
+		body = msg.HTMLBody
+	}
+
+	links := linkRegexp.FindAllString(body, -1)
+	for i := range links {
+		// links at the end of a sentence are not followed by a space
+		links[i] = strings.TrimRight(strings.ReplaceAll(links[i], "&amp;", "&"), ".,;:!?")
+	}
+
+	return links
+}
+
+func bareAddress(addr string) string {
+	a, err := mail.ParseAddress(addr)
+	if err != nil {
+		return addr
+	}
+
+	return a.Address
+}
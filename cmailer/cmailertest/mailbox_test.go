@@ -0,0 +1,76 @@
+package cmailertest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cmailer"
+	"github.com/gocopper/copper/cmailer/cmailertest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMailbox(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mailbox = cmailertest.NewMailbox()
+		mailer  = cmailer.NewMailer(cmailer.NewMailerParams{
+			Config:   cmailer.Config{From: "hello@example.com"},
+			Provider: mailbox,
+			Logger:   clogger.NewNoop(),
+		})
+	)
+
+	assert.NoError(t, mailer.Send(context.Background(), cmailer.Message{
+		To:        []string{"Alice <alice@example.com>"},
+		Subject:   "Verify your email",
+		PlainBody: "Verify your email at https://example.com/verify?token=abc.",
+	}))
+
+	assert.NoError(t, mailer.Send(context.Background(), cmailer.Message{
+		To:       []string{"bob@example.com"},
+		Bcc:      []string{"alice@example.com"},
+		Subject:  "Reset your password",
+		HTMLBody: `<a href="https://example.com/reset?token=xyz&amp;next=%2F">Reset</a>`,
+	}))
+
+	assert.Equal(t, 2, mailbox.Len())
+
+	email, ok := mailbox.LastTo("ALICE@example.com")
+	assert.True(t, ok)
+	assert.Equal(t, "Reset your password", email.Subject)
+	assert.Equal(t, []string{"https://example.com/reset?token=xyz&next=%2F"}, cmailertest.Links(email.Message))
+
+	email, ok = mailbox.Find("Verify your email")
+	assert.True(t, ok)
+	assert.Equal(t, 1, email.ID)
+	assert.Equal(t, []string{"https://example.com/verify?token=abc"}, cmailertest.Links(email.Message))
+
+	_, ok = mailbox.LastTo("carol@example.com")
+	assert.False(t, ok)
+
+	mailbox.Reset()
+
+	_, ok = mailbox.Last()
+	assert.False(t, ok)
+}
+
+func TestMailbox_SetErr(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mailbox = cmailertest.NewMailbox()
+		errTest = errors.New("test-err")
+		msg     = cmailer.Message{To: []string{"alice@example.com"}, PlainBody: "Hi"}
+	)
+
+	mailbox.SetErr(errTest)
+	assert.True(t, errors.Is(mailbox.Send(context.Background(), msg), errTest))
+	assert.Equal(t, 0, mailbox.Len())
+
+	mailbox.SetErr(nil)
+	assert.NoError(t, mailbox.Send(context.Background(), msg))
+	assert.Equal(t, 1, mailbox.Len())
+}
@@ -0,0 +1,171 @@
+package cmailertest
+
+import (
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+)
+
+// BasePath is the path of the mailbox web UI
+const BasePath = "/_mailbox"
+
+var (
+	listTemplate  = template.Must(template.New("list").Parse(listHTML))   //nolint:gochecknoglobals
+	emailTemplate = template.Must(template.New("email").Parse(emailHTML)) //nolint:gochecknoglobals
+)
+
+const listHTML = `<!doctype html>
+<html>
+<head><meta charset="utf-8"><title>Mailbox</title><style>` + uiCSS + `</style></head>
+<body>
+<header><h1>Mailbox</h1>
+<form method="post" action="{{ .BasePath }}/clear"><button type="submit">Clear</button></form></header>
+{{ if not .Emails }}<p class="empty">No emails have been sent.</p>{{ else }}
+<table>
+<tr><th>To</th><th>Subject</th><th>Sent</th></tr>
+{{ range .Emails }}<tr>
+<td>{{ range $i, $to := .To }}{{ if $i }}, {{ end }}{{ $to }}{{ end }}</td>
+<td><a href="{{ $.BasePath }}/{{ .ID }}">{{ if .Subject }}{{ .Subject }}{{ else }}(no subject){{ end }}</a></td>
+<td>{{ .SentAt.Format "15:04:05" }}</td>
+</tr>{{ end }}
+</table>{{ end }}
+</body>
+</html>`
+
+const emailHTML = `<!doctype html>
+<html>
+<head><meta charset="utf-8"><title>{{ .Email.Subject }}</title><style>` + uiCSS + `</style></head>
+<body>
+<header><h1><a href="{{ .BasePath }}">Mailbox</a> / {{ .Email.Subject }}</h1></header>
+<dl>
+<dt>From</dt><dd>{{ .Email.From }}</dd>
+<dt>To</dt><dd>{{ range $i, $to := .Email.To }}{{ if $i }}, {{ end }}{{ $to }}{{ end }}</dd>
+{{ if .Email.Cc }}<dt>Cc</dt><dd>{{ range $i, $cc := .Email.Cc }}{{ if $i }}, {{ end }}{{ $cc }}{{ end }}</dd>{{ end }}
+{{ if .Email.Bcc }}<dt>Bcc</dt><dd>{{ range $i, $bcc := .Email.Bcc }}{{ if $i }}, {{ end }}{{ $bcc }}{{ end }}</dd>{{ end }}
+{{ if .Email.ReplyTo }}<dt>Reply-To</dt><dd>{{ .Email.ReplyTo }}</dd>{{ end }}
+<dt>Sent</dt><dd>{{ .Email.SentAt.Format "2006-01-02 15:04:05" }}</dd>
+{{ range .Email.Attachments }}<dt>Attachment</dt><dd>{{ .Filename }} ({{ .ContentType }})</dd>{{ end }}
+</dl>
+{{ if .Email.HTMLBody }}<iframe sandbox src="{{ .BasePath }}/{{ .Email.ID }}/html"></iframe>{{ end }}
+{{ if .Email.PlainBody }}<pre>{{ .Email.PlainBody }}</pre>{{ end }}
+</body>
+</html>`
+
+const uiCSS = `body{font-family:system-ui,sans-serif;margin:2rem;color:#222}header{display:flex;align-items:center;
+justify-content:space-between}table{border-collapse:collapse;width:100%}th,td{text-align:left;padding:.5rem;
+border-bottom:1px solid #ddd}dl{display:grid;grid-template-columns:max-content auto;gap:.25rem 1rem}dt{color:#666}
+dd{margin:0}iframe{width:100%;height:60vh;border:1px solid #ddd}pre{white-space:pre-wrap;background:#f6f6f6;
+padding:1rem}.empty{color:#666}`
+
+// NewRouter creates a new Router
+func NewRouter(mailbox *Mailbox, logger clogger.Logger) *Router {
+	return &Router{
+		mailbox: mailbox,
+		logger:  logger,
+	}
+}
+
+// Router is a chttp.Router that serves a web UI to browse the emails captured by a Mailbox at /_mailbox. It is meant
+// for development, where the mailbox can be used as the app's cmailer.Provider so that emails can be read without
+// sending them. It must not be registered in production since it shows every email sent by the app.
+type Router struct {
+	mailbox *Mailbox
+	logger  clogger.Logger
+}
+
+// Routes returns the mailbox UI routes
+func (ro *Router) Routes() []chttp.Route {
+	return []chttp.Route{
+		{
+			Path:    BasePath,
+			Methods: []string{http.MethodGet},
+			Handler: ro.HandleList,
+		},
+		{
+			Path:    BasePath + "/clear",
+			Methods: []string{http.MethodPost},
+			Handler: ro.HandleClear,
+		},
+		{
+			Path:    BasePath + "/{id:[0-9]+}",
+			Methods: []string{http.MethodGet},
+			Handler: ro.HandleEmail,
+		},
+		{
+			Path:    BasePath + "/{id:[0-9]+}/html",
+			Methods: []string{http.MethodGet},
+			Handler: ro.HandleHTMLBody,
+		},
+	}
+}
+
+// HandleList renders the captured emails, most recent first
+func (ro *Router) HandleList(w http.ResponseWriter, r *http.Request) {
+	emails := ro.mailbox.Emails()
+
+	for i, j := 0, len(emails)-1; i < j; i, j = i+1, j-1 {
+		emails[i], emails[j] = emails[j], emails[i]
+	}
+
+	ro.render(w, listTemplate, map[string]interface{}{
+		"BasePath": BasePath,
+		"Emails":   emails,
+	})
+}
+
+// HandleEmail renders a captured email
+func (ro *Router) HandleEmail(w http.ResponseWriter, r *http.Request) {
+	email, ok := ro.email(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	ro.render(w, emailTemplate, map[string]interface{}{
+		"BasePath": BasePath,
+		"Email":    email,
+	})
+}
+
+// HandleHTMLBody responds with the HTML body of a captured email. It is shown in a sandboxed iframe by HandleEmail.
+func (ro *Router) HandleHTMLBody(w http.ResponseWriter, r *http.Request) {
+	email, ok := ro.email(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "sandbox")
+
+	_, _ = w.Write([]byte(email.HTMLBody))
+}
+
+// HandleClear removes all captured emails
+func (ro *Router) HandleClear(w http.ResponseWriter, r *http.Request) {
+	ro.mailbox.Reset()
+
+	http.Redirect(w, r, BasePath, http.StatusSeeOther)
+}
+
+func (ro *Router) email(r *http.Request) (Email, bool) {
+	id, err := strconv.Atoi(chttp.URLParams(r)["id"])
+	if err != nil {
+		return Email{}, false
+	}
+
+	return ro.mailbox.Get(id)
+}
+
+func (ro *Router) render(w http.ResponseWriter, tmpl *template.Template, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	err := tmpl.Execute(w, data)
+	if err != nil {
+		ro.logger.Error("Failed to render mailbox page", err)
+	}
+}
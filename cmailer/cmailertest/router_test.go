@@ -0,0 +1,69 @@
+package cmailertest_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cmailer"
+	"github.com/gocopper/copper/cmailer/cmailertest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter(t *testing.T) {
+	t.Parallel()
+
+	mailbox := cmailertest.NewMailbox()
+
+	assert.NoError(t, mailbox.Send(context.Background(), cmailer.Message{
+		From:      "hello@example.com",
+		To:        []string{"alice@example.com"},
+		Subject:   "Welcome <3",
+		HTMLBody:  "<h1>Welcome!</h1>",
+		PlainBody: "Welcome!",
+	}))
+
+	server := httptest.NewServer(chttp.NewHandler(chttp.NewHandlerParams{
+		Routers: []chttp.Router{cmailertest.NewRouter(mailbox, clogger.NewNoop())},
+		Logger:  clogger.NewNoop(),
+	}))
+	defer server.Close()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(server.URL + path) //nolint:noctx
+		assert.NoError(t, err)
+
+		defer func() { _ = resp.Body.Close() }()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := get("/_mailbox")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `<a href="/_mailbox/1">Welcome &lt;3</a>`)
+
+	status, body = get("/_mailbox/1")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `<iframe sandbox src="/_mailbox/1/html">`)
+	assert.Contains(t, body, "<pre>Welcome!</pre>")
+
+	status, body = get("/_mailbox/1/html")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "<h1>Welcome!</h1>", body)
+
+	status, _ = get("/_mailbox/2")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	resp, err := http.Post(server.URL+"/_mailbox/clear", "", nil) //nolint:noctx
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 0, mailbox.Len())
+}
@@ -0,0 +1,14 @@
+package cmailertest
+
+import (
+	"github.com/gocopper/copper/cmailer"
+	"github.com/google/wire"
+)
+
+// WireModule provides a Mailbox as the app's cmailer.Provider along with the mailbox UI Router. Use it in place of
+// cmailer.NewProvider in development builds.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	NewMailbox,
+	wire.Bind(new(cmailer.Provider), new(*Mailbox)),
+	NewRouter,
+)
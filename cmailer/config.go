@@ -0,0 +1,203 @@
+package cmailer
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/cqueue"
+)
+
+// Providers supported by NewProvider
+const (
+	ProviderLog      = "log"
+	ProviderSMTP     = "smtp"
+	ProviderSES      = "ses"
+	ProviderSendGrid = "sendgrid"
+	ProviderMailgun  = "mailgun"
+)
+
+const (
+	defaultSMTPPort    = 587
+	defaultSendTimeout = 30 * time.Second
+
+	defaultOutboxMaxAttempts    = 8
+	defaultOutboxBaseBackoff    = 30 * time.Second
+	defaultOutboxMaxBackoff     = time.Hour
+	defaultOutboxPollInterval   = 5 * time.Second
+	defaultOutboxBatchSize      = 10
+	defaultOutboxSendingTimeout = 10 * time.Minute
+
+	defaultBulkBatchSize = 100
+)
+
+// defaultBulkRateLimits are the max messages per second sent by Bulk for each provider if cmailer.bulk.rate_limit
+// is not set. They stay below the providers' usual sending quotas so that campaigns leave room for other emails.
+var defaultBulkRateLimits = map[string]float64{ //nolint:gochecknoglobals
+	ProviderSMTP:     5,
+	ProviderSES:      10,
+	ProviderSendGrid: 50,
+	ProviderMailgun:  50,
+}
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "cmailer",
+		Description: "cmailer configures the email provider",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("cmailer", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load cmailer config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Provider: ProviderLog,
+		Timeout:  defaultSendTimeout,
+		Templates: ConfigTemplates{
+			Layout: "email.html",
+		},
+		SMTP: ConfigSMTP{
+			Port: defaultSMTPPort,
+		},
+		Outbox: ConfigOutbox{
+			MaxAttempts:    defaultOutboxMaxAttempts,
+			BaseBackoff:    defaultOutboxBaseBackoff,
+			MaxBackoff:     defaultOutboxMaxBackoff,
+			PollInterval:   defaultOutboxPollInterval,
+			BatchSize:      defaultOutboxBatchSize,
+			SendingTimeout: defaultOutboxSendingTimeout,
+		},
+		Bulk: ConfigBulk{
+			Queue:     cqueue.DefaultQueue,
+			BatchSize: defaultBulkBatchSize,
+		},
+	}
+}
+
+// Config configures the mailer. For example:
+//
+//	[cmailer]
+//	provider = "sendgrid"
+//	from = "hello@example.com"
+//	rate_limit = 10
+//
+//	[cmailer.sendgrid]
+//	api_key = "SG.xxx"
+type Config struct {
+	// Provider is one of log, smtp, ses, sendgrid, or mailgun
+	Provider string `toml:"provider" valid:"in(log|smtp|ses|sendgrid|mailgun)" doc:"log, smtp, ses, sendgrid, or mailgun"`
+
+	// From is the sender used for messages that do not set one
+	From string `toml:"from" doc:"Sender used for messages that do not set one"`
+
+	// FromName is the display name of the default sender (ex. Copper)
+	FromName string `toml:"from_name" doc:"Display name of the default sender"`
+
+	// DryRun logs messages instead of sending them, regardless of the provider
+	DryRun bool `toml:"dry_run" doc:"Log messages instead of sending them"`
+
+	// RateLimit is the max number of messages sent per second (unlimited if 0). Sends wait for their turn.
+	RateLimit float64 `toml:"rate_limit" doc:"Max messages sent per second (unlimited if 0)"`
+
+	// Timeout is the max duration of a single send
+	Timeout time.Duration `toml:"timeout" doc:"Max duration of a single send"`
+
+	Templates ConfigTemplates `toml:"templates"`
+	Outbox    ConfigOutbox    `toml:"outbox"`
+	Bulk      ConfigBulk      `toml:"bulk"`
+
+	SMTP     ConfigSMTP     `toml:"smtp"`
+	SES      ConfigSES      `toml:"ses"`
+	SendGrid ConfigSendGrid `toml:"sendgrid"`
+	Mailgun  ConfigMailgun  `toml:"mailgun"`
+}
+
+// ConfigTemplates configures the email templates (see Templates)
+type ConfigTemplates struct {
+	// Layout is the default layout in src/emails/layouts
+	Layout string `toml:"layout" doc:"Default layout in src/emails/layouts"`
+}
+
+// ConfigOutbox configures the delivery of the emails queued in the Outbox
+type ConfigOutbox struct {
+	// MaxAttempts is the number of times an email is attempted before it is dead-lettered
+	MaxAttempts int `toml:"max_attempts"`
+
+	// BaseBackoff is the wait time after the first failed attempt. It doubles after each failed attempt up to
+	// MaxBackoff.
+	BaseBackoff time.Duration `toml:"base_backoff"`
+	MaxBackoff  time.Duration `toml:"max_backoff"`
+
+	// PollInterval is how often the OutboxWorker checks for pending emails
+	PollInterval time.Duration `toml:"poll_interval"`
+
+	// BatchSize is the max number of emails attempted in a single poll
+	BatchSize int `toml:"batch_size"`
+
+	// SendingTimeout is how long an email can stay in the sending status before it is claimed again. This recovers
+	// emails whose worker stopped in the middle of an attempt.
+	SendingTimeout time.Duration `toml:"sending_timeout"`
+}
+
+// ConfigBulk configures the campaigns sent using Bulk
+type ConfigBulk struct {
+	// Queue is the cqueue queue that runs the campaigns' batches
+	Queue string `toml:"queue"`
+
+	// BatchSize is the number of recipients sent to by a single job
+	BatchSize int `toml:"batch_size"`
+
+	// RateLimit is the max number of campaign messages sent per second by each worker process. It defaults to a
+	// rate that suits the provider. Campaign messages are also subject to cmailer.rate_limit.
+	RateLimit float64 `toml:"rate_limit"`
+}
+
+// ConfigSMTP configures the SMTP provider. STARTTLS is used if the server supports it, or TLS from the start if
+// ImplicitTLS is set (usually port 465).
+type ConfigSMTP struct {
+	Host        string `toml:"host"`
+	Port        int    `toml:"port"`
+	Username    string `toml:"username"`
+	Password    string `toml:"password"`
+	ImplicitTLS bool   `toml:"implicit_tls"`
+}
+
+// ConfigSES configures the AWS SES provider. Credentials that are not set are read from the standard AWS environment
+// variables.
+type ConfigSES struct {
+	Region          string `toml:"region"`
+	AccessKeyID     string `toml:"access_key_id"`
+	SecretAccessKey string `toml:"secret_access_key"`
+	SessionToken    string `toml:"session_token"`
+
+	// Endpoint overrides the regional endpoint (ex. for localstack)
+	Endpoint string `toml:"endpoint"`
+}
+
+// ConfigSendGrid configures the SendGrid provider
+type ConfigSendGrid struct {
+	APIKey string `toml:"api_key"`
+
+	// BaseURL overrides the SendGrid API URL
+	BaseURL string `toml:"base_url"`
+}
+
+// ConfigMailgun configures the Mailgun provider
+type ConfigMailgun struct {
+	Domain string `toml:"domain"`
+	APIKey string `toml:"api_key"`
+
+	// BaseURL overrides the Mailgun API URL (ex. https://api.eu.mailgun.net for EU domains)
+	BaseURL string `toml:"base_url"`
+}
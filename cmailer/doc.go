@@ -0,0 +1,6 @@
+// Package cmailer sends emails using a provider (SMTP, AWS SES, SendGrid, or Mailgun) selected in the app config.
+// In development, the log provider or dry-run mode logs emails instead of sending them. See Mailer.
+//
+// Emails that should not fail the request when the provider is down (ex. signup or password reset) can be queued in
+// the database using the Outbox. They are sent and retried in the background by the OutboxWorker.
+package cmailer
@@ -0,0 +1,300 @@
+package cmailer
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	cssCommentRegexp = regexp.MustCompile(`(?s)/\*.*?\*/`) //nolint:gochecknoglobals
+
+	// cssSimpleSelectorRegexp matches the selectors that can be inlined: a tag, class, or id, or a tag with a class
+	cssSimpleSelectorRegexp = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9]*)?(?:([.#])([\w-]+))?$`) //nolint:gochecknoglobals
+
+	whitespaceRegexp = regexp.MustCompile(`[ \t\r\n]+`) //nolint:gochecknoglobals
+	blankLinesRegexp = regexp.MustCompile(`\n{3,}`)     //nolint:gochecknoglobals
+)
+
+type cssRule struct {
+	tag         string
+	class       string
+	id          string
+	decls       string
+	specificity int
+	order       int
+}
+
+func (r cssRule) matches(n *html.Node) bool {
+	if r.tag != "" && !strings.EqualFold(n.Data, r.tag) {
+		return false
+	}
+
+	if r.id != "" && attr(n, "id") != r.id {
+		return false
+	}
+
+	if r.class != "" {
+		for _, c := range strings.Fields(attr(n, "class")) {
+			if c == r.class {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// inlineCSS copies the declarations of the CSS rules in the document's <style> tags into the style attribute of the
+// elements they match. Only simple selectors (ex. p, .button, #header, a.button) are inlined. Other rules, including
+// media queries, are left in the <style> tags for the email clients that support them. Declarations in an element's
+// own style attribute take precedence.
+func inlineCSS(doc string) (string, error) {
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		return "", cerrors.New(err, "failed to parse email html", nil)
+	}
+
+	var rules []cssRule
+
+	walk(root, func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Style && n.FirstChild != nil {
+			rules = append(rules, parseCSSRules(n.FirstChild.Data, len(rules))...)
+		}
+	})
+
+	if len(rules) == 0 {
+		return doc, nil
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].specificity != rules[j].specificity {
+			return rules[i].specificity < rules[j].specificity
+		}
+
+		return rules[i].order < rules[j].order
+	})
+
+	walk(root, func(n *html.Node) {
+		if n.Type != html.ElementNode || n.DataAtom == atom.Style || n.DataAtom == atom.Head {
+			return
+		}
+
+		var decls []string
+
+		for _, r := range rules {
+			if r.matches(n) {
+				decls = append(decls, r.decls)
+			}
+		}
+
+		if len(decls) == 0 {
+			return
+		}
+
+		if style := strings.TrimSpace(attr(n, "style")); style != "" {
+			decls = append(decls, strings.TrimSuffix(style, ";"))
+		}
+
+		setAttr(n, "style", strings.Join(decls, "; "))
+	})
+
+	var buf bytes.Buffer
+
+	err = html.Render(&buf, root)
+	if err != nil {
+		return "", cerrors.New(err, "failed to render email html", nil)
+	}
+
+	return buf.String(), nil
+}
+
+// parseCSSRules returns the rules with simple selectors in css
+func parseCSSRules(css string, order int) []cssRule {
+	var rules []cssRule
+
+	css = cssCommentRegexp.ReplaceAllString(css, "")
+
+	for {
+		open := strings.Index(css, "{")
+		if open == -1 {
+			break
+		}
+
+		selectors := strings.TrimSpace(css[:open])
+
+		// at-rules (ex. @media) contain nested blocks and are skipped entirely
+		if strings.HasPrefix(selectors, "@") {
+			css = skipBlock(css[open:])
+			continue
+		}
+
+		end := strings.Index(css[open:], "}")
+		if end == -1 {
+			break
+		}
+
+		decls := strings.TrimSuffix(strings.TrimSpace(css[open+1:open+end]), ";")
+		css = css[open+end+1:]
+
+		for _, sel := range strings.Split(selectors, ",") {
+			m := cssSimpleSelectorRegexp.FindStringSubmatch(strings.TrimSpace(sel))
+			if m == nil || decls == "" {
+				continue
+			}
+
+			r := cssRule{tag: m[1], decls: decls, order: order}
+			if r.tag != "" {
+				r.specificity = 1
+			}
+
+			switch m[2] {
+			case ".":
+				r.class = m[3]
+				r.specificity += 10
+			case "#":
+				r.id = m[3]
+				r.specificity += 100
+			}
+
+			rules = append(rules, r)
+			order++
+		}
+	}
+
+	return rules
+}
+
+// skipBlock returns css after the block that starts at its first character, including nested blocks
+func skipBlock(css string) string {
+	depth := 0
+
+	for i, c := range css {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return css[i+1:]
+			}
+		}
+	}
+
+	return ""
+}
+
+// htmlToText converts an HTML email into its plain text alternative. Block elements are separated by line breaks and
+// links are followed by their URL.
+func htmlToText(doc string) (string, error) {
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		return "", cerrors.New(err, "failed to parse email html", nil)
+	}
+
+	var buf strings.Builder
+
+	writeText(&buf, root)
+
+	lines := strings.Split(buf.String(), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+
+	text := blankLinesRegexp.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+
+	return strings.TrimSpace(text), nil
+}
+
+func writeText(buf *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		buf.WriteString(whitespaceRegexp.ReplaceAllString(n.Data, " "))
+		return
+	case html.ElementNode:
+		switch n.DataAtom {
+		case atom.Head, atom.Style, atom.Script, atom.Title:
+			return
+		case atom.Br:
+			buf.WriteString("\n")
+			return
+		case atom.Li:
+			buf.WriteString("\n- ")
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeText(buf, c)
+	}
+
+	if n.Type != html.ElementNode {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.A:
+		if href := attr(n, "href"); href != "" && !strings.HasPrefix(href, "#") && textContent(n) != href {
+			buf.WriteString(" (" + href + ")")
+		}
+	case atom.P, atom.Div, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Table, atom.Ul, atom.Ol,
+		atom.Blockquote, atom.Hr:
+		buf.WriteString("\n\n")
+	case atom.Tr:
+		buf.WriteString("\n")
+	case atom.Td, atom.Th:
+		buf.WriteString(" ")
+	}
+}
+
+// unescapeText returns the plain text of an HTML fragment
+func unescapeText(s string) string {
+	return html.UnescapeString(s)
+}
+
+func textContent(n *html.Node) string {
+	var buf strings.Builder
+
+	walk(n, func(c *html.Node) {
+		if c.Type == html.TextNode {
+			buf.WriteString(c.Data)
+		}
+	})
+
+	return strings.TrimSpace(buf.String())
+}
+
+func walk(n *html.Node, fn func(n *html.Node)) {
+	fn(n)
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i := range n.Attr {
+		if n.Attr[i].Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
@@ -0,0 +1,310 @@
+package cmailer
+
+import (
+	"context"
+	"math"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+)
+
+// Message is an email. At least one of HTMLBody and PlainBody must be set. Addresses can include a display name
+// (ex. "Copper <hello@example.com>").
+type Message struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Cc      []string `json:"cc,omitempty"`
+	Bcc     []string `json:"bcc,omitempty"`
+	ReplyTo string   `json:"reply_to,omitempty"`
+	Subject string   `json:"subject"`
+
+	HTMLBody  string `json:"html_body,omitempty"`
+	PlainBody string `json:"plain_body,omitempty"`
+
+	// Headers are added to the email (ex. List-Unsubscribe). They cannot override the headers set from the
+	// message's fields.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// reservedHeaders are set from the message's fields or by the MIME encoding and cannot be set using Message.Headers
+var reservedHeaders = map[string]bool{ //nolint:gochecknoglobals
+	"From":                      true,
+	"To":                        true,
+	"Cc":                        true,
+	"Bcc":                       true,
+	"Reply-To":                  true,
+	"Subject":                   true,
+	"Date":                      true,
+	"Message-Id":                true,
+	"Mime-Version":              true,
+	"Content-Type":              true,
+	"Content-Transfer-Encoding": true,
+}
+
+// Provider delivers messages to an email service
+type Provider interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewMailerParams holds the params needed for NewMailer
+type NewMailerParams struct {
+	Config    Config
+	Provider  Provider
+	Templates *Templates
+	Logger    clogger.Logger
+}
+
+// NewMailer creates a new Mailer
+func NewMailer(p NewMailerParams) *Mailer {
+	provider := p.Provider
+	if p.Config.DryRun {
+		provider = NewLogProvider(p.Logger)
+	}
+
+	return &Mailer{
+		config:    p.Config,
+		provider:  provider,
+		templates: p.Templates,
+		limiter:   newRateLimiter(p.Config.RateLimit),
+		logger:    p.Logger.With(clogger.Module("cmailer")),
+	}
+}
+
+// Mailer sends messages using the configured provider. It sets the default sender, validates the addresses, and
+// enforces cmailer.rate_limit so that the provider's sending limits are not exceeded.
+type Mailer struct {
+	config    Config
+	provider  Provider
+	templates *Templates
+	limiter   *rateLimiter
+	logger    clogger.Logger
+}
+
+// SendTemplate renders the template into the message's subject and bodies and sends it. The message's subject is
+// only used if the template does not define one.
+func (m *Mailer) SendTemplate(ctx context.Context, msg Message, tmpl Template) error {
+	msg, err := m.render(ctx, msg, tmpl)
+	if err != nil {
+		return err
+	}
+
+	return m.Send(ctx, msg)
+}
+
+// Send sends the message. It waits for its turn if the rate limit is reached.
+func (m *Mailer) Send(ctx context.Context, msg Message) error {
+	msg, err := m.prepare(msg)
+	if err != nil {
+		return err
+	}
+
+	err = m.limiter.wait(ctx)
+	if err != nil {
+		return cerrors.New(err, "context finished while waiting for the mailer rate limit", nil)
+	}
+
+	if m.config.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, m.config.Timeout)
+		defer cancel()
+	}
+
+	err = m.provider.Send(ctx, msg)
+	if err != nil {
+		return cerrors.New(err, "failed to send email", map[string]interface{}{
+			"provider": m.config.Provider,
+			"subject":  msg.Subject,
+		})
+	}
+
+	return nil
+}
+
+// render renders the template into the message's subject and bodies
+func (m *Mailer) render(ctx context.Context, msg Message, tmpl Template) (Message, error) {
+	if m.templates == nil {
+		return Message{}, cerrors.New(nil, "mailer has no templates", nil)
+	}
+
+	rendered, err := m.templates.Render(ctx, tmpl)
+	if err != nil {
+		return Message{}, cerrors.New(err, "failed to render email template", map[string]interface{}{
+			"template": tmpl.Name,
+		})
+	}
+
+	if rendered.Subject != "" {
+		msg.Subject = rendered.Subject
+	}
+
+	msg.HTMLBody = rendered.HTMLBody
+	msg.PlainBody = rendered.PlainBody
+
+	return msg, nil
+}
+
+// prepare sets the default sender, validates the message, and reads its attachments
+func (m *Mailer) prepare(msg Message) (Message, error) {
+	if msg.From == "" {
+		msg.From = m.defaultFrom()
+	}
+
+	err := validate(msg)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return readAttachments(msg)
+}
+
+// defaultFrom returns cmailer.from with the cmailer.from_name display name, if set
+func (m *Mailer) defaultFrom() string {
+	if m.config.FromName == "" {
+		return m.config.From
+	}
+
+	addr, err := mail.ParseAddress(m.config.From)
+	if err != nil {
+		// the invalid address is reported by validate
+		return m.config.From
+	}
+
+	addr.Name = m.config.FromName
+
+	return addr.String()
+}
+
+func validate(msg Message) error {
+	if msg.From == "" {
+		return cerrors.New(nil, "email has no sender; set cmailer.from or Message.From", nil)
+	}
+
+	if len(msg.To) == 0 {
+		return cerrors.New(nil, "email has no recipients", nil)
+	}
+
+	if msg.HTMLBody == "" && msg.PlainBody == "" {
+		return cerrors.New(nil, "email has no body", nil)
+	}
+
+	addrs := []string{msg.From}
+	addrs = append(addrs, msg.To...)
+	addrs = append(addrs, msg.Cc...)
+	addrs = append(addrs, msg.Bcc...)
+
+	if msg.ReplyTo != "" {
+		addrs = append(addrs, msg.ReplyTo)
+	}
+
+	for _, addr := range addrs {
+		_, err := mail.ParseAddress(addr)
+		if err != nil {
+			return cerrors.New(err, "invalid email address", map[string]interface{}{
+				"address": addr,
+			})
+		}
+	}
+
+	for key, val := range msg.Headers {
+		// line breaks in values could be used to inject headers
+		if !validHeaderName(key) || strings.ContainsAny(val, "\r\n") {
+			return cerrors.New(nil, "invalid email header", map[string]interface{}{
+				"header": key,
+			})
+		}
+
+		if reservedHeaders[textproto.CanonicalMIMEHeaderKey(key)] {
+			return cerrors.New(nil, "email header is set from the message's fields", map[string]interface{}{
+				"header": key,
+			})
+		}
+	}
+
+	return nil
+}
+
+// validHeaderName returns true if name only has the printable characters allowed in header names (RFC 5322)
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, c := range name {
+		if c < '!' || c > '~' || c == ':' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// rateLimiter is a token bucket that allows a burst of one second's worth of sends
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64) *rateLimiter {
+	burst := math.Max(rate, 1)
+
+	return &rateLimiter{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a send is allowed or the context is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+
+	now := time.Now()
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+
+	l.last = now
+	l.tokens--
+
+	// a negative balance is the time this send has to wait for its token
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
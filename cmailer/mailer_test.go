@@ -0,0 +1,187 @@
+package cmailer_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/clogger/cloggertest"
+	"github.com/gocopper/copper/cmailer"
+	"github.com/stretchr/testify/assert"
+)
+
+type testProvider struct {
+	sent []cmailer.Message
+	err  error
+}
+
+func (p *testProvider) Send(ctx context.Context, msg cmailer.Message) error {
+	p.sent = append(p.sent, msg)
+	return p.err
+}
+
+func TestMailer_Send(t *testing.T) {
+	t.Parallel()
+
+	var (
+		provider testProvider
+		mailer   = cmailer.NewMailer(cmailer.NewMailerParams{
+			Config:   cmailer.Config{From: "Copper <hello@example.com>"},
+			Provider: &provider,
+			Logger:   clogger.NewNoop(),
+		})
+	)
+
+	err := mailer.Send(context.Background(), cmailer.Message{
+		To:        []string{"alice@example.com"},
+		Subject:   "Welcome",
+		PlainBody: "Hello!",
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(provider.sent))
+	assert.Equal(t, "Copper <hello@example.com>", provider.sent[0].From)
+}
+
+func TestMailer_Send_FromName(t *testing.T) {
+	t.Parallel()
+
+	var (
+		provider testProvider
+		mailer   = cmailer.NewMailer(cmailer.NewMailerParams{
+			Config:   cmailer.Config{From: "hello@example.com", FromName: "Copper Team"},
+			Provider: &provider,
+			Logger:   clogger.NewNoop(),
+		})
+	)
+
+	err := mailer.Send(context.Background(), cmailer.Message{
+		To:        []string{"alice@example.com"},
+		PlainBody: "Hello!",
+		Attachments: []cmailer.Attachment{
+			{Filename: "notes.txt", Content: strings.NewReader("hello world")},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(provider.sent))
+	assert.Equal(t, `"Copper Team" <hello@example.com>`, provider.sent[0].From)
+	assert.Equal(t, "text/plain; charset=utf-8", provider.sent[0].Attachments[0].ContentType)
+	assert.Equal(t, []byte("hello world"), provider.sent[0].Attachments[0].Bytes())
+}
+
+func TestMailer_Send_Invalid(t *testing.T) {
+	t.Parallel()
+
+	var (
+		provider testProvider
+		mailer   = cmailer.NewMailer(cmailer.NewMailerParams{
+			Config:   cmailer.Config{From: "hello@example.com"},
+			Provider: &provider,
+			Logger:   clogger.NewNoop(),
+		})
+	)
+
+	testCases := map[string]cmailer.Message{
+		"no recipients": {Subject: "Hi", PlainBody: "Hello!"},
+		"no body":       {To: []string{"alice@example.com"}, Subject: "Hi"},
+		"bad address":   {To: []string{"alice"}, Subject: "Hi", PlainBody: "Hello!"},
+		"bad cc":        {To: []string{"alice@example.com"}, Cc: []string{"bob"}, PlainBody: "Hello!"},
+		"reserved header": {
+			To:        []string{"alice@example.com"},
+			PlainBody: "Hello!",
+			Headers:   map[string]string{"bcc": "eve@example.com"},
+		},
+		"header injection": {
+			To:        []string{"alice@example.com"},
+			PlainBody: "Hello!",
+			Headers:   map[string]string{"X-Campaign": "spring\r\nBcc: eve@example.com"},
+		},
+		"attachment without content": {
+			To:          []string{"alice@example.com"},
+			PlainBody:   "Hello!",
+			Attachments: []cmailer.Attachment{{Filename: "invoice.pdf"}},
+		},
+	}
+
+	for name, msg := range testCases {
+		assert.Error(t, mailer.Send(context.Background(), msg), name)
+	}
+
+	assert.Equal(t, 0, len(provider.sent))
+}
+
+func TestMailer_Send_ProviderErr(t *testing.T) {
+	t.Parallel()
+
+	mailer := cmailer.NewMailer(cmailer.NewMailerParams{
+		Config:   cmailer.Config{From: "hello@example.com"},
+		Provider: &testProvider{err: errors.New("test-err")},
+		Logger:   clogger.NewNoop(),
+	})
+
+	err := mailer.Send(context.Background(), cmailer.Message{
+		To:        []string{"alice@example.com"},
+		PlainBody: "Hello!",
+	})
+	assert.Error(t, err)
+}
+
+func TestMailer_DryRun(t *testing.T) {
+	t.Parallel()
+
+	var (
+		provider testProvider
+		logger   = cloggertest.NewRecorder()
+		mailer   = cmailer.NewMailer(cmailer.NewMailerParams{
+			Config:   cmailer.Config{From: "hello@example.com", DryRun: true},
+			Provider: &provider,
+			Logger:   logger,
+		})
+	)
+
+	err := mailer.Send(context.Background(), cmailer.Message{
+		To:        []string{"alice@example.com"},
+		Subject:   "Welcome",
+		PlainBody: "Hello!",
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 0, len(provider.sent))
+
+	entry, ok := logger.FindMessage("Email was not sent; logged instead")
+	assert.True(t, ok)
+	assert.Equal(t, "Welcome", entry.Fields["subject"])
+}
+
+func TestMailer_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	var (
+		provider testProvider
+		mailer   = cmailer.NewMailer(cmailer.NewMailerParams{
+			Config:   cmailer.Config{From: "hello@example.com", RateLimit: 20},
+			Provider: &provider,
+			Logger:   clogger.NewNoop(),
+		})
+		msg = cmailer.Message{To: []string{"alice@example.com"}, PlainBody: "Hello!"}
+	)
+
+	start := time.Now()
+
+	// the first 20 messages are sent right away, the next 5 wait for 50ms each
+	for i := 0; i < 25; i++ {
+		assert.NoError(t, mailer.Send(context.Background(), msg))
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	assert.Equal(t, 25, len(provider.sent))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.Error(t, mailer.Send(ctx, msg))
+}
@@ -0,0 +1,144 @@
+package cmailer
+
+import (
+	"bytes"
+	"context"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+const mailgunBaseURL = "https://api.mailgun.net"
+
+// NewMailgunProvider creates a MailgunProvider
+func NewMailgunProvider(config ConfigMailgun) *MailgunProvider {
+	if config.BaseURL == "" {
+		config.BaseURL = mailgunBaseURL
+	}
+
+	return &MailgunProvider{
+		config: config,
+		client: &http.Client{Timeout: defaultSendTimeout},
+	}
+}
+
+// MailgunProvider sends messages using the Mailgun messages API
+type MailgunProvider struct {
+	config ConfigMailgun
+	client *http.Client
+}
+
+// Send sends the message
+func (p *MailgunProvider) Send(ctx context.Context, msg Message) error {
+	if p.config.Domain == "" {
+		return cerrors.New(nil, "mailgun domain is not set", nil)
+	}
+
+	form := url.Values{}
+	form.Set("from", msg.From)
+	form.Set("subject", msg.Subject)
+
+	for _, to := range msg.To {
+		form.Add("to", to)
+	}
+
+	for _, cc := range msg.Cc {
+		form.Add("cc", cc)
+	}
+
+	for _, bcc := range msg.Bcc {
+		form.Add("bcc", bcc)
+	}
+
+	if msg.ReplyTo != "" {
+		form.Set("h:Reply-To", msg.ReplyTo)
+	}
+
+	for key, val := range msg.Headers {
+		form.Set("h:"+key, val)
+	}
+
+	if msg.PlainBody != "" {
+		form.Set("text", msg.PlainBody)
+	}
+
+	if msg.HTMLBody != "" {
+		form.Set("html", msg.HTMLBody)
+	}
+
+	var (
+		body        = []byte(form.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	)
+
+	// attachments can only be uploaded as multipart form data
+	if len(msg.Attachments) > 0 {
+		var err error
+
+		body, contentType, err = mailgunMultipart(form, msg.Attachments)
+		if err != nil {
+			return err
+		}
+	}
+
+	endpoint := strings.TrimSuffix(p.config.BaseURL, "/") + "/v3/" + url.PathEscape(p.config.Domain) + "/messages"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return cerrors.New(err, "failed to create mailgun request", nil)
+	}
+
+	req.SetBasicAuth("api", p.config.APIKey)
+	req.Header.Set("Content-Type", contentType)
+
+	_, err = doRequest(p.client, req)
+
+	return err
+}
+
+func mailgunMultipart(form url.Values, attachments []Attachment) ([]byte, string, error) {
+	var (
+		buf bytes.Buffer
+		mw  = multipart.NewWriter(&buf)
+	)
+
+	for key, vals := range form {
+		for _, val := range vals {
+			err := mw.WriteField(key, val)
+			if err != nil {
+				return nil, "", cerrors.New(err, "failed to write mailgun form field", map[string]interface{}{
+					"field": key,
+				})
+			}
+		}
+	}
+
+	for _, a := range attachments {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {a.ContentType},
+			"Content-Disposition": {mime.FormatMediaType("form-data", map[string]string{
+				"name":     "attachment",
+				"filename": a.Filename,
+			})},
+		})
+		if err != nil {
+			return nil, "", cerrors.New(err, "failed to create mailgun attachment", map[string]interface{}{
+				"filename": a.Filename,
+			})
+		}
+
+		_, _ = w.Write(a.Bytes())
+	}
+
+	err := mw.Close()
+	if err != nil {
+		return nil, "", cerrors.New(err, "failed to close mailgun form", nil)
+	}
+
+	return buf.Bytes(), mw.FormDataContentType(), nil
+}
@@ -0,0 +1,17 @@
+-- +migrate Up
+create table cmailer_outbox (
+    id varchar(64) primary key,
+    message mediumtext not null,
+    status varchar(32) not null,
+    attempts integer not null default 0,
+    next_attempt_at datetime(6) not null,
+    last_error text not null,
+    sent_at datetime(6) null,
+    created_at datetime(6) not null,
+    updated_at datetime(6) not null
+);
+
+create index cmailer_outbox_pending_idx on cmailer_outbox (status, next_attempt_at);
+
+-- +migrate Down
+drop table cmailer_outbox;
@@ -0,0 +1,17 @@
+-- +migrate Up
+create table cmailer_outbox (
+    id text primary key,
+    message text not null,
+    status text not null,
+    attempts integer not null default 0,
+    next_attempt_at timestamp not null,
+    last_error text not null default '',
+    sent_at timestamp null,
+    created_at timestamp not null,
+    updated_at timestamp not null
+);
+
+create index cmailer_outbox_pending_idx on cmailer_outbox (status, next_attempt_at);
+
+-- +migrate Down
+drop table cmailer_outbox;
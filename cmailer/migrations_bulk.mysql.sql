@@ -0,0 +1,24 @@
+-- +migrate Up
+create table cmailer_campaigns (
+    id varchar(64) primary key,
+    name varchar(255) not null,
+    status varchar(32) not null,
+    total integer not null,
+    sent integer not null default 0,
+    failed integer not null default 0,
+    suppressed integer not null default 0,
+    last_error text not null,
+    completed_at datetime(6) null,
+    created_at datetime(6) not null,
+    updated_at datetime(6) not null
+);
+
+create table cmailer_suppressions (
+    email varchar(255) primary key,
+    reason varchar(255) not null,
+    created_at datetime(6) not null
+);
+
+-- +migrate Down
+drop table cmailer_suppressions;
+drop table cmailer_campaigns;
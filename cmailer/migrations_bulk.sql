@@ -0,0 +1,24 @@
+-- +migrate Up
+create table cmailer_campaigns (
+    id text primary key,
+    name text not null,
+    status text not null,
+    total integer not null,
+    sent integer not null default 0,
+    failed integer not null default 0,
+    suppressed integer not null default 0,
+    last_error text not null default '',
+    completed_at timestamp null,
+    created_at timestamp not null,
+    updated_at timestamp not null
+);
+
+create table cmailer_suppressions (
+    email text primary key,
+    reason text not null,
+    created_at timestamp not null
+);
+
+-- +migrate Down
+drop table cmailer_suppressions;
+drop table cmailer_campaigns;
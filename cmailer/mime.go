@@ -0,0 +1,271 @@
+package cmailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// buildMIME encodes the message in the MIME format used by SMTP and SES. If the message has both an HTML and a plain
+// text body, they are sent as alternatives. Attachments are sent along with the body as multipart/mixed. Bcc
+// recipients are not included in the headers.
+func buildMIME(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return nil, cerrors.New(err, "invalid sender address", nil)
+	}
+
+	to, err := formatAddressList(msg.To)
+	if err != nil {
+		return nil, err
+	}
+
+	writeHeader(&buf, "From", from.String())
+	writeHeader(&buf, "To", to)
+
+	if len(msg.Cc) > 0 {
+		cc, err := formatAddressList(msg.Cc)
+		if err != nil {
+			return nil, err
+		}
+
+		writeHeader(&buf, "Cc", cc)
+	}
+
+	if msg.ReplyTo != "" {
+		replyTo, err := formatAddressList([]string{msg.ReplyTo})
+		if err != nil {
+			return nil, err
+		}
+
+		writeHeader(&buf, "Reply-To", replyTo)
+	}
+
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	writeHeader(&buf, "Date", time.Now().Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", messageID(from.Address))
+
+	for _, key := range sortedHeaderKeys(msg.Headers) {
+		writeHeader(&buf, key, mime.QEncoding.Encode("utf-8", msg.Headers[key]))
+	}
+
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	bodyHeader, body, err := buildMIMEBody(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(msg.Attachments) == 0 {
+		writeMIMEHeader(&buf, bodyHeader)
+		buf.WriteString("\r\n")
+		buf.Write(body)
+
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+
+	writeHeader(&buf, "Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+
+	w, err := mw.CreatePart(bodyHeader)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create mime part", nil)
+	}
+
+	_, _ = w.Write(body)
+
+	for _, a := range msg.Attachments {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachmentContentType(a)},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, cerrors.New(err, "failed to create mime part", map[string]interface{}{
+				"filename": a.Filename,
+			})
+		}
+
+		writeBase64(w, a.Bytes())
+	}
+
+	err = mw.Close()
+	if err != nil {
+		return nil, cerrors.New(err, "failed to close mime writer", nil)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// buildMIMEBody returns the headers and the content of the message's body
+func buildMIMEBody(msg Message) (textproto.MIMEHeader, []byte, error) {
+	if msg.HTMLBody == "" || msg.PlainBody == "" {
+		contentType, body := "text/plain; charset=utf-8", msg.PlainBody
+		if msg.HTMLBody != "" {
+			contentType, body = "text/html; charset=utf-8", msg.HTMLBody
+		}
+
+		var buf bytes.Buffer
+
+		err := writeQuotedPrintable(&buf, body)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		}, buf.Bytes(), nil
+	}
+
+	var buf bytes.Buffer
+
+	mw := multipart.NewWriter(&buf)
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.PlainBody},
+		{"text/html; charset=utf-8", msg.HTMLBody},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, nil, cerrors.New(err, "failed to create mime part", nil)
+		}
+
+		err = writeQuotedPrintable(w, part.body)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	err := mw.Close()
+	if err != nil {
+		return nil, nil, cerrors.New(err, "failed to close mime writer", nil)
+	}
+
+	return textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + mw.Boundary()},
+	}, buf.Bytes(), nil
+}
+
+// attachmentContentType returns the attachment's content type with its filename as the name param
+func attachmentContentType(a Attachment) string {
+	mediaType, params, err := mime.ParseMediaType(a.ContentType)
+	if err != nil {
+		mediaType, params = "application/octet-stream", map[string]string{}
+	}
+
+	params["name"] = a.Filename
+
+	return mime.FormatMediaType(mediaType, params)
+}
+
+// formatAddressList formats addresses for an address header, encoding display names if needed
+func formatAddressList(addrs []string) (string, error) {
+	formatted := make([]string, len(addrs))
+
+	for i, addr := range addrs {
+		a, err := mail.ParseAddress(addr)
+		if err != nil {
+			return "", cerrors.New(err, "invalid email address", map[string]interface{}{
+				"address": addr,
+			})
+		}
+
+		formatted[i] = a.String()
+	}
+
+	return strings.Join(formatted, ", "), nil
+}
+
+func writeMIMEHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		writeHeader(buf, key, header.Get(key))
+	}
+}
+
+// writeBase64 writes data as base64 with lines of 76 characters (RFC 2045)
+func writeBase64(w io.Writer, data []byte) {
+	const lineLen = 76
+
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	for len(encoded) > lineLen {
+		_, _ = io.WriteString(w, encoded[:lineLen]+"\r\n")
+		encoded = encoded[lineLen:]
+	}
+
+	_, _ = io.WriteString(w, encoded)
+}
+
+func sortedHeaderKeys(headers map[string]string) []string {
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func writeHeader(buf *bytes.Buffer, key, val string) {
+	// header values must not contain line breaks, which could be used to inject headers
+	val = strings.NewReplacer("\r", "", "\n", "").Replace(val)
+
+	buf.WriteString(key + ": " + val + "\r\n")
+}
+
+func writeQuotedPrintable(w interface{ Write([]byte) (int, error) }, body string) error {
+	qp := quotedprintable.NewWriter(w)
+
+	_, err := qp.Write([]byte(body))
+	if err != nil {
+		return cerrors.New(err, "failed to encode body", nil)
+	}
+
+	err = qp.Close()
+	if err != nil {
+		return cerrors.New(err, "failed to encode body", nil)
+	}
+
+	return nil
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i != -1 {
+		domain = from[i+1:]
+	}
+
+	b := make([]byte, 16) //nolint:gomnd
+	_, _ = rand.Read(b)
+
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain)
+}
@@ -0,0 +1,88 @@
+package cmailer
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"time"
+)
+
+// Outbox email statuses
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSending = "sending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusDead    = "dead"
+)
+
+// OutboxEmail is an email queued in the Outbox along with its delivery status. Emails that could not be sent after
+// cmailer.outbox.max_attempts are dead-lettered and kept until they are requeued.
+type OutboxEmail struct {
+	ID            string       `db:"id"`
+	Message       string       `db:"message"`
+	Status        string       `db:"status"`
+	Attempts      int          `db:"attempts"`
+	NextAttemptAt time.Time    `db:"next_attempt_at"`
+	LastError     string       `db:"last_error"`
+	SentAt        sql.NullTime `db:"sent_at"`
+	CreatedAt     time.Time    `db:"created_at"`
+	UpdatedAt     time.Time    `db:"updated_at"`
+}
+
+// Campaign statuses
+const (
+	CampaignStatusSending   = "sending"
+	CampaignStatusCompleted = "completed"
+	CampaignStatusCanceled  = "canceled"
+)
+
+// Campaign is a bulk send started using Bulk.Send along with its progress. Its counts are updated as the batches of
+// recipients are sent.
+type Campaign struct {
+	ID     string `db:"id" json:"id"`
+	Name   string `db:"name" json:"name"`
+	Status string `db:"status" json:"status"`
+
+	// Total is the number of unique recipients
+	Total int `db:"total" json:"total"`
+
+	Sent       int `db:"sent" json:"sent"`
+	Failed     int `db:"failed" json:"failed"`
+	Suppressed int `db:"suppressed" json:"suppressed"`
+
+	// LastError is the error of the most recent failed send
+	LastError string `db:"last_error" json:"last_error"`
+
+	CompletedAt sql.NullTime `db:"completed_at" json:"completed_at"`
+	CreatedAt   time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time    `db:"updated_at" json:"updated_at"`
+}
+
+// Pending returns the number of recipients that have not been sent to yet
+func (c *Campaign) Pending() int {
+	return c.Total - c.Sent - c.Failed - c.Suppressed
+}
+
+// CampaignProgress are the counts added to a campaign by a batch
+type CampaignProgress struct {
+	Sent       int
+	Failed     int
+	Suppressed int
+	LastError  string
+}
+
+// Suppression is an email address that campaigns are not sent to (ex. because it unsubscribed or bounced)
+type Suppression struct {
+	Email     string    `db:"email" json:"email"`
+	Reason    string    `db:"reason" json:"reason"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+func newID() string {
+	const idBytes = 16
+
+	b := make([]byte, idBytes)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,135 @@
+package cmailer
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// NewOutboxParams holds the params needed for NewOutbox
+type NewOutboxParams struct {
+	Queries *Queries
+	Mailer  *Mailer
+}
+
+// NewOutbox creates a new Outbox
+func NewOutbox(p NewOutboxParams) *Outbox {
+	return &Outbox{
+		queries: p.Queries,
+		mailer:  p.Mailer,
+		now:     time.Now,
+	}
+}
+
+// Outbox queues emails in the database so that they are sent in the background by the OutboxWorker. Failed sends are
+// retried with backoff, so a provider outage does not fail the request that sends the email (ex. signup). All methods
+// must be called with a context that has a database transaction (see csql.CtxWithTx) so that emails are queued only
+// if the surrounding transaction commits.
+type Outbox struct {
+	queries *Queries
+	mailer  *Mailer
+	now     func() time.Time
+}
+
+// Enqueue validates the message and queues it for sending. The returned email's id can be used to check its
+// delivery status.
+func (o *Outbox) Enqueue(ctx context.Context, msg Message) (*OutboxEmail, error) {
+	msg, err := o.mailer.prepare(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to marshal email", map[string]interface{}{
+			"subject": msg.Subject,
+		})
+	}
+
+	now := o.now()
+	email := OutboxEmail{
+		ID:            newID(),
+		Message:       string(data),
+		Status:        OutboxStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	err = o.queries.InsertEmail(ctx, &email)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to insert outbox email", map[string]interface{}{
+			"subject": msg.Subject,
+		})
+	}
+
+	return &email, nil
+}
+
+// EnqueueTemplate renders the template into the message (see Mailer.SendTemplate) and queues it for sending. The
+// template is rendered right away so that it has access to the request's context.
+func (o *Outbox) EnqueueTemplate(ctx context.Context, msg Message, tmpl Template) (*OutboxEmail, error) {
+	msg, err := o.mailer.render(ctx, msg, tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.Enqueue(ctx, msg)
+}
+
+// Status returns the outbox email with the given id along with its delivery status
+func (o *Outbox) Status(ctx context.Context, id string) (*OutboxEmail, error) {
+	email, err := o.queries.GetEmail(ctx, id)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to get outbox email", map[string]interface{}{
+			"id": id,
+		})
+	}
+
+	return email, nil
+}
+
+// DeadLetters returns the most recent emails that could not be sent after cmailer.outbox.max_attempts
+func (o *Outbox) DeadLetters(ctx context.Context, limit int) ([]OutboxEmail, error) {
+	emails, err := o.queries.ListEmails(ctx, OutboxStatusDead, limit)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to list dead-lettered emails", nil)
+	}
+
+	return emails, nil
+}
+
+// Requeue resets a dead-lettered email so that it is sent again with a new set of attempts
+func (o *Outbox) Requeue(ctx context.Context, id string) (*OutboxEmail, error) {
+	email, err := o.queries.GetEmail(ctx, id)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to get outbox email", map[string]interface{}{
+			"id": id,
+		})
+	}
+
+	if email.Status != OutboxStatusDead {
+		return nil, cerrors.New(nil, "only dead-lettered emails can be requeued", map[string]interface{}{
+			"id":     id,
+			"status": email.Status,
+		})
+	}
+
+	now := o.now()
+
+	email.Status = OutboxStatusPending
+	email.Attempts = 0
+	email.NextAttemptAt = now
+	email.UpdatedAt = now
+
+	err = o.queries.UpdateEmail(ctx, email)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to update outbox email", map[string]interface{}{
+			"id": id,
+		})
+	}
+
+	return email, nil
+}
@@ -0,0 +1,151 @@
+package cmailer_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cmailer"
+	"github.com/gocopper/copper/csql"
+	"github.com/gocopper/copper/csql/csqltest"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxWorker_ProcessPending(t *testing.T) {
+	t.Parallel()
+
+	testDB := csqltest.NewDB(t, csqltest.Options{Migrations: csql.Migrations(cmailer.Migrations)})
+
+	var (
+		db        = testDB.DB
+		sqlConfig = testDB.Config
+		err       error
+	)
+
+	var (
+		provider = testProvider{err: errors.New("provider is down")}
+		config   = cmailer.Config{
+			From: "hello@example.com",
+			Outbox: cmailer.ConfigOutbox{
+				MaxAttempts:    2,
+				BaseBackoff:    time.Minute,
+				MaxBackoff:     time.Hour,
+				BatchSize:      10,
+				SendingTimeout: time.Minute,
+			},
+		}
+		mailer = cmailer.NewMailer(cmailer.NewMailerParams{
+			Config:   config,
+			Provider: &provider,
+			Logger:   clogger.NewNoop(),
+		})
+		queries = cmailer.NewQueries(testDB.Querier)
+		outbox  = cmailer.NewOutbox(cmailer.NewOutboxParams{Queries: queries, Mailer: mailer})
+		worker  = cmailer.NewOutboxWorker(cmailer.NewOutboxWorkerParams{
+			DB:        db,
+			Queries:   queries,
+			Mailer:    mailer,
+			Lifecycle: clifecycle.New(),
+			Config:    config,
+			SQLConfig: sqlConfig,
+			Logger:    clogger.NewNoop(),
+		})
+		email *cmailer.OutboxEmail
+	)
+
+	inTx := func(fn func(ctx context.Context)) {
+		ctx, tx, err := csql.CtxWithTx(context.Background(), db, "sqlite3")
+		assert.NoError(t, err)
+
+		fn(ctx)
+
+		assert.NoError(t, tx.Commit())
+	}
+
+	inTx(func(ctx context.Context) {
+		email, err = outbox.Enqueue(ctx, cmailer.Message{
+			To:        []string{"alice@example.com"},
+			Subject:   "Reset your password",
+			PlainBody: "Hello!",
+			Attachments: []cmailer.Attachment{
+				{Filename: "notes.txt", Content: strings.NewReader("hello world")},
+			},
+		})
+		assert.NoError(t, err)
+
+		_, err = outbox.Enqueue(ctx, cmailer.Message{To: []string{"alice@example.com"}})
+		assert.Error(t, err)
+	})
+
+	// first attempt fails and the email is scheduled for a retry with a backoff
+	n, err := worker.ProcessPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = worker.ProcessPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	inTx(func(ctx context.Context) {
+		email, err = outbox.Status(ctx, email.ID)
+		assert.NoError(t, err)
+	})
+
+	assert.Equal(t, cmailer.OutboxStatusPending, email.Status)
+	assert.Equal(t, 1, email.Attempts)
+	assert.Contains(t, email.LastError, "provider is down")
+	assert.True(t, email.NextAttemptAt.After(time.Now()))
+
+	assert.Len(t, provider.sent, 1)
+	assert.Equal(t, "hello@example.com", provider.sent[0].From)
+	assert.Equal(t, "Reset your password", provider.sent[0].Subject)
+	assert.Equal(t, "notes.txt", provider.sent[0].Attachments[0].Filename)
+	assert.Equal(t, []byte("hello world"), provider.sent[0].Attachments[0].Bytes())
+
+	// the second attempt is the last one so the email is dead-lettered
+	inTx(func(ctx context.Context) {
+		email.NextAttemptAt = time.Now()
+		assert.NoError(t, queries.UpdateEmail(ctx, email))
+	})
+
+	n, err = worker.ProcessPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	var dead []cmailer.OutboxEmail
+
+	inTx(func(ctx context.Context) {
+		dead, err = outbox.DeadLetters(ctx, 10)
+		assert.NoError(t, err)
+	})
+
+	assert.Len(t, dead, 1)
+	assert.Equal(t, email.ID, dead[0].ID)
+	assert.Equal(t, 2, dead[0].Attempts)
+
+	// once requeued, the email is sent right away
+	provider.err = nil
+
+	inTx(func(ctx context.Context) {
+		_, err = outbox.Requeue(ctx, email.ID)
+		assert.NoError(t, err)
+	})
+
+	n, err = worker.ProcessPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	inTx(func(ctx context.Context) {
+		email, err = outbox.Status(ctx, email.ID)
+		assert.NoError(t, err)
+	})
+
+	assert.Equal(t, cmailer.OutboxStatusSent, email.Status)
+	assert.True(t, email.SentAt.Valid)
+	assert.Len(t, provider.sent, 3)
+}
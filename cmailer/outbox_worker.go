@@ -0,0 +1,199 @@
+package cmailer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/csql"
+)
+
+// NewOutboxWorkerParams holds the params needed to create an OutboxWorker
+type NewOutboxWorkerParams struct {
+	DB        *sql.DB
+	Queries   *Queries
+	Mailer    *Mailer
+	Lifecycle *clifecycle.Lifecycle
+	Config    Config
+	SQLConfig csql.Config
+	Logger    clogger.Logger
+}
+
+// NewOutboxWorker creates a new OutboxWorker
+func NewOutboxWorker(p NewOutboxWorkerParams) *OutboxWorker {
+	return &OutboxWorker{
+		db:      p.DB,
+		queries: p.Queries,
+		mailer:  p.Mailer,
+		lc:      p.Lifecycle,
+		config:  p.Config.Outbox,
+		dialect: p.SQLConfig.Dialect,
+		logger:  p.Logger.With(clogger.Module("cmailer")),
+		now:     time.Now,
+	}
+}
+
+// OutboxWorker sends the emails queued in the Outbox using the Mailer. Failed sends are retried with exponential
+// backoff until Config.Outbox.MaxAttempts is reached, after which the email is dead-lettered.
+type OutboxWorker struct {
+	db      *sql.DB
+	queries *Queries
+	mailer  *Mailer
+	lc      *clifecycle.Lifecycle
+	config  ConfigOutbox
+	dialect string
+	logger  clogger.Logger
+	now     func() time.Time
+}
+
+// Run starts polling for pending emails in the background. The worker stops when the app's lifecycle stops.
+func (w *OutboxWorker) Run() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	w.lc.OnStop(func(stopCtx context.Context) error {
+		w.logger.Info("Stopping email outbox worker..")
+		cancel()
+
+		select {
+		case <-done:
+			return nil
+		case <-stopCtx.Done():
+			return stopCtx.Err()
+		}
+	})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(w.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			_, err := w.ProcessPending(ctx)
+			if err != nil {
+				w.logger.Error("Failed to process pending outbox emails", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stage implements clifecycle.Staged. The outbox worker starts once the migrations have run and the servers are listening.
+func (w *OutboxWorker) Stage() clifecycle.Stage {
+	return clifecycle.Stage{
+		Name:  clifecycle.StageWorkers,
+		After: []string{clifecycle.StageMigrations, clifecycle.StageSeeds, clifecycle.StageHTTP},
+	}
+}
+
+// ProcessPending attempts a batch of pending emails that are due and returns the number of emails attempted.
+func (w *OutboxWorker) ProcessPending(ctx context.Context) (int, error) {
+	var emails []OutboxEmail
+
+	err := w.inTx(ctx, func(ctx context.Context) error {
+		var (
+			err error
+			now = w.now()
+		)
+
+		emails, err = w.queries.ClaimPendingEmails(ctx, now, now.Add(-w.config.SendingTimeout), w.config.BatchSize)
+
+		return err
+	})
+	if err != nil {
+		return 0, cerrors.New(err, "failed to claim pending emails", nil)
+	}
+
+	for i := range emails {
+		err = w.inTx(ctx, func(ctx context.Context) error {
+			return w.attempt(ctx, &emails[i])
+		})
+		if err != nil {
+			return i, cerrors.New(err, "failed to attempt email", map[string]interface{}{
+				"id": emails[i].ID,
+			})
+		}
+	}
+
+	return len(emails), nil
+}
+
+func (w *OutboxWorker) attempt(ctx context.Context, e *OutboxEmail) error {
+	var msg Message
+
+	sendErr := json.Unmarshal([]byte(e.Message), &msg)
+	if sendErr != nil {
+		sendErr = cerrors.New(sendErr, "failed to unmarshal email", nil)
+	} else {
+		sendErr = w.mailer.Send(ctx, msg)
+	}
+
+	e.Attempts++
+	e.LastError = ""
+	e.UpdatedAt = w.now()
+
+	log := w.logger.WithTags(map[string]interface{}{
+		"emailID": e.ID,
+		"attempt": e.Attempts,
+		"subject": msg.Subject,
+	})
+
+	switch {
+	case sendErr == nil:
+		e.Status = OutboxStatusSent
+		e.SentAt = sql.NullTime{Time: e.UpdatedAt, Valid: true}
+	case e.Attempts >= w.config.MaxAttempts:
+		e.Status = OutboxStatusDead
+		e.LastError = sendErr.Error()
+
+		log.Error("Email could not be sent; moved to dead letters", sendErr)
+	default:
+		e.Status = OutboxStatusPending
+		e.LastError = sendErr.Error()
+		e.NextAttemptAt = e.UpdatedAt.Add(w.backoff(e.Attempts))
+
+		log.Warn("Failed to send email; will retry", sendErr)
+	}
+
+	return w.queries.UpdateEmail(ctx, e)
+}
+
+func (w *OutboxWorker) backoff(attempts int) time.Duration {
+	d := w.config.BaseBackoff
+	for i := 1; i < attempts && d < w.config.MaxBackoff; i++ {
+		d *= 2
+	}
+
+	if d > w.config.MaxBackoff {
+		return w.config.MaxBackoff
+	}
+
+	return d
+}
+
+func (w *OutboxWorker) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, tx, err := csql.CtxWithTx(ctx, w.db, w.dialect)
+	if err != nil {
+		return err
+	}
+
+	err = fn(ctx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
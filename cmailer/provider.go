@@ -0,0 +1,112 @@
+package cmailer
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+)
+
+// NewProviderParams holds the params needed to create a Provider
+type NewProviderParams struct {
+	Config Config
+	Logger clogger.Logger
+}
+
+// NewProvider creates the Provider set in the config
+func NewProvider(p NewProviderParams) (Provider, error) {
+	switch p.Config.Provider {
+	case ProviderLog, "":
+		return NewLogProvider(p.Logger), nil
+	case ProviderSMTP:
+		return NewSMTPProvider(p.Config.SMTP), nil
+	case ProviderSES:
+		return NewSESProvider(p.Config.SES), nil
+	case ProviderSendGrid:
+		return NewSendGridProvider(p.Config.SendGrid), nil
+	case ProviderMailgun:
+		return NewMailgunProvider(p.Config.Mailgun), nil
+	default:
+		return nil, cerrors.New(nil, "unknown email provider", map[string]interface{}{
+			"provider": p.Config.Provider,
+		})
+	}
+}
+
+// NewLogProvider creates a LogProvider
+func NewLogProvider(logger clogger.Logger) *LogProvider {
+	return &LogProvider{logger: logger.With(clogger.Module("cmailer"))}
+}
+
+// LogProvider logs messages instead of sending them. It is meant for development.
+type LogProvider struct {
+	logger clogger.Logger
+}
+
+// Send logs the message
+func (p *LogProvider) Send(ctx context.Context, msg Message) error {
+	body := msg.PlainBody
+	if body == "" {
+		body = msg.HTMLBody
+	}
+
+	tags := map[string]interface{}{
+		"from":    msg.From,
+		"to":      strings.Join(msg.To, ", "),
+		"subject": msg.Subject,
+		"body":    body,
+	}
+
+	if len(msg.Cc) > 0 {
+		tags["cc"] = strings.Join(msg.Cc, ", ")
+	}
+
+	if len(msg.Bcc) > 0 {
+		tags["bcc"] = strings.Join(msg.Bcc, ", ")
+	}
+
+	if msg.ReplyTo != "" {
+		tags["replyTo"] = msg.ReplyTo
+	}
+
+	if len(msg.Attachments) > 0 {
+		filenames := make([]string, len(msg.Attachments))
+		for i := range msg.Attachments {
+			filenames[i] = msg.Attachments[i].Filename
+		}
+
+		tags["attachments"] = strings.Join(filenames, ", ")
+	}
+
+	p.logger.WithTags(tags).Info("Email was not sent; logged instead")
+
+	return nil
+}
+
+func doRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to send request", map[string]interface{}{
+			"url": req.URL.String(),
+		})
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to read response", nil)
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, cerrors.New(nil, "request failed", map[string]interface{}{
+			"url":        req.URL.String(),
+			"statusCode": resp.StatusCode,
+			"body":       string(body),
+		})
+	}
+
+	return body, nil
+}
@@ -0,0 +1,243 @@
+package cmailer_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cmailer"
+	"github.com/stretchr/testify/assert"
+)
+
+var testMessage = cmailer.Message{ //nolint:gochecknoglobals
+	From:      "Copper <hello@example.com>",
+	To:        []string{"alice@example.com"},
+	Subject:   "Welcome",
+	HTMLBody:  "<p>Hello!</p>",
+	PlainBody: "Hello!",
+}
+
+func TestSendGridProvider_Send(t *testing.T) {
+	t.Parallel()
+
+	var body map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/mail/send", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	provider := cmailer.NewSendGridProvider(cmailer.ConfigSendGrid{APIKey: "test-key", BaseURL: server.URL})
+
+	assert.NoError(t, provider.Send(context.Background(), testMessage))
+
+	assert.Equal(t, "Welcome", body["subject"])
+	assert.Equal(t, map[string]interface{}{"email": "hello@example.com", "name": "Copper"}, body["from"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"type": "text/plain", "value": "Hello!"},
+		map[string]interface{}{"type": "text/html", "value": "<p>Hello!</p>"},
+	}, body["content"])
+}
+
+func TestSendGridProvider_Send_Attachments(t *testing.T) {
+	t.Parallel()
+
+	var body map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	provider := cmailer.NewSendGridProvider(cmailer.ConfigSendGrid{APIKey: "test-key", BaseURL: server.URL})
+
+	msg := testMessage
+	msg.Cc = []string{"Bob <bob@example.com>"}
+	msg.Bcc = []string{"carol@example.com"}
+	msg.ReplyTo = "support@example.com"
+	msg.Headers = map[string]string{"X-Campaign": "spring"}
+	msg.Attachments = []cmailer.Attachment{testAttachment(t)}
+
+	assert.NoError(t, provider.Send(context.Background(), msg))
+
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"to":  []interface{}{map[string]interface{}{"email": "alice@example.com"}},
+		"cc":  []interface{}{map[string]interface{}{"email": "bob@example.com", "name": "Bob"}},
+		"bcc": []interface{}{map[string]interface{}{"email": "carol@example.com"}},
+	}}, body["personalizations"])
+	assert.Equal(t, map[string]interface{}{"email": "support@example.com"}, body["reply_to"])
+	assert.Equal(t, map[string]interface{}{"X-Campaign": "spring"}, body["headers"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"content":     base64.StdEncoding.EncodeToString([]byte("a,b\n1,2\n")),
+		"type":        "text/csv; charset=utf-8",
+		"filename":    "report.csv",
+		"disposition": "attachment",
+	}}, body["attachments"])
+}
+
+func TestMailgunProvider_Send(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+
+		assert.Equal(t, "/v3/mg.example.com/messages", r.URL.Path)
+		assert.Equal(t, "api", user)
+		assert.Equal(t, "test-key", pass)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, []string{"alice@example.com"}, r.PostForm["to"])
+		assert.Equal(t, "<p>Hello!</p>", r.PostForm.Get("html"))
+	}))
+	defer server.Close()
+
+	provider := cmailer.NewMailgunProvider(cmailer.ConfigMailgun{
+		Domain:  "mg.example.com",
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+	})
+
+	assert.NoError(t, provider.Send(context.Background(), testMessage))
+}
+
+func TestSESProvider_Send(t *testing.T) {
+	t.Parallel()
+
+	var raw string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/email/outbound-emails", r.URL.Path)
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+
+		var body struct {
+			Content struct {
+				Raw struct {
+					Data string
+				}
+			}
+		}
+
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		data, err := base64.StdEncoding.DecodeString(body.Content.Raw.Data)
+		assert.NoError(t, err)
+
+		raw = string(data)
+	}))
+	defer server.Close()
+
+	provider := cmailer.NewSESProvider(cmailer.ConfigSES{
+		Region:          "us-east-1",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		Endpoint:        server.URL,
+	})
+
+	assert.NoError(t, provider.Send(context.Background(), testMessage))
+
+	assert.Contains(t, raw, "Subject: Welcome\r\n")
+	assert.Contains(t, raw, "Content-Type: multipart/alternative; boundary=")
+	assert.Contains(t, raw, "<p>Hello!</p>")
+}
+
+func TestSESProvider_Send_Attachments(t *testing.T) {
+	t.Parallel()
+
+	var body struct {
+		Destination struct {
+			CcAddresses  []string
+			BccAddresses []string
+		}
+		Content struct {
+			Raw struct {
+				Data []byte
+			}
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	}))
+	defer server.Close()
+
+	provider := cmailer.NewSESProvider(cmailer.ConfigSES{
+		Region:          "us-east-1",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		Endpoint:        server.URL,
+	})
+
+	msg := testMessage
+	msg.Cc = []string{"Bob <bob@example.com>"}
+	msg.Bcc = []string{"carol@example.com"}
+	msg.ReplyTo = "support@example.com"
+	msg.Headers = map[string]string{"X-Campaign": "spring"}
+	msg.Attachments = []cmailer.Attachment{testAttachment(t)}
+
+	assert.NoError(t, provider.Send(context.Background(), msg))
+
+	raw := string(body.Content.Raw.Data)
+
+	assert.Equal(t, []string{"Bob <bob@example.com>"}, body.Destination.CcAddresses)
+	assert.Equal(t, []string{"carol@example.com"}, body.Destination.BccAddresses)
+	assert.Contains(t, raw, "From: \"Copper\" <hello@example.com>\r\n")
+	assert.Contains(t, raw, "Cc: \"Bob\" <bob@example.com>\r\n")
+	assert.Contains(t, raw, "Reply-To: <support@example.com>\r\n")
+	assert.Contains(t, raw, "X-Campaign: spring\r\n")
+	assert.NotContains(t, raw, "carol@example.com")
+	assert.Contains(t, raw, "Content-Type: multipart/mixed; boundary=")
+	assert.Contains(t, raw, "Content-Type: multipart/alternative; boundary=")
+	assert.Contains(t, raw, "Content-Disposition: attachment; filename=report.csv\r\n")
+	assert.Contains(t, raw, base64.StdEncoding.EncodeToString([]byte("a,b\n1,2\n")))
+}
+
+func TestProvider_RequestFailed(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	provider := cmailer.NewSendGridProvider(cmailer.ConfigSendGrid{APIKey: "bad-key", BaseURL: server.URL})
+
+	assert.Error(t, provider.Send(context.Background(), testMessage))
+}
+
+func TestNewProvider_Unknown(t *testing.T) {
+	t.Parallel()
+
+	_, err := cmailer.NewProvider(cmailer.NewProviderParams{Config: cmailer.Config{Provider: "postmark"}})
+	assert.Error(t, err)
+}
+
+// testAttachment returns an attachment that has been read by the mailer, as it is when it is passed to providers
+func testAttachment(t *testing.T) cmailer.Attachment {
+	t.Helper()
+
+	var provider testProvider
+
+	err := cmailer.NewMailer(cmailer.NewMailerParams{Provider: &provider, Logger: clogger.NewNoop()}).
+		Send(context.Background(), cmailer.Message{
+			From:        "hello@example.com",
+			To:          []string{"alice@example.com"},
+			PlainBody:   "Hello!",
+			Attachments: []cmailer.Attachment{{Filename: "report.csv", Content: strings.NewReader("a,b\n1,2\n")}},
+		})
+	assert.NoError(t, err)
+
+	return provider.sent[0].Attachments[0]
+}
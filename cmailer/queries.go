@@ -0,0 +1,257 @@
+package cmailer
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/csql"
+	"github.com/jmoiron/sqlx"
+)
+
+// Migrations holds the database schema needed for the Outbox and Bulk. Register them using csql.RegisterMigrations so that
+// they are applied by csql.Migrator along with the app's migrations:
+//
+//	csql.RegisterMigrations(csql.ModuleMigrations{Module: "cmailer", FS: cmailer.Migrations})
+//
+// MySQL uses the variants in the .mysql.sql files (see csql.ModuleMigrations).
+//
+//go:embed migrations.sql migrations.mysql.sql migrations_bulk.sql migrations_bulk.mysql.sql
+var Migrations embed.FS
+
+// ErrNotFound is returned when an outbox email or a campaign does not exist
+var ErrNotFound = errors.New("not found")
+
+// NewQueries creates a new Queries
+func NewQueries(querier csql.Querier) *Queries {
+	return &Queries{querier: querier}
+}
+
+// Queries holds the database queries for the outbox and bulk campaigns
+type Queries struct {
+	querier csql.Querier
+}
+
+// InsertEmail saves a new outbox email
+func (q *Queries) InsertEmail(ctx context.Context, e *OutboxEmail) error {
+	const query = `
+	insert into cmailer_outbox (id, message, status, attempts, next_attempt_at, last_error, sent_at, created_at,
+		updated_at)
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := q.querier.Exec(ctx, query, e.ID, e.Message, e.Status, e.Attempts, e.NextAttemptAt, e.LastError,
+		e.SentAt, e.CreatedAt, e.UpdatedAt)
+
+	return err
+}
+
+// UpdateEmail saves the status and attempt details of an outbox email
+func (q *Queries) UpdateEmail(ctx context.Context, e *OutboxEmail) error {
+	const query = `
+	update cmailer_outbox
+	set status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, sent_at = ?, updated_at = ?
+	where id = ?`
+
+	_, err := q.querier.Exec(ctx, query, e.Status, e.Attempts, e.NextAttemptAt, e.LastError, e.SentAt, e.UpdatedAt,
+		e.ID)
+
+	return err
+}
+
+// GetEmail returns the outbox email with the given id
+func (q *Queries) GetEmail(ctx context.Context, id string) (*OutboxEmail, error) {
+	var e OutboxEmail
+
+	err := q.querier.Get(ctx, &e, `select * from cmailer_outbox where id = ?`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+
+	return &e, err
+}
+
+// ListEmails returns the most recent outbox emails with the given status
+func (q *Queries) ListEmails(ctx context.Context, status string, limit int) ([]OutboxEmail, error) {
+	const query = `
+	select * from cmailer_outbox
+	where status = ?
+	order by created_at desc
+	limit ?`
+
+	var emails []OutboxEmail
+
+	err := q.querier.Select(ctx, &emails, query, status, limit)
+
+	return emails, err
+}
+
+// ClaimPendingEmails marks up to limit pending emails that are due as sending and returns them. Emails that have
+// been sending since before staleBefore are claimed again. An email can only be claimed by one worker.
+func (q *Queries) ClaimPendingEmails(ctx context.Context, now, staleBefore time.Time, limit int) ([]OutboxEmail,
+	error) {
+	const (
+		claimable = `((status = ? and next_attempt_at <= ?) or (status = ? and updated_at <= ?))`
+
+		selectQuery = `
+		select * from cmailer_outbox
+		where ` + claimable + `
+		order by next_attempt_at
+		limit ?`
+
+		claimQuery = `update cmailer_outbox set status = ?, updated_at = ? where id = ? and ` + claimable
+	)
+
+	var pending []OutboxEmail
+
+	err := q.querier.Select(ctx, &pending, selectQuery, OutboxStatusPending, now, OutboxStatusSending, staleBefore,
+		limit)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to query pending emails", nil)
+	}
+
+	claimed := make([]OutboxEmail, 0, len(pending))
+
+	for i := range pending {
+		res, err := q.querier.Exec(ctx, claimQuery, OutboxStatusSending, now, pending[i].ID,
+			OutboxStatusPending, now, OutboxStatusSending, staleBefore)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to claim email", map[string]interface{}{
+				"id": pending[i].ID,
+			})
+		}
+
+		if n, _ := res.RowsAffected(); n == 1 {
+			pending[i].Status = OutboxStatusSending
+			pending[i].UpdatedAt = now
+			claimed = append(claimed, pending[i])
+		}
+	}
+
+	return claimed, nil
+}
+
+// InsertCampaign saves a new campaign
+func (q *Queries) InsertCampaign(ctx context.Context, c *Campaign) error {
+	const query = `
+	insert into cmailer_campaigns (id, name, status, total, sent, failed, suppressed, last_error, completed_at,
+		created_at, updated_at)
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := q.querier.Exec(ctx, query, c.ID, c.Name, c.Status, c.Total, c.Sent, c.Failed, c.Suppressed,
+		c.LastError, c.CompletedAt, c.CreatedAt, c.UpdatedAt)
+
+	return err
+}
+
+// GetCampaign returns the campaign with the given id
+func (q *Queries) GetCampaign(ctx context.Context, id string) (*Campaign, error) {
+	var c Campaign
+
+	err := q.querier.Get(ctx, &c, `select * from cmailer_campaigns where id = ?`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+
+	return &c, err
+}
+
+// ListCampaigns returns the most recent campaigns
+func (q *Queries) ListCampaigns(ctx context.Context, limit int) ([]Campaign, error) {
+	var campaigns []Campaign
+
+	err := q.querier.Select(ctx, &campaigns, `select * from cmailer_campaigns order by created_at desc limit ?`,
+		limit)
+
+	return campaigns, err
+}
+
+// AddCampaignProgress adds the counts of a batch to the campaign's progress and marks the campaign as completed once
+// all of its recipients are counted. The counts are added in the database so that concurrent batches do not
+// overwrite each other's progress.
+func (q *Queries) AddCampaignProgress(ctx context.Context, id string, progress CampaignProgress,
+	now time.Time) error {
+	const (
+		progressQuery = `
+		update cmailer_campaigns
+		set sent = sent + ?, failed = failed + ?, suppressed = suppressed + ?, updated_at = ?
+		where id = ?`
+
+		errorQuery = `update cmailer_campaigns set last_error = ? where id = ?`
+
+		completeQuery = `
+		update cmailer_campaigns
+		set status = ?, completed_at = ?
+		where id = ? and status = ? and sent + failed + suppressed >= total`
+	)
+
+	_, err := q.querier.Exec(ctx, progressQuery, progress.Sent, progress.Failed, progress.Suppressed, now, id)
+	if err != nil {
+		return err
+	}
+
+	if progress.LastError != "" {
+		_, err = q.querier.Exec(ctx, errorQuery, progress.LastError, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = q.querier.Exec(ctx, completeQuery, CampaignStatusCompleted, now, id, CampaignStatusSending)
+
+	return err
+}
+
+// UpdateCampaignStatus sets the status of a campaign that has the from status. It returns false if the campaign
+// does not have it.
+func (q *Queries) UpdateCampaignStatus(ctx context.Context, id, from, to string, now time.Time) (bool, error) {
+	const query = `update cmailer_campaigns set status = ?, updated_at = ? where id = ? and status = ?`
+
+	res, err := q.querier.Exec(ctx, query, to, now, id, from)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return n == 1, err
+}
+
+// InsertSuppression adds an email address to the suppression list. Adding an address that is already suppressed
+// does nothing.
+func (q *Queries) InsertSuppression(ctx context.Context, s *Suppression) error {
+	const query = `
+	insert into cmailer_suppressions (email, reason, created_at)
+	select ?, ?, ? where not exists (select 1 from cmailer_suppressions where email = ?)`
+
+	_, err := q.querier.Exec(ctx, query, s.Email, s.Reason, s.CreatedAt, s.Email)
+
+	return err
+}
+
+// DeleteSuppression removes an email address from the suppression list
+func (q *Queries) DeleteSuppression(ctx context.Context, email string) error {
+	_, err := q.querier.Exec(ctx, `delete from cmailer_suppressions where email = ?`, email)
+
+	return err
+}
+
+// ListSuppressions returns the suppressions of the given email addresses
+func (q *Queries) ListSuppressions(ctx context.Context, emails []string) ([]Suppression, error) {
+	if len(emails) == 0 {
+		return nil, nil
+	}
+
+	query, args, err := sqlx.In(`select * from cmailer_suppressions where email in (?)`, emails)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to build suppressions query", nil)
+	}
+
+	var suppressions []Suppression
+
+	err = q.querier.Select(ctx, &suppressions, query, args...)
+
+	return suppressions, err
+}
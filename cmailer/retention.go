@@ -0,0 +1,16 @@
+package cmailer
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cretention"
+)
+
+// OutboxRetentionPolicy purges the outbox emails that were sent more than 30 days ago. Dead-lettered emails are kept
+// so that they can be requeued. Apps that use the Outbox can register it using cretention.Register.
+var OutboxRetentionPolicy = cretention.Policy{ //nolint:gochecknoglobals
+	Name:        "cmailer_outbox",
+	Description: "Sent outbox emails",
+	MaxAge:      30 * 24 * time.Hour, //nolint:gomnd
+	Purge:       cretention.DeleteRows("cmailer_outbox", "id", "status = '"+OutboxStatusSent+"' and sent_at < :before"),
+}
@@ -0,0 +1,162 @@
+package cmailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+const sendGridBaseURL = "https://api.sendgrid.com"
+
+// NewSendGridProvider creates a SendGridProvider
+func NewSendGridProvider(config ConfigSendGrid) *SendGridProvider {
+	if config.BaseURL == "" {
+		config.BaseURL = sendGridBaseURL
+	}
+
+	return &SendGridProvider{
+		config: config,
+		client: &http.Client{Timeout: defaultSendTimeout},
+	}
+}
+
+// SendGridProvider sends messages using the SendGrid v3 mail send API
+type SendGridProvider struct {
+	config ConfigSendGrid
+	client *http.Client
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridAttachment struct {
+	Content     string `json:"content"`
+	Type        string `json:"type"`
+	Filename    string `json:"filename"`
+	Disposition string `json:"disposition"`
+}
+
+// Send sends the message
+func (p *SendGridProvider) Send(ctx context.Context, msg Message) error {
+	from, err := sendGridAddr(msg.From)
+	if err != nil {
+		return err
+	}
+
+	personalization := make(map[string]interface{})
+
+	for key, addrs := range map[string][]string{"to": msg.To, "cc": msg.Cc, "bcc": msg.Bcc} {
+		if len(addrs) == 0 {
+			continue
+		}
+
+		list, err := sendGridAddrs(addrs)
+		if err != nil {
+			return err
+		}
+
+		personalization[key] = list
+	}
+
+	// SendGrid requires the plain text content to come first
+	content := make([]sendGridContent, 0, 2) //nolint:gomnd
+	if msg.PlainBody != "" {
+		content = append(content, sendGridContent{Type: "text/plain", Value: msg.PlainBody})
+	}
+
+	if msg.HTMLBody != "" {
+		content = append(content, sendGridContent{Type: "text/html", Value: msg.HTMLBody})
+	}
+
+	body := map[string]interface{}{
+		"personalizations": []map[string]interface{}{personalization},
+		"from":             from,
+		"subject":          msg.Subject,
+		"content":          content,
+	}
+
+	if msg.ReplyTo != "" {
+		replyTo, err := sendGridAddr(msg.ReplyTo)
+		if err != nil {
+			return err
+		}
+
+		body["reply_to"] = replyTo
+	}
+
+	if len(msg.Headers) > 0 {
+		body["headers"] = msg.Headers
+	}
+
+	if len(msg.Attachments) > 0 {
+		attachments := make([]sendGridAttachment, len(msg.Attachments))
+
+		for i, a := range msg.Attachments {
+			attachments[i] = sendGridAttachment{
+				Content:     base64.StdEncoding.EncodeToString(a.Bytes()),
+				Type:        a.ContentType,
+				Filename:    a.Filename,
+				Disposition: "attachment",
+			}
+		}
+
+		body["attachments"] = attachments
+	}
+
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return cerrors.New(err, "failed to encode sendgrid request", nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimSuffix(p.config.BaseURL, "/")+"/v3/mail/send", bytes.NewReader(reqBody))
+	if err != nil {
+		return cerrors.New(err, "failed to create sendgrid request", nil)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = doRequest(p.client, req)
+
+	return err
+}
+
+func sendGridAddrs(addrs []string) ([]sendGridAddress, error) {
+	list := make([]sendGridAddress, 0, len(addrs))
+
+	for _, addr := range addrs {
+		a, err := sendGridAddr(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, a)
+	}
+
+	return list, nil
+}
+
+func sendGridAddr(addr string) (sendGridAddress, error) {
+	a, err := mail.ParseAddress(addr)
+	if err != nil {
+		return sendGridAddress{}, cerrors.New(err, "invalid email address", map[string]interface{}{
+			"address": addr,
+		})
+	}
+
+	return sendGridAddress{Email: a.Address, Name: a.Name}, nil
+}
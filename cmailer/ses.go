@@ -0,0 +1,102 @@
+package cmailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/internal/awssig"
+)
+
+// NewSESProvider creates an SESProvider. Credentials that are not set in the config are read from the standard AWS
+// environment variables (AWS_REGION, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN).
+func NewSESProvider(config ConfigSES) *SESProvider {
+	if config.Region == "" {
+		config.Region = awssig.RegionFromEnv()
+	}
+
+	if config.AccessKeyID == "" {
+		config.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		config.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		config.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+
+	return &SESProvider{
+		config: config,
+		client: &http.Client{Timeout: defaultSendTimeout},
+	}
+}
+
+// SESProvider sends messages using the AWS SES v2 API. Messages are sent as raw MIME so that every message feature
+// is supported.
+type SESProvider struct {
+	config ConfigSES
+	client *http.Client
+}
+
+// Send sends the message
+func (p *SESProvider) Send(ctx context.Context, msg Message) error {
+	if p.config.Region == "" || p.config.AccessKeyID == "" || p.config.SecretAccessKey == "" {
+		return cerrors.New(nil, "ses region and credentials must be set", nil)
+	}
+
+	raw, err := buildMIME(msg)
+	if err != nil {
+		return err
+	}
+
+	// the destination includes the Bcc recipients since they are not in the raw message's headers
+	destination := map[string]interface{}{
+		"ToAddresses": msg.To,
+	}
+
+	if len(msg.Cc) > 0 {
+		destination["CcAddresses"] = msg.Cc
+	}
+
+	if len(msg.Bcc) > 0 {
+		destination["BccAddresses"] = msg.Bcc
+	}
+
+	reqBody, err := json.Marshal(map[string]interface{}{
+		"FromEmailAddress": msg.From,
+		"Destination":      destination,
+		"Content": map[string]interface{}{
+			"Raw": map[string]interface{}{
+				"Data": raw,
+			},
+		},
+	})
+	if err != nil {
+		return cerrors.New(err, "failed to encode ses request", nil)
+	}
+
+	endpoint := p.config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://email.%s.amazonaws.com", p.config.Region)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimSuffix(endpoint, "/")+"/v2/email/outbound-emails", bytes.NewReader(reqBody))
+	if err != nil {
+		return cerrors.New(err, "failed to create ses request", nil)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	awssig.Sign(req, reqBody, awssig.Credentials{
+		AccessKeyID:     p.config.AccessKeyID,
+		SecretAccessKey: p.config.SecretAccessKey,
+		SessionToken:    p.config.SessionToken,
+	}, p.config.Region, "ses", time.Now())
+
+	_, err = doRequest(p.client, req)
+
+	return err
+}
@@ -0,0 +1,147 @@
+package cmailer
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// NewSMTPProvider creates an SMTPProvider
+func NewSMTPProvider(config ConfigSMTP) *SMTPProvider {
+	return &SMTPProvider{config: config}
+}
+
+// SMTPProvider sends messages using an SMTP server. Each message is sent on a new connection.
+type SMTPProvider struct {
+	config ConfigSMTP
+}
+
+// Send sends the message
+func (p *SMTPProvider) Send(ctx context.Context, msg Message) error {
+	raw, err := buildMIME(msg)
+	if err != nil {
+		return err
+	}
+
+	client, err := p.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && !p.config.ImplicitTLS {
+		err = client.StartTLS(&tls.Config{ServerName: p.config.Host, MinVersion: tls.VersionTLS12})
+		if err != nil {
+			return cerrors.New(err, "failed to start tls", nil)
+		}
+	}
+
+	if p.config.Username != "" {
+		err = client.Auth(smtp.PlainAuth("", p.config.Username, p.config.Password, p.config.Host))
+		if err != nil {
+			return cerrors.New(err, "failed to authenticate with smtp server", nil)
+		}
+	}
+
+	err = p.send(client, msg, raw)
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (p *SMTPProvider) dial(ctx context.Context) (*smtp.Client, error) {
+	var (
+		addr   = net.JoinHostPort(p.config.Host, strconv.Itoa(p.config.Port))
+		dialer net.Dialer
+		conn   net.Conn
+		err    error
+	)
+
+	if p.config.ImplicitTLS {
+		tlsDialer := tls.Dialer{
+			NetDialer: &dialer,
+			Config:    &tls.Config{ServerName: p.config.Host, MinVersion: tls.VersionTLS12},
+		}
+
+		conn, err = tlsDialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+
+	if err != nil {
+		return nil, cerrors.New(err, "failed to connect to smtp server", map[string]interface{}{
+			"addr": addr,
+		})
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, p.config.Host)
+	if err != nil {
+		_ = conn.Close()
+
+		return nil, cerrors.New(err, "failed to create smtp client", map[string]interface{}{
+			"addr": addr,
+		})
+	}
+
+	return client, nil
+}
+
+func (p *SMTPProvider) send(client *smtp.Client, msg Message, raw []byte) error {
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return cerrors.New(err, "invalid sender address", nil)
+	}
+
+	err = client.Mail(from.Address)
+	if err != nil {
+		return cerrors.New(err, "smtp server rejected the sender", nil)
+	}
+
+	// Bcc recipients only appear in the envelope
+	recipients := make([]string, 0, len(msg.To)+len(msg.Cc)+len(msg.Bcc))
+	recipients = append(recipients, msg.To...)
+	recipients = append(recipients, msg.Cc...)
+	recipients = append(recipients, msg.Bcc...)
+
+	for _, to := range recipients {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return cerrors.New(err, "invalid recipient address", nil)
+		}
+
+		err = client.Rcpt(addr.Address)
+		if err != nil {
+			return cerrors.New(err, "smtp server rejected the recipient", map[string]interface{}{
+				"recipient": addr.Address,
+			})
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return cerrors.New(err, "failed to start smtp data", nil)
+	}
+
+	_, err = w.Write(raw)
+	if err != nil {
+		return cerrors.New(err, "failed to write smtp data", nil)
+	}
+
+	err = w.Close()
+	if err != nil {
+		return cerrors.New(err, "smtp server rejected the message", nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package cmailer
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/chttp"
+)
+
+// Template selects an email template and the data it is rendered with. See Templates.
+type Template struct {
+	// Name of the template in src/emails without its extension (ex. welcome)
+	Name string
+
+	// Layout in src/emails/layouts (default: cmailer.templates.layout)
+	Layout string
+
+	// Locale selects the translated version of the template (ex. src/emails/fr/welcome.html). If the template is not
+	// translated to the locale, the default template is used.
+	Locale string
+
+	Data interface{}
+}
+
+// RenderedTemplate is the subject and bodies of a rendered template
+type RenderedTemplate struct {
+	Subject   string
+	HTMLBody  string
+	PlainBody string
+}
+
+// NewTemplatesParams holds the params needed for NewTemplates
+type NewTemplatesParams struct {
+	HTMLDir     chttp.HTMLDir
+	RenderFuncs []chttp.HTMLRenderFunc
+	Config      Config
+}
+
+// NewTemplates creates a new Templates
+func NewTemplates(p NewTemplatesParams) *Templates {
+	return &Templates{
+		htmlDir:     p.HTMLDir,
+		renderFuncs: p.RenderFuncs,
+		layout:      p.Config.Templates.Layout,
+	}
+}
+
+// Templates renders email templates stored in the app's HTML dir (the same one used by chttp.HTMLRenderer):
+//
+//	src/emails/layouts/email.html  layout that renders the email's {{ template "content" . }}
+//	src/emails/welcome.html        defines the "subject" and "content" templates
+//	src/emails/welcome.txt         plain text version (optional)
+//	src/emails/fr/welcome.html     translated version used for the fr locale (optional)
+//
+// If a template has no plain text version, one is generated from the HTML. The CSS rules in the layout's <style>
+// tags are inlined into the HTML since many email clients ignore them. The partial func and the app's
+// chttp.HTMLRenderFunc funcs are available in templates, along with a locale func that returns the locale.
+type Templates struct {
+	htmlDir     chttp.HTMLDir
+	renderFuncs []chttp.HTMLRenderFunc
+	layout      string
+}
+
+// Render renders the template
+func (t *Templates) Render(ctx context.Context, tmpl Template) (RenderedTemplate, error) {
+	layout := tmpl.Layout
+	if layout == "" {
+		layout = t.layout
+	}
+
+	page, err := t.localized(tmpl.Locale, tmpl.Name+".html")
+	if err != nil {
+		return RenderedTemplate{}, err
+	}
+
+	// render funcs get a request so that they can read the context (ex. the user set by an auth middleware)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	if err != nil {
+		return RenderedTemplate{}, cerrors.New(err, "failed to create render request", nil)
+	}
+
+	funcs := t.funcMap(req, tmpl.Locale)
+
+	html, err := template.New(layout).
+		Funcs(funcs).
+		ParseFS(t.htmlDir, path.Join("src", "emails", "layouts", layout), page)
+	if err != nil {
+		return RenderedTemplate{}, cerrors.New(err, "failed to parse email templates", map[string]interface{}{
+			"layout":   layout,
+			"template": tmpl.Name,
+		})
+	}
+
+	var (
+		rendered RenderedTemplate
+		body     strings.Builder
+		subject  strings.Builder
+	)
+
+	err = html.Execute(&body, tmpl.Data)
+	if err != nil {
+		return RenderedTemplate{}, cerrors.New(err, "failed to execute email template", map[string]interface{}{
+			"template": tmpl.Name,
+		})
+	}
+
+	if html.Lookup("subject") != nil {
+		err = html.ExecuteTemplate(&subject, "subject", tmpl.Data)
+		if err != nil {
+			return RenderedTemplate{}, cerrors.New(err, "failed to execute email subject", map[string]interface{}{
+				"template": tmpl.Name,
+			})
+		}
+
+		// subjects are rendered by html/template so entities are unescaped to get plain text
+		rendered.Subject = strings.TrimSpace(unescapeText(subject.String()))
+	}
+
+	rendered.HTMLBody, err = inlineCSS(body.String())
+	if err != nil {
+		return RenderedTemplate{}, err
+	}
+
+	rendered.PlainBody, err = t.renderPlain(req, tmpl)
+	if err != nil {
+		return RenderedTemplate{}, err
+	}
+
+	if rendered.PlainBody == "" {
+		rendered.PlainBody, err = htmlToText(rendered.HTMLBody)
+		if err != nil {
+			return RenderedTemplate{}, err
+		}
+	}
+
+	return rendered, nil
+}
+
+// renderPlain renders the plain text version of the template. It returns an empty string if there is none.
+func (t *Templates) renderPlain(req *http.Request, tmpl Template) (string, error) {
+	page, err := t.localized(tmpl.Locale, tmpl.Name+".txt")
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	funcs := texttemplate.FuncMap(t.funcMap(req, tmpl.Locale))
+
+	text, err := texttemplate.New(path.Base(page)).Funcs(funcs).ParseFS(t.htmlDir, page)
+	if err != nil {
+		return "", cerrors.New(err, "failed to parse plain text email template", map[string]interface{}{
+			"template": tmpl.Name,
+		})
+	}
+
+	var dest strings.Builder
+
+	err = text.Execute(&dest, tmpl.Data)
+	if err != nil {
+		return "", cerrors.New(err, "failed to execute plain text email template", map[string]interface{}{
+			"template": tmpl.Name,
+		})
+	}
+
+	return strings.TrimSpace(dest.String()), nil
+}
+
+// localized returns the path of the file in src/emails for the locale, or the default file if it is not translated
+func (t *Templates) localized(locale, file string) (string, error) {
+	candidates := []string{path.Join("src", "emails", file)}
+	if locale != "" {
+		candidates = append([]string{path.Join("src", "emails", locale, file)}, candidates...)
+	}
+
+	for _, p := range candidates {
+		_, err := fs.Stat(t.htmlDir, p)
+		if err == nil {
+			return p, nil
+		}
+	}
+
+	return "", cerrors.New(fs.ErrNotExist, "email template does not exist", map[string]interface{}{
+		"file":   file,
+		"locale": locale,
+	})
+}
+
+func (t *Templates) funcMap(req *http.Request, locale string) template.FuncMap {
+	funcs := template.FuncMap{
+		"locale": func() string { return locale },
+		"partial": func(name string, data interface{}) (template.HTML, error) {
+			return t.partial(req, locale, name, data)
+		},
+	}
+
+	for i := range t.renderFuncs {
+		funcs[t.renderFuncs[i].Name] = t.renderFuncs[i].Func(req)
+	}
+
+	return funcs
+}
+
+func (t *Templates) partial(req *http.Request, locale, name string, data interface{}) (template.HTML, error) {
+	var dest strings.Builder
+
+	tmpl, err := template.New(name+".html").
+		Funcs(t.funcMap(req, locale)).
+		ParseFS(t.htmlDir, path.Join("src", "partials", "*.html"))
+	if err != nil {
+		return "", cerrors.New(err, "failed to parse partial template", map[string]interface{}{
+			"name": name,
+		})
+	}
+
+	err = tmpl.Execute(&dest, data)
+	if err != nil {
+		return "", cerrors.New(err, "failed to execute partial template", map[string]interface{}{
+			"name": name,
+		})
+	}
+
+	// nolint:gosec
+	return template.HTML(dest.String()), nil
+}
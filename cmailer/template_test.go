@@ -0,0 +1,126 @@
+package cmailer_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cmailer"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTemplates() *cmailer.Templates {
+	return cmailer.NewTemplates(cmailer.NewTemplatesParams{
+		HTMLDir: fstest.MapFS{
+			"src/emails/layouts/email.html": {Data: []byte(`<html><head><style>
+p { color: #333; }
+.button { background: blue; padding: 8px }
+@media (max-width: 600px) { p { font-size: 18px; } }
+</style></head><body>{{ template "content" . }}</body></html>`)},
+			"src/emails/welcome.html": {Data: []byte(`{{ define "subject" }}Welcome, {{ .Name }} & co{{ end }}
+{{ define "content" }}<h1>Hi {{ .Name }}</h1><p style="margin: 0">Thanks for joining {{ appName }}.</p>` +
+				`<a class="button" href="https://example.com/start">Get started</a>{{ end }}`)},
+			"src/emails/fr/welcome.html": {Data: []byte(`{{ define "subject" }}Bienvenue {{ .Name }}{{ end }}
+{{ define "content" }}<p>Bonjour {{ .Name }} ({{ locale }})</p>{{ end }}`)},
+			"src/emails/reset.html": {Data: []byte(`{{ define "content" }}<p>Reset</p>{{ end }}`)},
+			"src/emails/reset.txt":  {Data: []byte(`Reset your password: {{ .URL }}`)},
+		},
+		RenderFuncs: []chttp.HTMLRenderFunc{{
+			Name: "appName",
+			Func: func(r *http.Request) interface{} {
+				return func() string { return "Copper" }
+			},
+		}},
+		Config: cmailer.Config{Templates: cmailer.ConfigTemplates{Layout: "email.html"}},
+	})
+}
+
+func TestTemplates_Render(t *testing.T) {
+	t.Parallel()
+
+	rendered, err := newTestTemplates().Render(context.Background(), cmailer.Template{
+		Name: "welcome",
+		Data: map[string]string{"Name": "Alice"},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Welcome, Alice & co", rendered.Subject)
+	assert.Contains(t, rendered.HTMLBody, `<p style="color: #333; margin: 0">Thanks for joining Copper.</p>`)
+	assert.Contains(t, rendered.HTMLBody, `href="https://example.com/start" style="background: blue; padding: 8px"`)
+	assert.Contains(t, rendered.HTMLBody, "@media (max-width: 600px)")
+	assert.Equal(t, "Hi Alice\n\nThanks for joining Copper.\n\nGet started (https://example.com/start)",
+		rendered.PlainBody)
+}
+
+func TestTemplates_Render_Locale(t *testing.T) {
+	t.Parallel()
+
+	templates := newTestTemplates()
+
+	rendered, err := templates.Render(context.Background(), cmailer.Template{
+		Name:   "welcome",
+		Locale: "fr",
+		Data:   map[string]string{"Name": "Alice"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bienvenue Alice", rendered.Subject)
+	assert.Equal(t, "Bonjour Alice (fr)", rendered.PlainBody)
+
+	// templates that are not translated fall back to the default
+	rendered, err = templates.Render(context.Background(), cmailer.Template{
+		Name:   "welcome",
+		Locale: "de",
+		Data:   map[string]string{"Name": "Alice"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Welcome, Alice & co", rendered.Subject)
+}
+
+func TestTemplates_Render_PlainText(t *testing.T) {
+	t.Parallel()
+
+	rendered, err := newTestTemplates().Render(context.Background(), cmailer.Template{
+		Name: "reset",
+		Data: map[string]string{"URL": "https://example.com/reset?token=a&b"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "", rendered.Subject)
+	assert.Equal(t, "Reset your password: https://example.com/reset?token=a&b", rendered.PlainBody)
+}
+
+func TestTemplates_Render_NotFound(t *testing.T) {
+	t.Parallel()
+
+	_, err := newTestTemplates().Render(context.Background(), cmailer.Template{Name: "missing"})
+	assert.Error(t, err)
+}
+
+func TestMailer_SendTemplate(t *testing.T) {
+	t.Parallel()
+
+	var (
+		provider testProvider
+		mailer   = cmailer.NewMailer(cmailer.NewMailerParams{
+			Config:    cmailer.Config{From: "hello@example.com"},
+			Provider:  &provider,
+			Templates: newTestTemplates(),
+			Logger:    clogger.NewNoop(),
+		})
+	)
+
+	err := mailer.SendTemplate(context.Background(), cmailer.Message{
+		To:      []string{"alice@example.com"},
+		Subject: "Reset your password",
+	}, cmailer.Template{
+		Name: "reset",
+		Data: map[string]string{"URL": "https://example.com/reset"},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(provider.sent))
+	assert.Equal(t, "Reset your password", provider.sent[0].Subject)
+	assert.Contains(t, provider.sent[0].HTMLBody, `<p style="color: #333">Reset</p>`)
+}
@@ -0,0 +1,28 @@
+package cmailer
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+	NewProvider,
+	wire.Struct(new(NewProviderParams), "*"),
+
+	NewMailer,
+	wire.Struct(new(NewMailerParams), "*"),
+
+	NewTemplates,
+	wire.Struct(new(NewTemplatesParams), "*"),
+
+	NewQueries,
+	NewOutbox,
+	wire.Struct(new(NewOutboxParams), "*"),
+	NewOutboxWorker,
+	wire.Struct(new(NewOutboxWorkerParams), "*"),
+)
+
+// WireModuleBulk provides Bulk for apps that send campaigns. It needs a *cqueue.Queue (see cqueue.WireModule).
+var WireModuleBulk = wire.NewSet( //nolint:gochecknoglobals
+	NewBulk,
+	wire.Struct(new(NewBulkParams), "*"),
+)
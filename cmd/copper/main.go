@@ -0,0 +1,164 @@
+// Command copper scaffolds new copper apps and generates the boilerplate of their modules:
+//
+//	copper new <module path> [dir]           creates a new app (dir defaults to the last element of the path)
+//	copper gen module <name>                 generates a module (router, svc, repo, migration, tests) in pkg
+//	copper gen migration <module> <name>     creates an empty migration in a module
+//	copper wiring [dir]                      prints the dependency graph of the wire injectors in dir (default
+//	                                         pkg/app)
+//	copper dev [flags] [-- app args]         runs the app, and rebuilds and restarts it when its files change
+//
+// The gen and wiring commands run in the app's root dir.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"syscall"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/internal/devrunner"
+	"github.com/gocopper/copper/internal/scaffold"
+	"github.com/gocopper/copper/internal/wiregraph"
+)
+
+const usage = `Usage:
+  copper new <module path> [dir]
+  copper gen module <name>
+  copper gen migration <module> <name>
+  copper wiring [dir]
+  copper dev [flags] [-- app args]
+`
+
+func main() {
+	err := run(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	switch {
+	case len(args) >= 2 && args[0] == "new":
+		return newApp(args[1:])
+	case len(args) == 3 && args[0] == "gen" && args[1] == "module":
+		files, err := (&scaffold.Generator{Dir: "."}).Module(args[2])
+		printFiles(files)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("\nRun `go generate ./...` to update the app's wire setup.")
+
+		return nil
+	case len(args) == 4 && args[0] == "gen" && args[1] == "migration":
+		file, err := (&scaffold.Generator{Dir: "."}).Migration(args[2], args[3])
+		if err != nil {
+			return err
+		}
+
+		printFiles([]string{file})
+
+		return nil
+	case len(args) <= 2 && len(args) >= 1 && args[0] == "wiring":
+		dir := filepath.Join("pkg", "app")
+		if len(args) == 2 {
+			dir = args[1]
+		}
+
+		return printWiring(dir)
+	case len(args) >= 1 && args[0] == "dev":
+		return runDev(args[1:])
+	default:
+		fmt.Print(usage)
+		os.Exit(2) //nolint:gomnd
+
+		return nil
+	}
+}
+
+func newApp(args []string) error {
+	module := args[0]
+
+	dir := path.Base(module)
+	if len(args) > 1 {
+		dir = args[1]
+	}
+
+	files, err := (&scaffold.Generator{Dir: dir}).NewApp(module)
+	printFiles(files)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf(`
+Created %s. To run it:
+
+  cd %s
+  go get github.com/gocopper/copper github.com/google/wire/cmd/wire
+  go generate ./...
+  go mod tidy
+  go run ./cmd/app
+
+Run `+"`copper dev`"+` instead of go run to rebuild and restart the app when its files change.
+`, module, dir)
+
+	return nil
+}
+
+// printWiring prints the dependency graph of the injectors in the dir's wire_gen.go. It helps troubleshoot wiring
+// errors such as missing providers by showing which providers the injectors currently use and in which order.
+func printWiring(dir string) error {
+	src, err := os.ReadFile(filepath.Join(dir, "wire_gen.go"))
+	if err != nil {
+		return cerrors.New(err, "failed to read wire_gen.go; run `go generate ./...` first", map[string]interface{}{
+			"dir": dir,
+		})
+	}
+
+	injectors, err := wiregraph.Parse(src)
+	if err != nil {
+		return err
+	}
+
+	return wiregraph.WriteText(os.Stdout, injectors)
+}
+
+// runDev runs the app through devrunner until the command is interrupted
+func runDev(args []string) error {
+	var (
+		opts devrunner.Options
+		fs   = flag.NewFlagSet("copper dev", flag.ExitOnError)
+	)
+
+	fs.StringVar(&opts.Addr, "addr", ":3000", "address the dev proxy listens on")
+	fs.StringVar(&opts.AppAddr, "app-addr", "localhost:7501", "address of the app's HTTP server (see chttp.port)")
+	fs.StringVar(&opts.Package, "pkg", "./cmd/app", "app's main package")
+
+	_ = fs.Parse(args)
+
+	opts.Args = fs.Args()
+
+	runner, err := devrunner.New(opts)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return runner.Run(ctx)
+}
+
+func printFiles(files []string) {
+	for _, f := range files {
+		fmt.Println("  created " + f)
+	}
+}
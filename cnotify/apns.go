@@ -0,0 +1,196 @@
+package cnotify
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	apnsProductionURL = "https://api.push.apple.com"
+	apnsSandboxURL    = "https://api.sandbox.push.apple.com"
+
+	// apnsTokenLifetime is how long a provider token is reused. Apple rejects tokens older than an hour and
+	// throttles tokens that are renewed more often than every 20 minutes.
+	apnsTokenLifetime = 50 * time.Minute
+
+	// ecdsaP256Size is the size of each of the r and s values of an ES256 signature
+	ecdsaP256Size = 32
+)
+
+// NewAPNsProvider creates an APNsProvider using the token signing key in the key file
+func NewAPNsProvider(config ConfigAPNs) (*APNsProvider, error) {
+	data, err := os.ReadFile(config.KeyFile)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to read apns key file", map[string]interface{}{
+			"path": config.KeyFile,
+		})
+	}
+
+	key, err := parseECDSAKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.BaseURL == "" {
+		config.BaseURL = apnsSandboxURL
+		if config.Production {
+			config.BaseURL = apnsProductionURL
+		}
+	}
+
+	return &APNsProvider{
+		config: config,
+		key:    key,
+		client: &http.Client{Timeout: defaultRequestTimeout},
+	}, nil
+}
+
+// APNsProvider sends push notifications to Apple devices using the APNs HTTP/2 API with token-based authentication
+type APNsProvider struct {
+	config ConfigAPNs
+	key    *ecdsa.PrivateKey
+	client *http.Client
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+// Push sends the notification to the device. The notification's data is sent as custom keys of the payload.
+func (p *APNsProvider) Push(ctx context.Context, deviceToken string, n Notification) error {
+	token, err := p.providerToken()
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{
+				"title": n.Subject,
+				"body":  n.Body,
+			},
+			"sound": "default",
+		},
+	}
+
+	for key, val := range n.Data {
+		if key != "aps" {
+			payload[key] = val
+		}
+	}
+
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return cerrors.New(err, "failed to encode apns payload", nil)
+	}
+
+	endpoint := strings.TrimSuffix(p.config.BaseURL, "/") + "/3/device/" + url.PathEscape(deviceToken)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return cerrors.New(err, "failed to create apns request", nil)
+	}
+
+	req.Header.Set("Authorization", "bearer "+token)
+	req.Header.Set("Apns-Topic", p.config.Topic)
+	req.Header.Set("Apns-Push-Type", "alert")
+	req.Header.Set("Content-Type", "application/json")
+
+	status, body, err := sendRequest(p.client, req)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Reason string `json:"reason"`
+	}
+
+	_ = json.Unmarshal(body, &resp)
+
+	if status == http.StatusGone || resp.Reason == "BadDeviceToken" || resp.Reason == "Unregistered" {
+		return cerrors.New(ErrUnregistered, "apns device token is not registered", map[string]interface{}{
+			"reason": resp.Reason,
+		})
+	}
+
+	if status >= http.StatusMultipleChoices {
+		return responseErr(req, status, body)
+	}
+
+	return nil
+}
+
+// providerToken returns the cached provider token or signs a new one once it is too old
+func (p *APNsProvider) providerToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+
+	if p.token != "" && now.Sub(p.issuedAt) < apnsTokenLifetime {
+		return p.token, nil
+	}
+
+	token, err := signJWT(map[string]interface{}{
+		"alg": "ES256",
+		"kid": p.config.KeyID,
+	}, map[string]interface{}{
+		"iss": p.config.TeamID,
+		"iat": now.Unix(),
+	}, func(input []byte) ([]byte, error) {
+		digest := sha256.Sum256(input)
+
+		r, s, err := ecdsa.Sign(rand.Reader, p.key, digest[:])
+		if err != nil {
+			return nil, err
+		}
+
+		// ES256 signatures are the fixed size r and s values concatenated
+		sig := make([]byte, 2*ecdsaP256Size)
+		r.FillBytes(sig[:ecdsaP256Size])
+		s.FillBytes(sig[ecdsaP256Size:])
+
+		return sig, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	p.token = token
+	p.issuedAt = now
+
+	return token, nil
+}
+
+func parseECDSAKey(pemKey []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, cerrors.New(nil, "apns key is not pem encoded", nil)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to parse apns key", nil)
+	}
+
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, cerrors.New(nil, "apns key is not an ecdsa key", nil)
+	}
+
+	return ecKey, nil
+}
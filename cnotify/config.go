@@ -0,0 +1,171 @@
+package cnotify
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+// SMS providers supported by NewSMSProvider
+const (
+	SMSProviderLog    = "log"
+	SMSProviderTwilio = "twilio"
+	SMSProviderSNS    = "sns"
+)
+
+// Push providers supported by NewPushProvider
+const (
+	PushProviderLog  = "log"
+	PushProviderFCM  = "fcm"
+	PushProviderAPNs = "apns"
+)
+
+const (
+	defaultOTPLength         = 6
+	defaultOTPTTL            = 10 * time.Minute
+	defaultOTPMaxAttempts    = 5
+	defaultOTPResendInterval = 30 * time.Second
+	defaultOTPMaxPerHour     = 5
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "cnotify",
+		Description: "cnotify configures the SMS and push notification providers and one-time codes",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("cnotify", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load cnotify config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		SMSProvider:  SMSProviderLog,
+		PushProvider: PushProviderLog,
+		OTP: ConfigOTP{
+			Length:         defaultOTPLength,
+			TTL:            defaultOTPTTL,
+			MaxAttempts:    defaultOTPMaxAttempts,
+			ResendInterval: defaultOTPResendInterval,
+			MaxPerHour:     defaultOTPMaxPerHour,
+		},
+	}
+}
+
+// Config configures the notification channels. Emails are sent using cmailer and its config. For example:
+//
+//	[cnotify]
+//	sms_provider = "twilio"
+//	push_provider = "fcm"
+//
+//	[cnotify.twilio]
+//	account_sid = "ACxxx"
+//	auth_token = "xxx"
+//	from = "+15550100"
+//
+//	[cnotify.fcm]
+//	credentials_file = "firebase-service-account.json"
+type Config struct {
+	// SMSProvider is one of log, twilio, or sns
+	SMSProvider string `toml:"sms_provider" valid:"in(log|twilio|sns)" doc:"log, twilio, or sns"`
+
+	// PushProvider is one of log, fcm, or apns
+	PushProvider string `toml:"push_provider" valid:"in(log|fcm|apns)" doc:"log, fcm, or apns"`
+
+	Twilio ConfigTwilio `toml:"twilio"`
+	SNS    ConfigSNS    `toml:"sns"`
+	FCM    ConfigFCM    `toml:"fcm"`
+	APNs   ConfigAPNs   `toml:"apns"`
+
+	OTP ConfigOTP `toml:"otp"`
+}
+
+// ConfigOTP configures the one-time codes issued by OTP
+type ConfigOTP struct {
+	// Length is the number of digits in a code
+	Length int `toml:"length"`
+
+	// TTL is how long a code can be used after it is issued
+	TTL time.Duration `toml:"ttl"`
+
+	// MaxAttempts is the number of wrong codes that can be entered before the code is invalidated
+	MaxAttempts int `toml:"max_attempts"`
+
+	// ResendInterval is the min wait time before a new code can be issued to the same identifier
+	ResendInterval time.Duration `toml:"resend_interval"`
+
+	// MaxPerHour is the max number of codes issued to the same identifier in an hour
+	MaxPerHour int `toml:"max_per_hour"`
+}
+
+// ConfigTwilio configures the Twilio SMS provider
+type ConfigTwilio struct {
+	AccountSID string `toml:"account_sid"`
+	AuthToken  string `toml:"auth_token"`
+
+	// From is the sender's phone number. MessagingServiceSID can be set instead to let Twilio pick the sender.
+	From                string `toml:"from"`
+	MessagingServiceSID string `toml:"messaging_service_sid"`
+
+	// BaseURL overrides the Twilio API URL
+	BaseURL string `toml:"base_url"`
+}
+
+// ConfigSNS configures the AWS SNS SMS provider. Credentials that are not set are read from the standard AWS
+// environment variables.
+type ConfigSNS struct {
+	Region          string `toml:"region"`
+	AccessKeyID     string `toml:"access_key_id"`
+	SecretAccessKey string `toml:"secret_access_key"`
+	SessionToken    string `toml:"session_token"`
+
+	// SenderID is shown as the sender in the countries that support it
+	SenderID string `toml:"sender_id"`
+
+	// Transactional marks messages as transactional (ex. verification codes) so that they are delivered with higher
+	// reliability
+	Transactional bool `toml:"transactional"`
+
+	// Endpoint overrides the regional endpoint (ex. for localstack)
+	Endpoint string `toml:"endpoint"`
+}
+
+// ConfigFCM configures the Firebase Cloud Messaging push provider
+type ConfigFCM struct {
+	// CredentialsFile is the path to the Firebase service account's JSON key
+	CredentialsFile string `toml:"credentials_file"`
+
+	// ProjectID overrides the project id in the credentials file
+	ProjectID string `toml:"project_id"`
+
+	// BaseURL overrides the FCM API URL
+	BaseURL string `toml:"base_url"`
+}
+
+// ConfigAPNs configures the Apple Push Notification service provider. Requests are authenticated using a token
+// signing key (.p8) created in the Apple developer account.
+type ConfigAPNs struct {
+	KeyFile string `toml:"key_file"`
+	KeyID   string `toml:"key_id"`
+	TeamID  string `toml:"team_id"`
+
+	// Topic is the app's bundle id
+	Topic string `toml:"topic"`
+
+	// Production sends notifications using the production environment instead of the sandbox
+	Production bool `toml:"production"`
+
+	// BaseURL overrides the APNs URL
+	BaseURL string `toml:"base_url"`
+}
@@ -0,0 +1,4 @@
+// Package cnotify delivers notifications (ex. verification codes, alerts) to users over email, SMS, and push
+// channels. Email is sent using cmailer, SMS using Twilio or AWS SNS, and push notifications using Firebase Cloud
+// Messaging or Apple Push Notification service. See Notifier.
+package cnotify
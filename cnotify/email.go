@@ -0,0 +1,32 @@
+package cnotify
+
+import (
+	"context"
+
+	"github.com/gocopper/copper/cmailer"
+)
+
+// NewEmailChannel creates an EmailChannel
+func NewEmailChannel(mailer *cmailer.Mailer) *EmailChannel {
+	return &EmailChannel{mailer: mailer}
+}
+
+// EmailChannel sends notifications as emails using cmailer. The sender is cmailer.from.
+type EmailChannel struct {
+	mailer *cmailer.Mailer
+}
+
+// Reaches returns true if the recipient has an email address
+func (c *EmailChannel) Reaches(to Recipient) bool {
+	return to.Email != ""
+}
+
+// Send sends the notification as an email
+func (c *EmailChannel) Send(ctx context.Context, to Recipient, n Notification) error {
+	return c.mailer.Send(ctx, cmailer.Message{
+		To:        []string{to.Email},
+		Subject:   n.Subject,
+		HTMLBody:  n.HTMLBody,
+		PlainBody: n.Body,
+	})
+}
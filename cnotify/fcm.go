@@ -0,0 +1,219 @@
+package cnotify
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	fcmBaseURL = "https://fcm.googleapis.com"
+	fcmScope   = "https://www.googleapis.com/auth/firebase.messaging"
+
+	// fcmTokenLifetime is the lifetime of the requested access tokens. They are renewed a minute before they expire.
+	fcmTokenLifetime = time.Hour
+)
+
+type fcmCredentials struct {
+	ProjectID   string `json:"project_id"`
+	PrivateKey  string `json:"private_key"`
+	ClientEmail string `json:"client_email"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// NewFCMProvider creates an FCMProvider using the service account in the credentials file
+func NewFCMProvider(config ConfigFCM) (*FCMProvider, error) {
+	data, err := os.ReadFile(config.CredentialsFile)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to read fcm credentials file", map[string]interface{}{
+			"path": config.CredentialsFile,
+		})
+	}
+
+	var creds fcmCredentials
+
+	err = json.Unmarshal(data, &creds)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to decode fcm credentials file", map[string]interface{}{
+			"path": config.CredentialsFile,
+		})
+	}
+
+	key, err := parseRSAKey(creds.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.ProjectID == "" {
+		config.ProjectID = creds.ProjectID
+	}
+
+	if config.BaseURL == "" {
+		config.BaseURL = fcmBaseURL
+	}
+
+	return &FCMProvider{
+		config: config,
+		creds:  creds,
+		key:    key,
+		client: &http.Client{Timeout: defaultRequestTimeout},
+	}, nil
+}
+
+// FCMProvider sends push notifications to Android, iOS, and web devices using the Firebase Cloud Messaging HTTP v1
+// API. Requests are authorized using access tokens obtained with the service account's key.
+type FCMProvider struct {
+	config ConfigFCM
+	creds  fcmCredentials
+	key    *rsa.PrivateKey
+	client *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// Push sends the notification to the device
+func (p *FCMProvider) Push(ctx context.Context, deviceToken string, n Notification) error {
+	accessToken, err := p.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	message := map[string]interface{}{
+		"token": deviceToken,
+		"notification": map[string]string{
+			"title": n.Subject,
+			"body":  n.Body,
+		},
+	}
+
+	if len(n.Data) > 0 {
+		message["data"] = n.Data
+	}
+
+	reqBody, err := json.Marshal(map[string]interface{}{"message": message})
+	if err != nil {
+		return cerrors.New(err, "failed to encode fcm request", nil)
+	}
+
+	endpoint := strings.TrimSuffix(p.config.BaseURL, "/") + "/v1/projects/" + url.PathEscape(p.config.ProjectID) +
+		"/messages:send"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return cerrors.New(err, "failed to create fcm request", nil)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	status, body, err := sendRequest(p.client, req)
+	if err != nil {
+		return err
+	}
+
+	if status == http.StatusNotFound || bytes.Contains(body, []byte(`"UNREGISTERED"`)) {
+		return cerrors.New(ErrUnregistered, "fcm device token is not registered", nil)
+	}
+
+	if status >= http.StatusMultipleChoices {
+		return responseErr(req, status, body)
+	}
+
+	return nil
+}
+
+// token returns a cached access token or requests a new one using a JWT signed with the service account's key
+func (p *FCMProvider) token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+
+	if p.accessToken != "" && now.Before(p.expiresAt.Add(-time.Minute)) {
+		return p.accessToken, nil
+	}
+
+	assertion, err := signJWT(map[string]interface{}{
+		"alg": "RS256",
+		"typ": "JWT",
+	}, map[string]interface{}{
+		"iss":   p.creds.ClientEmail,
+		"scope": fcmScope,
+		"aud":   p.creds.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(fcmTokenLifetime).Unix(),
+	}, func(input []byte) ([]byte, error) {
+		digest := sha256.Sum256(input)
+
+		return rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	})
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.creds.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", cerrors.New(err, "failed to create fcm token request", nil)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	body, err := doRequest(p.client, req)
+	if err != nil {
+		return "", cerrors.New(err, "failed to get fcm access token", nil)
+	}
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+
+	err = json.Unmarshal(body, &resp)
+	if err != nil {
+		return "", cerrors.New(err, "failed to decode fcm access token", nil)
+	}
+
+	p.accessToken = resp.AccessToken
+	p.expiresAt = now.Add(time.Duration(resp.ExpiresIn) * time.Second)
+
+	return p.accessToken, nil
+}
+
+func parseRSAKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, cerrors.New(nil, "fcm private key is not pem encoded", nil)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to parse fcm private key", nil)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, cerrors.New(nil, "fcm private key is not an rsa key", nil)
+	}
+
+	return rsaKey, nil
+}
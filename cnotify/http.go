@@ -0,0 +1,52 @@
+package cnotify
+
+import (
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+const defaultRequestTimeout = 30 * time.Second
+
+// sendRequest sends the request and returns the response's status code and body. An error is only returned if no
+// response was received.
+func sendRequest(client *http.Client, req *http.Request) (int, []byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, cerrors.New(err, "failed to send request", map[string]interface{}{
+			"url": req.URL.String(),
+		})
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, cerrors.New(err, "failed to read response", nil)
+	}
+
+	return resp.StatusCode, body, nil
+}
+
+// doRequest sends the request and returns the response's body. An error is returned if the response is not a 2xx.
+func doRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	status, body, err := sendRequest(client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if status >= http.StatusMultipleChoices {
+		return nil, responseErr(req, status, body)
+	}
+
+	return body, nil
+}
+
+func responseErr(req *http.Request, status int, body []byte) error {
+	return cerrors.New(nil, "request failed", map[string]interface{}{
+		"url":        req.URL.String(),
+		"statusCode": status,
+		"body":       string(body),
+	})
+}
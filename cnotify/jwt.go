@@ -0,0 +1,31 @@
+package cnotify
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// signJWT creates a JWT with the given header and claims. The signing input is passed to sign, which returns the
+// signature in the format of the header's alg.
+func signJWT(header, claims map[string]interface{}, sign func(input []byte) ([]byte, error)) (string, error) {
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", cerrors.New(err, "failed to encode jwt header", nil)
+	}
+
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", cerrors.New(err, "failed to encode jwt claims", nil)
+	}
+
+	input := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	sig, err := sign([]byte(input))
+	if err != nil {
+		return "", cerrors.New(err, "failed to sign jwt", nil)
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
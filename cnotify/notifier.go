@@ -0,0 +1,204 @@
+package cnotify
+
+import (
+	"context"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+)
+
+// Channels supported by Notifier
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPush  = "push"
+)
+
+// phoneRegexp matches phone numbers in the E.164 format (ex. +15550100)
+var phoneRegexp = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`) //nolint:gochecknoglobals
+
+// Recipient is who a notification is sent to. A notification can only be sent on the channels the recipient has an
+// address for.
+type Recipient struct {
+	Email string
+
+	// Phone is a phone number in the E.164 format (ex. +15550100)
+	Phone string
+
+	// DeviceTokens are the push tokens of the recipient's devices
+	DeviceTokens []string
+}
+
+// RecipientFromIdentifier creates a Recipient from an identifier that is either an email address or a phone number,
+// such as the one a user signs in with. It returns false if the identifier is neither.
+func RecipientFromIdentifier(identifier string) (Recipient, bool) {
+	identifier = strings.TrimSpace(identifier)
+
+	if IsPhone(identifier) {
+		return Recipient{Phone: identifier}, true
+	}
+
+	if addr, err := mail.ParseAddress(identifier); err == nil && addr.Address == identifier {
+		return Recipient{Email: identifier}, true
+	}
+
+	return Recipient{}, false
+}
+
+// IsPhone returns true if s is a phone number in the E.164 format
+func IsPhone(s string) bool {
+	return phoneRegexp.MatchString(s)
+}
+
+// Notification is the content of a notification. Each channel uses the parts it supports.
+type Notification struct {
+	// Subject is the email subject and the push notification title
+	Subject string
+
+	// Body is the email plain text body, the SMS text, and the push notification body
+	Body string
+
+	// HTMLBody is the email HTML body (optional)
+	HTMLBody string
+
+	// Data is sent as the push notification's custom data
+	Data map[string]string
+}
+
+// Channel delivers notifications to recipients over a medium such as email or SMS
+type Channel interface {
+	// Reaches returns true if the recipient has an address on the channel
+	Reaches(to Recipient) bool
+
+	Send(ctx context.Context, to Recipient, n Notification) error
+}
+
+// NewNotifierParams holds the params needed for NewNotifier
+type NewNotifierParams struct {
+	Email  *EmailChannel
+	SMS    *SMSChannel
+	Push   *PushChannel
+	Logger clogger.Logger
+}
+
+// NewNotifier creates a new Notifier with the email, SMS, and push channels. A nil channel is not available.
+func NewNotifier(p NewNotifierParams) *Notifier {
+	channels := make(map[string]Channel)
+
+	if p.Email != nil {
+		channels[ChannelEmail] = p.Email
+	}
+
+	if p.SMS != nil {
+		channels[ChannelSMS] = p.SMS
+	}
+
+	if p.Push != nil {
+		channels[ChannelPush] = p.Push
+	}
+
+	return &Notifier{
+		channels: channels,
+		logger:   p.Logger.With(clogger.Module("cnotify")),
+	}
+}
+
+// Notifier sends notifications over one or more channels. For example, a verification code can be sent to the
+// identifier a user signs up with, whether it is an email address or a phone number:
+//
+//	to, ok := cnotify.RecipientFromIdentifier(identifier)
+//	if !ok {
+//		return errInvalidIdentifier
+//	}
+//
+//	err := notifier.Send(ctx, to, cnotify.Notification{
+//		Subject: "Your verification code",
+//		Body:    "Your verification code is " + code,
+//	})
+type Notifier struct {
+	channels map[string]Channel
+	logger   clogger.Logger
+}
+
+// Register adds a custom channel or replaces a built-in one. It must be called before notifications are sent.
+func (n *Notifier) Register(name string, channel Channel) {
+	n.channels[name] = channel
+}
+
+// Send sends the notification on the given channels. If no channels are given, it is sent on every channel that
+// reaches the recipient. A failure on one channel does not stop the others, and an error is returned if any of them
+// fail.
+func (n *Notifier) Send(ctx context.Context, to Recipient, notification Notification, channels ...string) error {
+	if len(channels) == 0 {
+		channels = n.reachable(to)
+	}
+
+	if len(channels) == 0 {
+		return cerrors.New(nil, "recipient cannot be reached on any channel", nil)
+	}
+
+	var (
+		failed   []string
+		firstErr error
+	)
+
+	for _, name := range channels {
+		err := n.send(ctx, name, to, notification)
+		if err == nil {
+			continue
+		}
+
+		n.logger.WithTags(map[string]interface{}{
+			"channel": name,
+		}).Warn("Failed to send notification", err)
+
+		failed = append(failed, name)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if firstErr != nil {
+		return cerrors.New(firstErr, "failed to send notification", map[string]interface{}{
+			"failedChannels": strings.Join(failed, ","),
+		})
+	}
+
+	return nil
+}
+
+func (n *Notifier) send(ctx context.Context, name string, to Recipient, notification Notification) error {
+	channel, ok := n.channels[name]
+	if !ok {
+		return cerrors.New(nil, "notification channel is not available", map[string]interface{}{
+			"channel": name,
+		})
+	}
+
+	if !channel.Reaches(to) {
+		return cerrors.New(nil, "recipient cannot be reached on the channel", map[string]interface{}{
+			"channel": name,
+		})
+	}
+
+	return channel.Send(ctx, to, notification)
+}
+
+// reachable returns the names of the channels that reach the recipient in a stable order
+func (n *Notifier) reachable(to Recipient) []string {
+	names := make([]string, 0, len(n.channels))
+
+	for name, channel := range n.channels {
+		if channel.Reaches(to) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
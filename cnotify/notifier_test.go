@@ -0,0 +1,94 @@
+package cnotify_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cmailer"
+	"github.com/gocopper/copper/cmailer/cmailertest"
+	"github.com/gocopper/copper/cnotify"
+	"github.com/stretchr/testify/assert"
+)
+
+type testSMSProvider struct {
+	sent map[string]string
+	err  error
+}
+
+func (p *testSMSProvider) SendSMS(ctx context.Context, to, body string) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	p.sent[to] = body
+
+	return nil
+}
+
+func TestRecipientFromIdentifier(t *testing.T) {
+	t.Parallel()
+
+	to, ok := cnotify.RecipientFromIdentifier("+15550100")
+	assert.True(t, ok)
+	assert.Equal(t, cnotify.Recipient{Phone: "+15550100"}, to)
+
+	to, ok = cnotify.RecipientFromIdentifier(" alice@example.com ")
+	assert.True(t, ok)
+	assert.Equal(t, cnotify.Recipient{Email: "alice@example.com"}, to)
+
+	_, ok = cnotify.RecipientFromIdentifier("555-0100")
+	assert.False(t, ok)
+
+	_, ok = cnotify.RecipientFromIdentifier("Alice <alice@example.com>")
+	assert.False(t, ok)
+}
+
+func TestNotifier_Send(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mailbox = cmailertest.NewMailbox()
+		sms     = testSMSProvider{sent: make(map[string]string)}
+
+		notifier = cnotify.NewNotifier(cnotify.NewNotifierParams{
+			Email: cnotify.NewEmailChannel(cmailer.NewMailer(cmailer.NewMailerParams{
+				Config:   cmailer.Config{From: "hello@example.com"},
+				Provider: mailbox,
+				Logger:   clogger.NewNoop(),
+			})),
+			SMS:    cnotify.NewSMSChannel(&sms),
+			Logger: clogger.NewNoop(),
+		})
+		code = cnotify.Notification{Subject: "Your code", Body: "Your code is 123456"}
+	)
+
+	// a phone number is only reached by sms
+	assert.NoError(t, notifier.Send(context.Background(), cnotify.Recipient{Phone: "+15550100"}, code))
+	assert.Equal(t, "Your code is 123456", sms.sent["+15550100"])
+	assert.Equal(t, 0, mailbox.Len())
+
+	// an email address is only reached by email
+	assert.NoError(t, notifier.Send(context.Background(), cnotify.Recipient{Email: "alice@example.com"}, code))
+
+	email, ok := mailbox.LastTo("alice@example.com")
+	assert.True(t, ok)
+	assert.Equal(t, "Your code", email.Subject)
+
+	// explicit channels must reach the recipient and be available
+	err := notifier.Send(context.Background(), cnotify.Recipient{Email: "alice@example.com"}, code, cnotify.ChannelSMS)
+	assert.Error(t, err)
+
+	err = notifier.Send(context.Background(), cnotify.Recipient{DeviceTokens: []string{"token"}}, code)
+	assert.Error(t, err)
+
+	// a failing channel does not stop the others
+	sms.err = errors.New("test-err")
+
+	err = notifier.Send(context.Background(), cnotify.Recipient{Email: "bob@example.com", Phone: "+15550101"}, code)
+	assert.Error(t, err)
+
+	_, ok = mailbox.LastTo("bob@example.com")
+	assert.True(t, ok)
+}
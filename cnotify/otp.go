@@ -0,0 +1,256 @@
+package cnotify
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Errors returned by OTP
+var (
+	ErrOTPRateLimited = errors.New("too many codes requested; try again later")
+	ErrOTPInvalid     = errors.New("code is invalid or expired")
+)
+
+// OTPRecord is the state of the codes issued to an identifier
+type OTPRecord struct {
+	// CodeHash is the hash of the current code
+	CodeHash  string
+	ExpiresAt time.Time
+
+	// Attempts is the number of wrong codes entered for the current code
+	Attempts int
+
+	// IssuedAt holds the times codes were issued in the last hour, used for rate limiting
+	IssuedAt []time.Time
+}
+
+// OTPStore persists OTPRecords by identifier
+type OTPStore interface {
+	// Get returns the record for the identifier. It returns false if there is none.
+	Get(ctx context.Context, identifier string) (OTPRecord, bool, error)
+
+	// Put saves the record for the identifier. It can be deleted after the ttl.
+	Put(ctx context.Context, identifier string, record OTPRecord, ttl time.Duration) error
+}
+
+// NewOTPParams holds the params needed for NewOTP
+type NewOTPParams struct {
+	Notifier *Notifier
+	Store    OTPStore
+	Config   Config
+}
+
+// NewOTP creates a new OTP
+func NewOTP(p NewOTPParams) *OTP {
+	return &OTP{
+		notifier: p.Notifier,
+		store:    p.Store,
+		config:   p.Config.OTP,
+		now:      time.Now,
+	}
+}
+
+// OTP issues numeric one-time codes to an email address or a phone number and verifies them. It can be used to
+// verify a user's identifier at signup and to log in without a password. Codes are sent using the Notifier over
+// email or SMS based on the identifier, and only their hashes are stored. Issuing codes is rate limited per
+// identifier and codes are invalidated after too many wrong attempts.
+type OTP struct {
+	notifier *Notifier
+	store    OTPStore
+	config   ConfigOTP
+	now      func() time.Time
+
+	// mu serializes the read-modify-write of records so that concurrent requests cannot bypass the limits
+	mu sync.Mutex
+}
+
+// Issue sends a new code to the identifier, which must be an email address or an E.164 phone number. Previously
+// issued codes are invalidated. It returns ErrOTPRateLimited if too many codes were requested.
+func (o *OTP) Issue(ctx context.Context, identifier string) error {
+	to, ok := RecipientFromIdentifier(identifier)
+	if !ok {
+		return cerrors.New(nil, "identifier must be an email address or an E.164 phone number", map[string]interface{}{
+			"identifier": identifier,
+		})
+	}
+
+	code, err := o.reserve(ctx, identifier)
+	if err != nil {
+		return err
+	}
+
+	err = o.notifier.Send(ctx, to, Notification{
+		Subject: "Your verification code",
+		Body: fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code,
+			int(o.config.TTL.Minutes())),
+	})
+	if err != nil {
+		return cerrors.New(err, "failed to send verification code", nil)
+	}
+
+	return nil
+}
+
+// Verify checks the code entered for the identifier. Each code can only be used once. It returns ErrOTPInvalid if
+// the code is wrong, expired, or was already used.
+func (o *OTP) Verify(ctx context.Context, identifier, code string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	record, ok, err := o.store.Get(ctx, identifier)
+	if err != nil {
+		return cerrors.New(err, "failed to get otp record", nil)
+	}
+
+	now := o.now()
+
+	if !ok || record.CodeHash == "" || now.After(record.ExpiresAt) {
+		return ErrOTPInvalid
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashOTP(identifier, code)), []byte(record.CodeHash)) == 1 {
+		record.CodeHash = ""
+		record.Attempts = 0
+	} else {
+		record.Attempts++
+		if record.Attempts >= o.config.MaxAttempts {
+			record.CodeHash = ""
+		}
+
+		err = ErrOTPInvalid
+	}
+
+	putErr := o.store.Put(ctx, identifier, record, o.recordTTL())
+	if putErr != nil {
+		return cerrors.New(putErr, "failed to save otp record", nil)
+	}
+
+	return err
+}
+
+// reserve checks the rate limits and saves a new code for the identifier
+func (o *OTP) reserve(ctx context.Context, identifier string) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	record, _, err := o.store.Get(ctx, identifier)
+	if err != nil {
+		return "", cerrors.New(err, "failed to get otp record", nil)
+	}
+
+	now := o.now()
+
+	issued := make([]time.Time, 0, len(record.IssuedAt)+1)
+	for _, t := range record.IssuedAt {
+		if now.Sub(t) < time.Hour {
+			issued = append(issued, t)
+		}
+	}
+
+	if len(issued) >= o.config.MaxPerHour ||
+		(len(issued) > 0 && now.Sub(issued[len(issued)-1]) < o.config.ResendInterval) {
+		return "", ErrOTPRateLimited
+	}
+
+	code, err := newOTPCode(o.config.Length)
+	if err != nil {
+		return "", err
+	}
+
+	err = o.store.Put(ctx, identifier, OTPRecord{
+		CodeHash:  hashOTP(identifier, code),
+		ExpiresAt: now.Add(o.config.TTL),
+		IssuedAt:  append(issued, now),
+	}, o.recordTTL())
+	if err != nil {
+		return "", cerrors.New(err, "failed to save otp record", nil)
+	}
+
+	return code, nil
+}
+
+// recordTTL is how long records are kept: until the code expires and for the hour used by the rate limit
+func (o *OTP) recordTTL() time.Duration {
+	if o.config.TTL > time.Hour {
+		return o.config.TTL
+	}
+
+	return time.Hour
+}
+
+func newOTPCode(length int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(length)), nil) //nolint:gomnd
+
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", cerrors.New(err, "failed to generate code", nil)
+	}
+
+	return fmt.Sprintf("%0*d", length, n), nil
+}
+
+// hashOTP hashes the code along with the identifier so that a code hash cannot be reused for another identifier
+func hashOTP(identifier, code string) string {
+	sum := sha256.Sum256([]byte(identifier + ":" + code))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// NewMemoryOTPStore returns an in-memory implementation of OTPStore. It is suitable for single instance deployments
+// and tests. Multi-instance deployments should use a shared store.
+func NewMemoryOTPStore() OTPStore {
+	return &memoryOTPStore{
+		records: make(map[string]memoryOTPEntry),
+		now:     time.Now,
+	}
+}
+
+type memoryOTPEntry struct {
+	record    OTPRecord
+	expiresAt time.Time
+}
+
+type memoryOTPStore struct {
+	mu      sync.Mutex
+	records map[string]memoryOTPEntry
+	now     func() time.Time
+}
+
+func (s *memoryOTPStore) Get(ctx context.Context, identifier string) (OTPRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.records[identifier]
+	if !ok || s.now().After(entry.expiresAt) {
+		return OTPRecord{}, false, nil
+	}
+
+	return entry.record, true, nil
+}
+
+func (s *memoryOTPStore) Put(ctx context.Context, identifier string, record OTPRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	for k, entry := range s.records {
+		if now.After(entry.expiresAt) {
+			delete(s.records, k)
+		}
+	}
+
+	s.records[identifier] = memoryOTPEntry{record: record, expiresAt: now.Add(ttl)}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package cnotify_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cnotify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTP(t *testing.T) {
+	t.Parallel()
+
+	var (
+		sms = testSMSProvider{sent: make(map[string]string)}
+		otp = cnotify.NewOTP(cnotify.NewOTPParams{
+			Notifier: cnotify.NewNotifier(cnotify.NewNotifierParams{
+				SMS:    cnotify.NewSMSChannel(&sms),
+				Logger: clogger.NewNoop(),
+			}),
+			Store: cnotify.NewMemoryOTPStore(),
+			Config: cnotify.Config{OTP: cnotify.ConfigOTP{
+				Length:      6,
+				TTL:         10 * time.Minute,
+				MaxAttempts: 2,
+				MaxPerHour:  2,
+			}},
+		})
+		codeRegexp = regexp.MustCompile(`[0-9]{6}`)
+		phone      = "+15550100"
+	)
+
+	ctx := context.Background()
+
+	assert.Error(t, otp.Issue(ctx, "not-a-phone"))
+
+	// a code can only be used once
+	assert.NoError(t, otp.Issue(ctx, phone))
+
+	code := codeRegexp.FindString(sms.sent[phone])
+	assert.NotEmpty(t, code)
+
+	assert.ErrorIs(t, otp.Verify(ctx, "+15550101", code), cnotify.ErrOTPInvalid)
+	assert.NoError(t, otp.Verify(ctx, phone, code))
+	assert.ErrorIs(t, otp.Verify(ctx, phone, code), cnotify.ErrOTPInvalid)
+
+	// a code is invalidated after too many wrong attempts
+	assert.NoError(t, otp.Issue(ctx, phone))
+
+	code = codeRegexp.FindString(sms.sent[phone])
+
+	assert.ErrorIs(t, otp.Verify(ctx, phone, "000000x"), cnotify.ErrOTPInvalid)
+	assert.ErrorIs(t, otp.Verify(ctx, phone, "000000y"), cnotify.ErrOTPInvalid)
+	assert.ErrorIs(t, otp.Verify(ctx, phone, code), cnotify.ErrOTPInvalid)
+
+	// issuing codes is rate limited
+	assert.ErrorIs(t, otp.Issue(ctx, phone), cnotify.ErrOTPRateLimited)
+}
@@ -0,0 +1,129 @@
+package cnotify
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+)
+
+// ErrUnregistered is returned by push providers when a device token is no longer valid (ex. the app was uninstalled).
+// The token should be removed from the recipient's devices.
+var ErrUnregistered = errors.New("device token is not registered")
+
+// PushProvider delivers push notifications to devices
+type PushProvider interface {
+	Push(ctx context.Context, deviceToken string, n Notification) error
+}
+
+// NewPushProviderParams holds the params needed to create a PushProvider
+type NewPushProviderParams struct {
+	Config Config
+	Logger clogger.Logger
+}
+
+// NewPushProvider creates the PushProvider set in the config
+func NewPushProvider(p NewPushProviderParams) (PushProvider, error) {
+	switch p.Config.PushProvider {
+	case PushProviderLog, "":
+		return NewLogPushProvider(p.Logger), nil
+	case PushProviderFCM:
+		return NewFCMProvider(p.Config.FCM)
+	case PushProviderAPNs:
+		return NewAPNsProvider(p.Config.APNs)
+	default:
+		return nil, cerrors.New(nil, "unknown push provider", map[string]interface{}{
+			"provider": p.Config.PushProvider,
+		})
+	}
+}
+
+// NewPushChannelParams holds the params needed for NewPushChannel
+type NewPushChannelParams struct {
+	Provider PushProvider
+	Logger   clogger.Logger
+}
+
+// NewPushChannel creates a PushChannel
+func NewPushChannel(p NewPushChannelParams) *PushChannel {
+	return &PushChannel{
+		provider: p.Provider,
+		logger:   p.Logger.With(clogger.Module("cnotify")),
+	}
+}
+
+// PushChannel sends notifications to each of the recipient's devices
+type PushChannel struct {
+	provider       PushProvider
+	logger         clogger.Logger
+	onUnregistered func(ctx context.Context, deviceToken string)
+}
+
+// OnUnregistered sets a func that is called with the device tokens that are no longer registered so that the app can
+// remove them. It must be called before notifications are sent.
+func (c *PushChannel) OnUnregistered(fn func(ctx context.Context, deviceToken string)) {
+	c.onUnregistered = fn
+}
+
+// Reaches returns true if the recipient has at least one device
+func (c *PushChannel) Reaches(to Recipient) bool {
+	return len(to.DeviceTokens) > 0
+}
+
+// Send sends the notification to each of the recipient's devices. Unregistered devices are skipped. An error is
+// returned if the notification could not be sent to any of the devices.
+func (c *PushChannel) Send(ctx context.Context, to Recipient, n Notification) error {
+	var (
+		sent    int
+		lastErr error
+	)
+
+	for _, token := range to.DeviceTokens {
+		err := c.provider.Push(ctx, token, n)
+
+		switch {
+		case err == nil:
+			sent++
+		case errors.Is(err, ErrUnregistered):
+			lastErr = err
+
+			if c.onUnregistered != nil {
+				c.onUnregistered(ctx, token)
+			}
+		default:
+			lastErr = err
+
+			c.logger.Warn("Failed to send push notification to device", err)
+		}
+	}
+
+	if sent == 0 {
+		return cerrors.New(lastErr, "failed to send push notification to any device", map[string]interface{}{
+			"devices": len(to.DeviceTokens),
+		})
+	}
+
+	return nil
+}
+
+// NewLogPushProvider creates a LogPushProvider
+func NewLogPushProvider(logger clogger.Logger) *LogPushProvider {
+	return &LogPushProvider{logger: logger.With(clogger.Module("cnotify"))}
+}
+
+// LogPushProvider logs push notifications instead of sending them. It is meant for development.
+type LogPushProvider struct {
+	logger clogger.Logger
+}
+
+// Push logs the push notification
+func (p *LogPushProvider) Push(ctx context.Context, deviceToken string, n Notification) error {
+	p.logger.WithTags(map[string]interface{}{
+		"deviceToken": deviceToken,
+		"title":       n.Subject,
+		"body":        n.Body,
+	}).Info("Push notification was not sent; logged instead")
+
+	return nil
+}
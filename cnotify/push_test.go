@@ -0,0 +1,178 @@
+package cnotify_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cnotify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFCMProvider_Push(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tokenRequests int
+		messages      []map[string]interface{}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokenRequests++
+
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.PostForm.Get("grant_type"))
+			assert.Len(t, strings.Split(r.PostForm.Get("assertion"), "."), 3)
+
+			_, _ = w.Write([]byte(`{"access_token":"access-token","expires_in":3600}`))
+		case "/v1/projects/test-project/messages:send":
+			assert.Equal(t, "Bearer access-token", r.Header.Get("Authorization"))
+
+			var body struct {
+				Message map[string]interface{} `json:"message"`
+			}
+
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+			if body.Message["token"] == "stale-token" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":{"status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`))
+
+				return
+			}
+
+			messages = append(messages, body.Message)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+
+	creds, err := json.Marshal(map[string]string{
+		"project_id":   "test-project",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email": "push@test-project.iam.gserviceaccount.com",
+		"token_uri":    server.URL + "/token",
+	})
+	assert.NoError(t, err)
+
+	credsPath := filepath.Join(t.TempDir(), "creds.json")
+	assert.NoError(t, os.WriteFile(credsPath, creds, 0o600))
+
+	provider, err := cnotify.NewFCMProvider(cnotify.ConfigFCM{CredentialsFile: credsPath, BaseURL: server.URL})
+	assert.NoError(t, err)
+
+	var unregistered []string
+
+	channel := cnotify.NewPushChannel(cnotify.NewPushChannelParams{Provider: provider, Logger: clogger.NewNoop()})
+	channel.OnUnregistered(func(ctx context.Context, deviceToken string) {
+		unregistered = append(unregistered, deviceToken)
+	})
+
+	err = channel.Send(context.Background(), cnotify.Recipient{DeviceTokens: []string{"stale-token", "device-token"}},
+		cnotify.Notification{Subject: "New message", Body: "Hi!", Data: map[string]string{"chatID": "1"}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, tokenRequests)
+	assert.Equal(t, []string{"stale-token"}, unregistered)
+	assert.Equal(t, []map[string]interface{}{{
+		"token":        "device-token",
+		"notification": map[string]interface{}{"title": "New message", "body": "Hi!"},
+		"data":         map[string]interface{}{"chatID": "1"},
+	}}, messages)
+
+	err = channel.Send(context.Background(), cnotify.Recipient{DeviceTokens: []string{"stale-token"}},
+		cnotify.Notification{Subject: "New message", Body: "Hi!"})
+	assert.ErrorIs(t, err, cnotify.ErrUnregistered)
+}
+
+func TestAPNsProvider_Push(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	var payload map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/3/device/device-token", r.URL.Path)
+		assert.Equal(t, "com.example.app", r.Header.Get("Apns-Topic"))
+		assert.True(t, verifyES256(t, &key.PublicKey, strings.TrimPrefix(r.Header.Get("Authorization"), "bearer ")))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer server.Close()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+
+	keyPath := filepath.Join(t.TempDir(), "AuthKey.p8")
+	assert.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	provider, err := cnotify.NewAPNsProvider(cnotify.ConfigAPNs{
+		KeyFile: keyPath,
+		KeyID:   "KEY123",
+		TeamID:  "TEAM123",
+		Topic:   "com.example.app",
+		BaseURL: server.URL,
+	})
+	assert.NoError(t, err)
+
+	err = provider.Push(context.Background(), "device-token", cnotify.Notification{
+		Subject: "New message",
+		Body:    "Hi!",
+		Data:    map[string]string{"chatID": "1"},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]interface{}{"title": "New message", "body": "Hi!"},
+			"sound": "default",
+		},
+		"chatID": "1",
+	}, payload)
+}
+
+func verifyES256(t *testing.T, key *ecdsa.PublicKey, token string) bool {
+	t.Helper()
+
+	parts := strings.Split(token, ".")
+	if !assert.Len(t, parts, 3) {
+		return false
+	}
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"alg":"ES256","kid":"KEY123"}`, string(header))
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	assert.NoError(t, err)
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	return len(sig) == 64 &&
+		ecdsa.Verify(key, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:]))
+}
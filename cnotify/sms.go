@@ -0,0 +1,90 @@
+package cnotify
+
+import (
+	"context"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+)
+
+// SMSProvider delivers text messages to phone numbers
+type SMSProvider interface {
+	SendSMS(ctx context.Context, to, body string) error
+}
+
+// NewSMSProviderParams holds the params needed to create an SMSProvider
+type NewSMSProviderParams struct {
+	Config Config
+	Logger clogger.Logger
+}
+
+// NewSMSProvider creates the SMSProvider set in the config
+func NewSMSProvider(p NewSMSProviderParams) (SMSProvider, error) {
+	switch p.Config.SMSProvider {
+	case SMSProviderLog, "":
+		return NewLogSMSProvider(p.Logger), nil
+	case SMSProviderTwilio:
+		return NewTwilioProvider(p.Config.Twilio), nil
+	case SMSProviderSNS:
+		return NewSNSProvider(p.Config.SNS), nil
+	default:
+		return nil, cerrors.New(nil, "unknown sms provider", map[string]interface{}{
+			"provider": p.Config.SMSProvider,
+		})
+	}
+}
+
+// NewSMSChannel creates an SMSChannel
+func NewSMSChannel(provider SMSProvider) *SMSChannel {
+	return &SMSChannel{provider: provider}
+}
+
+// SMSChannel sends notifications as text messages. Only the notification's body is sent.
+type SMSChannel struct {
+	provider SMSProvider
+}
+
+// Reaches returns true if the recipient has a phone number
+func (c *SMSChannel) Reaches(to Recipient) bool {
+	return to.Phone != ""
+}
+
+// Send sends the notification's body as a text message
+func (c *SMSChannel) Send(ctx context.Context, to Recipient, n Notification) error {
+	if !IsPhone(to.Phone) {
+		return cerrors.New(nil, "phone number must be in the E.164 format", map[string]interface{}{
+			"phone": to.Phone,
+		})
+	}
+
+	if n.Body == "" {
+		return cerrors.New(nil, "sms has no body", nil)
+	}
+
+	err := c.provider.SendSMS(ctx, to.Phone, n.Body)
+	if err != nil {
+		return cerrors.New(err, "failed to send sms", nil)
+	}
+
+	return nil
+}
+
+// NewLogSMSProvider creates a LogSMSProvider
+func NewLogSMSProvider(logger clogger.Logger) *LogSMSProvider {
+	return &LogSMSProvider{logger: logger.With(clogger.Module("cnotify"))}
+}
+
+// LogSMSProvider logs text messages instead of sending them. It is meant for development.
+type LogSMSProvider struct {
+	logger clogger.Logger
+}
+
+// SendSMS logs the text message
+func (p *LogSMSProvider) SendSMS(ctx context.Context, to, body string) error {
+	p.logger.WithTags(map[string]interface{}{
+		"to":   to,
+		"body": body,
+	}).Info("SMS was not sent; logged instead")
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package cnotify_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gocopper/copper/cnotify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwilioProvider_SendSMS(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", r.URL.Path)
+		assert.Equal(t, "AC123", user)
+		assert.Equal(t, "token", pass)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "+15550100", r.PostForm.Get("To"))
+		assert.Equal(t, "+15550199", r.PostForm.Get("From"))
+		assert.Equal(t, "Your code is 123456", r.PostForm.Get("Body"))
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	provider := cnotify.NewTwilioProvider(cnotify.ConfigTwilio{
+		AccountSID: "AC123",
+		AuthToken:  "token",
+		From:       "+15550199",
+		BaseURL:    server.URL,
+	})
+
+	assert.NoError(t, provider.SendSMS(context.Background(), "+15550100", "Your code is 123456"))
+}
+
+func TestSNSProvider_SendSMS(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/us-east-1/sns/aws4_request")
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "Publish", r.PostForm.Get("Action"))
+		assert.Equal(t, "+15550100", r.PostForm.Get("PhoneNumber"))
+		assert.Equal(t, "AWS.SNS.SMS.SMSType", r.PostForm.Get("MessageAttributes.entry.1.Name"))
+		assert.Equal(t, "Transactional", r.PostForm.Get("MessageAttributes.entry.1.Value.StringValue"))
+	}))
+	defer server.Close()
+
+	provider := cnotify.NewSNSProvider(cnotify.ConfigSNS{
+		Region:          "us-east-1",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		Transactional:   true,
+		Endpoint:        server.URL,
+	})
+
+	assert.NoError(t, provider.SendSMS(context.Background(), "+15550100", "Your code is 123456"))
+}
+
+func TestSMSChannel_Send_InvalidPhone(t *testing.T) {
+	t.Parallel()
+
+	channel := cnotify.NewSMSChannel(&testSMSProvider{sent: make(map[string]string)})
+
+	err := channel.Send(context.Background(), cnotify.Recipient{Phone: "555-0100"}, cnotify.Notification{Body: "Hi"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,97 @@
+package cnotify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/internal/awssig"
+)
+
+// NewSNSProvider creates an SNSProvider. Credentials that are not set in the config are read from the standard AWS
+// environment variables (AWS_REGION, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN).
+func NewSNSProvider(config ConfigSNS) *SNSProvider {
+	if config.Region == "" {
+		config.Region = awssig.RegionFromEnv()
+	}
+
+	if config.AccessKeyID == "" {
+		config.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		config.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		config.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+
+	return &SNSProvider{
+		config: config,
+		client: &http.Client{Timeout: defaultRequestTimeout},
+	}
+}
+
+// SNSProvider sends text messages directly to phone numbers using the AWS SNS Publish API
+type SNSProvider struct {
+	config ConfigSNS
+	client *http.Client
+}
+
+// SendSMS sends the text message
+func (p *SNSProvider) SendSMS(ctx context.Context, to, body string) error {
+	if p.config.Region == "" || p.config.AccessKeyID == "" || p.config.SecretAccessKey == "" {
+		return cerrors.New(nil, "sns region and credentials must be set", nil)
+	}
+
+	form := url.Values{}
+	form.Set("Action", "Publish")
+	form.Set("Version", "2010-03-31")
+	form.Set("PhoneNumber", to)
+	form.Set("Message", body)
+
+	var attrs []struct{ name, val string }
+
+	if p.config.SenderID != "" {
+		attrs = append(attrs, struct{ name, val string }{"AWS.SNS.SMS.SenderID", p.config.SenderID})
+	}
+
+	if p.config.Transactional {
+		attrs = append(attrs, struct{ name, val string }{"AWS.SNS.SMS.SMSType", "Transactional"})
+	}
+
+	for i, attr := range attrs {
+		prefix := "MessageAttributes.entry." + strconv.Itoa(i+1)
+
+		form.Set(prefix+".Name", attr.name)
+		form.Set(prefix+".Value.DataType", "String")
+		form.Set(prefix+".Value.StringValue", attr.val)
+	}
+
+	endpoint := p.config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://sns.%s.amazonaws.com", p.config.Region)
+	}
+
+	reqBody := []byte(form.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/",
+		bytes.NewReader(reqBody))
+	if err != nil {
+		return cerrors.New(err, "failed to create sns request", nil)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	awssig.Sign(req, reqBody, awssig.Credentials{
+		AccessKeyID:     p.config.AccessKeyID,
+		SecretAccessKey: p.config.SecretAccessKey,
+		SessionToken:    p.config.SessionToken,
+	}, p.config.Region, "sns", time.Now())
+
+	_, err = doRequest(p.client, req)
+
+	return err
+}
@@ -0,0 +1,62 @@
+package cnotify
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+const twilioBaseURL = "https://api.twilio.com"
+
+// NewTwilioProvider creates a TwilioProvider
+func NewTwilioProvider(config ConfigTwilio) *TwilioProvider {
+	if config.BaseURL == "" {
+		config.BaseURL = twilioBaseURL
+	}
+
+	return &TwilioProvider{
+		config: config,
+		client: &http.Client{Timeout: defaultRequestTimeout},
+	}
+}
+
+// TwilioProvider sends text messages using the Twilio messages API
+type TwilioProvider struct {
+	config ConfigTwilio
+	client *http.Client
+}
+
+// SendSMS sends the text message
+func (p *TwilioProvider) SendSMS(ctx context.Context, to, body string) error {
+	if p.config.AccountSID == "" || (p.config.From == "" && p.config.MessagingServiceSID == "") {
+		return cerrors.New(nil, "twilio account sid and sender must be set", nil)
+	}
+
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("Body", body)
+
+	if p.config.MessagingServiceSID != "" {
+		form.Set("MessagingServiceSid", p.config.MessagingServiceSID)
+	} else {
+		form.Set("From", p.config.From)
+	}
+
+	endpoint := strings.TrimSuffix(p.config.BaseURL, "/") + "/2010-04-01/Accounts/" +
+		url.PathEscape(p.config.AccountSID) + "/Messages.json"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return cerrors.New(err, "failed to create twilio request", nil)
+	}
+
+	req.SetBasicAuth(p.config.AccountSID, p.config.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	_, err = doRequest(p.client, req)
+
+	return err
+}
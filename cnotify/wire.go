@@ -0,0 +1,24 @@
+package cnotify
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+	NewSMSProvider,
+	wire.Struct(new(NewSMSProviderParams), "*"),
+	NewPushProvider,
+	wire.Struct(new(NewPushProviderParams), "*"),
+
+	NewEmailChannel,
+	NewSMSChannel,
+	NewPushChannel,
+	wire.Struct(new(NewPushChannelParams), "*"),
+
+	NewNotifier,
+	wire.Struct(new(NewNotifierParams), "*"),
+
+	NewOTP,
+	NewMemoryOTPStore,
+	wire.Struct(new(NewOTPParams), "*"),
+)
@@ -0,0 +1,65 @@
+package copper
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+const defaultStartupTimeout = time.Minute
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "copper",
+		Description: "copper configures how the app starts",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("copper", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load copper config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Startup: ConfigStartup{
+			Timeout: defaultStartupTimeout,
+		},
+	}
+}
+
+// Config configures the app
+type Config struct {
+	Startup ConfigStartup `toml:"startup"`
+}
+
+// ConfigStartup configures how the app's runners are started (see App.StartRunners). The stages declared by the
+// runners can be tuned by name. For example:
+//
+//	[copper.startup]
+//	timeout = "1m"
+//
+//	[copper.startup.stages.http]
+//	retries = 5
+//	backoff = "2s"
+type ConfigStartup struct {
+	Timeout time.Duration                 `toml:"timeout" doc:"Time each stage has to start unless the stage sets its own"`
+	Stages  map[string]ConfigStartupStage `toml:"stages" doc:"Overrides of the stages' timeouts and retries by stage name"`
+}
+
+// ConfigStartupStage overrides the timeout and retries of a clifecycle.Stage. Fields that are not set keep the values
+// declared by the stage.
+type ConfigStartupStage struct {
+	Timeout time.Duration `toml:"timeout" doc:"Time the stage's runners have to start, including retries"`
+	Retries *int          `toml:"retries" doc:"Number of times a runner that fails to start is retried"`
+	Backoff time.Duration `toml:"backoff" doc:"Wait before the first retry, which doubles after each attempt"`
+}
@@ -0,0 +1,65 @@
+package copenapi
+
+import (
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	defaultSpec         = "openapi.yaml"
+	defaultMaxBodyBytes = 1 << 20
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "copenapi",
+		Description: "copenapi configures the validation of requests and responses against the OpenAPI spec",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("copenapi", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load copenapi config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Spec:         defaultSpec,
+		MaxBodyBytes: defaultMaxBodyBytes,
+	}
+}
+
+// Config configures Middleware. For example:
+//
+//	[copenapi]
+//	enabled = true
+//	spec = "api/openapi.yaml"
+//	base_path = "/api"
+//	validate_responses = true
+type Config struct {
+	Enabled bool `toml:"enabled" doc:"Validate requests against the OpenAPI spec"`
+
+	// Spec is the path of the OpenAPI 3 spec in YAML or JSON
+	Spec string `toml:"spec" doc:"Path of the OpenAPI spec"`
+
+	// BasePath is the prefix of the request paths that is not part of the paths in the spec (ex. /api)
+	BasePath string `toml:"base_path" doc:"Prefix of the request paths that is not part of the spec's paths"`
+
+	// ReportOnly logs invalid requests instead of rejecting them, which can be used to roll out validation
+	ReportOnly bool `toml:"report_only" doc:"Log invalid requests instead of rejecting them"`
+
+	// ValidateResponses logs the responses that do not match the spec. Responses are sent as-is.
+	ValidateResponses bool `toml:"validate_responses" doc:"Log the responses that do not match the spec"`
+
+	// MaxBodyBytes is the max size of the request and response bodies that are validated. Larger bodies are not
+	// validated.
+	MaxBodyBytes int `toml:"max_body_bytes" doc:"Max size of the bodies that are validated"`
+}
@@ -0,0 +1,4 @@
+// Package copenapi validates requests, and optionally responses, against the app's OpenAPI 3 spec at runtime so that
+// spec-first APIs cannot drift from their contract. Middleware rejects requests with invalid parameters or bodies
+// with a structured 400 and logs the responses that violate the spec.
+package copenapi
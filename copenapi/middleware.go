@@ -0,0 +1,330 @@
+package copenapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+)
+
+// NewMiddlewareParams holds the params needed for NewMiddleware
+type NewMiddlewareParams struct {
+	Spec   *Spec
+	RW     *chttp.ReaderWriter
+	Config Config
+	Logger clogger.Logger
+}
+
+// NewMiddleware creates a new Middleware
+func NewMiddleware(p NewMiddlewareParams) *Middleware {
+	return &Middleware{
+		spec:   p.Spec,
+		rw:     p.RW,
+		config: p.Config,
+		logger: p.Logger.With(clogger.Module("copenapi")),
+	}
+}
+
+// Middleware validates the requests to the operations in the spec before they are handled. The path, query, header,
+// and cookie parameters are validated against their schemas, and so are JSON bodies. Requests that do not match
+// get a 400 with the list of violations:
+//
+//	{
+//		"error": "request does not match the api spec",
+//		"violations": [{"in": "body", "field": "items[0].quantity", "message": "must be at least 1"}]
+//	}
+//
+// If Config.ValidateResponses is set, the responses are validated as well and the ones that do not match are
+// logged. Requests to paths or methods that are not in the spec are passed through. If there is no spec (see
+// LoadSpec), all requests are passed through.
+type Middleware struct {
+	spec   *Spec
+	rw     *chttp.ReaderWriter
+	config Config
+	logger clogger.Logger
+}
+
+// Handle validates the request and, if enabled, its response
+func (mw *Middleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if mw.spec == nil || !strings.HasPrefix(r.URL.Path, mw.config.BasePath) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rt, pathParams, ok := mw.spec.match(strings.TrimPrefix(r.URL.Path, mw.config.BasePath))
+		if !ok || rt.item.operation(r.Method) == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		op := rt.item.operation(r.Method)
+
+		violations := mw.validateRequest(r, rt.item, op, pathParams)
+		if len(violations) > 0 {
+			mw.log(r, "Request does not match the api spec", rt.path, op, violations)
+
+			if !mw.config.ReportOnly {
+				mw.rw.WriteJSON(w, chttp.WriteJSONParams{
+					StatusCode: http.StatusBadRequest,
+					Data: map[string]interface{}{
+						"error":      "request does not match the api spec",
+						"violations": violations,
+					},
+				})
+
+				return
+			}
+		}
+
+		if !mw.config.ValidateResponses {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK, max: mw.config.MaxBodyBytes}
+
+		next.ServeHTTP(rec, r)
+
+		violations = mw.validateResponse(op, rec)
+		if len(violations) > 0 {
+			mw.log(r, "Response does not match the api spec", rt.path, op, violations)
+		}
+	})
+}
+
+func (mw *Middleware) validateRequest(r *http.Request, item *pathItem, op *operation,
+	pathParams map[string]string) []Violation {
+	var (
+		v     = validator{spec: mw.spec}
+		query = r.URL.Query()
+	)
+
+	for _, p := range mw.spec.parameters(item, op) {
+		var values []string
+
+		switch p.In {
+		case "path":
+			values = []string{pathParams[p.Name]}
+		case "query":
+			values = query[p.Name]
+		case "header":
+			values = r.Header.Values(p.Name)
+		case "cookie":
+			if c, err := r.Cookie(p.Name); err == nil {
+				values = []string{c.Value}
+			}
+		}
+
+		v.in = p.In
+
+		if len(values) == 0 {
+			if p.Required {
+				v.fail(p.Name, "is required")
+			}
+
+			continue
+		}
+
+		v.validate(p.Name, p.Schema, coerceParam(mw.spec, p.Schema, values))
+	}
+
+	body := mw.spec.resolveRequestBody(op.RequestBody)
+	if body == nil {
+		return v.violations
+	}
+
+	v.in = "body"
+
+	data, complete := mw.readBody(r)
+
+	if len(data) == 0 {
+		if body.Required {
+			v.fail("", "is required")
+		}
+
+		return v.violations
+	}
+
+	mt, ok := mediaTypeFor(body.Content, r.Header.Get("Content-Type"))
+	if !ok {
+		v.fail("", "content type %q is not allowed", r.Header.Get("Content-Type"))
+		return v.violations
+	}
+
+	if complete && mt != nil && isJSON(r.Header.Get("Content-Type")) {
+		validateJSON(&v, mt.Schema, data)
+	}
+
+	return v.violations
+}
+
+func (mw *Middleware) validateResponse(op *operation, rec *responseRecorder) []Violation {
+	var (
+		v      = validator{spec: mw.spec, in: "response"}
+		status = strconv.Itoa(rec.statusCode)
+	)
+
+	resp, ok := op.Responses[status]
+	if !ok {
+		resp, ok = op.Responses[status[:1]+"XX"]
+	}
+
+	if !ok {
+		resp, ok = op.Responses["default"]
+	}
+
+	if !ok {
+		v.fail("", "status %d is not in the spec", rec.statusCode)
+		return v.violations
+	}
+
+	resp = mw.spec.resolveResponse(resp)
+	if resp == nil || len(resp.Content) == 0 || rec.body.Len() == 0 {
+		return v.violations
+	}
+
+	contentType := rec.Header().Get("Content-Type")
+
+	mt, ok := mediaTypeFor(resp.Content, contentType)
+	if !ok {
+		v.fail("", "content type %q is not in the spec", contentType)
+		return v.violations
+	}
+
+	if !rec.truncated && mt != nil && isJSON(contentType) {
+		validateJSON(&v, mt.Schema, rec.body.Bytes())
+	}
+
+	return v.violations
+}
+
+// readBody reads the request body, up to Config.MaxBodyBytes, and replaces it so that it can be read again by the
+// handler. It returns false if the body is larger.
+func (mw *Middleware) readBody(r *http.Request) ([]byte, bool) {
+	if r.Body == nil {
+		return nil, true
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, int64(mw.config.MaxBodyBytes)+1))
+
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+
+	return data, err == nil && len(data) <= mw.config.MaxBodyBytes
+}
+
+func (mw *Middleware) log(r *http.Request, msg, path string, op *operation, violations []Violation) {
+	list := make([]string, len(violations))
+	for i := range violations {
+		list[i] = violations[i].String()
+	}
+
+	mw.logger.
+		WithFields(clogger.FieldsFromCtx(r.Context())).
+		WithTags(map[string]interface{}{
+			"method":      r.Method,
+			"path":        path,
+			"operationId": op.OperationID,
+			"violations":  list,
+		}).
+		Warn(msg, nil)
+}
+
+func validateJSON(v *validator, sc *schema, data []byte) {
+	var val interface{}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	err := dec.Decode(&val)
+	if err != nil {
+		v.fail("", "must be valid json")
+		return
+	}
+
+	v.validate("", sc, val)
+}
+
+// mediaTypeFor returns the media type in content that matches contentType, including wildcards (ex. image/*)
+func mediaTypeFor(content map[string]*mediaType, contentType string) (*mediaType, bool) {
+	if len(content) == 0 {
+		return nil, true
+	}
+
+	parsed, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		parsed = contentType
+	}
+
+	if mt, ok := content[parsed]; ok {
+		return mt, true
+	}
+
+	if isJSON(parsed) {
+		if mt, ok := jsonMediaType(content); ok {
+			return mt, true
+		}
+	}
+
+	if i := strings.Index(parsed, "/"); i != -1 {
+		if mt, ok := content[parsed[:i]+"/*"]; ok {
+			return mt, true
+		}
+	}
+
+	mt, ok := content["*/*"]
+
+	return mt, ok
+}
+
+// responseRecorder passes the response through while recording its status and the first max bytes of its body
+type responseRecorder struct {
+	http.ResponseWriter
+
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+	max         int
+	truncated   bool
+}
+
+func (rec *responseRecorder) WriteHeader(statusCode int) {
+	if !rec.wroteHeader {
+		rec.statusCode = statusCode
+		rec.wroteHeader = true
+	}
+
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+
+	if room := rec.max - rec.body.Len(); room < len(b) {
+		rec.truncated = true
+
+		if room > 0 {
+			rec.body.Write(b[:room])
+		}
+	} else {
+		rec.body.Write(b)
+	}
+
+	return rec.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher if the underlying writer does
+func (rec *responseRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
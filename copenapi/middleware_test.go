@@ -0,0 +1,302 @@
+package copenapi_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gocopper/copper/chttp/chttptest"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/copenapi"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info: {title: Orders, version: "1"}
+paths:
+  /orders:
+    get:
+      operationId: listOrders
+      parameters:
+        - name: status
+          in: query
+          schema: {type: string, enum: [open, shipped]}
+        - name: limit
+          in: query
+          schema: {type: integer, minimum: 1, maximum: 100}
+        - name: ids
+          in: query
+          schema: {type: array, items: {type: integer}}
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: "#/components/schemas/Order"}
+    post:
+      operationId: createOrder
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: "#/components/schemas/NewOrder"}
+      responses:
+        "201": {$ref: "#/components/responses/Order"}
+        4XX:
+          description: Error
+  /orders/{id}:
+    parameters:
+      - $ref: "#/components/parameters/OrderID"
+    get:
+      operationId: getOrder
+      parameters:
+        - name: X-Tenant
+          in: header
+          required: true
+          schema: {type: string, format: uuid}
+      responses:
+        "200": {$ref: "#/components/responses/Order"}
+  /orders/latest:
+    get:
+      operationId: getLatestOrder
+      responses:
+        default: {description: OK}
+components:
+  parameters:
+    OrderID:
+      name: id
+      in: path
+      required: true
+      schema: {type: integer}
+  responses:
+    Order:
+      description: OK
+      content:
+        application/json:
+          schema: {$ref: "#/components/schemas/Order"}
+  schemas:
+    NewOrder:
+      type: object
+      required: [email, items]
+      additionalProperties: false
+      properties:
+        email: {type: string, format: email}
+        note: {type: string, maxLength: 10, nullable: true}
+        items: {$ref: "#/components/schemas/Items"}
+    Order:
+      type: object
+      required: [id, email, items]
+      properties:
+        id: {type: integer}
+        email: {type: string, format: email}
+        items: {$ref: "#/components/schemas/Items"}
+    Items:
+      type: array
+      minItems: 1
+      items:
+        type: object
+        required: [sku, quantity]
+        properties:
+          sku: {type: string, pattern: "^[A-Z]{3}-[0-9]+$"}
+          quantity: {type: integer, minimum: 1}
+`
+
+type testServer struct {
+	handler http.Handler
+	logs    *[]clogger.RecordedLog
+}
+
+func newTestServer(t *testing.T, config copenapi.Config, handler http.HandlerFunc) testServer {
+	t.Helper()
+
+	spec, err := copenapi.ParseSpec([]byte(testSpec))
+	assert.NoError(t, err)
+
+	var logs []clogger.RecordedLog
+
+	if config.MaxBodyBytes == 0 {
+		config.MaxBodyBytes = 1 << 20
+	}
+
+	mw := copenapi.NewMiddleware(copenapi.NewMiddlewareParams{
+		Spec:   spec,
+		RW:     chttptest.NewReaderWriter(t),
+		Config: config,
+		Logger: clogger.NewRecorder(&logs),
+	})
+
+	return testServer{handler: mw.Handle(handler), logs: &logs}
+}
+
+func (s testServer) do(t *testing.T, method, path string, header http.Header, body string) (int, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp := httptest.NewRecorder()
+
+	s.handler.ServeHTTP(resp, req)
+
+	return resp.Code, resp.Body.String()
+}
+
+func violations(t *testing.T, body string) []copenapi.Violation {
+	t.Helper()
+
+	var resp struct {
+		Violations []copenapi.Violation `json:"violations"`
+	}
+
+	assert.NoError(t, json.Unmarshal([]byte(body), &resp))
+
+	return resp.Violations
+}
+
+func echoBody(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	_, _ = io.Copy(w, r.Body)
+}
+
+func TestMiddleware_Params(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, copenapi.Config{}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	status, _ := server.do(t, http.MethodGet, "/orders?status=open&limit=10&ids=1,2&ids=3", nil, "")
+	assert.Equal(t, http.StatusOK, status)
+
+	status, body := server.do(t, http.MethodGet, "/orders?status=lost&limit=0&ids=1,x", nil, "")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.ElementsMatch(t, []copenapi.Violation{
+		{In: "query", Field: "status", Message: "must be one of open, shipped"},
+		{In: "query", Field: "limit", Message: "must be at least 1"},
+		{In: "query", Field: "ids[1]", Message: "must be of type integer"},
+	}, violations(t, body))
+
+	status, body = server.do(t, http.MethodGet, "/orders/abc", nil, "")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.ElementsMatch(t, []copenapi.Violation{
+		{In: "header", Field: "X-Tenant", Message: "is required"},
+		{In: "path", Field: "id", Message: "must be of type integer"},
+	}, violations(t, body))
+
+	status, _ = server.do(t, http.MethodGet, "/orders/42", http.Header{
+		"X-Tenant": {"0b5a3d7c-7b1e-4c3e-9f59-3c1d1f0a2b4e"},
+	}, "")
+	assert.Equal(t, http.StatusOK, status)
+
+	// literal paths take precedence over path params, and paths that are not in the spec are passed through
+	status, _ = server.do(t, http.MethodGet, "/orders/latest", nil, "")
+	assert.Equal(t, http.StatusOK, status)
+
+	status, _ = server.do(t, http.MethodGet, "/customers", nil, "")
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestMiddleware_Body(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, copenapi.Config{}, echoBody)
+
+	valid := `{"email": "a@example.com", "note": null, "items": [{"sku": "ABC-1", "quantity": 2}]}`
+
+	status, body := server.do(t, http.MethodPost, "/orders", nil, valid)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.JSONEq(t, valid, body)
+
+	status, body = server.do(t, http.MethodPost, "/orders", nil,
+		`{"email": "nope", "note": "way too long", "items": [{"sku": "abc", "quantity": 0}, {}], "extra": 1}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.ElementsMatch(t, []copenapi.Violation{
+		{In: "body", Field: "email", Message: "must be a valid email"},
+		{In: "body", Field: "note", Message: "must be at most 10 characters long"},
+		{In: "body", Field: "items[0].sku", Message: "must match the pattern ^[A-Z]{3}-[0-9]+$"},
+		{In: "body", Field: "items[0].quantity", Message: "must be at least 1"},
+		{In: "body", Field: "items[1].sku", Message: "is required"},
+		{In: "body", Field: "items[1].quantity", Message: "is required"},
+		{In: "body", Field: "extra", Message: "is not allowed"},
+	}, violations(t, body))
+
+	status, body = server.do(t, http.MethodPost, "/orders", nil, `{"email":`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, []copenapi.Violation{{In: "body", Message: "must be valid json"}}, violations(t, body))
+
+	status, body = server.do(t, http.MethodPost, "/orders", nil, "")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, []copenapi.Violation{{In: "body", Message: "is required"}}, violations(t, body))
+
+	assert.Len(t, *server.logs, 3)
+	assert.Equal(t, "createOrder", (*server.logs)[0].Tags["operationId"])
+}
+
+func TestMiddleware_ReportOnly(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, copenapi.Config{ReportOnly: true}, echoBody)
+
+	status, _ := server.do(t, http.MethodPost, "/orders", nil, `{"email": "a@example.com"}`)
+	assert.Equal(t, http.StatusCreated, status)
+
+	assert.Len(t, *server.logs, 1)
+	assert.Equal(t, []string{"body items: is required"}, (*server.logs)[0].Tags["violations"])
+}
+
+func TestMiddleware_ValidateResponses(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, copenapi.Config{ValidateResponses: true}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Query().Get("status") {
+		case "open":
+			_, _ = w.Write([]byte(`[{"id": 1, "email": "a@example.com", "items": [{"sku": "ABC-1", "quantity": 1}]}]`))
+		case "shipped":
+			_, _ = w.Write([]byte(`[{"email": "a@example.com", "items": []}]`))
+		default:
+			w.WriteHeader(http.StatusTeapot)
+		}
+	})
+
+	status, _ := server.do(t, http.MethodGet, "/orders?status=open", nil, "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, *server.logs, 0)
+
+	status, _ = server.do(t, http.MethodGet, "/orders?status=shipped", nil, "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, *server.logs, 1)
+	assert.Equal(t, "Response does not match the api spec", (*server.logs)[0].Msg)
+	assert.ElementsMatch(t, []string{
+		"response [0].items: must have at least 1 items",
+		"response [0].id: is required",
+	}, (*server.logs)[0].Tags["violations"])
+
+	status, _ = server.do(t, http.MethodGet, "/orders", nil, "")
+	assert.Equal(t, http.StatusTeapot, status)
+	assert.Len(t, *server.logs, 2)
+	assert.Equal(t, []string{"response: status 418 is not in the spec"}, (*server.logs)[1].Tags["violations"])
+}
+
+func TestParseSpec_Version(t *testing.T) {
+	t.Parallel()
+
+	_, err := copenapi.ParseSpec([]byte(`swagger: "2.0"`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,392 @@
+package copenapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxRefDepth limits how many references are followed to resolve a component so that cyclic references end
+const maxRefDepth = 32
+
+var uuidRegexp = regexp.MustCompile( //nolint:gochecknoglobals
+	`^[[:xdigit:]]{8}-[[:xdigit:]]{4}-[[:xdigit:]]{4}-[[:xdigit:]]{4}-[[:xdigit:]]{12}$`)
+
+// Violation describes how a request or response does not match the spec
+type Violation struct {
+	// In is where the invalid value is: path, query, header, or body
+	In string `json:"in"`
+
+	// Field is the name of the parameter or the location of the value in the body (ex. items[0].name). It is empty
+	// if the whole body is invalid.
+	Field string `json:"field,omitempty"`
+
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.Field == "" {
+		return v.In + ": " + v.Message
+	}
+
+	return v.In + " " + v.Field + ": " + v.Message
+}
+
+// schema is the subset of the OpenAPI schema object that is validated
+type schema struct {
+	Ref                  string             `yaml:"$ref"`
+	Type                 schemaType         `yaml:"type"`
+	Format               string             `yaml:"format"`
+	Enum                 []interface{}      `yaml:"enum"`
+	Nullable             bool               `yaml:"nullable"`
+	Required             []string           `yaml:"required"`
+	Properties           map[string]*schema `yaml:"properties"`
+	AdditionalProperties *schemaOrBool      `yaml:"additionalProperties"`
+	Items                *schema            `yaml:"items"`
+	Minimum              *float64           `yaml:"minimum"`
+	Maximum              *float64           `yaml:"maximum"`
+	MinLength            *int               `yaml:"minLength"`
+	MaxLength            *int               `yaml:"maxLength"`
+	Pattern              string             `yaml:"pattern"`
+	MinItems             *int               `yaml:"minItems"`
+	MaxItems             *int               `yaml:"maxItems"`
+	AllOf                []*schema          `yaml:"allOf"`
+	AnyOf                []*schema          `yaml:"anyOf"`
+	OneOf                []*schema          `yaml:"oneOf"`
+}
+
+// schemaType is the type of a schema, which is a list of types in OpenAPI 3.1 (ex. [string, "null"])
+type schemaType []string
+
+func (t *schemaType) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = schemaType{node.Value}
+		return nil
+	}
+
+	var types []string
+
+	err := node.Decode(&types)
+	if err != nil {
+		return err
+	}
+
+	*t = types
+
+	return nil
+}
+
+func (t schemaType) has(name string) bool {
+	for _, typ := range t {
+		if typ == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// schemaOrBool is the value of additionalProperties, which is either a boolean or a schema
+type schemaOrBool struct {
+	allowed bool
+	schema  *schema
+}
+
+func (s *schemaOrBool) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&s.allowed)
+	}
+
+	s.allowed = true
+
+	return node.Decode(&s.schema)
+}
+
+// validator validates values decoded from JSON (with json.Number for numbers) against schemas
+type validator struct {
+	spec       *Spec
+	in         string
+	violations []Violation
+}
+
+func (v *validator) fail(field, format string, args ...interface{}) {
+	v.violations = append(v.violations, Violation{In: v.in, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// validate validates val against sc and returns true if it is valid
+func (v *validator) validate(field string, sc *schema, val interface{}) bool {
+	sc = v.spec.resolveSchema(sc)
+	if sc == nil {
+		return true
+	}
+
+	before := len(v.violations)
+
+	if val == nil {
+		if !sc.Nullable && !sc.Type.has("null") && len(sc.Type) > 0 {
+			v.fail(field, "must not be null")
+		}
+
+		return len(v.violations) == before
+	}
+
+	if len(sc.Type) > 0 && !v.validateType(field, sc, val) {
+		return false
+	}
+
+	if len(sc.Enum) > 0 && !inEnum(sc.Enum, val) {
+		v.fail(field, "must be one of %s", formatEnum(sc.Enum))
+	}
+
+	switch typed := val.(type) {
+	case string:
+		v.validateString(field, sc, typed)
+	case json.Number:
+		v.validateNumber(field, sc, typed)
+	case []interface{}:
+		v.validateArray(field, sc, typed)
+	case map[string]interface{}:
+		v.validateObject(field, sc, typed)
+	}
+
+	v.validateComposition(field, sc, val)
+
+	return len(v.violations) == before
+}
+
+func (v *validator) validateType(field string, sc *schema, val interface{}) bool {
+	for _, typ := range sc.Type {
+		if isType(typ, val) {
+			return true
+		}
+	}
+
+	v.fail(field, "must be of type %s", strings.Join(sc.Type, " or "))
+
+	return false
+}
+
+func (v *validator) validateString(field string, sc *schema, s string) {
+	length := len([]rune(s))
+
+	if sc.MinLength != nil && length < *sc.MinLength {
+		v.fail(field, "must be at least %d characters long", *sc.MinLength)
+	}
+
+	if sc.MaxLength != nil && length > *sc.MaxLength {
+		v.fail(field, "must be at most %d characters long", *sc.MaxLength)
+	}
+
+	if sc.Pattern != "" {
+		re, err := v.spec.pattern(sc.Pattern)
+		if err == nil && !re.MatchString(s) {
+			v.fail(field, "must match the pattern %s", sc.Pattern)
+		}
+	}
+
+	if !isFormat(sc.Format, s) {
+		v.fail(field, "must be a valid %s", sc.Format)
+	}
+}
+
+func (v *validator) validateNumber(field string, sc *schema, n json.Number) {
+	f, err := n.Float64()
+	if err != nil {
+		return
+	}
+
+	if sc.Minimum != nil && f < *sc.Minimum {
+		v.fail(field, "must be at least %v", *sc.Minimum)
+	}
+
+	if sc.Maximum != nil && f > *sc.Maximum {
+		v.fail(field, "must be at most %v", *sc.Maximum)
+	}
+}
+
+func (v *validator) validateArray(field string, sc *schema, items []interface{}) {
+	if sc.MinItems != nil && len(items) < *sc.MinItems {
+		v.fail(field, "must have at least %d items", *sc.MinItems)
+	}
+
+	if sc.MaxItems != nil && len(items) > *sc.MaxItems {
+		v.fail(field, "must have at most %d items", *sc.MaxItems)
+	}
+
+	if sc.Items == nil {
+		return
+	}
+
+	for i, item := range items {
+		v.validate(field+"["+strconv.Itoa(i)+"]", sc.Items, item)
+	}
+}
+
+func (v *validator) validateObject(field string, sc *schema, obj map[string]interface{}) {
+	for _, name := range sc.Required {
+		if _, ok := obj[name]; !ok {
+			v.fail(joinField(field, name), "is required")
+		}
+	}
+
+	for name, val := range obj {
+		if prop, ok := sc.Properties[name]; ok {
+			v.validate(joinField(field, name), prop, val)
+			continue
+		}
+
+		switch {
+		case sc.AdditionalProperties == nil:
+		case !sc.AdditionalProperties.allowed:
+			v.fail(joinField(field, name), "is not allowed")
+		case sc.AdditionalProperties.schema != nil:
+			v.validate(joinField(field, name), sc.AdditionalProperties.schema, val)
+		}
+	}
+}
+
+func (v *validator) validateComposition(field string, sc *schema, val interface{}) {
+	for _, sub := range sc.AllOf {
+		v.validate(field, sub, val)
+	}
+
+	if len(sc.AnyOf) > 0 && v.countValid(field, sc.AnyOf, val) == 0 {
+		v.fail(field, "must match at least one of the schemas in anyOf")
+	}
+
+	if len(sc.OneOf) > 0 && v.countValid(field, sc.OneOf, val) != 1 {
+		v.fail(field, "must match exactly one of the schemas in oneOf")
+	}
+}
+
+// countValid returns the number of schemas that val is valid against without recording their violations
+func (v *validator) countValid(field string, schemas []*schema, val interface{}) int {
+	var count int
+
+	for _, sub := range schemas {
+		sv := validator{spec: v.spec, in: v.in}
+		if sv.validate(field, sub, val) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func isType(typ string, val interface{}) bool {
+	switch typed := val.(type) {
+	case string:
+		return typ == "string"
+	case bool:
+		return typ == "boolean"
+	case json.Number:
+		if typ == "number" {
+			return true
+		}
+
+		f, err := typed.Float64()
+
+		return typ == "integer" && err == nil && f == math.Trunc(f)
+	case []interface{}:
+		return typ == "array"
+	case map[string]interface{}:
+		return typ == "object"
+	default:
+		return false
+	}
+}
+
+func isFormat(format, s string) bool {
+	var err error
+
+	switch format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, s)
+	case "date":
+		_, err = time.Parse("2006-01-02", s)
+	case "email":
+		_, err = mail.ParseAddress(s)
+	case "uri":
+		var u *url.URL
+
+		u, err = url.Parse(s)
+		if err == nil && !u.IsAbs() {
+			return false
+		}
+	case "uuid":
+		return uuidRegexp.MatchString(s)
+	}
+
+	return err == nil
+}
+
+func inEnum(enum []interface{}, val interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(val) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func formatEnum(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, e := range enum {
+		values[i] = fmt.Sprint(e)
+	}
+
+	return strings.Join(values, ", ")
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+
+	return parent + "." + name
+}
+
+// coerceParam converts the string values of a parameter into the values validated against its schema so that
+// numbers and booleans in the path, query, and headers are checked like the ones in JSON bodies. Arrays are read
+// from repeated values or comma-separated values.
+func coerceParam(spec *Spec, sc *schema, values []string) interface{} {
+	sc = spec.resolveSchema(sc)
+
+	if sc != nil && sc.Type.has("array") {
+		var items []interface{}
+
+		for _, val := range values {
+			for _, item := range strings.Split(val, ",") {
+				items = append(items, coerceParam(spec, sc.Items, []string{item}))
+			}
+		}
+
+		return items
+	}
+
+	val := values[0]
+
+	switch {
+	case sc == nil:
+		return val
+	case sc.Type.has("integer") || sc.Type.has("number"):
+		if _, err := strconv.ParseFloat(val, 64); err == nil {
+			return json.Number(val)
+		}
+	case sc.Type.has("boolean"):
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	}
+
+	return val
+}
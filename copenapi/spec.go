@@ -0,0 +1,284 @@
+package copenapi
+
+import (
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gocopper/copper/cerrors"
+	"gopkg.in/yaml.v3"
+)
+
+const componentsPrefix = "#/components/"
+
+// LoadSpec reads and parses the spec at Config.Spec. It returns a nil spec if validation is disabled.
+func LoadSpec(config Config) (*Spec, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(config.Spec)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to read openapi spec", map[string]interface{}{
+			"path": config.Spec,
+		})
+	}
+
+	return ParseSpec(data)
+}
+
+// ParseSpec parses an OpenAPI 3 spec in YAML or JSON. Only local references to components
+// (ex. #/components/schemas/User) are supported.
+func ParseSpec(data []byte) (*Spec, error) {
+	var spec Spec
+
+	err := yaml.Unmarshal(data, &spec)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to parse openapi spec", nil)
+	}
+
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, cerrors.New(nil, "unsupported openapi version", map[string]interface{}{
+			"version": spec.OpenAPI,
+		})
+	}
+
+	for path, item := range spec.Paths {
+		spec.routes = append(spec.routes, route{
+			path:     path,
+			segments: strings.Split(strings.Trim(path, "/"), "/"),
+			item:     item,
+		})
+	}
+
+	// literal segments take precedence over parameters (ex. /users/me over /users/{id})
+	sort.Slice(spec.routes, func(i, j int) bool {
+		a, b := spec.routes[i], spec.routes[j]
+		if len(a.segments) != len(b.segments) {
+			return len(a.segments) < len(b.segments)
+		}
+
+		for k := range a.segments {
+			if aParam, bParam := isParamSegment(a.segments[k]), isParamSegment(b.segments[k]); aParam != bParam {
+				return bParam
+			}
+		}
+
+		return a.path < b.path
+	})
+
+	return &spec, nil
+}
+
+// Spec is a parsed OpenAPI 3 spec
+type Spec struct {
+	OpenAPI    string               `yaml:"openapi"`
+	Paths      map[string]*pathItem `yaml:"paths"`
+	Components components           `yaml:"components"`
+
+	routes   []route
+	patterns sync.Map
+}
+
+type route struct {
+	path     string
+	segments []string
+	item     *pathItem
+}
+
+type components struct {
+	Schemas       map[string]*schema      `yaml:"schemas"`
+	Parameters    map[string]*parameter   `yaml:"parameters"`
+	RequestBodies map[string]*requestBody `yaml:"requestBodies"`
+	Responses     map[string]*response    `yaml:"responses"`
+}
+
+type pathItem struct {
+	Parameters []*parameter `yaml:"parameters"`
+	Get        *operation   `yaml:"get"`
+	Put        *operation   `yaml:"put"`
+	Post       *operation   `yaml:"post"`
+	Delete     *operation   `yaml:"delete"`
+	Options    *operation   `yaml:"options"`
+	Head       *operation   `yaml:"head"`
+	Patch      *operation   `yaml:"patch"`
+}
+
+func (p *pathItem) operation(method string) *operation {
+	switch method {
+	case http.MethodGet:
+		return p.Get
+	case http.MethodPut:
+		return p.Put
+	case http.MethodPost:
+		return p.Post
+	case http.MethodDelete:
+		return p.Delete
+	case http.MethodOptions:
+		return p.Options
+	case http.MethodHead:
+		return p.Head
+	case http.MethodPatch:
+		return p.Patch
+	default:
+		return nil
+	}
+}
+
+type operation struct {
+	OperationID string               `yaml:"operationId"`
+	Parameters  []*parameter         `yaml:"parameters"`
+	RequestBody *requestBody         `yaml:"requestBody"`
+	Responses   map[string]*response `yaml:"responses"`
+}
+
+type parameter struct {
+	Ref      string  `yaml:"$ref"`
+	Name     string  `yaml:"name"`
+	In       string  `yaml:"in"`
+	Required bool    `yaml:"required"`
+	Schema   *schema `yaml:"schema"`
+}
+
+type requestBody struct {
+	Ref      string                `yaml:"$ref"`
+	Required bool                  `yaml:"required"`
+	Content  map[string]*mediaType `yaml:"content"`
+}
+
+type response struct {
+	Ref     string                `yaml:"$ref"`
+	Content map[string]*mediaType `yaml:"content"`
+}
+
+type mediaType struct {
+	Schema *schema `yaml:"schema"`
+}
+
+// match returns the path item and path params of the spec path that matches path
+func (s *Spec) match(path string) (*route, map[string]string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i := range s.routes {
+		r := &s.routes[i]
+		if len(r.segments) != len(segments) {
+			continue
+		}
+
+		params := make(map[string]string)
+
+		for k, seg := range r.segments {
+			if isParamSegment(seg) {
+				params[strings.Trim(seg, "{}")] = segments[k]
+			} else if seg != segments[k] {
+				params = nil
+				break
+			}
+		}
+
+		if params != nil {
+			return r, params, true
+		}
+	}
+
+	return nil, nil, false
+}
+
+// parameters returns the operation's parameters along with the path item's ones that it does not override
+func (s *Spec) parameters(item *pathItem, op *operation) []*parameter {
+	var (
+		params = make([]*parameter, 0, len(item.Parameters)+len(op.Parameters))
+		seen   = make(map[string]bool)
+	)
+
+	for _, p := range op.Parameters {
+		p = s.resolveParameter(p)
+		seen[p.In+":"+p.Name] = true
+		params = append(params, p)
+	}
+
+	for _, p := range item.Parameters {
+		p = s.resolveParameter(p)
+		if !seen[p.In+":"+p.Name] {
+			params = append(params, p)
+		}
+	}
+
+	return params
+}
+
+func (s *Spec) resolveParameter(p *parameter) *parameter {
+	for i := 0; p != nil && p.Ref != "" && i < maxRefDepth; i++ {
+		p = s.Components.Parameters[strings.TrimPrefix(p.Ref, componentsPrefix+"parameters/")]
+	}
+
+	if p == nil {
+		return &parameter{}
+	}
+
+	return p
+}
+
+func (s *Spec) resolveRequestBody(b *requestBody) *requestBody {
+	for i := 0; b != nil && b.Ref != "" && i < maxRefDepth; i++ {
+		b = s.Components.RequestBodies[strings.TrimPrefix(b.Ref, componentsPrefix+"requestBodies/")]
+	}
+
+	return b
+}
+
+func (s *Spec) resolveResponse(r *response) *response {
+	for i := 0; r != nil && r.Ref != "" && i < maxRefDepth; i++ {
+		r = s.Components.Responses[strings.TrimPrefix(r.Ref, componentsPrefix+"responses/")]
+	}
+
+	return r
+}
+
+func (s *Spec) resolveSchema(sc *schema) *schema {
+	for i := 0; sc != nil && sc.Ref != "" && i < maxRefDepth; i++ {
+		sc = s.Components.Schemas[strings.TrimPrefix(sc.Ref, componentsPrefix+"schemas/")]
+	}
+
+	return sc
+}
+
+// pattern returns the compiled pattern, caching it since patterns are used for every request
+func (s *Spec) pattern(expr string) (*regexp.Regexp, error) {
+	if re, ok := s.patterns.Load(expr); ok {
+		return re.(*regexp.Regexp), nil //nolint:forcetypeassert
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	s.patterns.Store(expr, re)
+
+	return re, nil
+}
+
+func isParamSegment(seg string) bool {
+	return strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}")
+}
+
+// jsonMediaType returns the media type of content that is used for JSON bodies
+func jsonMediaType(content map[string]*mediaType) (*mediaType, bool) {
+	for contentType, mt := range content {
+		if isJSON(contentType) {
+			return mt, true
+		}
+	}
+
+	return nil, false
+}
+
+func isJSON(contentType string) bool {
+	contentType = strings.TrimSpace(strings.Split(contentType, ";")[0])
+
+	return contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+}
@@ -0,0 +1,11 @@
+package copenapi
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+	LoadSpec,
+	NewMiddleware,
+	wire.Struct(new(NewMiddlewareParams), "*"),
+)
@@ -0,0 +1,40 @@
+package cpubsub
+
+import (
+	"context"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Broker publishes and delivers messages
+type Broker interface {
+	// Publish publishes the message to the topic
+	Publish(ctx context.Context, topic string, msg *Message) error
+
+	// Consume delivers the messages of the topic to h until ctx is done. Each message is delivered to one of the
+	// consumers of the group. A message is acknowledged once h returns nil. Otherwise, it is redelivered.
+	Consume(ctx context.Context, topic, group string, h Handler) error
+}
+
+// NewBrokerParams holds the params needed for NewBroker
+type NewBrokerParams struct {
+	Config Config
+}
+
+// NewBroker creates the Broker configured by Config.Broker
+func NewBroker(p NewBrokerParams) (Broker, error) {
+	switch p.Config.Broker {
+	case BrokerMemory:
+		return NewMemoryBroker(), nil
+	case BrokerNATS:
+		return NewNATSBroker(p.Config.NATS)
+	case BrokerRedis:
+		return NewRedisBroker(p.Config.Redis)
+	case BrokerKafka:
+		return NewKafkaBroker(p.Config.Kafka), nil
+	default:
+		return nil, cerrors.New(nil, "unknown cpubsub broker", map[string]interface{}{
+			"broker": p.Config.Broker,
+		})
+	}
+}
@@ -0,0 +1,12 @@
+// Package cpubsub publishes messages to topics and delivers them to consumer groups. Each message is delivered to
+// one consumer of each group and is redelivered until a handler processes it without an error (at-least-once
+// delivery). Brokers are available for NATS JetStream, Redis Streams, Kafka (through the Confluent REST Proxy), and
+// an in-process bus for tests and single-instance apps.
+//
+// Unlike cgrpc, which lives in its own module so that its dependencies are only pulled in by apps that use it, the
+// brokers are part of the core module. To keep the core module free of client SDKs, they speak the wire protocols
+// directly: NATSBroker implements the small subset of the NATS protocol that JetStream pull consumers need (see
+// nats_conn.go) and RedisBroker uses the shared client in internal/redis. The tests in this package run the brokers
+// against fake servers and, when CPUBSUB_TEST_NATS_URL/CPUBSUB_TEST_NATS_STREAM or CPUBSUB_TEST_REDIS_URL are set,
+// against real ones.
+package cpubsub
@@ -0,0 +1,274 @@
+package cpubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	kafkaContentType   = "application/vnd.kafka.binary.v2+json"
+	kafkaJSONType      = "application/vnd.kafka.v2+json"
+	kafkaPollTimeout   = 2 * time.Second
+	kafkaClientTimeout = 30 * time.Second
+)
+
+// NewKafkaBroker creates a new KafkaBroker
+func NewKafkaBroker(config ConfigKafka) *KafkaBroker {
+	return &KafkaBroker{
+		config: config,
+		client: &http.Client{Timeout: kafkaClientTimeout},
+	}
+}
+
+// KafkaBroker is a Broker that uses Kafka through the Confluent REST Proxy (v2 API). Each group is a Kafka consumer
+// group. Since the REST Proxy does not support record headers, messages are published as a JSON envelope that holds
+// the message's data and headers. Offsets are committed once the handler succeeds. If it fails, the consumer seeks
+// back to the failed record so that it is redelivered.
+type KafkaBroker struct {
+	config ConfigKafka
+	client *http.Client
+}
+
+type kafkaEnvelope struct {
+	Headers map[string]string `json:"headers,omitempty"`
+	Data    []byte            `json:"data"`
+}
+
+type kafkaRecord struct {
+	Topic     string  `json:"topic"`
+	Key       *string `json:"key"`
+	Value     string  `json:"value"`
+	Partition int     `json:"partition"`
+	Offset    int64   `json:"offset"`
+}
+
+type kafkaOffset struct {
+	Topic     string `json:"topic"`
+	Partition int    `json:"partition"`
+	Offset    int64  `json:"offset"`
+}
+
+// Publish produces the message to the topic
+func (b *KafkaBroker) Publish(ctx context.Context, topic string, msg *Message) error {
+	value, err := json.Marshal(kafkaEnvelope{Headers: msg.Headers, Data: msg.Data})
+	if err != nil {
+		return cerrors.New(err, "failed to encode message", nil)
+	}
+
+	record := map[string]interface{}{"value": base64.StdEncoding.EncodeToString(value)}
+	if msg.Key != "" {
+		record["key"] = base64.StdEncoding.EncodeToString([]byte(msg.Key))
+	}
+
+	var resp struct {
+		Offsets []struct {
+			Partition int    `json:"partition"`
+			Offset    int64  `json:"offset"`
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+
+	err = b.do(ctx, http.MethodPost, b.config.RESTProxyURL+"/topics/"+topic, map[string]interface{}{
+		"records": []interface{}{record},
+	}, &resp)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range resp.Offsets {
+		if o.ErrorCode != nil || o.Error != "" {
+			return cerrors.New(nil, "failed to produce message", map[string]interface{}{
+				"topic": topic,
+				"error": o.Error,
+			})
+		}
+	}
+
+	return nil
+}
+
+// Consume delivers the messages of the topic to h until ctx is done. See Broker.
+func (b *KafkaBroker) Consume(ctx context.Context, topic, group string, h Handler) error {
+	var instance struct {
+		BaseURI string `json:"base_uri"`
+	}
+
+	err := b.do(ctx, http.MethodPost, b.config.RESTProxyURL+"/consumers/"+group, map[string]interface{}{
+		"name":               group + "-" + randomHex(6), //nolint:gomnd
+		"format":             "binary",
+		"auto.offset.reset":  "earliest",
+		"auto.commit.enable": "false",
+	}, &instance)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		deleteCtx, cancel := context.WithTimeout(context.Background(), kafkaClientTimeout)
+		defer cancel()
+
+		_ = b.do(deleteCtx, http.MethodDelete, instance.BaseURI, nil, nil)
+	}()
+
+	err = b.do(ctx, http.MethodPost, instance.BaseURI+"/subscription", map[string]interface{}{
+		"topics": []string{topic},
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	recordsURL := instance.BaseURI + "/records?timeout=" + strconv.FormatInt(kafkaPollTimeout.Milliseconds(), 10)
+
+	for ctx.Err() == nil {
+		var records []kafkaRecord
+
+		err = b.do(ctx, http.MethodGet, recordsURL, nil, &records)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		for i := range records {
+			ok, err := b.process(ctx, instance.BaseURI, records[i], h)
+			if err != nil {
+				return err
+			}
+
+			// the consumer was moved back to the failed record, so the rest of the batch is fetched again
+			if !ok {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// process delivers the record to h and commits its offset if h succeeds. Otherwise, the consumer seeks back to the
+// record so that it is fetched again and false is returned.
+func (b *KafkaBroker) process(ctx context.Context, baseURI string, record kafkaRecord, h Handler) (bool, error) {
+	var (
+		env    kafkaEnvelope
+		offset = kafkaOffset{Topic: record.Topic, Partition: record.Partition, Offset: record.Offset}
+		msg    = Message{
+			ID:    strconv.Itoa(record.Partition) + "-" + strconv.FormatInt(record.Offset, 10),
+			Topic: record.Topic,
+		}
+	)
+
+	if record.Key != nil {
+		key, _ := base64.StdEncoding.DecodeString(*record.Key)
+		msg.Key = string(key)
+	}
+
+	value, err := base64.StdEncoding.DecodeString(record.Value)
+	if err != nil {
+		return false, cerrors.New(err, "invalid kafka record value", map[string]interface{}{
+			"id": msg.ID,
+		})
+	}
+
+	err = json.Unmarshal(value, &env)
+
+	if err == nil {
+		msg.Data, msg.Headers = env.Data, env.Headers
+	} else {
+		// records that were not produced by cpubsub are delivered as is
+		msg.Data = value
+	}
+
+	// offsets are updated using a background context so that the processed record is committed even if ctx is done
+	updateCtx, cancel := context.WithTimeout(context.Background(), kafkaClientTimeout)
+	defer cancel()
+
+	if h(ctx, &msg) != nil {
+		return false, b.do(updateCtx, http.MethodPost, baseURI+"/positions", map[string]interface{}{
+			"offsets": []kafkaOffset{offset},
+		}, nil)
+	}
+
+	return true, b.do(updateCtx, http.MethodPost, baseURI+"/offsets", map[string]interface{}{
+		"offsets": []kafkaOffset{offset},
+	}, nil)
+}
+
+func (b *KafkaBroker) do(ctx context.Context, method, url string, body, dest interface{}) error {
+	var reqBody io.Reader
+
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return cerrors.New(err, "failed to encode request", nil)
+		}
+
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return cerrors.New(err, "failed to create request", nil)
+	}
+
+	// produce requests embed the records, so they use the binary format's content type
+	contentType := kafkaJSONType
+	if method == http.MethodPost && strings.HasPrefix(url, b.config.RESTProxyURL+"/topics/") {
+		contentType = kafkaContentType
+	}
+
+	req.Header.Set("Accept", kafkaContentType+", "+kafkaJSONType)
+
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if b.config.Username != "" {
+		req.SetBasicAuth(b.config.Username, b.config.Password)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return cerrors.New(err, "failed to send request to kafka rest proxy", map[string]interface{}{
+			"method": method,
+			"url":    url,
+		})
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return cerrors.New(err, "failed to read kafka rest proxy response", nil)
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return cerrors.New(nil, "kafka rest proxy request failed", map[string]interface{}{
+			"method": method,
+			"url":    url,
+			"status": resp.StatusCode,
+			"body":   string(respBody),
+		})
+	}
+
+	if dest == nil || len(respBody) == 0 {
+		return nil
+	}
+
+	err = json.Unmarshal(respBody, dest)
+	if err != nil {
+		return cerrors.New(err, "failed to decode kafka rest proxy response", nil)
+	}
+
+	return nil
+}
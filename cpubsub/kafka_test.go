@@ -0,0 +1,124 @@
+package cpubsub_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/cpubsub"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeKafkaProxy is a single partition topic served using the Confluent REST Proxy v2 API
+type fakeKafkaProxy struct {
+	mu        sync.Mutex
+	url       string
+	records   []map[string]interface{}
+	position  int
+	committed []int64
+}
+
+func (p *fakeKafkaProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var body map[string]json.RawMessage
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	switch {
+	case r.URL.Path == "/topics/orders":
+		var records []map[string]interface{}
+		_ = json.Unmarshal(body["records"], &records)
+
+		for _, rec := range records {
+			rec["topic"], rec["partition"], rec["offset"] = "orders", 0, len(p.records)
+			p.records = append(p.records, rec)
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"offsets": []map[string]interface{}{{"partition": 0, "offset": len(p.records) - 1}},
+		})
+	case r.URL.Path == "/consumers/billing":
+		_ = json.NewEncoder(w).Encode(map[string]string{"base_uri": p.url + "/consumers/billing/instances/c1"})
+	case strings.HasSuffix(r.URL.Path, "/records"):
+		records := p.records[p.position:]
+		p.position = len(p.records)
+
+		_ = json.NewEncoder(w).Encode(records)
+	case strings.HasSuffix(r.URL.Path, "/offsets"), strings.HasSuffix(r.URL.Path, "/positions"):
+		var offsets []struct {
+			Offset int64 `json:"offset"`
+		}
+		_ = json.Unmarshal(body["offsets"], &offsets)
+
+		if strings.HasSuffix(r.URL.Path, "/positions") {
+			p.position = int(offsets[0].Offset)
+		} else {
+			p.committed = append(p.committed, offsets[0].Offset)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestKafkaBroker(t *testing.T) {
+	t.Parallel()
+
+	var (
+		proxy       = &fakeKafkaProxy{}
+		server      = httptest.NewServer(proxy)
+		broker      = cpubsub.NewKafkaBroker(cpubsub.ConfigKafka{RESTProxyURL: server.URL})
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		attempts    = make(map[string]int)
+		received    []cpubsub.Message
+	)
+	defer server.Close()
+	defer cancel()
+
+	proxy.url = server.URL
+
+	for _, data := range []string{"a", "b"} {
+		assert.NoError(t, broker.Publish(ctx, "orders", &cpubsub.Message{
+			Key:     "order-1",
+			Data:    []byte(data),
+			Headers: map[string]string{"Trace-Id": "trace-1"},
+		}))
+	}
+
+	err := broker.Consume(ctx, "orders", "billing", func(ctx context.Context, msg *cpubsub.Message) error {
+		attempts[string(msg.Data)]++
+
+		// the first attempt of a fails, so a and b are fetched again
+		if string(msg.Data) == "a" && attempts["a"] == 1 {
+			return errors.New("test-err")
+		}
+
+		received = append(received, *msg)
+		if len(received) == 2 {
+			cancel()
+		}
+
+		return nil
+	})
+	assert.NoError(t, err)
+
+	if assert.Len(t, received, 2) {
+		assert.Equal(t, "a", string(received[0].Data))
+		assert.Equal(t, "b", string(received[1].Data))
+		assert.Equal(t, "order-1", received[0].Key)
+		assert.Equal(t, "trace-1", received[0].Headers["Trace-Id"])
+		assert.Equal(t, "0-1", received[1].ID)
+	}
+
+	assert.Equal(t, 2, attempts["a"])
+	assert.Equal(t, 1, attempts["b"])
+	assert.Equal(t, []int64{0, 1}, proxy.committed)
+}
@@ -0,0 +1,185 @@
+package cpubsub
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memoryRetryDelay is how long a message that failed is held before it is redelivered
+const memoryRetryDelay = 100 * time.Millisecond
+
+// NewMemoryBroker creates a new MemoryBroker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		groups:    make(map[string]map[string]*memoryGroup),
+		published: make(map[string][]Message),
+	}
+}
+
+// MemoryBroker is an in-process Broker for tests and apps that run a single instance. Messages are only delivered
+// to the groups that consumed the topic before they were published, and are lost when the app exits.
+type MemoryBroker struct {
+	mu        sync.Mutex
+	seq       int64
+	groups    map[string]map[string]*memoryGroup
+	published map[string][]Message
+}
+
+type memoryGroup struct {
+	mu       sync.Mutex
+	queue    []*Message
+	inFlight int
+	notify   chan struct{}
+}
+
+// Publish delivers the message to the groups that consume the topic
+func (b *MemoryBroker) Publish(_ context.Context, topic string, msg *Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+
+	published := *msg
+	published.ID = strconv.FormatInt(b.seq, 10)
+	published.Topic = topic
+	published.Headers = copyHeaders(msg.Headers)
+
+	b.published[topic] = append(b.published[topic], published)
+
+	for _, g := range b.groups[topic] {
+		m := published
+		m.Headers = copyHeaders(published.Headers)
+
+		g.push(&m)
+	}
+
+	return nil
+}
+
+// Consume delivers the messages of the topic to h until ctx is done. Failed messages are redelivered after a short
+// delay.
+func (b *MemoryBroker) Consume(ctx context.Context, topic, group string, h Handler) error {
+	g := b.group(topic, group)
+
+	for {
+		msg, ok := g.pop(ctx)
+		if !ok {
+			return nil
+		}
+
+		err := h(ctx, msg)
+		if err == nil {
+			g.done()
+			continue
+		}
+
+		go func() {
+			time.Sleep(memoryRetryDelay)
+			g.push(msg)
+			g.done()
+		}()
+	}
+}
+
+// Published returns the messages published to the topic
+func (b *MemoryBroker) Published(topic string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]Message(nil), b.published[topic]...)
+}
+
+// Pending returns the number of messages of the topic that the group has not processed yet, including the ones that
+// are being processed
+func (b *MemoryBroker) Pending(topic, group string) int {
+	g := b.group(topic, group)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return len(g.queue) + g.inFlight
+}
+
+// Wait blocks until the group processed all the messages of the topic or ctx is done
+func (b *MemoryBroker) Wait(ctx context.Context, topic, group string) error {
+	const interval = 5 * time.Millisecond
+
+	for b.Pending(topic, group) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+
+	return nil
+}
+
+func (b *MemoryBroker) group(topic, group string) *memoryGroup {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.groups[topic] == nil {
+		b.groups[topic] = make(map[string]*memoryGroup)
+	}
+
+	g, ok := b.groups[topic][group]
+	if !ok {
+		g = &memoryGroup{notify: make(chan struct{}, 1)}
+		b.groups[topic][group] = g
+	}
+
+	return g
+}
+
+func (g *memoryGroup) push(msg *Message) {
+	g.mu.Lock()
+	g.queue = append(g.queue, msg)
+	g.mu.Unlock()
+
+	select {
+	case g.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (g *memoryGroup) pop(ctx context.Context) (*Message, bool) {
+	for {
+		g.mu.Lock()
+
+		if len(g.queue) > 0 {
+			msg := g.queue[0]
+			g.queue = g.queue[1:]
+			g.inFlight++
+
+			// wake up the group's other consumers if there are more messages
+			if len(g.queue) > 0 {
+				select {
+				case g.notify <- struct{}{}:
+				default:
+				}
+			}
+
+			g.mu.Unlock()
+
+			return msg, true
+		}
+
+		g.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-g.notify:
+		}
+	}
+}
+
+func (g *memoryGroup) done() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.inFlight--
+}
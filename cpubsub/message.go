@@ -0,0 +1,64 @@
+package cpubsub
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Message is a message published to a topic
+type Message struct {
+	// ID is assigned by the broker when the message is delivered
+	ID string
+
+	// Topic the message was published to
+	Topic string
+
+	// Key is used by brokers that partition topics (ex. Kafka) so that messages with the same key are delivered in
+	// order
+	Key string
+
+	Data    []byte
+	Headers map[string]string
+}
+
+// Decode unmarshals the message's JSON data into dest
+func (m *Message) Decode(dest interface{}) error {
+	err := json.Unmarshal(m.Data, dest)
+	if err != nil {
+		return cerrors.New(err, "failed to decode message", map[string]interface{}{
+			"topic": m.Topic,
+			"id":    m.ID,
+		})
+	}
+
+	return nil
+}
+
+// Handler processes a message. If it returns an error, the message is redelivered.
+type Handler func(ctx context.Context, msg *Message) error
+
+// Middleware wraps a Handler (ex. to log or trace messages)
+type Middleware func(next Handler) Handler
+
+func chain(h Handler, mws []Middleware) Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+
+	return h
+}
+
+func copyHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+
+	c := make(map[string]string, len(headers))
+	for k, v := range headers {
+		c[k] = v
+	}
+
+	return c
+}
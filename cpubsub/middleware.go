@@ -0,0 +1,78 @@
+package cpubsub
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+)
+
+// HeaderTraceID is the message header that holds the trace id of the request that published the message
+const HeaderTraceID = "Trace-Id"
+
+// LoggingMiddleware logs each processed message with its duration. Messages that failed are logged as warnings since
+// they are redelivered.
+func LoggingMiddleware(logger clogger.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			start := time.Now()
+
+			err := next(ctx, msg)
+
+			log := logger.WithTags(map[string]interface{}{
+				"topic":      msg.Topic,
+				"messageID":  msg.ID,
+				"durationMs": time.Since(start).Milliseconds(),
+			}).WithFields(clogger.FieldsFromCtx(ctx))
+
+			if err != nil {
+				log.Warn("Failed to process message; it will be redelivered", err)
+				return err
+			}
+
+			log.Debug("Processed message")
+
+			return nil
+		}
+	}
+}
+
+// TracingMiddleware adds the trace id in the message's headers to the context's log fields so that the logs of the
+// handler are correlated with the request that published the message
+func TracingMiddleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			fields := map[string]interface{}{
+				"topic":     msg.Topic,
+				"messageID": msg.ID,
+			}
+
+			if traceID := msg.Headers[HeaderTraceID]; traceID != "" {
+				fields["traceID"] = traceID
+			}
+
+			return next(clogger.CtxWithFields(ctx, fields), msg)
+		}
+	}
+}
+
+// RecoverMiddleware turns panics in handlers into errors so that the message is redelivered instead of crashing the
+// app
+func RecoverMiddleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = cerrors.New(nil, fmt.Sprintf("message handler panicked: %v", r), map[string]interface{}{
+						"stack": string(debug.Stack()),
+					})
+				}
+			}()
+
+			return next(ctx, msg)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package cpubsub
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	natsRequestTimeout = 10 * time.Second
+	natsPullExpires    = 5 * time.Second
+	natsAckTimeout     = 5 * time.Second
+)
+
+// natsStatusNoResponders is the status of the reply to a request that no one is subscribed to. For JetStream
+// publishes, it means that no stream captures the subject.
+const natsStatusNoResponders = "503"
+
+// NewNATSBroker creates a new NATSBroker. It connects to the server when it is first used.
+func NewNATSBroker(config ConfigNATS) (*NATSBroker, error) {
+	if config.URL == "" || config.Stream == "" {
+		return nil, cerrors.New(nil, "cpubsub.nats.url and cpubsub.nats.stream are required", nil)
+	}
+
+	return &NATSBroker{config: config}, nil
+}
+
+// NATSBroker is a Broker that uses NATS JetStream. Topics are subjects captured by Config.NATS.Stream and each group
+// is a durable pull consumer of the stream. Messages that are not acknowledged within Config.NATS.AckWait are
+// redelivered.
+type NATSBroker struct {
+	config ConfigNATS
+
+	mu   sync.Mutex
+	conn *natsConn
+}
+
+type natsAPIError struct {
+	Code        int    `json:"code"`
+	ErrCode     int    `json:"err_code"`
+	Description string `json:"description"`
+}
+
+type natsAPIResponse struct {
+	Error *natsAPIError `json:"error"`
+}
+
+// Publish publishes the message to the topic and waits for JetStream to store it
+func (b *NATSBroker) Publish(ctx context.Context, topic string, msg *Message) error {
+	conn, err := b.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	headers := copyHeaders(msg.Headers)
+	if msg.Key != "" {
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+
+		headers["Cpubsub-Key"] = msg.Key
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, natsRequestTimeout)
+	defer cancel()
+
+	reply, err := conn.request(ctx, topic, headers, msg.Data)
+	if err != nil {
+		return err
+	}
+
+	if reply.status == natsStatusNoResponders {
+		return cerrors.New(nil, "no jetstream stream captures the topic", map[string]interface{}{
+			"topic":  topic,
+			"stream": b.config.Stream,
+		})
+	}
+
+	return natsReplyErr(reply, "failed to publish message to jetstream")
+}
+
+// Consume delivers the messages of the topic to h until ctx is done. See Broker.
+func (b *NATSBroker) Consume(ctx context.Context, topic, group string, h Handler) error {
+	conn, err := b.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	durable := natsDurableName(group, topic)
+
+	err = b.createConsumer(ctx, conn, durable, topic)
+	if err != nil {
+		return err
+	}
+
+	inbox := "_INBOX." + randomHex(11) //nolint:gomnd
+
+	sid, msgs, err := conn.subscribe(inbox, "")
+	if err != nil {
+		return err
+	}
+	defer conn.unsubscribe(sid)
+
+	pull, err := json.Marshal(map[string]interface{}{
+		"batch":   1,
+		"expires": natsPullExpires.Nanoseconds(),
+	})
+	if err != nil {
+		return cerrors.New(err, "failed to encode pull request", nil)
+	}
+
+	next := "$JS.API.CONSUMER.MSG.NEXT." + b.config.Stream + "." + durable
+
+	for ctx.Err() == nil {
+		err = conn.publish(next, inbox, nil, pull)
+		if err != nil {
+			return err
+		}
+
+		var nm *natsMsg
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-conn.done:
+			return conn.closedErr()
+		case <-time.After(natsPullExpires + time.Second):
+			continue
+		case nm = <-msgs:
+		}
+
+		// status messages (ex. 404 No Messages or 408 Request Timeout) end the pull request
+		if nm.status != "" {
+			continue
+		}
+
+		msg := Message{
+			ID:      natsStreamSeq(nm.reply),
+			Topic:   nm.subject,
+			Key:     nm.headers["Cpubsub-Key"],
+			Data:    nm.data,
+			Headers: nm.headers,
+		}
+
+		delete(msg.Headers, "Cpubsub-Key")
+
+		ack := "+ACK"
+		if h(ctx, &msg) != nil {
+			ack = "-NAK"
+		}
+
+		err = conn.publish(nm.reply, "", nil, []byte(ack))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close closes the connection to NATS
+func (b *NATSBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn != nil {
+		b.conn.close()
+		b.conn = nil
+	}
+
+	return nil
+}
+
+func (b *NATSBroker) createConsumer(ctx context.Context, conn *natsConn, durable, topic string) error {
+	req, err := json.Marshal(map[string]interface{}{
+		"stream_name": b.config.Stream,
+		"config": map[string]interface{}{
+			"durable_name":   durable,
+			"ack_policy":     "explicit",
+			"ack_wait":       b.config.AckWait.Nanoseconds(),
+			"deliver_policy": "all",
+			"filter_subject": topic,
+		},
+	})
+	if err != nil {
+		return cerrors.New(err, "failed to encode consumer config", nil)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, natsRequestTimeout)
+	defer cancel()
+
+	reply, err := conn.request(ctx, "$JS.API.CONSUMER.DURABLE.CREATE."+b.config.Stream+"."+durable, nil, req)
+	if err != nil {
+		return err
+	}
+
+	return natsReplyErr(reply, "failed to create jetstream consumer")
+}
+
+// connect returns the connection to NATS, reconnecting if it was closed
+func (b *NATSBroker) connect(ctx context.Context) (*natsConn, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn != nil && !b.conn.closed() {
+		return b.conn, nil
+	}
+
+	conn, err := dialNATS(ctx, b.config.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	b.conn = conn
+
+	return conn, nil
+}
+
+func natsReplyErr(reply *natsMsg, msg string) error {
+	var resp natsAPIResponse
+
+	err := json.Unmarshal(reply.data, &resp)
+	if err != nil {
+		return cerrors.New(err, "invalid jetstream response", nil)
+	}
+
+	if resp.Error != nil {
+		return cerrors.New(nil, msg, map[string]interface{}{
+			"code":        resp.Error.Code,
+			"errCode":     resp.Error.ErrCode,
+			"description": resp.Error.Description,
+		})
+	}
+
+	return nil
+}
+
+// natsDurableName returns the durable consumer name of the group for the topic. Durable names cannot contain dots,
+// wildcards, or whitespace.
+func natsDurableName(group, topic string) string {
+	return strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_").Replace(group + "_" + topic)
+}
+
+// natsStreamSeq returns the stream sequence of a message from its ack subject:
+// $JS.ACK.<stream>.<consumer>.<delivered>.<stream seq>.<consumer seq>.<timestamp>.<pending>, or with a domain and
+// account hash after $JS.ACK in newer servers
+func natsStreamSeq(reply string) string {
+	tokens := strings.Split(reply, ".")
+
+	switch {
+	case len(tokens) == 9: //nolint:gomnd
+		return tokens[5]
+	case len(tokens) >= 11: //nolint:gomnd
+		return tokens[7]
+	default:
+		return reply
+	}
+}
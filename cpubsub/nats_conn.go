@@ -0,0 +1,485 @@
+package cpubsub
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	natsDefaultPort   = "4222"
+	natsDialTimeout   = 5 * time.Second
+	natsSubBufferSize = 64
+	natsHeaderLine    = "NATS/1.0"
+)
+
+// natsConn is a minimal client of the NATS protocol (https://docs.nats.io/reference/reference-protocols/nats-protocol)
+// that supports publishing with headers, subscriptions, and requests
+type natsConn struct {
+	nc net.Conn
+	r  *bufio.Reader
+
+	wmu sync.Mutex
+	w   *bufio.Writer
+
+	mu              sync.Mutex
+	nextSID         int64
+	subs            map[int64]chan *natsMsg
+	inbox           string
+	inboxSubscribed bool
+	requests        map[string]chan *natsMsg
+	pongs           []chan struct{}
+	err             error
+	done            chan struct{}
+}
+
+type natsMsg struct {
+	subject string
+	reply   string
+	status  string
+	headers map[string]string
+	data    []byte
+}
+
+type natsServerInfo struct {
+	TLSRequired bool `json:"tls_required"`
+	Headers     bool `json:"headers"`
+}
+
+// dialNATS connects to the server at rawURL (nats://[user:password@|token@]host:port, or tls:// for TLS)
+func dialNATS(ctx context.Context, rawURL string) (*natsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, cerrors.New(err, "invalid nats url", nil)
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), natsDefaultPort)
+	}
+
+	dialer := net.Dialer{Timeout: natsDialTimeout}
+
+	nc, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to connect to nats", map[string]interface{}{
+			"host": host,
+		})
+	}
+
+	c := &natsConn{
+		nc:       nc,
+		r:        bufio.NewReader(nc),
+		w:        bufio.NewWriter(nc),
+		subs:     make(map[int64]chan *natsMsg),
+		requests: make(map[string]chan *natsMsg),
+		inbox:    "_INBOX." + randomHex(11), //nolint:gomnd
+		done:     make(chan struct{}),
+	}
+
+	err = c.handshake(ctx, u)
+	if err != nil {
+		_ = nc.Close()
+		return nil, err
+	}
+
+	go c.readLoop()
+
+	err = c.flush(ctx)
+	if err != nil {
+		c.close()
+		return nil, cerrors.New(err, "failed to connect to nats", nil)
+	}
+
+	return c, nil
+}
+
+func (c *natsConn) handshake(ctx context.Context, u *url.URL) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = c.nc.SetDeadline(deadline)
+		defer func() { _ = c.nc.SetDeadline(time.Time{}) }()
+	}
+
+	line, err := c.r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		return cerrors.New(err, "failed to read nats server info", nil)
+	}
+
+	var info natsServerInfo
+
+	err = json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "INFO "))), &info)
+	if err != nil {
+		return cerrors.New(err, "invalid nats server info", nil)
+	}
+
+	if !info.Headers {
+		return cerrors.New(nil, "nats server does not support headers", nil)
+	}
+
+	if u.Scheme == "tls" || info.TLSRequired {
+		tc := tls.Client(c.nc, &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12})
+
+		err = tc.HandshakeContext(ctx)
+		if err != nil {
+			return cerrors.New(err, "failed tls handshake with nats", nil)
+		}
+
+		c.nc = tc
+		c.r = bufio.NewReader(tc)
+		c.w = bufio.NewWriter(tc)
+	}
+
+	opts := map[string]interface{}{
+		"verbose":       false,
+		"pedantic":      false,
+		"headers":       true,
+		"no_responders": true,
+		"lang":          "go",
+		"version":       "copper",
+		"protocol":      1,
+	}
+
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			opts["user"] = u.User.Username()
+			opts["pass"] = pass
+		} else {
+			opts["auth_token"] = u.User.Username()
+		}
+	}
+
+	connect, err := json.Marshal(opts)
+	if err != nil {
+		return cerrors.New(err, "failed to encode nats connect options", nil)
+	}
+
+	return c.write("CONNECT " + string(connect) + "\r\n")
+}
+
+// publish sends a message. Headers are sent using HPUB if there are any.
+func (c *natsConn) publish(subject, reply string, headers map[string]string, data []byte) error {
+	var buf strings.Builder
+
+	target := subject
+	if reply != "" {
+		target += " " + reply
+	}
+
+	if len(headers) == 0 {
+		fmt.Fprintf(&buf, "PUB %s %d\r\n", target, len(data))
+	} else {
+		hdr := encodeNATSHeaders(headers)
+		fmt.Fprintf(&buf, "HPUB %s %d %d\r\n%s", target, len(hdr), len(hdr)+len(data), hdr)
+	}
+
+	buf.Write(data)
+	buf.WriteString("\r\n")
+
+	return c.write(buf.String())
+}
+
+// subscribe subscribes to the subject. Messages are sent to the returned channel until unsubscribe is called.
+func (c *natsConn) subscribe(subject, queue string) (int64, <-chan *natsMsg, error) {
+	c.mu.Lock()
+	c.nextSID++
+	sid := c.nextSID
+	ch := make(chan *natsMsg, natsSubBufferSize)
+	c.subs[sid] = ch
+	c.mu.Unlock()
+
+	target := subject
+	if queue != "" {
+		target += " " + queue
+	}
+
+	err := c.write(fmt.Sprintf("SUB %s %d\r\n", target, sid))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return sid, ch, nil
+}
+
+func (c *natsConn) unsubscribe(sid int64) {
+	c.mu.Lock()
+	delete(c.subs, sid)
+	c.mu.Unlock()
+
+	_ = c.write(fmt.Sprintf("UNSUB %d\r\n", sid))
+}
+
+// request publishes a message with a unique reply subject and waits for the reply
+func (c *natsConn) request(ctx context.Context, subject string, headers map[string]string, data []byte) (*natsMsg,
+	error) {
+	c.mu.Lock()
+
+	// the first request subscribes to the replies of all requests using the reserved sid 0
+	if !c.inboxSubscribed {
+		c.inboxSubscribed = true
+		c.mu.Unlock()
+
+		err := c.write(fmt.Sprintf("SUB %s.* 0\r\n", c.inbox))
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+	}
+
+	reply := c.inbox + "." + randomHex(8) //nolint:gomnd
+	ch := make(chan *natsMsg, 1)
+	c.requests[reply] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.requests, reply)
+		c.mu.Unlock()
+	}()
+
+	err := c.publish(subject, reply, headers, data)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg := <-ch:
+		return msg, nil
+	case <-c.done:
+		return nil, c.closedErr()
+	case <-ctx.Done():
+		return nil, cerrors.New(ctx.Err(), "nats request timed out", map[string]interface{}{
+			"subject": subject,
+		})
+	}
+}
+
+// flush waits until the server processed the commands sent so far
+func (c *natsConn) flush(ctx context.Context) error {
+	pong := make(chan struct{})
+
+	c.mu.Lock()
+	c.pongs = append(c.pongs, pong)
+	c.mu.Unlock()
+
+	err := c.write("PING\r\n")
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-pong:
+		return nil
+	case <-c.done:
+		return c.closedErr()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *natsConn) close() {
+	_ = c.nc.Close()
+	<-c.done
+}
+
+// closed returns true if the connection was closed
+func (c *natsConn) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *natsConn) closedErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return cerrors.New(c.err, "nats connection is closed", nil)
+}
+
+func (c *natsConn) write(s string) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	_, err := c.w.WriteString(s)
+	if err == nil {
+		err = c.w.Flush()
+	}
+
+	if err != nil {
+		return cerrors.New(err, "failed to write to nats", nil)
+	}
+
+	return nil
+}
+
+func (c *natsConn) readLoop() {
+	var err error
+
+	defer func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+
+		_ = c.nc.Close()
+		close(c.done)
+	}()
+
+	for {
+		var line string
+
+		line, err = c.r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		op, args, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(op) {
+		case "MSG", "HMSG":
+			var msg *natsMsg
+
+			msg, err = c.readMsg(strings.ToUpper(op) == "HMSG", strings.Fields(args))
+			if err != nil {
+				return
+			}
+
+			c.dispatch(msg, strings.Fields(args)[1])
+		case "PING":
+			_ = c.write("PONG\r\n")
+		case "PONG":
+			c.mu.Lock()
+			if len(c.pongs) > 0 {
+				close(c.pongs[0])
+				c.pongs = c.pongs[1:]
+			}
+			c.mu.Unlock()
+		case "-ERR":
+			err = cerrors.New(nil, "nats error: "+strings.Trim(args, "'"), nil)
+			return
+		}
+	}
+}
+
+// readMsg reads the payload of a MSG (subject sid [reply] size) or HMSG (subject sid [reply] hdr-size total-size)
+func (c *natsConn) readMsg(hasHeaders bool, args []string) (*natsMsg, error) {
+	sizes := 1
+	if hasHeaders {
+		sizes = 2
+	}
+
+	if len(args) < 2+sizes || len(args) > 3+sizes {
+		return nil, cerrors.New(nil, "invalid nats message", nil)
+	}
+
+	msg := &natsMsg{subject: args[0]}
+	if len(args) == 3+sizes {
+		msg.reply = args[2]
+	}
+
+	total, err := strconv.Atoi(args[len(args)-1])
+	if err != nil {
+		return nil, cerrors.New(err, "invalid nats message size", nil)
+	}
+
+	payload := make([]byte, total+2) //nolint:gomnd
+
+	_, err = io.ReadFull(c.r, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	payload = payload[:total]
+
+	if hasHeaders {
+		hdrSize, err := strconv.Atoi(args[len(args)-2])
+		if err != nil || hdrSize > total {
+			return nil, cerrors.New(err, "invalid nats header size", nil)
+		}
+
+		msg.status, msg.headers = decodeNATSHeaders(string(payload[:hdrSize]))
+		payload = payload[hdrSize:]
+	}
+
+	msg.data = payload
+
+	return msg, nil
+}
+
+func (c *natsConn) dispatch(msg *natsMsg, sidArg string) {
+	sid, _ := strconv.ParseInt(sidArg, 10, 64)
+
+	c.mu.Lock()
+
+	if sid == 0 {
+		ch, ok := c.requests[msg.subject]
+		c.mu.Unlock()
+
+		if ok {
+			ch <- msg
+		}
+
+		return
+	}
+
+	ch, ok := c.subs[sid]
+	c.mu.Unlock()
+
+	if ok {
+		ch <- msg
+	}
+}
+
+func encodeNATSHeaders(headers map[string]string) string {
+	var b strings.Builder
+
+	b.WriteString(natsHeaderLine + "\r\n")
+
+	for k, v := range headers {
+		b.WriteString(k + ": " + v + "\r\n")
+	}
+
+	b.WriteString("\r\n")
+
+	return b.String()
+}
+
+// decodeNATSHeaders parses a header block. It returns the status code of the first line if there is one (ex. 404
+// for NATS/1.0 404 No Messages).
+func decodeNATSHeaders(block string) (string, map[string]string) {
+	var (
+		lines   = strings.Split(strings.TrimRight(block, "\r\n"), "\r\n")
+		status  string
+		headers = make(map[string]string)
+	)
+
+	if len(lines) > 0 && strings.HasPrefix(lines[0], natsHeaderLine) {
+		if fields := strings.Fields(strings.TrimPrefix(lines[0], natsHeaderLine)); len(fields) > 0 {
+			status = fields[0]
+		}
+
+		lines = lines[1:]
+	}
+
+	for _, line := range lines {
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+
+	return status, headers
+}
@@ -0,0 +1,309 @@
+package cpubsub_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/cpubsub"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeJetStream is a NATS server with a single JetStream stream (EVENTS) that captures the orders subject. It
+// supports one client and the subset of the JetStream API used by NATSBroker.
+type fakeJetStream struct {
+	ln net.Listener
+
+	mu      sync.Mutex
+	w       *bufio.Writer
+	subs    map[string]string
+	msgs    []fakeJetStreamMsg
+	pending []int
+	acked   []int
+	naked   []int
+}
+
+type fakeJetStreamMsg struct {
+	headers string
+	data    string
+}
+
+func newFakeJetStream(t *testing.T) *fakeJetStream {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &fakeJetStream{ln: ln, subs: make(map[string]string)}
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+
+		s.serve(conn)
+	}()
+
+	return s
+}
+
+func (s *fakeJetStream) url() string {
+	return "nats://" + s.ln.Addr().String()
+}
+
+func (s *fakeJetStream) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	r := bufio.NewReader(conn)
+	s.w = bufio.NewWriter(conn)
+
+	s.send(`INFO {"headers":true}` + "\r\n")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+
+		switch args[0] {
+		case "PING":
+			s.send("PONG\r\n")
+		case "SUB":
+			s.mu.Lock()
+			s.subs[args[1]] = args[len(args)-1]
+			s.mu.Unlock()
+		case "PUB", "HPUB":
+			var (
+				hdrSize = 0
+				reply   string
+			)
+
+			if args[0] == "HPUB" {
+				hdrSize, _ = strconv.Atoi(args[len(args)-2])
+			}
+
+			if len(args) == 5 || (args[0] == "PUB" && len(args) == 4) {
+				reply = args[2]
+			}
+
+			size, _ := strconv.Atoi(args[len(args)-1])
+			payload := make([]byte, size+2)
+
+			_, err = io.ReadFull(r, payload)
+			if err != nil {
+				return
+			}
+
+			s.handle(args[1], reply, string(payload[:hdrSize]), string(payload[hdrSize:size]))
+		}
+	}
+}
+
+func (s *fakeJetStream) handle(subject, reply, headers, data string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case subject == "orders":
+		s.msgs = append(s.msgs, fakeJetStreamMsg{headers: headers, data: data})
+		s.pending = append(s.pending, len(s.msgs))
+		s.reply(reply, "", fmt.Sprintf(`{"stream":"EVENTS","seq":%d}`, len(s.msgs)))
+	case strings.HasPrefix(subject, "$JS.API.CONSUMER.DURABLE.CREATE.EVENTS."):
+		s.reply(reply, "", `{"type":"io.nats.jetstream.api.v1.consumer_create_response"}`)
+	case strings.HasPrefix(subject, "$JS.API.CONSUMER.MSG.NEXT.EVENTS."):
+		if len(s.pending) == 0 {
+			s.reply(reply, "NATS/1.0 404 No Messages\r\n\r\n", "")
+			return
+		}
+
+		seq := s.pending[0]
+		s.pending = s.pending[1:]
+
+		s.msg(reply, "orders", fmt.Sprintf("$JS.ACK.EVENTS.billing_orders.1.%d.%d.0.0", seq, seq),
+			s.msgs[seq-1].headers, s.msgs[seq-1].data)
+	case strings.HasPrefix(subject, "$JS.ACK.EVENTS."):
+		seq, _ := strconv.Atoi(strings.Split(subject, ".")[5])
+
+		if data == "+ACK" {
+			s.acked = append(s.acked, seq)
+		} else {
+			s.naked = append(s.naked, seq)
+			s.pending = append(s.pending, seq)
+		}
+	default:
+		s.reply(reply, "NATS/1.0 503\r\n\r\n", "")
+	}
+}
+
+// reply sends a reply to a request. It must be called with the lock held.
+func (s *fakeJetStream) reply(reply, headers, data string) {
+	s.msg(reply, reply, "", headers, data)
+}
+
+// msg sends a message with the subject to the subscription of inbox. It must be called with the lock held.
+func (s *fakeJetStream) msg(inbox, subject, reply, headers, data string) {
+	sid, ok := s.subs[inbox]
+	if !ok {
+		sid = s.subs[inbox[:strings.LastIndex(inbox, ".")]+".*"]
+	}
+
+	if reply != "" {
+		sid += " " + reply
+	}
+
+	s.send(fmt.Sprintf("HMSG %s %s %d %d\r\n%s%s\r\n", subject, sid, len(headers), len(headers)+len(data),
+		headers, data))
+}
+
+func (s *fakeJetStream) send(data string) {
+	_, _ = s.w.WriteString(data)
+	_ = s.w.Flush()
+}
+
+func TestNATSBroker(t *testing.T) {
+	t.Parallel()
+
+	var (
+		server      = newFakeJetStream(t)
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		attempts    int
+		received    *cpubsub.Message
+	)
+	defer func() { _ = server.ln.Close() }()
+	defer cancel()
+
+	broker, err := cpubsub.NewNATSBroker(cpubsub.ConfigNATS{
+		URL:     server.url(),
+		Stream:  "EVENTS",
+		AckWait: time.Second,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = broker.Close() }()
+
+	assert.NoError(t, broker.Publish(ctx, "orders", &cpubsub.Message{
+		Key:     "order-1",
+		Data:    []byte("a"),
+		Headers: map[string]string{"Trace-Id": "trace-1"},
+	}))
+
+	err = broker.Publish(ctx, "unknown", &cpubsub.Message{Data: []byte("a")})
+	assert.Error(t, err)
+
+	err = broker.Consume(ctx, "orders", "billing", func(ctx context.Context, msg *cpubsub.Message) error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("test-err")
+		}
+
+		received = msg
+		cancel()
+
+		return nil
+	})
+	assert.NoError(t, err)
+
+	if assert.NotNil(t, received) {
+		assert.Equal(t, "1", received.ID)
+		assert.Equal(t, "orders", received.Topic)
+		assert.Equal(t, "order-1", received.Key)
+		assert.Equal(t, "a", string(received.Data))
+		assert.Equal(t, "trace-1", received.Headers["Trace-Id"])
+	}
+
+	assert.Eventually(t, func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		return len(server.acked) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	assert.Equal(t, []int{1}, server.naked)
+	assert.Equal(t, []int{1}, server.acked)
+}
+
+const (
+	envVarNATSURL    = "CPUBSUB_TEST_NATS_URL"
+	envVarNATSStream = "CPUBSUB_TEST_NATS_STREAM"
+)
+
+// TestNATSBroker_Server runs against a real NATS server. The stream must capture the cpubsub-test.> subjects (ex.
+// nats stream add CPUBSUB_TEST --subjects 'cpubsub-test.>' --defaults).
+func TestNATSBroker_Server(t *testing.T) {
+	t.Parallel()
+
+	url, stream := os.Getenv(envVarNATSURL), os.Getenv(envVarNATSStream)
+	if url == "" || stream == "" {
+		t.Skipf("cpubsub: set %s and %s to run tests against nats", envVarNATSURL, envVarNATSStream)
+	}
+
+	broker, err := cpubsub.NewNATSBroker(cpubsub.ConfigNATS{
+		URL:     url,
+		Stream:  stream,
+		AckWait: time.Second,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = broker.Close() }()
+
+	var (
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+		run         = strconv.FormatInt(time.Now().UnixNano(), 10)
+		topic       = "cpubsub-test." + run
+		attempts    int
+		received    *cpubsub.Message
+	)
+	defer cancel()
+
+	assert.NoError(t, broker.Publish(ctx, topic, &cpubsub.Message{
+		Key:     "order-1",
+		Data:    []byte("a"),
+		Headers: map[string]string{"Trace-Id": "trace-1"},
+	}))
+
+	err = broker.Publish(ctx, "cpubsub-uncaptured."+run, &cpubsub.Message{Data: []byte("a")})
+	assert.Error(t, err)
+
+	// the message that failed is redelivered once it has been nak'ed
+	err = broker.Consume(ctx, topic, "billing"+run, func(ctx context.Context, msg *cpubsub.Message) error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("test-err")
+		}
+
+		received = msg
+		cancel()
+
+		return nil
+	})
+	assert.NoError(t, err)
+
+	if assert.NotNil(t, received) {
+		assert.Equal(t, topic, received.Topic)
+		assert.Equal(t, "order-1", received.Key)
+		assert.Equal(t, "a", string(received.Data))
+		assert.Equal(t, "trace-1", received.Headers["Trace-Id"])
+	}
+}
@@ -0,0 +1,194 @@
+package cpubsub
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+)
+
+// NewPubSubParams holds the params needed for NewPubSub
+type NewPubSubParams struct {
+	Broker    Broker
+	Lifecycle *clifecycle.Lifecycle
+	Config    Config
+	Logger    clogger.Logger
+}
+
+// NewPubSub creates a PubSub that uses the RecoverMiddleware, TracingMiddleware, and LoggingMiddleware
+func NewPubSub(p NewPubSubParams) *PubSub {
+	ps := &PubSub{
+		broker: p.Broker,
+		lc:     p.Lifecycle,
+		config: p.Config,
+		logger: p.Logger.With(clogger.Module("cpubsub")),
+	}
+
+	ps.Use(RecoverMiddleware(), TracingMiddleware(), LoggingMiddleware(p.Logger))
+
+	return ps
+}
+
+// PubSub publishes messages and runs the app's subscriptions using a Broker
+type PubSub struct {
+	broker Broker
+	lc     *clifecycle.Lifecycle
+	config Config
+	logger clogger.Logger
+
+	mu   sync.Mutex
+	mws  []Middleware
+	subs []subscription
+}
+
+type subscription struct {
+	topic   string
+	group   string
+	handler Handler
+}
+
+// Use adds middleware that wraps the handlers of the subscriptions. It must be called before Run.
+func (ps *PubSub) Use(mws ...Middleware) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.mws = append(ps.mws, mws...)
+}
+
+// Subscribe registers h to process the messages of the topic as part of the consumer group. Each message is
+// processed by one instance of each group. Subscriptions are started by Run.
+func (ps *PubSub) Subscribe(topic, group string, h Handler) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.subs = append(ps.subs, subscription{topic: topic, group: group, handler: h})
+}
+
+// Publish publishes the message to the topic. The trace id in the context's log fields is added to the message's
+// headers so that the message can be traced back to the request that published it (see TracingMiddleware).
+func (ps *PubSub) Publish(ctx context.Context, topic string, msg Message) error {
+	if traceID, ok := clogger.FieldsFromCtx(ctx)["traceID"].(string); ok && traceID != "" {
+		if _, exists := msg.Headers[HeaderTraceID]; !exists {
+			msg.Headers = copyHeaders(msg.Headers)
+			if msg.Headers == nil {
+				msg.Headers = make(map[string]string)
+			}
+
+			msg.Headers[HeaderTraceID] = traceID
+		}
+	}
+
+	err := ps.broker.Publish(ctx, topic, &msg)
+	if err != nil {
+		return cerrors.New(err, "failed to publish message", map[string]interface{}{
+			"topic": topic,
+		})
+	}
+
+	return nil
+}
+
+// PublishJSON publishes a message with the JSON encoding of v to the topic
+func (ps *PubSub) PublishJSON(ctx context.Context, topic string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return cerrors.New(err, "failed to encode message", map[string]interface{}{
+			"topic": topic,
+		})
+	}
+
+	return ps.Publish(ctx, topic, Message{Data: data})
+}
+
+// Run starts Config.Concurrency consumers for each subscription in the background. Consumers reconnect after broker
+// errors. When the app's lifecycle stops, the consumers stop receiving messages and wait for the ones being processed
+// to finish. Handlers that are still running when the stop deadline expires are canceled.
+func (ps *PubSub) Run() error {
+	ps.mu.Lock()
+	subs := append([]subscription(nil), ps.subs...)
+	mws := append([]Middleware(nil), ps.mws...)
+	ps.mu.Unlock()
+
+	var (
+		consumeCtx, stopConsuming = context.WithCancel(context.Background())
+		handlerCtx, cancelHandler = context.WithCancel(context.Background())
+		consumers                 sync.WaitGroup
+	)
+
+	for _, sub := range subs {
+		// handlers get their own context so that they can finish while the consumers stop
+		var (
+			sub = sub
+			h   = chain(sub.handler, mws)
+		)
+
+		handler := func(_ context.Context, msg *Message) error {
+			return h(handlerCtx, msg)
+		}
+
+		for i := 0; i < ps.config.Concurrency; i++ {
+			consumers.Add(1)
+
+			go func() {
+				defer consumers.Done()
+				ps.consume(consumeCtx, sub, handler)
+			}()
+		}
+	}
+
+	ps.lc.OnStop(func(stopCtx context.Context) error {
+		ps.logger.Info("Stopping pub/sub consumers..")
+
+		stopConsuming()
+
+		done := make(chan struct{})
+
+		go func() {
+			consumers.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			cancelHandler()
+			return nil
+		case <-stopCtx.Done():
+			cancelHandler()
+			return cerrors.New(stopCtx.Err(), "messages were still being processed when the consumers stopped", nil)
+		}
+	})
+
+	return nil
+}
+
+// Stage implements clifecycle.Staged. The consumers start once the migrations have run and the servers are listening.
+func (ps *PubSub) Stage() clifecycle.Stage {
+	return clifecycle.Stage{
+		Name:  clifecycle.StageWorkers,
+		After: []string{clifecycle.StageMigrations, clifecycle.StageSeeds, clifecycle.StageHTTP},
+	}
+}
+
+func (ps *PubSub) consume(ctx context.Context, sub subscription, h Handler) {
+	for {
+		err := ps.broker.Consume(ctx, sub.topic, sub.group, h)
+		if ctx.Err() != nil {
+			return
+		}
+
+		ps.logger.WithTags(map[string]interface{}{
+			"topic": sub.topic,
+			"group": sub.group,
+		}).Error("Consumer stopped; reconnecting", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(ps.config.RetryDelay):
+		}
+	}
+}
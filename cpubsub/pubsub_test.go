@@ -0,0 +1,151 @@
+package cpubsub_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cpubsub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBroker_Groups(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		broker      = cpubsub.NewMemoryBroker()
+		mu          sync.Mutex
+		received    = make(map[string][]string)
+	)
+	defer cancel()
+
+	consume := func(group string) {
+		go func() {
+			_ = broker.Consume(ctx, "orders", group, func(ctx context.Context, msg *cpubsub.Message) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				received[group] = append(received[group], string(msg.Data))
+
+				return nil
+			})
+		}()
+	}
+
+	// two consumers in the billing group share its messages
+	consume("billing")
+	consume("billing")
+	consume("shipping")
+
+	assert.NoError(t, broker.Wait(ctx, "orders", "billing"))
+	assert.NoError(t, broker.Wait(ctx, "orders", "shipping"))
+
+	for _, data := range []string{"a", "b", "c"} {
+		assert.NoError(t, broker.Publish(ctx, "orders", &cpubsub.Message{Data: []byte(data)}))
+	}
+
+	assert.NoError(t, broker.Wait(ctx, "orders", "billing"))
+	assert.NoError(t, broker.Wait(ctx, "orders", "shipping"))
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, received["billing"])
+	assert.Equal(t, []string{"a", "b", "c"}, received["shipping"])
+	assert.Len(t, broker.Published("orders"), 3)
+}
+
+func TestMemoryBroker_Redelivery(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		broker      = cpubsub.NewMemoryBroker()
+		attempts    int32
+	)
+	defer cancel()
+
+	go func() {
+		_ = broker.Consume(ctx, "orders", "billing", func(ctx context.Context, msg *cpubsub.Message) error {
+			if atomic.AddInt32(&attempts, 1) < 3 {
+				return errors.New("test-err")
+			}
+
+			return nil
+		})
+	}()
+
+	assert.NoError(t, broker.Wait(ctx, "orders", "billing"))
+	assert.NoError(t, broker.Publish(ctx, "orders", &cpubsub.Message{Data: []byte("a")}))
+	assert.NoError(t, broker.Wait(ctx, "orders", "billing"))
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestPubSub_Run(t *testing.T) {
+	t.Parallel()
+
+	var (
+		lc     = clifecycle.New()
+		broker = cpubsub.NewMemoryBroker()
+		ps     = cpubsub.NewPubSub(cpubsub.NewPubSubParams{
+			Broker:    broker,
+			Lifecycle: lc,
+			Config:    cpubsub.Config{Concurrency: 2, RetryDelay: time.Millisecond},
+			Logger:    clogger.NewNoop(),
+		})
+		received = make(chan *cpubsub.Message, 1)
+		traceIDs = make(chan interface{}, 1)
+	)
+
+	ps.Subscribe("orders", "billing", func(ctx context.Context, msg *cpubsub.Message) error {
+		var order struct {
+			ID string `json:"id"`
+		}
+
+		assert.NoError(t, msg.Decode(&order))
+		assert.Equal(t, "order-1", order.ID)
+
+		traceIDs <- clogger.FieldsFromCtx(ctx)["traceID"]
+		received <- msg
+
+		return nil
+	})
+
+	assert.NoError(t, ps.Run())
+
+	ctx := clogger.CtxWithFields(context.Background(), map[string]interface{}{"traceID": "trace-1"})
+
+	assert.NoError(t, broker.Wait(ctx, "orders", "billing"))
+	assert.NoError(t, ps.PublishJSON(ctx, "orders", map[string]string{"id": "order-1"}))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "orders", msg.Topic)
+		assert.Equal(t, "trace-1", msg.Headers[cpubsub.HeaderTraceID])
+		assert.Equal(t, "trace-1", <-traceIDs)
+	case <-time.After(time.Second):
+		t.Fatal("message was not received")
+	}
+
+	lc.Stop(clogger.NewNoop())
+}
+
+func TestPubSub_RecoverMiddleware(t *testing.T) {
+	t.Parallel()
+
+	h := cpubsub.RecoverMiddleware()(func(ctx context.Context, msg *cpubsub.Message) error {
+		panic("test-panic")
+	})
+
+	err := h(context.Background(), &cpubsub.Message{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "test-panic")
+	}
+}
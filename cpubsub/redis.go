@@ -0,0 +1,241 @@
+package cpubsub
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/internal/redis"
+)
+
+const (
+	redisBatchSize    = 10
+	redisBlockTimeout = 2 * time.Second
+	redisAckTimeout   = 5 * time.Second
+
+	redisFieldData      = "data"
+	redisFieldKey       = "key"
+	redisHeaderFieldPfx = "h:"
+)
+
+// NewRedisBroker creates a new RedisBroker
+func NewRedisBroker(config ConfigRedis) (*RedisBroker, error) {
+	opts, err := redis.ParseURL(config.URL)
+	if err != nil {
+		return nil, cerrors.New(err, "invalid cpubsub.redis.url", nil)
+	}
+
+	return &RedisBroker{
+		client: redis.New(opts),
+		config: config,
+	}, nil
+}
+
+// RedisBroker is a Broker that uses Redis Streams. Each topic is a stream and each group is a consumer group of the
+// stream. Messages that are not acknowledged within Config.Redis.ClaimIdle are claimed by another consumer of the
+// group. New groups start from the oldest message kept in the stream.
+type RedisBroker struct {
+	client *redis.Client
+	config ConfigRedis
+}
+
+// Publish adds the message to the topic's stream
+func (b *RedisBroker) Publish(ctx context.Context, topic string, msg *Message) error {
+	args := []interface{}{"XADD", b.streamKey(topic)}
+	if b.config.MaxLen > 0 {
+		args = append(args, "MAXLEN", "~", b.config.MaxLen)
+	}
+
+	args = append(args, "*", redisFieldData, msg.Data)
+
+	if msg.Key != "" {
+		args = append(args, redisFieldKey, msg.Key)
+	}
+
+	for k, v := range msg.Headers {
+		args = append(args, redisHeaderFieldPfx+k, v)
+	}
+
+	_, err := b.client.Do(ctx, args...)
+	if err != nil {
+		return cerrors.New(err, "failed to add message to stream", map[string]interface{}{
+			"topic": topic,
+		})
+	}
+
+	return nil
+}
+
+// Consume delivers the messages of the topic to h until ctx is done. See Broker.
+func (b *RedisBroker) Consume(ctx context.Context, topic, group string, h Handler) error {
+	var (
+		stream   = b.streamKey(topic)
+		consumer = group + "-" + randomHex(6) //nolint:gomnd
+	)
+
+	_, err := b.client.Do(ctx, "XGROUP", "CREATE", stream, group, "0", "MKSTREAM")
+
+	var redisErr redis.Error
+	if err != nil && !(errors.As(err, &redisErr) && strings.HasPrefix(string(redisErr), "BUSYGROUP")) {
+		return cerrors.New(err, "failed to create consumer group", map[string]interface{}{
+			"topic": topic,
+			"group": group,
+		})
+	}
+
+	// blocking reads hold their connection so they do not use the pool's
+	conn, err := b.client.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer b.client.Release(conn)
+
+	for ctx.Err() == nil {
+		// messages that another consumer did not acknowledge in time are processed first
+		reply, err := conn.Do(ctx, "XAUTOCLAIM", stream, group, consumer, b.config.ClaimIdle.Milliseconds(), "0-0",
+			"COUNT", redisBatchSize)
+		if err != nil {
+			return b.consumeErr(ctx, err, "failed to claim idle messages")
+		}
+
+		msgs, err := parseRedisXAutoClaim(topic, reply)
+		if err != nil {
+			return err
+		}
+
+		if len(msgs) == 0 {
+			reply, err = conn.Do(ctx, "XREADGROUP", "GROUP", group, consumer, "COUNT", redisBatchSize,
+				"BLOCK", redisBlockTimeout.Milliseconds(), "STREAMS", stream, ">")
+			if err != nil {
+				return b.consumeErr(ctx, err, "failed to read messages")
+			}
+
+			msgs, err = parseRedisXRead(topic, reply)
+			if err != nil {
+				return err
+			}
+		}
+
+		for i := range msgs {
+			if h(ctx, &msgs[i]) != nil {
+				continue
+			}
+
+			ackCtx, cancel := context.WithTimeout(context.Background(), redisAckTimeout)
+			_, err = b.client.Do(ackCtx, "XACK", stream, group, msgs[i].ID)
+			cancel()
+
+			if err != nil {
+				return cerrors.New(err, "failed to acknowledge message", map[string]interface{}{
+					"topic": topic,
+					"id":    msgs[i].ID,
+				})
+			}
+		}
+	}
+
+	return nil
+}
+
+// Close closes the connections to Redis
+func (b *RedisBroker) Close() error {
+	return b.client.Close()
+}
+
+func (b *RedisBroker) consumeErr(ctx context.Context, err error, msg string) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return cerrors.New(err, msg, nil)
+}
+
+func (b *RedisBroker) streamKey(topic string) string {
+	return b.config.Prefix + topic
+}
+
+// parseRedisXRead parses the reply of XREADGROUP: [[stream, [[id, [field, value, ..]], ..]]]
+func parseRedisXRead(topic string, reply interface{}) ([]Message, error) {
+	streams, ok := reply.([]interface{})
+	if !ok || len(streams) == 0 {
+		return nil, nil
+	}
+
+	stream, ok := streams[0].([]interface{})
+	if !ok || len(stream) != 2 { //nolint:gomnd
+		return nil, cerrors.New(nil, "unexpected XREADGROUP reply", nil)
+	}
+
+	return parseRedisEntries(topic, stream[1])
+}
+
+// parseRedisXAutoClaim parses the reply of XAUTOCLAIM: [next-id, [[id, [field, value, ..]], ..], ..]
+func parseRedisXAutoClaim(topic string, reply interface{}) ([]Message, error) {
+	arr, ok := reply.([]interface{})
+	if !ok || len(arr) < 2 { //nolint:gomnd
+		return nil, cerrors.New(nil, "unexpected XAUTOCLAIM reply", nil)
+	}
+
+	return parseRedisEntries(topic, arr[1])
+}
+
+func parseRedisEntries(topic string, reply interface{}) ([]Message, error) {
+	entries, ok := reply.([]interface{})
+	if !ok {
+		return nil, cerrors.New(nil, "unexpected stream entries reply", nil)
+	}
+
+	msgs := make([]Message, 0, len(entries))
+
+	for _, e := range entries {
+		entry, ok := e.([]interface{})
+		if !ok || len(entry) != 2 { //nolint:gomnd
+			return nil, cerrors.New(nil, "unexpected stream entry reply", nil)
+		}
+
+		id, err := redis.String(entry[0], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		// entries that were deleted (ex. trimmed) while they were pending have no fields
+		fields, err := redis.Strings(entry[1], nil)
+		if errors.Is(err, redis.ErrNil) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		msg := Message{ID: id, Topic: topic}
+
+		for i := 0; i+1 < len(fields); i += 2 {
+			switch k, v := fields[i], fields[i+1]; {
+			case k == redisFieldData:
+				msg.Data = []byte(v)
+			case k == redisFieldKey:
+				msg.Key = v
+			case strings.HasPrefix(k, redisHeaderFieldPfx):
+				if msg.Headers == nil {
+					msg.Headers = make(map[string]string)
+				}
+
+				msg.Headers[strings.TrimPrefix(k, redisHeaderFieldPfx)] = v
+			}
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	return msgs, nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
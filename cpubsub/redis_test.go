@@ -0,0 +1,67 @@
+package cpubsub_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/cpubsub"
+	"github.com/stretchr/testify/assert"
+)
+
+const envVarRedisURL = "CPUBSUB_TEST_REDIS_URL"
+
+func TestRedisBroker(t *testing.T) {
+	t.Parallel()
+
+	url := os.Getenv(envVarRedisURL)
+	if url == "" {
+		t.Skipf("cpubsub: set %s to run tests against redis", envVarRedisURL)
+	}
+
+	broker, err := cpubsub.NewRedisBroker(cpubsub.ConfigRedis{
+		URL:       url,
+		Prefix:    "cpubsub-test:" + time.Now().Format("150405.000000") + ":",
+		MaxLen:    100,
+		ClaimIdle: 100 * time.Millisecond,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = broker.Close() }()
+
+	var (
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+		attempts    int
+		received    *cpubsub.Message
+	)
+	defer cancel()
+
+	assert.NoError(t, broker.Publish(ctx, "orders", &cpubsub.Message{
+		Key:     "order-1",
+		Data:    []byte("a"),
+		Headers: map[string]string{"Trace-Id": "trace-1"},
+	}))
+
+	// the message that failed is claimed again once it has been idle for ClaimIdle
+	err = broker.Consume(ctx, "orders", "billing", func(ctx context.Context, msg *cpubsub.Message) error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("test-err")
+		}
+
+		received = msg
+		cancel()
+
+		return nil
+	})
+	assert.NoError(t, err)
+
+	if assert.NotNil(t, received) {
+		assert.Equal(t, "order-1", received.Key)
+		assert.Equal(t, "a", string(received.Data))
+		assert.Equal(t, "trace-1", received.Headers["Trace-Id"])
+	}
+}
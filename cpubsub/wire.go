@@ -0,0 +1,14 @@
+package cpubsub
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+
+	NewBroker,
+	wire.Struct(new(NewBrokerParams), "*"),
+
+	NewPubSub,
+	wire.Struct(new(NewPubSubParams), "*"),
+)
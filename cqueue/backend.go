@@ -0,0 +1,62 @@
+package cqueue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/csql"
+)
+
+// ErrNotFound is returned when a job does not exist
+var ErrNotFound = errors.New("not found")
+
+// Backend stores jobs. Implementations must make sure that a job is only claimed by one worker at a time.
+type Backend interface {
+	// Enqueue saves a new pending job
+	Enqueue(ctx context.Context, job *Job) error
+
+	// Claim marks up to limit jobs of the queue that are due as running until leaseUntil, increments their attempts,
+	// and returns them. Running jobs whose lease expired are claimed again.
+	Claim(ctx context.Context, queue string, now, leaseUntil time.Time, limit int) ([]Job, error)
+
+	// Update saves the status, attempts, run time, and error of a job
+	Update(ctx context.Context, job *Job) error
+
+	// Delete removes a job once it succeeded
+	Delete(ctx context.Context, job *Job) error
+
+	// Get returns the job with the given id or ErrNotFound
+	Get(ctx context.Context, id string) (*Job, error)
+
+	// ListDead returns up to limit dead jobs of the queue, most recent first
+	ListDead(ctx context.Context, queue string, limit int) ([]Job, error)
+}
+
+// NewBackendParams holds the params needed for NewBackend
+type NewBackendParams struct {
+	DB        *sql.DB
+	Querier   csql.Querier
+	SQLConfig csql.Config
+	Config    Config
+}
+
+// NewBackend creates the Backend configured by Config.Backend
+func NewBackend(p NewBackendParams) (Backend, error) {
+	switch p.Config.Backend {
+	case BackendSQL:
+		return NewSQLBackend(NewSQLBackendParams{
+			DB:        p.DB,
+			Querier:   p.Querier,
+			SQLConfig: p.SQLConfig,
+		}), nil
+	case BackendRedis:
+		return NewRedisBackend(p.Config.Redis)
+	default:
+		return nil, cerrors.New(nil, "unknown cqueue backend", map[string]interface{}{
+			"backend": p.Config.Backend,
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package cqueue
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Backends supported by NewBackend
+const (
+	BackendSQL   = "sql"
+	BackendRedis = "redis"
+)
+
+// DefaultQueue is the queue used by jobs that do not set one
+const DefaultQueue = "default"
+
+const (
+	defaultConcurrency  = 10
+	defaultMaxAttempts  = 25
+	defaultBaseBackoff  = 10 * time.Second
+	defaultMaxBackoff   = 6 * time.Hour
+	defaultPollInterval = time.Second
+	defaultJobTimeout   = 5 * time.Minute
+	defaultRedisPrefix  = "cqueue:"
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "cqueue",
+		Description: "cqueue configures the background job queues and their workers",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("cqueue", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load cqueue config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Backend: BackendSQL,
+		Queues: map[string]ConfigQueue{
+			DefaultQueue: {Concurrency: defaultConcurrency},
+		},
+		MaxAttempts:  defaultMaxAttempts,
+		BaseBackoff:  defaultBaseBackoff,
+		MaxBackoff:   defaultMaxBackoff,
+		PollInterval: defaultPollInterval,
+		JobTimeout:   defaultJobTimeout,
+		Redis: ConfigRedis{
+			Prefix: defaultRedisPrefix,
+		},
+	}
+}
+
+// Config configures the job queues. For example:
+//
+//	[cqueue]
+//	backend = "redis"
+//	max_attempts = 10
+//
+//	[cqueue.queues.default]
+//	concurrency = 10
+//
+//	[cqueue.queues.emails]
+//	concurrency = 2
+//
+//	[cqueue.redis]
+//	url = "redis://localhost:6379/0"
+type Config struct {
+	// Backend is where jobs are stored: sql (the app's database) or redis
+	Backend string `toml:"backend" valid:"in(sql|redis)" doc:"sql or redis"`
+
+	// Queues are the queues processed by the Worker by name
+	Queues map[string]ConfigQueue `toml:"queues"`
+
+	// MaxAttempts is the number of times a job is run before it is dead-lettered, unless the job sets its own
+	MaxAttempts int `toml:"max_attempts" doc:"Number of times a job is run before it is dead-lettered"`
+
+	// BaseBackoff is the wait time after the first failed attempt. It doubles after each failed attempt up to
+	// MaxBackoff.
+	BaseBackoff time.Duration `toml:"base_backoff" doc:"Wait time after the first failed attempt"`
+	MaxBackoff  time.Duration `toml:"max_backoff" doc:"Max wait time between attempts"`
+
+	// PollInterval is how often the Worker checks for jobs that are due when its queues are idle
+	PollInterval time.Duration `toml:"poll_interval" doc:"How often idle queues are checked for jobs"`
+
+	// JobTimeout is the max duration of a single attempt. A job that is still running after JobTimeout (ex. because
+	// its worker crashed) is picked up again.
+	JobTimeout time.Duration `toml:"job_timeout" doc:"Max duration of a single attempt"`
+
+	Redis ConfigRedis `toml:"redis"`
+}
+
+// ConfigQueue configures a queue
+type ConfigQueue struct {
+	// Concurrency is the max number of the queue's jobs that run at the same time
+	Concurrency int `toml:"concurrency"`
+}
+
+// ConfigRedis configures the Redis backend
+type ConfigRedis struct {
+	// URL of the Redis server (ex. redis://:password@localhost:6379/0, or rediss:// for TLS)
+	URL string `toml:"url"`
+
+	// Prefix is prepended to the keys used by the backend
+	Prefix string `toml:"prefix"`
+}
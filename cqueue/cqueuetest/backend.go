@@ -0,0 +1,217 @@
+package cqueuetest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cqueue"
+)
+
+// NewBackend creates an empty Backend
+func NewBackend() *Backend {
+	return &Backend{}
+}
+
+// Backend is a cqueue.Backend that keeps jobs in memory. Use it as the queue's backend in tests:
+//
+//	backend := cqueuetest.NewBackend()
+//	queue := cqueue.NewQueue(cqueue.NewQueueParams{Backend: backend, Config: config})
+//
+//	// handle a request that enqueues a job..
+//
+//	jobs := backend.JobsOfType("send_welcome_email")
+//
+// Jobs can be run using cqueue.Worker's ProcessPending with the same backend.
+type Backend struct {
+	mu   sync.Mutex
+	jobs []cqueue.Job
+	err  error
+}
+
+// SetErr makes the backend's methods return err (ex. to test how a handler deals with a queue outage). Pass nil to
+// make them succeed again.
+func (b *Backend) SetErr(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.err = err
+}
+
+// Jobs returns the stored jobs in the order they were enqueued. Jobs that succeeded are deleted.
+func (b *Backend) Jobs() []cqueue.Job {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]cqueue.Job(nil), b.jobs...)
+}
+
+// JobsOfType returns the stored jobs of the given type in the order they were enqueued
+func (b *Backend) JobsOfType(jobType string) []cqueue.Job {
+	var jobs []cqueue.Job
+
+	for _, job := range b.Jobs() {
+		if job.Type == jobType {
+			jobs = append(jobs, job)
+		}
+	}
+
+	return jobs
+}
+
+// Reset removes all jobs
+func (b *Backend) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.jobs = nil
+}
+
+// Enqueue saves a new pending job
+func (b *Backend) Enqueue(ctx context.Context, job *cqueue.Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return b.err
+	}
+
+	b.jobs = append(b.jobs, *job)
+
+	return nil
+}
+
+// Claim marks up to limit jobs of the queue that are due as running and returns them. See cqueue.Backend.
+func (b *Backend) Claim(ctx context.Context, queue string, now, leaseUntil time.Time, limit int) ([]cqueue.Job,
+	error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	var due []int
+
+	for i, job := range b.jobs {
+		claimable := job.Status == cqueue.JobStatusPending || job.Status == cqueue.JobStatusRunning
+		if job.Queue == queue && claimable && !job.RunAt.After(now) {
+			due = append(due, i)
+		}
+	}
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return b.jobs[due[i]].RunAt.Before(b.jobs[due[j]].RunAt)
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]cqueue.Job, 0, len(due))
+
+	for _, i := range due {
+		b.jobs[i].Status = cqueue.JobStatusRunning
+		b.jobs[i].Attempts++
+		b.jobs[i].RunAt = leaseUntil
+		b.jobs[i].UpdatedAt = now
+
+		claimed = append(claimed, b.jobs[i])
+	}
+
+	return claimed, nil
+}
+
+// Update saves the status, attempts, run time, and error of a job
+func (b *Backend) Update(ctx context.Context, job *cqueue.Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return b.err
+	}
+
+	if i := b.index(job.ID); i != -1 {
+		b.jobs[i].Status = job.Status
+		b.jobs[i].Attempts = job.Attempts
+		b.jobs[i].RunAt = job.RunAt
+		b.jobs[i].LastError = job.LastError
+		b.jobs[i].UpdatedAt = job.UpdatedAt
+	}
+
+	return nil
+}
+
+// Delete removes a job
+func (b *Backend) Delete(ctx context.Context, job *cqueue.Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return b.err
+	}
+
+	if i := b.index(job.ID); i != -1 {
+		b.jobs = append(b.jobs[:i], b.jobs[i+1:]...)
+	}
+
+	return nil
+}
+
+// Get returns the job with the given id or cqueue.ErrNotFound
+func (b *Backend) Get(ctx context.Context, id string) (*cqueue.Job, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	i := b.index(id)
+	if i == -1 {
+		return nil, cqueue.ErrNotFound
+	}
+
+	job := b.jobs[i]
+
+	return &job, nil
+}
+
+// ListDead returns up to limit dead jobs of the queue, most recent first
+func (b *Backend) ListDead(ctx context.Context, queue string, limit int) ([]cqueue.Job, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	var dead []cqueue.Job
+
+	for _, job := range b.jobs {
+		if job.Queue == queue && job.Status == cqueue.JobStatusDead {
+			dead = append(dead, job)
+		}
+	}
+
+	sort.SliceStable(dead, func(i, j int) bool {
+		return dead[i].UpdatedAt.After(dead[j].UpdatedAt)
+	})
+
+	if len(dead) > limit {
+		dead = dead[:limit]
+	}
+
+	return dead, nil
+}
+
+func (b *Backend) index(id string) int {
+	for i := range b.jobs {
+		if b.jobs[i].ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,90 @@
+package cqueuetest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cqueue"
+	"github.com/gocopper/copper/cqueue/cqueuetest"
+	"github.com/stretchr/testify/assert"
+)
+
+type sendEmail struct {
+	To string
+}
+
+func TestBackend(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		backend = cqueuetest.NewBackend()
+		config  = cqueue.Config{
+			Queues:      map[string]cqueue.ConfigQueue{cqueue.DefaultQueue: {Concurrency: 2}},
+			MaxAttempts: 1,
+			JobTimeout:  time.Minute,
+		}
+		queue  = cqueue.NewQueue(cqueue.NewQueueParams{Backend: backend, Config: config})
+		worker = cqueue.NewWorker(cqueue.NewWorkerParams{
+			Queue:     queue,
+			Backend:   backend,
+			Lifecycle: clifecycle.New(),
+			Config:    config,
+			Logger:    clogger.NewNoop(),
+		})
+		sent []string
+	)
+
+	cqueue.Register(queue, "send_email", func(ctx context.Context, p sendEmail) error {
+		if p.To == "bob@example.com" {
+			return errors.New("test-err")
+		}
+
+		sent = append(sent, p.To)
+
+		return nil
+	})
+
+	_, err := queue.Enqueue(ctx, "send_email", sendEmail{To: "alice@example.com"})
+	assert.NoError(t, err)
+
+	bobJob, err := queue.Enqueue(ctx, "send_email", sendEmail{To: "bob@example.com"})
+	assert.NoError(t, err)
+
+	_, err = queue.Enqueue(ctx, "other", nil)
+	assert.NoError(t, err)
+
+	jobs := backend.JobsOfType("send_email")
+	if assert.Len(t, jobs, 2) {
+		var p sendEmail
+
+		assert.NoError(t, jobs[0].Decode(&p))
+		assert.Equal(t, "alice@example.com", p.To)
+	}
+
+	n, err := worker.ProcessPending(ctx, cqueue.DefaultQueue)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"alice@example.com"}, sent)
+
+	dead, err := queue.DeadJobs(ctx, cqueue.DefaultQueue, 10)
+	assert.NoError(t, err)
+
+	if assert.Len(t, dead, 1) {
+		assert.Equal(t, bobJob.ID, dead[0].ID)
+		assert.Equal(t, "test-err", dead[0].LastError)
+	}
+
+	_, err = queue.Get(ctx, "unknown")
+	assert.True(t, errors.Is(err, cqueue.ErrNotFound))
+
+	errTest := errors.New("backend-err")
+	backend.SetErr(errTest)
+
+	_, err = queue.Enqueue(ctx, "send_email", sendEmail{To: "carol@example.com"})
+	assert.True(t, errors.Is(err, errTest))
+}
@@ -0,0 +1,5 @@
+// Package cqueuetest provides an in-memory cqueue.Backend so that code that enqueues jobs can be unit tested without
+// a database or Redis. Along with cmailertest and cstoragetest, it is one of the fakes for copper's core interfaces.
+// There are no fakes for users or auth middleware because this tree has no cauth module; apps that bring their own
+// auth provide the fakes for it.
+package cqueuetest
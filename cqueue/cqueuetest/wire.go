@@ -0,0 +1,12 @@
+package cqueuetest
+
+import (
+	"github.com/gocopper/copper/cqueue"
+	"github.com/google/wire"
+)
+
+// WireModule provides a Backend as the app's cqueue.Backend. Use it in place of cqueue.NewBackend in test builds.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	NewBackend,
+	wire.Bind(new(cqueue.Backend), new(*Backend)),
+)
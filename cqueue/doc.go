@@ -0,0 +1,5 @@
+// Package cqueue runs durable background jobs. Jobs have a type and a JSON payload, and are run by a Worker with
+// per-queue concurrency limits. Failed jobs are retried with exponential backoff and dead-lettered once they run out
+// of attempts. Jobs can be delayed or scheduled to run at a given time. They are stored in the app's database or in
+// Redis (see Config.Backend).
+package cqueue
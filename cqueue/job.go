@@ -0,0 +1,57 @@
+package cqueue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Job statuses. Jobs that succeed are deleted.
+const (
+	JobStatusPending = "pending"
+	JobStatusRunning = "running"
+	JobStatusDead    = "dead"
+)
+
+// Job is a unit of background work. Its payload is the JSON encoding of the value it was enqueued with.
+type Job struct {
+	ID          string `db:"id" json:"id"`
+	Queue       string `db:"queue" json:"queue"`
+	Type        string `db:"type" json:"type"`
+	Payload     string `db:"payload" json:"payload"`
+	Status      string `db:"status" json:"status"`
+	Attempts    int    `db:"attempts" json:"attempts"`
+	MaxAttempts int    `db:"max_attempts" json:"max_attempts"`
+
+	// RunAt is when a pending job is due. While the job is running, it is when its attempt times out.
+	RunAt time.Time `db:"run_at" json:"run_at"`
+
+	LastError string    `db:"last_error" json:"last_error"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Decode unmarshals the job's payload into dest
+func (j *Job) Decode(dest interface{}) error {
+	err := json.Unmarshal([]byte(j.Payload), dest)
+	if err != nil {
+		return cerrors.New(err, "failed to decode job payload", map[string]interface{}{
+			"id":   j.ID,
+			"type": j.Type,
+		})
+	}
+
+	return nil
+}
+
+func newID() string {
+	const idBytes = 16
+
+	b := make([]byte, idBytes)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
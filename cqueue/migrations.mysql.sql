@@ -0,0 +1,19 @@
+-- +migrate Up
+create table cqueue_jobs (
+    id varchar(64) primary key,
+    queue varchar(255) not null,
+    type varchar(255) not null,
+    payload mediumtext not null,
+    status varchar(32) not null,
+    attempts integer not null default 0,
+    max_attempts integer not null,
+    run_at datetime(6) not null,
+    last_error text not null,
+    created_at datetime(6) not null,
+    updated_at datetime(6) not null
+);
+
+create index cqueue_jobs_due_idx on cqueue_jobs (queue, status, run_at);
+
+-- +migrate Down
+drop table cqueue_jobs;
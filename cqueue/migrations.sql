@@ -0,0 +1,19 @@
+-- +migrate Up
+create table cqueue_jobs (
+    id text primary key,
+    queue text not null,
+    type text not null,
+    payload text not null,
+    status text not null,
+    attempts integer not null default 0,
+    max_attempts integer not null,
+    run_at timestamp not null,
+    last_error text not null default '',
+    created_at timestamp not null,
+    updated_at timestamp not null
+);
+
+create index cqueue_jobs_due_idx on cqueue_jobs (queue, status, run_at);
+
+-- +migrate Down
+drop table cqueue_jobs;
@@ -0,0 +1,180 @@
+package cqueue
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Handler runs a job. If it returns an error, the job is retried later.
+type Handler func(ctx context.Context, job *Job) error
+
+// Register registers fn as the handler of the jobs of the given type. The payload of each job is decoded into a T
+// before fn is called. For example:
+//
+//	type SendWelcomeEmail struct {
+//		UserID string
+//	}
+//
+//	cqueue.Register(queue, "send_welcome_email", func(ctx context.Context, p SendWelcomeEmail) error {
+//		..
+//	})
+//
+//	_, err := queue.Enqueue(ctx, "send_welcome_email", SendWelcomeEmail{UserID: user.ID})
+func Register[T any](q *Queue, jobType string, fn func(ctx context.Context, payload T) error) {
+	q.Handle(jobType, func(ctx context.Context, job *Job) error {
+		var payload T
+
+		err := job.Decode(&payload)
+		if err != nil {
+			return err
+		}
+
+		return fn(ctx, payload)
+	})
+}
+
+// EnqueueOptions configures a job enqueued using Queue.EnqueueWithOptions
+type EnqueueOptions struct {
+	// Queue the job is added to (default: DefaultQueue)
+	Queue string
+
+	// RunAt schedules the job to run at the given time
+	RunAt time.Time
+
+	// Delay schedules the job to run after the given duration. It is ignored if RunAt is set.
+	Delay time.Duration
+
+	// MaxAttempts is the number of times the job is run before it is dead-lettered (default: cqueue.max_attempts)
+	MaxAttempts int
+}
+
+// NewQueueParams holds the params needed for NewQueue
+type NewQueueParams struct {
+	Backend Backend
+	Config  Config
+}
+
+// NewQueue creates a new Queue
+func NewQueue(p NewQueueParams) *Queue {
+	return &Queue{
+		backend:  p.Backend,
+		config:   p.Config,
+		handlers: make(map[string]Handler),
+		now:      time.Now,
+	}
+}
+
+// Queue enqueues jobs and holds the handlers that run them. Jobs are run by a Worker.
+type Queue struct {
+	backend Backend
+	config  Config
+	now     func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+// Handle registers the handler of the jobs of the given type. See Register for handlers with typed payloads.
+func (q *Queue) Handle(jobType string, h Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.handlers[jobType] = h
+}
+
+// Enqueue adds a job of the given type to the default queue. The payload is encoded as JSON.
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload interface{}) (*Job, error) {
+	return q.EnqueueWithOptions(ctx, jobType, payload, EnqueueOptions{})
+}
+
+// EnqueueWithOptions adds a job of the given type. The payload is encoded as JSON.
+func (q *Queue) EnqueueWithOptions(ctx context.Context, jobType string, payload interface{},
+	opts EnqueueOptions) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to encode job payload", map[string]interface{}{
+			"type": jobType,
+		})
+	}
+
+	now := q.now()
+
+	job := Job{
+		ID:          newID(),
+		Queue:       opts.Queue,
+		Type:        jobType,
+		Payload:     string(data),
+		Status:      JobStatusPending,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       opts.RunAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if job.Queue == "" {
+		job.Queue = DefaultQueue
+	}
+
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = q.config.MaxAttempts
+	}
+
+	if job.RunAt.IsZero() {
+		job.RunAt = now.Add(opts.Delay)
+	}
+
+	err = q.backend.Enqueue(ctx, &job)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to enqueue job", map[string]interface{}{
+			"type":  jobType,
+			"queue": job.Queue,
+		})
+	}
+
+	return &job, nil
+}
+
+// Get returns the job with the given id. Jobs that succeeded are deleted so ErrNotFound is returned for them.
+func (q *Queue) Get(ctx context.Context, id string) (*Job, error) {
+	return q.backend.Get(ctx, id)
+}
+
+// DeadJobs returns up to limit jobs of the queue that were dead-lettered, most recent first
+func (q *Queue) DeadJobs(ctx context.Context, queue string, limit int) ([]Job, error) {
+	return q.backend.ListDead(ctx, queue, limit)
+}
+
+// Requeue resets the attempts of a dead job and schedules it to run now
+func (q *Queue) Requeue(ctx context.Context, id string) error {
+	job, err := q.backend.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if job.Status != JobStatusDead {
+		return cerrors.New(nil, "only dead jobs can be requeued", map[string]interface{}{
+			"id":     id,
+			"status": job.Status,
+		})
+	}
+
+	job.Status = JobStatusPending
+	job.Attempts = 0
+	job.RunAt = q.now()
+	job.UpdatedAt = job.RunAt
+
+	return q.backend.Update(ctx, job)
+}
+
+func (q *Queue) handler(jobType string) (Handler, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	h, ok := q.handlers[jobType]
+
+	return h, ok
+}
@@ -0,0 +1,288 @@
+package cqueue_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cqueue"
+	"github.com/gocopper/copper/csql"
+	"github.com/gocopper/copper/csql/csqltest"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+// envVarRedisURL is the Redis server used to test RedisBackend. The tests are skipped if it is not set.
+const envVarRedisURL = "CQUEUE_TEST_REDIS_URL"
+
+func TestSQLBackend(t *testing.T) {
+	t.Parallel()
+
+	testBackend(t, newSQLBackend(t))
+}
+
+func TestRedisBackend(t *testing.T) {
+	t.Parallel()
+
+	url := os.Getenv(envVarRedisURL)
+	if url == "" {
+		t.Skipf("cqueue: set %s to run tests against redis", envVarRedisURL)
+	}
+
+	backend, err := cqueue.NewRedisBackend(cqueue.ConfigRedis{
+		URL:    url,
+		Prefix: "cqueue_test:" + time.Now().Format(time.RFC3339Nano) + ":",
+	})
+	assert.NoError(t, err)
+
+	defer func() { _ = backend.Close() }()
+
+	testBackend(t, backend)
+}
+
+func TestQueue_EnqueueWithOptions(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		backend = newSQLBackend(t)
+		queue   = cqueue.NewQueue(cqueue.NewQueueParams{Backend: backend, Config: testConfig()})
+		runAt   = time.Now().Add(time.Hour).Truncate(time.Second)
+	)
+
+	job, err := queue.EnqueueWithOptions(ctx, "report", map[string]int{"month": 3}, cqueue.EnqueueOptions{
+		Queue:       "reports",
+		RunAt:       runAt,
+		MaxAttempts: 2,
+	})
+	assert.NoError(t, err)
+
+	saved, err := queue.Get(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "reports", saved.Queue)
+	assert.Equal(t, `{"month":3}`, saved.Payload)
+	assert.Equal(t, cqueue.JobStatusPending, saved.Status)
+	assert.Equal(t, 2, saved.MaxAttempts)
+	assert.True(t, runAt.Equal(saved.RunAt))
+
+	// scheduled jobs are not claimed before they are due
+	jobs, err := backend.Claim(ctx, "reports", time.Now(), time.Now().Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	job, err = queue.Enqueue(ctx, "report", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, cqueue.DefaultQueue, job.Queue)
+	assert.Equal(t, testConfig().MaxAttempts, job.MaxAttempts)
+}
+
+func TestQueue_EnqueueInTx(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db, backend = newSQLDB(t)
+		queue       = cqueue.NewQueue(cqueue.NewQueueParams{Backend: backend, Config: testConfig()})
+	)
+
+	ctx, tx, err := csql.CtxWithTx(context.Background(), db, "sqlite3")
+	assert.NoError(t, err)
+
+	job, err := queue.Enqueue(ctx, "welcome", nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, tx.Rollback())
+
+	_, err = queue.Get(context.Background(), job.ID)
+	assert.ErrorIs(t, err, cqueue.ErrNotFound)
+}
+
+func TestWorker_ProcessPending(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		config  = testConfig()
+		backend = newSQLBackend(t)
+		queue   = cqueue.NewQueue(cqueue.NewQueueParams{Backend: backend, Config: config})
+		worker  = cqueue.NewWorker(cqueue.NewWorkerParams{
+			Queue:     queue,
+			Backend:   backend,
+			Lifecycle: clifecycle.New(),
+			Config:    config,
+			Logger:    clogger.NewNoop(),
+		})
+		sent  []string
+		fails int32 = 1
+	)
+
+	type welcomeEmail struct {
+		UserID string
+	}
+
+	cqueue.Register(queue, "welcome", func(ctx context.Context, p welcomeEmail) error {
+		if atomic.AddInt32(&fails, -1) >= 0 {
+			return errors.New("mailer is down")
+		}
+
+		sent = append(sent, p.UserID)
+
+		return nil
+	})
+
+	cqueue.Register(queue, "broken", func(ctx context.Context, p struct{}) error {
+		panic("oops")
+	})
+
+	welcome, err := queue.Enqueue(ctx, "welcome", welcomeEmail{UserID: "user-1"})
+	assert.NoError(t, err)
+
+	broken, err := queue.EnqueueWithOptions(ctx, "broken", nil, cqueue.EnqueueOptions{MaxAttempts: 1})
+	assert.NoError(t, err)
+
+	n, err := worker.ProcessPending(ctx, cqueue.DefaultQueue)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// the welcome email failed once and is scheduled for a retry
+	job, err := queue.Get(ctx, welcome.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, cqueue.JobStatusPending, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Equal(t, "mailer is down", job.LastError)
+	assert.True(t, job.RunAt.After(time.Now().Add(config.BaseBackoff-time.Second)))
+
+	// the broken job ran out of attempts
+	dead, err := queue.DeadJobs(ctx, cqueue.DefaultQueue, 10)
+	assert.NoError(t, err)
+	assert.Len(t, dead, 1)
+	assert.Equal(t, broken.ID, dead[0].ID)
+	assert.Contains(t, dead[0].LastError, "job panicked: oops")
+
+	// nothing is due until the retry
+	n, err = worker.ProcessPending(ctx, cqueue.DefaultQueue)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	job.RunAt = time.Now().Add(-time.Second)
+	assert.NoError(t, backend.Update(ctx, job))
+
+	n, err = worker.ProcessPending(ctx, cqueue.DefaultQueue)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"user-1"}, sent)
+
+	// succeeded jobs are deleted
+	_, err = queue.Get(ctx, welcome.ID)
+	assert.ErrorIs(t, err, cqueue.ErrNotFound)
+
+	assert.NoError(t, queue.Requeue(ctx, broken.ID))
+	assert.Error(t, queue.Requeue(ctx, broken.ID))
+
+	job, err = queue.Get(ctx, broken.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, cqueue.JobStatusPending, job.Status)
+	assert.Equal(t, 0, job.Attempts)
+}
+
+func testBackend(t *testing.T, backend cqueue.Backend) {
+	t.Helper()
+
+	var (
+		ctx   = context.Background()
+		now   = time.Now().Truncate(time.Millisecond)
+		queue = "q" + time.Now().Format("150405.000000000")
+	)
+
+	for i, runAt := range []time.Time{now.Add(-time.Minute), now.Add(-time.Second), now.Add(time.Minute)} {
+		assert.NoError(t, backend.Enqueue(ctx, &cqueue.Job{
+			ID:          []string{"a", "b", "c"}[i] + queue,
+			Queue:       queue,
+			Type:        "test",
+			Payload:     "{}",
+			Status:      cqueue.JobStatusPending,
+			MaxAttempts: 3,
+			RunAt:       runAt,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}))
+	}
+
+	jobs, err := backend.Claim(ctx, queue, now, now.Add(30*time.Second), 1)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, "a"+queue, jobs[0].ID)
+	assert.Equal(t, cqueue.JobStatusRunning, jobs[0].Status)
+	assert.Equal(t, 1, jobs[0].Attempts)
+
+	// running jobs are not claimed again until their lease expires
+	jobs, err = backend.Claim(ctx, queue, now, now.Add(40*time.Second), 10)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, "b"+queue, jobs[0].ID)
+
+	jobs, err = backend.Claim(ctx, queue, now.Add(2*time.Minute), now.Add(3*time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 3)
+
+	job := jobs[0]
+	assert.Equal(t, "a"+queue, job.ID)
+	assert.Equal(t, 2, job.Attempts)
+
+	job.Status = cqueue.JobStatusDead
+	job.LastError = "failed"
+	assert.NoError(t, backend.Update(ctx, &job))
+
+	dead, err := backend.ListDead(ctx, queue, 10)
+	assert.NoError(t, err)
+	assert.Len(t, dead, 1)
+	assert.Equal(t, "failed", dead[0].LastError)
+
+	// dead jobs are never claimed
+	jobs, err = backend.Claim(ctx, queue, now.Add(time.Hour), now.Add(2*time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 2)
+
+	for i := range jobs {
+		assert.NoError(t, backend.Delete(ctx, &jobs[i]))
+	}
+
+	_, err = backend.Get(ctx, jobs[0].ID)
+	assert.ErrorIs(t, err, cqueue.ErrNotFound)
+}
+
+func testConfig() cqueue.Config {
+	return cqueue.Config{
+		Queues:       map[string]cqueue.ConfigQueue{cqueue.DefaultQueue: {Concurrency: 4}},
+		MaxAttempts:  3,
+		BaseBackoff:  time.Minute,
+		MaxBackoff:   time.Hour,
+		PollInterval: 10 * time.Millisecond,
+		JobTimeout:   time.Minute,
+	}
+}
+
+func newSQLBackend(t *testing.T) *cqueue.SQLBackend {
+	t.Helper()
+
+	_, backend := newSQLDB(t)
+
+	return backend
+}
+
+func newSQLDB(t *testing.T) (*sql.DB, *cqueue.SQLBackend) {
+	t.Helper()
+
+	db := csqltest.NewDB(t, csqltest.Options{Migrations: csql.Migrations(cqueue.Migrations)})
+
+	return db.DB, cqueue.NewSQLBackend(cqueue.NewSQLBackendParams{
+		DB:        db.DB,
+		Querier:   db.Querier,
+		SQLConfig: db.Config,
+	})
+}
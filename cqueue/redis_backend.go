@@ -0,0 +1,237 @@
+package cqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/internal/redis"
+)
+
+// redisClaimScript moves up to ARGV[3] jobs of the queue (KEYS[1]) that are due at ARGV[1] to the lease expiry in
+// ARGV[2] and returns their ids. Pending and running jobs share the queue's sorted set, scored by their run time or
+// lease expiry, so running jobs whose lease expired are claimed again.
+const redisClaimScript = `
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+for _, id in ipairs(ids) do
+	redis.call('ZADD', KEYS[1], ARGV[2], id)
+end
+return ids`
+
+// NewRedisBackend creates a new RedisBackend
+func NewRedisBackend(config ConfigRedis) (*RedisBackend, error) {
+	opts, err := redis.ParseURL(config.URL)
+	if err != nil {
+		return nil, cerrors.New(err, "invalid cqueue.redis.url", nil)
+	}
+
+	return &RedisBackend{
+		client: redis.New(opts),
+		prefix: config.Prefix,
+	}, nil
+}
+
+// RedisBackend stores jobs in Redis. Each job is stored as JSON in its own key. The ids of a queue's pending and
+// running jobs are kept in a sorted set scored by when they are due, and the ids of its dead jobs in another one
+// scored by when they died.
+type RedisBackend struct {
+	client *redis.Client
+	prefix string
+}
+
+// Enqueue saves a new pending job
+func (b *RedisBackend) Enqueue(ctx context.Context, job *Job) error {
+	return b.Update(ctx, job)
+}
+
+// Claim marks up to limit jobs of the queue that are due as running and returns them. See Backend.
+func (b *RedisBackend) Claim(ctx context.Context, queue string, now, leaseUntil time.Time, limit int) ([]Job,
+	error) {
+	ids, err := redis.Strings(b.client.Do(ctx, "EVAL", redisClaimScript, 1, b.queueKey(queue),
+		now.UnixMilli(), leaseUntil.UnixMilli(), limit))
+	if err != nil {
+		return nil, cerrors.New(err, "failed to claim jobs", map[string]interface{}{
+			"queue": queue,
+		})
+	}
+
+	jobs := make([]Job, 0, len(ids))
+
+	for _, id := range ids {
+		job, err := b.Get(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			// the job was deleted after its id was read
+			_, _ = b.client.Do(ctx, "ZREM", b.queueKey(queue), id)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		job.Status = JobStatusRunning
+		job.Attempts++
+		job.RunAt = leaseUntil
+		job.UpdatedAt = now
+
+		err = b.Update(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, *job)
+	}
+
+	return jobs, nil
+}
+
+// Update saves the job and moves it to the dead jobs if its status is dead
+func (b *RedisBackend) Update(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return cerrors.New(err, "failed to marshal job", nil)
+	}
+
+	cmds := [][]interface{}{
+		{"SET", b.jobKey(job.ID), data},
+	}
+
+	if job.Status == JobStatusDead {
+		cmds = append(cmds,
+			[]interface{}{"ZREM", b.queueKey(job.Queue), job.ID},
+			[]interface{}{"ZADD", b.deadKey(job.Queue), job.UpdatedAt.UnixMilli(), job.ID},
+		)
+	} else {
+		cmds = append(cmds,
+			[]interface{}{"ZREM", b.deadKey(job.Queue), job.ID},
+			[]interface{}{"ZADD", b.queueKey(job.Queue), job.RunAt.UnixMilli(), job.ID},
+		)
+	}
+
+	err = b.multi(ctx, cmds)
+	if err != nil {
+		return cerrors.New(err, "failed to save job", map[string]interface{}{
+			"id": job.ID,
+		})
+	}
+
+	return nil
+}
+
+// Delete removes a job
+func (b *RedisBackend) Delete(ctx context.Context, job *Job) error {
+	err := b.multi(ctx, [][]interface{}{
+		{"DEL", b.jobKey(job.ID)},
+		{"ZREM", b.queueKey(job.Queue), job.ID},
+		{"ZREM", b.deadKey(job.Queue), job.ID},
+	})
+	if err != nil {
+		return cerrors.New(err, "failed to delete job", map[string]interface{}{
+			"id": job.ID,
+		})
+	}
+
+	return nil
+}
+
+// Get returns the job with the given id
+func (b *RedisBackend) Get(ctx context.Context, id string) (*Job, error) {
+	data, err := redis.Bytes(b.client.Do(ctx, "GET", b.jobKey(id)))
+	if errors.Is(err, redis.ErrNil) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, cerrors.New(err, "failed to get job", map[string]interface{}{
+			"id": id,
+		})
+	}
+
+	var job Job
+
+	err = json.Unmarshal(data, &job)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to unmarshal job", map[string]interface{}{
+			"id": id,
+		})
+	}
+
+	return &job, nil
+}
+
+// ListDead returns up to limit dead jobs of the queue, most recent first
+func (b *RedisBackend) ListDead(ctx context.Context, queue string, limit int) ([]Job, error) {
+	ids, err := redis.Strings(b.client.Do(ctx, "ZREVRANGE", b.deadKey(queue), 0, limit-1))
+	if err != nil {
+		return nil, cerrors.New(err, "failed to list dead jobs", map[string]interface{}{
+			"queue": queue,
+		})
+	}
+
+	jobs := make([]Job, 0, len(ids))
+
+	for _, id := range ids {
+		job, err := b.Get(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, *job)
+	}
+
+	return jobs, nil
+}
+
+// Close closes the connections to Redis
+func (b *RedisBackend) Close() error {
+	return b.client.Close()
+}
+
+// multi runs the commands atomically in a MULTI/EXEC transaction
+func (b *RedisBackend) multi(ctx context.Context, cmds [][]interface{}) error {
+	conn, err := b.client.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer b.client.Release(conn)
+
+	_, err = conn.Do(ctx, "MULTI")
+	if err != nil {
+		return err
+	}
+
+	for _, cmd := range cmds {
+		_, err = conn.Do(ctx, cmd...)
+		if err != nil {
+			_, _ = conn.Do(ctx, "DISCARD")
+			return err
+		}
+	}
+
+	replies, err := conn.Do(ctx, "EXEC")
+	if err != nil {
+		return err
+	}
+
+	if arr, ok := replies.([]interface{}); ok {
+		for _, reply := range arr {
+			if err, ok := reply.(error); ok {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (b *RedisBackend) jobKey(id string) string {
+	return b.prefix + "job:" + id
+}
+
+func (b *RedisBackend) queueKey(queue string) string {
+	return b.prefix + "queue:" + queue
+}
+
+func (b *RedisBackend) deadKey(queue string) string {
+	return b.prefix + "dead:" + queue
+}
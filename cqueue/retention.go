@@ -0,0 +1,16 @@
+package cqueue
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cretention"
+)
+
+// DeadJobsRetentionPolicy purges the jobs that were dead-lettered more than 30 days ago from SQLBackend. Apps that
+// use the SQL backend can register it using cretention.Register.
+var DeadJobsRetentionPolicy = cretention.Policy{ //nolint:gochecknoglobals
+	Name:        "cqueue_dead_jobs",
+	Description: "Dead-lettered jobs",
+	MaxAge:      30 * 24 * time.Hour, //nolint:gomnd
+	Purge:       cretention.DeleteRows("cqueue_jobs", "id", "status = '"+JobStatusDead+"' and updated_at < :before"),
+}
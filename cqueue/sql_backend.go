@@ -0,0 +1,195 @@
+package cqueue
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/csql"
+)
+
+// Migrations holds the database schema needed by SQLBackend. Register them using csql.RegisterMigrations so that
+// they are applied by csql.Migrator along with the app's migrations:
+//
+//	csql.RegisterMigrations(csql.ModuleMigrations{Module: "cqueue", FS: cqueue.Migrations})
+//
+// MySQL uses the variant in migrations.mysql.sql (see csql.ModuleMigrations).
+//
+//go:embed migrations.sql migrations.mysql.sql
+var Migrations embed.FS
+
+// NewSQLBackendParams holds the params needed for NewSQLBackend
+type NewSQLBackendParams struct {
+	DB        *sql.DB
+	Querier   csql.Querier
+	SQLConfig csql.Config
+}
+
+// NewSQLBackend creates a new SQLBackend
+func NewSQLBackend(p NewSQLBackendParams) *SQLBackend {
+	return &SQLBackend{
+		db:      p.DB,
+		querier: p.Querier,
+		dialect: p.SQLConfig.Dialect,
+	}
+}
+
+// SQLBackend stores jobs in the cqueue_jobs table of the app's database (see Migrations). Jobs enqueued with a
+// transaction in the context (ex. within a request handled by csql.TxMiddleware) are saved in that transaction, so
+// they only run if it commits. Other operations run in their own transaction.
+type SQLBackend struct {
+	db      *sql.DB
+	querier csql.Querier
+	dialect string
+}
+
+// Enqueue saves a new pending job
+func (b *SQLBackend) Enqueue(ctx context.Context, job *Job) error {
+	const query = `
+	insert into cqueue_jobs (id, queue, type, payload, status, attempts, max_attempts, run_at, last_error,
+		created_at, updated_at)
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	return b.inTx(ctx, func(ctx context.Context) error {
+		_, err := b.querier.Exec(ctx, query, job.ID, job.Queue, job.Type, job.Payload, job.Status, job.Attempts,
+			job.MaxAttempts, job.RunAt, job.LastError, job.CreatedAt, job.UpdatedAt)
+
+		return err
+	})
+}
+
+// Claim marks up to limit jobs of the queue that are due as running and returns them. See Backend.
+func (b *SQLBackend) Claim(ctx context.Context, queue string, now, leaseUntil time.Time, limit int) ([]Job,
+	error) {
+	const (
+		claimable = `queue = ? and status in (?, ?) and run_at <= ?`
+
+		selectQuery = `
+		select * from cqueue_jobs
+		where ` + claimable + `
+		order by run_at
+		limit ?`
+
+		claimQuery = `
+		update cqueue_jobs
+		set status = ?, attempts = attempts + 1, run_at = ?, updated_at = ?
+		where id = ? and ` + claimable
+	)
+
+	var claimed []Job
+
+	err := b.inTx(ctx, func(ctx context.Context) error {
+		var due []Job
+
+		err := b.querier.Select(ctx, &due, selectQuery, queue, JobStatusPending, JobStatusRunning, now, limit)
+		if err != nil {
+			return cerrors.New(err, "failed to query due jobs", map[string]interface{}{
+				"queue": queue,
+			})
+		}
+
+		claimed = make([]Job, 0, len(due))
+
+		for i := range due {
+			res, err := b.querier.Exec(ctx, claimQuery, JobStatusRunning, leaseUntil, now, due[i].ID,
+				queue, JobStatusPending, JobStatusRunning, now)
+			if err != nil {
+				return cerrors.New(err, "failed to claim job", map[string]interface{}{
+					"id": due[i].ID,
+				})
+			}
+
+			if n, _ := res.RowsAffected(); n == 1 {
+				due[i].Status = JobStatusRunning
+				due[i].Attempts++
+				due[i].RunAt = leaseUntil
+				due[i].UpdatedAt = now
+				claimed = append(claimed, due[i])
+			}
+		}
+
+		return nil
+	})
+
+	return claimed, err
+}
+
+// Update saves the status, attempts, run time, and error of a job
+func (b *SQLBackend) Update(ctx context.Context, job *Job) error {
+	const query = `
+	update cqueue_jobs
+	set status = ?, attempts = ?, run_at = ?, last_error = ?, updated_at = ?
+	where id = ?`
+
+	return b.inTx(ctx, func(ctx context.Context) error {
+		_, err := b.querier.Exec(ctx, query, job.Status, job.Attempts, job.RunAt, job.LastError, job.UpdatedAt,
+			job.ID)
+
+		return err
+	})
+}
+
+// Delete removes a job
+func (b *SQLBackend) Delete(ctx context.Context, job *Job) error {
+	return b.inTx(ctx, func(ctx context.Context) error {
+		_, err := b.querier.Exec(ctx, `delete from cqueue_jobs where id = ?`, job.ID)
+
+		return err
+	})
+}
+
+// Get returns the job with the given id
+func (b *SQLBackend) Get(ctx context.Context, id string) (*Job, error) {
+	var job Job
+
+	err := b.inTx(ctx, func(ctx context.Context) error {
+		return b.querier.Get(ctx, &job, `select * from cqueue_jobs where id = ?`, id)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// ListDead returns up to limit dead jobs of the queue, most recent first
+func (b *SQLBackend) ListDead(ctx context.Context, queue string, limit int) ([]Job, error) {
+	const query = `
+	select * from cqueue_jobs
+	where queue = ? and status = ?
+	order by updated_at desc
+	limit ?`
+
+	var jobs []Job
+
+	err := b.inTx(ctx, func(ctx context.Context) error {
+		return b.querier.Select(ctx, &jobs, query, queue, JobStatusDead, limit)
+	})
+
+	return jobs, err
+}
+
+// inTx runs fn in the context's transaction if there is one, or in a new transaction otherwise
+func (b *SQLBackend) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, err := csql.TxFromCtx(ctx); err == nil {
+		return fn(ctx)
+	}
+
+	ctx, tx, err := csql.CtxWithTx(ctx, b.db, b.dialect)
+	if err != nil {
+		return err
+	}
+
+	err = fn(ctx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,16 @@
+package cqueue
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+	NewBackend,
+	wire.Struct(new(NewBackendParams), "*"),
+
+	NewQueue,
+	wire.Struct(new(NewQueueParams), "*"),
+
+	NewWorker,
+	wire.Struct(new(NewWorkerParams), "*"),
+)
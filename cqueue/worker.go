@@ -0,0 +1,301 @@
+package cqueue
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+)
+
+// NewWorkerParams holds the params needed to create a Worker
+type NewWorkerParams struct {
+	Queue     *Queue
+	Backend   Backend
+	Lifecycle *clifecycle.Lifecycle
+	Config    Config
+	Logger    clogger.Logger
+}
+
+// NewWorker creates a new Worker
+func NewWorker(p NewWorkerParams) *Worker {
+	return &Worker{
+		queue:   p.Queue,
+		backend: p.Backend,
+		lc:      p.Lifecycle,
+		config:  p.Config,
+		logger:  p.Logger.With(clogger.Module("cqueue")),
+		now:     time.Now,
+	}
+}
+
+// Worker runs the jobs of the queues in Config.Queues using the handlers registered on the Queue. Each queue runs up
+// to its configured concurrency jobs at the same time. Jobs that fail are retried with exponential backoff until
+// they run out of attempts, after which they are dead-lettered.
+// Jobs are run at least once: a job whose worker stopped in the middle of an attempt is run again once the attempt
+// times out (see Config.JobTimeout), so handlers should be idempotent.
+type Worker struct {
+	queue   *Queue
+	backend Backend
+	lc      *clifecycle.Lifecycle
+	config  Config
+	logger  clogger.Logger
+	now     func() time.Time
+}
+
+// Run starts processing the queues in the background. When the app's lifecycle stops, the worker stops claiming new
+// jobs and waits for the running ones to finish. Jobs that are still running when the stop deadline expires are
+// canceled and retried later.
+func (w *Worker) Run() error {
+	names := w.queueNames()
+
+	for _, name := range names {
+		if w.config.Queues[name].Concurrency <= 0 {
+			return cerrors.New(nil, "queue concurrency must be positive", map[string]interface{}{
+				"queue": name,
+			})
+		}
+	}
+
+	var (
+		pollCtx, stopPolling = context.WithCancel(context.Background())
+		jobsCtx, cancelJobs  = context.WithCancel(context.Background())
+		pollers              sync.WaitGroup
+		running              sync.WaitGroup
+	)
+
+	for _, name := range names {
+		pollers.Add(1)
+
+		go func(name string) {
+			defer pollers.Done()
+			w.poll(pollCtx, jobsCtx, name, w.config.Queues[name].Concurrency, &running)
+		}(name)
+	}
+
+	w.lc.OnStop(func(stopCtx context.Context) error {
+		w.logger.Info("Stopping job worker..")
+
+		stopPolling()
+		pollers.Wait()
+
+		done := make(chan struct{})
+
+		go func() {
+			running.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			cancelJobs()
+			return nil
+		case <-stopCtx.Done():
+			cancelJobs()
+			return cerrors.New(stopCtx.Err(), "jobs were still running when the worker stopped", nil)
+		}
+	})
+
+	return nil
+}
+
+// Stage implements clifecycle.Staged. The worker starts once the migrations have run and the servers are listening.
+func (w *Worker) Stage() clifecycle.Stage {
+	return clifecycle.Stage{
+		Name:  clifecycle.StageWorkers,
+		After: []string{clifecycle.StageMigrations, clifecycle.StageSeeds, clifecycle.StageHTTP},
+	}
+}
+
+// ProcessPending runs up to the queue's concurrency jobs that are due and waits for them to finish. It returns the
+// number of jobs that were run.
+func (w *Worker) ProcessPending(ctx context.Context, queue string) (int, error) {
+	concurrency := w.config.Queues[queue].Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	jobs, err := w.claim(ctx, queue, concurrency)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+
+	for i := range jobs {
+		wg.Add(1)
+
+		go func(job *Job) {
+			defer wg.Done()
+			w.process(ctx, job)
+		}(&jobs[i])
+	}
+
+	wg.Wait()
+
+	return len(jobs), nil
+}
+
+// poll claims the queue's jobs whenever it has free slots. It checks for new jobs every poll interval, or as soon as
+// a slot is freed if the last poll filled all of them.
+func (w *Worker) poll(ctx, jobsCtx context.Context, queue string, concurrency int, running *sync.WaitGroup) {
+	var (
+		slots = make(chan struct{}, concurrency)
+		freed = make(chan struct{}, 1)
+	)
+
+	for {
+		var (
+			free = concurrency - len(slots)
+			busy bool
+		)
+
+		if free > 0 {
+			jobs, err := w.claim(ctx, queue, free)
+			if err != nil && ctx.Err() == nil {
+				w.logger.WithTags(map[string]interface{}{
+					"queue": queue,
+				}).Error("Failed to claim jobs", err)
+			}
+
+			for i := range jobs {
+				slots <- struct{}{}
+				running.Add(1)
+
+				go func(job *Job) {
+					defer func() {
+						<-slots
+						running.Done()
+
+						select {
+						case freed <- struct{}{}:
+						default:
+						}
+					}()
+
+					w.process(jobsCtx, job)
+				}(&jobs[i])
+			}
+
+			busy = len(jobs) == free
+		} else {
+			busy = true
+		}
+
+		var wake <-chan struct{}
+		if busy {
+			wake = freed
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-time.After(w.config.PollInterval):
+		}
+	}
+}
+
+func (w *Worker) claim(ctx context.Context, queue string, limit int) ([]Job, error) {
+	now := w.now()
+
+	jobs, err := w.backend.Claim(ctx, queue, now, now.Add(w.config.JobTimeout), limit)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to claim jobs", map[string]interface{}{
+			"queue": queue,
+		})
+	}
+
+	return jobs, nil
+}
+
+// process runs the job's handler and saves the result. The attempt is canceled when the job's lease expires.
+func (w *Worker) process(ctx context.Context, job *Job) {
+	log := w.logger.WithTags(map[string]interface{}{
+		"jobID":   job.ID,
+		"jobType": job.Type,
+		"queue":   job.Queue,
+		"attempt": job.Attempts,
+	})
+
+	jobCtx, cancel := context.WithDeadline(clogger.CtxWithLogger(ctx, log), job.RunAt)
+	runErr := w.run(jobCtx, job)
+	cancel()
+
+	// the result is saved even if the worker is stopping so that the job is not run again
+	var (
+		saveErr error
+		saveCtx = context.Background()
+	)
+
+	job.UpdatedAt = w.now()
+
+	switch {
+	case runErr == nil:
+		saveErr = w.backend.Delete(saveCtx, job)
+	case job.Attempts >= job.MaxAttempts:
+		job.Status = JobStatusDead
+		job.LastError = runErr.Error()
+
+		log.Error("Job failed; moved to dead letters", runErr)
+
+		saveErr = w.backend.Update(saveCtx, job)
+	default:
+		job.Status = JobStatusPending
+		job.LastError = runErr.Error()
+		job.RunAt = job.UpdatedAt.Add(w.backoff(job.Attempts))
+
+		log.Warn("Job failed; will retry", runErr)
+
+		saveErr = w.backend.Update(saveCtx, job)
+	}
+
+	if saveErr != nil {
+		log.Error("Failed to save job result", saveErr)
+	}
+}
+
+func (w *Worker) run(ctx context.Context, job *Job) (err error) {
+	h, ok := w.queue.handler(job.Type)
+	if !ok {
+		return cerrors.New(nil, "no handler is registered for the job type", map[string]interface{}{
+			"type": job.Type,
+		})
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = cerrors.New(nil, fmt.Sprintf("job panicked: %v", r), nil)
+		}
+	}()
+
+	return h(ctx, job)
+}
+
+func (w *Worker) backoff(attempts int) time.Duration {
+	d := w.config.BaseBackoff
+	for i := 1; i < attempts && d < w.config.MaxBackoff; i++ {
+		d *= 2
+	}
+
+	if d > w.config.MaxBackoff {
+		return w.config.MaxBackoff
+	}
+
+	return d
+}
+
+func (w *Worker) queueNames() []string {
+	names := make([]string, 0, len(w.config.Queues))
+	for name := range w.config.Queues {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
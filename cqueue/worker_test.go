@@ -0,0 +1,74 @@
+package cqueue_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cqueue"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorker_Run(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		config  = testConfig()
+		lc      = clifecycle.New()
+		backend = newSQLBackend(t)
+		queue   = cqueue.NewQueue(cqueue.NewQueueParams{Backend: backend, Config: config})
+		worker  = cqueue.NewWorker(cqueue.NewWorkerParams{
+			Queue:     queue,
+			Backend:   backend,
+			Lifecycle: lc,
+			Config:    config,
+			Logger:    clogger.NewNoop(),
+		})
+		running, maxRunning, done int32
+	)
+
+	cqueue.Register(queue, "slow", func(ctx context.Context, _ struct{}) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for {
+			peak := atomic.LoadInt32(&maxRunning)
+			if n <= peak || atomic.CompareAndSwapInt32(&maxRunning, peak, n) {
+				break
+			}
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&done, 1)
+
+		return nil
+	})
+
+	for i := 0; i < 6; i++ {
+		_, err := queue.Enqueue(ctx, "slow", nil)
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, worker.Run())
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&running) > 0
+	}, time.Second, 5*time.Millisecond)
+
+	// stopping waits for the running jobs to finish
+	lc.Stop(clogger.NewNoop())
+
+	finished := atomic.LoadInt32(&done)
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&running))
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(4))
+	assert.GreaterOrEqual(t, finished, int32(4))
+
+	// the worker no longer runs jobs once stopped
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, finished, atomic.LoadInt32(&done))
+}
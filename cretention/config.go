@@ -0,0 +1,85 @@
+package cretention
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	defaultSchedule   = "0 3 * * *"
+	defaultBatchSize  = 1000
+	defaultMaxBatches = 1000
+	defaultBatchPause = 100 * time.Millisecond
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "cretention",
+		Description: "cretention configures the data retention policies",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("cretention", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load cretention config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Schedule:   defaultSchedule,
+		BatchSize:  defaultBatchSize,
+		MaxBatches: defaultMaxBatches,
+		BatchPause: defaultBatchPause,
+	}
+}
+
+// Config configures the retention policies. Each policy can override the defaults. For example:
+//
+//	[cretention]
+//	schedule = "0 3 * * *"
+//	dry_run = true
+//
+//	[cretention.policies.audit_logs]
+//	max_age = "8760h"
+//	batch_size = 500
+type Config struct {
+	// Disabled stops scheduling the policies
+	Disabled bool `toml:"disabled" doc:"Stop scheduling the policies"`
+
+	// DryRun counts the rows that each policy would purge instead of purging them
+	DryRun bool `toml:"dry_run" doc:"Count the rows that would be purged instead of purging them"`
+
+	// Schedule is the cron expression of the policies that do not set their own (see ccron.ParseSchedule)
+	Schedule string `toml:"schedule" doc:"Cron expression of the policies that do not set their own"`
+
+	// BatchSize is the max number of rows purged by a single statement. Purging in batches keeps transactions and
+	// locks short on large tables.
+	BatchSize int `toml:"batch_size" doc:"Max number of rows purged by a single statement"`
+
+	// MaxBatches is the max number of batches in a run. The remaining rows are purged by the next run.
+	MaxBatches int `toml:"max_batches" doc:"Max number of batches in a run"`
+
+	// BatchPause is the wait time between batches that leaves room for the app's queries
+	BatchPause time.Duration `toml:"batch_pause" doc:"Wait time between batches"`
+
+	Policies map[string]ConfigPolicy `toml:"policies"`
+}
+
+// ConfigPolicy overrides the settings of a policy
+type ConfigPolicy struct {
+	Disabled  bool          `toml:"disabled"`
+	DryRun    bool          `toml:"dry_run"`
+	Schedule  string        `toml:"schedule"`
+	MaxAge    time.Duration `toml:"max_age"`
+	BatchSize int           `toml:"batch_size"`
+}
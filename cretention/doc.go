@@ -0,0 +1,3 @@
+// Package cretention purges expired data (ex. sessions, used verification codes, old audit rows) using cleanup
+// policies that are registered by the app and its modules and run on ccron schedules.
+package cretention
@@ -0,0 +1,24 @@
+package cretention
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	rowsPurged = prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:gochecknoglobals
+		Name: "cretention_rows_purged_total",
+		Help: "Number of rows purged by each retention policy",
+	}, []string{"policy"})
+
+	rowsExpired = prometheus.NewGaugeVec(prometheus.GaugeOpts{ //nolint:gochecknoglobals
+		Name: "cretention_dry_run_rows",
+		Help: "Number of rows that each retention policy would purge, as of its last dry run",
+	}, []string{"policy"})
+
+	lastRun = prometheus.NewGaugeVec(prometheus.GaugeOpts{ //nolint:gochecknoglobals
+		Name: "cretention_last_run_timestamp_seconds",
+		Help: "Unix time of the last successful run of each retention policy",
+	}, []string{"policy"})
+)
+
+func init() { //nolint:gochecknoinits
+	prometheus.MustRegister(rowsPurged, rowsExpired, lastRun)
+}
@@ -0,0 +1,113 @@
+package cretention
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/csql"
+)
+
+// Policy purges a kind of expired data. It is run on its schedule by Retention.
+type Policy struct {
+	// Name identifies the policy in the config, logs, and metrics (ex. sessions). It must be unique.
+	Name string
+
+	// Description explains what the policy purges
+	Description string
+
+	// MaxAge is how long data is kept. Data older than it is purged. It can be overridden in the policy's config.
+	MaxAge time.Duration
+
+	// Schedule is a cron expression (default: cretention.schedule)
+	Schedule string
+
+	Purge PurgeFunc
+}
+
+// PurgeParams holds the params of a PurgeFunc call
+type PurgeParams struct {
+	// Querier runs queries within the batch's transaction
+	Querier csql.Querier
+
+	// Before is the time data must be older than to be purged (now minus the policy's max age)
+	Before time.Time
+
+	// Limit is the max number of rows to purge
+	Limit int
+
+	// DryRun is set if the func must count all the rows it would purge without purging them
+	DryRun bool
+}
+
+// PurgeFunc purges up to Limit expired rows and returns the number of rows purged. In a dry run, it returns the
+// number of rows it would purge in total instead. See DeleteRows for policies that delete rows from a table.
+type PurgeFunc func(ctx context.Context, p PurgeParams) (int64, error)
+
+var registeredPolicies = struct { //nolint:gochecknoglobals
+	sync.Mutex
+	policies map[string]Policy
+}{policies: make(map[string]Policy)}
+
+// Register registers a policy so that it is scheduled by Retention. Modules export their policies so that apps can
+// register the ones they need:
+//
+//	cretention.Register(cmailer.OutboxRetentionPolicy)
+//	cretention.Register(cretention.Policy{
+//		Name:   "sessions",
+//		MaxAge: 30 * 24 * time.Hour,
+//		Purge:  cretention.DeleteRows("sessions", "id", "last_seen_at < :before"),
+//	})
+//
+// Registering a policy again replaces it.
+func Register(policy Policy) {
+	registeredPolicies.Lock()
+	defer registeredPolicies.Unlock()
+
+	registeredPolicies.policies[policy.Name] = policy
+}
+
+// RegisteredPolicies returns the policies registered using Register sorted by name
+func RegisteredPolicies() []Policy {
+	registeredPolicies.Lock()
+	defer registeredPolicies.Unlock()
+
+	policies := make([]Policy, 0, len(registeredPolicies.policies))
+	for _, p := range registeredPolicies.policies {
+		policies = append(policies, p)
+	}
+
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+
+	return policies
+}
+
+// DeleteRows returns a PurgeFunc that deletes the rows of the table that match cond. The condition can use the
+// :before named arg (ex. "created_at < :before"). Rows are selected by their key column so that the batch limit
+// works with all the dialects, including the ones that do not support delete .. limit.
+func DeleteRows(table, key, cond string) PurgeFunc {
+	return func(ctx context.Context, p PurgeParams) (int64, error) {
+		args := csql.Args{"before": p.Before}
+
+		if p.DryRun {
+			var n int64
+
+			err := csql.Select("count(*)").From(table).Where(cond, args).One(ctx, p.Querier, &n)
+
+			return n, err
+		}
+
+		// the extra derived table works around MySQL not allowing limit in an in (..) subquery
+		expired := key + " in (select " + key + " from (select " + key + " from " + table + " where " + cond +
+			" limit " + strconv.Itoa(p.Limit) + ") as expired)"
+
+		res, err := csql.Delete(table).Where(expired, args).Exec(ctx, p.Querier)
+		if err != nil {
+			return 0, err
+		}
+
+		return res.RowsAffected()
+	}
+}
@@ -0,0 +1,270 @@
+package cretention
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gocopper/copper/ccron"
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/csql"
+)
+
+// taskPrefix is prepended to the name of each policy to get the name of its ccron task
+const taskPrefix = "cretention:"
+
+// Result describes a run of a policy
+type Result struct {
+	Policy string
+
+	// Purged is the number of rows purged, or the number of rows that would be purged in a dry run
+	Purged int64
+
+	Batches int
+	DryRun  bool
+
+	// Complete is false if the run stopped after cretention.max_batches with rows left to purge
+	Complete bool
+}
+
+// NewRetentionParams holds the params needed for NewRetention
+type NewRetentionParams struct {
+	DB        *sql.DB
+	Querier   csql.Querier
+	Scheduler *ccron.Scheduler
+	Config    Config
+	SQLConfig csql.Config
+	Logger    clogger.Logger
+}
+
+// NewRetention creates a new Retention
+func NewRetention(p NewRetentionParams) *Retention {
+	return &Retention{
+		db:        p.DB,
+		querier:   p.Querier,
+		scheduler: p.Scheduler,
+		config:    p.Config,
+		dialect:   p.SQLConfig.Dialect,
+		logger:    p.Logger.With(clogger.Module("cretention")),
+		now:       time.Now,
+	}
+}
+
+// Retention runs the registered policies (see Register) as ccron tasks named cretention:<policy>. Each run purges the
+// expired rows in batches of cretention.batch_size, each in its own transaction, until there are none left or
+// cretention.max_batches is reached. In dry run mode, the expired rows are only counted and logged.
+type Retention struct {
+	db        *sql.DB
+	querier   csql.Querier
+	scheduler *ccron.Scheduler
+	config    Config
+	dialect   string
+	logger    clogger.Logger
+	now       func() time.Time
+}
+
+// Run registers the tasks of the policies that are not disabled on the scheduler. Policies must be registered
+// before Run is called.
+func (r *Retention) Run() error {
+	if r.config.Disabled {
+		return nil
+	}
+
+	for _, policy := range RegisteredPolicies() {
+		config := r.config.Policies[policy.Name]
+		if config.Disabled {
+			continue
+		}
+
+		schedule := firstString(config.Schedule, policy.Schedule, r.config.Schedule)
+		name := policy.Name
+
+		err := r.scheduler.Register(ccron.Task{
+			Name:     taskPrefix + name,
+			Schedule: schedule,
+			Run: func(ctx context.Context) error {
+				_, err := r.RunPolicy(ctx, name)
+				return err
+			},
+		})
+		if err != nil {
+			return cerrors.New(err, "failed to schedule retention policy", map[string]interface{}{
+				"policy": name,
+			})
+		}
+	}
+
+	return nil
+}
+
+// Stage implements clifecycle.Staged. The retention tasks start once the migrations have run and the servers are listening.
+func (r *Retention) Stage() clifecycle.Stage {
+	return clifecycle.Stage{
+		Name:  clifecycle.StageWorkers,
+		After: []string{clifecycle.StageMigrations, clifecycle.StageSeeds, clifecycle.StageHTTP},
+	}
+}
+
+// RunPolicy runs the policy now and returns the result of the run
+func (r *Retention) RunPolicy(ctx context.Context, name string) (*Result, error) {
+	policy, ok := r.policy(name)
+	if !ok {
+		return nil, cerrors.New(nil, "retention policy is not registered", map[string]interface{}{
+			"policy": name,
+		})
+	}
+
+	var (
+		config    = r.config.Policies[name]
+		maxAge    = policy.MaxAge
+		batchSize = r.config.BatchSize
+		result    = Result{Policy: name, DryRun: r.config.DryRun || config.DryRun}
+		log       = r.logger.WithTags(map[string]interface{}{"policy": name})
+	)
+
+	if config.MaxAge > 0 {
+		maxAge = config.MaxAge
+	}
+
+	if config.BatchSize > 0 {
+		batchSize = config.BatchSize
+	}
+
+	if maxAge <= 0 {
+		return nil, cerrors.New(nil, "retention policy has no max age", map[string]interface{}{
+			"policy": name,
+		})
+	}
+
+	params := PurgeParams{
+		Querier: r.querier,
+		Before:  r.now().Add(-maxAge),
+		Limit:   batchSize,
+		DryRun:  result.DryRun,
+	}
+
+	if result.DryRun {
+		err := r.inTx(ctx, func(ctx context.Context) error {
+			var err error
+
+			result.Purged, err = policy.Purge(ctx, params)
+
+			return err
+		})
+		if err != nil {
+			return nil, cerrors.New(err, "failed to count expired rows", map[string]interface{}{
+				"policy": name,
+			})
+		}
+
+		result.Batches, result.Complete = 1, true
+		rowsExpired.WithLabelValues(name).Set(float64(result.Purged))
+		lastRun.WithLabelValues(name).SetToCurrentTime()
+
+		log.WithTags(map[string]interface{}{
+			"rows":   result.Purged,
+			"before": params.Before,
+		}).Info("Retention dry run")
+
+		return &result, nil
+	}
+
+	for result.Batches < r.config.MaxBatches || r.config.MaxBatches <= 0 {
+		var n int64
+
+		err := r.inTx(ctx, func(ctx context.Context) error {
+			var err error
+
+			n, err = policy.Purge(ctx, params)
+
+			return err
+		})
+		if err != nil {
+			return &result, cerrors.New(err, "failed to purge expired rows", map[string]interface{}{
+				"policy":  name,
+				"batches": result.Batches,
+				"purged":  result.Purged,
+			})
+		}
+
+		result.Batches++
+		result.Purged += n
+		rowsPurged.WithLabelValues(name).Add(float64(n))
+
+		if n < int64(batchSize) {
+			result.Complete = true
+			break
+		}
+
+		if !sleep(ctx, r.config.BatchPause) {
+			return &result, cerrors.New(ctx.Err(), "retention run was canceled", map[string]interface{}{
+				"policy": name,
+				"purged": result.Purged,
+			})
+		}
+	}
+
+	lastRun.WithLabelValues(name).SetToCurrentTime()
+
+	log.WithTags(map[string]interface{}{
+		"rows":     result.Purged,
+		"batches":  result.Batches,
+		"complete": result.Complete,
+	}).Info("Purged expired rows")
+
+	return &result, nil
+}
+
+func (r *Retention) policy(name string) (Policy, bool) {
+	for _, p := range RegisteredPolicies() {
+		if p.Name == name {
+			return p, true
+		}
+	}
+
+	return Policy{}, false
+}
+
+func (r *Retention) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, tx, err := csql.CtxWithTx(ctx, r.db, r.dialect)
+	if err != nil {
+		return err
+	}
+
+	err = fn(ctx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// sleep waits for d and returns false if the context is done before
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func firstString(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,166 @@
+package cretention_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/ccron"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cretention"
+	"github.com/gocopper/copper/csql"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRetention(t *testing.T, config cretention.Config) (*cretention.Retention, *ccron.Scheduler, *sql.DB) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`create table sessions (id integer primary key, last_seen_at timestamp not null)`)
+	assert.NoError(t, err)
+
+	now := time.Now()
+
+	for i := 0; i < 30; i++ {
+		lastSeen := now.Add(-48 * time.Hour)
+		if i%6 == 0 {
+			lastSeen = now
+		}
+
+		_, err = db.Exec(`insert into sessions (last_seen_at) values (?)`, lastSeen)
+		assert.NoError(t, err)
+	}
+
+	sqlConfig := csql.Config{Dialect: "sqlite3"}
+
+	scheduler, err := ccron.NewScheduler(ccron.NewSchedulerParams{
+		Locker:    ccron.NewMemoryLocker(),
+		History:   ccron.NewMemoryHistory(10),
+		Lifecycle: clifecycle.New(),
+		Config:    ccron.Config{Timeout: time.Minute},
+		Logger:    clogger.NewNoop(),
+	})
+	assert.NoError(t, err)
+
+	return cretention.NewRetention(cretention.NewRetentionParams{
+		DB:        db,
+		Querier:   csql.NewQuerier(db, sqlConfig),
+		Scheduler: scheduler,
+		Config:    config,
+		SQLConfig: sqlConfig,
+		Logger:    clogger.NewNoop(),
+	}), scheduler, db
+}
+
+func registerTestPolicy(name string) {
+	cretention.Register(cretention.Policy{
+		Name:   name,
+		MaxAge: 24 * time.Hour,
+		Purge:  cretention.DeleteRows("sessions", "id", "last_seen_at < :before"),
+	})
+}
+
+func countSessions(t *testing.T, db *sql.DB) int {
+	t.Helper()
+
+	var n int
+
+	assert.NoError(t, db.QueryRow(`select count(*) from sessions`).Scan(&n))
+
+	return n
+}
+
+func TestRetention_RunPolicy(t *testing.T) {
+	t.Parallel()
+
+	registerTestPolicy("test_sessions")
+
+	retention, _, db := newTestRetention(t, cretention.Config{BatchSize: 10, MaxBatches: 10})
+
+	result, err := retention.RunPolicy(context.Background(), "test_sessions")
+	assert.NoError(t, err)
+	assert.Equal(t, &cretention.Result{Policy: "test_sessions", Purged: 25, Batches: 3, Complete: true}, result)
+	assert.Equal(t, 5, countSessions(t, db))
+
+	_, err = retention.RunPolicy(context.Background(), "missing")
+	assert.Error(t, err)
+}
+
+func TestRetention_RunPolicy_MaxBatches(t *testing.T) {
+	t.Parallel()
+
+	registerTestPolicy("test_sessions_max_batches")
+
+	retention, _, db := newTestRetention(t, cretention.Config{
+		BatchSize:  10,
+		MaxBatches: 2,
+		Policies: map[string]cretention.ConfigPolicy{
+			"test_sessions_max_batches": {BatchSize: 4},
+		},
+	})
+
+	result, err := retention.RunPolicy(context.Background(), "test_sessions_max_batches")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8), result.Purged)
+	assert.Equal(t, 2, result.Batches)
+	assert.False(t, result.Complete)
+	assert.Equal(t, 22, countSessions(t, db))
+}
+
+func TestRetention_RunPolicy_DryRun(t *testing.T) {
+	t.Parallel()
+
+	registerTestPolicy("test_sessions_dry_run")
+
+	retention, _, db := newTestRetention(t, cretention.Config{
+		BatchSize:  10,
+		MaxBatches: 10,
+		Policies: map[string]cretention.ConfigPolicy{
+			"test_sessions_dry_run": {DryRun: true},
+		},
+	})
+
+	result, err := retention.RunPolicy(context.Background(), "test_sessions_dry_run")
+	assert.NoError(t, err)
+	assert.Equal(t, &cretention.Result{
+		Policy:   "test_sessions_dry_run",
+		Purged:   25,
+		Batches:  1,
+		DryRun:   true,
+		Complete: true,
+	}, result)
+	assert.Equal(t, 30, countSessions(t, db))
+}
+
+func TestRetention_Run(t *testing.T) {
+	t.Parallel()
+
+	registerTestPolicy("test_sessions_scheduled")
+	registerTestPolicy("test_sessions_disabled")
+
+	retention, scheduler, _ := newTestRetention(t, cretention.Config{
+		Schedule: "0 3 * * *",
+		Policies: map[string]cretention.ConfigPolicy{
+			"test_sessions_scheduled": {Schedule: "*/5 * * * *"},
+			"test_sessions_disabled":  {Disabled: true},
+		},
+	})
+
+	assert.NoError(t, retention.Run())
+
+	schedules := make(map[string]string)
+	for _, task := range scheduler.Tasks() {
+		schedules[task.Name] = task.Schedule
+	}
+
+	assert.Equal(t, "*/5 * * * *", schedules["cretention:test_sessions_scheduled"])
+	assert.Equal(t, "0 3 * * *", schedules["cretention:test_sessions"])
+	assert.NotContains(t, schedules, "cretention:test_sessions_disabled")
+}
@@ -0,0 +1,10 @@
+package cretention
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+	NewRetention,
+	wire.Struct(new(NewRetentionParams), "*"),
+)
@@ -0,0 +1,110 @@
+package crollout
+
+import (
+	"net/url"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+const defaultCookie = "crollout_id"
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "crollout",
+		Description: "crollout configures the canaries and experiments that requests are assigned to",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("crollout", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load crollout config", nil)
+	}
+
+	for name, exp := range config.Experiments {
+		err = exp.validate()
+		if err != nil {
+			return Config{}, cerrors.New(err, "invalid crollout experiment", map[string]interface{}{
+				"experiment": name,
+			})
+		}
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Cookie: defaultCookie,
+	}
+}
+
+// Config configures the experiments. For example:
+//
+//	[crollout.experiments.new_checkout]
+//	variants = { control = 50, treatment = 50 }
+//
+//	[crollout.experiments.api_v2]
+//	variants = { stable = 95, canary = 5 }
+//	upstreams = { canary = "http://api-canary.internal:7501" }
+//	paths = ["/api"]
+type Config struct {
+	// Cookie holds a random id that keeps the assignments of anonymous requests sticky
+	Cookie string `toml:"cookie" doc:"Cookie that keeps the assignments of anonymous requests sticky"`
+
+	Experiments map[string]ConfigExperiment `toml:"experiments"`
+}
+
+// ConfigExperiment configures an experiment or canary
+type ConfigExperiment struct {
+	// Variants maps each variant to its share of the traffic (ex. stable = 95, canary = 5). Shares are relative to
+	// their sum, so they do not need to add up to 100.
+	Variants map[string]float64 `toml:"variants"`
+
+	// Upstreams maps variants to the URLs their requests are proxied to instead of being handled by the app
+	Upstreams map[string]string `toml:"upstreams"`
+
+	// Paths are the path prefixes of the requests that are proxied to Upstreams. If it is not set, all requests are.
+	Paths []string `toml:"paths"`
+}
+
+func (e ConfigExperiment) validate() error {
+	var total float64
+
+	for variant, share := range e.Variants {
+		if share < 0 {
+			return cerrors.New(nil, "variant share must not be negative", map[string]interface{}{
+				"variant": variant,
+			})
+		}
+
+		total += share
+	}
+
+	if total <= 0 {
+		return cerrors.New(nil, "experiment needs at least one variant with a share", nil)
+	}
+
+	for variant, upstream := range e.Upstreams {
+		if _, ok := e.Variants[variant]; !ok {
+			return cerrors.New(nil, "upstream is set for an unknown variant", map[string]interface{}{
+				"variant": variant,
+			})
+		}
+
+		u, err := url.Parse(upstream)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return cerrors.New(err, "invalid upstream url", map[string]interface{}{
+				"variant":  variant,
+				"upstream": upstream,
+			})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,4 @@
+// Package crollout routes a share of the app's traffic to alternate handlers or upstreams to run canaries and A/B
+// experiments. Each request is assigned a variant of every configured experiment. Assignments are sticky per user, or
+// per browser using a cookie, and are exposed in the request context and its log fields.
+package crollout
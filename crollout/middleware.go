@@ -0,0 +1,298 @@
+package crollout
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"hash/fnv"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+)
+
+type ctxKey string
+
+const ctxAssignmentsKey = ctxKey("crollout/assignments")
+
+// cookieMaxAge is how long the cookie that keeps anonymous assignments sticky lasts
+const cookieMaxAge = 365 * 24 * time.Hour
+
+// Users identifies the user of a request so that assignments are sticky per user across devices. Apps implement it
+// using the user set in the request context by their auth middleware.
+type Users interface {
+	// UserID returns the id of the request's user or an empty string if the request is anonymous
+	UserID(r *http.Request) string
+}
+
+// Anonymous is a Users that does not identify users, in which case assignments are only sticky per browser
+type Anonymous struct{}
+
+// UserID implements Users. It always returns an empty string.
+func (Anonymous) UserID(r *http.Request) string {
+	return ""
+}
+
+// VariantFromCtx returns the variant of the experiment assigned to the request, or an empty string if the
+// experiment is not configured or the request did not go through Middleware
+func VariantFromCtx(ctx context.Context, experiment string) string {
+	assignments, _ := ctx.Value(ctxAssignmentsKey).(map[string]string)
+
+	return assignments[experiment]
+}
+
+// AssignmentsFromCtx returns the variant of each experiment assigned to the request
+func AssignmentsFromCtx(ctx context.Context) map[string]string {
+	assignments, _ := ctx.Value(ctxAssignmentsKey).(map[string]string)
+
+	copied := make(map[string]string, len(assignments))
+	for exp, variant := range assignments {
+		copied[exp] = variant
+	}
+
+	return copied
+}
+
+// Split returns a handler that routes each request to the handler of the variant of the experiment it is assigned
+// to. Requests whose variant has no handler are handled by fallback. For example:
+//
+//	chttp.Route{
+//		Path:    "/checkout",
+//		Methods: []string{http.MethodGet},
+//		Handler: crollout.Split("new_checkout", map[string]http.HandlerFunc{
+//			"treatment": ro.HandleNewCheckout,
+//		}, ro.HandleCheckout),
+//	}
+func Split(experiment string, handlers map[string]http.HandlerFunc, fallback http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := handlers[VariantFromCtx(r.Context(), experiment)]; ok {
+			h(w, r)
+			return
+		}
+
+		fallback(w, r)
+	}
+}
+
+// NewMiddlewareParams holds the params needed for NewMiddleware
+type NewMiddlewareParams struct {
+	Config Config
+	Users  Users
+	Logger clogger.Logger
+}
+
+// NewMiddleware creates a new Middleware
+func NewMiddleware(p NewMiddlewareParams) (*Middleware, error) {
+	mw := &Middleware{
+		config:      p.Config,
+		users:       p.Users,
+		logger:      p.Logger.With(clogger.Module("crollout")),
+		experiments: make([]experiment, 0, len(p.Config.Experiments)),
+	}
+
+	names := make([]string, 0, len(p.Config.Experiments))
+	for name := range p.Config.Experiments {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		config := p.Config.Experiments[name]
+
+		err := config.validate()
+		if err != nil {
+			return nil, cerrors.New(err, "invalid crollout experiment", map[string]interface{}{
+				"experiment": name,
+			})
+		}
+
+		exp := experiment{
+			name:      name,
+			variants:  sortedVariants(config.Variants),
+			paths:     config.Paths,
+			upstreams: make(map[string]*httputil.ReverseProxy, len(config.Upstreams)),
+		}
+
+		for variant, upstream := range config.Upstreams {
+			u, _ := url.Parse(upstream)
+
+			exp.upstreams[variant] = mw.newProxy(name, variant, u)
+		}
+
+		mw.experiments = append(mw.experiments, exp)
+	}
+
+	return mw, nil
+}
+
+// Middleware is a chttp.Middleware that assigns each request a variant of every configured experiment. A request's
+// variant is picked by hashing the experiment and the request's user id (see Users), or a random id kept in a cookie
+// for anonymous requests, so that it does not change across requests. Assignments are added to the request context
+// (see VariantFromCtx and Split) and to its log fields.
+// Requests assigned to a variant with an upstream are proxied to it instead of being handled by the app.
+// It should run after the app's auth middleware so that Users can read the user.
+type Middleware struct {
+	config      Config
+	users       Users
+	logger      clogger.Logger
+	experiments []experiment
+}
+
+type experiment struct {
+	name      string
+	variants  []variantShare
+	paths     []string
+	upstreams map[string]*httputil.ReverseProxy
+}
+
+type variantShare struct {
+	name  string
+	share float64
+}
+
+// Handle implements the chttp.Middleware interface. See Middleware
+func (mw *Middleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(mw.experiments) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var (
+			unitID      = mw.unitID(w, r)
+			assignments = make(map[string]string, len(mw.experiments))
+			proxy       *httputil.ReverseProxy
+		)
+
+		for _, exp := range mw.experiments {
+			variant := exp.assign(unitID)
+			assignments[exp.name] = variant
+
+			if p, ok := exp.upstreams[variant]; ok && proxy == nil && exp.matches(r.URL.Path) {
+				proxy = p
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), ctxAssignmentsKey, assignments)
+		ctx = clogger.CtxWithFields(ctx, map[string]interface{}{
+			"experiments": assignments,
+		})
+
+		if proxy != nil {
+			proxy.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// unitID returns the id that assignments are sticky to: the user's id, or the random id in the cookie, which is set
+// if the request does not have one yet
+func (mw *Middleware) unitID(w http.ResponseWriter, r *http.Request) string {
+	if userID := mw.users.UserID(r); userID != "" {
+		return "user:" + userID
+	}
+
+	if c, err := r.Cookie(mw.config.Cookie); err == nil && c.Value != "" {
+		return "anon:" + c.Value
+	}
+
+	id := newID()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     mw.config.Cookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(cookieMaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return "anon:" + id
+}
+
+func (mw *Middleware) newProxy(experiment, variant string, upstream *url.URL) *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(upstream)
+
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		clogger.FromCtx(r.Context()).WithTags(map[string]interface{}{
+			"experiment": experiment,
+			"variant":    variant,
+			"upstream":   upstream.String(),
+		}).Error("Failed to proxy request to upstream", err)
+
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	return proxy
+}
+
+// assign returns the variant of the experiment for the unit. The same unit always gets the same variant as long as
+// the experiment's variants and shares do not change.
+func (e experiment) assign(unitID string) string {
+	var total float64
+	for _, v := range e.variants {
+		total += v.share
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(e.name + ":" + unitID))
+
+	var (
+		point = float64(h.Sum64()%1_000_000) / 1_000_000 * total
+		sum   float64
+	)
+
+	for _, v := range e.variants {
+		sum += v.share
+		if point < sum {
+			return v.name
+		}
+	}
+
+	return e.variants[len(e.variants)-1].name
+}
+
+func (e experiment) matches(path string) bool {
+	if len(e.paths) == 0 {
+		return true
+	}
+
+	for _, prefix := range e.paths {
+		prefix = strings.TrimSuffix(prefix, "/")
+
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedVariants(variants map[string]float64) []variantShare {
+	sorted := make([]variantShare, 0, len(variants))
+	for name, share := range variants {
+		sorted = append(sorted, variantShare{name: name, share: share})
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+
+	return sorted
+}
+
+func newID() string {
+	const idBytes = 16
+
+	b := make([]byte, idBytes)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
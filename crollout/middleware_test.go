@@ -0,0 +1,155 @@
+package crollout_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/crollout"
+	"github.com/stretchr/testify/assert"
+)
+
+type headerUsers struct{}
+
+func (headerUsers) UserID(r *http.Request) string {
+	return r.Header.Get("X-User")
+}
+
+func newTestMiddleware(t *testing.T, experiments map[string]crollout.ConfigExperiment) *crollout.Middleware {
+	t.Helper()
+
+	mw, err := crollout.NewMiddleware(crollout.NewMiddlewareParams{
+		Config: crollout.Config{Cookie: "crollout_id", Experiments: experiments},
+		Users:  headerUsers{},
+		Logger: clogger.NewNoop(),
+	})
+	assert.NoError(t, err)
+
+	return mw
+}
+
+func variantHandler(experiment string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(crollout.VariantFromCtx(r.Context(), experiment)))
+	})
+}
+
+func TestMiddleware_Sticky(t *testing.T) {
+	t.Parallel()
+
+	handler := newTestMiddleware(t, map[string]crollout.ConfigExperiment{
+		"checkout": {Variants: map[string]float64{"control": 50, "treatment": 50}},
+	}).Handle(variantHandler("checkout"))
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	cookies := resp.Result().Cookies() //nolint:bodyclose
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, "crollout_id", cookies[0].Name)
+
+	variant := resp.Body.String()
+	assert.Contains(t, []string{"control", "treatment"}, variant)
+
+	for i := 0; i < 10; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(cookies[0])
+
+		resp = httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		assert.Equal(t, variant, resp.Body.String())
+		assert.Empty(t, resp.Result().Cookies()) //nolint:bodyclose
+	}
+}
+
+func TestMiddleware_Shares(t *testing.T) {
+	t.Parallel()
+
+	handler := newTestMiddleware(t, map[string]crollout.ConfigExperiment{
+		"api": {Variants: map[string]float64{"stable": 90, "canary": 10}},
+	}).Handle(variantHandler("api"))
+
+	counts := make(map[string]int)
+
+	for i := 0; i < 10000; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-User", strconv.Itoa(i))
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		counts[resp.Body.String()]++
+	}
+
+	assert.InDelta(t, 1000, counts["canary"], 150)
+	assert.Equal(t, 10000, counts["stable"]+counts["canary"])
+}
+
+func TestMiddleware_Upstream(t *testing.T) {
+	t.Parallel()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("upstream " + r.URL.Path))
+	}))
+	defer upstream.Close()
+
+	handler := newTestMiddleware(t, map[string]crollout.ConfigExperiment{
+		"api": {
+			Variants:  map[string]float64{"stable": 0, "canary": 100},
+			Upstreams: map[string]string{"canary": upstream.URL},
+			Paths:     []string{"/api"},
+		},
+	}).Handle(variantHandler("api"))
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+	assert.Equal(t, "upstream /api/users", resp.Body.String())
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/home", nil))
+	assert.Equal(t, "canary", resp.Body.String())
+}
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mw = newTestMiddleware(t, map[string]crollout.ConfigExperiment{
+			"checkout": {Variants: map[string]float64{"treatment": 1}},
+		})
+		respond = func(body string) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte(body)) }
+		}
+	)
+
+	resp := httptest.NewRecorder()
+	mw.Handle(crollout.Split("checkout", map[string]http.HandlerFunc{
+		"treatment": respond("new checkout"),
+	}, respond("checkout"))).ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/checkout", nil))
+	assert.Equal(t, "new checkout", resp.Body.String())
+
+	resp = httptest.NewRecorder()
+	crollout.Split("checkout", map[string]http.HandlerFunc{
+		"treatment": respond("new checkout"),
+	}, respond("checkout")).ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/checkout", nil))
+	assert.Equal(t, "checkout", resp.Body.String())
+}
+
+func TestNewMiddleware_InvalidExperiment(t *testing.T) {
+	t.Parallel()
+
+	_, err := crollout.NewMiddleware(crollout.NewMiddlewareParams{
+		Config: crollout.Config{Experiments: map[string]crollout.ConfigExperiment{
+			"api": {
+				Variants:  map[string]float64{"stable": 100},
+				Upstreams: map[string]string{"canary": "http://canary.internal"},
+			},
+		}},
+		Users:  crollout.Anonymous{},
+		Logger: clogger.NewNoop(),
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,11 @@
+package crollout
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup. Apps must also provide Users, or bind Anonymous if
+// assignments should only be sticky per browser.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+	NewMiddleware,
+	wire.Struct(new(NewMiddlewareParams), "*"),
+)
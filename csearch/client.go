@@ -0,0 +1,94 @@
+package csearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+const maxErrorBodyBytes = 4 << 10
+
+// client sends JSON requests to the REST API of a search backend
+type client struct {
+	baseURL string
+	http    *http.Client
+	auth    func(req *http.Request)
+}
+
+func newClient(config Config, auth func(req *http.Request)) *client {
+	return &client{
+		baseURL: strings.TrimSuffix(config.URL, "/"),
+		http:    &http.Client{Timeout: config.Timeout},
+		auth:    auth,
+	}
+}
+
+// do sends body (encoded as JSON unless it is a []byte) and decodes the response into dest if it is not nil.
+// Responses with an error status are returned as errors.
+func (c *client) do(ctx context.Context, method, path, contentType string, body, dest interface{}) (int, error) {
+	var reqBody io.Reader
+
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		reqBody = bytes.NewReader(b)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, cerrors.New(err, "failed to encode search request", nil)
+		}
+
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return 0, cerrors.New(err, "failed to create search request", nil)
+	}
+
+	if reqBody != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	c.auth(req)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, cerrors.New(err, "failed to send search request", map[string]interface{}{
+			"method": method,
+			"path":   path,
+		})
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+
+		return resp.StatusCode, cerrors.New(nil, "search request failed", map[string]interface{}{
+			"method": method,
+			"path":   path,
+			"status": resp.StatusCode,
+			"body":   string(respBody),
+		})
+	}
+
+	if dest == nil {
+		return resp.StatusCode, nil
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(dest)
+	if err != nil {
+		return resp.StatusCode, cerrors.New(err, "failed to decode search response", map[string]interface{}{
+			"method": method,
+			"path":   path,
+		})
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,68 @@
+package csearch
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Backends supported by NewEngine
+const (
+	BackendElasticsearch = "elasticsearch"
+	BackendOpenSearch    = "opensearch"
+	BackendMeilisearch   = "meilisearch"
+)
+
+const defaultTimeout = 10 * time.Second
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "csearch",
+		Description: "csearch configures the full-text search backend",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("csearch", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load csearch config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Backend: BackendElasticsearch,
+		URL:     "http://localhost:9200",
+		Timeout: defaultTimeout,
+	}
+}
+
+// Config configures the search backend. For example:
+//
+//	[csearch]
+//	backend = "meilisearch"
+//	url = "http://localhost:7700"
+//	api_key = "masterKey"
+//	prefix = "myapp_"
+type Config struct {
+	// Backend is one of elasticsearch, opensearch, or meilisearch
+	Backend string `toml:"backend" valid:"in(elasticsearch|opensearch|meilisearch)" doc:"Search backend"`
+	URL     string `toml:"url" doc:"URL of the search backend"`
+
+	// APIKey is sent as an ApiKey to Elasticsearch and OpenSearch, and as a bearer token to Meilisearch
+	APIKey   string `toml:"api_key" doc:"API key of the search backend"`
+	Username string `toml:"username" doc:"Basic auth username (Elasticsearch and OpenSearch only)"`
+	Password string `toml:"password" doc:"Basic auth password (Elasticsearch and OpenSearch only)"`
+
+	// Prefix is prepended to the name of every index so that multiple apps or environments can share a backend
+	Prefix string `toml:"prefix" doc:"Prepended to the name of every index"`
+
+	Timeout time.Duration `toml:"timeout" doc:"Timeout of the requests to the search backend"`
+}
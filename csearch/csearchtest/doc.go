@@ -0,0 +1,3 @@
+// Package csearchtest provides an in-memory csearch.Engine so that code that indexes and searches documents can be
+// unit tested without a search backend.
+package csearchtest
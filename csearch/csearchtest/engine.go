@@ -0,0 +1,252 @@
+package csearchtest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/csearch"
+)
+
+// NewEngine creates an empty Engine
+func NewEngine() *Engine {
+	return &Engine{
+		indexes: make(map[string]map[string]csearch.Document),
+	}
+}
+
+// Engine is a csearch.Engine that keeps documents in memory. Use it in place of the app's engine in tests:
+//
+//	engine := csearchtest.NewEngine()
+//
+//	// handle an event that indexes a product..
+//
+//	doc, ok := engine.Document(ProductsIndex, "42")
+//
+// Query.Text matches the documents whose searchable fields contain all of its words, ignoring case. Hits are sorted
+// by id unless the query is ordered by a field, and their score is always 1.
+type Engine struct {
+	mu      sync.Mutex
+	indexes map[string]map[string]csearch.Document
+
+	// PingErr is returned by Ping if it is set
+	PingErr error
+}
+
+// CreateIndex implements csearch.Engine
+func (e *Engine) CreateIndex(_ context.Context, idx csearch.Index) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.indexes[idx.Name]; !ok {
+		e.indexes[idx.Name] = make(map[string]csearch.Document)
+	}
+
+	return nil
+}
+
+// Index implements csearch.Engine. The index is created if it does not exist.
+func (e *Engine) Index(_ context.Context, idx csearch.Index, docs ...csearch.Document) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.indexes[idx.Name]; !ok {
+		e.indexes[idx.Name] = make(map[string]csearch.Document)
+	}
+
+	for _, doc := range docs {
+		id := doc.ID(idx)
+		if id == "" {
+			return cerrors.New(nil, "search document has no id", map[string]interface{}{
+				"index": idx.Name,
+			})
+		}
+
+		e.indexes[idx.Name][id] = doc
+	}
+
+	return nil
+}
+
+// Delete implements csearch.Engine
+func (e *Engine) Delete(_ context.Context, idx csearch.Index, ids ...string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, id := range ids {
+		delete(e.indexes[idx.Name], id)
+	}
+
+	return nil
+}
+
+// Search implements csearch.Engine
+func (e *Engine) Search(_ context.Context, idx csearch.Index, q *csearch.Query) (csearch.Results, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var hits []csearch.Hit
+
+	for id, doc := range e.indexes[idx.Name] {
+		if matchesText(idx, doc, q.Text) && matchesFilters(doc, q.Filters) {
+			hits = append(hits, csearch.Hit{ID: id, Score: 1, Document: doc})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		for _, s := range q.Sort {
+			c := compare(hits[i].Document[s.Field], hits[j].Document[s.Field])
+			if c == 0 {
+				continue
+			}
+
+			return (c < 0) != s.Desc
+		}
+
+		return hits[i].ID < hits[j].ID
+	})
+
+	results := csearch.Results{Total: len(hits)}
+
+	if q.Offset < len(hits) {
+		hits = hits[q.Offset:]
+		if len(hits) > q.Size() {
+			hits = hits[:q.Size()]
+		}
+
+		results.Hits = hits
+	}
+
+	return results, nil
+}
+
+// Ping implements csearch.Engine
+func (e *Engine) Ping(context.Context) error {
+	return e.PingErr
+}
+
+// Document returns the indexed document with the id
+func (e *Engine) Document(idx csearch.Index, id string) (csearch.Document, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	doc, ok := e.indexes[idx.Name][id]
+
+	return doc, ok
+}
+
+func matchesText(idx csearch.Index, doc csearch.Document, text string) bool {
+	var values []string
+
+	for field, val := range doc {
+		if len(idx.Searchable) == 0 || contains(idx.Searchable, field) {
+			values = append(values, strings.ToLower(fmt.Sprint(val)))
+		}
+	}
+
+	content := strings.Join(values, " ")
+
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		if !strings.Contains(content, word) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchesFilters(doc csearch.Document, filters []csearch.Filter) bool {
+	for _, f := range filters {
+		val := doc[f.Field]
+
+		switch f.Op {
+		case csearch.OpEq:
+			if compare(val, f.Value) != 0 {
+				return false
+			}
+		case csearch.OpIn:
+			values, _ := f.Value.([]interface{})
+			if !containsValue(values, val) {
+				return false
+			}
+		case csearch.OpGt, csearch.OpGte, csearch.OpLt, csearch.OpLte:
+			if !matchesRange(f.Op, compare(val, f.Value)) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func matchesRange(op string, c int) bool {
+	switch op {
+	case csearch.OpGt:
+		return c > 0
+	case csearch.OpGte:
+		return c >= 0
+	case csearch.OpLt:
+		return c < 0
+	default:
+		return c <= 0
+	}
+}
+
+func containsValue(values []interface{}, val interface{}) bool {
+	for _, v := range values {
+		if compare(v, val) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compare compares numbers numerically and other values by their string representation
+func compare(a, b interface{}) int {
+	af, aIsNum := toFloat(a)
+	bf, bIsNum := toFloat(b)
+
+	if aIsNum && bIsNum {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+package csearchtest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gocopper/copper/csearch"
+	"github.com/gocopper/copper/csearch/csearchtest"
+	"github.com/stretchr/testify/assert"
+)
+
+var productsIndex = csearch.Index{ //nolint:gochecknoglobals
+	Name:       "products",
+	Searchable: []string{"name"},
+	Filterable: []string{"category", "price"},
+	Sortable:   []string{"price"},
+}
+
+type product struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Category string  `json:"category"`
+	Price    float64 `json:"price"`
+}
+
+type productSaved struct {
+	Product product
+}
+
+type productDeleted struct {
+	ProductID string
+}
+
+func TestEngine_Search(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx    = context.Background()
+		engine = csearchtest.NewEngine()
+		index  = csearch.IndexHook(engine, productsIndex,
+			func(ctx context.Context, e productSaved) (csearch.Document, error) {
+				return csearch.NewDocument(e.Product)
+			})
+		del = csearch.DeleteHook(engine, productsIndex, func(e productDeleted) string { return e.ProductID })
+	)
+
+	assert.NoError(t, engine.CreateIndex(ctx, productsIndex))
+
+	for _, p := range []product{
+		{ID: "1", Name: "Running Shoes", Category: "footwear", Price: 120},
+		{ID: "2", Name: "Trail Shoes", Category: "footwear", Price: 90},
+		{ID: "3", Name: "Running Socks", Category: "socks", Price: 10},
+		{ID: "4", Name: "Old Shoes", Category: "footwear", Price: 5},
+	} {
+		assert.NoError(t, index(ctx, productSaved{Product: p}))
+	}
+
+	assert.NoError(t, del(ctx, productDeleted{ProductID: "4"}))
+
+	_, ok := engine.Document(productsIndex, "4")
+	assert.False(t, ok)
+
+	results, err := engine.Search(ctx, productsIndex, csearch.Match("shoes").
+		Where(csearch.Eq("category", "footwear"), csearch.Lt("price", 200)).
+		OrderBy(csearch.Asc("price")))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, results.Total)
+
+	var products []product
+
+	assert.NoError(t, results.Decode(&products))
+	assert.Equal(t, []string{"Trail Shoes", "Running Shoes"}, []string{products[0].Name, products[1].Name})
+
+	results, err = engine.Search(ctx, productsIndex, csearch.All().
+		Where(csearch.In("category", "socks", "hats")).
+		Page(10, 0))
+	assert.NoError(t, err)
+	assert.Len(t, results.Hits, 1)
+	assert.Equal(t, "3", results.Hits[0].ID)
+
+	results, err = engine.Search(ctx, productsIndex, csearch.All().OrderBy(csearch.Desc("price")).Page(1, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, results.Total)
+	assert.Equal(t, "2", results.Hits[0].ID)
+}
+
+func TestEngine_Ping(t *testing.T) {
+	t.Parallel()
+
+	engine := csearchtest.NewEngine()
+	assert.NoError(t, engine.Ping(context.Background()))
+
+	engine.PingErr = errors.New("test-err")
+	assert.Error(t, engine.Ping(context.Background()))
+}
@@ -0,0 +1,12 @@
+package csearchtest
+
+import (
+	"github.com/gocopper/copper/csearch"
+	"github.com/google/wire"
+)
+
+// WireModule provides an Engine as the app's csearch.Engine. Use it in place of csearch.NewEngine in test builds.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	NewEngine,
+	wire.Bind(new(csearch.Engine), new(*Engine)),
+)
@@ -0,0 +1,5 @@
+// Package csearch provides full-text search backed by Elasticsearch, OpenSearch, or Meilisearch. Apps define their
+// indexes, keep them up to date using hooks that can be subscribed to domain events (see cevents), and search them
+// using a backend-independent query DSL. A readiness checker reports the app as unready while the search backend is
+// unreachable (see chealth).
+package csearch
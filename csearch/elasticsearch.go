@@ -0,0 +1,343 @@
+package csearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// keywordSubfield is the subfield of filterable and sortable string fields that is used to match their text
+const keywordSubfield = "search"
+
+// NewElasticsearchEngine creates an Engine that uses the REST API of Elasticsearch or OpenSearch
+func NewElasticsearchEngine(config Config) *ElasticsearchEngine {
+	return &ElasticsearchEngine{
+		prefix: config.Prefix,
+		client: newClient(config, func(req *http.Request) {
+			switch {
+			case config.APIKey != "":
+				req.Header.Set("Authorization", "ApiKey "+config.APIKey)
+			case config.Username != "":
+				req.SetBasicAuth(config.Username, config.Password)
+			}
+		}),
+	}
+}
+
+// ElasticsearchEngine is an Engine backed by Elasticsearch or OpenSearch. String fields that are filterable or sortable
+// are mapped as keywords so that they are matched exactly by filters, with a text subfield that is matched by
+// Query.Text. Other fields use the dynamic mapping.
+type ElasticsearchEngine struct {
+	prefix string
+	client *client
+}
+
+type esBulkResponse struct {
+	Errors bool                                `json:"errors"`
+	Items  []map[string]esBulkResponseItemInfo `json:"items"`
+}
+
+type esBulkResponseItemInfo struct {
+	ID     string          `json:"_id"`
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error"`
+}
+
+type esSearchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			ID     string   `json:"_id"`
+			Score  *float64 `json:"_score"`
+			Source Document `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// CreateIndex creates the index with mappings for its filterable and sortable fields. If the index exists, its
+// mappings are updated, which only applies to the fields that are indexed for the first time.
+func (e *ElasticsearchEngine) CreateIndex(ctx context.Context, idx Index) error {
+	var (
+		path     = "/" + url.PathEscape(e.prefix+idx.Name)
+		mappings = esMappings(idx)
+	)
+
+	status, err := e.client.do(ctx, http.MethodHead, path, "", nil, nil)
+	if status == http.StatusNotFound {
+		_, err = e.client.do(ctx, http.MethodPut, path, "application/json", map[string]interface{}{
+			"mappings": mappings,
+		}, nil)
+	} else if err == nil {
+		_, err = e.client.do(ctx, http.MethodPut, path+"/_mapping", "application/json", mappings, nil)
+	}
+
+	if err != nil {
+		return cerrors.New(err, "failed to create search index", map[string]interface{}{
+			"index": idx.Name,
+		})
+	}
+
+	return nil
+}
+
+// Index adds the documents to the index using the bulk API
+func (e *ElasticsearchEngine) Index(ctx context.Context, idx Index, docs ...Document) error {
+	var body bytes.Buffer
+
+	for _, doc := range docs {
+		err := e.writeBulkAction(&body, "index", idx, doc.ID(idx))
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(&body).Encode(doc)
+		if err != nil {
+			return cerrors.New(err, "failed to encode search document", nil)
+		}
+	}
+
+	return e.bulk(ctx, idx, body.Bytes())
+}
+
+// Delete deletes the documents from the index using the bulk API
+func (e *ElasticsearchEngine) Delete(ctx context.Context, idx Index, ids ...string) error {
+	var body bytes.Buffer
+
+	for _, id := range ids {
+		err := e.writeBulkAction(&body, "delete", idx, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return e.bulk(ctx, idx, body.Bytes())
+}
+
+// Search runs the query as a bool query
+func (e *ElasticsearchEngine) Search(ctx context.Context, idx Index, q *Query) (Results, error) {
+	var resp esSearchResponse
+
+	_, err := e.client.do(ctx, http.MethodPost, "/"+url.PathEscape(e.prefix+idx.Name)+"/_search",
+		"application/json", esSearchBody(idx, q), &resp)
+	if err != nil {
+		return Results{}, cerrors.New(err, "failed to search index", map[string]interface{}{
+			"index": idx.Name,
+		})
+	}
+
+	results := Results{
+		Total: resp.Hits.Total.Value,
+		Hits:  make([]Hit, len(resp.Hits.Hits)),
+	}
+
+	for i, h := range resp.Hits.Hits {
+		results.Hits[i] = Hit{ID: h.ID, Document: h.Source}
+
+		if h.Score != nil {
+			results.Hits[i].Score = *h.Score
+		}
+	}
+
+	return results, nil
+}
+
+// Ping returns an error if the cluster is unreachable or its health is red
+func (e *ElasticsearchEngine) Ping(ctx context.Context) error {
+	var health struct {
+		Status string `json:"status"`
+	}
+
+	_, err := e.client.do(ctx, http.MethodGet, "/_cluster/health", "", nil, &health)
+	if err != nil {
+		return err
+	}
+
+	if health.Status == "red" {
+		return cerrors.New(nil, "search cluster health is red", nil)
+	}
+
+	return nil
+}
+
+func (e *ElasticsearchEngine) writeBulkAction(buf *bytes.Buffer, action string, idx Index, id string) error {
+	if id == "" {
+		return cerrors.New(nil, "search document has no id", map[string]interface{}{
+			"index":      idx.Name,
+			"primaryKey": idx.Key(),
+		})
+	}
+
+	return json.NewEncoder(buf).Encode(map[string]interface{}{
+		action: map[string]string{"_index": e.prefix + idx.Name, "_id": id},
+	})
+}
+
+func (e *ElasticsearchEngine) bulk(ctx context.Context, idx Index, body []byte) error {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var resp esBulkResponse
+
+	_, err := e.client.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", body, &resp)
+	if err != nil {
+		return cerrors.New(err, "failed to send bulk request", map[string]interface{}{
+			"index": idx.Name,
+		})
+	}
+
+	if !resp.Errors {
+		return nil
+	}
+
+	for _, item := range resp.Items {
+		for action, info := range item {
+			// deleting a document that does not exist is not an error
+			if info.Status < http.StatusMultipleChoices ||
+				(action == "delete" && info.Status == http.StatusNotFound) {
+				continue
+			}
+
+			return cerrors.New(nil, "bulk request failed", map[string]interface{}{
+				"index":  idx.Name,
+				"action": action,
+				"id":     info.ID,
+				"status": info.Status,
+				"error":  string(info.Error),
+			})
+		}
+	}
+
+	return nil
+}
+
+// esMappings maps the filterable and sortable string fields as keywords with a text subfield
+func esMappings(idx Index) map[string]interface{} {
+	templates := make([]map[string]interface{}, 0)
+
+	for _, field := range keywordFields(idx) {
+		templates = append(templates, map[string]interface{}{
+			"csearch_" + field: map[string]interface{}{
+				"path_match":         field,
+				"match_mapping_type": "string",
+				"mapping": map[string]interface{}{
+					"type": "keyword",
+					"fields": map[string]interface{}{
+						keywordSubfield: map[string]string{"type": "text"},
+					},
+				},
+			},
+		})
+	}
+
+	return map[string]interface{}{"dynamic_templates": templates}
+}
+
+func esSearchBody(idx Index, q *Query) map[string]interface{} {
+	var (
+		must    = map[string]interface{}{"match_all": map[string]interface{}{}}
+		filters = make([]map[string]interface{}, len(q.Filters))
+		sorts   = make([]map[string]interface{}, len(q.Sort))
+	)
+
+	if q.Text != "" {
+		match := map[string]interface{}{"query": q.Text}
+
+		if len(idx.Searchable) > 0 {
+			var (
+				keywords = keywordFields(idx)
+				fields   = make([]string, len(idx.Searchable))
+			)
+
+			for i, field := range idx.Searchable {
+				if contains(keywords, field) {
+					field += "." + keywordSubfield
+				}
+
+				// earlier fields are more important
+				fields[i] = field + "^" + strconv.Itoa(len(idx.Searchable)-i)
+			}
+
+			match["fields"] = fields
+		}
+
+		must = map[string]interface{}{"multi_match": match}
+	}
+
+	for i, f := range q.Filters {
+		switch f.Op {
+		case OpEq:
+			filters[i] = map[string]interface{}{"term": map[string]interface{}{f.Field: f.Value}}
+		case OpIn:
+			filters[i] = map[string]interface{}{"terms": map[string]interface{}{f.Field: f.Value}}
+		default:
+			filters[i] = map[string]interface{}{"range": map[string]interface{}{
+				f.Field: map[string]interface{}{esRangeOps[f.Op]: f.Value},
+			}}
+		}
+	}
+
+	for i, s := range q.Sort {
+		order := "asc"
+		if s.Desc {
+			order = "desc"
+		}
+
+		sorts[i] = map[string]interface{}{s.Field: map[string]string{"order": order}}
+	}
+
+	body := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must":   must,
+				"filter": filters,
+			},
+		},
+		"from":             q.Offset,
+		"size":             q.Size(),
+		"track_total_hits": true,
+	}
+
+	if len(sorts) > 0 {
+		body["sort"] = sorts
+	}
+
+	return body
+}
+
+var esRangeOps = map[string]string{ //nolint:gochecknoglobals
+	OpGt:  "gt",
+	OpGte: "gte",
+	OpLt:  "lt",
+	OpLte: "lte",
+}
+
+// keywordFields returns the filterable and sortable fields without duplicates
+func keywordFields(idx Index) []string {
+	var fields []string
+
+	for _, field := range append(append([]string{}, idx.Filterable...), idx.Sortable...) {
+		if !contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,208 @@
+package csearch_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gocopper/copper/csearch"
+	"github.com/stretchr/testify/assert"
+)
+
+var productsIndex = csearch.Index{ //nolint:gochecknoglobals
+	Name:       "products",
+	Searchable: []string{"name", "category"},
+	Filterable: []string{"category", "price"},
+	Sortable:   []string{"price"},
+}
+
+type recordedRequest struct {
+	Method string
+	Path   string
+	Auth   string
+	Body   string
+}
+
+// fakeBackend records requests and responds with the response of their method and path
+type fakeBackend struct {
+	mu        sync.Mutex
+	requests  []recordedRequest
+	responses map[string]string
+}
+
+func newFakeBackend(t *testing.T, responses map[string]string) (*fakeBackend, string) {
+	t.Helper()
+
+	backend := &fakeBackend{responses: responses}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backend.mu.Lock()
+		defer backend.mu.Unlock()
+
+		body, _ := io.ReadAll(r.Body)
+
+		backend.requests = append(backend.requests, recordedRequest{
+			Method: r.Method,
+			Path:   r.URL.RequestURI(),
+			Auth:   r.Header.Get("Authorization"),
+			Body:   string(body),
+		})
+
+		resp, ok := backend.responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(resp))
+	}))
+	t.Cleanup(server.Close)
+
+	return backend, server.URL
+}
+
+func (b *fakeBackend) request(t *testing.T, i int) recordedRequest {
+	t.Helper()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !assert.Greater(t, len(b.requests), i) {
+		t.FailNow()
+	}
+
+	return b.requests[i]
+}
+
+func TestElasticsearchEngine_CreateIndex(t *testing.T) {
+	t.Parallel()
+
+	backend, url := newFakeBackend(t, map[string]string{
+		"PUT /app_products": `{"acknowledged":true}`,
+	})
+
+	engine := csearch.NewElasticsearchEngine(csearch.Config{URL: url, APIKey: "test-key", Prefix: "app_"})
+
+	err := engine.CreateIndex(context.Background(), productsIndex)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.MethodHead, backend.request(t, 0).Method)
+
+	create := backend.request(t, 1)
+	assert.Equal(t, "PUT /app_products", create.Method+" "+create.Path)
+	assert.Equal(t, "ApiKey test-key", create.Auth)
+	assert.Contains(t, create.Body, `"path_match":"category"`)
+	assert.Contains(t, create.Body, `"path_match":"price"`)
+	assert.Contains(t, create.Body, `"type":"keyword"`)
+}
+
+func TestElasticsearchEngine_Index(t *testing.T) {
+	t.Parallel()
+
+	backend, url := newFakeBackend(t, map[string]string{
+		"POST /_bulk": `{"errors":false,"items":[]}`,
+	})
+
+	engine := csearch.NewElasticsearchEngine(csearch.Config{URL: url, Username: "user", Password: "pass"})
+
+	err := engine.Index(context.Background(), productsIndex,
+		csearch.Document{"id": "1", "name": "Shoes"},
+		csearch.Document{"id": float64(2), "name": "Socks"})
+	assert.NoError(t, err)
+
+	req := backend.request(t, 0)
+	assert.True(t, strings.HasPrefix(req.Auth, "Basic "))
+	assert.Equal(t, strings.Join([]string{
+		`{"index":{"_id":"1","_index":"products"}}`,
+		`{"id":"1","name":"Shoes"}`,
+		`{"index":{"_id":"2","_index":"products"}}`,
+		`{"id":2,"name":"Socks"}`,
+		``,
+	}, "\n"), req.Body)
+
+	err = engine.Index(context.Background(), productsIndex, csearch.Document{"name": "No ID"})
+	assert.Error(t, err)
+}
+
+func TestElasticsearchEngine_Index_ItemErr(t *testing.T) {
+	t.Parallel()
+
+	_, url := newFakeBackend(t, map[string]string{
+		"POST /_bulk": `{"errors":true,"items":[{"delete":{"_id":"1","status":404}},` +
+			`{"index":{"_id":"2","status":400,"error":{"type":"mapper_parsing_exception"}}}]}`,
+	})
+
+	engine := csearch.NewElasticsearchEngine(csearch.Config{URL: url})
+
+	err := engine.Index(context.Background(), productsIndex, csearch.Document{"id": "2"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "bulk request failed")
+}
+
+func TestElasticsearchEngine_Search(t *testing.T) {
+	t.Parallel()
+
+	backend, url := newFakeBackend(t, map[string]string{
+		"POST /products/_search": `{"hits":{"total":{"value":12},"hits":[` +
+			`{"_id":"1","_score":1.5,"_source":{"id":"1","name":"Shoes","price":50}}]}}`,
+	})
+
+	engine := csearch.NewElasticsearchEngine(csearch.Config{URL: url})
+
+	results, err := engine.Search(context.Background(), productsIndex, csearch.Match("shoes").
+		Where(csearch.Eq("category", "footwear"), csearch.In("price", 50, 60), csearch.Lte("price", 100)).
+		OrderBy(csearch.Desc("price")).
+		Page(10, 20))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 12, results.Total)
+	assert.Equal(t, []csearch.Hit{{
+		ID:       "1",
+		Score:    1.5,
+		Document: csearch.Document{"id": "1", "name": "Shoes", "price": float64(50)},
+	}}, results.Hits)
+
+	var body map[string]interface{}
+
+	assert.NoError(t, json.Unmarshal([]byte(backend.request(t, 0).Body), &body))
+	assert.Equal(t, map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": map[string]interface{}{"multi_match": map[string]interface{}{
+					"query":  "shoes",
+					"fields": []interface{}{"name^2", "category.search^1"},
+				}},
+				"filter": []interface{}{
+					map[string]interface{}{"term": map[string]interface{}{"category": "footwear"}},
+					map[string]interface{}{"terms": map[string]interface{}{"price": []interface{}{float64(50), float64(60)}}},
+					map[string]interface{}{"range": map[string]interface{}{"price": map[string]interface{}{"lte": float64(100)}}},
+				},
+			},
+		},
+		"sort":             []interface{}{map[string]interface{}{"price": map[string]interface{}{"order": "desc"}}},
+		"from":             float64(20),
+		"size":             float64(10),
+		"track_total_hits": true,
+	}, body)
+}
+
+func TestElasticsearchEngine_Ping(t *testing.T) {
+	t.Parallel()
+
+	_, url := newFakeBackend(t, map[string]string{
+		"GET /_cluster/health": `{"status":"red"}`,
+	})
+
+	assert.Error(t, csearch.NewElasticsearchEngine(csearch.Config{URL: url}).Ping(context.Background()))
+
+	_, url = newFakeBackend(t, map[string]string{
+		"GET /_cluster/health": `{"status":"yellow"}`,
+	})
+
+	assert.NoError(t, csearch.NewElasticsearchEngine(csearch.Config{URL: url}).Ping(context.Background()))
+}
@@ -0,0 +1,44 @@
+package csearch
+
+import "context"
+
+// IndexHook returns an event handler that indexes the document that fn builds from the event. If fn returns a nil
+// document, nothing is indexed. It can be subscribed to domain events to keep an index up to date. For example:
+//
+//	cevents.Subscribe(ps, eventsConfig, "search", csearch.IndexHook(engine, ProductsIndex,
+//		func(ctx context.Context, e ProductSaved) (csearch.Document, error) {
+//			product, err := products.GetProduct(ctx, e.ProductID)
+//			if err != nil {
+//				return nil, err
+//			}
+//
+//			return csearch.NewDocument(product)
+//		}))
+func IndexHook[T any](engine Engine, idx Index, fn func(ctx context.Context, event T) (Document, error)) func(
+	ctx context.Context, event T) error {
+	return func(ctx context.Context, event T) error {
+		doc, err := fn(ctx, event)
+		if err != nil {
+			return err
+		}
+
+		if doc == nil {
+			return nil
+		}
+
+		return engine.Index(ctx, idx, doc)
+	}
+}
+
+// DeleteHook returns an event handler that deletes the document with the id returned by fn from the index. If fn
+// returns an empty id, nothing is deleted.
+func DeleteHook[T any](engine Engine, idx Index, fn func(event T) string) func(ctx context.Context, event T) error {
+	return func(ctx context.Context, event T) error {
+		id := fn(event)
+		if id == "" {
+			return nil
+		}
+
+		return engine.Delete(ctx, idx, id)
+	}
+}
@@ -0,0 +1,224 @@
+package csearch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// NewMeilisearchEngine creates an Engine that uses the REST API of Meilisearch
+func NewMeilisearchEngine(config Config) *MeilisearchEngine {
+	return &MeilisearchEngine{
+		prefix: config.Prefix,
+		client: newClient(config, func(req *http.Request) {
+			if config.APIKey != "" {
+				req.Header.Set("Authorization", "Bearer "+config.APIKey)
+			}
+		}),
+	}
+}
+
+// MeilisearchEngine is an Engine backed by Meilisearch. Meilisearch applies writes asynchronously, so indexed
+// documents and index settings may take a moment to be searchable.
+type MeilisearchEngine struct {
+	prefix string
+	client *client
+}
+
+type meiliSearchResponse struct {
+	Hits               []Document `json:"hits"`
+	EstimatedTotalHits int        `json:"estimatedTotalHits"`
+}
+
+// CreateIndex creates the index and updates its settings. Creating an index that exists fails asynchronously in
+// Meilisearch, which is ignored.
+func (e *MeilisearchEngine) CreateIndex(ctx context.Context, idx Index) error {
+	uid := e.prefix + idx.Name
+
+	_, err := e.client.do(ctx, http.MethodPost, "/indexes", "application/json", map[string]string{
+		"uid":        uid,
+		"primaryKey": idx.Key(),
+	}, nil)
+	if err != nil {
+		return cerrors.New(err, "failed to create search index", map[string]interface{}{
+			"index": idx.Name,
+		})
+	}
+
+	searchable := idx.Searchable
+	if len(searchable) == 0 {
+		searchable = []string{"*"}
+	}
+
+	_, err = e.client.do(ctx, http.MethodPatch, e.indexPath(idx)+"/settings", "application/json",
+		map[string]interface{}{
+			"searchableAttributes": searchable,
+			"filterableAttributes": nonNil(idx.Filterable),
+			"sortableAttributes":   nonNil(idx.Sortable),
+		}, nil)
+	if err != nil {
+		return cerrors.New(err, "failed to update search index settings", map[string]interface{}{
+			"index": idx.Name,
+		})
+	}
+
+	return nil
+}
+
+// Index adds or replaces the documents
+func (e *MeilisearchEngine) Index(ctx context.Context, idx Index, docs ...Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	for _, doc := range docs {
+		if doc.ID(idx) == "" {
+			return cerrors.New(nil, "search document has no id", map[string]interface{}{
+				"index":      idx.Name,
+				"primaryKey": idx.Key(),
+			})
+		}
+	}
+
+	_, err := e.client.do(ctx, http.MethodPost,
+		e.indexPath(idx)+"/documents?primaryKey="+url.QueryEscape(idx.Key()), "application/json", docs, nil)
+	if err != nil {
+		return cerrors.New(err, "failed to index search documents", map[string]interface{}{
+			"index": idx.Name,
+		})
+	}
+
+	return nil
+}
+
+// Delete deletes the documents in a batch
+func (e *MeilisearchEngine) Delete(ctx context.Context, idx Index, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := e.client.do(ctx, http.MethodPost, e.indexPath(idx)+"/documents/delete-batch", "application/json",
+		ids, nil)
+	if err != nil {
+		return cerrors.New(err, "failed to delete search documents", map[string]interface{}{
+			"index": idx.Name,
+		})
+	}
+
+	return nil
+}
+
+// Search runs the query with its filters converted to a Meilisearch filter expression
+func (e *MeilisearchEngine) Search(ctx context.Context, idx Index, q *Query) (Results, error) {
+	filter, err := meiliFilter(q.Filters)
+	if err != nil {
+		return Results{}, err
+	}
+
+	body := map[string]interface{}{
+		"q":                q.Text,
+		"limit":            q.Size(),
+		"offset":           q.Offset,
+		"showRankingScore": true,
+	}
+
+	if filter != "" {
+		body["filter"] = filter
+	}
+
+	if len(q.Sort) > 0 {
+		sorts := make([]string, len(q.Sort))
+
+		for i, s := range q.Sort {
+			sorts[i] = s.Field + ":asc"
+			if s.Desc {
+				sorts[i] = s.Field + ":desc"
+			}
+		}
+
+		body["sort"] = sorts
+	}
+
+	var resp meiliSearchResponse
+
+	_, err = e.client.do(ctx, http.MethodPost, e.indexPath(idx)+"/search", "application/json", body, &resp)
+	if err != nil {
+		return Results{}, cerrors.New(err, "failed to search index", map[string]interface{}{
+			"index": idx.Name,
+		})
+	}
+
+	results := Results{
+		Total: resp.EstimatedTotalHits,
+		Hits:  make([]Hit, len(resp.Hits)),
+	}
+
+	for i, doc := range resp.Hits {
+		score, _ := doc["_rankingScore"].(float64)
+		delete(doc, "_rankingScore")
+
+		results.Hits[i] = Hit{ID: doc.ID(idx), Score: score, Document: doc}
+	}
+
+	return results, nil
+}
+
+// Ping returns an error if Meilisearch is unreachable or unavailable
+func (e *MeilisearchEngine) Ping(ctx context.Context) error {
+	var health struct {
+		Status string `json:"status"`
+	}
+
+	_, err := e.client.do(ctx, http.MethodGet, "/health", "", nil, &health)
+	if err != nil {
+		return err
+	}
+
+	if health.Status != "available" {
+		return cerrors.New(nil, "meilisearch is not available", map[string]interface{}{
+			"status": health.Status,
+		})
+	}
+
+	return nil
+}
+
+func (e *MeilisearchEngine) indexPath(idx Index) string {
+	return "/indexes/" + url.PathEscape(e.prefix+idx.Name)
+}
+
+// meiliFilter converts the filters into a filter expression (ex. category = "shoes" AND price <= 100). Values are
+// JSON encoded, which quotes and escapes strings.
+func meiliFilter(filters []Filter) (string, error) {
+	exprs := make([]string, len(filters))
+
+	for i, f := range filters {
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return "", cerrors.New(err, "failed to encode search filter", map[string]interface{}{
+				"field": f.Field,
+			})
+		}
+
+		op := f.Op
+		if op == OpIn {
+			op = "IN"
+		}
+
+		exprs[i] = f.Field + " " + op + " " + string(value)
+	}
+
+	return strings.Join(exprs, " AND "), nil
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+
+	return list
+}
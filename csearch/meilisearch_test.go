@@ -0,0 +1,107 @@
+package csearch_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gocopper/copper/csearch"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeilisearchEngine_CreateIndex(t *testing.T) {
+	t.Parallel()
+
+	backend, url := newFakeBackend(t, map[string]string{
+		"POST /indexes":                        `{"taskUid":1}`,
+		"PATCH /indexes/app_products/settings": `{"taskUid":2}`,
+	})
+
+	engine := csearch.NewMeilisearchEngine(csearch.Config{URL: url, APIKey: "test-key", Prefix: "app_"})
+
+	err := engine.CreateIndex(context.Background(), productsIndex)
+	assert.NoError(t, err)
+
+	create := backend.request(t, 0)
+	assert.Equal(t, "Bearer test-key", create.Auth)
+	assert.JSONEq(t, `{"uid":"app_products","primaryKey":"id"}`, create.Body)
+
+	settings := backend.request(t, 1)
+	assert.Equal(t, http.MethodPatch, settings.Method)
+	assert.JSONEq(t, `{
+		"searchableAttributes": ["name", "category"],
+		"filterableAttributes": ["category", "price"],
+		"sortableAttributes": ["price"]
+	}`, settings.Body)
+}
+
+func TestMeilisearchEngine_IndexDelete(t *testing.T) {
+	t.Parallel()
+
+	backend, url := newFakeBackend(t, map[string]string{
+		"POST /indexes/products/documents":              `{"taskUid":1}`,
+		"POST /indexes/products/documents/delete-batch": `{"taskUid":2}`,
+	})
+
+	engine := csearch.NewMeilisearchEngine(csearch.Config{URL: url})
+
+	err := engine.Index(context.Background(), productsIndex, csearch.Document{"id": "1", "name": "Shoes"})
+	assert.NoError(t, err)
+
+	err = engine.Delete(context.Background(), productsIndex, "1", "2")
+	assert.NoError(t, err)
+
+	index := backend.request(t, 0)
+	assert.Equal(t, "/indexes/products/documents?primaryKey=id", index.Path)
+	assert.JSONEq(t, `[{"id":"1","name":"Shoes"}]`, index.Body)
+
+	assert.JSONEq(t, `["1","2"]`, backend.request(t, 1).Body)
+}
+
+func TestMeilisearchEngine_Search(t *testing.T) {
+	t.Parallel()
+
+	backend, url := newFakeBackend(t, map[string]string{
+		"POST /indexes/products/search": `{"hits":[{"id":"1","name":"Shoes","_rankingScore":0.9}],` +
+			`"estimatedTotalHits":3}`,
+	})
+
+	engine := csearch.NewMeilisearchEngine(csearch.Config{URL: url})
+
+	results, err := engine.Search(context.Background(), productsIndex, csearch.Match("shoes").
+		Where(csearch.Eq("category", `foot "wear"`), csearch.In("price", 50, 60), csearch.Gt("price", 10)).
+		OrderBy(csearch.Asc("price")))
+	assert.NoError(t, err)
+
+	assert.Equal(t, csearch.Results{
+		Total: 3,
+		Hits:  []csearch.Hit{{ID: "1", Score: 0.9, Document: csearch.Document{"id": "1", "name": "Shoes"}}},
+	}, results)
+
+	var body map[string]interface{}
+
+	assert.NoError(t, json.Unmarshal([]byte(backend.request(t, 0).Body), &body))
+	assert.Equal(t, map[string]interface{}{
+		"q":                "shoes",
+		"filter":           `category = "foot \"wear\"" AND price IN [50,60] AND price > 10`,
+		"sort":             []interface{}{"price:asc"},
+		"limit":            float64(csearch.DefaultLimit),
+		"offset":           float64(0),
+		"showRankingScore": true,
+	}, body)
+}
+
+func TestMeilisearchEngine_Ping(t *testing.T) {
+	t.Parallel()
+
+	_, url := newFakeBackend(t, map[string]string{
+		"GET /health": `{"status":"available"}`,
+	})
+
+	assert.NoError(t, csearch.NewMeilisearchEngine(csearch.Config{URL: url}).Ping(context.Background()))
+
+	_, url = newFakeBackend(t, nil)
+
+	assert.Error(t, csearch.NewMeilisearchEngine(csearch.Config{URL: url}).Ping(context.Background()))
+}
@@ -0,0 +1,127 @@
+package csearch
+
+// DefaultLimit is the max number of hits returned by a query if Query.Limit is not set
+const DefaultLimit = 20
+
+// Filter operators
+const (
+	OpEq  = "="
+	OpIn  = "in"
+	OpGt  = ">"
+	OpGte = ">="
+	OpLt  = "<"
+	OpLte = "<="
+)
+
+// Filter restricts the documents that match a query to the ones whose field matches the value. See Eq, In, Gt, Gte,
+// Lt, and Lte.
+type Filter struct {
+	Field string
+	Op    string
+	Value interface{}
+}
+
+// Eq matches the documents whose field is equal to value
+func Eq(field string, value interface{}) Filter {
+	return Filter{Field: field, Op: OpEq, Value: value}
+}
+
+// In matches the documents whose field is equal to one of values
+func In(field string, values ...interface{}) Filter {
+	return Filter{Field: field, Op: OpIn, Value: values}
+}
+
+// Gt matches the documents whose field is greater than value
+func Gt(field string, value interface{}) Filter {
+	return Filter{Field: field, Op: OpGt, Value: value}
+}
+
+// Gte matches the documents whose field is greater than or equal to value
+func Gte(field string, value interface{}) Filter {
+	return Filter{Field: field, Op: OpGte, Value: value}
+}
+
+// Lt matches the documents whose field is less than value
+func Lt(field string, value interface{}) Filter {
+	return Filter{Field: field, Op: OpLt, Value: value}
+}
+
+// Lte matches the documents whose field is less than or equal to value
+func Lte(field string, value interface{}) Filter {
+	return Filter{Field: field, Op: OpLte, Value: value}
+}
+
+// Sort orders the results by a field
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Asc sorts the results by field in ascending order
+func Asc(field string) Sort {
+	return Sort{Field: field}
+}
+
+// Desc sorts the results by field in descending order
+func Desc(field string) Sort {
+	return Sort{Field: field, Desc: true}
+}
+
+// Query selects the documents returned by Engine.Search. For example:
+//
+//	results, err := engine.Search(ctx, ProductsIndex, csearch.Match("running shoes").
+//		Where(csearch.Eq("in_stock", true), csearch.Lte("price", 100)).
+//		OrderBy(csearch.Desc("created_at")).
+//		Page(20, 40))
+//
+// Results are sorted by relevance unless they are ordered by a field. Filters and sorts can only use the fields
+// declared as Index.Filterable and Index.Sortable.
+type Query struct {
+	// Text is matched against the index's searchable fields. If it is empty, all documents match.
+	Text    string
+	Filters []Filter
+	Sort    []Sort
+
+	// Limit is the max number of hits returned (default: 20)
+	Limit  int
+	Offset int
+}
+
+// Match starts a query that matches text
+func Match(text string) *Query {
+	return &Query{Text: text}
+}
+
+// All starts a query that matches all documents
+func All() *Query {
+	return &Query{}
+}
+
+// Where adds filters that are combined with the other filters using and
+func (q *Query) Where(filters ...Filter) *Query {
+	q.Filters = append(q.Filters, filters...)
+	return q
+}
+
+// OrderBy adds sorts. Results are ordered by the first sort, then by the next ones.
+func (q *Query) OrderBy(sorts ...Sort) *Query {
+	q.Sort = append(q.Sort, sorts...)
+	return q
+}
+
+// Page sets the max number of hits returned and the number of hits skipped
+func (q *Query) Page(limit, offset int) *Query {
+	q.Limit = limit
+	q.Offset = offset
+
+	return q
+}
+
+// Size returns the max number of hits returned by the query
+func (q *Query) Size() int {
+	if q.Limit <= 0 {
+		return DefaultLimit
+	}
+
+	return q.Limit
+}
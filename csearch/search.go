@@ -0,0 +1,170 @@
+package csearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/chealth"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+)
+
+const defaultPrimaryKey = "id"
+
+// Index defines a search index. For example:
+//
+//	var ProductsIndex = csearch.Index{
+//		Name:       "products",
+//		Searchable: []string{"name", "description"},
+//		Filterable: []string{"category", "price", "in_stock"},
+//		Sortable:   []string{"price", "created_at"},
+//	}
+type Index struct {
+	Name string
+
+	// PrimaryKey is the field of the documents that holds their id (default: id)
+	PrimaryKey string
+
+	// Searchable are the fields matched by Query.Text in order of importance. If it is empty, all fields are.
+	Searchable []string
+
+	// Filterable are the fields that can be used in Query.Filters
+	Filterable []string
+
+	// Sortable are the fields that can be used in Query.Sort
+	Sortable []string
+}
+
+// Key returns the index's primary key
+func (idx Index) Key() string {
+	if idx.PrimaryKey == "" {
+		return defaultPrimaryKey
+	}
+
+	return idx.PrimaryKey
+}
+
+// Document is an indexed document. Its id is stored in the index's primary key field.
+type Document map[string]interface{}
+
+// NewDocument converts v to a Document using its JSON encoding
+func NewDocument(v interface{}) (Document, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to encode search document", nil)
+	}
+
+	var doc Document
+
+	err = json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to decode search document", nil)
+	}
+
+	return doc, nil
+}
+
+// ID returns the value of the document's primary key as a string
+func (d Document) ID(idx Index) string {
+	switch id := d[idx.Key()].(type) {
+	case nil:
+		return ""
+	case string:
+		return id
+	case float64:
+		return fmt.Sprintf("%.0f", id)
+	default:
+		return fmt.Sprint(id)
+	}
+}
+
+// Hit is a document that matched a query
+type Hit struct {
+	ID       string
+	Score    float64
+	Document Document
+}
+
+// Results are the documents that matched a query
+type Results struct {
+	// Total is the number of documents that matched the query. Meilisearch returns an estimate.
+	Total int
+	Hits  []Hit
+}
+
+// Decode decodes the documents of the hits into dest, which must be a pointer to a slice
+func (r Results) Decode(dest interface{}) error {
+	docs := make([]Document, len(r.Hits))
+	for i := range r.Hits {
+		docs[i] = r.Hits[i].Document
+	}
+
+	data, err := json.Marshal(docs)
+	if err != nil {
+		return cerrors.New(err, "failed to encode search hits", nil)
+	}
+
+	err = json.Unmarshal(data, dest)
+	if err != nil {
+		return cerrors.New(err, "failed to decode search hits", nil)
+	}
+
+	return nil
+}
+
+// Engine indexes and searches documents
+type Engine interface {
+	// CreateIndex creates the index, or updates its settings if it already exists
+	CreateIndex(ctx context.Context, idx Index) error
+
+	// Index adds the documents to the index, replacing the documents with the same ids
+	Index(ctx context.Context, idx Index, docs ...Document) error
+
+	// Delete deletes the documents with the ids from the index. Deleting a document that does not exist is not an
+	// error.
+	Delete(ctx context.Context, idx Index, ids ...string) error
+
+	// Search returns the documents of the index that match the query
+	Search(ctx context.Context, idx Index, q *Query) (Results, error)
+
+	// Ping returns an error if the backend is unavailable
+	Ping(ctx context.Context) error
+}
+
+// NewEngineParams holds the params needed for NewEngine
+type NewEngineParams struct {
+	Lifecycle *clifecycle.Lifecycle
+	Config    Config
+	Logger    clogger.Logger
+}
+
+// NewEngine creates the Engine configured by Config.Backend and registers a readiness checker that pings it
+func NewEngine(p NewEngineParams) (Engine, error) {
+	var engine Engine
+
+	switch p.Config.Backend {
+	case BackendElasticsearch, BackendOpenSearch:
+		engine = NewElasticsearchEngine(p.Config)
+	case BackendMeilisearch:
+		engine = NewMeilisearchEngine(p.Config)
+	default:
+		return nil, cerrors.New(nil, "unknown csearch backend", map[string]interface{}{
+			"backend": p.Config.Backend,
+		})
+	}
+
+	unregister := chealth.Register("csearch", chealth.CheckerFunc(engine.Ping))
+
+	p.Lifecycle.OnStop(func(ctx context.Context) error {
+		unregister()
+		return nil
+	})
+
+	p.Logger.With(clogger.Module("csearch")).WithTags(map[string]interface{}{
+		"backend": p.Config.Backend,
+	}).Info("Created search engine")
+
+	return engine, nil
+}
@@ -0,0 +1,393 @@
+package csql
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/jmoiron/sqlx"
+)
+
+// Args are the named args of a query built using Select, Insert, Update, or Delete. They are referenced in conditions
+// using :name. Slices are expanded so that they can be used with IN (ex. "id in (:ids)").
+type Args map[string]interface{}
+
+// SelectBuilder builds a select query. See Select.
+type SelectBuilder struct {
+	columns []string
+	from    string
+	joins   []string
+	where   whereClause
+	groupBy []string
+	orderBy []string
+	limit   int
+	offset  int
+}
+
+// Select starts a select query for the given columns. For example:
+//
+//	var users []User
+//
+//	err := csql.Select("id", "email").
+//		From("users").
+//		Where("org_id = :org", csql.Args{"org": orgID}).
+//		Where("role in (:roles)", csql.Args{"roles": roles}).
+//		OrderBy("created_at desc").
+//		Limit(20).
+//		All(ctx, querier, &users)
+//
+// Like Querier, the query runs within the transaction in the context.
+func Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{columns: columns}
+}
+
+// From sets the table that is selected from
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	b.from = table
+	return b
+}
+
+// Join adds a join clause (ex. "join orgs on orgs.id = users.org_id")
+func (b *SelectBuilder) Join(join string) *SelectBuilder {
+	b.joins = append(b.joins, join)
+	return b
+}
+
+// Where adds a condition that is combined with the other conditions using and
+func (b *SelectBuilder) Where(cond string, args ...Args) *SelectBuilder {
+	b.where.add(cond, args)
+	return b
+}
+
+// GroupBy adds group by columns
+func (b *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	b.groupBy = append(b.groupBy, columns...)
+	return b
+}
+
+// OrderBy adds order by expressions (ex. "created_at desc")
+func (b *SelectBuilder) OrderBy(exprs ...string) *SelectBuilder {
+	b.orderBy = append(b.orderBy, exprs...)
+	return b
+}
+
+// Limit sets the max number of rows returned
+func (b *SelectBuilder) Limit(limit int) *SelectBuilder {
+	b.limit = limit
+	return b
+}
+
+// Offset sets the number of rows skipped
+func (b *SelectBuilder) Offset(offset int) *SelectBuilder {
+	b.offset = offset
+	return b
+}
+
+// Build returns the query and its positional args. The query uses ? bind vars that are rebound to the dialect's by
+// Querier.
+func (b *SelectBuilder) Build() (string, []interface{}, error) {
+	var query strings.Builder
+
+	columns := b.columns
+	if len(columns) == 0 {
+		columns = []string{"*"}
+	}
+
+	query.WriteString("select " + strings.Join(columns, ", ") + " from " + b.from)
+
+	for _, join := range b.joins {
+		query.WriteString(" " + join)
+	}
+
+	query.WriteString(b.where.String())
+
+	if len(b.groupBy) > 0 {
+		query.WriteString(" group by " + strings.Join(b.groupBy, ", "))
+	}
+
+	if len(b.orderBy) > 0 {
+		query.WriteString(" order by " + strings.Join(b.orderBy, ", "))
+	}
+
+	if b.limit > 0 {
+		query.WriteString(" limit " + strconv.Itoa(b.limit))
+	}
+
+	if b.offset > 0 {
+		query.WriteString(" offset " + strconv.Itoa(b.offset))
+	}
+
+	return bindNamed(query.String(), b.where.args)
+}
+
+// One runs the query using querier and scans the first row into dest. It returns sql.ErrNoRows if there are no rows.
+func (b *SelectBuilder) One(ctx context.Context, querier Querier, dest interface{}) error {
+	query, args, err := b.Build()
+	if err != nil {
+		return err
+	}
+
+	return querier.Get(ctx, dest, query, args...)
+}
+
+// All runs the query using querier and scans the rows into dest, which must be a pointer to a slice
+func (b *SelectBuilder) All(ctx context.Context, querier Querier, dest interface{}) error {
+	query, args, err := b.Build()
+	if err != nil {
+		return err
+	}
+
+	return querier.Select(ctx, dest, query, args...)
+}
+
+// InsertBuilder builds an insert query. See Insert.
+type InsertBuilder struct {
+	table     string
+	values    Args
+	returning []string
+}
+
+// Insert starts an insert query into the given table. For example:
+//
+//	_, err := csql.Insert("users").
+//		Values(csql.Args{"id": id, "email": email}).
+//		Exec(ctx, querier)
+func Insert(table string) *InsertBuilder {
+	return &InsertBuilder{table: table, values: make(Args)}
+}
+
+// Values sets the values of the inserted row by column
+func (b *InsertBuilder) Values(values Args) *InsertBuilder {
+	for col, val := range values {
+		b.values[col] = val
+	}
+
+	return b
+}
+
+// Returning adds a returning clause so that the inserted row can be scanned using One (Postgres and SQLite only)
+func (b *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	b.returning = append(b.returning, columns...)
+	return b
+}
+
+// Build returns the query and its positional args
+func (b *InsertBuilder) Build() (string, []interface{}, error) {
+	if len(b.values) == 0 {
+		return "", nil, cerrors.New(nil, "insert query has no values", map[string]interface{}{
+			"table": b.table,
+		})
+	}
+
+	var (
+		columns = sortedKeys(b.values)
+		params  = make([]string, len(columns))
+	)
+
+	for i, col := range columns {
+		params[i] = ":" + col
+	}
+
+	query := "insert into " + b.table + " (" + strings.Join(columns, ", ") + ") values (" +
+		strings.Join(params, ", ") + ")"
+
+	if len(b.returning) > 0 {
+		query += " returning " + strings.Join(b.returning, ", ")
+	}
+
+	return bindNamed(query, b.values)
+}
+
+// Exec runs the query using querier
+func (b *InsertBuilder) Exec(ctx context.Context, querier Querier) (sql.Result, error) {
+	query, args, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return querier.Exec(ctx, query, args...)
+}
+
+// One runs the query using querier and scans the returned row into dest (see Returning)
+func (b *InsertBuilder) One(ctx context.Context, querier Querier, dest interface{}) error {
+	query, args, err := b.Build()
+	if err != nil {
+		return err
+	}
+
+	return querier.Get(ctx, dest, query, args...)
+}
+
+// UpdateBuilder builds an update query. See Update.
+type UpdateBuilder struct {
+	table string
+	set   Args
+	where whereClause
+}
+
+// Update starts an update query of the given table. For example:
+//
+//	_, err := csql.Update("users").
+//		Set(csql.Args{"email": email}).
+//		Where("id = :id", csql.Args{"id": id}).
+//		Exec(ctx, querier)
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table, set: make(Args)}
+}
+
+// Set sets the values of the updated columns
+func (b *UpdateBuilder) Set(values Args) *UpdateBuilder {
+	for col, val := range values {
+		b.set[col] = val
+	}
+
+	return b
+}
+
+// Where adds a condition that is combined with the other conditions using and
+func (b *UpdateBuilder) Where(cond string, args ...Args) *UpdateBuilder {
+	b.where.add(cond, args)
+	return b
+}
+
+// Build returns the query and its positional args
+func (b *UpdateBuilder) Build() (string, []interface{}, error) {
+	if len(b.set) == 0 {
+		return "", nil, cerrors.New(nil, "update query has no values", map[string]interface{}{
+			"table": b.table,
+		})
+	}
+
+	var (
+		columns = sortedKeys(b.set)
+		sets    = make([]string, len(columns))
+		args    = make(Args, len(b.set)+len(b.where.args))
+	)
+
+	for name, val := range b.where.args {
+		args[name] = val
+	}
+
+	// set values use their own param names so that they do not conflict with the args of the conditions
+	for i, col := range columns {
+		sets[i] = col + " = :csql_set_" + col
+		args["csql_set_"+col] = b.set[col]
+	}
+
+	query := "update " + b.table + " set " + strings.Join(sets, ", ") + b.where.String()
+
+	return bindNamed(query, args)
+}
+
+// Exec runs the query using querier
+func (b *UpdateBuilder) Exec(ctx context.Context, querier Querier) (sql.Result, error) {
+	query, args, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return querier.Exec(ctx, query, args...)
+}
+
+// DeleteBuilder builds a delete query. See Delete.
+type DeleteBuilder struct {
+	table string
+	where whereClause
+}
+
+// Delete starts a delete query from the given table. For example:
+//
+//	_, err := csql.Delete("sessions").
+//		Where("expires_at < :now", csql.Args{"now": time.Now()}).
+//		Exec(ctx, querier)
+func Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+// Where adds a condition that is combined with the other conditions using and
+func (b *DeleteBuilder) Where(cond string, args ...Args) *DeleteBuilder {
+	b.where.add(cond, args)
+	return b
+}
+
+// Build returns the query and its positional args
+func (b *DeleteBuilder) Build() (string, []interface{}, error) {
+	return bindNamed("delete from "+b.table+b.where.String(), b.where.args)
+}
+
+// Exec runs the query using querier
+func (b *DeleteBuilder) Exec(ctx context.Context, querier Querier) (sql.Result, error) {
+	query, args, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return querier.Exec(ctx, query, args...)
+}
+
+type whereClause struct {
+	conds []string
+	args  Args
+}
+
+func (w *whereClause) add(cond string, args []Args) {
+	w.conds = append(w.conds, cond)
+
+	if w.args == nil {
+		w.args = make(Args)
+	}
+
+	for _, a := range args {
+		for name, val := range a {
+			w.args[name] = val
+		}
+	}
+}
+
+func (w *whereClause) String() string {
+	switch len(w.conds) {
+	case 0:
+		return ""
+	case 1:
+		return " where " + w.conds[0]
+	default:
+		return " where (" + strings.Join(w.conds, ") and (") + ")"
+	}
+}
+
+// bindNamed replaces the named args in query with ? bind vars and expands slice args
+func bindNamed(query string, args Args) (string, []interface{}, error) {
+	if args == nil {
+		args = Args{}
+	}
+
+	query, bound, err := sqlx.Named(query, map[string]interface{}(args))
+	if err != nil {
+		return "", nil, cerrors.New(err, "failed to bind named args", map[string]interface{}{
+			"query": query,
+		})
+	}
+
+	query, bound, err = sqlx.In(query, bound...)
+	if err != nil {
+		return "", nil, cerrors.New(err, "failed to expand args", map[string]interface{}{
+			"query": query,
+		})
+	}
+
+	return query, bound, nil
+}
+
+func sortedKeys(args Args) []string {
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,82 @@
+package csql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gocopper/copper/csql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelect_Build(t *testing.T) {
+	t.Parallel()
+
+	query, args, err := csql.Select("id", "email").
+		From("users").
+		Join("join orgs on orgs.id = users.org_id").
+		Where("org_id = :org", csql.Args{"org": "acme"}).
+		Where("role in (:roles)", csql.Args{"roles": []string{"admin", "owner"}}).
+		OrderBy("created_at desc").
+		Limit(20).
+		Offset(40).
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, "select id, email from users join orgs on orgs.id = users.org_id "+
+		"where (org_id = ?) and (role in (?, ?)) order by created_at desc limit 20 offset 40", query)
+	assert.Equal(t, []interface{}{"acme", "admin", "owner"}, args)
+}
+
+func TestSelect_Build_MissingArg(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := csql.Select().From("users").Where("id = :id").Build()
+	assert.Error(t, err)
+}
+
+func TestUpdate_Build(t *testing.T) {
+	t.Parallel()
+
+	query, args, err := csql.Update("users").
+		Set(csql.Args{"name": "bob", "email": "bob@example.com"}).
+		Where("email = :email", csql.Args{"email": "alice@example.com"}).
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, "update users set email = ?, name = ? where email = ?", query)
+	assert.Equal(t, []interface{}{"bob@example.com", "bob", "alice@example.com"}, args)
+}
+
+func TestBuilder_Querier(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db      = openTestDB(t, "alice")
+		querier = csql.NewQuerier(db, csql.Config{Dialect: "sqlite3"})
+		names   []string
+		name    string
+	)
+
+	ctx, tx, err := csql.CtxWithTx(context.Background(), db, "sqlite3")
+	assert.NoError(t, err)
+
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = csql.Insert("people").Values(csql.Args{"name": "bob"}).Exec(ctx, querier)
+	assert.NoError(t, err)
+
+	_, err = csql.Update("people").
+		Set(csql.Args{"name": "carol"}).
+		Where("name = :name", csql.Args{"name": "alice"}).
+		Exec(ctx, querier)
+	assert.NoError(t, err)
+
+	err = csql.Select("name").From("people").OrderBy("name").All(ctx, querier, &names)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bob", "carol"}, names)
+
+	_, err = csql.Delete("people").Where("name = :name", csql.Args{"name": "bob"}).Exec(ctx, querier)
+	assert.NoError(t, err)
+
+	err = csql.Select("name").From("people").One(ctx, querier, &name)
+	assert.NoError(t, err)
+	assert.Equal(t, "carol", name)
+}
@@ -1,11 +1,10 @@
 package csql
 
 import (
-	"strings"
+	"time"
 
 	"github.com/gocopper/copper/cconfig"
 	"github.com/gocopper/copper/cerrors"
-	migrate "github.com/rubenv/sql-migrate"
 )
 
 // MigrationsSource are valid options for csql.migrations.source configuration option.
@@ -23,48 +22,79 @@ const (
 	MigrationsDirectionDown = "down"
 )
 
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "csql",
+		Description: "csql configures the SQL database connection and migrations",
+		Default:     defaultConfig(),
+	})
+}
+
 // LoadConfig loads the csql config from the app config
 func LoadConfig(appConfig cconfig.Loader) (Config, error) {
-	config := Config{
-		Migrations: ConfigMigrations{
-			Direction: MigrationsDirectionUp,
-			Source:    MigrationsSourceEmbed,
-		},
-	}
+	config := defaultConfig()
 
 	err := appConfig.Load("csql", &config)
 	if err != nil {
 		return Config{}, cerrors.New(err, "failed to load sql config", nil)
 	}
 
+	config.Dialect = normalizeDialect(config.Dialect)
+
 	return config, nil
 }
 
+func defaultConfig() Config {
+	return Config{
+		Migrations: ConfigMigrations{
+			Direction: MigrationsDirectionUp,
+			Source:    MigrationsSourceEmbed,
+		},
+		ConnectTimeout:     defaultConnectTimeout,
+		ConnectRetries:     defaultConnectRetries,
+		SlowQueryThreshold: defaultSlowQueryThreshold,
+		Replicas: ConfigReplicas{
+			HealthCheckInterval: defaultReplicaHealthCheckInterval,
+		},
+		Seeds: ConfigSeeds{
+			Environments: []string{"", "dev", "development", "local", "test"},
+		},
+	}
+}
+
 type (
 	// Config configures the csql module
 	Config struct {
-		Dialect            string           `toml:"dialect"`
-		DSN                string           `toml:"dsn"`
+		Dialect            string           `toml:"dialect" valid:"required" doc:"Database driver: postgres, mysql, or sqlite3"`
+		DSN                string           `toml:"dsn" valid:"required" doc:"Data source name used to connect to the database"`
 		Migrations         ConfigMigrations `toml:"migrations"`
-		MaxOpenConnections *int             `toml:"max_open_connections"`
+		MaxOpenConnections *int             `toml:"max_open_connections" doc:"Maximum number of open connections (unlimited if not set)"`
+		MaxIdleConnections *int             `toml:"max_idle_connections" doc:"Maximum number of idle connections (2 if not set)"`
+		ConnMaxLifetime    time.Duration    `toml:"conn_max_lifetime" doc:"Maximum time a connection is reused (forever if 0)"`
+		ConnMaxIdleTime    time.Duration    `toml:"conn_max_idle_time" doc:"Maximum time a connection is idle (forever if 0)"`
+		ConnectTimeout     time.Duration    `toml:"connect_timeout" doc:"Time the startup ping has to connect to the database"`
+		ConnectRetries     int              `toml:"connect_retries" doc:"Number of times the startup ping is retried with backoff"`
+		SlowQueryThreshold time.Duration    `toml:"slow_query_threshold" doc:"Queries slower than this are logged as warnings"`
+		StatementCacheSize int              `toml:"statement_cache_size" doc:"Number of prepared statements cached for queries outside transactions (disabled if 0)"`
+		Replicas           ConfigReplicas   `toml:"replicas" doc:"Read replicas used by queries run with csql.CtxWithReplica"`
+		Seeds              ConfigSeeds      `toml:"seeds" doc:"Seeds registered using csql.RegisterSeeds"`
+	}
+
+	// ConfigSeeds configures the seeds
+	ConfigSeeds struct {
+		RunOnBoot    bool     `toml:"run_on_boot" doc:"Run the seeds when the app starts"`
+		Environments []string `toml:"environments" doc:"Environments (APP_ENV) that can be seeded; \"\" is the default env"`
+	}
+
+	// ConfigReplicas configures the read replicas
+	ConfigReplicas struct {
+		DSNs                []string      `toml:"dsns" doc:"Data source names used to connect to the read replicas"`
+		HealthCheckInterval time.Duration `toml:"health_check_interval" doc:"How often replicas are pinged"`
 	}
 
 	// ConfigMigrations configures the migrations
 	ConfigMigrations struct {
-		Direction string `toml:"direction"`
-		Source    string `toml:"source"`
+		Direction string `toml:"direction" doc:"up or down"`
+		Source    string `toml:"source" doc:"embed or dir"`
 	}
 )
-
-func (cm ConfigMigrations) sqlMigrateDirection() (migrate.MigrationDirection, error) {
-	switch strings.ToLower(cm.Direction) {
-	case MigrationsDirectionUp:
-		return migrate.Up, nil
-	case MigrationsDirectionDown:
-		return migrate.Down, nil
-	default:
-		return 0, cerrors.New(nil, "invalid migration direction", map[string]interface{}{
-			"direction": cm.Direction,
-		})
-	}
-}
@@ -0,0 +1,287 @@
+package csqltest
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/csql"
+	"github.com/stretchr/testify/assert"
+)
+
+// Environment variables with the DSN of the database server used when Options.DSN is not set
+const (
+	EnvVarPostgresDSN = "CSQLTEST_POSTGRES_DSN"
+	EnvVarMySQLDSN    = "CSQLTEST_MYSQL_DSN"
+)
+
+// Options configures the test database
+type Options struct {
+	// Dialect of the database (default: sqlite3)
+	Dialect string
+
+	// DSN of the Postgres or MySQL server the test database is created in. If it is not set, the DSN is read from
+	// CSQLTEST_POSTGRES_DSN or CSQLTEST_MYSQL_DSN and the test is skipped if that is not set either (see also
+	// StartContainer). It is not used for SQLite, which uses an in-memory database.
+	DSN string
+
+	// Migrations are the app's migrations. The migrations of modules registered using csql.RegisterMigrations are
+	// always applied.
+	Migrations csql.Migrations
+}
+
+// DB is an isolated test database with the migrations applied
+type DB struct {
+	DB      *sql.DB
+	Config  csql.Config
+	Querier csql.Querier
+}
+
+// NewDB creates an isolated database for the test and applies the migrations. The database is dropped when the test
+// ends. Use Ctx to run each test in a transaction that is rolled back instead.
+func NewDB(t testing.TB, opts Options) *DB {
+	t.Helper()
+
+	if opts.Dialect == "" {
+		opts.Dialect = csql.DialectSQLite
+	}
+
+	if opts.DSN == "" {
+		opts.DSN = dsnFromEnv(opts.Dialect)
+	}
+
+	if opts.DSN == "" && opts.Dialect != csql.DialectSQLite {
+		t.Skipf("csqltest: set %s to run tests against %s", envVarForDialect(opts.Dialect), opts.Dialect)
+	}
+
+	db, teardown, err := Open(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("csqltest: %v", err)
+	}
+
+	t.Cleanup(func() {
+		assert.NoError(t, teardown())
+	})
+
+	return db
+}
+
+// Open creates an isolated database and applies the migrations. The returned func drops the database. It can be used
+// in TestMain to share a database between the tests of a package (see Ctx).
+func Open(ctx context.Context, opts Options) (*DB, func() error, error) {
+	if opts.Dialect == "" {
+		opts.Dialect = csql.DialectSQLite
+	}
+
+	err := csql.ValidateMigrations(embed.FS(opts.Migrations))
+	if err != nil {
+		return nil, nil, cerrors.New(err, "invalid migrations", nil)
+	}
+
+	name := "csqltest_" + randomSuffix()
+
+	dsn, drop, err := createDatabase(ctx, opts.Dialect, opts.DSN, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config := csql.Config{
+		Dialect: opts.Dialect,
+		DSN:     dsn,
+		Migrations: csql.ConfigMigrations{
+			Direction: csql.MigrationsDirectionUp,
+			Source:    csql.MigrationsSourceEmbed,
+		},
+	}
+
+	db, err := sql.Open(opts.Dialect, dsn)
+	if err != nil {
+		_ = drop()
+		return nil, nil, cerrors.New(err, "failed to open test db", nil)
+	}
+
+	if opts.Dialect == csql.DialectSQLite {
+		// Each connection to an in-memory database opens a new, empty database
+		db.SetMaxOpenConns(1)
+	}
+
+	teardown := func() error {
+		err := db.Close()
+		if err != nil {
+			return cerrors.New(err, "failed to close test db", nil)
+		}
+
+		return drop()
+	}
+
+	_, err = csql.NewMigrator(csql.NewMigratorParams{
+		DB:         db,
+		Migrations: opts.Migrations,
+		Config:     config,
+		Logger:     clogger.NewNoop(),
+	}).Migrate(ctx)
+	if err != nil {
+		_ = teardown()
+		return nil, nil, cerrors.New(err, "failed to migrate test db", nil)
+	}
+
+	return &DB{
+		DB:      db,
+		Config:  config,
+		Querier: csql.NewQuerier(db, config),
+	}, teardown, nil
+}
+
+// Ctx returns a context with a database transaction that is rolled back when the test ends. Tests that only use the
+// returned context are isolated from each other without creating a database per test.
+func (db *DB) Ctx(t testing.TB) context.Context {
+	t.Helper()
+
+	ctx, tx, err := csql.CtxWithTx(context.Background(), db.DB, db.Config.Dialect)
+	if err != nil {
+		t.Fatalf("csqltest: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = tx.Rollback()
+	})
+
+	return ctx
+}
+
+// createDatabase creates a database named name and returns its DSN along with a func that drops it
+func createDatabase(ctx context.Context, dialect, serverDSN, name string) (string, func() error, error) {
+	noop := func() error { return nil }
+
+	switch dialect {
+	case csql.DialectSQLite:
+		return "file:" + name + "?mode=memory&cache=shared", noop, nil
+	case csql.DialectPostgres:
+		return createPostgresSchema(ctx, serverDSN, name)
+	case csql.DialectMySQL:
+		return createMySQLDatabase(ctx, serverDSN, name)
+	default:
+		return "", nil, cerrors.New(nil, "unsupported test db dialect", map[string]interface{}{
+			"dialect": dialect,
+		})
+	}
+}
+
+// createPostgresSchema creates a schema and returns a DSN that uses it as the search path
+func createPostgresSchema(ctx context.Context, serverDSN, name string) (string, func() error, error) {
+	admin, err := sql.Open(csql.DialectPostgres, serverDSN)
+	if err != nil {
+		return "", nil, cerrors.New(err, "failed to open postgres server db", nil)
+	}
+
+	_, err = admin.ExecContext(ctx, "create schema "+name)
+	if err != nil {
+		_ = admin.Close()
+		return "", nil, cerrors.New(err, "failed to create test schema", map[string]interface{}{
+			"schema": name,
+		})
+	}
+
+	drop := func() error {
+		defer func() { _ = admin.Close() }()
+
+		_, err := admin.Exec("drop schema " + name + " cascade")
+		if err != nil {
+			return cerrors.New(err, "failed to drop test schema", map[string]interface{}{
+				"schema": name,
+			})
+		}
+
+		return nil
+	}
+
+	return withDSNParam(serverDSN, "search_path", name), drop, nil
+}
+
+// createMySQLDatabase creates a database and returns a DSN that connects to it
+func createMySQLDatabase(ctx context.Context, serverDSN, name string) (string, func() error, error) {
+	cfg, err := mysql.ParseDSN(serverDSN)
+	if err != nil {
+		return "", nil, cerrors.New(err, "invalid mysql dsn", nil)
+	}
+
+	admin, err := sql.Open(csql.DialectMySQL, serverDSN)
+	if err != nil {
+		return "", nil, cerrors.New(err, "failed to open mysql server db", nil)
+	}
+
+	_, err = admin.ExecContext(ctx, "create database "+name)
+	if err != nil {
+		_ = admin.Close()
+		return "", nil, cerrors.New(err, "failed to create test database", map[string]interface{}{
+			"database": name,
+		})
+	}
+
+	drop := func() error {
+		defer func() { _ = admin.Close() }()
+
+		_, err := admin.Exec("drop database " + name)
+		if err != nil {
+			return cerrors.New(err, "failed to drop test database", map[string]interface{}{
+				"database": name,
+			})
+		}
+
+		return nil
+	}
+
+	cfg.DBName = name
+	cfg.ParseTime = true
+
+	return cfg.FormatDSN(), drop, nil
+}
+
+// withDSNParam adds a param to a URL or key=value Postgres DSN
+func withDSNParam(dsn, key, val string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		q := u.Query()
+		q.Set(key, val)
+		u.RawQuery = q.Encode()
+
+		return u.String()
+	}
+
+	return strings.TrimSpace(dsn) + " " + key + "=" + val
+}
+
+func dsnFromEnv(dialect string) string {
+	env := envVarForDialect(dialect)
+	if env == "" {
+		return ""
+	}
+
+	return os.Getenv(env)
+}
+
+func envVarForDialect(dialect string) string {
+	switch dialect {
+	case csql.DialectPostgres:
+		return EnvVarPostgresDSN
+	case csql.DialectMySQL:
+		return EnvVarMySQLDSN
+	default:
+		return ""
+	}
+}
+
+func randomSuffix() string {
+	b := make([]byte, 6) //nolint:gomnd
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
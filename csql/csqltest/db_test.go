@@ -0,0 +1,45 @@
+package csqltest_test
+
+import (
+	"testing"
+
+	"github.com/gocopper/copper/csql/csqltest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDB(t *testing.T) {
+	t.Parallel()
+
+	db := csqltest.NewDB(t, csqltest.Options{})
+
+	_, err := db.DB.Exec("create table people (name text)")
+	assert.NoError(t, err)
+
+	t.Run("Ctx", func(t *testing.T) {
+		ctx := db.Ctx(t)
+
+		_, err := db.Querier.Exec(ctx, "insert into people (name) values (?)", "test")
+		assert.NoError(t, err)
+	})
+
+	var count int
+
+	// The insert was rolled back when the subtest ended
+	assert.NoError(t, db.DB.QueryRow("select count(*) from people").Scan(&count))
+	assert.Equal(t, 0, count)
+
+	// Each test gets its own database
+	other := csqltest.NewDB(t, csqltest.Options{})
+
+	_, err = other.DB.Exec("select * from people")
+	assert.Error(t, err)
+}
+
+func TestNewDB_Postgres(t *testing.T) {
+	t.Parallel()
+
+	db := csqltest.NewDB(t, csqltest.Options{Dialect: "postgres"})
+
+	_, err := db.DB.Exec("create table people (name text)")
+	assert.NoError(t, err)
+}
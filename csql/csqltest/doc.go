@@ -0,0 +1,3 @@
+// Package csqltest provides isolated databases for tests. Each test gets its own SQLite database, Postgres schema, or
+// MySQL database with the migrations applied, and it is torn down when the test ends. See NewDB.
+package csqltest
@@ -3,6 +3,7 @@ package csql
 import (
 	"context"
 	"database/sql"
+	"sync"
 
 	"github.com/gocopper/copper/cerrors"
 	"github.com/jmoiron/sqlx"
@@ -10,23 +11,39 @@ import (
 
 type ctxKey string
 
-const connCtxKey = ctxKey("csql/*sqlx.Tx")
+const (
+	connCtxKey   = ctxKey("csql/*sqlx.Tx")
+	reqTxCtxKey  = ctxKey("csql/*requestTx")
+	beginTxError = "failed to begin db transaction"
+)
+
+// conn is implemented by both *sqlx.Tx and *sqlx.DB
+type conn interface {
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Rebind(query string) string
+}
 
 // CtxWithTx creates a context with a new database transaction. Any queries run using Querier will be run within
 // this transaction.
 func CtxWithTx(parentCtx context.Context, db *sql.DB, dialect string) (context.Context, *sql.Tx, error) {
-	tx, err := sqlx.NewDb(db, dialect).Beginx()
+	dbx := sqlx.NewDb(db, dialect)
+
+	tx, err := dbx.Beginx()
 	if err != nil {
-		return nil, nil, cerrors.New(err, "failed to begin db transaction", map[string]interface{}{
+		return nil, nil, cerrors.New(err, beginTxError, map[string]interface{}{
 			"dialect": dialect,
 		})
 	}
 
-	return context.WithValue(parentCtx, connCtxKey, tx), tx.Tx, nil
+	ctx := context.WithValue(parentCtx, dbCtxKey, dbx)
+
+	return context.WithValue(ctx, connCtxKey, tx), tx.Tx, nil
 }
 
 // TxFromCtx returns an existing transaction from the context. This method should be called with context created
-// using CtxWithTx.
+// using CtxWithTx or within a request handled by TxMiddleware.
 func TxFromCtx(ctx context.Context) (*sql.Tx, error) {
 	tx, err := txFromCtx(ctx)
 	if err != nil {
@@ -37,19 +54,117 @@ func TxFromCtx(ctx context.Context) (*sql.Tx, error) {
 }
 
 func txFromCtx(ctx context.Context) (*sqlx.Tx, error) {
-	tx, ok := ctx.Value(connCtxKey).(*sqlx.Tx)
-	if !ok {
-		return nil, cerrors.New(nil, "no database transaction in the context", nil)
+	if tx, ok := ctx.Value(connCtxKey).(*sqlx.Tx); ok {
+		return tx, nil
 	}
 
-	return tx, nil
+	if rtx, ok := ctx.Value(reqTxCtxKey).(*requestTx); ok {
+		return rtx.get()
+	}
+
+	return nil, cerrors.New(nil, "no database transaction in the context", nil)
 }
 
-func mustTxFromCtx(ctx context.Context) *sqlx.Tx {
-	tx, err := txFromCtx(ctx)
+// connFromCtx returns the connection queries should be run on. It is the context's transaction unless the request
+// opted out of its transaction using NoTxMiddleware.
+func connFromCtx(ctx context.Context) (conn, error) {
+	if rtx, ok := ctx.Value(reqTxCtxKey).(*requestTx); ok && ctx.Value(connCtxKey) == nil && rtx.isSkipped() {
+		return rtx.db, nil
+	}
+
+	return txFromCtx(ctx)
+}
+
+// requestTx is a transaction that is started by TxMiddleware when the request runs its first query. This lets
+// handlers that don't query the database, and handlers that opt out using NoTxMiddleware, avoid holding a
+// transaction open. The transaction is bound to the request's context rather than the context of the first query,
+// so that a query with its own timeout does not roll back the transaction for the rest of the request.
+type requestTx struct {
+	ctx context.Context
+	db  *sqlx.DB
+
+	mu      sync.Mutex
+	tx      *sqlx.Tx
+	skipped bool
+	done    bool
+}
+
+func (r *requestTx) get() (*sqlx.Tx, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.tx != nil {
+		return r.tx, nil
+	}
+
+	if r.skipped {
+		return nil, cerrors.New(nil, "database transaction is disabled for this request", nil)
+	}
+
+	if r.done {
+		return nil, cerrors.New(sql.ErrTxDone, "database transaction is already finished for this request", nil)
+	}
+
+	tx, err := r.db.BeginTxx(r.ctx, nil)
 	if err != nil {
-		panic(err)
+		return nil, cerrors.New(err, beginTxError, map[string]interface{}{
+			"dialect": r.db.DriverName(),
+		})
+	}
+
+	r.tx = tx
+
+	return tx, nil
+}
+
+// skip disables the transaction for the request. It returns false if the transaction was already started.
+func (r *requestTx) skip() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.tx != nil {
+		return false
+	}
+
+	r.skipped = true
+
+	return true
+}
+
+func (r *requestTx) isSkipped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.skipped
+}
+
+// isOpen returns true if the transaction was started and is not finished yet
+func (r *requestTx) isOpen() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.tx != nil && !r.done
+}
+
+// finish commits or rolls back the transaction if it was started. Once finished, no new transaction is started for
+// the request and sql.ErrTxDone is returned if finish is called again.
+func (r *requestTx) finish(commit bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.done {
+		return sql.ErrTxDone
+	}
+
+	r.done = true
+
+	if r.tx == nil {
+		return nil
+	}
+
+	if commit {
+		return r.tx.Commit()
 	}
 
-	return tx
+	return r.tx.Rollback()
 }
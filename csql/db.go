@@ -3,37 +3,60 @@ package csql
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/chealth"
 	"github.com/gocopper/copper/clifecycle"
 	"github.com/gocopper/copper/clogger"
 )
 
-// NewDBConnection creates and returns a new database connection. The connection is closed when the app exits.
+const (
+	defaultConnectTimeout = 5 * time.Second
+	defaultConnectRetries = 5
+
+	connectBackoffInitial = 500 * time.Millisecond
+	connectBackoffMax     = 10 * time.Second
+)
+
+// NewDBConnection creates and returns a new database connection. The connection is pinged before it is returned,
+// retrying with exponential backoff while the database is unreachable (ex. when it starts along with the app), and a
+// readiness checker is registered so that the app reports unready when the database is unreachable (see chealth).
+// The connection is closed when the app exits.
 func NewDBConnection(lc *clifecycle.Lifecycle, config Config, logger clogger.Logger) (*sql.DB, error) {
+	logger = logger.With(clogger.Module("csql"))
+
 	logger.WithTags(map[string]interface{}{
 		"dialect": config.Dialect,
 	}).Info("Opening a database connection..")
 
-	db, err := sql.Open(config.Dialect, config.DSN)
+	err := checkDriver(config.Dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(config.Dialect, prepareDSN(config.Dialect, config.DSN))
 	if err != nil {
 		return nil, cerrors.New(err, "failed to open db connection", map[string]interface{}{
 			"dialect": config.Dialect,
 		})
 	}
 
-	err = db.Ping()
+	configurePool(db, config, config.DSN)
+
+	err = pingWithRetry(context.Background(), db, config, logger)
 	if err != nil {
-		return nil, cerrors.New(err, "failed to ping db", nil)
+		_ = db.Close()
+		return nil, err
 	}
 
-	if config.MaxOpenConnections != nil {
-		db.SetMaxOpenConns(*config.MaxOpenConnections)
-	}
+	unregister := chealth.Register("csql", chealth.CheckerFunc(db.PingContext))
 
 	lc.OnStop(func(ctx context.Context) error {
 		logger.Info("Closing database connection..")
 
+		unregister()
+
 		err := db.Close()
 		if err != nil {
 			return cerrors.New(err, "failed to close db connection", nil)
@@ -44,3 +67,72 @@ func NewDBConnection(lc *clifecycle.Lifecycle, config Config, logger clogger.Log
 
 	return db, nil
 }
+
+// configurePool applies the connection pool config to db
+func configurePool(db *sql.DB, config Config, dsn string) {
+	if config.MaxOpenConnections != nil {
+		db.SetMaxOpenConns(*config.MaxOpenConnections)
+	}
+
+	if config.MaxIdleConnections != nil {
+		db.SetMaxIdleConns(*config.MaxIdleConnections)
+	}
+
+	db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+
+	if isSQLiteMemory(config.Dialect, dsn) {
+		// The database is lost when its only connection is closed, so it must be kept open
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		db.SetConnMaxLifetime(0)
+		db.SetConnMaxIdleTime(0)
+	}
+}
+
+// pingWithRetry pings db until it succeeds or the configured number of retries is exhausted. The wait between each
+// attempt doubles, up to connectBackoffMax.
+func pingWithRetry(ctx context.Context, db *sql.DB, config Config, logger clogger.Logger) error {
+	var (
+		timeout = config.ConnectTimeout
+		backoff = connectBackoffInitial
+		err     error
+	)
+
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+
+	for attempt := 0; attempt <= config.ConnectRetries; attempt++ {
+		if attempt > 0 {
+			logger.WithTags(map[string]interface{}{
+				"attempt": attempt,
+				"backoff": backoff.String(),
+			}).Warn("Failed to ping db; retrying..", err)
+
+			select {
+			case <-ctx.Done():
+				return cerrors.New(ctx.Err(), "failed to ping db", nil)
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+			if backoff > connectBackoffMax {
+				backoff = connectBackoffMax
+			}
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err = db.PingContext(pingCtx)
+
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+	}
+
+	return cerrors.New(err, "failed to ping db", map[string]interface{}{
+		"attempts": config.ConnectRetries + 1,
+	})
+}
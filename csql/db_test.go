@@ -2,6 +2,7 @@ package csql_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/gocopper/copper/clifecycle"
 	"github.com/gocopper/copper/clogger"
@@ -30,3 +31,43 @@ func TestNewDBConnection(t *testing.T) {
 
 	assert.Error(t, db.Ping())
 }
+
+func TestNewDBConnection_SQLiteMemory(t *testing.T) {
+	t.Parallel()
+
+	db, err := csql.NewDBConnection(clifecycle.New(), csql.Config{
+		Dialect: csql.DialectSQLite,
+		DSN:     ":memory:",
+	}, clogger.NewNoop())
+	assert.NoError(t, err)
+
+	// Each connection to :memory: opens a new database, so the pool is limited to one connection
+	assert.Equal(t, 1, db.Stats().MaxOpenConnections)
+
+	var fk int
+
+	assert.NoError(t, db.QueryRow("pragma foreign_keys").Scan(&fk))
+	assert.Equal(t, 1, fk)
+}
+
+func TestNewDBConnection_UnknownDialect(t *testing.T) {
+	t.Parallel()
+
+	_, err := csql.NewDBConnection(clifecycle.New(), csql.Config{
+		Dialect: "oracle",
+		DSN:     "test",
+	}, clogger.NewNoop())
+	assert.Error(t, err)
+}
+
+func TestNewDBConnection_PingRetry(t *testing.T) {
+	t.Parallel()
+
+	_, err := csql.NewDBConnection(clifecycle.New(), csql.Config{
+		Dialect:        csql.DialectPostgres,
+		DSN:            "postgres://copper@127.0.0.1:1/copper?sslmode=disable",
+		ConnectTimeout: time.Second,
+		ConnectRetries: 1,
+	}, clogger.NewNoop())
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package csql
+
+import (
+	"database/sql"
+	"net/url"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+
+	// Register the drivers of the supported dialects so that they can be selected using csql.dialect
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Dialects are valid options for csql.dialect configuration option. The dialect selects both the database driver and
+// the SQL variations used by csql (ex. bind vars and column types in the migrations table).
+// Note that the sqlite3 driver requires cgo.
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+	DialectSQLite   = "sqlite3"
+)
+
+// dialectAliases maps alternative names of a dialect to the name of its driver
+var dialectAliases = map[string]string{ //nolint:gochecknoglobals
+	"postgresql": DialectPostgres,
+	"pg":         DialectPostgres,
+	"sqlite":     DialectSQLite,
+	"mariadb":    DialectMySQL,
+}
+
+// knownDialects are the dialects that migration files can target (see ModuleMigrations)
+var knownDialects = map[string]bool{ //nolint:gochecknoglobals
+	DialectPostgres: true,
+	DialectMySQL:    true,
+	DialectSQLite:   true,
+}
+
+func normalizeDialect(dialect string) string {
+	dialect = strings.ToLower(strings.TrimSpace(dialect))
+
+	if d, ok := dialectAliases[dialect]; ok {
+		return d
+	}
+
+	return dialect
+}
+
+// checkDriver returns an error if the driver for dialect is not registered
+func checkDriver(dialect string) error {
+	for _, driver := range sql.Drivers() {
+		if driver == dialect {
+			return nil
+		}
+	}
+
+	return cerrors.New(nil, "database driver is not registered; import it in the app", map[string]interface{}{
+		"dialect": dialect,
+	})
+}
+
+// prepareDSN sets the connection options that csql relies on if the DSN does not set them already:
+//   - MySQL: parseTime=true so that timestamps are scanned into time.Time
+//   - SQLite: foreign key enforcement and a busy timeout so that concurrent writers wait instead of failing
+func prepareDSN(dialect, dsn string) string {
+	switch dialect {
+	case DialectMySQL:
+		return addDSNParam(dsn, "parseTime", "true")
+	case DialectSQLite:
+		dsn = addDSNParam(dsn, "_foreign_keys", "on")
+		return addDSNParam(dsn, "_busy_timeout", "5000")
+	default:
+		return dsn
+	}
+}
+
+func addDSNParam(dsn, key, val string) string {
+	i := strings.Index(dsn, "?")
+	if i == -1 {
+		return dsn + "?" + key + "=" + val
+	}
+
+	params, err := url.ParseQuery(dsn[i+1:])
+	if err != nil || params.Get(key) != "" {
+		return dsn
+	}
+
+	return dsn + "&" + key + "=" + val
+}
+
+// isSQLiteMemory returns true if dsn opens an in-memory SQLite database. Each connection to such a database opens a
+// new, empty database so the pool must be limited to a single connection.
+func isSQLiteMemory(dialect, dsn string) bool {
+	return dialect == DialectSQLite && (strings.Contains(dsn, ":memory:") || strings.Contains(dsn, "mode=memory"))
+}
+
+// timestampColumnType returns the column type used to store timestamps in tables created by csql
+func timestampColumnType(dialect string) string {
+	if dialect == DialectMySQL {
+		// MySQL's timestamp type is limited to 2038 and may be updated automatically
+		return "datetime(6)"
+	}
+
+	return "timestamp"
+}
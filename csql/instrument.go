@@ -0,0 +1,130 @@
+package csql
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const defaultSlowQueryThreshold = 500 * time.Millisecond
+
+// Labels of the query metrics
+const (
+	dbPrimary = "primary"
+	dbReplica = "replica"
+
+	statusOK    = "ok"
+	statusError = "error"
+)
+
+var (
+	// sqlStringLiteralRegexp matches single-quoted string literals in a query
+	sqlStringLiteralRegexp = regexp.MustCompile(`'(?:[^']|'')*'`) //nolint:gochecknoglobals
+
+	// sqlNumberLiteralRegexp matches number literals in a query without matching placeholders (ex. $1) or identifiers
+	sqlNumberLiteralRegexp = regexp.MustCompile(`(^|[^\w$.])\d+(?:\.\d+)?\b`) //nolint:gochecknoglobals
+
+	sqlWhitespaceRegexp = regexp.MustCompile(`\s+`) //nolint:gochecknoglobals
+)
+
+var (
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{ //nolint:gochecknoglobals
+		Name:    "csql_query_duration_seconds",
+		Help:    "Duration of database queries run using csql.Querier",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation", "db", "status"})
+
+	slowQueries = prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:gochecknoglobals
+		Name: "csql_slow_queries_total",
+		Help: "Number of database queries that exceeded csql.slow_query_threshold",
+	}, []string{"operation", "db"})
+)
+
+func init() { //nolint:gochecknoinits
+	prometheus.MustRegister(queryDuration, slowQueries)
+}
+
+// queryInstrument logs and records metrics for the queries run by a querier
+type queryInstrument struct {
+	logger        clogger.Logger
+	slowThreshold time.Duration
+}
+
+// observe logs the query at debug level, or as a warning if it exceeded the slow query threshold, and records its
+// duration. The query's args are never logged and literals in the query are redacted.
+func (i *queryInstrument) observe(op, db, query string, start time.Time, rows int64, err error) {
+	var (
+		duration = time.Since(start)
+		status   = statusOK
+	)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		status = statusError
+	}
+
+	queryDuration.WithLabelValues(op, db, status).Observe(duration.Seconds())
+
+	logger := i.logger.WithTags(map[string]interface{}{
+		"operation":  op,
+		"db":         db,
+		"query":      redactQuery(query),
+		"durationMs": duration.Milliseconds(),
+		"rows":       rows,
+	})
+
+	if i.slowThreshold > 0 && duration >= i.slowThreshold {
+		slowQueries.WithLabelValues(op, db).Inc()
+
+		logger.Warn("Slow database query", err)
+
+		return
+	}
+
+	logger.Debug("Ran database query")
+}
+
+// redactQuery replaces the string and number literals in a query with ? and collapses its whitespace so that it can
+// be logged
+func redactQuery(query string) string {
+	query = sqlStringLiteralRegexp.ReplaceAllString(query, "?")
+	query = sqlNumberLiteralRegexp.ReplaceAllString(query, "${1}?")
+
+	return strings.TrimSpace(sqlWhitespaceRegexp.ReplaceAllString(query, " "))
+}
+
+// resultRows returns the number of rows scanned into dest by Select or Get
+func resultRows(dest interface{}, err error) int64 {
+	if err != nil {
+		return 0
+	}
+
+	v := reflect.ValueOf(dest)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Slice {
+		return int64(v.Len())
+	}
+
+	return 1
+}
+
+func affectedRows(res sql.Result, err error) int64 {
+	if err != nil || res == nil {
+		return 0
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
@@ -0,0 +1,62 @@
+package csql_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/csql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuerier_LogsQueries(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db      = openTestDB(t, "test")
+		out     bytes.Buffer
+		querier = csql.NewQuerierWithParams(csql.NewQuerierParams{
+			DB:     db,
+			Config: csql.Config{Dialect: "sqlite3", SlowQueryThreshold: time.Hour},
+			Logger: clogger.NewWithWriters(&out, &out, clogger.FormatPlain),
+		})
+		names []string
+	)
+
+	ctx, tx, err := csql.CtxWithTx(context.Background(), db, "sqlite3")
+	assert.NoError(t, err)
+
+	defer func() { _ = tx.Rollback() }()
+
+	assert.NoError(t, querier.Select(ctx, &names, "select name from people where name = ?", "test"))
+	assert.Contains(t, out.String(), "Ran database query")
+	assert.Contains(t, out.String(), "rows=1")
+}
+
+func TestQuerier_LogsSlowQueries(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db      = openTestDB(t, "test")
+		out     bytes.Buffer
+		querier = csql.NewQuerierWithParams(csql.NewQuerierParams{
+			DB:     db,
+			Config: csql.Config{Dialect: "sqlite3", SlowQueryThreshold: time.Nanosecond},
+			Logger: clogger.NewWithWriters(&out, &out, clogger.FormatPlain),
+		})
+	)
+
+	ctx, tx, err := csql.CtxWithTx(context.Background(), db, "sqlite3")
+	assert.NoError(t, err)
+
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = querier.Exec(ctx, "update people set name = 'secret' where rowid = 1 and name = ?", "test")
+	assert.NoError(t, err)
+
+	assert.Contains(t, out.String(), "Slow database query")
+	assert.Contains(t, out.String(), "update people set name = ? where rowid = ? and name = ?")
+	assert.NotContains(t, out.String(), "secret")
+}
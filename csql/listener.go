@@ -0,0 +1,339 @@
+package csql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/lib/pq"
+)
+
+const (
+	listenerMinReconnectInterval = 100 * time.Millisecond
+	listenerMaxReconnectInterval = 30 * time.Second
+
+	// listenerPingInterval is how often an idle listener pings the database so that a dead connection is detected
+	// and re-established even if no notifications are sent
+	listenerPingInterval = 90 * time.Second
+)
+
+// Notification is a message sent to a channel using NOTIFY (or Notify). If Reconnected is true, the notification has
+// no payload and is dispatched to all handlers after the listener reconnected to the database. Notifications sent
+// while the listener was disconnected are lost, so handlers should refresh their state (ex. flush a cache) on it.
+type Notification struct {
+	Channel     string
+	Payload     string
+	Reconnected bool
+}
+
+// NotificationHandler handles the notifications sent to a channel
+type NotificationHandler func(ctx context.Context, n Notification) error
+
+// NewListenerParams holds the params needed for NewListener
+type NewListenerParams struct {
+	Config    Config
+	Lifecycle *clifecycle.Lifecycle
+	Logger    clogger.Logger
+}
+
+// NewListener creates a Listener that subscribes to Postgres channels using LISTEN. It opens its own connection to
+// the primary database when it runs and closes it when the lifecycle stops.
+func NewListener(p NewListenerParams) *Listener {
+	l := &Listener{
+		config:   p.Config,
+		logger:   p.Logger.With(clogger.Module("csql")),
+		handlers: make(map[string][]NotificationHandler),
+	}
+
+	p.Lifecycle.OnStop(func(ctx context.Context) error {
+		return l.close()
+	})
+
+	return l
+}
+
+// Listener dispatches the notifications sent to Postgres channels to the handlers registered using Listen. It
+// reconnects to the database with backoff if the connection is lost and listens to all channels again.
+type Listener struct {
+	config Config
+	logger clogger.Logger
+
+	mu       sync.Mutex
+	handlers map[string][]NotificationHandler
+	pql      *pq.Listener
+	done     chan struct{}
+}
+
+// Listen registers a handler for the notifications sent to the given channel. Handlers can be registered before or
+// after the listener runs. Handlers are called one at a time in the order notifications are received, so slow
+// handlers delay the notifications that follow.
+func (l *Listener) Listen(channel string, handler NotificationHandler) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.handlers[channel] = append(l.handlers[channel], handler)
+
+	if l.pql == nil || len(l.handlers[channel]) > 1 {
+		return nil
+	}
+
+	err := l.pql.Listen(channel)
+	if err != nil {
+		return cerrors.New(err, "failed to listen to channel", map[string]interface{}{
+			"channel": channel,
+		})
+	}
+
+	return nil
+}
+
+// Run connects to the database, listens to the channels that have handlers, and starts dispatching notifications in
+// the background. It can be passed to the app's Run or Start funcs. Listener is only supported with Postgres.
+func (l *Listener) Run() error {
+	if l.config.Dialect != DialectPostgres {
+		return cerrors.New(nil, "listener is only supported with postgres", map[string]interface{}{
+			"dialect": l.config.Dialect,
+		})
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.pql != nil {
+		return nil
+	}
+
+	l.pql = pq.NewListener(
+		prepareDSN(l.config.Dialect, l.config.DSN),
+		listenerMinReconnectInterval,
+		listenerMaxReconnectInterval,
+		l.onEvent,
+	)
+
+	for channel := range l.handlers {
+		err := l.pql.Listen(channel)
+		if err != nil {
+			_ = l.pql.Close()
+			l.pql = nil
+
+			return cerrors.New(err, "failed to listen to channel", map[string]interface{}{
+				"channel": channel,
+			})
+		}
+	}
+
+	l.done = make(chan struct{})
+
+	go l.dispatch(l.pql, l.done)
+
+	l.logger.WithTags(map[string]interface{}{
+		"channels": len(l.handlers),
+	}).Info("Listening to database notifications..")
+
+	return nil
+}
+
+// Stage implements clifecycle.Staged. The listener starts with the app's workers.
+func (l *Listener) Stage() clifecycle.Stage {
+	return clifecycle.Stage{
+		Name:  clifecycle.StageWorkers,
+		After: []string{clifecycle.StageMigrations, clifecycle.StageSeeds, clifecycle.StageHTTP},
+	}
+}
+
+func (l *Listener) dispatch(pql *pq.Listener, done chan struct{}) {
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			err := pql.Ping()
+			if err != nil {
+				l.logger.Warn("Failed to ping database listener connection", err)
+			}
+		case n, ok := <-pql.NotificationChannel():
+			if !ok {
+				return
+			}
+
+			ticker.Reset(listenerPingInterval)
+
+			// pq sends a nil notification after the connection is re-established
+			if n == nil {
+				l.dispatchAll(Notification{Reconnected: true})
+				continue
+			}
+
+			l.handle(Notification{
+				Channel: n.Channel,
+				Payload: n.Extra,
+			})
+		}
+	}
+}
+
+func (l *Listener) dispatchAll(n Notification) {
+	l.mu.Lock()
+	channels := make([]string, 0, len(l.handlers))
+	for channel := range l.handlers {
+		channels = append(channels, channel)
+	}
+	l.mu.Unlock()
+
+	for _, channel := range channels {
+		n.Channel = channel
+		l.handle(n)
+	}
+}
+
+func (l *Listener) handle(n Notification) {
+	l.mu.Lock()
+	handlers := append([]NotificationHandler(nil), l.handlers[n.Channel]...)
+	l.mu.Unlock()
+
+	for _, handler := range handlers {
+		err := l.call(handler, n)
+		if err != nil {
+			l.logger.WithTags(map[string]interface{}{
+				"channel": n.Channel,
+			}).Error("Failed to handle database notification", err)
+		}
+	}
+}
+
+func (l *Listener) call(handler NotificationHandler, n Notification) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = cerrors.New(nil, "notification handler panicked", map[string]interface{}{
+				"panic": fmt.Sprint(r),
+			})
+		}
+	}()
+
+	return handler(context.Background(), n)
+}
+
+func (l *Listener) onEvent(event pq.ListenerEventType, err error) {
+	switch event {
+	case pq.ListenerEventDisconnected:
+		l.logger.Warn("Database listener disconnected; reconnecting..", err)
+	case pq.ListenerEventConnectionAttemptFailed:
+		l.logger.Warn("Failed to reconnect database listener", err)
+	case pq.ListenerEventReconnected:
+		l.logger.Info("Database listener reconnected")
+	case pq.ListenerEventConnected:
+	}
+}
+
+func (l *Listener) close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.pql == nil {
+		return nil
+	}
+
+	close(l.done)
+
+	l.logger.Info("Closing database listener connection..")
+
+	err := l.pql.Close()
+	l.pql = nil
+
+	if err != nil {
+		return cerrors.New(err, "failed to close database listener connection", nil)
+	}
+
+	return nil
+}
+
+// Notify sends a notification to a Postgres channel using pg_notify. If the context has a transaction, the
+// notification is delivered when the transaction commits and dropped if it rolls back.
+func Notify(ctx context.Context, querier Querier, channel, payload string) error {
+	_, err := querier.Exec(ctx, "SELECT pg_notify(?, ?)", channel, payload)
+	if err != nil {
+		return cerrors.New(err, "failed to send notification", map[string]interface{}{
+			"channel": channel,
+		})
+	}
+
+	return nil
+}
+
+// ChangeEvent is the payload of the notifications sent by the trigger created using ChangeTriggerSQL
+type ChangeEvent struct {
+	Table string          `json:"table"`
+	Op    string          `json:"op"`
+	Key   json.RawMessage `json:"key"`
+}
+
+// Operations of a ChangeEvent
+const (
+	ChangeOpInsert = "INSERT"
+	ChangeOpUpdate = "UPDATE"
+	ChangeOpDelete = "DELETE"
+)
+
+// ChangeEventHandler wraps fn in a NotificationHandler that decodes the payload of the notifications sent by the
+// trigger created using ChangeTriggerSQL. On reconnect, fn is called with an empty ChangeEvent so that it can drop
+// any state that depends on the changes it may have missed.
+func ChangeEventHandler(fn func(ctx context.Context, e ChangeEvent) error) NotificationHandler {
+	return func(ctx context.Context, n Notification) error {
+		if n.Reconnected {
+			return fn(ctx, ChangeEvent{})
+		}
+
+		var e ChangeEvent
+
+		err := json.Unmarshal([]byte(n.Payload), &e)
+		if err != nil {
+			return cerrors.New(err, "failed to decode change event", map[string]interface{}{
+				"channel": n.Channel,
+			})
+		}
+
+		return fn(ctx, e)
+	}
+}
+
+// ChangeTriggerSQL returns the statements that create and drop a trigger that sends a ChangeEvent to channel whenever
+// a row in table is inserted, updated, or deleted. Only the primary key of the row is sent since notification
+// payloads are limited to 8000 bytes. The statements can be used in a migration (ex. using SQLMigration).
+func ChangeTriggerSQL(table, primaryKey, channel string) (up, down []string) {
+	var (
+		fn      = fmt.Sprintf("csql_notify_%s_changes", table)
+		trigger = fmt.Sprintf("csql_%s_changes", table)
+	)
+
+	up = []string{fmt.Sprintf(`CREATE OR REPLACE FUNCTION %[1]s() RETURNS trigger AS $$
+DECLARE
+	r record;
+BEGIN
+	IF TG_OP = 'DELETE' THEN r := OLD; ELSE r := NEW; END IF;
+	PERFORM pg_notify('%[2]s', json_build_object(
+		'table', TG_TABLE_NAME,
+		'op', TG_OP,
+		'key', to_jsonb(r)->'%[3]s'
+	)::text);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql`, fn, channel, primaryKey),
+		fmt.Sprintf(`CREATE TRIGGER %s AFTER INSERT OR UPDATE OR DELETE ON %s
+	FOR EACH ROW EXECUTE PROCEDURE %s()`, trigger, table, fn),
+	}
+
+	down = []string{
+		fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", trigger, table),
+		fmt.Sprintf("DROP FUNCTION IF EXISTS %s()", fn),
+	}
+
+	return up, down
+}
@@ -0,0 +1,83 @@
+package csql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/csql"
+	"github.com/gocopper/copper/csql/csqltest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListener_Run_NotPostgres(t *testing.T) {
+	t.Parallel()
+
+	listener := csql.NewListener(csql.NewListenerParams{
+		Config:    csql.Config{Dialect: csql.DialectSQLite, DSN: ":memory:"},
+		Lifecycle: clifecycle.New(),
+		Logger:    clogger.NewNoop(),
+	})
+
+	assert.Error(t, listener.Run())
+}
+
+func TestListener_Notify(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db       = csqltest.NewDB(t, csqltest.Options{Dialect: csql.DialectPostgres})
+		lc       = clifecycle.New()
+		received = make(chan csql.Notification, 1)
+		listener = csql.NewListener(csql.NewListenerParams{
+			Config:    db.Config,
+			Lifecycle: lc,
+			Logger:    clogger.NewNoop(),
+		})
+	)
+
+	defer lc.Stop(clogger.NewNoop())
+
+	assert.NoError(t, listener.Listen("csql_test", func(ctx context.Context, n csql.Notification) error {
+		received <- n
+		return nil
+	}))
+	assert.NoError(t, listener.Run())
+
+	assert.NoError(t, csql.Notify(context.Background(), db.Querier, "csql_test", "hello"))
+
+	select {
+	case n := <-received:
+		assert.Equal(t, "csql_test", n.Channel)
+		assert.Equal(t, "hello", n.Payload)
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification was not received")
+	}
+}
+
+func TestChangeEventHandler(t *testing.T) {
+	t.Parallel()
+
+	var event csql.ChangeEvent
+
+	handler := csql.ChangeEventHandler(func(ctx context.Context, e csql.ChangeEvent) error {
+		event = e
+		return nil
+	})
+
+	err := handler(context.Background(), csql.Notification{
+		Channel: "users_changes",
+		Payload: `{"table":"users","op":"UPDATE","key":42}`,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "users", event.Table)
+	assert.Equal(t, csql.ChangeOpUpdate, event.Op)
+	assert.Equal(t, "42", string(event.Key))
+
+	assert.Error(t, handler(context.Background(), csql.Notification{Payload: "{"}))
+
+	assert.NoError(t, handler(context.Background(), csql.Notification{Channel: "users_changes", Reconnected: true}))
+	assert.Equal(t, csql.ChangeEvent{}, event)
+}
@@ -0,0 +1,357 @@
+package csql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/rubenv/sql-migrate/sqlparse"
+)
+
+// AppMigrationsModule is the module name of the app's own migrations (see Migrations)
+const AppMigrationsModule = "app"
+
+// migrationVersionRegexp matches the numeric version prefix of a migration id (ex. 20240101120000_create_users)
+var migrationVersionRegexp = regexp.MustCompile(`^(\d+)`) //nolint:gochecknoglobals
+
+// registeredMigrations holds the module migrations registered using RegisterMigrations
+var registeredMigrations = struct { //nolint:gochecknoglobals
+	sync.Mutex
+	modules []ModuleMigrations
+}{}
+
+// MigrationFunc applies or reverts a migration within the given transaction
+type MigrationFunc func(ctx context.Context, tx *sql.Tx) error
+
+// Migration is a versioned schema change. Migrations are applied in the order of their numeric version prefix (ex.
+// 001_create_users before 002_add_email).
+type Migration struct {
+	// ID uniquely identifies the migration within its module and starts with its version (ex. 001_create_users)
+	ID string
+
+	Up   MigrationFunc
+	Down MigrationFunc
+
+	// NoTransaction runs the migration outside of a transaction (ex. for CREATE INDEX CONCURRENTLY). The tx passed
+	// to Up and Down is nil in that case, so the migration must use the db returned by MigrationDB.
+	NoTransaction bool
+}
+
+// ModuleMigrations are the migrations shipped by a module. FS holds SQL migration files and Migrations holds Go
+// migrations. Both are applied together in version order. SQL migration files can be written as:
+//   - A pair of <version>_<name>.up.sql and <version>_<name>.down.sql files
+//   - A single <version>_<name>.sql file with -- +migrate Up and -- +migrate Down sections
+//
+// Files that need dialect specific SQL can be overridden for a dialect (ex. <version>_<name>.mysql.sql). Copper's
+// modules write their schema for Postgres and SQLite in migrations.sql and override it for MySQL in
+// migrations.mysql.sql, since MySQL cannot use text columns in primary keys and indexes. The module embeds both files
+// (//go:embed migrations.sql migrations.mysql.sql) and ValidateMigrations checks that they follow this convention.
+type ModuleMigrations struct {
+	// Module is the name migrations are recorded under (ex. cauth). It must be unique.
+	Module string
+
+	FS         fs.FS
+	Migrations []Migration
+}
+
+// RegisterMigrations registers the migrations of a module so that they are applied by Migrator before the app's own
+// migrations. Modules usually register their migrations in an init func:
+//
+//	//go:embed migrations
+//	var migrations embed.FS
+//
+//	func init() {
+//		csql.RegisterMigrations(csql.ModuleMigrations{Module: "cauth", FS: migrations})
+//	}
+//
+// Registering a module again replaces its migrations.
+func RegisterMigrations(mm ModuleMigrations) {
+	registeredMigrations.Lock()
+	defer registeredMigrations.Unlock()
+
+	for i := range registeredMigrations.modules {
+		if registeredMigrations.modules[i].Module == mm.Module {
+			registeredMigrations.modules[i] = mm
+			return
+		}
+	}
+
+	registeredMigrations.modules = append(registeredMigrations.modules, mm)
+}
+
+// RegisteredMigrations returns the module migrations registered using RegisterMigrations sorted by module
+func RegisteredMigrations() []ModuleMigrations {
+	registeredMigrations.Lock()
+	defer registeredMigrations.Unlock()
+
+	modules := make([]ModuleMigrations, len(registeredMigrations.modules))
+	copy(modules, registeredMigrations.modules)
+
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Module < modules[j].Module })
+
+	return modules
+}
+
+// ValidateMigrations checks that the SQL migration files in fsys can be loaded for every dialect and that each file
+// for a dialect overrides a file without a dialect (see ModuleMigrations). csqltest.NewDB runs it on the migrations
+// of the test database so that a dialect variant with a typo in its name is caught without a database of that
+// dialect.
+func ValidateMigrations(fsys fs.FS) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return cerrors.New(err, "failed to read migrations dir", nil)
+	}
+
+	names := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		names[entry.Name()] = true
+	}
+
+	for _, entry := range entries {
+		base, dialect := splitMigrationFileDialect(entry.Name())
+		if dialect != "" && !names[base] {
+			return cerrors.New(nil, "dialect migration file does not override a migration file", map[string]interface{}{
+				"name": entry.Name(),
+				"base": base,
+			})
+		}
+	}
+
+	for _, dialect := range []string{DialectPostgres, DialectMySQL, DialectSQLite} {
+		_, err = loadSQLMigrations(fsys, dialect)
+		if err != nil {
+			return cerrors.New(err, "failed to load migrations", map[string]interface{}{
+				"dialect": dialect,
+			})
+		}
+	}
+
+	return nil
+}
+
+// SQLMigration creates a Migration that runs the given SQL statements
+func SQLMigration(id string, up, down []string) Migration {
+	return Migration{
+		ID:   id,
+		Up:   execStatements(up),
+		Down: execStatements(down),
+	}
+}
+
+type ctxMigrationDBKey struct{}
+
+// MigrationDB returns the db connection in a migration that sets NoTransaction
+func MigrationDB(ctx context.Context) *sql.DB {
+	db, _ := ctx.Value(ctxMigrationDBKey{}).(*sql.DB)
+	return db
+}
+
+func execStatements(statements []string) MigrationFunc {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		for _, stmt := range statements {
+			var err error
+
+			if tx != nil {
+				_, err = tx.ExecContext(ctx, stmt)
+			} else {
+				_, err = MigrationDB(ctx).ExecContext(ctx, stmt)
+			}
+
+			if err != nil {
+				return cerrors.New(err, "failed to exec migration statement", map[string]interface{}{
+					"statement": stmt,
+				})
+			}
+		}
+
+		return nil
+	}
+}
+
+// migrations returns the module's SQL and Go migrations sorted by version
+func (mm ModuleMigrations) migrations(dialect string) ([]Migration, error) {
+	migrations := make([]Migration, 0, len(mm.Migrations))
+	migrations = append(migrations, mm.Migrations...)
+
+	if mm.FS != nil {
+		sqlMigrations, err := loadSQLMigrations(mm.FS, dialect)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to load sql migrations", map[string]interface{}{
+				"module": mm.Module,
+			})
+		}
+
+		migrations = append(migrations, sqlMigrations...)
+	}
+
+	ids := make(map[string]bool, len(migrations))
+
+	for _, m := range migrations {
+		if ids[m.ID] {
+			return nil, cerrors.New(nil, "duplicate migration id", map[string]interface{}{
+				"module": mm.Module,
+				"id":     m.ID,
+			})
+		}
+
+		ids[m.ID] = true
+	}
+
+	sortMigrations(migrations)
+
+	return migrations, nil
+}
+
+// loadSQLMigrations loads the .sql files in the root of fsys. A file can target a single dialect by adding it before
+// the extension (ex. 001_create_users.up.mysql.sql). Such files replace the file of the same name without a dialect
+// and are ignored for other dialects.
+func loadSQLMigrations(fsys fs.FS, dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, cerrors.New(err, "failed to read migrations dir", nil)
+	}
+
+	// files maps the name of each migration file without its dialect to the file that is loaded for the dialect
+	files := make(map[string]string, len(entries))
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || path.Ext(name) != ".sql" {
+			continue
+		}
+
+		base, fileDialect := splitMigrationFileDialect(name)
+
+		if fileDialect != "" && fileDialect != dialect {
+			continue
+		}
+
+		if _, ok := files[base]; ok && fileDialect == "" {
+			continue
+		}
+
+		files[base] = name
+	}
+
+	var (
+		migrations = make([]Migration, 0, len(files))
+		split      = make(map[string]*sqlparse.ParsedMigration)
+	)
+
+	for base, name := range files {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to read migration file", map[string]interface{}{
+				"name": name,
+			})
+		}
+
+		switch {
+		case strings.HasSuffix(base, ".up.sql"), strings.HasSuffix(base, ".down.sql"):
+			var (
+				isUp = strings.HasSuffix(base, ".up.sql")
+				id   = strings.TrimSuffix(strings.TrimSuffix(base, ".up.sql"), ".down.sql")
+				dir  = "Down"
+			)
+
+			if isUp {
+				dir = "Up"
+			}
+
+			// Split files may use sql-migrate's StatementBegin/StatementEnd annotations, so they are parsed the same way
+			// as single files
+			parsed, err := sqlparse.ParseMigration(bytes.NewReader(append([]byte("-- +migrate "+dir+"\n"), data...)))
+			if err != nil {
+				return nil, cerrors.New(err, "failed to parse migration file", map[string]interface{}{
+					"name": name,
+				})
+			}
+
+			if split[id] == nil {
+				split[id] = &sqlparse.ParsedMigration{}
+			}
+
+			if isUp {
+				split[id].UpStatements = parsed.UpStatements
+				split[id].DisableTransactionUp = parsed.DisableTransactionUp
+			} else {
+				split[id].DownStatements = parsed.DownStatements
+				split[id].DisableTransactionDown = parsed.DisableTransactionDown
+			}
+		default:
+			parsed, err := sqlparse.ParseMigration(bytes.NewReader(data))
+			if err != nil {
+				return nil, cerrors.New(err, "failed to parse migration file", map[string]interface{}{
+					"name": name,
+				})
+			}
+
+			// Single file migrations are identified by their file name to stay compatible with sql-migrate
+			migrations = append(migrations, parsedSQLMigration(base, parsed))
+		}
+	}
+
+	for id, parsed := range split {
+		migrations = append(migrations, parsedSQLMigration(id, parsed))
+	}
+
+	return migrations, nil
+}
+
+// splitMigrationFileDialect returns the name of a migration file without its dialect, and the dialect. For example,
+// 001_create_users.up.mysql.sql returns 001_create_users.up.sql and mysql.
+func splitMigrationFileDialect(name string) (string, string) {
+	var (
+		base    = strings.TrimSuffix(name, ".sql")
+		dialect = strings.TrimPrefix(path.Ext(base), ".")
+	)
+
+	if !knownDialects[dialect] {
+		return name, ""
+	}
+
+	return strings.TrimSuffix(base, "."+dialect) + ".sql", dialect
+}
+
+func parsedSQLMigration(id string, parsed *sqlparse.ParsedMigration) Migration {
+	m := SQLMigration(id, parsed.UpStatements, parsed.DownStatements)
+
+	// sql-migrate allows disabling the transaction per direction but a migration either runs in a transaction or not
+	m.NoTransaction = parsed.DisableTransactionUp || parsed.DisableTransactionDown
+
+	return m
+}
+
+// sortMigrations sorts migrations by their numeric version prefix and then by id
+func sortMigrations(migrations []Migration) {
+	sort.SliceStable(migrations, func(i, j int) bool {
+		vi := migrationVersionRegexp.FindString(migrations[i].ID)
+		vj := migrationVersionRegexp.FindString(migrations[j].ID)
+
+		// Versioned migrations run before the ones without a version
+		if (vi == "") != (vj == "") {
+			return vi != ""
+		}
+
+		if vi != "" {
+			vi, vj = strings.TrimLeft(vi, "0"), strings.TrimLeft(vj, "0")
+
+			if len(vi) != len(vj) {
+				return len(vi) < len(vj)
+			}
+
+			if vi != vj {
+				return vi < vj
+			}
+		}
+
+		return migrations[i].ID < migrations[j].ID
+	})
+}
@@ -1,15 +1,38 @@
 package csql
 
 import (
+	"context"
 	"crypto/sha256"
 	"database/sql"
 	"embed"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clifecycle"
 	"github.com/gocopper/copper/clogger"
-	migrate "github.com/rubenv/sql-migrate"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	migrationsTable       = "csql_migrations"
+	legacyMigrationsTable = "gorp_migrations"
+
+	migrationsStartupTimeout = 10 * time.Minute
+
+	pqUndefinedTable = "42P01"
+	mysqlNoSuchTable = 1146
 )
 
 // Migrations is a collection of .sql files that represent the database schema
@@ -29,11 +52,16 @@ func NewMigrator(p NewMigratorParams) *Migrator {
 		db:         p.DB,
 		migrations: embed.FS(p.Migrations),
 		config:     p.Config,
-		logger:     p.Logger,
+		logger:     p.Logger.With(clogger.Module("csql")),
 	}
 }
 
-// Migrator can run database migrations by running the provided migrations in the migrations dir
+// Migrator runs versioned database migrations. It applies the migrations of modules registered using
+// RegisterMigrations followed by the app's own migrations (the provided migrations dir). Applied migrations are
+// recorded in the csql_migrations table by module and id.
+//
+// Apps that previously ran migrations using sql-migrate have their applied migrations imported from the
+// gorp_migrations table the first time Migrator runs.
 type Migrator struct {
 	db         *sql.DB
 	migrations embed.FS
@@ -41,55 +69,482 @@ type Migrator struct {
 	logger     clogger.Logger
 }
 
-// Run runs the provided database migrations
+// MigrationStatus is the status of a single migration. See Migrator.Status.
+type MigrationStatus struct {
+	Module    string
+	ID        string
+	AppliedAt *time.Time
+}
+
+// Run runs the database migrations in the direction set in the config. Migrating up applies all pending migrations
+// and migrating down reverts all applied migrations.
 func (m *Migrator) Run() error {
 	m.logger.WithTags(map[string]interface{}{
 		"direction": m.config.Migrations.Direction,
 		"source":    m.config.Migrations.Source,
 	}).Info("Running database migrations..")
 
-	direction, err := m.config.Migrations.sqlMigrateDirection()
-	if err != nil {
-		return cerrors.New(err, "failed to get sql migrate direction from config", nil)
+	var (
+		n   int
+		err error
+	)
+
+	switch m.config.Migrations.Direction {
+	case MigrationsDirectionUp, "":
+		n, err = m.Migrate(context.Background())
+	case MigrationsDirectionDown:
+		n, err = m.Rollback(context.Background(), 0)
+	default:
+		return cerrors.New(nil, "invalid migration direction", map[string]interface{}{
+			"direction": m.config.Migrations.Direction,
+		})
 	}
 
-	hasMigrations, err := m.hasMigrations()
 	if err != nil {
-		return cerrors.New(err, "failed to check for migrations", nil)
+		return cerrors.New(err, "failed to exec database migrations", nil)
 	}
 
-	if !hasMigrations {
-		m.logger.Info("No migrations found")
+	if n == 0 {
+		m.logger.Info("No migrations to apply")
 		return nil
 	}
 
-	source := migrate.MigrationSource(migrate.EmbedFileSystemMigrationSource{
-		FileSystem: m.migrations,
-		Root:       ".",
-	})
+	m.logger.WithTags(map[string]interface{}{
+		"count": n,
+	}).Info("Successfully applied migrations")
+
+	return nil
+}
+
+// Stage implements clifecycle.Staged. Migrations run before the app's servers and workers. Since migrations of large
+// tables can take a while, they have a longer timeout than the other stages.
+func (m *Migrator) Stage() clifecycle.Stage {
+	return clifecycle.Stage{
+		Name:    clifecycle.StageMigrations,
+		Timeout: migrationsStartupTimeout,
+	}
+}
+
+// Migrate applies all pending migrations and returns the number of applied migrations. Each migration is applied in
+// its own transaction along with its record in the migrations table.
+func (m *Migrator) Migrate(ctx context.Context) (int, error) {
+	plan, applied, err := m.plan(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+
+	for _, pm := range plan {
+		if applied[pm.key()] != nil {
+			continue
+		}
+
+		m.logger.WithTags(map[string]interface{}{
+			"module": pm.module,
+			"id":     pm.migration.ID,
+		}).Info("Applying migration..")
+
+		err = m.exec(ctx, pm, pm.migration.Up, true)
+		if err != nil {
+			return n, err
+		}
+
+		n++
+	}
+
+	return n, nil
+}
+
+// Rollback reverts the given number of applied migrations, in the reverse order that Migrate applies them, and returns
+// the number of reverted migrations. If steps is 0, all applied migrations are reverted.
+func (m *Migrator) Rollback(ctx context.Context, steps int) (int, error) {
+	plan, applied, err := m.plan(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+
+	for i := len(plan) - 1; i >= 0; i-- {
+		if steps > 0 && n >= steps {
+			break
+		}
+
+		pm := plan[i]
+		if applied[pm.key()] == nil {
+			continue
+		}
+
+		m.logger.WithTags(map[string]interface{}{
+			"module": pm.module,
+			"id":     pm.migration.ID,
+		}).Info("Reverting migration..")
+
+		err = m.exec(ctx, pm, pm.migration.Down, false)
+		if err != nil {
+			return n, err
+		}
+
+		n++
+	}
+
+	return n, nil
+}
+
+// Status returns the status of all known migrations in the order they are applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	plan, applied, err := m.plan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(plan))
+
+	for _, pm := range plan {
+		statuses = append(statuses, MigrationStatus{
+			Module:    pm.module,
+			ID:        pm.migration.ID,
+			AppliedAt: applied[pm.key()],
+		})
+	}
+
+	return statuses, nil
+}
+
+// RunCommand runs a migration command and writes its output to w. It can be used to build a CLI for the app's
+// database (ex. `./app db migrate`). The supported commands are:
+//
+//	migrate              applies all pending migrations
+//	rollback [-steps N]  reverts the last N migrations (default 1)
+//	status               lists all migrations and when they were applied
+func (m *Migrator) RunCommand(ctx context.Context, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return cerrors.New(nil, "migration command is required (migrate, rollback, or status)", nil)
+	}
+
+	switch args[0] {
+	case "migrate":
+		n, err := m.Migrate(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "Applied %d migrations\n", n)
+
+		return err
+	case "rollback":
+		flags := flag.NewFlagSet("rollback", flag.ContinueOnError)
+		flags.SetOutput(ioutil.Discard)
+
+		steps := flags.Int("steps", 1, "Number of migrations to revert")
+
+		err := flags.Parse(args[1:])
+		if err != nil {
+			return cerrors.New(err, "invalid rollback flags", nil)
+		}
+
+		if *steps < 1 {
+			return cerrors.New(nil, "steps must be at least 1", nil)
+		}
+
+		n, err := m.Rollback(ctx, *steps)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "Reverted %d migrations\n", n)
+
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:gomnd
+		_, _ = fmt.Fprintln(tw, "MODULE\tID\tAPPLIED AT")
+
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Module, s.ID, appliedAt)
+		}
+
+		return tw.Flush()
+	default:
+		return cerrors.New(nil, "unknown migration command", map[string]interface{}{
+			"command": args[0],
+		})
+	}
+}
+
+type plannedMigration struct {
+	module    string
+	migration Migration
+}
+
+func (pm plannedMigration) key() string {
+	return pm.module + "/" + pm.migration.ID
+}
+
+// plan returns all migrations in the order they are applied along with the applied migrations by key
+func (m *Migrator) plan(ctx context.Context) ([]plannedMigration, map[string]*time.Time, error) {
+	modules := RegisteredMigrations()
+
+	appMigrations, err := m.appMigrations()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if appMigrations != nil {
+		modules = append(modules, ModuleMigrations{Module: AppMigrationsModule, FS: appMigrations})
+	}
+
+	var plan []plannedMigration
+
+	for _, mm := range modules {
+		migrations, err := mm.migrations(m.config.Dialect)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, mig := range migrations {
+			plan = append(plan, plannedMigration{module: mm.Module, migration: mig})
+		}
+	}
+
+	err = m.ensureMigrationsTable(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	applied, err := m.appliedMigrations(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return plan, applied, nil
+}
+
+// appMigrations returns the app's migrations dir based on the config or nil if the app has no migrations
+func (m *Migrator) appMigrations() (fs.FS, error) {
+	var migrations fs.FS = m.migrations
+
 	if m.config.Migrations.Source == MigrationsSourceDir {
-		source = migrate.FileMigrationSource{
-			Dir: "./migrations",
+		if _, err := os.Stat("./migrations"); os.IsNotExist(err) {
+			return nil, nil
 		}
+
+		migrations = os.DirFS("./migrations")
 	}
 
-	n, err := migrate.Exec(m.db, m.config.Dialect, source, direction)
+	hasMigrations, err := hasMigrations(migrations)
 	if err != nil {
-		return cerrors.New(err, "failed to exec database migrations", nil)
+		return nil, cerrors.New(err, "failed to check for migrations", nil)
 	}
 
-	m.logger.WithTags(map[string]interface{}{
-		"count": n,
-	}).Info("Successfully applied migrations")
+	if !hasMigrations {
+		return nil, nil
+	}
+
+	return migrations, nil
+}
+
+func (m *Migrator) exec(ctx context.Context, pm plannedMigration, fn MigrationFunc, up bool) error {
+	tags := map[string]interface{}{
+		"module": pm.module,
+		"id":     pm.migration.ID,
+	}
+
+	if fn == nil {
+		return cerrors.New(nil, "migration does not support this direction", tags)
+	}
+
+	query := "insert into " + migrationsTable + " (module, id, applied_at) values (?, ?, ?)"
+	args := []interface{}{pm.module, pm.migration.ID, time.Now().UTC()}
+
+	if !up {
+		query = "delete from " + migrationsTable + " where module = ? and id = ?"
+		args = args[:2]
+	}
+
+	query = sqlx.Rebind(sqlx.BindType(m.config.Dialect), query)
+
+	if pm.migration.NoTransaction {
+		err := fn(context.WithValue(ctx, ctxMigrationDBKey{}, m.db), nil)
+		if err != nil {
+			return cerrors.New(err, "failed to run migration", tags)
+		}
+
+		_, err = m.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return cerrors.New(err, "failed to record migration", tags)
+		}
+
+		return nil
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return cerrors.New(err, "failed to begin migration tx", tags)
+	}
+
+	err = fn(context.WithValue(ctx, ctxMigrationDBKey{}, m.db), tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return cerrors.New(err, "failed to run migration", tags)
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		_ = tx.Rollback()
+		return cerrors.New(err, "failed to record migration", tags)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return cerrors.New(err, "failed to commit migration tx", tags)
+	}
+
+	return nil
+}
+
+// ensureMigrationsTable creates the migrations table if needed and imports the migrations applied by sql-migrate
+func (m *Migrator) ensureMigrationsTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `create table if not exists `+migrationsTable+` (
+		module varchar(255) not null,
+		id varchar(255) not null,
+		applied_at `+timestampColumnType(m.config.Dialect)+` not null,
+		primary key (module, id)
+	)`)
+	if err != nil {
+		return cerrors.New(err, "failed to create migrations table", nil)
+	}
+
+	var count int
+
+	err = m.db.QueryRowContext(ctx, "select count(*) from "+migrationsTable).Scan(&count)
+	if err != nil {
+		return cerrors.New(err, "failed to count migrations", nil)
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	legacy, err := m.legacyMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := sqlx.Rebind(sqlx.BindType(m.config.Dialect),
+		"insert into "+migrationsTable+" (module, id, applied_at) values (?, ?, ?)")
+
+	for _, s := range legacy {
+		_, err = m.db.ExecContext(ctx, query, AppMigrationsModule, s.ID, s.AppliedAt)
+		if err != nil {
+			return cerrors.New(err, "failed to import legacy migration", map[string]interface{}{
+				"id": s.ID,
+			})
+		}
+	}
 
 	return nil
 }
 
+// legacyMigrations returns the migrations recorded by sql-migrate. The legacy table does not exist for new apps, in
+// which case no migrations are returned. Other errors are returned so that migrations that were already applied are
+// not applied again.
+func (m *Migrator) legacyMigrations(ctx context.Context) ([]MigrationStatus, error) {
+	rows, err := m.db.QueryContext(ctx, "select id, applied_at from "+legacyMigrationsTable)
+	if isUndefinedTableErr(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, cerrors.New(err, "failed to query legacy migrations", nil)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var legacy []MigrationStatus
+
+	for rows.Next() {
+		var s MigrationStatus
+
+		err = rows.Scan(&s.ID, &s.AppliedAt)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to read legacy migrations", nil)
+		}
+
+		legacy = append(legacy, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, cerrors.New(err, "failed to read legacy migrations", nil)
+	}
+
+	return legacy, nil
+}
+
+// isUndefinedTableErr returns true if err is the error returned by a query on a table that does not exist
+func isUndefinedTableErr(err error) bool {
+	var (
+		pqErr     *pq.Error
+		mysqlErr  *mysql.MySQLError
+		sqliteErr sqlite3.Error
+	)
+
+	switch {
+	case errors.As(err, &pqErr):
+		return pqErr.Code == pqUndefinedTable
+	case errors.As(err, &mysqlErr):
+		return mysqlErr.Number == mysqlNoSuchTable
+	case errors.As(err, &sqliteErr):
+		return sqliteErr.Code == sqlite3.ErrError && strings.HasPrefix(sqliteErr.Error(), "no such table")
+	}
+
+	return false
+}
+
+func (m *Migrator) appliedMigrations(ctx context.Context) (map[string]*time.Time, error) {
+	rows, err := m.db.QueryContext(ctx, "select module, id, applied_at from "+migrationsTable)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to query applied migrations", nil)
+	}
+	defer func() { _ = rows.Close() }()
+
+	applied := make(map[string]*time.Time)
+
+	for rows.Next() {
+		var (
+			module, id string
+			appliedAt  time.Time
+		)
+
+		err = rows.Scan(&module, &id, &appliedAt)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to scan applied migration", nil)
+		}
+
+		applied[module+"/"+id] = &appliedAt
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, cerrors.New(err, "failed to query applied migrations", nil)
+	}
+
+	return applied, nil
+}
+
 // hasMigrations returns true if the migrations directory has at least 1 non-empty migration file.
-func (m *Migrator) hasMigrations() (bool, error) {
+func hasMigrations(migrations fs.FS) (bool, error) {
 	const emptyMigrationsChecksum = "fba9ab24993a94e181dc952f2568a4e98b47e331d89772af3115fe1c7b90d27f"
 
-	entries, err := m.migrations.ReadDir(".")
+	entries, err := fs.ReadDir(migrations, ".")
 	if err != nil {
 		return false, cerrors.New(err, "failed to read migrations dir", nil)
 	}
@@ -102,7 +557,7 @@ func (m *Migrator) hasMigrations() (bool, error) {
 		return true, nil
 	}
 
-	f, err := m.migrations.Open(entries[0].Name())
+	f, err := migrations.Open(entries[0].Name())
 	if err != nil {
 		return false, cerrors.New(err, "failed to open migrations file", map[string]interface{}{
 			"name": entries[0].Name(),
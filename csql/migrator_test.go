@@ -1,9 +1,12 @@
 package csql_test
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"embed"
 	"testing"
+	"testing/fstest"
 
 	"github.com/gocopper/copper/clogger"
 	"github.com/gocopper/copper/csql"
@@ -65,3 +68,190 @@ func TestMigrator_Run(t *testing.T) {
 	_, err = db.Query("select * from people") //nolint:rowserrcheck
 	assert.EqualError(t, err, "no such table: people")
 }
+
+func TestMigrator_VersionedMigrations(t *testing.T) {
+	csql.RegisterMigrations(csql.ModuleMigrations{
+		Module: "migrator_test_module",
+		FS: fstest.MapFS{
+			"001_create_accounts.up.sql":   {Data: []byte("create table accounts (id integer primary key);")},
+			"001_create_accounts.down.sql": {Data: []byte("drop table accounts;")},
+		},
+		Migrations: []csql.Migration{{
+			ID: "002_seed_accounts",
+			Up: func(ctx context.Context, tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, "insert into accounts (id) values (1)")
+				return err
+			},
+			Down: func(ctx context.Context, tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, "delete from accounts")
+				return err
+			},
+		}},
+	})
+
+	// Unregister the migrations so that they don't affect other tests
+	defer csql.RegisterMigrations(csql.ModuleMigrations{Module: "migrator_test_module"})
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+
+	db.SetMaxOpenConns(1)
+
+	var (
+		ctx      = context.Background()
+		migrator = csql.NewMigrator(csql.NewMigratorParams{
+			DB:         db,
+			Migrations: csql.Migrations(Migrations),
+			Config:     csql.Config{Dialect: "sqlite3"},
+			Logger:     clogger.NewNoop(),
+		})
+		count int
+	)
+
+	n, err := migrator.Migrate(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	// Module migrations run before the app's migrations
+	statuses, err := migrator.Status(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 3)
+	assert.Equal(t, "001_create_accounts", statuses[0].ID)
+	assert.Equal(t, "002_seed_accounts", statuses[1].ID)
+	assert.Equal(t, "app", statuses[2].Module)
+
+	for _, s := range statuses {
+		assert.NotNil(t, s.AppliedAt)
+	}
+
+	n, err = migrator.Migrate(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	var out bytes.Buffer
+
+	assert.NoError(t, migrator.RunCommand(ctx, []string{"rollback", "-steps", "2"}, &out))
+	assert.Equal(t, "Reverted 2 migrations\n", out.String())
+
+	assert.NoError(t, db.QueryRow("select count(*) from accounts").Scan(&count))
+	assert.Equal(t, 0, count)
+
+	out.Reset()
+
+	assert.NoError(t, migrator.RunCommand(ctx, []string{"status"}, &out))
+	assert.Contains(t, out.String(), "migrator_test_module  001_create_accounts")
+	assert.Contains(t, out.String(), "pending")
+}
+
+func TestMigrator_ImportsLegacyMigrations(t *testing.T) {
+	t.Parallel()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`
+		create table people (name text);
+		create table gorp_migrations (id text primary key, applied_at datetime);
+		insert into gorp_migrations (id, applied_at) values ('migrations_test.sql', '2022-01-01 00:00:00');
+	`)
+	assert.NoError(t, err)
+
+	migrator := csql.NewMigrator(csql.NewMigratorParams{
+		DB:         db,
+		Migrations: csql.Migrations(Migrations),
+		Config:     csql.Config{Dialect: "sqlite3"},
+		Logger:     clogger.NewNoop(),
+	})
+
+	statuses, err := migrator.Status(context.Background())
+	assert.NoError(t, err)
+
+	for _, s := range statuses {
+		if s.Module == csql.AppMigrationsModule {
+			assert.Equal(t, "migrations_test.sql", s.ID)
+			assert.NotNil(t, s.AppliedAt)
+		}
+	}
+
+	// The app's migration is not applied again
+	assert.NoError(t, migrator.Run())
+}
+
+func TestMigrator_LegacyMigrationsErr(t *testing.T) {
+	t.Parallel()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+
+	db.SetMaxOpenConns(1)
+
+	// the legacy table exists but cannot be read, so the migrator must not assume that no migrations were applied
+	_, err = db.Exec(`create table gorp_migrations (id text primary key)`)
+	assert.NoError(t, err)
+
+	migrator := csql.NewMigrator(csql.NewMigratorParams{
+		DB:         db,
+		Migrations: csql.Migrations(Migrations),
+		Config:     csql.Config{Dialect: "sqlite3"},
+		Logger:     clogger.NewNoop(),
+	})
+
+	_, err = migrator.Status(context.Background())
+	assert.Error(t, err)
+	assert.Error(t, migrator.Run())
+}
+
+func TestMigrator_DialectMigrations(t *testing.T) {
+	csql.RegisterMigrations(csql.ModuleMigrations{
+		Module: "migrator_test_dialect",
+		FS: fstest.MapFS{
+			"001_create_pets.up.sql":         {Data: []byte("create table pets (name text);")},
+			"001_create_pets.up.sqlite3.sql": {Data: []byte("create table pets (name text, sqlite integer);")},
+			"001_create_pets.up.mysql.sql":   {Data: []byte("create table pets (name varchar(255)) engine=InnoDB;")},
+			"001_create_pets.down.sql":       {Data: []byte("drop table pets;")},
+		},
+	})
+
+	// Unregister the migrations so that they don't affect other tests
+	defer csql.RegisterMigrations(csql.ModuleMigrations{Module: "migrator_test_dialect"})
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+
+	db.SetMaxOpenConns(1)
+
+	migrator := csql.NewMigrator(csql.NewMigratorParams{
+		DB:         db,
+		Migrations: csql.Migrations(Migrations),
+		Config:     csql.Config{Dialect: csql.DialectSQLite},
+		Logger:     clogger.NewNoop(),
+	})
+
+	_, err = migrator.Migrate(context.Background())
+	assert.NoError(t, err)
+
+	_, err = db.Exec("insert into pets (name, sqlite) values ('test', 1)")
+	assert.NoError(t, err)
+}
+
+func TestValidateMigrations(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, csql.ValidateMigrations(fstest.MapFS{
+		"migrations.sql":       {Data: []byte("-- +migrate Up\ncreate table pets (id text primary key);")},
+		"migrations.mysql.sql": {Data: []byte("-- +migrate Up\ncreate table pets (id varchar(64) primary key);")},
+	}))
+
+	// the mysql variant is never loaded since it does not override a migration file
+	assert.Error(t, csql.ValidateMigrations(fstest.MapFS{
+		"migration.sql":        {Data: []byte("-- +migrate Up\ncreate table pets (id text primary key);")},
+		"migrations.mysql.sql": {Data: []byte("-- +migrate Up\ncreate table pets (id varchar(64) primary key);")},
+	}))
+
+	assert.Error(t, csql.ValidateMigrations(fstest.MapFS{
+		"migrations.sql":       {Data: []byte("-- +migrate Up\ncreate table pets (id text primary key);")},
+		"migrations.mysql.sql": {Data: []byte("create table pets (id varchar(64) primary key);")},
+	}))
+}
@@ -3,8 +3,10 @@ package csql
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -19,52 +21,158 @@ type Querier interface {
 
 // NewQuerier returns a querier using the given database connection and the dialect
 func NewQuerier(db *sql.DB, config Config) Querier {
+	return NewQuerierWithParams(NewQuerierParams{
+		DB:     db,
+		Config: config,
+		Logger: clogger.NewNoop(),
+	})
+}
+
+// NewQuerierParams holds the params needed for NewQuerierWithParams
+type NewQuerierParams struct {
+	DB       *sql.DB
+	Replicas *Replicas
+	Config   Config
+	Logger   clogger.Logger
+}
+
+// NewQuerierWithParams returns a querier that runs queries on the given database connection and reads on the
+// replicas for contexts created using CtxWithReplica. Replicas may be nil.
+// Every query is logged at debug level and its duration is recorded in the csql_query_duration_seconds Prometheus
+// histogram. Queries that take longer than csql.slow_query_threshold are logged as warnings.
+// If csql.statement_cache_size is set, queries run on the primary outside of a transaction (ex. in handlers that use
+// NoTxMiddleware) use prepared statements that are cached by query.
+func NewQuerierWithParams(p NewQuerierParams) Querier {
+	db := sqlx.NewDb(p.DB, p.Config.Dialect)
+
+	var stmts *stmtCache
+	if p.Config.StatementCacheSize > 0 {
+		stmts = newStmtCache(db, p.Config.StatementCacheSize)
+	}
+
 	return &querier{
-		db: sqlx.NewDb(db, config.Dialect),
+		db:       db,
+		replicas: p.Replicas,
+		stmts:    stmts,
+		instrument: &queryInstrument{
+			logger:        p.Logger.With(clogger.Module("csql")),
+			slowThreshold: p.Config.SlowQueryThreshold,
+		},
 		in: false,
 	}
 }
 
 type querier struct {
-	db *sqlx.DB
-	in bool
+	db         *sqlx.DB
+	replicas   *Replicas
+	stmts      *stmtCache
+	instrument *queryInstrument
+	in         bool
 }
 
 func (q *querier) WithIn() Querier {
 	return &querier{
-		db: q.db,
-		in: true,
+		db:         q.db,
+		replicas:   q.replicas,
+		stmts:      q.stmts,
+		instrument: q.instrument,
+		in:         true,
 	}
 }
 
 func (q *querier) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	query, args, err := q.mkQueryWithArgs(ctx, query, args)
+	query, args, err := q.mkQueryWithArgs(query, args)
 	if err != nil {
 		return err
 	}
 
-	return mustTxFromCtx(ctx).GetContext(ctx, dest, query, args...)
+	return q.read(ctx, "get", query, func(c conn) (int64, error) {
+		err := c.GetContext(ctx, dest, query, args...)
+		return resultRows(dest, err), err
+	})
 }
 
 func (q *querier) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	query, args, err := q.mkQueryWithArgs(ctx, query, args)
+	query, args, err := q.mkQueryWithArgs(query, args)
 	if err != nil {
 		return err
 	}
 
-	return mustTxFromCtx(ctx).SelectContext(ctx, dest, query, args...)
+	return q.read(ctx, "select", query, func(c conn) (int64, error) {
+		err := c.SelectContext(ctx, dest, query, args...)
+		return resultRows(dest, err), err
+	})
 }
 
 func (q *querier) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	query, args, err := q.mkQueryWithArgs(ctx, query, args)
+	query, args, err := q.mkQueryWithArgs(query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := connFromCtx(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return mustTxFromCtx(ctx).ExecContext(ctx, query, args...)
+	start := time.Now()
+	res, err := q.primary(c).ExecContext(ctx, query, args...)
+
+	q.instrument.observe("exec", dbPrimary, query, start, affectedRows(res, err), err)
+
+	return res, err
+}
+
+// read runs fn on a replica if the context was created using CtxWithReplica. If the replica cannot be reached, it
+// is taken out of rotation and fn is run on the primary instead.
+func (q *querier) read(ctx context.Context, op, query string, fn func(c conn) (int64, error)) error {
+	run := func(c conn, db string) error {
+		start := time.Now()
+		rows, err := fn(c)
+
+		q.instrument.observe(op, db, query, start, rows, err)
+
+		return err
+	}
+
+	if !isReplicaCtx(ctx) {
+		c, err := connFromCtx(ctx)
+		if err != nil {
+			return err
+		}
+
+		return run(q.primary(c), dbPrimary)
+	}
+
+	if rep := q.replicas.pick(); rep != nil {
+		err := run(rep.db, dbReplica)
+		if err == nil || !isConnErr(err) {
+			return err
+		}
+
+		q.replicas.setHealth(rep, err)
+	}
+
+	// Reads that opted into replicas may not have a transaction (ex. in a background job)
+	c, err := connFromCtx(ctx)
+	if err != nil {
+		c = q.db
+	}
+
+	return run(q.primary(c), dbPrimary)
 }
 
-func (q *querier) mkQueryWithArgs(ctx context.Context, query string, args []interface{}) (string, []interface{}, error) {
+// primary returns the conn used to run queries on the primary. It uses the statement cache if it is enabled and c is
+// not a transaction.
+func (q *querier) primary(c conn) conn {
+	if _, isTx := c.(*sqlx.Tx); isTx || q.stmts == nil {
+		return c
+	}
+
+	return &stmtConn{conn: c, cache: q.stmts}
+}
+
+func (q *querier) mkQueryWithArgs(query string, args []interface{}) (string, []interface{}, error) {
 	var err error
 
 	if q.in {
@@ -74,10 +182,5 @@ func (q *querier) mkQueryWithArgs(ctx context.Context, query string, args []inte
 		}
 	}
 
-	tx, err := txFromCtx(ctx)
-	if err != nil {
-		return "", nil, err
-	}
-
-	return tx.Rebind(query), args, nil
+	return q.db.Rebind(query), args, nil
 }
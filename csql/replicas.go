@@ -0,0 +1,256 @@
+package csql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	defaultReplicaHealthCheckInterval = 10 * time.Second
+	replicaPingTimeout                = 5 * time.Second
+)
+
+const replicaCtxKey = ctxKey("csql/replica")
+
+// NewReplicasParams holds the params needed for NewReplicas
+type NewReplicasParams struct {
+	Config    Config
+	Lifecycle *clifecycle.Lifecycle
+	Logger    clogger.Logger
+}
+
+// NewReplicas opens connections to the read replicas in the config and checks their health at the configured
+// interval until the lifecycle stops. Replicas that are unreachable are taken out of rotation until they pass a
+// health check again. If no replicas are configured, a nil *Replicas is returned and all queries run on the primary.
+func NewReplicas(p NewReplicasParams) (*Replicas, error) {
+	if len(p.Config.Replicas.DSNs) == 0 {
+		return nil, nil
+	}
+
+	logger := p.Logger.With(clogger.Module("csql"))
+
+	logger.WithTags(map[string]interface{}{
+		"dialect":  p.Config.Dialect,
+		"replicas": len(p.Config.Replicas.DSNs),
+	}).Info("Opening read replica connections..")
+
+	dbs := make([]*sql.DB, 0, len(p.Config.Replicas.DSNs))
+
+	for i, dsn := range p.Config.Replicas.DSNs {
+		db, err := sql.Open(p.Config.Dialect, prepareDSN(p.Config.Dialect, dsn))
+		if err != nil {
+			return nil, cerrors.New(err, "failed to open replica db connection", map[string]interface{}{
+				"dialect": p.Config.Dialect,
+				"replica": i,
+			})
+		}
+
+		configurePool(db, p.Config, dsn)
+
+		dbs = append(dbs, db)
+	}
+
+	replicas := NewReplicasWithDBs(p.Config.Dialect, dbs...)
+	replicas.logger = logger
+
+	replicas.CheckHealth(context.Background())
+
+	interval := p.Config.Replicas.HealthCheckInterval
+	if interval <= 0 {
+		interval = defaultReplicaHealthCheckInterval
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				replicas.CheckHealth(context.Background())
+			}
+		}
+	}()
+
+	p.Lifecycle.OnStop(func(ctx context.Context) error {
+		close(done)
+
+		logger.Info("Closing read replica connections..")
+
+		for i, db := range dbs {
+			err := db.Close()
+			if err != nil {
+				return cerrors.New(err, "failed to close replica db connection", map[string]interface{}{
+					"replica": i,
+				})
+			}
+		}
+
+		return nil
+	})
+
+	return replicas, nil
+}
+
+// NewReplicasWithDBs creates Replicas with the given connections. All replicas are considered healthy until they fail
+// a health check (see CheckHealth) or a query fails because the replica is unreachable.
+func NewReplicasWithDBs(dialect string, dbs ...*sql.DB) *Replicas {
+	replicas := make([]*replica, 0, len(dbs))
+
+	for i, db := range dbs {
+		replicas = append(replicas, &replica{
+			index:   i,
+			db:      sqlx.NewDb(db, dialect),
+			healthy: 1,
+		})
+	}
+
+	return &Replicas{
+		replicas: replicas,
+		logger:   clogger.NewNoop(),
+	}
+}
+
+// Replicas is a set of read replicas of the primary database. Reads are spread across the healthy replicas in a
+// round-robin manner. See CtxWithReplica.
+type Replicas struct {
+	replicas []*replica
+	next     uint32
+	logger   clogger.Logger
+}
+
+type replica struct {
+	index   int
+	db      *sqlx.DB
+	healthy int32
+}
+
+// CtxWithReplica returns a context whose read queries (i.e. Querier's Get and Select) are run on a read replica
+// instead of the primary, even if the context has a database transaction. Writes are always run on the primary.
+// If there are no healthy replicas, reads are run on the primary.
+// Since replicas may lag behind the primary, it should only be used for reads that can tolerate slightly stale data.
+func CtxWithReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaCtxKey, true)
+}
+
+// NewReplicaMiddleware creates a new ReplicaMiddleware
+func NewReplicaMiddleware() *ReplicaMiddleware {
+	return &ReplicaMiddleware{}
+}
+
+// ReplicaMiddleware is a chttp.Middleware that runs the reads of a request on a read replica. See CtxWithReplica.
+// It can be used as a route middleware for read-only handlers.
+type ReplicaMiddleware struct{}
+
+// Handle implements the chttp.Middleware interface. See ReplicaMiddleware
+func (m *ReplicaMiddleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(CtxWithReplica(r.Context())))
+	})
+}
+
+func isReplicaCtx(ctx context.Context) bool {
+	replica, _ := ctx.Value(replicaCtxKey).(bool)
+	return replica
+}
+
+// DB returns the connection to the next healthy replica or nil if there are none
+func (r *Replicas) DB() *sql.DB {
+	rep := r.pick()
+	if rep == nil {
+		return nil
+	}
+
+	return rep.db.DB
+}
+
+// Healthy returns the number of healthy replicas
+func (r *Replicas) Healthy() int {
+	if r == nil {
+		return 0
+	}
+
+	var n int
+
+	for _, rep := range r.replicas {
+		if atomic.LoadInt32(&rep.healthy) == 1 {
+			n++
+		}
+	}
+
+	return n
+}
+
+// CheckHealth pings each replica and updates whether it is used for reads
+func (r *Replicas) CheckHealth(ctx context.Context) {
+	if r == nil {
+		return
+	}
+
+	for _, rep := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+		err := rep.db.PingContext(pingCtx)
+
+		cancel()
+
+		r.setHealth(rep, err)
+	}
+}
+
+func (r *Replicas) pick() *replica {
+	if r == nil || len(r.replicas) == 0 {
+		return nil
+	}
+
+	start := atomic.AddUint32(&r.next, 1)
+
+	for i := 0; i < len(r.replicas); i++ {
+		rep := r.replicas[(int(start)+i)%len(r.replicas)]
+
+		if atomic.LoadInt32(&rep.healthy) == 1 {
+			return rep
+		}
+	}
+
+	return nil
+}
+
+func (r *Replicas) setHealth(rep *replica, err error) {
+	logger := r.logger.WithTags(map[string]interface{}{
+		"replica": rep.index,
+	})
+
+	if err == nil {
+		if atomic.CompareAndSwapInt32(&rep.healthy, 0, 1) {
+			logger.Info("Read replica is healthy again")
+		}
+
+		return
+	}
+
+	if atomic.CompareAndSwapInt32(&rep.healthy, 1, 0) {
+		logger.Warn("Read replica is unhealthy; reads are moved to other replicas or the primary", err)
+	}
+}
+
+// isConnErr returns true if err means that the database could not be reached
+func isConnErr(err error) bool {
+	var netErr net.Error
+
+	return errors.Is(err, driver.ErrBadConn) || errors.As(err, &netErr)
+}
@@ -0,0 +1,77 @@
+package csql_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/csql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuerier_Replicas(t *testing.T) {
+	t.Parallel()
+
+	var (
+		primary = openTestDB(t, "primary")
+		replica = openTestDB(t, "replica")
+
+		config   = csql.Config{Dialect: "sqlite3"}
+		replicas = csql.NewReplicasWithDBs("sqlite3", replica)
+		querier  = csql.NewQuerierWithParams(csql.NewQuerierParams{
+			DB:       primary,
+			Replicas: replicas,
+			Config:   config,
+			Logger:   clogger.NewNoop(),
+		})
+		name string
+	)
+
+	ctx, tx, err := csql.CtxWithTx(context.Background(), primary, "sqlite3")
+	assert.NoError(t, err)
+
+	defer func() { _ = tx.Rollback() }()
+
+	assert.NoError(t, querier.Get(ctx, &name, "select name from people"))
+	assert.Equal(t, "primary", name)
+
+	assert.NoError(t, querier.Get(csql.CtxWithReplica(ctx), &name, "select name from people"))
+	assert.Equal(t, "replica", name)
+
+	// Reads fail over to the primary when the replica is unhealthy
+	assert.NoError(t, replica.Close())
+
+	replicas.CheckHealth(context.Background())
+	assert.Equal(t, 0, replicas.Healthy())
+
+	assert.NoError(t, querier.Get(csql.CtxWithReplica(ctx), &name, "select name from people"))
+	assert.Equal(t, "primary", name)
+}
+
+func TestQuerier_Replicas_NoTx(t *testing.T) {
+	t.Parallel()
+
+	var (
+		primary = openTestDB(t, "primary")
+		querier = csql.NewQuerier(primary, csql.Config{Dialect: "sqlite3"})
+		names   []string
+	)
+
+	assert.NoError(t, querier.Select(csql.CtxWithReplica(context.Background()), &names, "select name from people"))
+	assert.Equal(t, []string{"primary"}, names)
+}
+
+func openTestDB(t *testing.T, name string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("create table people (name text); insert into people (name) values (?)", name)
+	assert.NoError(t, err)
+
+	return db
+}
@@ -0,0 +1,465 @@
+package csql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// maxBatchParams is the maximum number of bind params used by a single batch insert. It stays below SQLite's default
+// limit of 999 params.
+const maxBatchParams = 999
+
+// ErrStaleVersion is returned by Repo.Update when the row was updated since it was read (see Table.VersionColumn)
+var ErrStaleVersion = errors.New("row was updated concurrently")
+
+// Table describes the table a Repo queries
+type Table struct {
+	// Name of the table
+	Name string
+
+	// PrimaryKey is the primary key column (default: id)
+	PrimaryKey string
+
+	// SoftDeleteColumn is a nullable timestamp column (ex. deleted_at). If set, Delete sets the column instead of
+	// deleting the row, and rows where it is set are excluded from all queries.
+	SoftDeleteColumn string
+
+	// VersionColumn is an integer column (ex. version) used for optimistic locking. If set, Update only succeeds if
+	// the row's version has not changed since it was read and increments it.
+	VersionColumn string
+}
+
+// PageParams are the params of an offset-based page
+type PageParams struct {
+	Limit  int
+	Offset int
+}
+
+// KeysetParams are the params of a keyset-based page. Keyset pagination is faster than offset pagination for large
+// tables but requires a unique, sortable column.
+type KeysetParams struct {
+	// Column the rows are sorted by (default: the primary key)
+	Column string
+
+	// After is the value of Column of the last row on the previous page (see Page.NextAfter). It is nil for the first
+	// page.
+	After interface{}
+
+	Limit int
+	Desc  bool
+}
+
+// Page is a page of rows
+type Page[T any] struct {
+	Items   []T
+	HasMore bool
+
+	// NextAfter is the value to use as KeysetParams.After to get the next page. It is only set by Repo.PageAfter.
+	NextAfter interface{}
+}
+
+// Repo provides common queries for a table whose rows are scanned into T. T must be a struct whose fields are mapped
+// to columns using db tags (the same as Querier). Fields without a db tag are ignored. For example:
+//
+//	type User struct {
+//		ID        string     `db:"id"`
+//		Email     string     `db:"email"`
+//		DeletedAt *time.Time `db:"deleted_at"`
+//		Version   int        `db:"version"`
+//	}
+//
+//	users := csql.NewRepo[User](querier, config.Dialect, csql.Table{
+//		Name:             "users",
+//		SoftDeleteColumn: "deleted_at",
+//		VersionColumn:    "version",
+//	})
+//
+// Like Querier, Repo runs its queries within the transaction in the context.
+type Repo[T any] struct {
+	querier Querier
+	dialect string
+	table   Table
+	columns []string
+	fields  map[string][]int
+}
+
+// NewRepo creates a Repo for the given table. It panics if T is not a struct.
+func NewRepo[T any](querier Querier, dialect string, table Table) *Repo[T] {
+	var zero T
+
+	t := reflect.TypeOf(zero)
+	if t == nil || t.Kind() != reflect.Struct {
+		panic("csql: NewRepo requires a struct type")
+	}
+
+	if table.PrimaryKey == "" {
+		table.PrimaryKey = "id"
+	}
+
+	r := &Repo[T]{
+		querier: querier,
+		dialect: normalizeDialect(dialect),
+		table:   table,
+		fields:  make(map[string][]int),
+	}
+
+	r.mapFields(t, nil)
+
+	return r
+}
+
+func (r *Repo[T]) mapFields(t reflect.Type, index []int) {
+	for i := 0; i < t.NumField(); i++ {
+		var (
+			field      = t.Field(i)
+			fieldIndex = append(append([]int{}, index...), i)
+			col        = strings.Split(field.Tag.Get("db"), ",")[0]
+		)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && col == "" {
+			r.mapFields(field.Type, fieldIndex)
+			continue
+		}
+
+		if col == "" || col == "-" || field.PkgPath != "" {
+			continue
+		}
+
+		r.columns = append(r.columns, col)
+		r.fields[col] = fieldIndex
+	}
+}
+
+// Get returns the row with the given primary key. It returns sql.ErrNoRows if the row does not exist.
+func (r *Repo[T]) Get(ctx context.Context, id interface{}) (T, error) {
+	var dest T
+
+	err := r.querier.Get(ctx, &dest, r.selectQuery(r.table.PrimaryKey+" = ?"), id)
+
+	return dest, err
+}
+
+// List returns the rows that match where (ex. "org_id = ?"). where may be empty to list all rows.
+func (r *Repo[T]) List(ctx context.Context, where string, args ...interface{}) ([]T, error) {
+	var dest []T
+
+	err := r.querier.Select(ctx, &dest, r.selectQuery(where)+" order by "+r.table.PrimaryKey, args...)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to list rows", map[string]interface{}{
+			"table": r.table.Name,
+		})
+	}
+
+	return dest, nil
+}
+
+// Page returns a page of the rows that match where using offset pagination
+func (r *Repo[T]) Page(ctx context.Context, p PageParams, where string, args ...interface{}) (Page[T], error) {
+	var dest []T
+
+	query := r.selectQuery(where) + " order by " + r.table.PrimaryKey + " limit ? offset ?"
+
+	err := r.querier.Select(ctx, &dest, query, append(args, p.Limit+1, p.Offset)...)
+	if err != nil {
+		return Page[T]{}, cerrors.New(err, "failed to query page", map[string]interface{}{
+			"table": r.table.Name,
+		})
+	}
+
+	return newPage(dest, p.Limit), nil
+}
+
+// PageAfter returns a page of the rows that match where using keyset pagination
+func (r *Repo[T]) PageAfter(ctx context.Context, p KeysetParams, where string, args ...interface{}) (Page[T], error) {
+	var (
+		dest  []T
+		col   = p.Column
+		op    = ">"
+		order = "asc"
+	)
+
+	if col == "" {
+		col = r.table.PrimaryKey
+	}
+
+	if p.Desc {
+		op, order = "<", "desc"
+	}
+
+	if p.After != nil {
+		where = andWhere(where, col+" "+op+" ?")
+		args = append(args, p.After)
+	}
+
+	query := r.selectQuery(where) + " order by " + col + " " + order + " limit ?"
+
+	err := r.querier.Select(ctx, &dest, query, append(args, p.Limit+1)...)
+	if err != nil {
+		return Page[T]{}, cerrors.New(err, "failed to query page", map[string]interface{}{
+			"table": r.table.Name,
+		})
+	}
+
+	page := newPage(dest, p.Limit)
+
+	if len(page.Items) > 0 {
+		page.NextAfter = r.value(&page.Items[len(page.Items)-1], col)
+	}
+
+	return page, nil
+}
+
+// Insert inserts a row
+func (r *Repo[T]) Insert(ctx context.Context, item T) error {
+	return r.InsertMany(ctx, []T{item})
+}
+
+// InsertMany inserts the rows in batches
+func (r *Repo[T]) InsertMany(ctx context.Context, items []T) error {
+	return r.insert(ctx, items, "")
+}
+
+// Upsert inserts the rows in batches. Rows that conflict with an existing row on conflictColumns (default: the
+// primary key) update the existing row instead. On MySQL, the conflict is detected using any unique index so
+// conflictColumns are only used to exclude columns from the update.
+func (r *Repo[T]) Upsert(ctx context.Context, items []T, conflictColumns ...string) error {
+	if len(conflictColumns) == 0 {
+		conflictColumns = []string{r.table.PrimaryKey}
+	}
+
+	return r.insert(ctx, items, r.upsertClause(conflictColumns))
+}
+
+// Update updates all columns of the row with the item's primary key. If the table has a version column, the update
+// fails with ErrStaleVersion if the row's version does not match the item's, and the item's version is incremented
+// on success. It returns sql.ErrNoRows if the row does not exist.
+func (r *Repo[T]) Update(ctx context.Context, item *T) error {
+	var (
+		sets = make([]string, 0, len(r.columns))
+		args = make([]interface{}, 0, len(r.columns)+2)
+	)
+
+	for _, col := range r.columns {
+		if col == r.table.PrimaryKey || col == r.table.VersionColumn {
+			continue
+		}
+
+		sets = append(sets, col+" = ?")
+		args = append(args, r.value(item, col))
+	}
+
+	where := r.table.PrimaryKey + " = ?"
+	args = append(args, r.value(item, r.table.PrimaryKey))
+
+	if r.table.VersionColumn != "" {
+		sets = append(sets, r.table.VersionColumn+" = "+r.table.VersionColumn+" + 1")
+		where = andWhere(where, r.table.VersionColumn+" = ?")
+		args = append(args, r.value(item, r.table.VersionColumn))
+	}
+
+	query := "update " + r.table.Name + " set " + strings.Join(sets, ", ") + " where " + r.notDeleted(where)
+
+	res, err := r.querier.Exec(ctx, query, args...)
+	if err != nil {
+		return cerrors.New(err, "failed to update row", map[string]interface{}{
+			"table": r.table.Name,
+		})
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return cerrors.New(err, "failed to get affected rows", nil)
+	}
+
+	if n == 0 {
+		return r.missingOrStale(ctx, item)
+	}
+
+	if r.table.VersionColumn != "" {
+		v := reflect.ValueOf(item).Elem().FieldByIndex(r.fields[r.table.VersionColumn])
+		v.SetInt(v.Int() + 1)
+	}
+
+	return nil
+}
+
+// Delete deletes the row with the given primary key. If the table has a soft delete column, the column is set
+// instead.
+func (r *Repo[T]) Delete(ctx context.Context, id interface{}) error {
+	if r.table.SoftDeleteColumn == "" {
+		return r.HardDelete(ctx, id)
+	}
+
+	query := "update " + r.table.Name + " set " + r.table.SoftDeleteColumn + " = ? where " +
+		r.notDeleted(r.table.PrimaryKey+" = ?")
+
+	_, err := r.querier.Exec(ctx, query, time.Now().UTC(), id)
+	if err != nil {
+		return cerrors.New(err, "failed to soft delete row", map[string]interface{}{
+			"table": r.table.Name,
+		})
+	}
+
+	return nil
+}
+
+// HardDelete deletes the row with the given primary key even if the table has a soft delete column
+func (r *Repo[T]) HardDelete(ctx context.Context, id interface{}) error {
+	_, err := r.querier.Exec(ctx, "delete from "+r.table.Name+" where "+r.table.PrimaryKey+" = ?", id)
+	if err != nil {
+		return cerrors.New(err, "failed to delete row", map[string]interface{}{
+			"table": r.table.Name,
+		})
+	}
+
+	return nil
+}
+
+func (r *Repo[T]) insert(ctx context.Context, items []T, suffix string) error {
+	if len(r.columns) == 0 {
+		return cerrors.New(nil, "row type has no db tagged fields", map[string]interface{}{
+			"table": r.table.Name,
+		})
+	}
+
+	batchSize := maxBatchParams / len(r.columns)
+
+	for start := 0; start < len(items); start += batchSize {
+		end := start + batchSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		var (
+			rows = make([]string, 0, end-start)
+			args = make([]interface{}, 0, (end-start)*len(r.columns))
+			row  = "(" + strings.TrimSuffix(strings.Repeat("?, ", len(r.columns)), ", ") + ")"
+		)
+
+		for i := start; i < end; i++ {
+			rows = append(rows, row)
+
+			for _, col := range r.columns {
+				args = append(args, r.value(&items[i], col))
+			}
+		}
+
+		query := "insert into " + r.table.Name + " (" + strings.Join(r.columns, ", ") + ") values " +
+			strings.Join(rows, ", ") + suffix
+
+		_, err := r.querier.Exec(ctx, query, args...)
+		if err != nil {
+			return cerrors.New(err, "failed to insert rows", map[string]interface{}{
+				"table": r.table.Name,
+				"rows":  end - start,
+			})
+		}
+	}
+
+	return nil
+}
+
+func (r *Repo[T]) upsertClause(conflictColumns []string) string {
+	var (
+		conflict = make(map[string]bool, len(conflictColumns))
+		sets     = make([]string, 0, len(r.columns))
+	)
+
+	for _, col := range conflictColumns {
+		conflict[col] = true
+	}
+
+	for _, col := range r.columns {
+		if conflict[col] {
+			continue
+		}
+
+		if r.dialect == DialectMySQL {
+			sets = append(sets, col+" = values("+col+")")
+		} else {
+			sets = append(sets, col+" = excluded."+col)
+		}
+	}
+
+	if r.dialect == DialectMySQL {
+		if len(sets) == 0 {
+			// MySQL has no "do nothing" so the first conflict column is set to itself
+			sets = append(sets, conflictColumns[0]+" = "+conflictColumns[0])
+		}
+
+		return " on duplicate key update " + strings.Join(sets, ", ")
+	}
+
+	if len(sets) == 0 {
+		return " on conflict (" + strings.Join(conflictColumns, ", ") + ") do nothing"
+	}
+
+	return " on conflict (" + strings.Join(conflictColumns, ", ") + ") do update set " + strings.Join(sets, ", ")
+}
+
+// missingOrStale returns the error for an update that did not affect any row
+func (r *Repo[T]) missingOrStale(ctx context.Context, item *T) error {
+	if r.table.VersionColumn == "" {
+		return cerrors.New(sql.ErrNoRows, "row does not exist", map[string]interface{}{
+			"table": r.table.Name,
+		})
+	}
+
+	_, err := r.Get(ctx, r.value(item, r.table.PrimaryKey))
+	if err != nil {
+		return err
+	}
+
+	return ErrStaleVersion
+}
+
+func (r *Repo[T]) selectQuery(where string) string {
+	query := "select " + strings.Join(r.columns, ", ") + " from " + r.table.Name
+
+	where = r.notDeleted(where)
+	if where != "" {
+		query += " where " + where
+	}
+
+	return query
+}
+
+// notDeleted adds the soft delete condition to where
+func (r *Repo[T]) notDeleted(where string) string {
+	if r.table.SoftDeleteColumn == "" {
+		return where
+	}
+
+	return andWhere(where, r.table.SoftDeleteColumn+" is null")
+}
+
+func (r *Repo[T]) value(item *T, col string) interface{} {
+	index, ok := r.fields[col]
+	if !ok {
+		return nil
+	}
+
+	return reflect.ValueOf(item).Elem().FieldByIndex(index).Interface()
+}
+
+func newPage[T any](items []T, limit int) Page[T] {
+	if len(items) > limit {
+		return Page[T]{Items: items[:limit], HasMore: true}
+	}
+
+	return Page[T]{Items: items}
+}
+
+func andWhere(where, cond string) string {
+	if where == "" {
+		return cond
+	}
+
+	return "(" + where + ") and " + cond
+}
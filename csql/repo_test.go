@@ -0,0 +1,121 @@
+package csql_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/csql"
+	"github.com/stretchr/testify/assert"
+)
+
+type testUser struct {
+	ID        int        `db:"id"`
+	Email     string     `db:"email"`
+	DeletedAt *time.Time `db:"deleted_at"`
+	Version   int        `db:"version"`
+	Ignored   string
+}
+
+func newTestRepo(t *testing.T) (context.Context, *csql.Repo[testUser]) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`create table users (
+		id integer primary key,
+		email text not null unique,
+		deleted_at timestamp,
+		version integer not null default 0
+	)`)
+	assert.NoError(t, err)
+
+	ctx, tx, err := csql.CtxWithTx(context.Background(), db, csql.DialectSQLite)
+	assert.NoError(t, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	config := csql.Config{Dialect: csql.DialectSQLite}
+
+	return ctx, csql.NewRepo[testUser](csql.NewQuerier(db, config), config.Dialect, csql.Table{
+		Name:             "users",
+		SoftDeleteColumn: "deleted_at",
+		VersionColumn:    "version",
+	})
+}
+
+func TestRepo_CRUD(t *testing.T) {
+	t.Parallel()
+
+	ctx, repo := newTestRepo(t)
+
+	assert.NoError(t, repo.Insert(ctx, testUser{ID: 1, Email: "a@example.com"}))
+
+	user, err := repo.Get(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "a@example.com", user.Email)
+
+	user.Email = "b@example.com"
+	assert.NoError(t, repo.Update(ctx, &user))
+	assert.Equal(t, 1, user.Version)
+
+	stale := user
+	stale.Version = 0
+	assert.True(t, errors.Is(repo.Update(ctx, &stale), csql.ErrStaleVersion))
+
+	assert.NoError(t, repo.Delete(ctx, 1))
+
+	_, err = repo.Get(ctx, 1)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	assert.True(t, errors.Is(repo.Update(ctx, &user), sql.ErrNoRows))
+}
+
+func TestRepo_Pagination(t *testing.T) {
+	t.Parallel()
+
+	ctx, repo := newTestRepo(t)
+
+	assert.NoError(t, repo.InsertMany(ctx, []testUser{
+		{ID: 1, Email: "1@example.com"},
+		{ID: 2, Email: "2@example.com"},
+		{ID: 3, Email: "3@example.com"},
+	}))
+
+	page, err := repo.Page(ctx, csql.PageParams{Limit: 2}, "")
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 2)
+	assert.True(t, page.HasMore)
+
+	page, err = repo.PageAfter(ctx, csql.KeysetParams{Limit: 2}, "id != ?", 2)
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 2)
+	assert.False(t, page.HasMore)
+
+	page, err = repo.PageAfter(ctx, csql.KeysetParams{Limit: 1, After: page.NextAfter, Desc: true}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, page.Items[0].ID)
+	assert.True(t, page.HasMore)
+}
+
+func TestRepo_Upsert(t *testing.T) {
+	t.Parallel()
+
+	ctx, repo := newTestRepo(t)
+
+	assert.NoError(t, repo.Insert(ctx, testUser{ID: 1, Email: "a@example.com"}))
+	assert.NoError(t, repo.Upsert(ctx, []testUser{
+		{ID: 1, Email: "b@example.com"},
+		{ID: 2, Email: "c@example.com"},
+	}))
+
+	users, err := repo.List(ctx, "")
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, "b@example.com", users[0].Email)
+}
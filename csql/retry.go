@@ -0,0 +1,214 @@
+package csql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand"
+	"syscall"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+const dbCtxKey = ctxKey("csql/*sqlx.DB")
+
+// Error codes of the errors that are retried by WithRetry
+const (
+	pqSerializationFailure = "40001"
+	pqDeadlockDetected     = "40P01"
+
+	mysqlLockWaitTimeout = 1205
+	mysqlDeadlock        = 1213
+)
+
+// RetryOptions configures WithRetryOptions
+type RetryOptions struct {
+	// MaxAttempts is the maximum number of times the transaction is run, including the first attempt
+	MaxAttempts int
+
+	// InitialBackoff is the time waited before the first retry. It doubles after each retry up to MaxBackoff and a
+	// random jitter of up to 50% is added so that conflicting transactions do not retry in lockstep.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryOptions are the options used by WithRetry
+func DefaultRetryOptions() RetryOptions {
+	return RetryOptions{
+		MaxAttempts:    5,
+		InitialBackoff: 50 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+	}
+}
+
+// CtxWithDB creates a context that holds the database used by WithRetry. Contexts created using CtxWithTx and
+// requests handled by TxMiddleware already hold it.
+func CtxWithDB(parentCtx context.Context, db *sql.DB, dialect string) context.Context {
+	return context.WithValue(parentCtx, dbCtxKey, sqlx.NewDb(db, dialect))
+}
+
+// CommitError is returned by WithRetry when a transaction fails to commit with an error that does not tell whether
+// the commit was applied (ex. the connection was lost). It is never retried since running the transaction again could
+// apply its writes twice. Commits rejected by the database because of a conflict (ex. a Postgres serialization_failure
+// under SERIALIZABLE) are not applied, so they are retried like any other conflict.
+type CommitError struct {
+	Err error
+}
+
+// Error implements error
+func (e *CommitError) Error() string {
+	return "failed to commit db transaction: " + e.Err.Error()
+}
+
+// Unwrap returns the error returned by the commit
+func (e *CommitError) Unwrap() error {
+	return e.Err
+}
+
+// WithRetry runs fn in a new database transaction and commits it. If fn fails with a retryable error (see
+// IsRetryableErr), the transaction is rolled back and fn is run again in a new transaction with backoff, up to
+// DefaultRetryOptions().MaxAttempts times. Commits that may have been applied are not retried (see CommitError).
+// Each retry is logged using the context's logger.
+// The transaction is independent of any transaction already in the context (ex. the one started by TxMiddleware),
+// so fn must not depend on uncommitted changes made outside of it. Since fn may run multiple times, it should not
+// have side effects outside of the database.
+func WithRetry(ctx context.Context, fn func(ctx context.Context) error) error {
+	return WithRetryOptions(ctx, DefaultRetryOptions(), fn)
+}
+
+// WithRetryOptions is WithRetry with custom retry options
+func WithRetryOptions(ctx context.Context, opts RetryOptions, fn func(ctx context.Context) error) error {
+	db, err := dbFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+
+	backoff := opts.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err = runTx(ctx, db, fn)
+		if err == nil || attempt >= opts.MaxAttempts || !IsRetryableErr(err) {
+			break
+		}
+
+		wait := backoff
+		if wait > 0 {
+			wait += time.Duration(rand.Int63n(int64(wait)/2 + 1)) //nolint:gosec
+		}
+
+		clogger.FromCtx(ctx).WithTags(map[string]interface{}{
+			"attempt":       attempt,
+			"maxAttempts":   opts.MaxAttempts,
+			"backoffMillis": wait.Milliseconds(),
+		}).Warn("Retrying database transaction", err)
+
+		select {
+		case <-ctx.Done():
+			return cerrors.New(ctx.Err(), "context finished before database transaction could be retried", nil)
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+		if opts.MaxBackoff > 0 && backoff > opts.MaxBackoff {
+			backoff = opts.MaxBackoff
+		}
+	}
+
+	if err != nil {
+		return cerrors.New(err, "failed to run database transaction", map[string]interface{}{
+			"maxAttempts": opts.MaxAttempts,
+		})
+	}
+
+	return nil
+}
+
+// IsRetryableErr returns true if err is a transient database error after which the transaction can be run again:
+//   - Postgres serialization_failure and deadlock_detected
+//   - MySQL deadlocks and lock wait timeouts
+//   - SQLite database is busy or locked
+//   - Connection resets and bad connections
+//
+// Commit failures that may have been applied (see CommitError) are not retryable.
+func IsRetryableErr(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var commitErr *CommitError
+	if errors.As(err, &commitErr) {
+		return false
+	}
+
+	return isConflictErr(err) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, mysql.ErrInvalidConn) || isConnErr(err)
+}
+
+// isConflictErr returns true if err was returned by the database because the transaction conflicted with another
+// one. The database rolls back the transaction in this case, even if err is returned by its commit.
+func isConflictErr(err error) bool {
+	var (
+		pqErr     *pq.Error
+		mysqlErr  *mysql.MySQLError
+		sqliteErr sqlite3.Error
+	)
+
+	switch {
+	case errors.As(err, &pqErr):
+		return pqErr.Code == pqSerializationFailure || pqErr.Code == pqDeadlockDetected
+	case errors.As(err, &mysqlErr):
+		return mysqlErr.Number == mysqlDeadlock || mysqlErr.Number == mysqlLockWaitTimeout
+	case errors.As(err, &sqliteErr):
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+
+	return false
+}
+
+func runTx(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return cerrors.New(err, beginTxError, map[string]interface{}{
+			"dialect": db.DriverName(),
+		})
+	}
+
+	err = fn(context.WithValue(ctx, connCtxKey, tx))
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil && isConflictErr(err) {
+		return cerrors.New(err, "failed to commit db transaction", nil)
+	}
+
+	if err != nil {
+		return &CommitError{Err: err}
+	}
+
+	return nil
+}
+
+func dbFromCtx(ctx context.Context) (*sqlx.DB, error) {
+	if db, ok := ctx.Value(dbCtxKey).(*sqlx.DB); ok {
+		return db, nil
+	}
+
+	if rtx, ok := ctx.Value(reqTxCtxKey).(*requestTx); ok {
+		return rtx.db, nil
+	}
+
+	return nil, cerrors.New(nil, "no database in the context; use csql.CtxWithDB", nil)
+}
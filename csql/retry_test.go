@@ -0,0 +1,166 @@
+package csql_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/csql"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRetry(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db      = openTestDB(t, "alice")
+		querier = csql.NewQuerier(db, csql.Config{Dialect: "sqlite3"})
+		ctx     = clogger.CtxWithLogger(csql.CtxWithDB(context.Background(), db, "sqlite3"), clogger.NewNoop())
+		opts    = csql.RetryOptions{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+		attempts int
+		count    int
+	)
+
+	err := csql.WithRetryOptions(ctx, opts, func(ctx context.Context) error {
+		attempts++
+
+		_, err := querier.Exec(ctx, "insert into people (name) values (?)", "bob")
+		if err != nil {
+			return err
+		}
+
+		if attempts < 2 {
+			return cerrors.New(&pq.Error{Code: "40001"}, "conflict", nil)
+		}
+
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	// the insert of the first attempt is rolled back
+	assert.NoError(t, db.QueryRow("select count(*) from people where name='bob'").Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestWithRetry_MaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db       = openTestDB(t, "alice")
+		ctx      = clogger.CtxWithLogger(csql.CtxWithDB(context.Background(), db, "sqlite3"), clogger.NewNoop())
+		opts     = csql.RetryOptions{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+		deadlock = &mysql.MySQLError{Number: 1213}
+		attempts int
+	)
+
+	err := csql.WithRetryOptions(ctx, opts, func(ctx context.Context) error {
+		attempts++
+		return deadlock
+	})
+	assert.True(t, errors.Is(err, deadlock))
+	assert.Equal(t, 3, attempts)
+}
+
+func TestWithRetry_CommitErr(t *testing.T) {
+	t.Parallel()
+
+	opts := csql.RetryOptions{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	run := func(commitErr error) (int, error) {
+		var (
+			conn     = &commitErrConn{err: commitErr}
+			db       = sql.OpenDB(conn)
+			ctx      = clogger.CtxWithLogger(csql.CtxWithDB(context.Background(), db, "postgres"), clogger.NewNoop())
+			attempts int
+		)
+
+		err := csql.WithRetryOptions(ctx, opts, func(ctx context.Context) error {
+			attempts++
+			return nil
+		})
+
+		return attempts, err
+	}
+
+	// postgres reports serialization failures under SERIALIZABLE at commit
+	attempts, err := run(&pq.Error{Code: "40001"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	// the commit may have been applied before the connection was lost
+	attempts, err = run(syscall.ECONNRESET)
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestWithRetry_NotRetryable(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db       = openTestDB(t, "alice")
+		attempts int
+	)
+
+	err := csql.WithRetry(csql.CtxWithDB(context.Background(), db, "sqlite3"), func(ctx context.Context) error {
+		attempts++
+		return errors.New("test-err")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestWithRetry_NoDB(t *testing.T) {
+	t.Parallel()
+
+	err := csql.WithRetry(context.Background(), func(ctx context.Context) error {
+		return nil
+	})
+	assert.Error(t, err)
+}
+
+func TestIsRetryableErr(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, csql.IsRetryableErr(&pq.Error{Code: "40P01"}))
+	assert.True(t, csql.IsRetryableErr(&mysql.MySQLError{Number: 1205}))
+	assert.False(t, csql.IsRetryableErr(&pq.Error{Code: "23505"}))
+	assert.False(t, csql.IsRetryableErr(errors.New("test-err")))
+	assert.False(t, csql.IsRetryableErr(nil))
+
+	// the commit may have been applied before the connection was lost
+	assert.True(t, csql.IsRetryableErr(syscall.ECONNRESET))
+	assert.False(t, csql.IsRetryableErr(&csql.CommitError{Err: syscall.ECONNRESET}))
+	assert.False(t, csql.IsRetryableErr(cerrors.New(&csql.CommitError{Err: mysql.ErrInvalidConn}, "test", nil)))
+}
+
+// commitErrConn is a driver.Connector for a database whose first commit fails with err
+type commitErrConn struct {
+	err     error
+	commits int
+}
+
+func (c *commitErrConn) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c *commitErrConn) Driver() driver.Driver                        { return nil }
+func (c *commitErrConn) Prepare(string) (driver.Stmt, error)          { return nil, errors.New("not supported") }
+func (c *commitErrConn) Close() error                                 { return nil }
+func (c *commitErrConn) Begin() (driver.Tx, error)                    { return c, nil } //nolint:staticcheck
+func (c *commitErrConn) Rollback() error                              { return nil }
+
+func (c *commitErrConn) Commit() error {
+	c.commits++
+	if c.commits == 1 {
+		return c.err
+	}
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package csql
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+)
+
+// registeredSeeds holds the module seeds registered using RegisterSeeds
+var registeredSeeds = struct { //nolint:gochecknoglobals
+	sync.Mutex
+	modules []ModuleSeeds
+}{}
+
+// SeedFunc inserts fixture data. The context has a database transaction so that seeds can use Querier. Seeds must
+// be idempotent (ex. insert an admin user only if it does not exist) since they run every time the seeder runs.
+type SeedFunc func(ctx context.Context) error
+
+// Seed is a named SeedFunc
+type Seed struct {
+	Name string
+	Run  SeedFunc
+}
+
+// ModuleSeeds are the seeds shipped by a module. Seeds run in the order they are listed.
+type ModuleSeeds struct {
+	Module string
+	Seeds  []Seed
+}
+
+// RegisterSeeds registers the seeds of a module so that they are run by Seeder. Modules usually register their
+// seeds in an init func. Registering a module again replaces its seeds.
+func RegisterSeeds(ms ModuleSeeds) {
+	registeredSeeds.Lock()
+	defer registeredSeeds.Unlock()
+
+	for i := range registeredSeeds.modules {
+		if registeredSeeds.modules[i].Module == ms.Module {
+			registeredSeeds.modules[i] = ms
+			return
+		}
+	}
+
+	registeredSeeds.modules = append(registeredSeeds.modules, ms)
+}
+
+// RegisteredSeeds returns the module seeds registered using RegisterSeeds sorted by module
+func RegisteredSeeds() []ModuleSeeds {
+	registeredSeeds.Lock()
+	defer registeredSeeds.Unlock()
+
+	modules := make([]ModuleSeeds, len(registeredSeeds.modules))
+	copy(modules, registeredSeeds.modules)
+
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Module < modules[j].Module })
+
+	return modules
+}
+
+// NewSeederParams holds the params needed for NewSeeder
+type NewSeederParams struct {
+	DB     *sql.DB
+	Config Config
+	Logger clogger.Logger
+}
+
+// NewSeeder creates a new Seeder
+func NewSeeder(p NewSeederParams) *Seeder {
+	return &Seeder{
+		db:     p.DB,
+		config: p.Config,
+		logger: p.Logger.With(clogger.Module("csql")),
+		env:    cconfig.AppEnv(),
+	}
+}
+
+// Seeder runs the seeds registered using RegisterSeeds. Seeds only run in the environments (see APP_ENV) listed in
+// csql.seeds.environments so that fixtures are never inserted in production by accident.
+type Seeder struct {
+	db     *sql.DB
+	config Config
+	logger clogger.Logger
+	env    string
+}
+
+// Run runs all seeds if csql.seeds.run_on_boot is enabled (ex. using -set "csql.seeds.run_on_boot=true"). It can be
+// passed to the app's Run or Start funcs after the Migrator.
+func (s *Seeder) Run() error {
+	if !s.config.Seeds.RunOnBoot {
+		return nil
+	}
+
+	n, err := s.Seed(context.Background())
+	if err != nil {
+		return err
+	}
+
+	s.logger.WithTags(map[string]interface{}{
+		"count": n,
+	}).Info("Successfully ran seeds")
+
+	return nil
+}
+
+// Stage implements clifecycle.Staged. Seeds run once the migrations have created their tables.
+func (s *Seeder) Stage() clifecycle.Stage {
+	return clifecycle.Stage{
+		Name:  clifecycle.StageSeeds,
+		After: []string{clifecycle.StageMigrations},
+	}
+}
+
+// Seed runs the seeds of the given modules, or all modules if none are given, and returns the number of seeds that
+// ran. Each seed runs in its own transaction. It returns an error without running any seed if the current environment
+// is not allowed to be seeded.
+func (s *Seeder) Seed(ctx context.Context, modules ...string) (int, error) {
+	if !s.envAllowed() {
+		return 0, cerrors.New(nil, "seeds are not allowed in this environment; add it to csql.seeds.environments "+
+			"to allow them", map[string]interface{}{
+			"env": s.env,
+		})
+	}
+
+	only := make(map[string]bool, len(modules))
+	for _, m := range modules {
+		only[m] = true
+	}
+
+	n := 0
+
+	for _, ms := range RegisteredSeeds() {
+		if len(only) > 0 && !only[ms.Module] {
+			continue
+		}
+
+		for _, seed := range ms.Seeds {
+			s.logger.WithTags(map[string]interface{}{
+				"module": ms.Module,
+				"seed":   seed.Name,
+			}).Info("Running seed..")
+
+			err := s.run(ctx, seed)
+			if err != nil {
+				return n, cerrors.New(err, "failed to run seed", map[string]interface{}{
+					"module": ms.Module,
+					"seed":   seed.Name,
+				})
+			}
+
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// RunCommand runs a seed subcommand and writes its output to w. Apps usually run it from a CLI command
+// (ex. `./app seed -module cauth`). The supported flags are:
+//
+//	-module   run the seeds of the given module only; can be repeated
+func (s *Seeder) RunCommand(ctx context.Context, args []string, w io.Writer) error {
+	var modules moduleFlags
+
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	flags.Var(&modules, "module", "Module whose seeds are run")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return cerrors.New(err, "invalid seed flags", nil)
+	}
+
+	n, err := s.Seed(ctx, modules...)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Ran %d seeds\n", n)
+
+	return err
+}
+
+func (s *Seeder) run(ctx context.Context, seed Seed) error {
+	ctx, tx, err := CtxWithTx(ctx, s.db, s.config.Dialect)
+	if err != nil {
+		return err
+	}
+
+	err = seed.Run(ctx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return cerrors.New(err, "failed to commit seed tx", nil)
+	}
+
+	return nil
+}
+
+func (s *Seeder) envAllowed() bool {
+	for _, env := range s.config.Seeds.Environments {
+		if env == s.env {
+			return true
+		}
+	}
+
+	return false
+}
+
+type moduleFlags []string
+
+func (f *moduleFlags) String() string {
+	return fmt.Sprint([]string(*f))
+}
+
+func (f *moduleFlags) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
@@ -0,0 +1,64 @@
+package csql_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/csql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeeder_Seed(t *testing.T) {
+	var (
+		db      = openTestDB(t, "test")
+		config  = csql.Config{Dialect: csql.DialectSQLite, Seeds: csql.ConfigSeeds{Environments: []string{""}}}
+		querier = csql.NewQuerier(db, config)
+		seeder  = csql.NewSeeder(csql.NewSeederParams{DB: db, Config: config, Logger: clogger.NewNoop()})
+		out     bytes.Buffer
+		count   int
+	)
+
+	csql.RegisterSeeds(csql.ModuleSeeds{
+		Module: "seeds_test",
+		Seeds: []csql.Seed{{
+			Name: "admin",
+			Run: func(ctx context.Context) error {
+				_, err := querier.Exec(ctx, "insert into people (name) select 'admin' "+
+					"where not exists (select 1 from people where name = 'admin')")
+				return err
+			},
+		}},
+	})
+
+	defer csql.RegisterSeeds(csql.ModuleSeeds{Module: "seeds_test"})
+
+	assert.NoError(t, seeder.RunCommand(context.Background(), []string{"-module", "seeds_test"}, &out))
+	assert.Equal(t, "Ran 1 seeds\n", out.String())
+
+	// Seeds are idempotent
+	n, err := seeder.Seed(context.Background(), "seeds_test")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	assert.NoError(t, db.QueryRow("select count(*) from people where name = 'admin'").Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestSeeder_Seed_EnvNotAllowed(t *testing.T) {
+	t.Parallel()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+
+	seeder := csql.NewSeeder(csql.NewSeederParams{
+		DB:     db,
+		Config: csql.Config{Dialect: csql.DialectSQLite, Seeds: csql.ConfigSeeds{Environments: []string{"not-the-env"}}},
+		Logger: clogger.NewNoop(),
+	})
+
+	_, err = seeder.Seed(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,178 @@
+package csql
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/jmoiron/sqlx"
+)
+
+// stmtCache holds prepared statements keyed by their query so that queries that run often are only prepared once per
+// connection. The least recently used statement is closed when the cache is full.
+type stmtCache struct {
+	db   *sqlx.DB
+	size int
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type stmtCacheEntry struct {
+	query   string
+	stmt    *sqlx.Stmt
+	refs    int
+	evicted bool
+}
+
+func newStmtCache(db *sqlx.DB, size int) *stmtCache {
+	return &stmtCache{
+		db:      db,
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached statement for query without preparing it. The returned func must be called once the
+// statement is no longer used.
+func (c *stmtCache) get(query string) (*sqlx.Stmt, func(), bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[query]
+	if !ok {
+		return nil, nil, false
+	}
+
+	c.lru.MoveToFront(el)
+
+	entry := el.Value.(*stmtCacheEntry) //nolint:forcetypeassert
+	entry.refs++
+
+	return entry.stmt, func() { c.release(entry) }, true
+}
+
+// acquire returns the prepared statement for query, preparing it if it is not cached. The returned func must be
+// called once the statement is no longer used so that evicted statements can be closed.
+func (c *stmtCache) acquire(ctx context.Context, query string) (*sqlx.Stmt, func(), error) {
+	if stmt, release, ok := c.get(query); ok {
+		return stmt, release, nil
+	}
+
+	stmt, err := c.db.PreparexContext(ctx, query)
+	if err != nil {
+		return nil, nil, cerrors.New(err, "failed to prepare statement", nil)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// another goroutine may have prepared the same query in the meantime
+	if el, ok := c.entries[query]; ok {
+		_ = stmt.Close()
+
+		c.lru.MoveToFront(el)
+
+		entry := el.Value.(*stmtCacheEntry) //nolint:forcetypeassert
+		entry.refs++
+
+		return entry.stmt, func() { c.release(entry) }, nil
+	}
+
+	entry := &stmtCacheEntry{query: query, stmt: stmt, refs: 1}
+	c.entries[query] = c.lru.PushFront(entry)
+
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.evict(oldest.Value.(*stmtCacheEntry)) //nolint:forcetypeassert
+		c.lru.Remove(oldest)
+	}
+
+	return stmt, func() { c.release(entry) }, nil
+}
+
+func (c *stmtCache) release(entry *stmtCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.refs--
+
+	if entry.evicted && entry.refs == 0 {
+		_ = entry.stmt.Close()
+	}
+}
+
+// evict removes entry from the cache and closes its statement once it is no longer in use. It must be called with
+// the lock held.
+func (c *stmtCache) evict(entry *stmtCacheEntry) {
+	delete(c.entries, entry.query)
+
+	entry.evicted = true
+
+	if entry.refs == 0 {
+		_ = entry.stmt.Close()
+	}
+}
+
+// len returns the number of cached statements
+func (c *stmtCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// stmtConn is a conn that runs queries using the statements in a stmtCache. If a statement cannot be prepared, the
+// query runs unprepared. It is not used for transactions since a statement prepared on the pool has to be prepared
+// again on the transaction's connection for every query, which costs more round trips than it saves.
+type stmtConn struct {
+	conn  conn
+	cache *stmtCache
+}
+
+func (s *stmtConn) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	stmt, done, ok := s.stmt(ctx, query)
+	if !ok {
+		return s.conn.GetContext(ctx, dest, query, args...)
+	}
+	defer done()
+
+	return stmt.GetContext(ctx, dest, args...)
+}
+
+func (s *stmtConn) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	stmt, done, ok := s.stmt(ctx, query)
+	if !ok {
+		return s.conn.SelectContext(ctx, dest, query, args...)
+	}
+	defer done()
+
+	return stmt.SelectContext(ctx, dest, args...)
+}
+
+func (s *stmtConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, done, ok := s.stmt(ctx, query)
+	if !ok {
+		return s.conn.ExecContext(ctx, query, args...)
+	}
+	defer done()
+
+	return stmt.ExecContext(ctx, args...)
+}
+
+func (s *stmtConn) Rebind(query string) string {
+	return s.conn.Rebind(query)
+}
+
+func (s *stmtConn) stmt(ctx context.Context, query string) (*sqlx.Stmt, func(), bool) {
+	stmt, release, err := s.cache.acquire(ctx, query)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	return stmt, release, true
+}
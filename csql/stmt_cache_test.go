@@ -0,0 +1,84 @@
+package csql_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/csql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuerier_StatementCache(t *testing.T) {
+	t.Parallel()
+
+	db, err := sql.Open("sqlite3", "file:stmt_cache_test?mode=memory&cache=shared")
+	assert.NoError(t, err)
+
+	defer func() { _ = db.Close() }()
+
+	_, err = db.Exec("create table people (name text)")
+	assert.NoError(t, err)
+
+	var (
+		logger  = clogger.NewNoop()
+		config  = csql.Config{Dialect: "sqlite3", StatementCacheSize: 2}
+		querier = csql.NewQuerier(db, config)
+		count   int
+	)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+	assert.NoError(t, err)
+
+	// queries run outside of a transaction use the cache
+	csql.NewTxMiddleware(db, config, logger).Handle(csql.NewNoTxMiddleware(logger).Handle(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// more distinct queries than the cache holds so that statements are evicted
+			for i := 0; i < 5; i++ {
+				_, err := querier.Exec(r.Context(), fmt.Sprintf("insert into people (name) values (? || '%d')", i), "person")
+				assert.NoError(t, err)
+
+				_, err = querier.Exec(r.Context(), "insert into people (name) values (?)", "repeated")
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, querier.Get(r.Context(), &count, "select count(*) from people"))
+		}),
+	)).ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, 10, count)
+}
+
+func TestQuerier_StatementCache_Tx(t *testing.T) {
+	t.Parallel()
+
+	db, err := sql.Open("sqlite3", "file:stmt_cache_tx_test?mode=memory&cache=shared")
+	assert.NoError(t, err)
+
+	defer func() { _ = db.Close() }()
+
+	db.SetMaxOpenConns(2)
+
+	_, err = db.Exec("create table people (name text); insert into people (name) values ('alice')")
+	assert.NoError(t, err)
+
+	var (
+		querier = csql.NewQuerier(db, csql.Config{Dialect: "sqlite3", StatementCacheSize: 10})
+		name    string
+	)
+
+	ctx, tx, err := csql.CtxWithTx(context.Background(), db, "sqlite3")
+	assert.NoError(t, err)
+
+	defer func() { _ = tx.Rollback() }()
+
+	assert.NoError(t, querier.Get(ctx, &name, "select name from people"))
+	assert.Equal(t, "alice", name)
+
+	// queries in a transaction are not prepared on another connection of the pool
+	assert.Equal(t, 1, db.Stats().OpenConnections)
+}
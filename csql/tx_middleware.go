@@ -2,6 +2,7 @@ package csql
 
 import (
 	"bufio"
+	"context"
 	"database/sql"
 	"errors"
 	"net"
@@ -9,57 +10,59 @@ import (
 
 	"github.com/gocopper/copper/cerrors"
 	"github.com/gocopper/copper/clogger"
+	"github.com/jmoiron/sqlx"
 )
 
 // NewTxMiddleware creates a new TxMiddleware
 func NewTxMiddleware(db *sql.DB, config Config, logger clogger.Logger) *TxMiddleware {
 	return &TxMiddleware{
-		db:     db,
-		config: config,
-		logger: logger,
+		db:     sqlx.NewDb(db, config.Dialect),
+		logger: logger.With(clogger.Module("csql")),
 	}
 }
 
-// TxMiddleware is a chttp.Middleware that wraps an HTTP request in a database transaction. If the request succeeds
-// (i.e. 2xx or 3xx response code), the transaction is committed. Else, the transaction is rolled back.
+// TxMiddleware is a chttp.Middleware that wraps an HTTP request in a database transaction. The transaction is started
+// when the request runs its first query, so that requests that don't use the database do not hold a transaction
+// open. If the request succeeds (i.e. 2xx or 3xx response code), the transaction is committed. Else, or if the
+// handler panics, the transaction is rolled back.
+// Routes that are long-running or read-only can opt out of the transaction using NoTxMiddleware.
 type TxMiddleware struct {
-	db     *sql.DB
-	config Config
+	db     *sqlx.DB
 	logger clogger.Logger
 }
 
 // Handle implements the chttp.Middleware interface. See TxMiddleware
 func (m *TxMiddleware) Handle(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx, tx, err := CtxWithTx(r.Context(), m.db, m.config.Dialect)
-		if err != nil {
-			m.logger.Error("Failed to create context with database transaction", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+		var (
+			rtx = &requestTx{ctx: r.Context(), db: m.db}
+			ctx = context.WithValue(r.Context(), reqTxCtxKey, rtx)
+		)
 
 		defer func() {
 			// Try a rollback in a deferred function to account for panics
-			err := tx.Rollback()
+			open := rtx.isOpen()
+
+			err := rtx.finish(false)
 			if err != nil && !errors.Is(err, sql.ErrTxDone) {
 				m.logger.Error("Failed to rollback database transaction", err)
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
 
-			if err == nil {
+			if err == nil && open {
 				m.logger.Warn("Rolled back an unexpectedly open database transaction", nil)
 			}
 		}()
 
 		next.ServeHTTP(&txnrw{
 			internal: w,
-			tx:       tx,
+			tx:       rtx,
 			logger:   m.logger,
 		}, r.WithContext(ctx))
 
 		// note: this commit will only succeed if neither Write nor WriteHeader was called on the ResponseWriter
-		err = tx.Commit()
+		err := rtx.finish(true)
 		if err != nil && !errors.Is(err, sql.ErrTxDone) {
 			m.logger.Error("Failed to commit database transaction", err)
 			return
@@ -67,9 +70,41 @@ func (m *TxMiddleware) Handle(next http.Handler) http.Handler {
 	})
 }
 
+// NewNoTxMiddleware creates a new NoTxMiddleware
+func NewNoTxMiddleware(logger clogger.Logger) *NoTxMiddleware {
+	return &NoTxMiddleware{
+		logger: logger.With(clogger.Module("csql")),
+	}
+}
+
+// NoTxMiddleware is a chttp.Middleware that opts a route out of the transaction started by TxMiddleware. Queries
+// run using Querier are run directly on the database instead, and are committed immediately. It should be used as a
+// route middleware for long-running or read-only handlers that should not hold a transaction open. For example:
+//
+//	chttp.Route{
+//		Path:        "/exports",
+//		Middlewares: []chttp.Middleware{noTxMiddleware},
+//		Handler:     ro.HandleExport,
+//	}
+type NoTxMiddleware struct {
+	logger clogger.Logger
+}
+
+// Handle implements the chttp.Middleware interface. See NoTxMiddleware
+func (m *NoTxMiddleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rtx, ok := r.Context().Value(reqTxCtxKey).(*requestTx)
+		if ok && !rtx.skip() {
+			m.logger.Warn("Database transaction was started before NoTxMiddleware; it will be used for the request", nil)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 type txnrw struct {
 	internal http.ResponseWriter
-	tx       *sql.Tx
+	tx       *requestTx
 	logger   clogger.Logger
 }
 
@@ -78,7 +113,7 @@ func (w *txnrw) Header() http.Header {
 }
 
 func (w *txnrw) Write(b []byte) (int, error) {
-	err := w.tx.Commit()
+	err := w.tx.finish(true)
 	if err != nil && !errors.Is(err, sql.ErrTxDone) {
 		return 0, cerrors.New(err, "failed to commit database transaction", nil)
 	}
@@ -90,7 +125,7 @@ func (w *txnrw) WriteHeader(statusCode int) {
 	const MinErrStatusCode = 400
 
 	if statusCode >= MinErrStatusCode {
-		err := w.tx.Rollback()
+		err := w.tx.finish(false)
 		if err != nil && !errors.Is(err, sql.ErrTxDone) {
 			w.logger.WithTags(map[string]interface{}{
 				"originalStatusCode": statusCode,
@@ -103,8 +138,8 @@ func (w *txnrw) WriteHeader(statusCode int) {
 		return
 	}
 
-	err := w.tx.Commit()
-	if err != nil {
+	err := w.tx.finish(true)
+	if err != nil && !errors.Is(err, sql.ErrTxDone) {
 		w.logger.Error("Failed to commit database transaction", err)
 		w.internal.WriteHeader(http.StatusInternalServerError)
 		return
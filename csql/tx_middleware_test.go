@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gocopper/copper/clogger"
 	"github.com/gocopper/copper/csql"
@@ -75,3 +76,116 @@ func TestTxMiddleware_Handle_Rollback(t *testing.T) {
 
 	assert.False(t, rows.Next())
 }
+
+func TestTxMiddleware_Handle_QueryCtx(t *testing.T) {
+	t.Parallel()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("create table people (name text)")
+	assert.NoError(t, err)
+
+	var (
+		logger  = clogger.NewNoop()
+		config  = csql.Config{Dialect: "sqlite3"}
+		querier = csql.NewQuerier(db, config)
+		mw      = csql.NewTxMiddleware(db, config, logger)
+	)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+	assert.NoError(t, err)
+
+	mw.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first query has its own timeout, which must not end the request's transaction
+		ctx, cancel := context.WithTimeout(r.Context(), time.Minute)
+
+		_, err := querier.Exec(ctx, "insert into people (name) values ('first')")
+		assert.NoError(t, err)
+
+		cancel()
+
+		_, err = querier.Exec(r.Context(), "insert into people (name) values ('second')")
+		assert.NoError(t, err)
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	var count int
+
+	assert.NoError(t, db.QueryRow("select count(*) from people").Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+func TestTxMiddleware_Handle_Panic(t *testing.T) {
+	t.Parallel()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("create table people (name text)")
+	assert.NoError(t, err)
+
+	var (
+		logger  = clogger.NewNoop()
+		config  = csql.Config{Dialect: "sqlite3"}
+		querier = csql.NewQuerier(db, config)
+		mw      = csql.NewTxMiddleware(db, config, logger)
+		count   int
+	)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+	assert.NoError(t, err)
+
+	assert.Panics(t, func() {
+		mw.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := querier.Exec(r.Context(), "insert into people (name) values ('test')")
+			assert.NoError(t, err)
+
+			panic("test")
+		})).ServeHTTP(httptest.NewRecorder(), req)
+	})
+
+	assert.NoError(t, db.QueryRow("select count(*) from people").Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func TestNoTxMiddleware_Handle(t *testing.T) {
+	t.Parallel()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("create table people (name text)")
+	assert.NoError(t, err)
+
+	var (
+		logger  = clogger.NewNoop()
+		config  = csql.Config{Dialect: "sqlite3"}
+		querier = csql.NewQuerier(db, config)
+		mw      = csql.NewTxMiddleware(db, config, logger)
+		noTxMw  = csql.NewNoTxMiddleware(logger)
+		count   int
+	)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+	assert.NoError(t, err)
+
+	mw.Handle(noTxMw.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := querier.Exec(r.Context(), "insert into people (name) values (?)", "test")
+		assert.NoError(t, err)
+
+		_, err = csql.TxFromCtx(r.Context())
+		assert.Error(t, err)
+
+		// The insert is not rolled back since it was not run in a transaction
+		w.WriteHeader(http.StatusInternalServerError)
+	}))).ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.NoError(t, db.QueryRow("select count(*) from people").Scan(&count))
+	assert.Equal(t, 1, count)
+}
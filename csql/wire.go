@@ -5,10 +5,19 @@ import "github.com/google/wire"
 // WireModule can be used as part of google/wire setup.
 var WireModule = wire.NewSet(
 	NewDBConnection,
-	NewQuerier,
+	NewQuerierWithParams,
+	NewReplicas,
+	NewReplicaMiddleware,
 	NewMigrator,
+	NewSeeder,
+	NewListener,
 	LoadConfig,
 	NewTxMiddleware,
+	NewNoTxMiddleware,
 
 	wire.Struct(new(NewMigratorParams), "*"),
+	wire.Struct(new(NewSeederParams), "*"),
+	wire.Struct(new(NewReplicasParams), "*"),
+	wire.Struct(new(NewQuerierParams), "*"),
+	wire.Struct(new(NewListenerParams), "*"),
 )
@@ -0,0 +1,393 @@
+package cstorage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"image"
+	_ "image/gif"  // Registers the GIF decoder used to validate avatars
+	_ "image/jpeg" // Registers the JPEG decoder used to validate avatars
+	_ "image/png"  // Registers the PNG decoder used to validate avatars
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+)
+
+// avatarTypes are the content types accepted for avatars and the extensions of their keys
+var avatarTypes = map[string]string{ //nolint:gochecknoglobals
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// errInvalidAvatar is returned (wrapped) when an uploaded file is not an acceptable avatar
+var errInvalidAvatar = errors.New("invalid avatar")
+
+// AvatarProfiles connects AvatarRouter to the app's authentication and user profiles
+type AvatarProfiles interface {
+	// CurrentUserID returns the id of the request's authenticated user, or false if the request is not authenticated
+	CurrentUserID(r *http.Request) (string, bool)
+
+	// SetAvatarURL saves the URL of the user's new avatar on their profile
+	SetAvatarURL(ctx context.Context, userID, url string) error
+}
+
+// AvatarProcessor transforms an avatar before it is stored (ex. to crop and resize it). It returns the new content
+// and its content type.
+type AvatarProcessor func(ctx context.Context, data []byte, contentType string) ([]byte, string, error)
+
+// NewAvatarRouterParams holds the params needed for NewAvatarRouter
+type NewAvatarRouterParams struct {
+	Storage  Storage
+	Profiles AvatarProfiles
+	RW       *chttp.ReaderWriter
+	Config   Config
+	Logger   clogger.Logger
+}
+
+// NewAvatarRouter creates a new AvatarRouter
+func NewAvatarRouter(p NewAvatarRouterParams) *AvatarRouter {
+	return &AvatarRouter{
+		storage:  p.Storage,
+		profiles: p.Profiles,
+		rw:       p.RW,
+		config:   p.Config.Avatar,
+		logger:   p.Logger.With(clogger.Module("cstorage")),
+	}
+}
+
+// AvatarRouter is a chttp.Router with endpoints that let the authenticated user upload their avatar:
+//
+//	POST /api/user/avatar            multipart upload with the image in the avatar field
+//	POST /api/user/avatar/presign    {"content_type": "image/png"} returns a URL the image can be PUT to directly
+//	POST /api/user/avatar/complete   {"key": "..."} validates a presigned upload and sets it as the avatar
+//
+// Avatars must be PNG, JPEG, GIF, or WebP images within the configured size and dimensions. The avatar's URL is
+// saved on the user's profile using AvatarProfiles. The router is not part of WireModule since the app provides
+// AvatarProfiles (ex. using its auth and user packages).
+type AvatarRouter struct {
+	storage    Storage
+	profiles   AvatarProfiles
+	rw         *chttp.ReaderWriter
+	config     ConfigAvatar
+	logger     clogger.Logger
+	processors []AvatarProcessor
+}
+
+// Process adds a processor that runs on each avatar after it is validated. It must be called before the routes are
+// served.
+func (ro *AvatarRouter) Process(fn AvatarProcessor) {
+	ro.processors = append(ro.processors, fn)
+}
+
+// Routes returns the avatar upload endpoints, and the endpoint that serves avatars if Config.Avatar.BaseURL is a path
+func (ro *AvatarRouter) Routes() []chttp.Route {
+	routes := []chttp.Route{
+		{
+			Path:    "/api/user/avatar",
+			Methods: []string{http.MethodPost},
+			Handler: ro.HandleUpload,
+		},
+		{
+			Path:    "/api/user/avatar/presign",
+			Methods: []string{http.MethodPost},
+			Handler: ro.HandlePresign,
+		},
+		{
+			Path:    "/api/user/avatar/complete",
+			Methods: []string{http.MethodPost},
+			Handler: ro.HandleComplete,
+		},
+	}
+
+	if strings.HasPrefix(ro.config.BaseURL, "/") {
+		routes = append(routes, chttp.Route{
+			Path:    strings.TrimSuffix(ro.config.BaseURL, "/") + "/{key:.+}",
+			Methods: []string{http.MethodGet, http.MethodHead},
+			Handler: ro.HandleServe,
+		})
+	}
+
+	return routes
+}
+
+// HandleUpload stores the image in the avatar field of a multipart form as the user's avatar
+func (ro *AvatarRouter) HandleUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ro.profiles.CurrentUserID(r)
+	if !ok {
+		ro.writeError(w, http.StatusUnauthorized, errors.New("not authenticated"))
+		return
+	}
+
+	// the form's other fields and boundaries are small, so they are given 64 KiB on top of the max avatar size
+	r.Body = http.MaxBytesReader(w, r.Body, ro.config.MaxSize+64<<10)
+
+	file, _, err := r.FormFile("avatar")
+	if err != nil {
+		ro.writeError(w, http.StatusBadRequest, cerrors.New(err, "avatar file is required", nil))
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := ro.read(file)
+	if err != nil {
+		ro.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	url, err := ro.save(r.Context(), userID, data)
+	ro.writeResult(w, r, url, err)
+}
+
+// HandlePresign returns a presigned URL that the client can upload the avatar to. The upload must be followed by a
+// request to HandleComplete.
+func (ro *AvatarRouter) HandlePresign(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ro.profiles.CurrentUserID(r)
+	if !ok {
+		ro.writeError(w, http.StatusUnauthorized, errors.New("not authenticated"))
+		return
+	}
+
+	var body struct {
+		ContentType string `json:"content_type" valid:"required"`
+	}
+
+	if !ro.rw.ReadJSON(w, r, &body) {
+		return
+	}
+
+	ext, ok := avatarTypes[body.ContentType]
+	if !ok {
+		ro.writeError(w, http.StatusBadRequest, errors.New("avatar must be a png, jpeg, gif, or webp image"))
+		return
+	}
+
+	key := ro.userPrefix(userID) + randomHex(16) + ext //nolint:gomnd
+
+	url, err := ro.storage.PresignPut(r.Context(), key, PresignOptions{ContentType: body.ContentType})
+	if err != nil {
+		ro.logger.Error("Failed to presign avatar upload", err)
+		ro.writeError(w, http.StatusInternalServerError, errors.New("failed to create upload url"))
+
+		return
+	}
+
+	ro.rw.WriteJSON(w, chttp.WriteJSONParams{
+		Data: map[string]string{
+			"key":          key,
+			"upload_url":   url,
+			"content_type": body.ContentType,
+		},
+	})
+}
+
+// HandleComplete validates an avatar that was uploaded using a presigned URL and sets it as the user's avatar. Invalid
+// uploads are deleted.
+func (ro *AvatarRouter) HandleComplete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ro.profiles.CurrentUserID(r)
+	if !ok {
+		ro.writeError(w, http.StatusUnauthorized, errors.New("not authenticated"))
+		return
+	}
+
+	var body struct {
+		Key string `json:"key" valid:"required"`
+	}
+
+	if !ro.rw.ReadJSON(w, r, &body) {
+		return
+	}
+
+	// users can only complete the uploads that were presigned for them
+	if !strings.HasPrefix(body.Key, ro.userPrefix(userID)) || validateKey(body.Key) != nil {
+		ro.writeError(w, http.StatusForbidden, errors.New("invalid avatar key"))
+		return
+	}
+
+	obj, err := ro.storage.Get(r.Context(), body.Key)
+	if errors.Is(err, ErrNotFound) {
+		ro.writeError(w, http.StatusNotFound, errors.New("avatar was not uploaded"))
+		return
+	} else if err != nil {
+		ro.writeResult(w, r, "", err)
+		return
+	}
+
+	data, err := ro.read(obj)
+	_ = obj.Close()
+
+	if err == nil {
+		err = ro.validate(data)
+	}
+
+	if err != nil {
+		_ = ro.storage.Delete(r.Context(), body.Key)
+		ro.writeError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	if len(ro.processors) > 0 {
+		// processed avatars are stored under a new key and the original upload is deleted
+		url, err := ro.save(r.Context(), userID, data)
+		if err == nil {
+			_ = ro.storage.Delete(r.Context(), body.Key)
+		}
+
+		ro.writeResult(w, r, url, err)
+
+		return
+	}
+
+	url := ro.url(body.Key)
+
+	err = ro.profiles.SetAvatarURL(r.Context(), userID, url)
+	ro.writeResult(w, r, url, err)
+}
+
+// HandleServe serves a stored avatar
+func (ro *AvatarRouter) HandleServe(w http.ResponseWriter, r *http.Request) {
+	key := chttp.URLParams(r)["key"]
+	if !strings.HasPrefix(key, ro.config.Prefix) {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+
+	err := ServeObject(w, r, ro.storage, key)
+	if err != nil {
+		ro.logger.WithTags(map[string]interface{}{"key": key}).Error("Failed to serve avatar", err)
+	}
+}
+
+// save validates and processes the avatar, stores it, and sets it on the user's profile
+func (ro *AvatarRouter) save(ctx context.Context, userID string, data []byte) (string, error) {
+	err := ro.validate(data)
+	if err != nil {
+		return "", err
+	}
+
+	contentType := http.DetectContentType(data)
+
+	for _, process := range ro.processors {
+		data, contentType, err = process(ctx, data, contentType)
+		if err != nil {
+			return "", cerrors.New(err, "failed to process avatar", nil)
+		}
+	}
+
+	ext, ok := avatarTypes[contentType]
+	if !ok {
+		return "", cerrors.New(nil, "avatar processor returned an unsupported content type", map[string]interface{}{
+			"contentType": contentType,
+		})
+	}
+
+	key := ro.userPrefix(userID) + randomHex(16) + ext //nolint:gomnd
+
+	err = ro.storage.Put(ctx, key, bytes.NewReader(data), PutOptions{
+		ContentType:  contentType,
+		CacheControl: "public, max-age=31536000, immutable",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	url := ro.url(key)
+
+	err = ro.profiles.SetAvatarURL(ctx, userID, url)
+	if err != nil {
+		return "", cerrors.New(err, "failed to set avatar url", map[string]interface{}{"userID": userID})
+	}
+
+	return url, nil
+}
+
+// read reads the avatar, up to the max size
+func (ro *AvatarRouter) read(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, ro.config.MaxSize+1))
+	if err != nil {
+		return nil, cerrors.New(err, "failed to read avatar", nil)
+	}
+
+	if int64(len(data)) > ro.config.MaxSize {
+		return nil, cerrors.New(errInvalidAvatar, "avatar is too large", map[string]interface{}{
+			"maxSize": ro.config.MaxSize,
+		})
+	}
+
+	return data, nil
+}
+
+// validate checks that the avatar is a supported image within the max dimensions. The content type is detected from
+// the data instead of trusting the client.
+func (ro *AvatarRouter) validate(data []byte) error {
+	contentType := http.DetectContentType(data)
+	if _, ok := avatarTypes[contentType]; !ok {
+		return cerrors.New(errInvalidAvatar, "avatar must be a png, jpeg, gif, or webp image", nil)
+	}
+
+	// the standard library cannot decode webp, so only its size is checked
+	if contentType == "image/webp" {
+		return nil
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return cerrors.New(errInvalidAvatar, "avatar is not a valid image", nil)
+	}
+
+	if ro.config.MaxDimension > 0 && (cfg.Width > ro.config.MaxDimension || cfg.Height > ro.config.MaxDimension) {
+		return cerrors.New(errInvalidAvatar, "avatar is too large", map[string]interface{}{
+			"maxDimension": ro.config.MaxDimension,
+		})
+	}
+
+	return nil
+}
+
+func (ro *AvatarRouter) writeResult(w http.ResponseWriter, r *http.Request, url string, err error) {
+	switch {
+	case errors.Is(err, errInvalidAvatar):
+		ro.writeError(w, http.StatusBadRequest, err)
+	case err != nil:
+		clogger.FromCtx(r.Context()).Error("Failed to save avatar", err)
+		ro.writeError(w, http.StatusInternalServerError, errors.New("failed to save avatar"))
+	default:
+		ro.rw.WriteJSON(w, chttp.WriteJSONParams{
+			Data: map[string]string{"avatar_url": url},
+		})
+	}
+}
+
+func (ro *AvatarRouter) writeError(w http.ResponseWriter, status int, err error) {
+	var cerr cerrors.Error
+	if errors.As(err, &cerr) {
+		err = errors.New(cerr.Message)
+	}
+
+	ro.rw.WriteJSON(w, chttp.WriteJSONParams{StatusCode: status, Data: err})
+}
+
+func (ro *AvatarRouter) userPrefix(userID string) string {
+	return ro.config.Prefix + userID + "/"
+}
+
+func (ro *AvatarRouter) url(key string) string {
+	return strings.TrimSuffix(ro.config.BaseURL, "/") + "/" + escapeKey(key)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
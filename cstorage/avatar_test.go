@@ -0,0 +1,194 @@
+package cstorage_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cstorage"
+	"github.com/stretchr/testify/assert"
+)
+
+type testProfiles struct {
+	mu      sync.Mutex
+	avatars map[string]string
+}
+
+func (p *testProfiles) CurrentUserID(r *http.Request) (string, bool) {
+	userID := r.Header.Get("X-User-Id")
+
+	return userID, userID != ""
+}
+
+func (p *testProfiles) SetAvatarURL(_ context.Context, userID, url string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.avatars[userID] = url
+
+	return nil
+}
+
+func newAvatarServer(t *testing.T) (*httptest.Server, *testProfiles) {
+	t.Helper()
+
+	var (
+		storage  = newLocalStorage(t)
+		profiles = &testProfiles{avatars: make(map[string]string)}
+		logger   = clogger.NewNoop()
+		config   = cstorage.Config{
+			Local: cstorage.ConfigLocal{BaseURL: "/storage"},
+			Avatar: cstorage.ConfigAvatar{
+				Prefix:       "avatars/",
+				BaseURL:      "/avatars",
+				MaxSize:      1 << 20,
+				MaxDimension: 64,
+			},
+		}
+	)
+
+	avatars := cstorage.NewAvatarRouter(cstorage.NewAvatarRouterParams{
+		Storage:  storage,
+		Profiles: profiles,
+		RW:       chttp.NewReaderWriter(nil, chttp.Config{}, logger),
+		Config:   config,
+		Logger:   logger,
+	})
+
+	server := httptest.NewServer(chttp.NewHandler(chttp.NewHandlerParams{
+		Routers: []chttp.Router{
+			avatars,
+			cstorage.NewRouter(cstorage.NewRouterParams{Storage: storage, Config: config, Logger: logger}),
+		},
+		Logger: logger,
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server, profiles
+}
+
+func testPNG(t *testing.T, size int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, size, size)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func uploadAvatar(t *testing.T, server *httptest.Server, userID string, data []byte) (*http.Response, string) {
+	t.Helper()
+
+	var body bytes.Buffer
+
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("avatar", "avatar.png")
+	_, _ = part.Write(data)
+	_ = form.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/api/user/avatar", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("X-User-Id", userID)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result map[string]string
+
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+
+	return resp, result["avatar_url"]
+}
+
+func TestAvatarRouter_Upload(t *testing.T) {
+	t.Parallel()
+
+	server, profiles := newAvatarServer(t)
+
+	resp, url := uploadAvatar(t, server, "42", testPNG(t, 32))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(url, "/avatars/avatars/42/"))
+	assert.True(t, strings.HasSuffix(url, ".png"))
+	assert.Equal(t, url, profiles.avatars["42"])
+
+	served, err := http.Get(server.URL + url) //nolint:noctx
+	if assert.NoError(t, err) {
+		_ = served.Body.Close()
+		assert.Equal(t, http.StatusOK, served.StatusCode)
+		assert.Equal(t, "image/png", served.Header.Get("Content-Type"))
+	}
+
+	resp, _ = uploadAvatar(t, server, "", testPNG(t, 32))
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, _ = uploadAvatar(t, server, "42", testPNG(t, 128))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = uploadAvatar(t, server, "42", []byte("<html>not an image</html>"))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestAvatarRouter_Presigned(t *testing.T) {
+	t.Parallel()
+
+	server, profiles := newAvatarServer(t)
+
+	post := func(path, userID, body string, dest interface{}) int {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+path, strings.NewReader(body))
+		req.Header.Set("X-User-Id", userID)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		_ = json.NewDecoder(resp.Body).Decode(dest)
+
+		return resp.StatusCode
+	}
+
+	var presigned struct {
+		Key       string `json:"key"`
+		UploadURL string `json:"upload_url"`
+	}
+
+	assert.Equal(t, http.StatusOK, post("/api/user/avatar/presign", "42", `{"content_type":"image/png"}`, &presigned))
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL+presigned.UploadURL, bytes.NewReader(testPNG(t, 16)))
+	req.Header.Set("Content-Type", "image/png")
+
+	resp, err := http.DefaultClient.Do(req)
+	if assert.NoError(t, err) {
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	var result map[string]string
+
+	// another user cannot claim the upload
+	assert.Equal(t, http.StatusForbidden, post("/api/user/avatar/complete", "7", `{"key":"`+presigned.Key+`"}`,
+		&result))
+
+	assert.Equal(t, http.StatusOK, post("/api/user/avatar/complete", "42", `{"key":"`+presigned.Key+`"}`, &result))
+	assert.Equal(t, "/avatars/"+presigned.Key, result["avatar_url"])
+	assert.Equal(t, result["avatar_url"], profiles.avatars["42"])
+}
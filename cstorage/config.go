@@ -0,0 +1,142 @@
+package cstorage
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Backends supported by NewStorage
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+	BackendGCS   = "gcs"
+)
+
+const (
+	defaultLocalDir      = "storage"
+	defaultLocalBaseURL  = "/storage"
+	defaultPresignExpiry = 15 * time.Minute
+	defaultGCSEndpoint   = "https://storage.googleapis.com"
+
+	defaultAvatarPrefix       = "avatars/"
+	defaultAvatarBaseURL      = "/avatars"
+	defaultAvatarMaxSize      = 5 << 20
+	defaultAvatarMaxDimension = 4096
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "cstorage",
+		Description: "cstorage configures the object storage backend",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("cstorage", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load cstorage config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Backend:       BackendLocal,
+		PresignExpiry: defaultPresignExpiry,
+		Local: ConfigLocal{
+			Dir:     defaultLocalDir,
+			BaseURL: defaultLocalBaseURL,
+		},
+		GCS: ConfigGCS{
+			Endpoint: defaultGCSEndpoint,
+		},
+		Avatar: ConfigAvatar{
+			Prefix:       defaultAvatarPrefix,
+			BaseURL:      defaultAvatarBaseURL,
+			MaxSize:      defaultAvatarMaxSize,
+			MaxDimension: defaultAvatarMaxDimension,
+		},
+	}
+}
+
+// Config configures the storage backend. For example:
+//
+//	[cstorage]
+//	backend = "s3"
+//
+//	[cstorage.s3]
+//	bucket = "my-app-uploads"
+//	region = "us-east-1"
+type Config struct {
+	// Backend is one of local, s3, or gcs
+	Backend string `toml:"backend" valid:"in(local|s3|gcs)" doc:"local, s3, or gcs"`
+
+	// PresignExpiry is how long presigned URLs are valid for if PresignOptions.Expiry is not set
+	PresignExpiry time.Duration `toml:"presign_expiry" doc:"How long presigned URLs are valid for by default"`
+
+	Local ConfigLocal `toml:"local"`
+	S3    ConfigS3    `toml:"s3"`
+	GCS   ConfigGCS   `toml:"gcs"`
+
+	Avatar ConfigAvatar `toml:"avatar"`
+}
+
+// ConfigLocal configures the local filesystem backend
+type ConfigLocal struct {
+	// Dir is the directory that objects are stored in
+	Dir string `toml:"dir"`
+
+	// BaseURL is the path (or URL) that Router serves presigned URLs at
+	BaseURL string `toml:"base_url"`
+
+	// SigningKey signs the presigned URLs. Presigned URLs are not available if it is not set.
+	SigningKey string `toml:"signing_key"`
+}
+
+// ConfigS3 configures the S3 backend. Credentials that are not set are read from the standard AWS environment
+// variables.
+type ConfigS3 struct {
+	Bucket          string `toml:"bucket"`
+	Region          string `toml:"region"`
+	AccessKeyID     string `toml:"access_key_id"`
+	SecretAccessKey string `toml:"secret_access_key"`
+	SessionToken    string `toml:"session_token"`
+
+	// Endpoint overrides the S3 endpoint for S3-compatible services (ex. http://localhost:9000 for MinIO)
+	Endpoint string `toml:"endpoint"`
+
+	// PathStyle puts the bucket in the URL's path instead of its host. It is needed by most S3-compatible services.
+	PathStyle bool `toml:"path_style"`
+}
+
+// ConfigGCS configures the Google Cloud Storage backend. It uses the Cloud Storage XML API with HMAC keys, which
+// can be created for a service account in the Cloud Storage interoperability settings.
+type ConfigGCS struct {
+	Bucket          string `toml:"bucket"`
+	AccessKeyID     string `toml:"access_key_id"`
+	SecretAccessKey string `toml:"secret_access_key"`
+	Endpoint        string `toml:"endpoint"`
+}
+
+// ConfigAvatar configures the avatar upload endpoints of AvatarRouter
+type ConfigAvatar struct {
+	// Prefix of the avatars' keys. Each user's avatars are stored under <prefix><user id>/.
+	Prefix string `toml:"prefix"`
+
+	// BaseURL is the URL (ex. a CDN in front of the bucket) that avatars are served at. The avatar URL saved on the
+	// user's profile is <base url>/<key>. If it is a path, AvatarRouter serves the avatars itself.
+	BaseURL string `toml:"base_url"`
+
+	// MaxSize is the max size of an avatar in bytes
+	MaxSize int64 `toml:"max_size"`
+
+	// MaxDimension is the max width and height of an avatar in pixels
+	MaxDimension int `toml:"max_dimension"`
+}
@@ -0,0 +1,3 @@
+// Package cstoragetest provides an in-memory cstorage.Storage so that code that stores objects can be unit tested
+// without a local directory or a cloud bucket. See cqueuetest for the other fakes, and why there are none for auth.
+package cstoragetest
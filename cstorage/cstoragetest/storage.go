@@ -0,0 +1,248 @@
+package cstoragetest
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/cstorage"
+)
+
+// PresignBaseURL is the base of the URLs returned by Storage's PresignGet and PresignPut
+const PresignBaseURL = "https://storage.test/"
+
+// NewStorage creates an empty Storage
+func NewStorage() *Storage {
+	return &Storage{
+		objects: make(map[string]object),
+		now:     time.Now,
+	}
+}
+
+// Storage is a cstorage.Storage that keeps objects in memory. Use it in place of the app's storage in tests:
+//
+//	storage := cstoragetest.NewStorage()
+//
+//	// handle a request that uploads a file..
+//
+//	data, ok := storage.Data("avatars/42.png")
+//
+// Presigned URLs are not served. They point to PresignBaseURL so that tests can assert on them.
+type Storage struct {
+	mu      sync.Mutex
+	objects map[string]object
+	err     error
+	now     func() time.Time
+}
+
+type object struct {
+	cstorage.Object
+	data []byte
+}
+
+// SetErr makes the storage's methods return err (ex. to test how a handler deals with a storage outage). Pass nil to
+// make them succeed again.
+func (s *Storage) SetErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.err = err
+}
+
+// Data returns the content of the object with the given key
+func (s *Storage) Data(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	obj, ok := s.objects[key]
+	if !ok {
+		return nil, false
+	}
+
+	return append([]byte(nil), obj.data...), true
+}
+
+// Keys returns the keys of the stored objects, sorted
+func (s *Storage) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Reset removes all objects
+func (s *Storage) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.objects = make(map[string]object)
+}
+
+// Put stores the object read from r
+func (s *Storage) Put(ctx context.Context, key string, r io.Reader, opts cstorage.PutOptions) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return cerrors.New(err, "failed to read object", map[string]interface{}{"key": key})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(key))
+	}
+
+	s.objects[key] = object{
+		Object: cstorage.Object{
+			Key:         key,
+			Size:        int64(len(data)),
+			ContentType: contentType,
+			ETag:        fmt.Sprintf(`"%x"`, md5.Sum(data)), //nolint:gosec
+			ModifiedAt:  s.now(),
+		},
+		data: data,
+	}
+
+	return nil
+}
+
+// Get returns a reader for the object's content
+func (s *Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.GetRange(ctx, key, 0, -1)
+}
+
+// GetRange returns a reader for length bytes of the object's content starting at offset
+func (s *Storage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	obj, err := s.object(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data := obj.data
+
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+
+	data = data[offset:]
+
+	if length >= 0 && length < int64(len(data)) {
+		data = data[:length]
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Stat returns the object's metadata
+func (s *Storage) Stat(ctx context.Context, key string) (cstorage.Object, error) {
+	obj, err := s.object(key)
+	if err != nil {
+		return cstorage.Object{}, err
+	}
+
+	return obj.Object, nil
+}
+
+// Delete deletes the object
+func (s *Storage) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+
+	delete(s.objects, key)
+
+	return nil
+}
+
+// List returns the objects with keys that start with prefix, sorted by key
+func (s *Storage) List(ctx context.Context, prefix string) ([]cstorage.Object, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	objects := make([]cstorage.Object, 0)
+
+	for key, obj := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, obj.Object)
+		}
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+
+	return objects, nil
+}
+
+// PresignGet returns a URL to download the object. It is not served.
+func (s *Storage) PresignGet(ctx context.Context, key string, opts cstorage.PresignOptions) (string, error) {
+	return s.presign("GET", key, opts)
+}
+
+// PresignPut returns a URL to upload the object. It is not served.
+func (s *Storage) PresignPut(ctx context.Context, key string, opts cstorage.PresignOptions) (string, error) {
+	return s.presign("PUT", key, opts)
+}
+
+func (s *Storage) presign(method, key string, opts cstorage.PresignOptions) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return "", s.err
+	}
+
+	query := url.Values{"method": {method}}
+	if opts.Expiry > 0 {
+		query.Set("expires", s.now().Add(opts.Expiry).UTC().Format(time.RFC3339))
+	}
+
+	if opts.ContentType != "" {
+		query.Set("content_type", opts.ContentType)
+	}
+
+	return PresignBaseURL + key + "?" + query.Encode(), nil
+}
+
+func (s *Storage) object(key string) (object, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return object{}, s.err
+	}
+
+	obj, ok := s.objects[key]
+	if !ok {
+		return object{}, cerrors.New(cstorage.ErrNotFound, "object does not exist", map[string]interface{}{"key": key})
+	}
+
+	return obj, nil
+}
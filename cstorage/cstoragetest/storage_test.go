@@ -0,0 +1,62 @@
+package cstoragetest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gocopper/copper/cstorage"
+	"github.com/gocopper/copper/cstorage/cstoragetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		storage = cstoragetest.NewStorage()
+	)
+
+	assert.NoError(t, storage.Put(ctx, "avatars/1.png", strings.NewReader("png-1"), cstorage.PutOptions{}))
+	assert.NoError(t, storage.Put(ctx, "docs/a.txt", strings.NewReader("hello world"), cstorage.PutOptions{
+		ContentType: "text/plain",
+	}))
+
+	data, ok := storage.Data("avatars/1.png")
+	assert.True(t, ok)
+	assert.Equal(t, "png-1", string(data))
+	assert.Equal(t, []string{"avatars/1.png", "docs/a.txt"}, storage.Keys())
+
+	obj, err := storage.Stat(ctx, "avatars/1.png")
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", obj.ContentType)
+	assert.Equal(t, int64(5), obj.Size)
+
+	body, err := storage.GetRange(ctx, "docs/a.txt", 6, 3)
+	if assert.NoError(t, err) {
+		data, _ := io.ReadAll(body)
+		assert.Equal(t, "wor", string(data))
+	}
+
+	objects, err := storage.List(ctx, "docs/")
+	assert.NoError(t, err)
+	assert.Len(t, objects, 1)
+
+	url, err := storage.PresignPut(ctx, "docs/b.txt", cstorage.PresignOptions{ContentType: "text/plain"})
+	assert.NoError(t, err)
+	assert.Equal(t, cstoragetest.PresignBaseURL+"docs/b.txt?content_type=text%2Fplain&method=PUT", url)
+
+	assert.NoError(t, storage.Delete(ctx, "avatars/1.png"))
+
+	_, err = storage.Get(ctx, "avatars/1.png")
+	assert.True(t, errors.Is(err, cstorage.ErrNotFound))
+
+	errTest := errors.New("test-err")
+	storage.SetErr(errTest)
+
+	_, err = storage.Get(ctx, "docs/a.txt")
+	assert.True(t, errors.Is(err, errTest))
+}
@@ -0,0 +1,12 @@
+package cstoragetest
+
+import (
+	"github.com/gocopper/copper/cstorage"
+	"github.com/google/wire"
+)
+
+// WireModule provides a Storage as the app's cstorage.Storage. Use it in place of cstorage.NewStorage in test builds.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	NewStorage,
+	wire.Bind(new(cstorage.Storage), new(*Storage)),
+)
@@ -0,0 +1,4 @@
+// Package cstorage stores files (objects) by key in S3, Google Cloud Storage, or the local filesystem. Objects are
+// streamed in and out of the backend, and can be uploaded and downloaded directly by clients using presigned URLs.
+// ServeObject serves stored objects over HTTP with support for range requests.
+package cstorage
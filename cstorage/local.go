@@ -0,0 +1,395 @@
+package cstorage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// localMetaDir is the directory in ConfigLocal.Dir that holds the metadata of the objects
+const localMetaDir = ".cstorage"
+
+// NewLocalStorage creates a LocalStorage that stores objects in config.Dir
+func NewLocalStorage(config ConfigLocal, presignExpiry time.Duration) (*LocalStorage, error) {
+	err := os.MkdirAll(config.Dir, 0o755) //nolint:gomnd
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create storage dir", map[string]interface{}{
+			"dir": config.Dir,
+		})
+	}
+
+	return &LocalStorage{
+		config:        config,
+		presignExpiry: presignExpiry,
+	}, nil
+}
+
+// LocalStorage is a Storage that stores objects as files in a local directory. It is meant for development and
+// single-instance apps. Presigned URLs are served by Router.
+type LocalStorage struct {
+	config        ConfigLocal
+	presignExpiry time.Duration
+}
+
+type localMeta struct {
+	ContentType  string `json:"content_type"`
+	CacheControl string `json:"cache_control,omitempty"`
+}
+
+// Put stores the object read from r. The file is written to a temporary file first so that readers never see a
+// partial object.
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(p), 0o755) //nolint:gomnd
+	if err != nil {
+		return cerrors.New(err, "failed to create object dir", map[string]interface{}{"key": key})
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return cerrors.New(err, "failed to create object file", map[string]interface{}{"key": key})
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	_, err = io.Copy(tmp, readerWithCtx{ctx: ctx, r: r})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return cerrors.New(err, "failed to write object file", map[string]interface{}{"key": key})
+	}
+
+	err = s.writeMeta(key, localMeta{ContentType: opts.ContentType, CacheControl: opts.CacheControl})
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmp.Name(), p)
+	if err != nil {
+		return cerrors.New(err, "failed to move object file", map[string]interface{}{"key": key})
+	}
+
+	return nil
+}
+
+// Get returns a reader for the object's content
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.GetRange(ctx, key, 0, -1)
+}
+
+// GetRange returns a reader for length bytes of the object's content starting at offset
+func (s *LocalStorage) GetRange(_ context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, cerrors.New(ErrNotFound, "object does not exist", map[string]interface{}{"key": key})
+	} else if err != nil {
+		return nil, cerrors.New(err, "failed to open object file", map[string]interface{}{"key": key})
+	}
+
+	if offset > 0 {
+		_, err = f.Seek(offset, io.SeekStart)
+		if err != nil {
+			_ = f.Close()
+			return nil, cerrors.New(err, "failed to seek object file", map[string]interface{}{"key": key})
+		}
+	}
+
+	if length < 0 {
+		return f, nil
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{Reader: io.LimitReader(f, length), Closer: f}, nil
+}
+
+// Stat returns the object's metadata
+func (s *LocalStorage) Stat(_ context.Context, key string) (Object, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return Object{}, err
+	}
+
+	info, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return Object{}, cerrors.New(ErrNotFound, "object does not exist", map[string]interface{}{"key": key})
+	} else if err != nil {
+		return Object{}, cerrors.New(err, "failed to stat object file", map[string]interface{}{"key": key})
+	}
+
+	return s.object(key, info), nil
+}
+
+// Delete deletes the object
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range []string{p, s.metaPath(p)} {
+		err = os.Remove(f)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return cerrors.New(err, "failed to delete object file", map[string]interface{}{"key": key})
+		}
+	}
+
+	return nil
+}
+
+// List returns the objects with keys that start with prefix
+func (s *LocalStorage) List(_ context.Context, prefix string) ([]Object, error) {
+	objects := make([]Object, 0)
+
+	err := filepath.WalkDir(s.config.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if d.Name() == localMetaDir {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.config.Dir, p)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		objects = append(objects, s.object(key, info))
+
+		return nil
+	})
+	if err != nil {
+		return nil, cerrors.New(err, "failed to list objects", map[string]interface{}{"prefix": prefix})
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+
+	return objects, nil
+}
+
+// PresignGet returns a URL served by Router that can be used to download the object until it expires
+func (s *LocalStorage) PresignGet(_ context.Context, key string, opts PresignOptions) (string, error) {
+	return s.presign("GET", key, opts)
+}
+
+// PresignPut returns a URL served by Router that can be used to upload the object until it expires
+func (s *LocalStorage) PresignPut(_ context.Context, key string, opts PresignOptions) (string, error) {
+	return s.presign("PUT", key, opts)
+}
+
+// VerifyPresigned returns true if the query has a valid, unexpired signature for the method on the key
+func (s *LocalStorage) VerifyPresigned(method, key string, query url.Values, now time.Time) bool {
+	if s.config.SigningKey == "" {
+		return false
+	}
+
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || now.Unix() > expires {
+		return false
+	}
+
+	sig, err := hex.DecodeString(query.Get("signature"))
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(sig, s.sign(method, key, expires, query.Get("content_type")))
+}
+
+func (s *LocalStorage) presign(method, key string, opts PresignOptions) (string, error) {
+	if s.config.SigningKey == "" {
+		return "", cerrors.New(nil, "cstorage.local.signing_key is required for presigned urls", nil)
+	}
+
+	_, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+
+	expires := time.Now().Add(presignExpiry(opts, s.presignExpiry)).Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+
+	contentType := ""
+	if method == "PUT" && opts.ContentType != "" {
+		contentType = opts.ContentType
+		query.Set("content_type", contentType)
+	}
+
+	query.Set("signature", hex.EncodeToString(s.sign(method, key, expires, contentType)))
+
+	return strings.TrimSuffix(s.config.BaseURL, "/") + "/" + escapeKey(key) + "?" + query.Encode(), nil
+}
+
+func (s *LocalStorage) sign(method, key string, expires int64, contentType string) []byte {
+	h := hmac.New(sha256.New, []byte(s.config.SigningKey))
+	_, _ = fmt.Fprintf(h, "%s\n%s\n%d\n%s", method, key, expires, contentType)
+
+	return h.Sum(nil)
+}
+
+func (s *LocalStorage) object(key string, info fs.FileInfo) Object {
+	obj := Object{
+		Key:        key,
+		Size:       info.Size(),
+		ModifiedAt: info.ModTime(),
+		ETag:       fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()),
+	}
+
+	meta, err := s.readMeta(key)
+	if err == nil {
+		obj.ContentType = meta.ContentType
+	}
+
+	if obj.ContentType == "" {
+		obj.ContentType = mime.TypeByExtension(path.Ext(key))
+	}
+
+	return obj
+}
+
+func (s *LocalStorage) readMeta(key string) (localMeta, error) {
+	var meta localMeta
+
+	p, err := s.path(key)
+	if err != nil {
+		return meta, err
+	}
+
+	data, err := os.ReadFile(s.metaPath(p))
+	if err != nil {
+		return meta, err
+	}
+
+	err = json.Unmarshal(data, &meta)
+
+	return meta, err
+}
+
+func (s *LocalStorage) writeMeta(key string, meta localMeta) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return cerrors.New(err, "failed to encode object metadata", map[string]interface{}{"key": key})
+	}
+
+	metaPath := s.metaPath(p)
+
+	err = os.MkdirAll(filepath.Dir(metaPath), 0o755) //nolint:gomnd
+	if err == nil {
+		err = os.WriteFile(metaPath, data, 0o644) //nolint:gomnd,gosec
+	}
+
+	if err != nil {
+		return cerrors.New(err, "failed to write object metadata", map[string]interface{}{"key": key})
+	}
+
+	return nil
+}
+
+// path returns the file path of the object. Keys cannot escape the storage dir.
+func (s *LocalStorage) path(key string) (string, error) {
+	err := validateKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(s.config.Dir, filepath.FromSlash(key)), nil
+}
+
+func (s *LocalStorage) metaPath(p string) string {
+	rel, _ := filepath.Rel(s.config.Dir, p)
+
+	return filepath.Join(s.config.Dir, localMetaDir, rel+".json")
+}
+
+// validateKey returns an error if the key is empty, absolute, or has empty, . or .. segments
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") || strings.Contains(key, "\\") {
+		return cerrors.New(nil, "invalid object key", map[string]interface{}{"key": key})
+	}
+
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." || seg == localMetaDir {
+			return cerrors.New(nil, "invalid object key", map[string]interface{}{"key": key})
+		}
+	}
+
+	return nil
+}
+
+// escapeKey escapes each segment of the key for use in a URL path
+func escapeKey(key string) string {
+	segs := strings.Split(key, "/")
+	for i := range segs {
+		segs[i] = url.PathEscape(segs[i])
+	}
+
+	return strings.Join(segs, "/")
+}
+
+// readerWithCtx stops reading once ctx is done
+type readerWithCtx struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r readerWithCtx) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return r.r.Read(p)
+}
@@ -0,0 +1,479 @@
+package cstorage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/internal/awssig"
+)
+
+const (
+	// s3PartSize is the size of the parts that large objects are uploaded in. Each part is buffered in memory.
+	s3PartSize = 8 << 20
+
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3RequestTimeout  = 30 * time.Second
+)
+
+// NewS3Storage creates an S3Storage. Credentials that are not set in the config are read from the standard AWS
+// environment variables (AWS_REGION, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN).
+func NewS3Storage(config ConfigS3, presignExpiry time.Duration) (*S3Storage, error) {
+	if config.Region == "" {
+		config.Region = awssig.RegionFromEnv()
+	}
+
+	if config.AccessKeyID == "" {
+		config.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		config.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		config.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+
+	if config.Bucket == "" || config.Region == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, cerrors.New(nil, "s3 bucket, region, and credentials must be set", nil)
+	}
+
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+
+	base, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, cerrors.New(err, "invalid s3 endpoint", map[string]interface{}{"endpoint": endpoint})
+	}
+
+	if config.PathStyle {
+		base.Path += "/" + config.Bucket
+	} else {
+		base.Host = config.Bucket + "." + base.Host
+	}
+
+	return &S3Storage{
+		config:        config,
+		base:          base,
+		presignExpiry: presignExpiry,
+		// requests are not limited by a client timeout since objects are streamed; they use their context instead
+		client: &http.Client{},
+	}, nil
+}
+
+// NewGCSStorage creates an S3Storage for Google Cloud Storage. It uses the Cloud Storage XML API, which is
+// compatible with S3 when requests are signed using HMAC keys.
+func NewGCSStorage(config ConfigGCS, presignExpiry time.Duration) (*S3Storage, error) {
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = defaultGCSEndpoint
+	}
+
+	return NewS3Storage(ConfigS3{
+		Bucket:          config.Bucket,
+		Region:          "auto",
+		AccessKeyID:     config.AccessKeyID,
+		SecretAccessKey: config.SecretAccessKey,
+		Endpoint:        endpoint,
+		PathStyle:       true,
+	}, presignExpiry)
+}
+
+// S3Storage is a Storage that uses the S3 REST API. It works with S3-compatible services such as Google Cloud
+// Storage, MinIO, and Cloudflare R2. Objects larger than 8 MiB are uploaded in parts using a multipart upload.
+type S3Storage struct {
+	config        ConfigS3
+	base          *url.URL
+	presignExpiry time.Duration
+	client        *http.Client
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// Put stores the object read from r
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) error {
+	err := validateKey(key)
+	if err != nil {
+		return err
+	}
+
+	part := make([]byte, s3PartSize)
+
+	n, err := io.ReadFull(r, part)
+	if err == io.EOF || err == io.ErrUnexpectedEOF { //nolint:errorlint
+		return s.putObject(ctx, key, part[:n], opts)
+	} else if err != nil {
+		return cerrors.New(err, "failed to read object", map[string]interface{}{"key": key})
+	}
+
+	return s.putMultipart(ctx, key, part, r, opts)
+}
+
+// Get returns a reader for the object's content
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.GetRange(ctx, key, 0, -1)
+}
+
+// GetRange returns a reader for length bytes of the object's content starting at offset
+func (s *S3Storage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	err := validateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+
+	switch {
+	case length == 0:
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	case length > 0:
+		header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-"+strconv.FormatInt(offset+length-1, 10))
+	case offset > 0:
+		header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, key, nil, header, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// Stat returns the object's metadata
+func (s *S3Storage) Stat(ctx context.Context, key string) (Object, error) {
+	err := validateKey(key)
+	if err != nil {
+		return Object{}, err
+	}
+
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return Object{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	modifiedAt, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	return Object{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        resp.Header.Get("ETag"),
+		ModifiedAt:  modifiedAt,
+	}, nil
+}
+
+// Delete deletes the object
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	err := validateKey(key)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// List returns the objects with keys that start with prefix. The objects' content types are not set since S3 does
+// not return them when listing.
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]Object, error) {
+	var (
+		objects = make([]Object, 0)
+		token   string
+	)
+
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		var result struct {
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+			Contents              []struct {
+				Key          string    `xml:"Key"`
+				LastModified time.Time `xml:"LastModified"`
+				ETag         string    `xml:"ETag"`
+				Size         int64     `xml:"Size"`
+			} `xml:"Contents"`
+		}
+
+		err := s.doXML(ctx, http.MethodGet, "", query, nil, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range result.Contents {
+			objects = append(objects, Object{
+				Key:        c.Key,
+				Size:       c.Size,
+				ETag:       c.ETag,
+				ModifiedAt: c.LastModified,
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+
+		token = result.NextContinuationToken
+	}
+}
+
+// PresignGet returns a URL that can be used to download the object until it expires
+func (s *S3Storage) PresignGet(_ context.Context, key string, opts PresignOptions) (string, error) {
+	return s.presign(http.MethodGet, key, "", opts)
+}
+
+// PresignPut returns a URL that can be used to upload the object until it expires. If opts.ContentType is set, the
+// upload must send the same Content-Type header.
+func (s *S3Storage) PresignPut(_ context.Context, key string, opts PresignOptions) (string, error) {
+	return s.presign(http.MethodPut, key, opts.ContentType, opts)
+}
+
+func (s *S3Storage) presign(method, key, contentType string, opts PresignOptions) (string, error) {
+	err := validateKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(method, s.objectURL(key, nil), nil) //nolint:noctx
+	if err != nil {
+		return "", cerrors.New(err, "failed to create presign request", map[string]interface{}{"key": key})
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	return awssig.Presign(req, s.creds(), s.config.Region, "s3", time.Now(), presignExpiry(opts, s.presignExpiry)),
+		nil
+}
+
+func (s *S3Storage) putObject(ctx context.Context, key string, data []byte, opts PutOptions) error {
+	header := http.Header{}
+
+	if opts.ContentType != "" {
+		header.Set("Content-Type", opts.ContentType)
+	}
+
+	if opts.CacheControl != "" {
+		header.Set("Cache-Control", opts.CacheControl)
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, key, nil, header, data)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// putMultipart uploads the object in parts, starting with the first part that was already read from r. The upload
+// is aborted if a part fails so that the bucket is not billed for the uploaded parts.
+func (s *S3Storage) putMultipart(ctx context.Context, key string, first []byte, r io.Reader, opts PutOptions) error {
+	header := http.Header{}
+
+	if opts.ContentType != "" {
+		header.Set("Content-Type", opts.ContentType)
+	}
+
+	if opts.CacheControl != "" {
+		header.Set("Cache-Control", opts.CacheControl)
+	}
+
+	var upload struct {
+		UploadID string `xml:"UploadId"`
+	}
+
+	err := s.doXML(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, header, &upload)
+	if err != nil {
+		return err
+	}
+
+	err = s.uploadParts(ctx, key, upload.UploadID, first, r)
+	if err != nil {
+		abortCtx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+		defer cancel()
+
+		resp, abortErr := s.do(abortCtx, http.MethodDelete, key, url.Values{"uploadId": {upload.UploadID}}, nil, nil)
+		if abortErr == nil {
+			_ = resp.Body.Close()
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (s *S3Storage) uploadParts(ctx context.Context, key, uploadID string, part []byte, r io.Reader) error {
+	var (
+		parts []s3CompletedPart
+		buf   = part
+	)
+
+	for n := 1; len(part) > 0; n++ {
+		query := url.Values{"partNumber": {strconv.Itoa(n)}, "uploadId": {uploadID}}
+
+		resp, err := s.do(ctx, http.MethodPut, key, query, nil, part)
+		if err != nil {
+			return err
+		}
+
+		_ = resp.Body.Close()
+
+		parts = append(parts, s3CompletedPart{PartNumber: n, ETag: resp.Header.Get("ETag")})
+
+		read, err := io.ReadFull(r, buf[:cap(buf)])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF { //nolint:errorlint
+			return cerrors.New(err, "failed to read object", map[string]interface{}{"key": key})
+		}
+
+		part = buf[:read]
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+		Parts   []s3CompletedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return cerrors.New(err, "failed to encode multipart upload", nil)
+	}
+
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, nil, body)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// completing an upload can fail after the 200 response has started, in which case the body holds the error
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return cerrors.New(err, "failed to read s3 response", nil)
+	}
+
+	var s3Err s3Error
+	if xml.Unmarshal(respBody, &s3Err) == nil && s3Err.Code != "" {
+		return cerrors.New(nil, "failed to complete multipart upload", map[string]interface{}{
+			"key":     key,
+			"code":    s3Err.Code,
+			"message": s3Err.Message,
+		})
+	}
+
+	return nil
+}
+
+func (s *S3Storage) doXML(ctx context.Context, method, key string, query url.Values, header http.Header,
+	dest interface{}) error {
+	resp, err := s.do(ctx, method, key, query, header, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	err = xml.NewDecoder(resp.Body).Decode(dest)
+	if err != nil {
+		return cerrors.New(err, "failed to decode s3 response", nil)
+	}
+
+	return nil
+}
+
+// do sends a signed request for the object (or the bucket if key is empty). Responses with an error status are
+// returned as errors, with ErrNotFound for missing objects.
+func (s *S3Storage) do(ctx context.Context, method, key string, query url.Values, header http.Header,
+	body []byte) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key, query), reqBody)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create s3 request", nil)
+	}
+
+	for k := range header {
+		req.Header.Set(k, header.Get(k))
+	}
+
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+	awssig.Sign(req, nil, s.creds(), s.config.Region, "s3", time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to send s3 request", map[string]interface{}{
+			"method": method,
+			"key":    key,
+		})
+	}
+
+	if resp.StatusCode < http.StatusMultipleChoices {
+		return resp, nil
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound && key != "" {
+		return nil, cerrors.New(ErrNotFound, "object does not exist", map[string]interface{}{"key": key})
+	}
+
+	var s3Err s3Error
+
+	respBody, _ := io.ReadAll(resp.Body)
+	_ = xml.Unmarshal(respBody, &s3Err)
+
+	return nil, cerrors.New(nil, "s3 request failed", map[string]interface{}{
+		"method":  method,
+		"key":     key,
+		"status":  resp.StatusCode,
+		"code":    s3Err.Code,
+		"message": s3Err.Message,
+	})
+}
+
+func (s *S3Storage) objectURL(key string, query url.Values) string {
+	u := *s.base
+	if key != "" {
+		u.Path = s.base.Path + "/" + key
+		u.RawPath = s.base.EscapedPath() + "/" + escapeKey(key)
+	} else if u.Path == "" {
+		u.Path = "/"
+	}
+
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+func (s *S3Storage) creds() awssig.Credentials {
+	return awssig.Credentials{
+		AccessKeyID:     s.config.AccessKeyID,
+		SecretAccessKey: s.config.SecretAccessKey,
+		SessionToken:    s.config.SessionToken,
+	}
+}
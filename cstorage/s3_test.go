@@ -0,0 +1,179 @@
+package cstorage_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/cstorage"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 is a path-style S3 bucket named "bucket" that lists one object per page
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+	parts   map[int][]byte
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test-key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	var (
+		key   = strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/bucket"), "/")
+		query = r.URL.Query()
+	)
+
+	body, _ := io.ReadAll(r.Body)
+
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		s.list(w, query.Get("prefix"), query.Get("continuation-token"))
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		s.parts = make(map[int][]byte)
+		s.types[key] = r.Header.Get("Content-Type")
+		_, _ = fmt.Fprint(w, "<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>")
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		n, _ := strconv.Atoi(query.Get("partNumber"))
+		s.parts[n] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, n))
+	case r.Method == http.MethodPost && query.Get("uploadId") == "upload-1":
+		var data []byte
+		for n := 1; n <= len(s.parts); n++ {
+			data = append(data, s.parts[n]...)
+		}
+
+		s.objects[key] = data
+		_, _ = fmt.Fprint(w, "<CompleteMultipartUploadResult></CompleteMultipartUploadResult>")
+	case r.Method == http.MethodPut:
+		s.objects[key] = body
+		s.types[key] = r.Header.Get("Content-Type")
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		data, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, "<Error><Code>NoSuchKey</Code></Error>")
+
+			return
+		}
+
+		w.Header().Set("Content-Type", s.types[key])
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}
+}
+
+func (s *fakeS3) list(w http.ResponseWriter, prefix, token string) {
+	keys := make([]string, 0)
+
+	for k := range s.objects {
+		if strings.HasPrefix(k, prefix) && k > token {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	type content struct {
+		Key  string `xml:"Key"`
+		Size int    `xml:"Size"`
+	}
+
+	result := struct {
+		XMLName               xml.Name  `xml:"ListBucketResult"`
+		IsTruncated           bool      `xml:"IsTruncated"`
+		NextContinuationToken string    `xml:"NextContinuationToken"`
+		Contents              []content `xml:"Contents"`
+	}{}
+
+	if len(keys) > 0 {
+		result.Contents = []content{{Key: keys[0], Size: len(s.objects[keys[0]])}}
+		result.IsTruncated = len(keys) > 1
+		result.NextContinuationToken = keys[0]
+	}
+
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+func TestS3Storage(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx    = context.Background()
+		fake   = &fakeS3{objects: make(map[string][]byte), types: make(map[string]string)}
+		server = httptest.NewServer(fake)
+	)
+	defer server.Close()
+
+	storage, err := cstorage.NewS3Storage(cstorage.ConfigS3{
+		Bucket:          "bucket",
+		Region:          "us-east-1",
+		AccessKeyID:     "test-key",
+		SecretAccessKey: "test-secret",
+		Endpoint:        server.URL,
+		PathStyle:       true,
+	}, time.Minute)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	large := bytes.Repeat([]byte("0123456789"), 1<<20)
+
+	assert.NoError(t, storage.Put(ctx, "avatars/1 a.png", strings.NewReader("png-1"), cstorage.PutOptions{
+		ContentType: "image/png",
+	}))
+	assert.NoError(t, storage.Put(ctx, "avatars/2.bin", bytes.NewReader(large), cstorage.PutOptions{}))
+	assert.Len(t, fake.parts, 2)
+	assert.Equal(t, large, fake.objects["avatars/2.bin"])
+
+	body, err := storage.GetRange(ctx, "avatars/1 a.png", 1, 3)
+	if assert.NoError(t, err) {
+		data, _ := io.ReadAll(body)
+		_ = body.Close()
+
+		assert.Equal(t, "ng-", string(data))
+	}
+
+	obj, err := storage.Stat(ctx, "avatars/1 a.png")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), obj.Size)
+	assert.Equal(t, "image/png", obj.ContentType)
+
+	objects, err := storage.List(ctx, "avatars/")
+	assert.NoError(t, err)
+
+	if assert.Len(t, objects, 2) {
+		assert.Equal(t, "avatars/1 a.png", objects[0].Key)
+		assert.Equal(t, int64(len(large)), objects[1].Size)
+	}
+
+	assert.NoError(t, storage.Delete(ctx, "avatars/1 a.png"))
+
+	_, err = storage.Stat(ctx, "avatars/1 a.png")
+	assert.True(t, errors.Is(err, cstorage.ErrNotFound))
+
+	url, err := storage.PresignPut(ctx, "avatars/3.png", cstorage.PresignOptions{ContentType: "image/png"})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(url, server.URL+"/bucket/avatars/3.png?X-Amz-Algorithm=AWS4-HMAC-SHA256&"))
+	assert.Contains(t, url, "X-Amz-Expires=60&X-Amz-SignedHeaders=content-type%3Bhost&X-Amz-Signature=")
+}
@@ -0,0 +1,196 @@
+package cstorage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+)
+
+// ServeObject writes the object to the response. Range and conditional requests (If-None-Match, If-Modified-Since,
+// etc.) are supported, so large files such as videos can be streamed and resumed. Only the requested ranges are read
+// from the storage. It responds with 404 if the object does not exist.
+func ServeObject(w http.ResponseWriter, r *http.Request, storage Storage, key string) error {
+	obj, err := storage.Stat(r.Context(), key)
+	if errors.Is(err, ErrNotFound) {
+		http.NotFound(w, r)
+		return nil
+	} else if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return err
+	}
+
+	if obj.ContentType != "" {
+		w.Header().Set("Content-Type", obj.ContentType)
+	}
+
+	if obj.ETag != "" {
+		w.Header().Set("ETag", obj.ETag)
+	}
+
+	content := &objectReader{ctx: r.Context(), storage: storage, key: key, size: obj.Size}
+	defer content.Close()
+
+	http.ServeContent(w, r, key, obj.ModifiedAt, content)
+
+	return content.err
+}
+
+// objectReader is an io.ReadSeeker over a stored object. The object is read from the storage starting at the current
+// offset on the first Read after a Seek, so that http.ServeContent only downloads the requested range.
+type objectReader struct {
+	ctx     context.Context
+	storage Storage
+	key     string
+	size    int64
+
+	offset int64
+	body   io.ReadCloser
+	err    error
+}
+
+func (r *objectReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+
+	if r.body == nil {
+		r.body, r.err = r.storage.GetRange(r.ctx, r.key, r.offset, -1)
+		if r.err != nil {
+			return 0, r.err
+		}
+	}
+
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.err = err
+	}
+
+	return n, err
+}
+
+func (r *objectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	}
+
+	if offset < 0 {
+		return 0, cerrors.New(nil, "invalid seek offset", nil)
+	}
+
+	if offset != r.offset {
+		r.Close()
+		r.offset = offset
+	}
+
+	return offset, nil
+}
+
+func (r *objectReader) Close() {
+	if r.body != nil {
+		_ = r.body.Close()
+		r.body = nil
+	}
+}
+
+// NewRouterParams holds the params needed for NewRouter
+type NewRouterParams struct {
+	Storage Storage
+	Config  Config
+	Logger  clogger.Logger
+}
+
+// NewRouter creates a new Router
+func NewRouter(p NewRouterParams) *Router {
+	local, _ := p.Storage.(*LocalStorage)
+
+	return &Router{
+		local:  local,
+		config: p.Config,
+		logger: p.Logger.With(clogger.Module("cstorage")),
+	}
+}
+
+// Router is a chttp.Router that serves the presigned URLs of LocalStorage at ConfigLocal.BaseURL. It has no routes
+// for the other backends since their presigned URLs point to the backend directly.
+type Router struct {
+	local  *LocalStorage
+	config Config
+	logger clogger.Logger
+}
+
+// Routes returns the routes that download and upload objects using presigned URLs
+func (ro *Router) Routes() []chttp.Route {
+	if ro.local == nil || strings.Contains(ro.config.Local.BaseURL, "://") {
+		return nil
+	}
+
+	return []chttp.Route{
+		{
+			Path:    strings.TrimSuffix(ro.config.Local.BaseURL, "/") + "/{key:.+}",
+			Methods: []string{http.MethodGet, http.MethodHead, http.MethodPut},
+			Handler: ro.HandlePresigned,
+		},
+	}
+}
+
+// HandlePresigned serves a presigned URL after verifying its signature
+func (ro *Router) HandlePresigned(w http.ResponseWriter, r *http.Request) {
+	var (
+		key    = chttp.URLParams(r)["key"]
+		method = r.Method
+	)
+
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+
+	if !ro.local.VerifyPresigned(method, key, r.URL.Query(), time.Now()) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	var err error
+
+	if r.Method == http.MethodPut {
+		err = ro.put(w, r, key)
+	} else {
+		err = ServeObject(w, r, ro.local, key)
+	}
+
+	if err != nil {
+		ro.logger.WithTags(map[string]interface{}{
+			"key":    key,
+			"method": r.Method,
+		}).Error("Failed to serve presigned storage url", err)
+	}
+}
+
+func (ro *Router) put(w http.ResponseWriter, r *http.Request, key string) error {
+	contentType := r.URL.Query().Get("content_type")
+	if contentType != "" && r.Header.Get("Content-Type") != contentType {
+		http.Error(w, "Content-Type does not match the presigned url", http.StatusBadRequest)
+		return nil
+	}
+
+	err := ro.local.Put(r.Context(), key, r.Body, PutOptions{ContentType: r.Header.Get("Content-Type")})
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package cstorage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// Object describes a stored object
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ETag        string
+	ModifiedAt  time.Time
+}
+
+// PutOptions configures how an object is stored
+type PutOptions struct {
+	ContentType  string
+	CacheControl string
+}
+
+// PresignOptions configures a presigned URL
+type PresignOptions struct {
+	// Expiry is how long the URL is valid for (default: Config.PresignExpiry)
+	Expiry time.Duration
+
+	// ContentType that must be sent when the presigned upload URL is used
+	ContentType string
+}
+
+// Storage stores objects by key. Keys are slash-separated paths (ex. avatars/42.png).
+type Storage interface {
+	// Put stores the object read from r, replacing the object with the same key if there is one
+	Put(ctx context.Context, key string, r io.Reader, opts PutOptions) error
+
+	// Get returns a reader for the object's content that must be closed
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// GetRange returns a reader for length bytes of the object's content starting at offset. If length is negative,
+	// the rest of the object is read.
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+
+	// Stat returns the object's metadata
+	Stat(ctx context.Context, key string) (Object, error)
+
+	// Delete deletes the object. Deleting an object that does not exist is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// List returns the objects with keys that start with prefix, sorted by key
+	List(ctx context.Context, prefix string) ([]Object, error)
+
+	// PresignGet returns a URL that can be used to download the object without credentials until it expires
+	PresignGet(ctx context.Context, key string, opts PresignOptions) (string, error)
+
+	// PresignPut returns a URL that can be used to upload the object with a PUT request without credentials until it
+	// expires
+	PresignPut(ctx context.Context, key string, opts PresignOptions) (string, error)
+}
+
+// NewStorageParams holds the params needed for NewStorage
+type NewStorageParams struct {
+	Config Config
+}
+
+// NewStorage creates the Storage configured by Config.Backend
+func NewStorage(p NewStorageParams) (Storage, error) {
+	switch p.Config.Backend {
+	case BackendLocal:
+		return NewLocalStorage(p.Config.Local, p.Config.PresignExpiry)
+	case BackendS3:
+		return NewS3Storage(p.Config.S3, p.Config.PresignExpiry)
+	case BackendGCS:
+		return NewGCSStorage(p.Config.GCS, p.Config.PresignExpiry)
+	default:
+		return nil, cerrors.New(nil, "unknown cstorage backend", map[string]interface{}{
+			"backend": p.Config.Backend,
+		})
+	}
+}
+
+func presignExpiry(opts PresignOptions, defaultExpiry time.Duration) time.Duration {
+	if opts.Expiry > 0 {
+		return opts.Expiry
+	}
+
+	if defaultExpiry > 0 {
+		return defaultExpiry
+	}
+
+	return defaultPresignExpiry
+}
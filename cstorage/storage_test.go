@@ -0,0 +1,165 @@
+package cstorage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cstorage"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLocalStorage(t *testing.T) *cstorage.LocalStorage {
+	t.Helper()
+
+	storage, err := cstorage.NewLocalStorage(cstorage.ConfigLocal{
+		Dir:        t.TempDir(),
+		BaseURL:    "/storage",
+		SigningKey: "test-key",
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return storage
+}
+
+func TestLocalStorage(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		storage = newLocalStorage(t)
+	)
+
+	assert.NoError(t, storage.Put(ctx, "avatars/1.png", strings.NewReader("png-1"), cstorage.PutOptions{
+		ContentType: "image/png",
+	}))
+	assert.NoError(t, storage.Put(ctx, "avatars/2.txt", strings.NewReader("text-2"), cstorage.PutOptions{}))
+	assert.NoError(t, storage.Put(ctx, "docs/a.pdf", strings.NewReader("pdf"), cstorage.PutOptions{}))
+
+	body, err := storage.Get(ctx, "avatars/1.png")
+	if assert.NoError(t, err) {
+		data, _ := io.ReadAll(body)
+		_ = body.Close()
+
+		assert.Equal(t, "png-1", string(data))
+	}
+
+	body, err = storage.GetRange(ctx, "avatars/2.txt", 2, 3)
+	if assert.NoError(t, err) {
+		data, _ := io.ReadAll(body)
+		_ = body.Close()
+
+		assert.Equal(t, "xt-", string(data))
+	}
+
+	obj, err := storage.Stat(ctx, "avatars/1.png")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), obj.Size)
+	assert.Equal(t, "image/png", obj.ContentType)
+
+	objects, err := storage.List(ctx, "avatars/")
+	assert.NoError(t, err)
+
+	if assert.Len(t, objects, 2) {
+		assert.Equal(t, "avatars/1.png", objects[0].Key)
+		assert.Equal(t, "avatars/2.txt", objects[1].Key)
+		assert.Equal(t, "text/plain; charset=utf-8", objects[1].ContentType)
+	}
+
+	assert.NoError(t, storage.Delete(ctx, "avatars/1.png"))
+	assert.NoError(t, storage.Delete(ctx, "avatars/1.png"))
+
+	_, err = storage.Get(ctx, "avatars/1.png")
+	assert.True(t, errors.Is(err, cstorage.ErrNotFound))
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../b", "a//b", ".cstorage/x"} {
+		assert.Error(t, storage.Put(ctx, key, strings.NewReader(""), cstorage.PutOptions{}), key)
+	}
+}
+
+func TestRouter_Presigned(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		storage = newLocalStorage(t)
+		router  = cstorage.NewRouter(cstorage.NewRouterParams{
+			Storage: storage,
+			Config:  cstorage.Config{Local: cstorage.ConfigLocal{BaseURL: "/storage"}},
+			Logger:  clogger.NewNoop(),
+		})
+		server = httptest.NewServer(chttp.NewHandler(chttp.NewHandlerParams{
+			Routers: []chttp.Router{router},
+			Logger:  clogger.NewNoop(),
+		}))
+	)
+	defer server.Close()
+
+	putURL, err := storage.PresignPut(ctx, "videos/intro.mp4", cstorage.PresignOptions{ContentType: "video/mp4"})
+	assert.NoError(t, err)
+
+	// the content type must match the one that was signed
+	req, _ := http.NewRequest(http.MethodPut, server.URL+putURL, strings.NewReader("0123456789"))
+	req.Header.Set("Content-Type", "text/plain")
+
+	resp, err := http.DefaultClient.Do(req)
+	if assert.NoError(t, err) {
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+
+	req, _ = http.NewRequest(http.MethodPut, server.URL+putURL, strings.NewReader("0123456789"))
+	req.Header.Set("Content-Type", "video/mp4")
+
+	resp, err = http.DefaultClient.Do(req)
+	if assert.NoError(t, err) {
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	getURL, err := storage.PresignGet(ctx, "videos/intro.mp4", cstorage.PresignOptions{})
+	assert.NoError(t, err)
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+getURL, nil)
+	req.Header.Set("Range", "bytes=2-5")
+
+	resp, err = http.DefaultClient.Do(req)
+	if assert.NoError(t, err) {
+		data, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+		assert.Equal(t, "video/mp4", resp.Header.Get("Content-Type"))
+		assert.Equal(t, "bytes 2-5/10", resp.Header.Get("Content-Range"))
+		assert.Equal(t, "2345", string(data))
+	}
+
+	// the signature is bound to the method and key
+	for _, u := range []string{
+		strings.Replace(getURL, "intro", "other", 1),
+		strings.Replace(getURL, "signature=", "signature=00", 1),
+	} {
+		resp, err = http.Get(server.URL + u) //nolint:noctx
+		if assert.NoError(t, err) {
+			_ = resp.Body.Close()
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		}
+	}
+
+	req, _ = http.NewRequest(http.MethodPut, server.URL+getURL, bytes.NewReader(nil))
+
+	resp, err = http.DefaultClient.Do(req)
+	if assert.NoError(t, err) {
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	}
+}
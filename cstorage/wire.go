@@ -0,0 +1,14 @@
+package cstorage
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+
+	NewStorage,
+	wire.Struct(new(NewStorageParams), "*"),
+
+	NewRouter,
+	wire.Struct(new(NewRouterParams), "*"),
+)
@@ -0,0 +1,60 @@
+package ctasks
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/cqueue"
+)
+
+const (
+	defaultBasePath    = "/api/tasks"
+	defaultTTL         = 24 * time.Hour
+	defaultMaxAttempts = 3
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "ctasks",
+		Description: "ctasks configures long-running tasks",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("ctasks", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load ctasks config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Queue:       cqueue.DefaultQueue,
+		BasePath:    defaultBasePath,
+		TTL:         defaultTTL,
+		MaxAttempts: defaultMaxAttempts,
+	}
+}
+
+// Config configures ctasks. For example:
+//
+//	[ctasks]
+//	queue = "tasks"
+//	ttl = "72h"
+type Config struct {
+	Queue    string `toml:"queue" doc:"cqueue queue that runs the tasks"`
+	BasePath string `toml:"base_path" doc:"Path prefix of the task status endpoint"`
+
+	// TTL is how long a completed task can be polled. Expired tasks are purged by RetentionPolicy.
+	TTL time.Duration `toml:"ttl" doc:"How long completed tasks are kept"`
+
+	// MaxAttempts is the number of times a task that fails is run before it is marked as failed
+	MaxAttempts int `toml:"max_attempts" doc:"Number of times a failing task is run"`
+}
@@ -0,0 +1,241 @@
+package ctasks_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cqueue"
+	"github.com/gocopper/copper/cqueue/cqueuetest"
+	"github.com/gocopper/copper/csql"
+	"github.com/gocopper/copper/csql/csqltest"
+	"github.com/gocopper/copper/ctasks"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+type exportPayload struct {
+	Rows int
+}
+
+type testTasks struct {
+	db     *sql.DB
+	tasks  *ctasks.Tasks
+	worker *cqueue.Worker
+	server *httptest.Server
+}
+
+func newTestTasks(t *testing.T) *testTasks {
+	t.Helper()
+
+	testDB := csqltest.NewDB(t, csqltest.Options{Migrations: csql.Migrations(ctasks.Migrations)})
+
+	var (
+		db        = testDB.DB
+		sqlConfig = testDB.Config
+	)
+
+	var (
+		logger      = clogger.NewNoop()
+		rw          = chttp.NewReaderWriter(nil, chttp.Config{}, logger)
+		config      = ctasks.Config{Queue: cqueue.DefaultQueue, BasePath: "/api/tasks", TTL: time.Hour, MaxAttempts: 2}
+		queueConfig = cqueue.Config{JobTimeout: time.Minute}
+		backend     = cqueuetest.NewBackend()
+		queue       = cqueue.NewQueue(cqueue.NewQueueParams{Backend: backend, Config: queueConfig})
+		tasks       = ctasks.NewTasks(ctasks.NewTasksParams{
+			DB:        db,
+			Queries:   ctasks.NewQueries(testDB.Querier),
+			Queue:     queue,
+			RW:        rw,
+			Config:    config,
+			SQLConfig: sqlConfig,
+			Logger:    logger,
+		})
+		router = ctasks.NewRouter(ctasks.NewRouterParams{
+			Tasks: tasks,
+			Owners: ctasks.OwnersFunc(func(r *http.Request) (string, error) {
+				return r.Header.Get("X-User"), nil
+			}),
+			RW:     rw,
+			Config: config,
+			Logger: logger,
+		})
+	)
+
+	server := httptest.NewServer(chttp.NewHandler(chttp.NewHandlerParams{
+		Routers:           []chttp.Router{router},
+		GlobalMiddlewares: []chttp.Middleware{csql.NewTxMiddleware(db, sqlConfig, logger)},
+		Logger:            logger,
+	}))
+	t.Cleanup(server.Close)
+
+	return &testTasks{
+		db:    db,
+		tasks: tasks,
+		worker: cqueue.NewWorker(cqueue.NewWorkerParams{
+			Queue:     queue,
+			Backend:   backend,
+			Lifecycle: clifecycle.New(),
+			Config:    queueConfig,
+			Logger:    logger,
+		}),
+		server: server,
+	}
+}
+
+func (tt *testTasks) start(t *testing.T, taskType string, payload interface{}, opts ctasks.StartOptions) *ctasks.Task {
+	t.Helper()
+
+	ctx, tx, err := csql.CtxWithTx(context.Background(), tt.db, "sqlite3")
+	assert.NoError(t, err)
+
+	task, err := tt.tasks.Start(ctx, taskType, payload, opts)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+
+	return task
+}
+
+func (tt *testTasks) process(t *testing.T) {
+	t.Helper()
+
+	_, err := tt.worker.ProcessPending(context.Background(), cqueue.DefaultQueue)
+	assert.NoError(t, err)
+}
+
+func (tt *testTasks) poll(t *testing.T, id, user string) (int, ctasks.TaskResponse) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, tt.server.URL+"/api/tasks/"+id, nil)
+	assert.NoError(t, err)
+
+	req.Header.Set("X-User", user)
+
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return 0, ctasks.TaskResponse{}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var body ctasks.TaskResponse
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+
+	return resp.StatusCode, body
+}
+
+func TestTasks_Succeeded(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tt       = newTestTasks(t)
+		progress ctasks.TaskResponse
+	)
+
+	ctasks.Register(tt.tasks, "export", func(ctx context.Context, r *ctasks.Reporter, p exportPayload) (interface{}, error) {
+		assert.NoError(t, r.Progress(ctx, 40, "Exported 4 rows"))
+
+		// the progress is visible while the task runs
+		_, progress = tt.poll(t, r.TaskID(), "")
+
+		return map[string]int{"rows": p.Rows}, nil
+	})
+
+	task := tt.start(t, "export", exportPayload{Rows: 10}, ctasks.StartOptions{})
+	assert.Equal(t, ctasks.StatusPending, task.Status)
+
+	status, body := tt.poll(t, task.ID, "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, ctasks.StatusPending, body.Status)
+	assert.Equal(t, "/api/tasks/"+task.ID, body.URL)
+
+	tt.process(t)
+
+	assert.Equal(t, ctasks.StatusRunning, progress.Status)
+	assert.Equal(t, 40, progress.Progress)
+	assert.Equal(t, "Exported 4 rows", progress.Message)
+
+	status, body = tt.poll(t, task.ID, "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, ctasks.StatusSucceeded, body.Status)
+	assert.Equal(t, 100, body.Progress)
+	assert.JSONEq(t, `{"rows": 10}`, string(body.Result))
+	assert.NotNil(t, body.CompletedAt)
+	assert.NotNil(t, body.ExpiresAt)
+}
+
+func TestTasks_Failed(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tt       = newTestTasks(t)
+		attempts int
+	)
+
+	ctasks.Register(tt.tasks, "export", func(ctx context.Context, r *ctasks.Reporter, p exportPayload) (interface{}, error) {
+		attempts++
+		return nil, errors.New("export failed")
+	})
+
+	task := tt.start(t, "export", exportPayload{}, ctasks.StartOptions{})
+
+	// the error is not shown while the task is retried
+	tt.process(t)
+
+	_, body := tt.poll(t, task.ID, "")
+	assert.Equal(t, ctasks.StatusPending, body.Status)
+	assert.Empty(t, body.Error)
+
+	tt.process(t)
+
+	_, body = tt.poll(t, task.ID, "")
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, ctasks.StatusFailed, body.Status)
+	assert.Equal(t, "export failed", body.Error)
+	assert.Empty(t, body.Result)
+}
+
+func TestTasks_Expired(t *testing.T) {
+	t.Parallel()
+
+	tt := newTestTasks(t)
+
+	ctasks.Register(tt.tasks, "export", func(ctx context.Context, r *ctasks.Reporter, p exportPayload) (interface{}, error) {
+		return nil, nil
+	})
+
+	task := tt.start(t, "export", exportPayload{}, ctasks.StartOptions{TTL: time.Nanosecond})
+	tt.process(t)
+
+	time.Sleep(time.Millisecond)
+
+	status, _ := tt.poll(t, task.ID, "")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _ = tt.poll(t, "unknown", "")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestRouter_Owner(t *testing.T) {
+	t.Parallel()
+
+	tt := newTestTasks(t)
+	task := tt.start(t, "export", exportPayload{}, ctasks.StartOptions{OwnerID: "alice"})
+
+	status, _ := tt.poll(t, task.ID, "bob")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _ = tt.poll(t, task.ID, "")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, body := tt.poll(t, task.ID, "alice")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, task.ID, body.ID)
+}
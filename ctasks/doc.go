@@ -0,0 +1,4 @@
+// Package ctasks runs long operations started by HTTP handlers (ex. exports or imports) in the background using
+// cqueue. Starting a task saves a task record that the work updates with its progress and result, and clients poll
+// the record at /api/tasks/{id} until the task completes. Completed tasks expire after ctasks.ttl.
+package ctasks
@@ -0,0 +1,21 @@
+-- +migrate Up
+create table ctasks_tasks (
+    id varchar(64) primary key,
+    type varchar(255) not null,
+    status varchar(32) not null,
+    owner_id varchar(255) not null default '',
+    progress integer not null default 0,
+    message text not null,
+    result mediumtext not null,
+    error text not null,
+    started_at datetime(6) null,
+    completed_at datetime(6) null,
+    expires_at datetime(6) null,
+    created_at datetime(6) not null,
+    updated_at datetime(6) not null
+);
+
+create index ctasks_tasks_expires_idx on ctasks_tasks (expires_at);
+
+-- +migrate Down
+drop table ctasks_tasks;
@@ -0,0 +1,21 @@
+-- +migrate Up
+create table ctasks_tasks (
+    id text primary key,
+    type text not null,
+    status text not null,
+    owner_id text not null default '',
+    progress integer not null default 0,
+    message text not null default '',
+    result text not null default '',
+    error text not null default '',
+    started_at timestamp null,
+    completed_at timestamp null,
+    expires_at timestamp null,
+    created_at timestamp not null,
+    updated_at timestamp not null
+);
+
+create index ctasks_tasks_expires_idx on ctasks_tasks (expires_at);
+
+-- +migrate Down
+drop table ctasks_tasks;
@@ -0,0 +1,61 @@
+package ctasks
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"time"
+)
+
+// Task statuses
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Task is a long operation started using Tasks.Start along with its progress and result
+type Task struct {
+	ID     string `db:"id"`
+	Type   string `db:"type"`
+	Status string `db:"status"`
+
+	// OwnerID is the user that started the task. Tasks with an owner can only be polled by their owner.
+	OwnerID string `db:"owner_id"`
+
+	// Progress is the percentage of the work that is done and Message describes it (ex. "Exported 500 of 1000 rows")
+	Progress int    `db:"progress"`
+	Message  string `db:"message"`
+
+	// Result is the JSON encoding of the value returned by the task's handler
+	Result string `db:"result"`
+
+	// Error is the error of the task's last failed attempt
+	Error string `db:"error"`
+
+	StartedAt   sql.NullTime `db:"started_at"`
+	CompletedAt sql.NullTime `db:"completed_at"`
+	ExpiresAt   sql.NullTime `db:"expires_at"`
+	CreatedAt   time.Time    `db:"created_at"`
+	UpdatedAt   time.Time    `db:"updated_at"`
+}
+
+// Done returns true if the task succeeded or failed
+func (t *Task) Done() bool {
+	return t.Status == StatusSucceeded || t.Status == StatusFailed
+}
+
+// Expired returns true if the task completed more than ctasks.ttl ago
+func (t *Task) Expired(now time.Time) bool {
+	return t.ExpiresAt.Valid && !now.Before(t.ExpiresAt.Time)
+}
+
+func newID() string {
+	const idBytes = 16
+
+	b := make([]byte, idBytes)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,85 @@
+package ctasks
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"time"
+
+	"github.com/gocopper/copper/csql"
+)
+
+// Migrations holds the database schema needed for ctasks. Register them using csql.RegisterMigrations so that they are
+// applied by csql.Migrator along with the app's migrations:
+//
+//	csql.RegisterMigrations(csql.ModuleMigrations{Module: "ctasks", FS: ctasks.Migrations})
+//
+// MySQL uses the variant in migrations.mysql.sql (see csql.ModuleMigrations).
+//
+//go:embed migrations.sql migrations.mysql.sql
+var Migrations embed.FS
+
+// ErrNotFound is returned when a task does not exist
+var ErrNotFound = errors.New("not found")
+
+// NewQueries creates a new Queries
+func NewQueries(querier csql.Querier) *Queries {
+	return &Queries{querier: querier}
+}
+
+// Queries holds the database queries for tasks
+type Queries struct {
+	querier csql.Querier
+}
+
+// InsertTask saves a new task
+func (q *Queries) InsertTask(ctx context.Context, t *Task) error {
+	const query = `
+	insert into ctasks_tasks (id, type, status, owner_id, progress, message, result, error, started_at, completed_at,
+		expires_at, created_at, updated_at)
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := q.querier.Exec(ctx, query, t.ID, t.Type, t.Status, t.OwnerID, t.Progress, t.Message, t.Result, t.Error,
+		t.StartedAt, t.CompletedAt, t.ExpiresAt, t.CreatedAt, t.UpdatedAt)
+
+	return err
+}
+
+// UpdateTask saves the status, progress, and result of a task
+func (q *Queries) UpdateTask(ctx context.Context, t *Task) error {
+	const query = `
+	update ctasks_tasks
+	set status = ?, progress = ?, message = ?, result = ?, error = ?, started_at = ?, completed_at = ?,
+		expires_at = ?, updated_at = ?
+	where id = ?`
+
+	_, err := q.querier.Exec(ctx, query, t.Status, t.Progress, t.Message, t.Result, t.Error, t.StartedAt,
+		t.CompletedAt, t.ExpiresAt, t.UpdatedAt, t.ID)
+
+	return err
+}
+
+// UpdateProgress saves the progress of a running task
+func (q *Queries) UpdateProgress(ctx context.Context, id string, progress int, message string, now time.Time) error {
+	const query = `
+	update ctasks_tasks
+	set progress = ?, message = ?, updated_at = ?
+	where id = ? and status = ?`
+
+	_, err := q.querier.Exec(ctx, query, progress, message, now, id, StatusRunning)
+
+	return err
+}
+
+// GetTask returns the task with the given id
+func (q *Queries) GetTask(ctx context.Context, id string) (*Task, error) {
+	var t Task
+
+	err := q.querier.Get(ctx, &t, `select * from ctasks_tasks where id = ?`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+
+	return &t, err
+}
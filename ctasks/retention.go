@@ -0,0 +1,16 @@
+package ctasks
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cretention"
+)
+
+// RetentionPolicy purges the tasks that expired, which are no longer returned by Tasks.Get, an hour after they
+// expire. Apps that use ctasks can register it using cretention.Register.
+var RetentionPolicy = cretention.Policy{ //nolint:gochecknoglobals
+	Name:        "ctasks_tasks",
+	Description: "Expired tasks",
+	MaxAge:      time.Hour,
+	Purge:       cretention.DeleteRows("ctasks_tasks", "id", "expires_at < :before"),
+}
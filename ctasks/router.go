@@ -0,0 +1,168 @@
+package ctasks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+)
+
+// Owners identifies the user that polls a task so that tasks started with StartOptions.OwnerID are only visible to
+// their owner. It must be implemented by the app.
+type Owners interface {
+	// OwnerID returns the id of the user that made the request or an empty string if the request is anonymous
+	OwnerID(r *http.Request) (string, error)
+}
+
+// OwnersFunc is an adapter to use a func as Owners
+type OwnersFunc func(r *http.Request) (string, error)
+
+// OwnerID implements Owners
+func (f OwnersFunc) OwnerID(r *http.Request) (string, error) {
+	return f(r)
+}
+
+// TaskResponse is the JSON representation of a Task that is served to clients
+type TaskResponse struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Status      string          `json:"status"`
+	Progress    int             `json:"progress"`
+	Message     string          `json:"message,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	URL         string          `json:"url"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time      `json:"expires_at,omitempty"`
+}
+
+func (t *Tasks) response(task *Task) TaskResponse {
+	resp := TaskResponse{
+		ID:        task.ID,
+		Type:      task.Type,
+		Status:    task.Status,
+		Progress:  task.Progress,
+		Message:   task.Message,
+		URL:       t.StatusPath(task.ID),
+		CreatedAt: task.CreatedAt,
+		UpdatedAt: task.UpdatedAt,
+	}
+
+	if task.Result != "" {
+		resp.Result = json.RawMessage(task.Result)
+	}
+
+	// errors of attempts that will be retried are not shown since the task may still succeed
+	if task.Status == StatusFailed {
+		resp.Error = task.Error
+	}
+
+	if task.CompletedAt.Valid {
+		resp.CompletedAt = &task.CompletedAt.Time
+	}
+
+	if task.ExpiresAt.Valid {
+		resp.ExpiresAt = &task.ExpiresAt.Time
+	}
+
+	return resp
+}
+
+// NewRouterParams holds the params needed for NewRouter
+type NewRouterParams struct {
+	Tasks  *Tasks
+	Owners Owners
+	RW     *chttp.ReaderWriter
+	Config Config
+	Logger clogger.Logger
+}
+
+// NewRouter creates a new Router
+func NewRouter(p NewRouterParams) *Router {
+	return &Router{
+		tasks:  p.Tasks,
+		owners: p.Owners,
+		rw:     p.RW,
+		config: p.Config,
+		logger: p.Logger.With(clogger.Module("ctasks")),
+	}
+}
+
+// Router is a chttp.Router that serves the status of tasks (paths are relative to ctasks.base_path):
+//
+//	GET /{id}  responds with the task's status, progress and, once it succeeds, its result
+//
+// Tasks that have expired or belong to another user respond with a 404.
+type Router struct {
+	tasks  *Tasks
+	owners Owners
+	rw     *chttp.ReaderWriter
+	config Config
+	logger clogger.Logger
+}
+
+// Routes implements chttp.Router
+func (ro *Router) Routes() []chttp.Route {
+	return []chttp.Route{
+		{
+			Path:    ro.config.BasePath + "/{id}",
+			Methods: []string{http.MethodGet},
+			Handler: ro.HandleGetTask,
+		},
+	}
+}
+
+// HandleGetTask responds with the status of the task in the id URL param
+func (ro *Router) HandleGetTask(w http.ResponseWriter, r *http.Request) {
+	task, err := ro.tasks.Get(r.Context(), chttp.URLParams(r)["id"])
+	if errors.Is(err, ErrNotFound) {
+		ro.writeNotFound(w)
+		return
+	}
+
+	if err != nil {
+		ro.logger.Error("Failed to get task", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if task.OwnerID != "" {
+		if ro.owners == nil {
+			ro.writeNotFound(w)
+			return
+		}
+
+		ownerID, err := ro.owners.OwnerID(r)
+		if err != nil {
+			ro.logger.Error("Failed to get task owner", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if ownerID != task.OwnerID {
+			ro.writeNotFound(w)
+			return
+		}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	ro.rw.WriteJSON(w, chttp.WriteJSONParams{
+		StatusCode: http.StatusOK,
+		Data:       ro.tasks.response(task),
+	})
+}
+
+func (ro *Router) writeNotFound(w http.ResponseWriter) {
+	ro.rw.WriteJSON(w, chttp.WriteJSONParams{
+		StatusCode: http.StatusNotFound,
+		Data:       map[string]string{"error": "task not found"},
+	})
+}
@@ -0,0 +1,348 @@
+package ctasks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cqueue"
+	"github.com/gocopper/copper/csql"
+)
+
+// jobTypePrefix prefixes the task type to get the type of the cqueue jobs that run the tasks
+const jobTypePrefix = "ctasks:"
+
+// HandlerFunc runs a task. Its result is encoded as JSON and returned to the clients that poll the task. If it returns
+// an error, the task is retried until it runs out of attempts (see ctasks.max_attempts), after which it fails.
+type HandlerFunc[T any] func(ctx context.Context, r *Reporter, payload T) (interface{}, error)
+
+// Register registers fn as the handler of the tasks of the given type. The payload of each task is decoded into a T
+// before fn is called. Since tasks are run by cqueue.Worker, the handlers must be registered in the process that
+// runs the worker. For example:
+//
+//	type ExportOrders struct {
+//		Since time.Time
+//	}
+//
+//	ctasks.Register(tasks, "export_orders", func(ctx context.Context, r *ctasks.Reporter, p ExportOrders) (interface{}, error) {
+//		..
+//		_ = r.Progress(ctx, 50, "Exported 500 of 1000 orders")
+//		..
+//		return map[string]string{"url": url}, nil
+//	})
+func Register[T any](t *Tasks, taskType string, fn HandlerFunc[T]) {
+	t.queue.Handle(jobTypePrefix+taskType, func(ctx context.Context, job *cqueue.Job) error {
+		var tj taskJob
+
+		err := job.Decode(&tj)
+		if err != nil {
+			return err
+		}
+
+		return t.run(ctx, job, tj, func(ctx context.Context, r *Reporter) (interface{}, error) {
+			var payload T
+
+			err := json.Unmarshal(tj.Payload, &payload)
+			if err != nil {
+				return nil, cerrors.New(err, "failed to decode task payload", nil)
+			}
+
+			return fn(ctx, r, payload)
+		})
+	})
+}
+
+// StartOptions configures a task started using Tasks.Start
+type StartOptions struct {
+	// OwnerID is the user that started the task. If it is set, only the owner can poll the task (see Owners).
+	OwnerID string
+
+	// TTL is how long the task can be polled once it completes (default: ctasks.ttl)
+	TTL time.Duration
+}
+
+// taskJob is the payload of the cqueue job that runs a task
+type taskJob struct {
+	TaskID  string          `json:"task_id"`
+	TTL     time.Duration   `json:"ttl,omitempty"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// NewTasksParams holds the params needed for NewTasks
+type NewTasksParams struct {
+	DB        *sql.DB
+	Queries   *Queries
+	Queue     *cqueue.Queue
+	RW        *chttp.ReaderWriter
+	Config    Config
+	SQLConfig csql.Config
+	Logger    clogger.Logger
+}
+
+// NewTasks creates a new Tasks
+func NewTasks(p NewTasksParams) *Tasks {
+	return &Tasks{
+		db:      p.DB,
+		queries: p.Queries,
+		queue:   p.Queue,
+		rw:      p.RW,
+		config:  p.Config,
+		dialect: p.SQLConfig.Dialect,
+		logger:  p.Logger.With(clogger.Module("ctasks")),
+		now:     time.Now,
+	}
+}
+
+// Tasks starts long operations in the background and tracks their progress. A handler that starts a task responds
+// with a 202 and the task's status URL, which the client polls until the task is done. For example:
+//
+//	func (ro *Router) HandleExport(w http.ResponseWriter, r *http.Request) {
+//		task, err := ro.tasks.Start(r.Context(), "export_orders", ExportOrders{Since: since}, ctasks.StartOptions{
+//			OwnerID: user.ID,
+//		})
+//		..
+//		ro.tasks.WriteAccepted(w, task)
+//	}
+//
+// Start and Get must be called with a context that has a database transaction (see csql.CtxWithTx), such as the
+// context of a request that runs csql.TxMiddleware.
+type Tasks struct {
+	db      *sql.DB
+	queries *Queries
+	queue   *cqueue.Queue
+	rw      *chttp.ReaderWriter
+	config  Config
+	dialect string
+	logger  clogger.Logger
+	now     func() time.Time
+}
+
+// Start saves a pending task and enqueues the job that runs it. The payload is encoded as JSON and passed to the
+// handler registered for the task type.
+func (t *Tasks) Start(ctx context.Context, taskType string, payload interface{}, opts StartOptions) (*Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to encode task payload", map[string]interface{}{
+			"type": taskType,
+		})
+	}
+
+	now := t.now()
+	task := Task{
+		ID:        newID(),
+		Type:      taskType,
+		Status:    StatusPending,
+		OwnerID:   opts.OwnerID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err = t.queries.InsertTask(ctx, &task)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to insert task", map[string]interface{}{
+			"type": taskType,
+		})
+	}
+
+	_, err = t.queue.EnqueueWithOptions(ctx, jobTypePrefix+taskType, taskJob{
+		TaskID:  task.ID,
+		TTL:     opts.TTL,
+		Payload: data,
+	}, cqueue.EnqueueOptions{
+		Queue:       t.config.Queue,
+		MaxAttempts: t.config.MaxAttempts,
+	})
+	if err != nil {
+		return nil, cerrors.New(err, "failed to enqueue task", map[string]interface{}{
+			"type":   taskType,
+			"taskID": task.ID,
+		})
+	}
+
+	return &task, nil
+}
+
+// Get returns the task with the given id. It returns ErrNotFound if the task does not exist or has expired.
+func (t *Tasks) Get(ctx context.Context, id string) (*Task, error) {
+	task, err := t.queries.GetTask(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, cerrors.New(err, "failed to get task", map[string]interface{}{
+			"taskID": id,
+		})
+	}
+
+	if task.Expired(t.now()) {
+		return nil, ErrNotFound
+	}
+
+	return task, nil
+}
+
+// StatusPath returns the path that clients poll for the status of the task with the given id
+func (t *Tasks) StatusPath(id string) string {
+	return t.config.BasePath + "/" + id
+}
+
+// WriteAccepted responds with a 202 along with the task and its status path in the Location header
+func (t *Tasks) WriteAccepted(w http.ResponseWriter, task *Task) {
+	w.Header().Set("Location", t.StatusPath(task.ID))
+
+	t.rw.WriteJSON(w, chttp.WriteJSONParams{
+		StatusCode: http.StatusAccepted,
+		Data:       t.response(task),
+	})
+}
+
+// run runs a task's handler and saves its result. The result is saved even if the worker is stopping so that a
+// task that completed is not run again.
+func (t *Tasks) run(ctx context.Context, job *cqueue.Job, tj taskJob,
+	fn func(ctx context.Context, r *Reporter) (interface{}, error)) error {
+	var task *Task
+
+	err := t.inTx(ctx, func(ctx context.Context) error {
+		var err error
+
+		task, err = t.queries.GetTask(ctx, tj.TaskID)
+		if err != nil || task.Done() {
+			return err
+		}
+
+		now := t.now()
+
+		task.Status = StatusRunning
+		task.UpdatedAt = now
+
+		if !task.StartedAt.Valid {
+			task.StartedAt = sql.NullTime{Time: now, Valid: true}
+		}
+
+		return t.queries.UpdateTask(ctx, task)
+	})
+	if errors.Is(err, ErrNotFound) {
+		t.logger.WithTags(map[string]interface{}{
+			"taskID": tj.TaskID,
+		}).Warn("Skipped a task that no longer exists", nil)
+
+		return nil
+	}
+
+	if err != nil {
+		return cerrors.New(err, "failed to start task", map[string]interface{}{
+			"taskID": tj.TaskID,
+		})
+	}
+
+	if task.Done() {
+		return nil
+	}
+
+	result, runErr := fn(ctx, &Reporter{tasks: t, task: task})
+
+	var data []byte
+	if runErr == nil {
+		data, runErr = json.Marshal(result)
+		if runErr != nil {
+			runErr = cerrors.New(runErr, "failed to encode task result", nil)
+		}
+	}
+
+	now := t.now()
+	task.UpdatedAt = now
+
+	switch {
+	case runErr == nil:
+		task.Status = StatusSucceeded
+		task.Progress = 100
+		task.Result = string(data)
+		task.Error = ""
+		t.complete(task, tj.TTL, now)
+	case job.Attempts >= job.MaxAttempts:
+		task.Status = StatusFailed
+		task.Error = runErr.Error()
+		t.complete(task, tj.TTL, now)
+	default:
+		task.Status = StatusPending
+		task.Error = runErr.Error()
+	}
+
+	err = t.inTx(context.Background(), func(ctx context.Context) error {
+		return t.queries.UpdateTask(ctx, task)
+	})
+	if err != nil {
+		return cerrors.New(err, "failed to save task result", map[string]interface{}{
+			"taskID": task.ID,
+		})
+	}
+
+	return runErr
+}
+
+func (t *Tasks) complete(task *Task, ttl time.Duration, now time.Time) {
+	if ttl <= 0 {
+		ttl = t.config.TTL
+	}
+
+	task.CompletedAt = sql.NullTime{Time: now, Valid: true}
+	task.ExpiresAt = sql.NullTime{Time: now.Add(ttl), Valid: true}
+}
+
+func (t *Tasks) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, tx, err := csql.CtxWithTx(ctx, t.db, t.dialect)
+	if err != nil {
+		return err
+	}
+
+	err = fn(ctx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Reporter saves the progress of a running task so that the clients that poll the task can show it
+type Reporter struct {
+	tasks *Tasks
+	task  *Task
+}
+
+// TaskID returns the id of the running task
+func (r *Reporter) TaskID() string {
+	return r.task.ID
+}
+
+// Progress saves the percentage (0-100) of the task's work that is done along with a message that describes it. It
+// runs in its own database transaction so that the progress is visible while the task runs.
+func (r *Reporter) Progress(ctx context.Context, percent int, message string) error {
+	if percent < 0 {
+		percent = 0
+	}
+
+	if percent > 100 { //nolint:gomnd
+		percent = 100
+	}
+
+	r.task.Progress, r.task.Message = percent, message
+
+	err := r.tasks.inTx(ctx, func(ctx context.Context) error {
+		return r.tasks.queries.UpdateProgress(ctx, r.task.ID, percent, message, r.tasks.now())
+	})
+	if err != nil {
+		return cerrors.New(err, "failed to save task progress", map[string]interface{}{
+			"taskID": r.task.ID,
+		})
+	}
+
+	return nil
+}
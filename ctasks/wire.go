@@ -0,0 +1,16 @@
+package ctasks
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup. It needs the app's Owners and a *cqueue.Queue (see
+// cqueue.WireModule).
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+	NewQueries,
+
+	NewTasks,
+	wire.Struct(new(NewTasksParams), "*"),
+
+	NewRouter,
+	wire.Struct(new(NewRouterParams), "*"),
+)
@@ -0,0 +1,72 @@
+package ctenant
+
+import (
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Resolvers are valid options for the ctenant.resolvers configuration option.
+// Use "subdomain" to read the tenant from the request host (ex. acme.example.com with base_domain example.com).
+// Use "header" to read the tenant from a request header (ex. X-Tenant-ID).
+// Use "membership" to use the user's default tenant returned by Membership.
+const (
+	ResolverSubdomain  = "subdomain"
+	ResolverHeader     = "header"
+	ResolverMembership = "membership"
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "ctenant",
+		Description: "ctenant configures how the tenant of each request is resolved",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("ctenant", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load ctenant config", nil)
+	}
+
+	for _, resolver := range config.Resolvers {
+		if resolver != ResolverSubdomain && resolver != ResolverHeader && resolver != ResolverMembership {
+			return Config{}, cerrors.New(nil, "invalid tenant resolver", map[string]interface{}{
+				"resolver": resolver,
+			})
+		}
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Resolvers:  []string{ResolverHeader},
+		Header:     "X-Tenant-ID",
+		Required:   true,
+		RLSSetting: "app.tenant_id",
+	}
+}
+
+// Config configures the ctenant module
+type Config struct {
+	// Resolvers are tried in order until one of them resolves the tenant
+	Resolvers []string `toml:"resolvers" doc:"Tenant resolvers tried in order: subdomain, header, or membership"`
+
+	// Header is the request header read by the header resolver
+	Header string `toml:"header" doc:"Request header read by the header resolver"`
+
+	// BaseDomain is the domain whose subdomains are tenants (ex. example.com). It is required by the subdomain
+	// resolver.
+	BaseDomain string `toml:"base_domain" doc:"Domain whose subdomains are tenants (ex. example.com)"`
+
+	// Required rejects requests whose tenant cannot be resolved. If false, such requests are served without a tenant.
+	Required bool `toml:"required" doc:"Reject requests whose tenant cannot be resolved"`
+
+	// RLSSetting is the Postgres setting that RLSMiddleware sets to the tenant id
+	RLSSetting string `toml:"rls_setting" doc:"Postgres setting read by row-level security policies"`
+}
@@ -0,0 +1,97 @@
+package ctenant
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/csql"
+)
+
+// Scope adds a condition on the tenant column to a where clause so that the query only matches the rows of the
+// context's tenant. It can be used with csql.Repo or with Querier. For example:
+//
+//	where, args, err := ctenant.Scope(ctx, "tenant_id", "status = ?", "active")
+//	if err != nil {
+//		return nil, err
+//	}
+//
+//	return projects.List(ctx, where, args...)
+//
+// It returns an error if the context does not have a tenant so that queries are never run unscoped by accident.
+func Scope(ctx context.Context, column, where string, args ...interface{}) (string, []interface{}, error) {
+	tenantID, err := RequireTenant(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	cond := column + " = ?"
+	if where != "" {
+		cond = "(" + where + ") and " + cond
+	}
+
+	return cond, append(args, tenantID), nil
+}
+
+// SetRLS sets the given Postgres setting (ex. app.tenant_id) to the context's tenant for the rest of the context's
+// transaction. Row-level security policies can read it using current_setting. For example:
+//
+//	create policy tenant_isolation on projects
+//		using (tenant_id = current_setting('app.tenant_id'));
+func SetRLS(ctx context.Context, querier csql.Querier, setting string) error {
+	tenantID, err := RequireTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = querier.Exec(ctx, "SELECT set_config(?, ?, true)", setting, tenantID)
+	if err != nil {
+		return cerrors.New(err, "failed to set tenant for row-level security", map[string]interface{}{
+			"setting": setting,
+		})
+	}
+
+	return nil
+}
+
+// NewRLSMiddlewareParams holds the params needed for NewRLSMiddleware
+type NewRLSMiddlewareParams struct {
+	Querier csql.Querier
+	Config  Config
+	Logger  clogger.Logger
+}
+
+// NewRLSMiddleware creates a new RLSMiddleware
+func NewRLSMiddleware(p NewRLSMiddlewareParams) *RLSMiddleware {
+	return &RLSMiddleware{
+		querier: p.Querier,
+		setting: p.Config.RLSSetting,
+		logger:  p.Logger.With(clogger.Module("ctenant")),
+	}
+}
+
+// RLSMiddleware is a chttp.Middleware that sets ctenant.rls_setting to the request's tenant in the request's
+// database transaction (see SetRLS). It must run after csql.TxMiddleware and Middleware. Requests without a tenant
+// are served without the setting so that the policies match no rows.
+type RLSMiddleware struct {
+	querier csql.Querier
+	setting string
+	logger  clogger.Logger
+}
+
+// Handle implements the chttp.Middleware interface. See RLSMiddleware
+func (m *RLSMiddleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := TenantFromCtx(r.Context()); ok {
+			err := SetRLS(r.Context(), m.querier, m.setting)
+			if err != nil {
+				m.logger.Error("Failed to set tenant for row-level security", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,32 @@
+package ctenant_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gocopper/copper/ctenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScope(t *testing.T) {
+	t.Parallel()
+
+	ctx := ctenant.CtxWithTenant(context.Background(), "acme")
+
+	where, args, err := ctenant.Scope(ctx, "tenant_id", "status = ?", "active")
+	assert.NoError(t, err)
+	assert.Equal(t, "(status = ?) and tenant_id = ?", where)
+	assert.Equal(t, []interface{}{"active", "acme"}, args)
+
+	where, args, err = ctenant.Scope(ctx, "tenant_id", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "tenant_id = ?", where)
+	assert.Equal(t, []interface{}{"acme"}, args)
+}
+
+func TestScope_NoTenant(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := ctenant.Scope(context.Background(), "tenant_id", "")
+	assert.Error(t, err)
+}
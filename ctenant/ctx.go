@@ -0,0 +1,39 @@
+package ctenant
+
+import (
+	"context"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+)
+
+type ctxKey string
+
+const tenantCtxKey = ctxKey("ctenant/tenant-id")
+
+// CtxWithTenant returns a context that holds the given tenant id. The id is also added to the context's log fields.
+// Middleware uses it for each request, and background jobs can use it to restore the tenant of the work they run.
+func CtxWithTenant(ctx context.Context, tenantID string) context.Context {
+	ctx = context.WithValue(ctx, tenantCtxKey, tenantID)
+
+	return clogger.CtxWithFields(ctx, map[string]interface{}{
+		"tenantID": tenantID,
+	})
+}
+
+// TenantFromCtx returns the tenant id in the context and false if the context does not have a tenant
+func TenantFromCtx(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantCtxKey).(string)
+
+	return tenantID, ok && tenantID != ""
+}
+
+// RequireTenant returns the tenant id in the context or an error if the context does not have a tenant
+func RequireTenant(ctx context.Context) (string, error) {
+	tenantID, ok := TenantFromCtx(ctx)
+	if !ok {
+		return "", cerrors.New(nil, "no tenant in the context", nil)
+	}
+
+	return tenantID, nil
+}
@@ -0,0 +1,4 @@
+// Package ctenant provides row-level multi-tenancy. Middleware resolves the tenant of each request (by subdomain,
+// header, or the user's membership) and adds its id to the request context. Queries are scoped to the tenant using
+// Scope, or by Postgres row-level security policies that read the tenant id set by RLSMiddleware.
+package ctenant
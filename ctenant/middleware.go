@@ -0,0 +1,159 @@
+package ctenant
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clogger"
+)
+
+var errNotMember = errors.New("user is not a member of the tenant")
+
+// Membership checks which tenants the user of a request belongs to. Apps implement it using their own users (ex.
+// a memberships table) and read the user from the request context set by their auth middleware.
+type Membership interface {
+	// DefaultTenant returns the tenant used for the request when it is resolved by membership. It returns an empty
+	// string if the user does not belong to any tenant.
+	DefaultTenant(ctx context.Context, r *http.Request) (string, error)
+
+	// IsMember returns true if the user of the request belongs to the given tenant. It is used to verify tenants
+	// resolved by subdomain or header so that users cannot access other tenants by changing them.
+	IsMember(ctx context.Context, r *http.Request, tenantID string) (bool, error)
+}
+
+// AllowAll is a Membership that allows all requests to access any tenant. It can be used by apps whose tenants are
+// not tied to users (ex. a public site per tenant).
+type AllowAll struct{}
+
+// DefaultTenant implements Membership. It never resolves a tenant.
+func (AllowAll) DefaultTenant(ctx context.Context, r *http.Request) (string, error) {
+	return "", nil
+}
+
+// IsMember implements Membership. It always returns true.
+func (AllowAll) IsMember(ctx context.Context, r *http.Request, tenantID string) (bool, error) {
+	return true, nil
+}
+
+// NewMiddlewareParams holds the params needed for NewMiddleware
+type NewMiddlewareParams struct {
+	Config     Config
+	Membership Membership
+	Logger     clogger.Logger
+}
+
+// NewMiddleware creates a new Middleware
+func NewMiddleware(p NewMiddlewareParams) *Middleware {
+	return &Middleware{
+		config:     p.Config,
+		membership: p.Membership,
+		logger:     p.Logger.With(clogger.Module("ctenant")),
+	}
+}
+
+// Middleware is a chttp.Middleware that resolves the tenant of each request using the configured resolvers and adds
+// it to the request context (see TenantFromCtx). Tenants resolved by subdomain or header are verified using
+// Membership. Requests whose tenant cannot be resolved are rejected with a 404 if a tenant is required, and requests
+// by users that do not belong to the tenant are rejected with a 403.
+// It should run after the app's auth middleware so that Membership can read the user.
+type Middleware struct {
+	config     Config
+	membership Membership
+	logger     clogger.Logger
+}
+
+// Handle implements the chttp.Middleware interface. See Middleware
+func (m *Middleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID, err := m.resolve(r)
+		if errors.Is(err, errNotMember) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if err != nil {
+			m.logger.Error("Failed to resolve tenant", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if tenantID == "" {
+			if m.config.Required {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(CtxWithTenant(r.Context(), tenantID)))
+	})
+}
+
+// resolve returns the tenant of the request or an empty string if it cannot be resolved. It returns errNotMember if
+// the user does not belong to the resolved tenant.
+func (m *Middleware) resolve(r *http.Request) (string, error) {
+	for _, resolver := range m.config.Resolvers {
+		var tenantID string
+
+		switch resolver {
+		case ResolverSubdomain:
+			tenantID = subdomain(r.Host, m.config.BaseDomain)
+		case ResolverHeader:
+			tenantID = strings.TrimSpace(r.Header.Get(m.config.Header))
+		case ResolverMembership:
+			// the membership's default tenant does not need to be verified
+			return m.membership.DefaultTenant(r.Context(), r)
+		}
+
+		if tenantID == "" {
+			continue
+		}
+
+		ok, err := m.membership.IsMember(r.Context(), r, tenantID)
+		if err != nil {
+			return "", cerrors.New(err, "failed to check tenant membership", map[string]interface{}{
+				"tenantID": tenantID,
+			})
+		}
+
+		if !ok {
+			m.logger.WithTags(map[string]interface{}{
+				"tenantID": tenantID,
+				"resolver": resolver,
+			}).Warn("Request is not allowed to access tenant", nil)
+
+			return "", errNotMember
+		}
+
+		return tenantID, nil
+	}
+
+	return "", nil
+}
+
+// subdomain returns the subdomain of host directly under baseDomain (ex. acme for acme.example.com)
+func subdomain(host, baseDomain string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	baseDomain = strings.ToLower(strings.Trim(baseDomain, "."))
+
+	if baseDomain == "" || !strings.HasSuffix(host, "."+baseDomain) {
+		return ""
+	}
+
+	sub := strings.TrimSuffix(host, "."+baseDomain)
+	if strings.Contains(sub, ".") || sub == "www" {
+		return ""
+	}
+
+	return sub
+}
@@ -0,0 +1,135 @@
+package ctenant_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/ctenant"
+	"github.com/stretchr/testify/assert"
+)
+
+type testMembership struct {
+	defaultTenant string
+	tenants       map[string]bool
+	err           error
+}
+
+func (m *testMembership) DefaultTenant(ctx context.Context, r *http.Request) (string, error) {
+	return m.defaultTenant, m.err
+}
+
+func (m *testMembership) IsMember(ctx context.Context, r *http.Request, tenantID string) (bool, error) {
+	return m.tenants[tenantID], m.err
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	membership := &testMembership{
+		defaultTenant: "globex",
+		tenants:       map[string]bool{"acme": true, "globex": true},
+	}
+
+	testCases := map[string]struct {
+		config     ctenant.Config
+		membership ctenant.Membership
+		host       string
+		header     string
+		wantStatus int
+		wantTenant string
+	}{
+		"header": {
+			config:     ctenant.Config{Resolvers: []string{"header"}, Header: "X-Tenant-ID", Required: true},
+			header:     "acme",
+			wantStatus: http.StatusOK,
+			wantTenant: "acme",
+		},
+		"subdomain": {
+			config: ctenant.Config{
+				Resolvers:  []string{"subdomain", "header"},
+				Header:     "X-Tenant-ID",
+				BaseDomain: "example.com",
+				Required:   true,
+			},
+			host:       "acme.example.com:8080",
+			header:     "globex",
+			wantStatus: http.StatusOK,
+			wantTenant: "acme",
+		},
+		"membership fallback": {
+			config:     ctenant.Config{Resolvers: []string{"subdomain", "membership"}, BaseDomain: "example.com"},
+			host:       "example.com",
+			wantStatus: http.StatusOK,
+			wantTenant: "globex",
+		},
+		"not a member": {
+			config:     ctenant.Config{Resolvers: []string{"header"}, Header: "X-Tenant-ID", Required: true},
+			header:     "initech",
+			wantStatus: http.StatusForbidden,
+		},
+		"missing and required": {
+			config:     ctenant.Config{Resolvers: []string{"header"}, Header: "X-Tenant-ID", Required: true},
+			wantStatus: http.StatusNotFound,
+		},
+		"missing and optional": {
+			config:     ctenant.Config{Resolvers: []string{"header"}, Header: "X-Tenant-ID"},
+			wantStatus: http.StatusOK,
+		},
+		"membership error": {
+			config:     ctenant.Config{Resolvers: []string{"header"}, Header: "X-Tenant-ID", Required: true},
+			membership: &testMembership{err: errors.New("test-err")},
+			header:     "acme",
+			wantStatus: http.StatusInternalServerError,
+		},
+		"allow all": {
+			config:     ctenant.Config{Resolvers: []string{"header"}, Header: "X-Tenant-ID", Required: true},
+			membership: ctenant.AllowAll{},
+			header:     "initech",
+			wantStatus: http.StatusOK,
+			wantTenant: "initech",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.membership == nil {
+				tc.membership = membership
+			}
+
+			var (
+				tenantID string
+				mw       = ctenant.NewMiddleware(ctenant.NewMiddlewareParams{
+					Config:     tc.config,
+					Membership: tc.membership,
+					Logger:     clogger.NewNoop(),
+				})
+				handler = mw.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					tenantID, _ = ctenant.TenantFromCtx(r.Context())
+				}))
+				req  = httptest.NewRequest(http.MethodGet, "/", nil)
+				resp = httptest.NewRecorder()
+			)
+
+			if tc.host != "" {
+				req.Host = tc.host
+			}
+
+			if tc.header != "" {
+				req.Header.Set("X-Tenant-ID", tc.header)
+			}
+
+			handler.ServeHTTP(resp, req)
+
+			assert.Equal(t, tc.wantStatus, resp.Code)
+			assert.Equal(t, tc.wantTenant, tenantID)
+		})
+	}
+}
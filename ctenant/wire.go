@@ -0,0 +1,13 @@
+package ctenant
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup. Apps must also provide a Membership, or bind AllowAll if their
+// tenants are not tied to users.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+	NewMiddleware,
+	NewRLSMiddleware,
+	wire.Struct(new(NewMiddlewareParams), "*"),
+	wire.Struct(new(NewRLSMiddlewareParams), "*"),
+)
@@ -0,0 +1,108 @@
+package cwebhook
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cconfig"
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	defaultMaxBodyBytes  = 1 << 20
+	defaultTolerance     = 5 * time.Minute
+	defaultDeliveryIDTTL = 24 * time.Hour
+
+	defaultDeliveryMaxAttempts  = 8
+	defaultDeliveryBaseBackoff  = 30 * time.Second
+	defaultDeliveryMaxBackoff   = 6 * time.Hour
+	defaultDeliveryPollInterval = 5 * time.Second
+	defaultDeliveryTimeout      = 10 * time.Second
+	defaultDeliveryBatchSize    = 10
+	defaultDeliveringTimeout    = 5 * time.Minute
+)
+
+func init() { //nolint:gochecknoinits
+	cconfig.Declare(cconfig.KeyDoc{
+		Key:         "cwebhook",
+		Description: "cwebhook configures incoming webhook verification and outgoing webhook delivery",
+		Default:     defaultConfig(),
+	})
+}
+
+// LoadConfig loads Config from app's config
+func LoadConfig(appConfig cconfig.Loader) (Config, error) {
+	config := defaultConfig()
+
+	err := appConfig.Load("cwebhook", &config)
+	if err != nil {
+		return Config{}, cerrors.New(err, "failed to load cwebhook config", nil)
+	}
+
+	return config, nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		MaxBodyBytes:  defaultMaxBodyBytes,
+		Tolerance:     defaultTolerance,
+		DeliveryIDTTL: defaultDeliveryIDTTL,
+		Delivery: ConfigDelivery{
+			MaxAttempts:       defaultDeliveryMaxAttempts,
+			BaseBackoff:       defaultDeliveryBaseBackoff,
+			MaxBackoff:        defaultDeliveryMaxBackoff,
+			PollInterval:      defaultDeliveryPollInterval,
+			Timeout:           defaultDeliveryTimeout,
+			BatchSize:         defaultDeliveryBatchSize,
+			DeliveringTimeout: defaultDeliveringTimeout,
+		},
+	}
+}
+
+// Config holds the params needed to configure the webhook Receiver, the built-in verifiers, and the outgoing
+// webhook DeliveryWorker.
+type Config struct {
+	// MaxBodyBytes is the max size of a webhook request body. Larger requests are rejected.
+	MaxBodyBytes int64 `toml:"max_body_bytes"`
+
+	// Tolerance is the max allowed age of a signed timestamp for providers that sign timestamps (Stripe, Slack).
+	// This protects against replay attacks.
+	Tolerance time.Duration `toml:"tolerance"`
+
+	// DeliveryIDTTL is how long delivery ids are remembered to de-duplicate retried deliveries.
+	DeliveryIDTTL time.Duration `toml:"delivery_id_ttl"`
+
+	Stripe ConfigSecret `toml:"stripe"`
+	GitHub ConfigSecret `toml:"github"`
+	Slack  ConfigSecret `toml:"slack"`
+
+	Delivery ConfigDelivery `toml:"delivery"`
+}
+
+// ConfigSecret holds the signing secret for a webhook provider
+type ConfigSecret struct {
+	Secret string `toml:"secret"`
+}
+
+// ConfigDelivery configures the delivery of outgoing webhooks
+type ConfigDelivery struct {
+	// MaxAttempts is the number of times a delivery is attempted before it is marked as failed
+	MaxAttempts int `toml:"max_attempts"`
+
+	// BaseBackoff is the wait time after the first failed attempt. It doubles after each failed attempt up to
+	// MaxBackoff.
+	BaseBackoff time.Duration `toml:"base_backoff"`
+	MaxBackoff  time.Duration `toml:"max_backoff"`
+
+	// PollInterval is how often the DeliveryWorker checks for pending deliveries
+	PollInterval time.Duration `toml:"poll_interval"`
+
+	// Timeout is the max duration of a single delivery attempt
+	Timeout time.Duration `toml:"timeout"`
+
+	// BatchSize is the max number of deliveries attempted in a single poll
+	BatchSize int `toml:"batch_size"`
+
+	// DeliveringTimeout is how long a delivery can stay in the delivering status before it is claimed again. This
+	// recovers deliveries whose worker stopped in the middle of an attempt. It should be longer than Timeout.
+	DeliveringTimeout time.Duration `toml:"delivering_timeout"`
+}
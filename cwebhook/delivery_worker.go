@@ -0,0 +1,285 @@
+package cwebhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/csql"
+)
+
+// Headers set on each outgoing webhook request. The signature header has the format t=<unix ts>,v1=<hex hmac> where
+// the HMAC-SHA256 is computed over "<unix ts>.<body>" using the subscription's secret.
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderEventID   = "X-Webhook-ID"
+	HeaderEventType = "X-Webhook-Event"
+)
+
+// NewDeliveryWorkerParams holds the params needed to create a DeliveryWorker
+type NewDeliveryWorkerParams struct {
+	DB        *sql.DB
+	Queries   *Queries
+	Lifecycle *clifecycle.Lifecycle
+	Config    Config
+	SQLConfig csql.Config
+	Logger    clogger.Logger
+}
+
+// NewDeliveryWorker creates a new DeliveryWorker
+func NewDeliveryWorker(p NewDeliveryWorkerParams) *DeliveryWorker {
+	return &DeliveryWorker{
+		db:      p.DB,
+		queries: p.Queries,
+		lc:      p.Lifecycle,
+		config:  p.Config.Delivery,
+		dialect: p.SQLConfig.Dialect,
+		logger:  p.Logger.With(clogger.Module("cwebhook")),
+		client:  &http.Client{Timeout: p.Config.Delivery.Timeout},
+		now:     time.Now,
+	}
+}
+
+// DeliveryWorker sends the deliveries queued by the Dispatcher. Failed deliveries are retried with exponential
+// backoff until Config.Delivery.MaxAttempts is reached.
+type DeliveryWorker struct {
+	db      *sql.DB
+	queries *Queries
+	lc      *clifecycle.Lifecycle
+	config  ConfigDelivery
+	dialect string
+	logger  clogger.Logger
+	client  *http.Client
+	now     func() time.Time
+}
+
+// Run starts polling for pending deliveries in the background. The worker stops when the app's lifecycle stops.
+func (w *DeliveryWorker) Run() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	w.lc.OnStop(func(stopCtx context.Context) error {
+		w.logger.Info("Stopping webhook delivery worker..")
+		cancel()
+
+		select {
+		case <-done:
+			return nil
+		case <-stopCtx.Done():
+			return stopCtx.Err()
+		}
+	})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(w.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			_, err := w.ProcessPending(ctx)
+			if err != nil {
+				w.logger.Error("Failed to process pending webhook deliveries", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stage implements clifecycle.Staged. The delivery worker starts once the migrations have run and the servers are listening.
+func (w *DeliveryWorker) Stage() clifecycle.Stage {
+	return clifecycle.Stage{
+		Name:  clifecycle.StageWorkers,
+		After: []string{clifecycle.StageMigrations, clifecycle.StageSeeds, clifecycle.StageHTTP},
+	}
+}
+
+// ProcessPending attempts a batch of pending deliveries that are due and returns the number of deliveries attempted.
+// Deliveries that fail to be attempted are logged and left to be claimed again once Config.Delivery.DeliveringTimeout
+// expires.
+func (w *DeliveryWorker) ProcessPending(ctx context.Context) (int, error) {
+	var deliveries []Delivery
+
+	err := w.inTx(ctx, func(ctx context.Context) error {
+		var (
+			err error
+			now = w.now()
+		)
+
+		deliveries, err = w.queries.ClaimPendingDeliveries(ctx, now, now.Add(-w.config.DeliveringTimeout),
+			w.config.BatchSize)
+
+		return err
+	})
+	if err != nil {
+		return 0, cerrors.New(err, "failed to claim pending deliveries", nil)
+	}
+
+	for i := range deliveries {
+		err = w.attempt(ctx, &deliveries[i])
+		if err != nil {
+			w.logger.WithTags(map[string]interface{}{
+				"deliveryID": deliveries[i].ID,
+			}).Error("Failed to attempt webhook delivery", err)
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// attempt sends a delivery and records the result. The request is sent outside of a database transaction so that
+// the worker does not hold a connection while it waits for the subscriber.
+func (w *DeliveryWorker) attempt(ctx context.Context, d *Delivery) error {
+	var subscription *Subscription
+
+	err := w.inTx(ctx, func(ctx context.Context) error {
+		var err error
+
+		subscription, err = w.queries.GetSubscription(ctx, d.SubscriptionID)
+
+		return err
+	})
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return cerrors.New(err, "failed to get subscription", map[string]interface{}{
+			"id": d.SubscriptionID,
+		})
+	}
+
+	var (
+		statusCode int
+		sendErr    error
+	)
+
+	if subscription == nil {
+		sendErr = cerrors.New(ErrNotFound, "subscription does not exist", map[string]interface{}{
+			"id": d.SubscriptionID,
+		})
+	} else {
+		statusCode, sendErr = w.send(ctx, subscription, d)
+	}
+
+	claimedAttempts := d.Attempts
+
+	d.Attempts++
+	d.LastStatusCode = statusCode
+	d.LastError = ""
+	d.UpdatedAt = w.now()
+
+	log := w.logger.WithTags(map[string]interface{}{
+		"deliveryID":     d.ID,
+		"subscriptionID": d.SubscriptionID,
+		"attempt":        d.Attempts,
+		"statusCode":     statusCode,
+	})
+
+	switch {
+	case sendErr == nil:
+		d.Status = DeliveryStatusSucceeded
+	case d.Attempts >= w.config.MaxAttempts || subscription == nil:
+		// deliveries of deleted subscriptions can never succeed, so they are not retried
+		d.Status = DeliveryStatusFailed
+		d.LastError = sendErr.Error()
+
+		log.Error("Webhook delivery failed permanently", sendErr)
+	default:
+		d.Status = DeliveryStatusPending
+		d.LastError = sendErr.Error()
+		d.NextAttemptAt = d.UpdatedAt.Add(w.backoff(d.Attempts))
+
+		log.Warn("Webhook delivery failed; will retry", sendErr)
+	}
+
+	// the result is recorded even if the worker is stopping so that a sent delivery is not sent again
+	err = w.inTx(context.Background(), func(ctx context.Context) error {
+		return w.queries.UpdateDelivery(ctx, d, claimedAttempts)
+	})
+	if errors.Is(err, ErrClaimLost) {
+		// the claim expired while the delivery was being sent and the worker that claimed it again recorded its result
+		log.Warn("Webhook delivery was claimed by another worker; its result is discarded", err)
+		return nil
+	}
+
+	if err != nil {
+		return cerrors.New(err, "failed to record delivery attempt", nil)
+	}
+
+	return nil
+}
+
+func (w *DeliveryWorker) send(ctx context.Context, s *Subscription, d *Delivery) (int, error) {
+	ts := strconv.FormatInt(w.now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader([]byte(d.Payload)))
+	if err != nil {
+		return 0, cerrors.New(err, "failed to create webhook request", nil)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventType, d.EventType)
+	req.Header.Set(HeaderEventID, d.ID)
+	req.Header.Set(HeaderSignature, fmt.Sprintf("t=%s,v1=%s", ts,
+		computeHMAC(sha256.New, []byte(s.Secret), []byte(ts+"."), []byte(d.Payload))))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, cerrors.New(err, "failed to send webhook request", nil)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	const (
+		MinSuccessStatusCode = 200
+		MaxSuccessStatusCode = 299
+	)
+
+	if resp.StatusCode < MinSuccessStatusCode || resp.StatusCode > MaxSuccessStatusCode {
+		return resp.StatusCode, cerrors.New(nil, "unexpected webhook response status code", map[string]interface{}{
+			"statusCode": resp.StatusCode,
+		})
+	}
+
+	return resp.StatusCode, nil
+}
+
+func (w *DeliveryWorker) backoff(attempts int) time.Duration {
+	d := w.config.BaseBackoff
+	for i := 1; i < attempts && d < w.config.MaxBackoff; i++ {
+		d *= 2
+	}
+
+	if d > w.config.MaxBackoff {
+		return w.config.MaxBackoff
+	}
+
+	return d
+}
+
+func (w *DeliveryWorker) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, tx, err := csql.CtxWithTx(ctx, w.db, w.dialect)
+	if err != nil {
+		return err
+	}
+
+	err = fn(ctx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
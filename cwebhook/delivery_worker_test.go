@@ -0,0 +1,334 @@
+package cwebhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/csql"
+	"github.com/gocopper/copper/csql/csqltest"
+	"github.com/gocopper/copper/cwebhook"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeliveryWorker_ProcessPending(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		received []*http.Request
+		fail     = true
+	)
+
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		received = append(received, r)
+
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer subscriber.Close()
+
+	testDB := csqltest.NewDB(t, csqltest.Options{Migrations: csql.Migrations(cwebhook.Migrations)})
+
+	var (
+		db        = testDB.DB
+		sqlConfig = testDB.Config
+		err       error
+	)
+
+	var (
+		queries    = cwebhook.NewQueries(testDB.Querier)
+		dispatcher = cwebhook.NewDispatcher(queries)
+		worker     = cwebhook.NewDeliveryWorker(cwebhook.NewDeliveryWorkerParams{
+			DB:        db,
+			Queries:   queries,
+			Lifecycle: clifecycle.New(),
+			Config: cwebhook.Config{Delivery: cwebhook.ConfigDelivery{
+				MaxAttempts: 2,
+				BaseBackoff: time.Minute,
+				MaxBackoff:  time.Hour,
+				BatchSize:   10,
+				Timeout:     time.Second,
+			}},
+			SQLConfig: sqlConfig,
+			Logger:    clogger.NewNoop(),
+		})
+		subscription *cwebhook.Subscription
+	)
+
+	inTx := func(fn func(ctx context.Context)) {
+		ctx, tx, err := csql.CtxWithTx(context.Background(), db, "sqlite3")
+		assert.NoError(t, err)
+
+		fn(ctx)
+
+		assert.NoError(t, tx.Commit())
+	}
+
+	inTx(func(ctx context.Context) {
+		subscription, err = dispatcher.Subscribe(ctx, subscriber.URL, "secret", []string{"user.created"})
+		assert.NoError(t, err)
+
+		assert.NoError(t, dispatcher.Publish(ctx, "user.created", map[string]string{"name": "test"}))
+		assert.NoError(t, dispatcher.Publish(ctx, "user.deleted", map[string]string{"name": "test"}))
+	})
+
+	// first attempt fails and the delivery is scheduled for a retry with a backoff
+	n, err := worker.ProcessPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = worker.ProcessPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	var deliveries []cwebhook.Delivery
+
+	inTx(func(ctx context.Context) {
+		deliveries, err = dispatcher.Deliveries(ctx, subscription.ID, 10)
+		assert.NoError(t, err)
+	})
+
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, cwebhook.DeliveryStatusPending, deliveries[0].Status)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, deliveries[0].LastStatusCode)
+
+	assert.Len(t, received, 1)
+	assert.Equal(t, "user.created", received[0].Header.Get(cwebhook.HeaderEventType))
+	assert.Contains(t, received[0].Header.Get(cwebhook.HeaderSignature), "v1=")
+
+	// redelivery is sent right away
+	fail = false
+
+	inTx(func(ctx context.Context) {
+		_, err = dispatcher.Redeliver(ctx, deliveries[0].ID)
+		assert.NoError(t, err)
+	})
+
+	n, err = worker.ProcessPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	inTx(func(ctx context.Context) {
+		deliveries, err = dispatcher.Deliveries(ctx, subscription.ID, 10)
+		assert.NoError(t, err)
+	})
+
+	assert.Len(t, deliveries, 2)
+
+	var payload cwebhook.Payload
+
+	assert.NoError(t, json.Unmarshal([]byte(deliveries[0].Payload), &payload))
+	assert.Equal(t, "user.created", payload.Type)
+	assert.Equal(t, deliveries[0].Payload, deliveries[1].Payload)
+}
+
+func TestDeliveryWorker_ProcessPending_Recover(t *testing.T) {
+	t.Parallel()
+
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer subscriber.Close()
+
+	testDB := csqltest.NewDB(t, csqltest.Options{Migrations: csql.Migrations(cwebhook.Migrations)})
+
+	var (
+		db        = testDB.DB
+		sqlConfig = testDB.Config
+		err       error
+	)
+
+	var (
+		queries    = cwebhook.NewQueries(testDB.Querier)
+		dispatcher = cwebhook.NewDispatcher(queries)
+		worker     = cwebhook.NewDeliveryWorker(cwebhook.NewDeliveryWorkerParams{
+			DB:        db,
+			Queries:   queries,
+			Lifecycle: clifecycle.New(),
+			Config: cwebhook.Config{Delivery: cwebhook.ConfigDelivery{
+				MaxAttempts:       3,
+				BaseBackoff:       time.Minute,
+				MaxBackoff:        time.Hour,
+				BatchSize:         10,
+				Timeout:           time.Second,
+				DeliveringTimeout: time.Minute,
+			}},
+			SQLConfig: sqlConfig,
+			Logger:    clogger.NewNoop(),
+		})
+		live, deleted *cwebhook.Subscription
+		deliveries    []cwebhook.Delivery
+	)
+
+	inTx := func(fn func(ctx context.Context)) {
+		ctx, tx, err := csql.CtxWithTx(context.Background(), db, "sqlite3")
+		assert.NoError(t, err)
+
+		fn(ctx)
+
+		assert.NoError(t, tx.Commit())
+	}
+
+	inTx(func(ctx context.Context) {
+		live, err = dispatcher.Subscribe(ctx, subscriber.URL, "secret", []string{"user.created"})
+		assert.NoError(t, err)
+
+		deleted, err = dispatcher.Subscribe(ctx, subscriber.URL, "secret", []string{"user.created"})
+		assert.NoError(t, err)
+
+		assert.NoError(t, dispatcher.Publish(ctx, "user.created", map[string]string{"name": "test"}))
+	})
+
+	_, err = db.Exec(`delete from cwebhook_subscriptions where id = ?`, deleted.ID)
+	assert.NoError(t, err)
+
+	// the live subscription's delivery was claimed by a worker that stopped in the middle of the attempt
+	_, err = db.Exec(`update cwebhook_deliveries set status = ?, updated_at = ? where subscription_id = ?`,
+		cwebhook.DeliveryStatusDelivering, time.Now().Add(-time.Hour), live.ID)
+	assert.NoError(t, err)
+
+	n, err := worker.ProcessPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	inTx(func(ctx context.Context) {
+		deliveries, err = dispatcher.Deliveries(ctx, live.ID, 10)
+		assert.NoError(t, err)
+	})
+
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, cwebhook.DeliveryStatusSucceeded, deliveries[0].Status)
+	}
+
+	inTx(func(ctx context.Context) {
+		deliveries, err = dispatcher.Deliveries(ctx, deleted.ID, 10)
+		assert.NoError(t, err)
+	})
+
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, cwebhook.DeliveryStatusFailed, deliveries[0].Status)
+	}
+
+	n, err = worker.ProcessPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestDeliveryWorker_ProcessPending_ClaimLost(t *testing.T) {
+	t.Parallel()
+
+	var (
+		requests = make(chan struct{}, 2)
+		release  = make(chan struct{})
+		calls    int32
+	)
+
+	// the first attempt hangs until its claim has expired and the delivery was sent by another worker
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- struct{}{}
+
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-release
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer subscriber.Close()
+
+	testDB := csqltest.NewDB(t, csqltest.Options{Migrations: csql.Migrations(cwebhook.Migrations)})
+
+	var (
+		db        = testDB.DB
+		sqlConfig = testDB.Config
+		err       error
+	)
+
+	var (
+		queries    = cwebhook.NewQueries(testDB.Querier)
+		dispatcher = cwebhook.NewDispatcher(queries)
+		newWorker  = func() *cwebhook.DeliveryWorker {
+			return cwebhook.NewDeliveryWorker(cwebhook.NewDeliveryWorkerParams{
+				DB:        db,
+				Queries:   queries,
+				Lifecycle: clifecycle.New(),
+				Config: cwebhook.Config{Delivery: cwebhook.ConfigDelivery{
+					MaxAttempts:       3,
+					BaseBackoff:       time.Minute,
+					MaxBackoff:        time.Hour,
+					BatchSize:         10,
+					Timeout:           5 * time.Second,
+					DeliveringTimeout: time.Nanosecond,
+				}},
+				SQLConfig: sqlConfig,
+				Logger:    clogger.NewNoop(),
+			})
+		}
+		subscription *cwebhook.Subscription
+		deliveries   []cwebhook.Delivery
+	)
+
+	inTx := func(fn func(ctx context.Context)) {
+		ctx, tx, err := csql.CtxWithTx(context.Background(), db, "sqlite3")
+		assert.NoError(t, err)
+
+		fn(ctx)
+
+		assert.NoError(t, tx.Commit())
+	}
+
+	inTx(func(ctx context.Context) {
+		subscription, err = dispatcher.Subscribe(ctx, subscriber.URL, "secret", []string{"user.created"})
+		assert.NoError(t, err)
+
+		assert.NoError(t, dispatcher.Publish(ctx, "user.created", map[string]string{"name": "test"}))
+	})
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		_, err := newWorker().ProcessPending(context.Background())
+		assert.NoError(t, err)
+	}()
+
+	<-requests
+
+	n, err := newWorker().ProcessPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	close(release)
+	<-done
+
+	inTx(func(ctx context.Context) {
+		deliveries, err = dispatcher.Deliveries(ctx, subscription.ID, 10)
+		assert.NoError(t, err)
+	})
+
+	// the failure of the expired claim does not overwrite the success of the worker that claimed it again
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, cwebhook.DeliveryStatusSucceeded, deliveries[0].Status)
+		assert.Equal(t, 1, deliveries[0].Attempts)
+	}
+}
@@ -0,0 +1,164 @@
+package cwebhook
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// NewDispatcher creates a new Dispatcher
+func NewDispatcher(queries *Queries) *Dispatcher {
+	return &Dispatcher{
+		queries: queries,
+		now:     time.Now,
+	}
+}
+
+// Dispatcher manages webhook subscriptions and queues events for delivery to them. The queued deliveries are sent by
+// the DeliveryWorker. All methods must be called with a context that has a database transaction (see csql.CtxWithTx)
+// so that events are queued only if the surrounding transaction commits.
+type Dispatcher struct {
+	queries *Queries
+	now     func() time.Time
+}
+
+// Payload is the JSON body sent to subscribers for each event
+type Payload struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Subscribe registers a new subscription for the given URL and event types. Use "*" to subscribe to all events.
+func (d *Dispatcher) Subscribe(ctx context.Context, url, secret string, eventTypes []string) (*Subscription, error) {
+	if url == "" || len(eventTypes) == 0 {
+		return nil, cerrors.New(nil, "subscription needs a url and at least one event type", map[string]interface{}{
+			"url": url,
+		})
+	}
+
+	s := Subscription{
+		ID:         newID(),
+		URL:        url,
+		Secret:     secret,
+		EventTypes: strings.Join(eventTypes, ","),
+		CreatedAt:  d.now(),
+	}
+
+	err := d.queries.InsertSubscription(ctx, &s)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to insert subscription", map[string]interface{}{
+			"url": url,
+		})
+	}
+
+	return &s, nil
+}
+
+// Unsubscribe deletes the subscription with the given id along with its delivery logs
+func (d *Dispatcher) Unsubscribe(ctx context.Context, subscriptionID string) error {
+	err := d.queries.DeleteSubscription(ctx, subscriptionID)
+	if err != nil {
+		return cerrors.New(err, "failed to delete subscription", map[string]interface{}{
+			"id": subscriptionID,
+		})
+	}
+
+	return nil
+}
+
+// Publish queues a delivery of the event to each subscription that is subscribed to the event type. The data is
+// sent as the 'data' field of the JSON Payload.
+func (d *Dispatcher) Publish(ctx context.Context, eventType string, data interface{}) error {
+	subscriptions, err := d.queries.ListSubscriptions(ctx)
+	if err != nil {
+		return cerrors.New(err, "failed to list subscriptions", nil)
+	}
+
+	now := d.now()
+
+	payload, err := json.Marshal(Payload{
+		ID:        newID(),
+		Type:      eventType,
+		CreatedAt: now,
+		Data:      data,
+	})
+	if err != nil {
+		return cerrors.New(err, "failed to marshal webhook payload", map[string]interface{}{
+			"type": eventType,
+		})
+	}
+
+	for i := range subscriptions {
+		if !subscriptions[i].Wants(eventType) {
+			continue
+		}
+
+		err = d.queries.InsertDelivery(ctx, &Delivery{
+			ID:             newID(),
+			SubscriptionID: subscriptions[i].ID,
+			EventType:      eventType,
+			Payload:        string(payload),
+			Status:         DeliveryStatusPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+		if err != nil {
+			return cerrors.New(err, "failed to insert delivery", map[string]interface{}{
+				"subscriptionID": subscriptions[i].ID,
+				"type":           eventType,
+			})
+		}
+	}
+
+	return nil
+}
+
+// Deliveries returns the most recent delivery logs for the given subscription
+func (d *Dispatcher) Deliveries(ctx context.Context, subscriptionID string, limit int) ([]Delivery, error) {
+	deliveries, err := d.queries.ListDeliveries(ctx, subscriptionID, limit)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to list deliveries", map[string]interface{}{
+			"subscriptionID": subscriptionID,
+		})
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver queues a new delivery with the same payload as the given delivery. The payload (including the event id)
+// is unchanged so subscribers can de-duplicate it.
+func (d *Dispatcher) Redeliver(ctx context.Context, deliveryID string) (*Delivery, error) {
+	original, err := d.queries.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to get delivery", map[string]interface{}{
+			"id": deliveryID,
+		})
+	}
+
+	now := d.now()
+	redelivery := Delivery{
+		ID:             newID(),
+		SubscriptionID: original.SubscriptionID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         DeliveryStatusPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	err = d.queries.InsertDelivery(ctx, &redelivery)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to insert redelivery", map[string]interface{}{
+			"id": deliveryID,
+		})
+	}
+
+	return &redelivery, nil
+}
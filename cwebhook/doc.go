@@ -0,0 +1,12 @@
+// Package cwebhook receives webhooks from third-party providers and delivers the app's own webhooks to subscribers.
+//
+// Receiving: Receiver verifies the signatures of incoming webhooks (Stripe, GitHub, Slack, etc.) and handles each
+// delivery once even if the provider retries it.
+//
+// Delivering: Dispatcher manages the app's webhook subscriptions and queues events for the subscribers in the same
+// database transaction as the changes that caused them. DeliveryWorker sends the queued deliveries with a signature
+// header (see HeaderSignature) and retries failed deliveries with exponential backoff. A delivery is claimed by one
+// worker at a time. If a worker stops while sending a delivery, the delivery is claimed again once
+// cwebhook.delivery.delivering_timeout expires, so subscribers must handle a delivery more than once using its
+// HeaderEventID. The schema is in Migrations and old deliveries can be purged using DeliveriesRetentionPolicy.
+package cwebhook
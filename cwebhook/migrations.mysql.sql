@@ -0,0 +1,30 @@
+-- +migrate Up
+create table cwebhook_subscriptions (
+    id varchar(64) primary key,
+    url text not null,
+    secret text not null,
+    event_types text not null,
+    created_at datetime(6) not null
+);
+
+create table cwebhook_deliveries (
+    id varchar(64) primary key,
+    subscription_id varchar(64) not null,
+    event_type varchar(255) not null,
+    payload mediumtext not null,
+    status varchar(32) not null,
+    attempts integer not null default 0,
+    next_attempt_at datetime(6) not null,
+    last_status_code integer not null default 0,
+    last_error text not null,
+    created_at datetime(6) not null,
+    updated_at datetime(6) not null,
+    foreign key (subscription_id) references cwebhook_subscriptions (id)
+);
+
+create index cwebhook_deliveries_pending_idx on cwebhook_deliveries (status, next_attempt_at);
+create index cwebhook_deliveries_subscription_idx on cwebhook_deliveries (subscription_id, created_at);
+
+-- +migrate Down
+drop table cwebhook_deliveries;
+drop table cwebhook_subscriptions;
@@ -0,0 +1,29 @@
+-- +migrate Up
+create table cwebhook_subscriptions (
+    id text primary key,
+    url text not null,
+    secret text not null,
+    event_types text not null,
+    created_at timestamp not null
+);
+
+create table cwebhook_deliveries (
+    id text primary key,
+    subscription_id text not null references cwebhook_subscriptions (id),
+    event_type text not null,
+    payload text not null,
+    status text not null,
+    attempts integer not null default 0,
+    next_attempt_at timestamp not null,
+    last_status_code integer not null default 0,
+    last_error text not null default '',
+    created_at timestamp not null,
+    updated_at timestamp not null
+);
+
+create index cwebhook_deliveries_pending_idx on cwebhook_deliveries (status, next_attempt_at);
+create index cwebhook_deliveries_subscription_idx on cwebhook_deliveries (subscription_id, created_at);
+
+-- +migrate Down
+drop table cwebhook_deliveries;
+drop table cwebhook_subscriptions;
@@ -0,0 +1,70 @@
+package cwebhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// Delivery statuses
+const (
+	DeliveryStatusPending    = "pending"
+	DeliveryStatusDelivering = "delivering"
+	DeliveryStatusSucceeded  = "succeeded"
+	DeliveryStatusFailed     = "failed"
+)
+
+type (
+	// Subscription registers a URL to receive outgoing webhooks for the given event types. Each delivery is signed
+	// using the subscription's secret.
+	Subscription struct {
+		ID         string    `db:"id"`
+		URL        string    `db:"url"`
+		Secret     string    `db:"secret"`
+		EventTypes string    `db:"event_types"`
+		CreatedAt  time.Time `db:"created_at"`
+	}
+
+	// Delivery is a single event that is delivered (or is pending delivery) to a subscription. It also serves as
+	// the delivery log for the subscription.
+	Delivery struct {
+		ID             string    `db:"id"`
+		SubscriptionID string    `db:"subscription_id"`
+		EventType      string    `db:"event_type"`
+		Payload        string    `db:"payload"`
+		Status         string    `db:"status"`
+		Attempts       int       `db:"attempts"`
+		NextAttemptAt  time.Time `db:"next_attempt_at"`
+		LastStatusCode int       `db:"last_status_code"`
+		LastError      string    `db:"last_error"`
+		CreatedAt      time.Time `db:"created_at"`
+		UpdatedAt      time.Time `db:"updated_at"`
+	}
+)
+
+// Events returns the list of event types the subscription is subscribed to
+func (s Subscription) Events() []string {
+	return strings.Split(s.EventTypes, ",")
+}
+
+// Wants returns true if the subscription is subscribed to the given event type. The "*" event type subscribes to all
+// events.
+func (s Subscription) Wants(eventType string) bool {
+	for _, e := range s.Events() {
+		if e == "*" || e == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newID() string {
+	const idBytes = 16
+
+	b := make([]byte, idBytes)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,191 @@
+package cwebhook
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/csql"
+)
+
+// Migrations holds the database schema needed for outgoing webhooks. Register them using csql.RegisterMigrations so
+// that they are applied by csql.Migrator along with the app's migrations:
+//
+//	csql.RegisterMigrations(csql.ModuleMigrations{Module: "cwebhook", FS: cwebhook.Migrations})
+//
+// MySQL uses the variant in migrations.mysql.sql (see csql.ModuleMigrations).
+//
+//go:embed migrations.sql migrations.mysql.sql
+var Migrations embed.FS
+
+// ErrNotFound is returned when a subscription or delivery does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrClaimLost is returned by UpdateDelivery when the delivery is no longer claimed by the caller (ex. because its
+// claim expired and another worker claimed it again)
+var ErrClaimLost = errors.New("delivery is no longer claimed")
+
+// NewQueries creates a new Queries
+func NewQueries(querier csql.Querier) *Queries {
+	return &Queries{querier: querier}
+}
+
+// Queries holds the database queries for webhook subscriptions and deliveries
+type Queries struct {
+	querier csql.Querier
+}
+
+// InsertSubscription saves a new subscription
+func (q *Queries) InsertSubscription(ctx context.Context, s *Subscription) error {
+	const query = `
+	insert into cwebhook_subscriptions (id, url, secret, event_types, created_at)
+	values (?, ?, ?, ?, ?)`
+
+	_, err := q.querier.Exec(ctx, query, s.ID, s.URL, s.Secret, s.EventTypes, s.CreatedAt)
+
+	return err
+}
+
+// DeleteSubscription deletes a subscription along with its deliveries
+func (q *Queries) DeleteSubscription(ctx context.Context, id string) error {
+	_, err := q.querier.Exec(ctx, `delete from cwebhook_deliveries where subscription_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.querier.Exec(ctx, `delete from cwebhook_subscriptions where id = ?`, id)
+
+	return err
+}
+
+// GetSubscription returns the subscription with the given id
+func (q *Queries) GetSubscription(ctx context.Context, id string) (*Subscription, error) {
+	var s Subscription
+
+	err := q.querier.Get(ctx, &s, `select * from cwebhook_subscriptions where id = ?`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+
+	return &s, err
+}
+
+// ListSubscriptions returns all subscriptions
+func (q *Queries) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	var subscriptions []Subscription
+
+	err := q.querier.Select(ctx, &subscriptions, `select * from cwebhook_subscriptions order by created_at`)
+
+	return subscriptions, err
+}
+
+// InsertDelivery saves a new delivery
+func (q *Queries) InsertDelivery(ctx context.Context, d *Delivery) error {
+	const query = `
+	insert into cwebhook_deliveries (id, subscription_id, event_type, payload, status, attempts, next_attempt_at,
+		last_status_code, last_error, created_at, updated_at)
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := q.querier.Exec(ctx, query, d.ID, d.SubscriptionID, d.EventType, d.Payload, d.Status, d.Attempts,
+		d.NextAttemptAt, d.LastStatusCode, d.LastError, d.CreatedAt, d.UpdatedAt)
+
+	return err
+}
+
+// UpdateDelivery saves the status and attempt details of a delivery that was claimed using ClaimPendingDeliveries.
+// claimedAttempts is the number of attempts the delivery had when it was claimed. Since every recorded attempt
+// increments it, it returns ErrClaimLost if another worker has recorded an attempt since then.
+func (q *Queries) UpdateDelivery(ctx context.Context, d *Delivery, claimedAttempts int) error {
+	const query = `
+	update cwebhook_deliveries
+	set status = ?, attempts = ?, next_attempt_at = ?, last_status_code = ?, last_error = ?, updated_at = ?
+	where id = ? and status = ? and attempts = ?`
+
+	res, err := q.querier.Exec(ctx, query, d.Status, d.Attempts, d.NextAttemptAt, d.LastStatusCode, d.LastError,
+		d.UpdatedAt, d.ID, DeliveryStatusDelivering, claimedAttempts)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrClaimLost
+	}
+
+	return nil
+}
+
+// GetDelivery returns the delivery with the given id
+func (q *Queries) GetDelivery(ctx context.Context, id string) (*Delivery, error) {
+	var d Delivery
+
+	err := q.querier.Get(ctx, &d, `select * from cwebhook_deliveries where id = ?`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+
+	return &d, err
+}
+
+// ListDeliveries returns the most recent deliveries for a subscription
+func (q *Queries) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]Delivery, error) {
+	const query = `
+	select * from cwebhook_deliveries
+	where subscription_id = ?
+	order by created_at desc
+	limit ?`
+
+	var deliveries []Delivery
+
+	err := q.querier.Select(ctx, &deliveries, query, subscriptionID, limit)
+
+	return deliveries, err
+}
+
+// ClaimPendingDeliveries marks up to limit pending deliveries that are due as delivering and returns them.
+// Deliveries that have been delivering since before staleBefore are claimed again. A delivery can only be claimed by
+// one worker.
+func (q *Queries) ClaimPendingDeliveries(ctx context.Context, now, staleBefore time.Time, limit int) ([]Delivery,
+	error) {
+	const (
+		claimable = `((status = ? and next_attempt_at <= ?) or (status = ? and updated_at <= ?))`
+
+		selectQuery = `
+		select * from cwebhook_deliveries
+		where ` + claimable + `
+		order by next_attempt_at
+		limit ?`
+
+		claimQuery = `update cwebhook_deliveries set status = ?, updated_at = ? where id = ? and ` + claimable
+	)
+
+	var pending []Delivery
+
+	err := q.querier.Select(ctx, &pending, selectQuery, DeliveryStatusPending, now, DeliveryStatusDelivering,
+		staleBefore, limit)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to query pending deliveries", nil)
+	}
+
+	claimed := make([]Delivery, 0, len(pending))
+
+	for i := range pending {
+		res, err := q.querier.Exec(ctx, claimQuery, DeliveryStatusDelivering, now, pending[i].ID,
+			DeliveryStatusPending, now, DeliveryStatusDelivering, staleBefore)
+		if err != nil {
+			return nil, cerrors.New(err, "failed to claim delivery", map[string]interface{}{
+				"id": pending[i].ID,
+			})
+		}
+
+		if n, _ := res.RowsAffected(); n == 1 {
+			pending[i].Status = DeliveryStatusDelivering
+			pending[i].UpdatedAt = now
+			claimed = append(claimed, pending[i])
+		}
+	}
+
+	return claimed, nil
+}
@@ -0,0 +1,146 @@
+package cwebhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+)
+
+type (
+	// Event is a verified webhook delivery
+	Event struct {
+		Provider   string
+		DeliveryID string
+		Header     http.Header
+		Body       []byte
+	}
+
+	// HandlerFunc handles a verified webhook delivery. If it returns an error, the provider is sent a 500 response so
+	// it can retry the delivery.
+	HandlerFunc func(ctx context.Context, event Event) error
+
+	// NewReceiverParams holds the params needed to create a Receiver
+	NewReceiverParams struct {
+		Store  DeliveryStore
+		Config Config
+		Logger clogger.Logger
+	}
+
+	// Receiver is a chttp.Router that serves the registered webhook endpoints
+	Receiver struct {
+		store  DeliveryStore
+		config Config
+		logger clogger.Logger
+		routes []chttp.Route
+	}
+)
+
+// Decode unmarshals the event's JSON body into dest
+func (e Event) Decode(dest interface{}) error {
+	err := json.Unmarshal(e.Body, dest)
+	if err != nil {
+		return cerrors.New(err, "failed to decode webhook body", map[string]interface{}{
+			"provider": e.Provider,
+		})
+	}
+
+	return nil
+}
+
+// NewReceiver creates a new Receiver
+func NewReceiver(p NewReceiverParams) *Receiver {
+	return &Receiver{
+		store:  p.Store,
+		config: p.Config,
+		logger: p.Logger.With(clogger.Module("cwebhook")),
+		routes: make([]chttp.Route, 0),
+	}
+}
+
+// Handle registers a webhook endpoint at the given path. Each request is verified using the verifier before the
+// handler is called. Deliveries with an id that has already been handled successfully are acknowledged without
+// calling the handler.
+func (rc *Receiver) Handle(path string, verifier Verifier, handler HandlerFunc) {
+	rc.routes = append(rc.routes, chttp.Route{
+		Path:    path,
+		Methods: []string{http.MethodPost},
+		Handler: rc.handler(verifier, handler),
+	})
+}
+
+// Routes returns the registered webhook endpoints
+func (rc *Receiver) Routes() []chttp.Route {
+	return rc.routes
+}
+
+func (rc *Receiver) handler(verifier Verifier, handler HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := rc.logger.WithTags(map[string]interface{}{
+			"provider": verifier.Provider(),
+			"path":     r.URL.Path,
+		})
+
+		// The raw body must be captured before any decoding since signatures are computed over the exact bytes
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, rc.config.MaxBodyBytes))
+		if err != nil {
+			log.Warn("Failed to read webhook body", err)
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		err = verifier.Verify(r.Header, body)
+		if err != nil {
+			log.Warn("Failed to verify webhook", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		event := Event{
+			Provider:   verifier.Provider(),
+			DeliveryID: verifier.DeliveryID(r.Header, body),
+			Header:     r.Header,
+			Body:       body,
+		}
+
+		if event.DeliveryID != "" {
+			ok, err := rc.store.Claim(r.Context(), event.Provider, event.DeliveryID, rc.config.DeliveryIDTTL)
+			if err != nil {
+				log.Error("Failed to claim webhook delivery", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if !ok {
+				log.WithTags(map[string]interface{}{
+					"deliveryID": event.DeliveryID,
+				}).Info("Skipping duplicate webhook delivery")
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+		}
+
+		err = handler(r.Context(), event)
+		if err != nil {
+			log.WithTags(map[string]interface{}{
+				"deliveryID": event.DeliveryID,
+			}).Error("Failed to handle webhook", err)
+
+			if event.DeliveryID != "" {
+				releaseErr := rc.store.Release(r.Context(), event.Provider, event.DeliveryID)
+				if releaseErr != nil {
+					log.Error("Failed to release webhook delivery", releaseErr)
+				}
+			}
+
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,93 @@
+package cwebhook_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/chttp"
+	"github.com/gocopper/copper/clogger"
+	"github.com/gocopper/copper/cwebhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceiver_Handle(t *testing.T) {
+	t.Parallel()
+
+	var (
+		calls    = 0
+		failNext = false
+		config   = cwebhook.Config{
+			MaxBodyBytes:  1024,
+			DeliveryIDTTL: time.Hour,
+		}
+		receiver = cwebhook.NewReceiver(cwebhook.NewReceiverParams{
+			Store:  cwebhook.NewMemoryDeliveryStore(),
+			Config: config,
+			Logger: clogger.NewNoop(),
+		})
+	)
+
+	verifier, err := cwebhook.NewHMACVerifier("test", "secret", "X-Signature", "X-Delivery")
+	assert.NoError(t, err)
+
+	receiver.Handle("/webhooks/test", verifier,
+		func(ctx context.Context, event cwebhook.Event) error {
+			calls++
+
+			var body struct {
+				Key string `json:"key"`
+			}
+
+			assert.NoError(t, event.Decode(&body))
+			assert.Equal(t, "val", body.Key)
+
+			if failNext {
+				failNext = false
+				return errors.New("test-err") //nolint:goerr113
+			}
+
+			return nil
+		})
+
+	server := httptest.NewServer(chttp.NewHandler(chttp.NewHandlerParams{
+		Routers: []chttp.Router{receiver},
+		Logger:  clogger.NewNoop(),
+	}))
+	defer server.Close()
+
+	send := func(deliveryID, signature string) int {
+		body := []byte(`{"key":"val"}`)
+
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost,
+			server.URL+"/webhooks/test", bytes.NewReader(body))
+		assert.NoError(t, err)
+
+		req.Header.Set("X-Delivery", deliveryID)
+		req.Header.Set("X-Signature", signature)
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.NoError(t, resp.Body.Close())
+
+		return resp.StatusCode
+	}
+
+	validSig := sign("secret", `{"key":"val"}`)
+
+	assert.Equal(t, http.StatusUnauthorized, send("d1", "invalid"))
+	assert.Equal(t, 0, calls)
+
+	assert.Equal(t, http.StatusOK, send("d1", validSig))
+	assert.Equal(t, http.StatusOK, send("d1", validSig))
+	assert.Equal(t, 1, calls)
+
+	failNext = true
+	assert.Equal(t, http.StatusInternalServerError, send("d2", validSig))
+	assert.Equal(t, http.StatusOK, send("d2", validSig))
+	assert.Equal(t, 3, calls)
+}
@@ -0,0 +1,17 @@
+package cwebhook
+
+import (
+	"time"
+
+	"github.com/gocopper/copper/cretention"
+)
+
+// DeliveriesRetentionPolicy purges the outgoing webhook deliveries that succeeded more than 30 days ago. Apps that
+// use the Dispatcher can register it using cretention.Register.
+var DeliveriesRetentionPolicy = cretention.Policy{ //nolint:gochecknoglobals
+	Name:        "cwebhook_deliveries",
+	Description: "Succeeded webhook deliveries",
+	MaxAge:      30 * 24 * time.Hour, //nolint:gomnd
+	Purge: cretention.DeleteRows("cwebhook_deliveries", "id",
+		"status = '"+DeliveryStatusSucceeded+"' and updated_at < :before"),
+}
@@ -0,0 +1,62 @@
+package cwebhook
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DeliveryStore keeps track of processed webhook deliveries so retried deliveries are handled only once.
+type DeliveryStore interface {
+	// Claim marks the delivery as being processed. It returns false if the delivery has already been claimed.
+	Claim(ctx context.Context, provider, deliveryID string, ttl time.Duration) (bool, error)
+
+	// Release removes the claim on a delivery so it can be retried (ex. when the handler fails).
+	Release(ctx context.Context, provider, deliveryID string) error
+}
+
+// NewMemoryDeliveryStore returns an in-memory implementation of DeliveryStore. It is suitable for single instance
+// deployments and tests. Multi-instance deployments should use a shared store.
+func NewMemoryDeliveryStore() DeliveryStore {
+	return &memoryDeliveryStore{
+		claims: make(map[string]time.Time),
+		now:    time.Now,
+	}
+}
+
+type memoryDeliveryStore struct {
+	mu     sync.Mutex
+	claims map[string]time.Time
+	now    func() time.Time
+}
+
+func (s *memoryDeliveryStore) Claim(ctx context.Context, provider, deliveryID string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	for k, expiresAt := range s.claims {
+		if now.After(expiresAt) {
+			delete(s.claims, k)
+		}
+	}
+
+	key := provider + "/" + deliveryID
+	if _, ok := s.claims[key]; ok {
+		return false, nil
+	}
+
+	s.claims[key] = now.Add(ttl)
+
+	return true, nil
+}
+
+func (s *memoryDeliveryStore) Release(ctx context.Context, provider, deliveryID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.claims, provider+"/"+deliveryID)
+
+	return nil
+}
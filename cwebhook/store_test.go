@@ -0,0 +1,37 @@
+package cwebhook_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/cwebhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryDeliveryStore(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx   = context.Background()
+		store = cwebhook.NewMemoryDeliveryStore()
+	)
+
+	ok, err := store.Claim(ctx, "github", "d1", time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = store.Claim(ctx, "github", "d1", time.Hour)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = store.Claim(ctx, "stripe", "d1", time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, store.Release(ctx, "github", "d1"))
+
+	ok, err = store.Claim(ctx, "github", "d1", time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,298 @@
+package cwebhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Providers with built-in verifiers
+const (
+	ProviderStripe = "stripe"
+	ProviderGitHub = "github"
+	ProviderSlack  = "slack"
+)
+
+// ErrInvalidSignature is returned by a Verifier when the webhook's signature does not match the expected signature.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// ErrMissingSecret is returned when a Verifier is created without a signing secret. An empty secret would accept
+// signatures that anyone can compute, so webhooks are rejected instead.
+var ErrMissingSecret = errors.New("webhook secret is not set")
+
+// Verifier verifies that a webhook request was sent by the provider and extracts a unique id for each delivery so
+// that retried deliveries can be de-duplicated.
+type Verifier interface {
+	// Provider returns the name of the webhook provider (ex. stripe)
+	Provider() string
+
+	// Verify checks the signature of the raw webhook body. It returns an error if the signature is invalid.
+	Verify(header http.Header, body []byte) error
+
+	// DeliveryID returns a unique id for the webhook delivery. It may return an empty string if the provider does
+	// not send one, in which case the delivery is not de-duplicated.
+	DeliveryID(header http.Header, body []byte) string
+}
+
+// NewStripeVerifier returns a Verifier for Stripe webhooks. It verifies the Stripe-Signature header and uses the
+// event id as the delivery id.
+// See https://stripe.com/docs/webhooks/signatures
+func NewStripeVerifier(config Config) (Verifier, error) {
+	if config.Stripe.Secret == "" {
+		return nil, missingSecretErr(ProviderStripe)
+	}
+
+	return &stripeVerifier{
+		secret:    []byte(config.Stripe.Secret),
+		tolerance: config.Tolerance,
+		now:       time.Now,
+	}, nil
+}
+
+type stripeVerifier struct {
+	secret    []byte
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+func (v *stripeVerifier) Provider() string {
+	return ProviderStripe
+}
+
+func (v *stripeVerifier) Verify(header http.Header, body []byte) error {
+	if len(v.secret) == 0 {
+		return ErrMissingSecret
+	}
+
+	var (
+		timestamp  string
+		signatures []string
+	)
+
+	for _, part := range strings.Split(header.Get("Stripe-Signature"), ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2) //nolint:gomnd
+		if len(kv) != 2 {                                     //nolint:gomnd
+			continue
+		}
+
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+
+	err := verifyTimestamp(timestamp, v.tolerance, v.now())
+	if err != nil {
+		return err
+	}
+
+	expected := computeHMAC(sha256.New, v.secret, []byte(timestamp+"."), body)
+	for _, sig := range signatures {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+func (v *stripeVerifier) DeliveryID(header http.Header, body []byte) string {
+	return jsonStringField(body, "id")
+}
+
+// NewGitHubVerifier returns a Verifier for GitHub webhooks. It verifies the X-Hub-Signature-256 header (or the legacy
+// X-Hub-Signature header) and uses X-GitHub-Delivery as the delivery id.
+// See https://docs.github.com/en/webhooks/using-webhooks/validating-webhook-deliveries
+func NewGitHubVerifier(config Config) (Verifier, error) {
+	if config.GitHub.Secret == "" {
+		return nil, missingSecretErr(ProviderGitHub)
+	}
+
+	return &githubVerifier{
+		secret: []byte(config.GitHub.Secret),
+	}, nil
+}
+
+type githubVerifier struct {
+	secret []byte
+}
+
+func (v *githubVerifier) Provider() string {
+	return ProviderGitHub
+}
+
+func (v *githubVerifier) Verify(header http.Header, body []byte) error {
+	if sig := header.Get("X-Hub-Signature-256"); sig != "" {
+		return verifyPrefixedHMAC(sha256.New, v.secret, "sha256=", sig, body)
+	}
+
+	if sig := header.Get("X-Hub-Signature"); sig != "" {
+		return verifyPrefixedHMAC(sha1.New, v.secret, "sha1=", sig, body)
+	}
+
+	return ErrInvalidSignature
+}
+
+func (v *githubVerifier) DeliveryID(header http.Header, body []byte) string {
+	return header.Get("X-GitHub-Delivery")
+}
+
+// NewSlackVerifier returns a Verifier for Slack requests. It verifies the X-Slack-Signature header along with the
+// X-Slack-Request-Timestamp header and uses the event_id (if any) as the delivery id.
+// See https://api.slack.com/authentication/verifying-requests-from-slack
+func NewSlackVerifier(config Config) (Verifier, error) {
+	if config.Slack.Secret == "" {
+		return nil, missingSecretErr(ProviderSlack)
+	}
+
+	return &slackVerifier{
+		secret:    []byte(config.Slack.Secret),
+		tolerance: config.Tolerance,
+		now:       time.Now,
+	}, nil
+}
+
+type slackVerifier struct {
+	secret    []byte
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+func (v *slackVerifier) Provider() string {
+	return ProviderSlack
+}
+
+func (v *slackVerifier) Verify(header http.Header, body []byte) error {
+	if len(v.secret) == 0 {
+		return ErrMissingSecret
+	}
+
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+
+	err := verifyTimestamp(timestamp, v.tolerance, v.now())
+	if err != nil {
+		return err
+	}
+
+	expected := "v0=" + computeHMAC(sha256.New, v.secret, []byte("v0:"+timestamp+":"), body)
+	if !hmac.Equal([]byte(header.Get("X-Slack-Signature")), []byte(expected)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (v *slackVerifier) DeliveryID(header http.Header, body []byte) string {
+	return jsonStringField(body, "event_id")
+}
+
+// NewHMACVerifier returns a generic Verifier for providers that sign the raw body using HMAC-SHA256 and send the
+// hex-encoded signature in the given header. The delivery id is read from the idHeader, if set.
+func NewHMACVerifier(provider, secret, signatureHeader, idHeader string) (Verifier, error) {
+	if secret == "" {
+		return nil, missingSecretErr(provider)
+	}
+
+	return &hmacVerifier{
+		provider:        provider,
+		secret:          []byte(secret),
+		signatureHeader: signatureHeader,
+		idHeader:        idHeader,
+	}, nil
+}
+
+type hmacVerifier struct {
+	provider        string
+	secret          []byte
+	signatureHeader string
+	idHeader        string
+}
+
+func (v *hmacVerifier) Provider() string {
+	return v.provider
+}
+
+func (v *hmacVerifier) Verify(header http.Header, body []byte) error {
+	return verifyPrefixedHMAC(sha256.New, v.secret, "", header.Get(v.signatureHeader), body)
+}
+
+func (v *hmacVerifier) DeliveryID(header http.Header, body []byte) string {
+	if v.idHeader == "" {
+		return ""
+	}
+
+	return header.Get(v.idHeader)
+}
+
+func verifyPrefixedHMAC(h func() hash.Hash, secret []byte, prefix, signature string, body []byte) error {
+	if len(secret) == 0 {
+		return ErrMissingSecret
+	}
+
+	if !strings.HasPrefix(signature, prefix) {
+		return ErrInvalidSignature
+	}
+
+	expected := computeHMAC(h, secret, nil, body)
+	if !hmac.Equal([]byte(strings.TrimPrefix(signature, prefix)), []byte(expected)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func verifyTimestamp(timestamp string, tolerance time.Duration, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return cerrors.New(err, "invalid webhook timestamp", map[string]interface{}{
+			"timestamp": timestamp,
+		})
+	}
+
+	age := now.Sub(time.Unix(ts, 0))
+	if tolerance > 0 && (age > tolerance || age < -tolerance) {
+		return cerrors.New(nil, "webhook timestamp is outside the tolerance window", map[string]interface{}{
+			"timestamp": timestamp,
+		})
+	}
+
+	return nil
+}
+
+func missingSecretErr(provider string) error {
+	return cerrors.New(ErrMissingSecret, "failed to create webhook verifier", map[string]interface{}{
+		"provider": provider,
+	})
+}
+
+func computeHMAC(h func() hash.Hash, secret, prefix, body []byte) string {
+	mac := hmac.New(h, secret)
+	_, _ = mac.Write(prefix)
+	_, _ = mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func jsonStringField(body []byte, field string) string {
+	var data map[string]interface{}
+
+	if err := json.Unmarshal(body, &data); err != nil {
+		return ""
+	}
+
+	val, _ := data[field].(string)
+
+	return val
+}
@@ -0,0 +1,111 @@
+package cwebhook_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/cwebhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func sign(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(payload))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestStripeVerifier(t *testing.T) {
+	t.Parallel()
+
+	var (
+		body          = []byte(`{"id":"evt_123","type":"invoice.paid"}`)
+		ts            = strconv.FormatInt(time.Now().Unix(), 10)
+		verifier, err = cwebhook.NewStripeVerifier(cwebhook.Config{
+			Tolerance: time.Minute,
+			Stripe:    cwebhook.ConfigSecret{Secret: "whsec_test"},
+		})
+	)
+	assert.NoError(t, err)
+
+	header := http.Header{}
+	header.Set("Stripe-Signature", fmt.Sprintf("t=%s,v1=%s", ts, sign("whsec_test", ts+"."+string(body))))
+
+	assert.NoError(t, verifier.Verify(header, body))
+	assert.Equal(t, "evt_123", verifier.DeliveryID(header, body))
+
+	header.Set("Stripe-Signature", fmt.Sprintf("t=%s,v1=%s", ts, sign("wrong", ts+"."+string(body))))
+	assert.ErrorIs(t, verifier.Verify(header, body), cwebhook.ErrInvalidSignature)
+
+	oldTS := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	header.Set("Stripe-Signature", fmt.Sprintf("t=%s,v1=%s", oldTS, sign("whsec_test", oldTS+"."+string(body))))
+	assert.Error(t, verifier.Verify(header, body))
+}
+
+func TestGitHubVerifier(t *testing.T) {
+	t.Parallel()
+
+	var (
+		body          = []byte(`{"action":"opened"}`)
+		verifier, err = cwebhook.NewGitHubVerifier(cwebhook.Config{
+			GitHub: cwebhook.ConfigSecret{Secret: "gh-secret"},
+		})
+	)
+	assert.NoError(t, err)
+
+	header := http.Header{}
+	header.Set("X-Hub-Signature-256", "sha256="+sign("gh-secret", string(body)))
+	header.Set("X-GitHub-Delivery", "delivery-1")
+
+	assert.NoError(t, verifier.Verify(header, body))
+	assert.Equal(t, "delivery-1", verifier.DeliveryID(header, body))
+
+	header.Set("X-Hub-Signature-256", "sha256="+sign("wrong", string(body)))
+	assert.ErrorIs(t, verifier.Verify(header, body), cwebhook.ErrInvalidSignature)
+}
+
+func TestSlackVerifier(t *testing.T) {
+	t.Parallel()
+
+	var (
+		body          = []byte(`{"event_id":"Ev123"}`)
+		ts            = strconv.FormatInt(time.Now().Unix(), 10)
+		verifier, err = cwebhook.NewSlackVerifier(cwebhook.Config{
+			Tolerance: time.Minute,
+			Slack:     cwebhook.ConfigSecret{Secret: "slack-secret"},
+		})
+	)
+	assert.NoError(t, err)
+
+	header := http.Header{}
+	header.Set("X-Slack-Request-Timestamp", ts)
+	header.Set("X-Slack-Signature", "v0="+sign("slack-secret", "v0:"+ts+":"+string(body)))
+
+	assert.NoError(t, verifier.Verify(header, body))
+	assert.Equal(t, "Ev123", verifier.DeliveryID(header, body))
+
+	header.Set("X-Slack-Signature", "v0=invalid")
+	assert.ErrorIs(t, verifier.Verify(header, body), cwebhook.ErrInvalidSignature)
+}
+
+func TestVerifier_MissingSecret(t *testing.T) {
+	t.Parallel()
+
+	_, err := cwebhook.NewStripeVerifier(cwebhook.Config{Tolerance: time.Minute})
+	assert.ErrorIs(t, err, cwebhook.ErrMissingSecret)
+
+	_, err = cwebhook.NewGitHubVerifier(cwebhook.Config{})
+	assert.ErrorIs(t, err, cwebhook.ErrMissingSecret)
+
+	_, err = cwebhook.NewSlackVerifier(cwebhook.Config{})
+	assert.ErrorIs(t, err, cwebhook.ErrMissingSecret)
+
+	_, err = cwebhook.NewHMACVerifier("test", "", "X-Signature", "")
+	assert.ErrorIs(t, err, cwebhook.ErrMissingSecret)
+}
@@ -0,0 +1,16 @@
+package cwebhook
+
+import "github.com/google/wire"
+
+// WireModule can be used as part of google/wire setup.
+var WireModule = wire.NewSet( //nolint:gochecknoglobals
+	LoadConfig,
+	NewReceiver,
+	NewMemoryDeliveryStore,
+	wire.Struct(new(NewReceiverParams), "*"),
+
+	NewQueries,
+	NewDispatcher,
+	NewDeliveryWorker,
+	wire.Struct(new(NewDeliveryWorkerParams), "*"),
+)
@@ -0,0 +1,110 @@
+package copper
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gocopper/copper/clifecycle"
+)
+
+// StartupStep is a step of the app's startup (ex. running chttp.Server) along with how long it took
+type StartupStep struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+}
+
+// Diagnostics describes how the app's dependencies were wired and started. It helps troubleshoot slow startups and
+// dependencies that are not created or stopped as expected. The static dependency graph of an injector generated by
+// google/wire can be printed using `copper wiring`.
+type Diagnostics struct {
+	// Hooks are the lifecycle's stop funcs in the order their constructors registered them
+	Hooks []clifecycle.Hook
+
+	// Startup are the steps run by Start, Run, or ccli in order
+	Startup []StartupStep
+}
+
+// RunStep runs fn and records it as a step of the app's startup (see Diagnostics)
+func (a *App) RunStep(name string, fn func() error) error {
+	start := time.Now()
+
+	err := fn()
+
+	a.startupMu.Lock()
+	defer a.startupMu.Unlock()
+
+	a.startup = append(a.startup, StartupStep{
+		Name:     name,
+		Duration: time.Since(start),
+		Err:      err,
+	})
+
+	return err
+}
+
+// Diagnostics returns the app's diagnostics
+func (a *App) Diagnostics() Diagnostics {
+	a.startupMu.Lock()
+	defer a.startupMu.Unlock()
+
+	startup := make([]StartupStep, len(a.startup))
+	copy(startup, a.startup)
+
+	return Diagnostics{
+		Hooks:   a.Lifecycle.Hooks(),
+		Startup: startup,
+	}
+}
+
+// PrintDiagnostics writes the app's diagnostics to stderr if the app was started with the -debug-wiring flag
+func (a *App) PrintDiagnostics() {
+	if !a.DebugWiring {
+		return
+	}
+
+	err := a.Diagnostics().WriteText(os.Stderr)
+	if err != nil {
+		a.Logger.Error("Failed to print diagnostics", err)
+	}
+}
+
+// WriteText writes the diagnostics as tables
+func (d Diagnostics) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:gomnd
+
+	_, _ = fmt.Fprintln(tw, "LIFECYCLE HOOK\tREGISTERED AFTER\tSTOP DURATION\tSTOP ERROR")
+
+	for _, h := range d.Hooks {
+		stopDuration, stopErr := "-", "-"
+		if h.Stopped {
+			stopDuration = h.StopDuration.String()
+		}
+
+		if h.StopErr != nil {
+			stopErr = h.StopErr.Error()
+		}
+
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", h.Name, h.RegisteredAfter, stopDuration, stopErr)
+	}
+
+	_, _ = fmt.Fprintln(tw, "\nSTARTUP STEP\tDURATION\tERROR\t")
+
+	for _, s := range d.Startup {
+		err := "-"
+		if s.Err != nil {
+			err = s.Err.Error()
+		}
+
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t\n", s.Name, s.Duration, err)
+	}
+
+	return tw.Flush()
+}
+
+func runnerName(r Runner) string {
+	return fmt.Sprintf("%T", r)
+}
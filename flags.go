@@ -11,13 +11,24 @@ import (
 type Flags struct {
 	ConfigPath      cconfig.Path
 	ConfigOverrides cconfig.Overrides
+
+	// DebugWiring prints the app's diagnostics (see App.Diagnostics) once it has started
+	DebugWiring bool
 }
 
-// NewFlags reads the command line flags and returns Flags with the values set.
+// NewFlags reads the command line flags and returns Flags with the values set. If the APP_ENV environment variable
+// is set, the config path defaults to the ./config directory so that the environment's overlay is loaded (see
+// cconfig.New). Otherwise, it defaults to ./config/dev.toml.
 func NewFlags() *Flags {
+	defaultConfigPath := "./config/dev.toml"
+	if cconfig.AppEnv() != "" {
+		defaultConfigPath = "./config"
+	}
+
 	var (
-		configPath      = flag.String("config", "./config/dev.toml", "Path to config file")
+		configPath      = flag.String("config", defaultConfigPath, "Path to config file or config dir")
 		configOverrides = flag.String("set", "", "Config overrides ex. \"chttp.port=5902\". Separate multiple overrides with ;")
+		debugWiring     = flag.Bool("debug-wiring", false, "Print lifecycle hooks and startup timing once the app starts")
 	)
 
 	flag.Parse()
@@ -25,5 +36,6 @@ func NewFlags() *Flags {
 	return &Flags{
 		ConfigPath:      cconfig.Path(*configPath),
 		ConfigOverrides: cconfig.Overrides(*configOverrides),
+		DebugWiring:     *debugWiring,
 	}
 }
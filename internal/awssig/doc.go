@@ -0,0 +1,3 @@
+// Package awssig signs HTTP requests to AWS APIs using Signature Version 4 so that copper modules can call AWS
+// services (Secrets Manager, SES, S3, etc.) without depending on the AWS SDK.
+package awssig
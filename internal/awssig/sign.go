@@ -0,0 +1,212 @@
+package awssig
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	algorithm       = "AWS4-HMAC-SHA256"
+	timeFormat      = "20060102T150405Z"
+	dateFormat      = "20060102"
+	headerDate      = "X-Amz-Date"
+	headerToken     = "X-Amz-Security-Token"
+	headerPayload   = "X-Amz-Content-Sha256"
+	scopeTerminator = "aws4_request"
+)
+
+// Credentials are used to sign requests
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// RegionFromEnv returns the region set in AWS_REGION or AWS_DEFAULT_REGION
+func RegionFromEnv() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+// Sign adds the X-Amz-Date and Authorization headers (and X-Amz-Security-Token for temporary credentials) to the
+// request. body must be the request's payload. If the X-Amz-Content-Sha256 header is set (ex. UNSIGNED-PAYLOAD for
+// S3), its value is used as the payload hash instead.
+func Sign(req *http.Request, body []byte, creds Credentials, region, service string, now time.Time) {
+	now = now.UTC()
+
+	req.Header.Set(headerDate, now.Format(timeFormat))
+
+	if creds.SessionToken != "" {
+		req.Header.Set(headerToken, creds.SessionToken)
+	}
+
+	payloadHash := req.Header.Get(headerPayload)
+	if payloadHash == "" {
+		payloadHash = hashHex(body)
+	}
+
+	canonicalHeaders, signedHeaders := canonicalizeHeaders(req)
+
+	scope, signature := sign(req, canonicalHeaders, signedHeaders, payloadHash, creds, region, service, now)
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// Presign returns the URL of the request with its signature in the query string so that it can be used without
+// credentials until it expires (ex. S3 presigned URLs). The payload is not signed. Headers set on the request are
+// signed, so they must be sent with the same values when the URL is used.
+func Presign(req *http.Request, creds Credentials, region, service string, now time.Time,
+	expires time.Duration) string {
+	now = now.UTC()
+
+	canonicalHeaders, signedHeaders := canonicalizeHeaders(req)
+
+	query := req.URL.Query()
+	query.Set("X-Amz-Algorithm", algorithm)
+	query.Set("X-Amz-Credential", creds.AccessKeyID+"/"+credentialScope(now, region, service))
+	query.Set("X-Amz-Date", now.Format(timeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", signedHeaders)
+
+	if creds.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	req.URL.RawQuery = canonicalQuery(query)
+
+	_, signature := sign(req, canonicalHeaders, signedHeaders, "UNSIGNED-PAYLOAD", creds, region, service, now)
+
+	return req.URL.String() + "&X-Amz-Signature=" + signature
+}
+
+// sign returns the credential scope and the signature of the request
+func sign(req *http.Request, canonicalHeaders, signedHeaders, payloadHash string, creds Credentials, region,
+	service string, now time.Time) (string, string) {
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := credentialScope(now, region, service)
+
+	stringToSign := strings.Join([]string{
+		algorithm,
+		now.Format(timeFormat),
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), now.Format(dateFormat))
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, scopeTerminator)
+
+	return scope, hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func credentialScope(now time.Time, region, service string) string {
+	return strings.Join([]string{now.Format(dateFormat), region, service, scopeTerminator}, "/")
+}
+
+func canonicalURI(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+
+	return path
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+
+	for k := range query {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+
+	for _, k := range keys {
+		vals := query[k]
+		sort.Strings(vals)
+
+		for _, v := range vals {
+			pairs = append(pairs, escape(k)+"="+escape(v))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+func canonicalizeHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+
+	for k, vals := range req.Header {
+		k = strings.ToLower(k)
+		if k == "authorization" || k == "user-agent" {
+			continue
+		}
+
+		trimmed := make([]string, len(vals))
+		for i := range vals {
+			trimmed[i] = strings.Join(strings.Fields(vals[i]), " ")
+		}
+
+		headers[k] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+
+	var b strings.Builder
+
+	for _, k := range names {
+		b.WriteString(k + ":" + headers[k] + "\n")
+	}
+
+	return b.String(), strings.Join(names, ";")
+}
+
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hashHex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+
+	return h.Sum(nil)
+}
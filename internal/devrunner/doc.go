@@ -0,0 +1,5 @@
+// Package devrunner runs a copper app in development. It rebuilds and restarts the app each time its Go files or
+// templates change, and serves it through a proxy that holds requests while the app restarts. Build errors are shown
+// in the browser for HTML requests, and HTML pages reload once the app is rebuilt. It is used by the copper command
+// (see cmd/copper).
+package devrunner
@@ -0,0 +1,232 @@
+package devrunner
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventsPath is the path of the server-sent events stream that tells HTML pages to reload once the app is rebuilt
+const EventsPath = "/_copper/dev/events"
+
+const reloadScript = `<script>new EventSource("` + EventsPath + `").onmessage = function () { location.reload(); };</script>`
+
+var errorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<title>{{ .Title }}</title>
+	<style>
+		body { margin: 0; padding: 2rem; font-family: sans-serif; background: #fff5f5; color: #1a202c; }
+		h1 { font-size: 1.25rem; color: #c53030; }
+		pre { padding: 1rem; background: #fff; border: 1px solid #feb2b2; overflow: auto; font-size: 0.875rem; }
+	</style>
+</head>
+<body>
+	<h1>{{ .Title }}</h1>
+	<pre>{{ .Output }}</pre>
+	<p>The page reloads once the app is rebuilt.</p>
+</body>
+</html>
+`)) //nolint:gochecknoglobals
+
+type state int
+
+const (
+	stateBuilding state = iota
+	stateRunning
+	stateFailed
+)
+
+// proxy forwards requests to the app. While the app is being rebuilt, requests wait until it is running again
+// instead of failing with connection errors.
+type proxy struct {
+	rp          *httputil.ReverseProxy
+	waitTimeout time.Duration
+
+	mu      sync.Mutex
+	state   state
+	title   string
+	output  string
+	changed chan struct{}
+}
+
+func newProxy(target *url.URL, waitTimeout time.Duration) *proxy {
+	p := &proxy{
+		waitTimeout: waitTimeout,
+		changed:     make(chan struct{}),
+	}
+
+	p.rp = httputil.NewSingleHostReverseProxy(target)
+	p.rp.ModifyResponse = injectReloadScript
+	p.rp.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		p.writeError(w, r, "App did not respond", err.Error())
+	}
+
+	director := p.rp.Director
+	p.rp.Director = func(r *http.Request) {
+		director(r)
+
+		// the response is not compressed so that the reload script can be added to HTML pages
+		r.Header.Del("Accept-Encoding")
+	}
+
+	return p
+}
+
+func (p *proxy) setBuilding() {
+	p.setState(stateBuilding, "", "")
+}
+
+func (p *proxy) setRunning() {
+	p.setState(stateRunning, "", "")
+}
+
+// setFailed makes the proxy respond with the given error (ex. the build output) until the app is rebuilt
+func (p *proxy) setFailed(title, output string) {
+	p.setState(stateFailed, title, output)
+}
+
+func (p *proxy) setState(s state, title, output string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.state, p.title, p.output = s, title, output
+
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// current returns the current state and a channel that is closed when it changes
+func (p *proxy) current() (state, string, string, <-chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.state, p.title, p.output, p.changed
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == EventsPath {
+		p.serveEvents(w, r)
+		return
+	}
+
+	timeout := time.NewTimer(p.waitTimeout)
+	defer timeout.Stop()
+
+	for {
+		s, title, output, changed := p.current()
+
+		switch s {
+		case stateRunning:
+			p.rp.ServeHTTP(w, r)
+			return
+		case stateFailed:
+			p.writeError(w, r, title, output)
+			return
+		case stateBuilding:
+		}
+
+		select {
+		case <-changed:
+		case <-timeout.C:
+			p.writeError(w, r, "App is still building", "Timed out waiting for the app to start")
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// serveEvents streams an event each time the app is running again after a rebuild or fails to build
+func (p *proxy) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		_, _, _, changed := p.current()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+		}
+
+		if s, _, _, _ := p.current(); s == stateBuilding {
+			continue
+		}
+
+		_, err := io.WriteString(w, "data: reload\n\n")
+		if err != nil {
+			return
+		}
+
+		flusher.Flush()
+	}
+}
+
+// writeError responds with an error page for HTML requests (so that build errors show up in the browser) and with
+// plain text otherwise
+func (p *proxy) writeError(w http.ResponseWriter, r *http.Request, title, output string) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		http.Error(w, title+"\n\n"+output, http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusBadGateway)
+
+	var body bytes.Buffer
+
+	_ = errorPage.Execute(&body, map[string]string{
+		"Title":  title,
+		"Output": output,
+	})
+
+	_, _ = w.Write(addReloadScript(body.Bytes()))
+}
+
+// injectReloadScript adds the reload script to the app's HTML responses
+func injectReloadScript(resp *http.Response) error {
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") || resp.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if err != nil {
+		return err
+	}
+
+	body = addReloadScript(body)
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	return nil
+}
+
+func addReloadScript(body []byte) []byte {
+	i := bytes.LastIndex(body, []byte("</body>"))
+	if i == -1 {
+		return append(body, reloadScript...)
+	}
+
+	return append(body[:i:i], append([]byte(reloadScript), body[i:]...)...)
+}
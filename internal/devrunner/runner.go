@@ -0,0 +1,318 @@
+package devrunner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Options configures Runner
+type Options struct {
+	// Dir is the app's root dir that is watched for changes (default: .)
+	Dir string
+
+	// Package is the app's main package that is built (default: ./cmd/app)
+	Package string
+
+	// Args are passed to the app (ex. serve)
+	Args []string
+
+	// Addr is the address the proxy listens on (default: :3000)
+	Addr string
+
+	// AppAddr is the address of the app's HTTP server that requests are proxied to (default: localhost:7501)
+	AppAddr string
+
+	// Extensions of the files that trigger a rebuild when they change (default: .go, .html, .tmpl, .toml, .sql)
+	Extensions []string
+
+	// StartTimeout is how long the app may take to start listening on AppAddr, and how long requests wait for it
+	// (default: 30s)
+	StartTimeout time.Duration
+
+	// Out receives the app's output and the runner's logs (default: os.Stdout)
+	Out io.Writer
+}
+
+// Runner builds and runs the app, and rebuilds and restarts it each time its files change. See Options.
+type Runner struct {
+	opts  Options
+	proxy *proxy
+	bin   string
+	cmd   *exec.Cmd
+	done  chan struct{}
+}
+
+// New creates a Runner with the given options
+func New(opts Options) (*Runner, error) {
+	if opts.Dir == "" {
+		opts.Dir = "."
+	}
+
+	if opts.Package == "" {
+		opts.Package = "./cmd/app"
+	}
+
+	if opts.Addr == "" {
+		opts.Addr = ":3000"
+	}
+
+	if opts.AppAddr == "" {
+		opts.AppAddr = "localhost:7501"
+	}
+
+	if len(opts.Extensions) == 0 {
+		opts.Extensions = []string{".go", ".html", ".tmpl", ".toml", ".sql"}
+	}
+
+	if opts.StartTimeout == 0 {
+		opts.StartTimeout = 30 * time.Second
+	}
+
+	if opts.Out == nil {
+		opts.Out = os.Stdout
+	}
+
+	target, err := url.Parse("http://" + opts.AppAddr)
+	if err != nil {
+		return nil, cerrors.New(err, "invalid app address", map[string]interface{}{
+			"appAddr": opts.AppAddr,
+		})
+	}
+
+	return &Runner{
+		opts:  opts,
+		proxy: newProxy(target, opts.StartTimeout),
+	}, nil
+}
+
+// Run builds and runs the app until ctx is done
+func (r *Runner) Run(ctx context.Context) error {
+	binDir, err := os.MkdirTemp("", "copper-dev-")
+	if err != nil {
+		return cerrors.New(err, "failed to create dir for the app binary", nil)
+	}
+	defer os.RemoveAll(binDir)
+
+	r.bin = filepath.Join(binDir, "app")
+
+	w, err := newWatcher(r.opts.Dir, r.opts.Extensions)
+	if err != nil {
+		return err
+	}
+	defer w.close()
+
+	server := &http.Server{
+		Addr:              r.opts.Addr,
+		Handler:           r.proxy,
+		ReadHeaderTimeout: 10 * time.Second, //nolint:gomnd
+	}
+
+	serverErr := make(chan error, 1)
+
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- cerrors.New(err, "failed to start dev proxy", map[string]interface{}{
+				"addr": r.opts.Addr,
+			})
+		}
+	}()
+
+	r.logf("Proxying http://%s to the app at %s", displayAddr(r.opts.Addr), r.opts.AppAddr)
+
+	changes := w.changes(func(err error) {
+		r.logf("%v", err)
+	})
+
+	r.restart(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.stop()
+
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second) //nolint:gomnd
+			defer cancel()
+
+			_ = server.Shutdown(shutdownCtx)
+
+			return nil
+		case err := <-serverErr:
+			r.stop()
+			return err
+		case file, ok := <-changes:
+			if !ok {
+				r.stop()
+				return cerrors.New(nil, "file watcher stopped", nil)
+			}
+
+			r.logf("%s changed, rebuilding", file)
+			r.restart(ctx)
+		}
+	}
+}
+
+// restart stops the app, rebuilds it, and starts it again. Requests wait until the app is listening on AppAddr. If
+// the build fails or the app exits, the proxy responds with the error until the next change.
+func (r *Runner) restart(ctx context.Context) {
+	r.proxy.setBuilding()
+	r.stop()
+
+	start := time.Now()
+
+	output, err := r.build(ctx)
+	if err != nil {
+		r.logf("Build failed:\n%s", output)
+		r.proxy.setFailed("Build failed", output)
+
+		return
+	}
+
+	r.logf("Built in %s", time.Since(start).Round(time.Millisecond))
+
+	err = r.start()
+	if err != nil {
+		r.logf("%v", err)
+		r.proxy.setFailed("Failed to start the app", err.Error())
+
+		return
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, r.opts.StartTimeout)
+	defer cancel()
+
+	select {
+	case <-r.done:
+		r.logf("App exited before it started listening on %s", r.opts.AppAddr)
+		r.proxy.setFailed("App exited", "The app exited before it started listening on "+r.opts.AppAddr+
+			". See the terminal for its output.")
+	case ok := <-waitForListener(waitCtx, r.opts.AppAddr):
+		if !ok {
+			r.proxy.setFailed("App did not start", "The app did not start listening on "+r.opts.AppAddr)
+			return
+		}
+
+		r.proxy.setRunning()
+	}
+}
+
+func (r *Runner) build(ctx context.Context) (string, error) {
+	var output bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "go", "build", "-o", r.bin, r.opts.Package) //nolint:gosec
+	cmd.Dir = r.opts.Dir
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	if err != nil {
+		return output.String(), cerrors.New(err, "failed to build app", map[string]interface{}{
+			"package": r.opts.Package,
+		})
+	}
+
+	return output.String(), nil
+}
+
+func (r *Runner) start() error {
+	cmd := exec.Command(r.bin, r.opts.Args...) //nolint:gosec
+	cmd.Dir = r.opts.Dir
+	cmd.Stdout = r.opts.Out
+	cmd.Stderr = r.opts.Out
+
+	err := cmd.Start()
+	if err != nil {
+		return cerrors.New(err, "failed to start app", nil)
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		_ = cmd.Wait()
+		close(done)
+	}()
+
+	r.cmd, r.done = cmd, done
+
+	return nil
+}
+
+// stop interrupts the app so that it shuts down gracefully, and kills it if it does not exit in time
+func (r *Runner) stop() {
+	if r.cmd == nil {
+		return
+	}
+
+	defer func() { r.cmd, r.done = nil, nil }()
+
+	select {
+	case <-r.done:
+		return
+	default:
+	}
+
+	err := r.cmd.Process.Signal(os.Interrupt)
+	if err != nil {
+		_ = r.cmd.Process.Kill()
+	}
+
+	select {
+	case <-r.done:
+	case <-time.After(10 * time.Second): //nolint:gomnd
+		_ = r.cmd.Process.Kill()
+		<-r.done
+	}
+}
+
+func (r *Runner) logf(format string, args ...interface{}) {
+	fmt.Fprintf(r.opts.Out, "[copper dev] "+format+"\n", args...)
+}
+
+// waitForListener returns a channel that receives true once addr accepts connections, or false if ctx is done first
+func waitForListener(ctx context.Context, addr string) <-chan bool {
+	ch := make(chan bool, 1)
+
+	go func() {
+		var d net.Dialer
+
+		for {
+			conn, err := d.DialContext(ctx, "tcp", addr)
+			if err == nil {
+				_ = conn.Close()
+				ch <- true
+
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				ch <- false
+				return
+			case <-time.After(50 * time.Millisecond): //nolint:gomnd
+			}
+		}
+	}()
+
+	return ch
+}
+
+func displayAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+
+	return "localhost:" + port
+}
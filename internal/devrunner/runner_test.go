@@ -0,0 +1,124 @@
+package devrunner_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/internal/devrunner"
+	"github.com/stretchr/testify/assert"
+)
+
+const appMain = `package main
+
+import (
+	"net/http"
+	"os"
+)
+
+func main() {
+	_ = http.ListenAndServe(os.Args[1], http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><body>VERSION</body></html>"))
+	}))
+}
+`
+
+func TestRunner_Run(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir     = t.TempDir()
+		addr    = freeAddr(t)
+		appAddr = freeAddr(t)
+	)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module devapp\n\ngo 1.18\n"), 0o600))
+	writeMain(t, dir, strings.Replace(appMain, "VERSION", "v1", 1))
+
+	runner, err := devrunner.New(devrunner.Options{
+		Dir:     dir,
+		Package: ".",
+		Args:    []string{appAddr},
+		Addr:    addr,
+		AppAddr: appAddr,
+		Out:     io.Discard,
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() { done <- runner.Run(ctx) }()
+
+	status, body := waitForBody(t, addr, "v1")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, devrunner.EventsPath)
+
+	writeMain(t, dir, "package main\n\nfunc main() {\n")
+
+	status, body = waitForBody(t, addr, "Build failed")
+	assert.Equal(t, http.StatusBadGateway, status)
+	assert.Contains(t, body, "main.go")
+
+	writeMain(t, dir, strings.Replace(appMain, "VERSION", "v2", 1))
+
+	status, _ = waitForBody(t, addr, "v2")
+	assert.Equal(t, http.StatusOK, status)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func writeMain(t *testing.T, dir, src string) {
+	t.Helper()
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0o600))
+}
+
+// waitForBody requests an HTML page from the proxy until the response contains want
+func waitForBody(t *testing.T, addr, want string) (int, string) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Minute)
+
+	for time.Now().Before(deadline) {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://"+addr+"/", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Accept", "text/html")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+
+			if strings.Contains(string(body), want) {
+				return resp.StatusCode, string(body)
+			}
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	t.Fatalf("proxy did not respond with %q", want)
+
+	return 0, ""
+}
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	defer l.Close()
+
+	return l.Addr().String()
+}
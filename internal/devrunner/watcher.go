@@ -0,0 +1,135 @@
+package devrunner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gocopper/copper/cerrors"
+)
+
+const fileChangeDebounce = 100 * time.Millisecond
+
+// skippedDirs are not watched since they do not contain the app's source or change too often
+var skippedDirs = map[string]bool{ //nolint:gochecknoglobals
+	"node_modules": true,
+	"vendor":       true,
+	"tmp":          true,
+}
+
+type watcher struct {
+	fsw        *fsnotify.Watcher
+	extensions map[string]bool
+}
+
+// newWatcher watches the files in dir and its sub dirs that have one of the given extensions
+func newWatcher(dir string, extensions []string) (*watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, cerrors.New(err, "failed to create file watcher", nil)
+	}
+
+	w := &watcher{
+		fsw:        fsw,
+		extensions: make(map[string]bool, len(extensions)),
+	}
+
+	for _, ext := range extensions {
+		w.extensions[ext] = true
+	}
+
+	err = w.addDir(dir)
+	if err != nil {
+		_ = fsw.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// addDir watches dir and its sub dirs. fsnotify is not recursive so each dir is watched on its own.
+func (w *watcher) addDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return cerrors.New(err, "failed to walk dir", map[string]interface{}{
+				"path": path,
+			})
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		if path != dir && (strings.HasPrefix(d.Name(), ".") || skippedDirs[d.Name()]) {
+			return filepath.SkipDir
+		}
+
+		err = w.fsw.Add(path)
+		if err != nil {
+			return cerrors.New(err, "failed to watch dir", map[string]interface{}{
+				"path": path,
+			})
+		}
+
+		return nil
+	})
+}
+
+// changes returns a channel that receives the changed file each time the watched files change. Changes are debounced
+// so that saving multiple files (ex. a refactor or a git checkout) triggers a single rebuild.
+func (w *watcher) changes(onError func(err error)) <-chan string {
+	ch := make(chan string)
+
+	go func() {
+		var (
+			debounce <-chan time.Time
+			changed  string
+		)
+
+		for {
+			select {
+			case event, ok := <-w.fsw.Events:
+				if !ok {
+					close(ch)
+					return
+				}
+
+				if event.Op&fsnotify.Create != 0 {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						err = w.addDir(event.Name)
+						if err != nil {
+							onError(err)
+						}
+
+						continue
+					}
+				}
+
+				if !w.extensions[filepath.Ext(event.Name)] || event.Op == fsnotify.Chmod {
+					continue
+				}
+
+				changed = event.Name
+				debounce = time.After(fileChangeDebounce)
+			case err, ok := <-w.fsw.Errors:
+				if !ok {
+					close(ch)
+					return
+				}
+
+				onError(cerrors.New(err, "file watcher failed", nil))
+			case <-debounce:
+				debounce = nil
+				ch <- changed
+			}
+		}
+	}()
+
+	return ch
+}
+
+func (w *watcher) close() error {
+	return w.fsw.Close()
+}
@@ -0,0 +1,222 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+const (
+	defaultPoolSize    = 10
+	defaultDialTimeout = 5 * time.Second
+)
+
+// ErrNil is returned by the reply helpers when the reply is nil (ex. GET of a key that does not exist)
+var ErrNil = errors.New("redis: nil reply")
+
+// Error is an error reply from the server (ex. WRONGTYPE Operation against a key holding the wrong kind of value)
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// Options configures a Client
+type Options struct {
+	// Addr is the host:port of the server
+	Addr     string
+	Username string
+	Password string
+	DB       int
+	TLS      bool
+
+	// PoolSize is the max number of idle connections kept open (default: 10)
+	PoolSize int
+
+	DialTimeout time.Duration
+}
+
+// ParseURL parses a Redis URL (ex. redis://:password@localhost:6379/0 or rediss:// for TLS) into Options
+func ParseURL(rawURL string) (Options, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Options{}, cerrors.New(err, "invalid redis url", nil)
+	}
+
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return Options{}, cerrors.New(nil, "redis url must use the redis or rediss scheme", map[string]interface{}{
+			"scheme": u.Scheme,
+		})
+	}
+
+	opts := Options{
+		Addr: u.Host,
+		TLS:  u.Scheme == "rediss",
+	}
+
+	if u.Port() == "" {
+		opts.Addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+
+	if u.User != nil {
+		opts.Username = u.User.Username()
+		opts.Password, _ = u.User.Password()
+	}
+
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		opts.DB, err = strconv.Atoi(db)
+		if err != nil {
+			return Options{}, cerrors.New(err, "invalid redis db in url", nil)
+		}
+	}
+
+	return opts, nil
+}
+
+// New creates a Client. Connections are opened when they are first needed.
+func New(opts Options) *Client {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = defaultPoolSize
+	}
+
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = defaultDialTimeout
+	}
+
+	return &Client{
+		opts: opts,
+		idle: make(chan *Conn, opts.PoolSize),
+	}
+}
+
+// Client runs commands on a Redis server. It is safe for concurrent use.
+type Client struct {
+	opts Options
+	idle chan *Conn
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// Do runs the command and returns its reply. Replies are string for simple strings, int64 for integers, []byte for
+// bulk strings, []interface{} for arrays, and nil for nil replies. Error replies are returned as an Error.
+func (c *Client) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	conn, err := c.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.Do(ctx, args...)
+
+	c.Release(conn)
+
+	return reply, err
+}
+
+// Conn returns a connection from the pool or opens a new one. It must be returned to the pool using Release.
+// Connections are useful to run commands that hold the connection, such as SUBSCRIBE.
+func (c *Client) Conn(ctx context.Context) (*Conn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+
+	return c.dial(ctx)
+}
+
+// Release returns the connection to the pool. Broken connections and connections beyond the pool size are closed.
+func (c *Client) Release(conn *Conn) {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+
+	if conn.broken || closed {
+		_ = conn.Close()
+		return
+	}
+
+	select {
+	case c.idle <- conn:
+	default:
+		_ = conn.Close()
+	}
+}
+
+// Close closes the idle connections. Connections in use are closed when they are released.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	for {
+		select {
+		case conn := <-c.idle:
+			_ = conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *Client) dial(ctx context.Context) (*Conn, error) {
+	var (
+		dialer = net.Dialer{Timeout: c.opts.DialTimeout}
+		nc     net.Conn
+		err    error
+	)
+
+	if c.opts.TLS {
+		host, _, _ := net.SplitHostPort(c.opts.Addr)
+		tlsDialer := tls.Dialer{
+			NetDialer: &dialer,
+			Config:    &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12},
+		}
+
+		nc, err = tlsDialer.DialContext(ctx, "tcp", c.opts.Addr)
+	} else {
+		nc, err = dialer.DialContext(ctx, "tcp", c.opts.Addr)
+	}
+
+	if err != nil {
+		return nil, cerrors.New(err, "failed to connect to redis", map[string]interface{}{
+			"addr": c.opts.Addr,
+		})
+	}
+
+	conn := &Conn{nc: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+
+	if c.opts.Password != "" {
+		args := []interface{}{"AUTH", c.opts.Password}
+		if c.opts.Username != "" {
+			args = []interface{}{"AUTH", c.opts.Username, c.opts.Password}
+		}
+
+		_, err = conn.Do(ctx, args...)
+		if err != nil {
+			_ = conn.Close()
+			return nil, cerrors.New(err, "failed to authenticate with redis", nil)
+		}
+	}
+
+	if c.opts.DB != 0 {
+		_, err = conn.Do(ctx, "SELECT", c.opts.DB)
+		if err != nil {
+			_ = conn.Close()
+			return nil, cerrors.New(err, "failed to select redis db", map[string]interface{}{
+				"db": c.opts.DB,
+			})
+		}
+	}
+
+	return conn, nil
+}
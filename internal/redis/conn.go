@@ -0,0 +1,197 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Conn is a single connection to the server. It is not safe for concurrent use.
+type Conn struct {
+	nc     net.Conn
+	r      *bufio.Reader
+	w      *bufio.Writer
+	broken bool
+}
+
+// Do sends the command and reads its reply. See Client.Do.
+func (c *Conn) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	err := c.Send(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Receive(ctx)
+}
+
+// Send writes the command without reading its reply
+func (c *Conn) Send(ctx context.Context, args ...interface{}) error {
+	c.setDeadline(ctx)
+
+	err := writeCommand(c.w, args)
+	if err == nil {
+		err = c.w.Flush()
+	}
+
+	if err != nil {
+		c.broken = true
+		return cerrors.New(err, "failed to send redis command", nil)
+	}
+
+	return nil
+}
+
+// Receive reads the next reply from the server. It blocks until a reply is received or the context is done.
+func (c *Conn) Receive(ctx context.Context) (interface{}, error) {
+	c.setDeadline(ctx)
+
+	if done := ctx.Done(); done != nil {
+		// unblock the read when the context is canceled
+		stop := make(chan struct{})
+		defer close(stop)
+
+		go func() {
+			select {
+			case <-done:
+				_ = c.nc.SetReadDeadline(time.Now())
+			case <-stop:
+			}
+		}()
+	}
+
+	reply, err := readReply(c.r)
+	if err != nil {
+		if _, ok := err.(Error); !ok { //nolint:errorlint
+			c.broken = true
+			return nil, cerrors.New(err, "failed to read redis reply", nil)
+		}
+	}
+
+	return reply, err
+}
+
+// Close closes the connection
+func (c *Conn) Close() error {
+	return c.nc.Close()
+}
+
+func (c *Conn) setDeadline(ctx context.Context) {
+	deadline, _ := ctx.Deadline()
+	_ = c.nc.SetDeadline(deadline)
+}
+
+func writeCommand(w *bufio.Writer, args []interface{}) error {
+	_, err := fmt.Fprintf(w, "*%d\r\n", len(args))
+	if err != nil {
+		return err
+	}
+
+	for _, arg := range args {
+		var b []byte
+
+		switch v := arg.(type) {
+		case string:
+			b = []byte(v)
+		case []byte:
+			b = v
+		case int:
+			b = strconv.AppendInt(nil, int64(v), 10)
+		case int64:
+			b = strconv.AppendInt(nil, v, 10)
+		case float64:
+			b = strconv.AppendFloat(nil, v, 'f', -1, 64)
+		case time.Duration:
+			b = strconv.AppendInt(nil, v.Milliseconds(), 10)
+		default:
+			b = []byte(fmt.Sprint(v))
+		}
+
+		_, err = fmt.Fprintf(w, "$%d\r\n", len(b))
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(append(b, '\r', '\n'))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 {
+		return nil, fmt.Errorf("empty redis reply line") //nolint:goerr113
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+
+		buf := make([]byte, n+2) //nolint:gomnd
+
+		_, err = io.ReadFull(r, buf)
+		if err != nil {
+			return nil, err
+		}
+
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+
+		arr := make([]interface{}, n)
+
+		for i := range arr {
+			arr[i], err = readReply(r)
+			if err != nil {
+				if _, ok := err.(Error); !ok { //nolint:errorlint
+					return nil, err
+				}
+
+				// errors inside arrays (ex. EXEC results) are returned as values
+				arr[i] = err
+			}
+		}
+
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("unknown redis reply type %q", line[0]) //nolint:goerr113
+	}
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	if len(line) < 2 || line[len(line)-2] != '\r' { //nolint:gomnd
+		return "", fmt.Errorf("invalid redis reply line") //nolint:goerr113
+	}
+
+	return line[:len(line)-2], nil
+}
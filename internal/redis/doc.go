@@ -0,0 +1,6 @@
+// Package redis is a minimal Redis client (RESP2) with connection pooling so that copper modules can use Redis as a
+// backend (queues, caches, pub/sub) without depending on a Redis SDK. A Redis SDK would be a dependency of every app
+// that uses the core module, so it is only worth it if a module needs more than plain commands (ex. cluster or
+// sentinel support), in which case that module should move to its own go.mod like cgrpc. Set REDIS_TEST_URL to run
+// the tests against a real server.
+package redis
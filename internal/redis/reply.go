@@ -0,0 +1,96 @@
+package redis
+
+import (
+	"strconv"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// String converts a reply to a string. It returns ErrNil for nil replies.
+func String(reply interface{}, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+
+	switch v := reply.(type) {
+	case nil:
+		return "", ErrNil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	default:
+		return "", cerrors.New(nil, "unexpected redis reply type for string", nil)
+	}
+}
+
+// Bytes converts a reply to a byte slice. It returns ErrNil for nil replies.
+func Bytes(reply interface{}, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := reply.(type) {
+	case nil:
+		return nil, ErrNil
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return nil, cerrors.New(nil, "unexpected redis reply type for bytes", nil)
+	}
+}
+
+// Int64 converts a reply to an int64. It returns ErrNil for nil replies.
+func Int64(reply interface{}, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+
+	switch v := reply.(type) {
+	case nil:
+		return 0, ErrNil
+	case int64:
+		return v, nil
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, cerrors.New(nil, "unexpected redis reply type for int", nil)
+	}
+}
+
+// Strings converts an array reply to a slice of strings. Nil elements are returned as empty strings.
+func Strings(reply interface{}, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if reply == nil {
+		return nil, ErrNil
+	}
+
+	arr, ok := reply.([]interface{})
+	if !ok {
+		return nil, cerrors.New(nil, "unexpected redis reply type for strings", nil)
+	}
+
+	strs := make([]string, len(arr))
+
+	for i := range arr {
+		if arr[i] == nil {
+			continue
+		}
+
+		strs[i], err = String(arr[i], nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return strs, nil
+}
@@ -0,0 +1,3 @@
+// Package scaffold generates new copper apps and the boilerplate of their modules from templates. It is used by the
+// copper command (see cmd/copper).
+package scaffold
@@ -0,0 +1,353 @@
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"go/format"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Markers in the generated pkg/app/app.go where the generated modules are added
+const (
+	markerWire         = "// copper:gen:wire"
+	markerRouterParams = "// copper:gen:router-params"
+	markerRouters      = "// copper:gen:routers"
+)
+
+const migrationVersionFormat = "20060102150405"
+
+//go:embed templates
+var templates embed.FS
+
+var (
+	nameRegexp     = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)       //nolint:gochecknoglobals
+	modulePathLine = regexp.MustCompile(`(?m)^module\s+(\S+)\s*$`) //nolint:gochecknoglobals
+)
+
+// Generator writes generated files into Dir. Existing files are never overwritten.
+type Generator struct {
+	Dir string
+
+	// Now is used to version generated migrations (default: time.Now)
+	Now func() time.Time
+}
+
+// NewApp scaffolds a new app with the given Go module path in Dir, which must be empty or not exist. The app has a
+// config, a main package, and a google/wire setup that serves HTTP and runs the ccli commands. It returns the paths
+// of the created files.
+func (g *Generator) NewApp(module string) ([]string, error) {
+	entries, err := os.ReadDir(g.Dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, cerrors.New(err, "failed to read app dir", map[string]interface{}{
+			"dir": g.Dir,
+		})
+	}
+
+	if len(entries) > 0 {
+		return nil, cerrors.New(nil, "app dir is not empty", map[string]interface{}{
+			"dir": g.Dir,
+		})
+	}
+
+	data := map[string]string{
+		"Module": module,
+		"Name":   path.Base(module),
+	}
+
+	return g.render(data, map[string]string{
+		"app/go.mod.tmpl":    "go.mod",
+		"app/gitignore.tmpl": ".gitignore",
+		"app/dev.toml.tmpl":  "config/dev.toml",
+		"app/main.go.tmpl":   "cmd/app/main.go",
+		"app/wire.go.tmpl":   "pkg/app/wire.go",
+		"app/app.go.tmpl":    "pkg/app/app.go",
+	})
+}
+
+// Module generates a module with a model, repo, svc, router, migration, tests, and wire setup in pkg/<name>s of the
+// app in Dir. The name is the singular name of the module's model in snake case (ex. blog_post). If the app was
+// created by NewApp, the module is added to its wire setup and routers. It returns the paths of the created files.
+func (g *Generator) Module(name string) ([]string, error) {
+	module, err := g.modulePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := moduleData(module, name)
+	if err != nil {
+		return nil, err
+	}
+
+	data["Version"] = g.now().UTC().Format(migrationVersionFormat)
+
+	dir := path.Join("pkg", data["Package"])
+
+	files, err := g.render(data, map[string]string{
+		"module/models.go.tmpl":            path.Join(dir, "models.go"),
+		"module/repo.go.tmpl":              path.Join(dir, "repo.go"),
+		"module/svc.go.tmpl":               path.Join(dir, "svc.go"),
+		"module/router.go.tmpl":            path.Join(dir, "router.go"),
+		"module/wire.go.tmpl":              path.Join(dir, "wire.go"),
+		"module/migrations.go.tmpl":        path.Join(dir, "migrations.go"),
+		"module/svc_test.go.tmpl":          path.Join(dir, "svc_test.go"),
+		"module/router_test.go.tmpl":       path.Join(dir, "router_test.go"),
+		"module/create_migration.sql.tmpl": path.Join(dir, "migrations", data["Version"]+"_create_"+data["Table"]+".sql"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := g.addToApp(data)
+	if err != nil {
+		return files, err
+	}
+
+	if updated {
+		files = append(files, path.Join("pkg", "app", "app.go"))
+	}
+
+	return files, nil
+}
+
+// Migration creates an empty SQL migration in the migrations dir of the module in pkg/<pkg>. It returns the path of
+// the created file.
+func (g *Generator) Migration(pkg, name string) (string, error) {
+	if !nameRegexp.MatchString(pkg) || !nameRegexp.MatchString(name) {
+		return "", cerrors.New(nil, "module and migration names must be in snake case", map[string]interface{}{
+			"module": pkg,
+			"name":   name,
+		})
+	}
+
+	dir := path.Join("pkg", pkg, "migrations")
+
+	_, err := os.Stat(filepath.Join(g.Dir, filepath.FromSlash(dir)))
+	if err != nil {
+		return "", cerrors.New(err, "module has no migrations dir", map[string]interface{}{
+			"module": pkg,
+		})
+	}
+
+	file := path.Join(dir, g.now().UTC().Format(migrationVersionFormat)+"_"+name+".sql")
+
+	_, err = g.render(nil, map[string]string{"module/migration.sql.tmpl": file})
+	if err != nil {
+		return "", err
+	}
+
+	return file, nil
+}
+
+// addToApp adds the module to the markers in pkg/app/app.go. It returns false if the app has no such file.
+func (g *Generator) addToApp(data map[string]string) (bool, error) {
+	appFile := filepath.Join(g.Dir, "pkg", "app", "app.go")
+
+	src, err := os.ReadFile(appFile)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, cerrors.New(err, "failed to read app file", nil)
+	}
+
+	var (
+		code  = string(src)
+		field = data["Model"] + "Router"
+	)
+
+	for marker, line := range map[string]string{
+		markerWire:         data["Package"] + ".WireModule,",
+		markerRouterParams: field + " *" + data["Package"] + ".Router",
+		markerRouters:      "p." + field + ",",
+	} {
+		if !strings.Contains(code, marker) {
+			return false, cerrors.New(nil, "app file is missing a copper:gen marker", map[string]interface{}{
+				"marker": marker,
+			})
+		}
+
+		code = strings.Replace(code, marker, line+"\n"+marker, 1)
+	}
+
+	code = addImport(code, data["Module"]+"/pkg/", data["Package"])
+
+	formatted, err := format.Source([]byte(code))
+	if err != nil {
+		return false, cerrors.New(err, "failed to format app file", nil)
+	}
+
+	err = os.WriteFile(appFile, formatted, 0644) //nolint:gosec,gomnd
+	if err != nil {
+		return false, cerrors.New(err, "failed to write app file", nil)
+	}
+
+	return true, nil
+}
+
+// addImport adds the import of the app's package to the group of the app's other packages, or a new group at the end
+// of the imports if there is none
+func addImport(code, pkgPrefix, pkg string) string {
+	spec := "\t\"" + pkgPrefix + pkg + "\"\n"
+
+	if i := strings.Index(code, "\t\""+pkgPrefix); i != -1 {
+		return code[:i] + spec + code[i:]
+	}
+
+	start := strings.Index(code, "import (")
+	end := strings.Index(code[start:], "\n)") + start
+
+	return code[:end] + "\n\n" + strings.TrimSuffix(spec, "\n") + code[end:]
+}
+
+// render executes the templates and writes them to their files. Go files are formatted.
+func (g *Generator) render(data interface{}, files map[string]string) ([]string, error) {
+	created := make([]string, 0, len(files))
+
+	for tmplName, file := range files {
+		dest := filepath.Join(g.Dir, filepath.FromSlash(file))
+
+		if _, err := os.Stat(dest); err == nil {
+			return created, cerrors.New(nil, "file already exists", map[string]interface{}{
+				"file": file,
+			})
+		}
+
+		tmpl, err := template.ParseFS(templates, path.Join("templates", tmplName))
+		if err != nil {
+			return created, cerrors.New(err, "failed to parse template", map[string]interface{}{
+				"template": tmplName,
+			})
+		}
+
+		var buf bytes.Buffer
+
+		err = tmpl.Execute(&buf, data)
+		if err != nil {
+			return created, cerrors.New(err, "failed to execute template", map[string]interface{}{
+				"template": tmplName,
+			})
+		}
+
+		out := buf.Bytes()
+
+		if strings.HasSuffix(file, ".go") {
+			out, err = format.Source(out)
+			if err != nil {
+				return created, cerrors.New(err, "failed to format generated file", map[string]interface{}{
+					"file": file,
+				})
+			}
+		}
+
+		err = os.MkdirAll(filepath.Dir(dest), 0755) //nolint:gomnd
+		if err != nil {
+			return created, cerrors.New(err, "failed to create dir", map[string]interface{}{
+				"dir": filepath.Dir(dest),
+			})
+		}
+
+		err = os.WriteFile(dest, out, 0644) //nolint:gosec,gomnd
+		if err != nil {
+			return created, cerrors.New(err, "failed to write file", map[string]interface{}{
+				"file": file,
+			})
+		}
+
+		created = append(created, file)
+	}
+
+	sort.Strings(created)
+
+	return created, nil
+}
+
+// modulePath reads the app's Go module path from its go.mod
+func (g *Generator) modulePath() (string, error) {
+	src, err := os.ReadFile(filepath.Join(g.Dir, "go.mod"))
+	if err != nil {
+		return "", cerrors.New(err, "failed to read go.mod; run the command in the app's root dir", nil)
+	}
+
+	m := modulePathLine.FindSubmatch(src)
+	if m == nil {
+		return "", cerrors.New(nil, "go.mod has no module path", nil)
+	}
+
+	return string(m[1]), nil
+}
+
+func (g *Generator) now() time.Time {
+	if g.Now == nil {
+		return time.Now()
+	}
+
+	return g.Now()
+}
+
+// moduleData returns the names used by the module templates for the model name in snake case (ex. blog_post):
+//
+//	Package  blogposts   Go package and dir in pkg
+//	Model    BlogPost    model type
+//	Models   BlogPosts
+//	Var      blogPost    variable of a single model
+//	Vars     blogPosts
+//	Table    blog_posts
+//	Path     /api/blog_posts
+func moduleData(module, name string) (map[string]string, error) {
+	if !nameRegexp.MatchString(name) {
+		return nil, cerrors.New(nil, "module name must be in snake case (ex. blog_post)", map[string]interface{}{
+			"name": name,
+		})
+	}
+
+	var (
+		table  = plural(name)
+		model  = camel(name, true)
+		models = camel(table, true)
+	)
+
+	return map[string]string{
+		"Module":  module,
+		"Package": strings.ReplaceAll(table, "_", ""),
+		"Model":   model,
+		"Models":  models,
+		"Var":     camel(name, false),
+		"Vars":    camel(table, false),
+		"Table":   table,
+		"Path":    "/api/" + table,
+	}, nil
+}
+
+func camel(name string, upper bool) string {
+	parts := strings.Split(name, "_")
+
+	for i := range parts {
+		if parts[i] == "" || (i == 0 && !upper) {
+			continue
+		}
+
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+
+	return strings.Join(parts, "")
+}
+
+func plural(name string) string {
+	switch {
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsAny(name[len(name)-2:len(name)-1], "aeiou"):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "z"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	default:
+		return name + "s"
+	}
+}
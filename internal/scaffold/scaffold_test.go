@@ -0,0 +1,127 @@
+package scaffold_test
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper/internal/scaffold"
+	"github.com/stretchr/testify/assert"
+)
+
+func newGenerator(t *testing.T) *scaffold.Generator {
+	t.Helper()
+
+	return &scaffold.Generator{
+		Dir: filepath.Join(t.TempDir(), "demo"),
+		Now: func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) },
+	}
+}
+
+func TestGenerator_NewApp(t *testing.T) {
+	t.Parallel()
+
+	g := newGenerator(t)
+
+	files, err := g.NewApp("example.com/demo")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		".gitignore",
+		"cmd/app/main.go",
+		"config/dev.toml",
+		"go.mod",
+		"pkg/app/app.go",
+		"pkg/app/wire.go",
+	}, files)
+
+	goMod, err := os.ReadFile(filepath.Join(g.Dir, "go.mod"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(goMod), "module example.com/demo")
+
+	assertParses(t, g.Dir)
+
+	_, err = g.NewApp("example.com/demo")
+	assert.Error(t, err)
+}
+
+func TestGenerator_Module(t *testing.T) {
+	t.Parallel()
+
+	g := newGenerator(t)
+
+	_, err := g.NewApp("example.com/demo")
+	assert.NoError(t, err)
+
+	files, err := g.Module("blog_post")
+	assert.NoError(t, err)
+	assert.Contains(t, files, "pkg/blogposts/router.go")
+	assert.Contains(t, files, "pkg/blogposts/migrations/20240102030405_create_blog_posts.sql")
+	assert.Contains(t, files, "pkg/app/app.go")
+
+	_, err = g.Module("category")
+	assert.NoError(t, err)
+
+	app, err := os.ReadFile(filepath.Join(g.Dir, "pkg", "app", "app.go"))
+	assert.NoError(t, err)
+
+	for _, line := range []string{
+		"\"example.com/demo/pkg/blogposts\"\n\t\"example.com/demo/pkg/categories\"",
+		"blogposts.WireModule,",
+		"CategoryRouter *categories.Router",
+		"p.BlogPostRouter,",
+	} {
+		assert.Contains(t, string(app), line)
+	}
+
+	router, err := os.ReadFile(filepath.Join(g.Dir, "pkg", "categories", "router.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(router), `Path:    "/api/categories/{id}"`)
+	assert.Contains(t, string(router), "func (ro *Router) HandleListCategories(")
+
+	assertParses(t, g.Dir)
+
+	_, err = g.Module("category")
+	assert.Error(t, err)
+
+	_, err = g.Module("BlogPost")
+	assert.Error(t, err)
+}
+
+func TestGenerator_Migration(t *testing.T) {
+	t.Parallel()
+
+	g := newGenerator(t)
+
+	_, err := g.NewApp("example.com/demo")
+	assert.NoError(t, err)
+
+	_, err = g.Migration("posts", "add_title")
+	assert.Error(t, err)
+
+	_, err = g.Module("post")
+	assert.NoError(t, err)
+
+	file, err := g.Migration("posts", "add_title")
+	assert.NoError(t, err)
+	assert.Equal(t, "pkg/posts/migrations/20240102030405_add_title.sql", file)
+}
+
+func assertParses(t *testing.T, dir string) {
+	t.Helper()
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !strings.HasSuffix(path, ".go") {
+			return err
+		}
+
+		_, err = parser.ParseFile(token.NewFileSet(), path, nil, parser.AllErrors)
+		assert.NoError(t, err, path)
+
+		return nil
+	})
+	assert.NoError(t, err)
+}
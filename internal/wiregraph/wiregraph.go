@@ -0,0 +1,230 @@
+// Package wiregraph reads the dependency graph of the injectors generated by google/wire (wire_gen.go) so that the
+// order in which an app's dependencies are created can be inspected without running the app.
+package wiregraph
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gocopper/copper/cerrors"
+)
+
+// Step is a dependency created by an injector
+type Step struct {
+	// Provider is the constructor that was called (ex. csql.NewMigrator) or the struct that was built using
+	// wire.Struct (ex. csql.NewMigratorParams{})
+	Provider string
+
+	// Package of the provider or "" if it is in the injector's package
+	Package string
+
+	// Inputs are the variables passed to the provider and Outputs are the variables it returned
+	Inputs  []string
+	Outputs []string
+
+	// Field is set if the step reads a field of another dependency (see wire.FieldsOf)
+	Field bool
+}
+
+// Injector is an injector func and the steps it runs in order
+type Injector struct {
+	Name   string
+	Params []string
+	Steps  []Step
+}
+
+// Parse returns the injectors in the source of a wire_gen.go file
+func Parse(src []byte) ([]Injector, error) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "wire_gen.go", src, 0)
+	if err != nil {
+		return nil, cerrors.New(err, "failed to parse wire_gen.go", nil)
+	}
+
+	var injectors []Injector
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil || fn.Recv != nil {
+			continue
+		}
+
+		var (
+			injector = Injector{Name: fn.Name.Name}
+			vars     = make(map[string]bool)
+		)
+
+		for _, field := range fn.Type.Params.List {
+			for _, name := range field.Names {
+				injector.Params = append(injector.Params, name.Name)
+				vars[name.Name] = true
+			}
+		}
+
+		for _, stmt := range fn.Body.List {
+			assign, ok := stmt.(*ast.AssignStmt)
+			if !ok || len(assign.Rhs) != 1 {
+				continue
+			}
+
+			step, ok := parseStep(fset, assign.Rhs[0], vars)
+			if !ok {
+				continue
+			}
+
+			for _, lhs := range assign.Lhs {
+				step.Outputs = append(step.Outputs, exprString(fset, lhs))
+
+				if ident, ok := lhs.(*ast.Ident); ok {
+					vars[ident.Name] = true
+				}
+			}
+
+			injector.Steps = append(injector.Steps, step)
+		}
+
+		if len(injector.Steps) > 0 {
+			injectors = append(injectors, injector)
+		}
+	}
+
+	return injectors, nil
+}
+
+// parseStep returns the step of a provider call (ex. csql.NewMigrator(params)), a struct built using wire.Struct
+// (ex. csql.NewMigratorParams{DB: db}), or a field of another dependency provided using wire.FieldsOf
+// (ex. flags.ConfigPath). vars are the injector's variables defined so far.
+func parseStep(fset *token.FileSet, expr ast.Expr, vars map[string]bool) (Step, bool) {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+
+	switch e := expr.(type) {
+	case *ast.SelectorExpr:
+		x, ok := e.X.(*ast.Ident)
+		if !ok || !vars[x.Name] {
+			return Step{}, false
+		}
+
+		return Step{Provider: exprString(fset, e), Inputs: []string{x.Name}, Field: true}, true
+	case *ast.CallExpr:
+		step := newStep(fset, e.Fun)
+		for _, arg := range e.Args {
+			step.Inputs = append(step.Inputs, exprString(fset, arg))
+		}
+
+		return step, true
+	case *ast.CompositeLit:
+		if e.Type == nil {
+			return Step{}, false
+		}
+
+		step := newStep(fset, e.Type)
+		step.Provider += "{}"
+
+		for _, elt := range e.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				elt = kv.Value
+			}
+
+			step.Inputs = append(step.Inputs, exprString(fset, elt))
+		}
+
+		return step, true
+	default:
+		return Step{}, false
+	}
+}
+
+func newStep(fset *token.FileSet, fn ast.Expr) Step {
+	step := Step{Provider: exprString(fset, fn)}
+
+	// generic constructors are called with their type params (ex. csql.NewRepo[User])
+	if index, ok := fn.(*ast.IndexExpr); ok {
+		fn = index.X
+	}
+
+	if sel, ok := fn.(*ast.SelectorExpr); ok {
+		if pkg, ok := sel.X.(*ast.Ident); ok {
+			step.Package = pkg.Name
+		}
+	}
+
+	return step
+}
+
+// WriteText writes the injectors' steps in order followed by the number of providers used from each package
+func WriteText(w io.Writer, injectors []Injector) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:gomnd
+
+	for i, injector := range injectors {
+		if i > 0 {
+			_, _ = fmt.Fprintln(tw)
+		}
+
+		_, _ = fmt.Fprintf(tw, "%s(%s)\n", injector.Name, strings.Join(injector.Params, ", "))
+
+		for j, step := range injector.Steps {
+			call := step.Provider
+			inputs := strings.Join(step.Inputs, ", ")
+
+			switch {
+			case step.Field:
+			case strings.HasSuffix(call, "{}"):
+				call = strings.TrimSuffix(call, "{}") + "{" + inputs + "}"
+			default:
+				call += "(" + inputs + ")"
+			}
+
+			_, _ = fmt.Fprintf(tw, "  %d\t%s\t-> %s\n", j+1, call, strings.Join(step.Outputs, ", "))
+		}
+	}
+
+	counts := make(map[string]int)
+
+	for _, injector := range injectors {
+		for _, step := range injector.Steps {
+			if step.Field {
+				continue
+			}
+
+			pkg := step.Package
+			if pkg == "" {
+				pkg = "(local)"
+			}
+
+			counts[pkg]++
+		}
+	}
+
+	pkgs := make([]string, 0, len(counts))
+	for pkg := range counts {
+		pkgs = append(pkgs, pkg)
+	}
+
+	sort.Strings(pkgs)
+
+	_, _ = fmt.Fprintln(tw, "\nPACKAGE\tPROVIDERS")
+
+	for _, pkg := range pkgs {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\n", pkg, counts[pkg])
+	}
+
+	return tw.Flush()
+}
+
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	var b strings.Builder
+
+	_ = printer.Fprint(&b, fset, expr)
+
+	return b.String()
+}
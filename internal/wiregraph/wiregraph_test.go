@@ -0,0 +1,71 @@
+package wiregraph_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gocopper/copper/internal/wiregraph"
+	"github.com/stretchr/testify/assert"
+)
+
+const wireGen = `// Code generated by Wire. DO NOT EDIT.
+
+package app
+
+func InitServer(app *copper.App) (*chttp.Server, error) {
+	loader := app.Config
+	config, err := chttp.LoadConfig(loader)
+	if err != nil {
+		return nil, err
+	}
+	newRouterParams := &posts.NewRouterParams{
+		Config: config,
+	}
+	router := posts.NewRouter(newRouterParams)
+	server := NewServer(router)
+	return server, nil
+}
+
+var WireModule = wire.NewSet(posts.WireModule)
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	injectors, err := wiregraph.Parse([]byte(wireGen))
+	assert.NoError(t, err)
+	assert.Equal(t, []wiregraph.Injector{
+		{
+			Name:   "InitServer",
+			Params: []string{"app"},
+			Steps: []wiregraph.Step{
+				{Provider: "app.Config", Inputs: []string{"app"}, Outputs: []string{"loader"}, Field: true},
+				{
+					Provider: "chttp.LoadConfig",
+					Package:  "chttp",
+					Inputs:   []string{"loader"},
+					Outputs:  []string{"config", "err"},
+				},
+				{
+					Provider: "posts.NewRouterParams{}",
+					Package:  "posts",
+					Inputs:   []string{"config"},
+					Outputs:  []string{"newRouterParams"},
+				},
+				{
+					Provider: "posts.NewRouter",
+					Package:  "posts",
+					Inputs:   []string{"newRouterParams"},
+					Outputs:  []string{"router"},
+				},
+				{Provider: "NewServer", Inputs: []string{"router"}, Outputs: []string{"server"}},
+			},
+		},
+	}, injectors)
+
+	var out bytes.Buffer
+
+	assert.NoError(t, wiregraph.WriteText(&out, injectors))
+	assert.Contains(t, out.String(), "posts.NewRouterParams{config}")
+	assert.Contains(t, out.String(), "chttp.LoadConfig(loader)          -> config, err")
+}
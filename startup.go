@@ -0,0 +1,229 @@
+package copper
+
+import (
+	"context"
+	"time"
+
+	"github.com/gocopper/copper/cerrors"
+	"github.com/gocopper/copper/clifecycle"
+)
+
+const (
+	defaultStartupBackoff = time.Second
+	maxStartupBackoff     = 30 * time.Second
+)
+
+// StartRunners starts the runners in two phases. First, the runners are ordered by their stages (see
+// clifecycle.Staged) so that each stage starts once the stages it runs after have started. If the stages depend on
+// each other in a cycle, no runner is started. Then, each runner is started in order within its stage's timeout and
+// is retried with backoff if it fails. Each runner is recorded as a startup step (see Diagnostics).
+// StartRunners is used by Start and Run and can be used by apps that start their runners themselves (ex. see ccli).
+func (a *App) StartRunners(fns ...Runner) error {
+	runners, err := planStartup(fns)
+	if err != nil {
+		return err
+	}
+
+	for i := range runners {
+		r := runners[i]
+
+		err := a.RunStep(r.name, func() error {
+			return a.startRunner(r)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type startupRunner struct {
+	runner Runner
+	name   string
+	stage  clifecycle.Stage
+	staged bool
+}
+
+// planStartup orders the runners so that each one starts after the runners of the stages it runs after. Otherwise,
+// the runners keep the order they were given in.
+func planStartup(fns []Runner) ([]startupRunner, error) {
+	pending := make([]startupRunner, len(fns))
+
+	for i := range fns {
+		r := startupRunner{
+			runner: fns[i],
+			name:   runnerName(fns[i]),
+		}
+
+		if staged, ok := fns[i].(clifecycle.Staged); ok {
+			r.stage, r.staged = staged.Stage(), true
+		} else {
+			r.stage.Name = r.name
+		}
+
+		pending[i] = r
+	}
+
+	ordered := make([]startupRunner, 0, len(pending))
+
+	for len(pending) > 0 {
+		next := -1
+
+		for i := range pending {
+			if isStageReady(pending[i].stage, pending) {
+				next = i
+				break
+			}
+		}
+
+		if next == -1 {
+			stages := make([]string, len(pending))
+			for i := range pending {
+				stages[i] = pending[i].stage.Name
+			}
+
+			return nil, cerrors.New(nil, "startup stages depend on each other in a cycle", map[string]interface{}{
+				"stages": stages,
+			})
+		}
+
+		ordered = append(ordered, pending[next])
+		pending = append(pending[:next], pending[next+1:]...)
+	}
+
+	return ordered, nil
+}
+
+// isStageReady returns true if none of the pending runners are part of a stage that stage runs after
+func isStageReady(stage clifecycle.Stage, pending []startupRunner) bool {
+	for _, after := range stage.After {
+		if after == stage.Name {
+			continue
+		}
+
+		for i := range pending {
+			if pending[i].stage.Name == after {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (a *App) startRunner(r startupRunner) error {
+	var (
+		stage  = a.stageWithConfig(r)
+		logger = a.Logger.WithTags(map[string]interface{}{
+			"stage":  stage.Name,
+			"runner": r.name,
+		})
+
+		ctx     = context.Background()
+		start   = time.Now()
+		backoff = stage.Backoff
+
+		attempts int
+		err      error
+	)
+
+	if stage.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, stage.Timeout)
+		defer cancel()
+	}
+
+	for attempts < stage.Retries+1 {
+		if attempts > 0 {
+			logger.WithTags(map[string]interface{}{
+				"attempt": attempts,
+				"backoff": backoff.String(),
+			}).Warn("Failed to start runner; retrying..", err)
+
+			select {
+			case <-ctx.Done():
+				return startupErr(ctx.Err(), stage, r, attempts)
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+			if backoff > maxStartupBackoff {
+				backoff = maxStartupBackoff
+			}
+		}
+
+		attempts++
+
+		err = runWithContext(ctx, r.runner)
+		if err == nil {
+			logger.WithTags(map[string]interface{}{
+				"duration": time.Since(start).String(),
+			}).Info("Started runner")
+
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			// the runner may still be starting, so it is not retried
+			break
+		}
+	}
+
+	return startupErr(err, stage, r, attempts)
+}
+
+// stageWithConfig returns the runner's stage with the timeout and retries overridden by the app's config
+func (a *App) stageWithConfig(r startupRunner) clifecycle.Stage {
+	stage := r.stage
+
+	if stage.Timeout <= 0 && r.staged {
+		stage.Timeout = a.StartupConfig.Timeout
+	}
+
+	if override, ok := a.StartupConfig.Stages[stage.Name]; ok {
+		if override.Timeout > 0 {
+			stage.Timeout = override.Timeout
+		}
+
+		if override.Retries != nil {
+			stage.Retries = *override.Retries
+		}
+
+		if override.Backoff > 0 {
+			stage.Backoff = override.Backoff
+		}
+	}
+
+	if stage.Backoff <= 0 {
+		stage.Backoff = defaultStartupBackoff
+	}
+
+	return stage
+}
+
+// runWithContext runs r and waits for it to return until ctx is done
+func runWithContext(ctx context.Context, r Runner) error {
+	done := make(chan error, 1)
+
+	go func() {
+		done <- r.Run()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return cerrors.New(ctx.Err(), "runner did not start in time", nil)
+	}
+}
+
+func startupErr(err error, stage clifecycle.Stage, r startupRunner, attempts int) error {
+	return cerrors.New(err, "failed to start runner", map[string]interface{}{
+		"stage":    stage.Name,
+		"runner":   r.name,
+		"attempts": attempts,
+		"timeout":  stage.Timeout.String(),
+	})
+}
@@ -0,0 +1,137 @@
+package copper_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocopper/copper"
+	"github.com/gocopper/copper/clifecycle"
+	"github.com/gocopper/copper/clogger"
+	"github.com/stretchr/testify/assert"
+)
+
+type stagedRunner struct {
+	stage clifecycle.Stage
+	run   func() error
+}
+
+func (r *stagedRunner) Run() error { return r.run() }
+
+func (r *stagedRunner) Stage() clifecycle.Stage { return r.stage }
+
+func newTestApp(config copper.ConfigStartup) *copper.App {
+	return &copper.App{
+		Lifecycle:     clifecycle.New(),
+		Logger:        clogger.NewNoop(),
+		StartupConfig: config,
+	}
+}
+
+func TestApp_StartRunners_Order(t *testing.T) {
+	t.Parallel()
+
+	var started []string
+
+	runner := func(name string, after ...string) *stagedRunner {
+		return &stagedRunner{
+			stage: clifecycle.Stage{Name: name, After: after},
+			run: func() error {
+				started = append(started, name)
+				return nil
+			},
+		}
+	}
+
+	app := newTestApp(copper.ConfigStartup{})
+
+	err := app.StartRunners(
+		runner(clifecycle.StageWorkers, clifecycle.StageMigrations, clifecycle.StageHTTP),
+		runner(clifecycle.StageHTTP, clifecycle.StageMigrations, clifecycle.StageSeeds),
+		runner(clifecycle.StageMigrations),
+		runner(clifecycle.StageWorkers, clifecycle.StageHTTP),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		clifecycle.StageMigrations,
+		clifecycle.StageHTTP,
+		clifecycle.StageWorkers,
+		clifecycle.StageWorkers,
+	}, started)
+	assert.Len(t, app.Diagnostics().Startup, 4)
+}
+
+func TestApp_StartRunners_Cycle(t *testing.T) {
+	t.Parallel()
+
+	var started bool
+
+	run := func() error {
+		started = true
+		return nil
+	}
+
+	err := newTestApp(copper.ConfigStartup{}).StartRunners(
+		&stagedRunner{stage: clifecycle.Stage{Name: "a", After: []string{"b"}}, run: run},
+		&stagedRunner{stage: clifecycle.Stage{Name: "b", After: []string{"a"}}, run: run},
+	)
+	assert.Error(t, err)
+	assert.False(t, started)
+}
+
+func TestApp_StartRunners_Retries(t *testing.T) {
+	t.Parallel()
+
+	var attempts int
+
+	app := newTestApp(copper.ConfigStartup{})
+
+	err := app.StartRunners(&stagedRunner{
+		stage: clifecycle.Stage{Name: "db", Retries: 2, Backoff: time.Millisecond},
+		run: func() error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("test-err")
+			}
+
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	retries := 0
+	attempts = 0
+
+	app = newTestApp(copper.ConfigStartup{
+		Stages: map[string]copper.ConfigStartupStage{"db": {Retries: &retries}},
+	})
+
+	err = app.StartRunners(&stagedRunner{
+		stage: clifecycle.Stage{Name: "db", Retries: 2, Backoff: time.Millisecond},
+		run: func() error {
+			attempts++
+			return errors.New("test-err")
+		},
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestApp_StartRunners_Timeout(t *testing.T) {
+	t.Parallel()
+
+	block := make(chan struct{})
+	defer close(block)
+
+	app := newTestApp(copper.ConfigStartup{Timeout: 10 * time.Millisecond})
+
+	err := app.StartRunners(&stagedRunner{
+		stage: clifecycle.Stage{Name: clifecycle.StageHTTP, Retries: 1},
+		run: func() error {
+			<-block
+			return nil
+		},
+	})
+	assert.Error(t, err)
+}
@@ -16,9 +16,11 @@ func InitApp() (*App, error) {
 		wire.Build(
 			NewApp,
 			NewFlags,
+			LoadConfig,
 			clifecycle.New,
 			cconfig.NewWithKeyOverrides,
-			clogger.NewWithConfig,
+			clogger.NewWithSinks,
+			clogger.NewLevels,
 			clogger.LoadConfig,
 
 			wire.FieldsOf(new(*Flags), "ConfigPath", "ConfigOverrides"),
@@ -27,7 +29,7 @@ func InitApp() (*App, error) {
 }
 
 // WireModule can be used as part of google/wire setup to include the app's
-// lifecycle, config, and logger.
+// lifecycle, config, logger, and log levels.
 var WireModule = wire.NewSet(
-	wire.FieldsOf(new(*App), "Lifecycle", "Config", "Logger"),
+	wire.FieldsOf(new(*App), "Lifecycle", "Config", "Logger", "LogLevels"),
 )
@@ -25,20 +25,28 @@ func InitApp() (*App, error) {
 	if err != nil {
 		return nil, err
 	}
+	copperConfig, err := LoadConfig(loader)
+	if err != nil {
+		return nil, err
+	}
 	config, err := clogger.LoadConfig(loader)
 	if err != nil {
 		return nil, err
 	}
-	logger, err := clogger.NewWithConfig(config)
+	levels, err := clogger.NewLevels(config)
+	if err != nil {
+		return nil, err
+	}
+	logger, err := clogger.NewWithSinks(config, levels, lifecycle)
 	if err != nil {
 		return nil, err
 	}
-	app := NewApp(lifecycle, loader, logger)
+	app := NewApp(lifecycle, loader, copperConfig, logger, levels, flags)
 	return app, nil
 }
 
 // wire.go:
 
 // WireModule can be used as part of google/wire setup to include the app's
-// lifecycle, config, and logger.
-var WireModule = wire.NewSet(wire.FieldsOf(new(*App), "Lifecycle", "Config", "Logger"))
+// lifecycle, config, logger, and log levels.
+var WireModule = wire.NewSet(wire.FieldsOf(new(*App), "Lifecycle", "Config", "Logger", "LogLevels"))